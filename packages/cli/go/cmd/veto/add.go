@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/compile"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/impact"
+	"github.com/VulnZap/veto/internal/output"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// runAdd handles `veto add`, refusing phrases an existing policy already
+// covers unless --force is given. "all <category> builtins" adds a whole
+// category or tag at once. Before adding a single policy it estimates how
+// much in the project and the shell history the policy would flag, so a
+// noisy one can start in monitor mode (--monitor). With --json it doesn't
+// ask: duplicates are skipped and noisy policies enforced.
+func runAdd(args []string) {
+	force, monitor := false, false
+	var words []string
+	for _, a := range args {
+		switch a {
+		case "--force":
+			force = true
+			continue
+		case "--monitor":
+			monitor = true
+			continue
+		}
+		words = append(words, a)
+	}
+	if len(words) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: veto add [--force] [--monitor] \"policy\"")
+		os.Exit(1)
+	}
+	phrase := strings.Join(words, " ")
+
+	if label, ok := builtin.Selection(phrase); ok {
+		addSelection(label, force)
+		return
+	}
+
+	doc := output.Add{Added: []output.Added{}, Skipped: []output.Skipped{}}
+	if !force && config.Exists() {
+		path, _ := config.Find()
+		cfg, err := config.Load(path)
+		if err != nil {
+			fail(err)
+		}
+		if dup, reason := builtin.Duplicate(phrase, cfg.Policies); dup != "" {
+			if !resolveDuplicate(phrase, dup, reason) {
+				if jsonOutput {
+					doc.Skipped = append(doc.Skipped, output.Skipped{Policy: phrase, CoveredBy: dup, Reason: reason})
+					printJSON(doc)
+				}
+				return
+			}
+		}
+	}
+
+	// Builtins resolve without an LLM
+	found := builtin.Find(phrase) != nil
+	c := &compile.Compiler{}
+	if !found {
+		fmt.Println("Compiling...")
+		var err error
+		if c, err = newCompiler(); err != nil {
+			fail(err)
+		}
+	}
+	result, err := c.Compile(phrase)
+	if err != nil {
+		fail(err)
+	}
+
+	entry := config.PolicyEntry{Policy: phrase}
+	if monitor || !confirmImpact(result.Policy) {
+		entry.Enforce = policy.EnforceMonitor
+	}
+	if err := config.AddEntry(entry); err != nil {
+		fail(err)
+	}
+	how := "compiled by " + result.Source
+	switch result.Source {
+	case compile.SourceBuiltin:
+		how = "builtin"
+	case compile.SourceCache:
+		how = "compiled earlier"
+	}
+	if entry.Enforce == policy.EnforceMonitor {
+		how += ", monitor mode"
+	}
+	fmt.Printf("✓ Added: %s %s\n", phrase, dimStyle.Render("("+how+")"))
+	fireEvent(events.Event{Name: events.OnPolicyAdded, Policy: phrase})
+	if jsonOutput {
+		doc.Added = append(doc.Added, output.Added{Policy: phrase, Source: result.Source, Monitor: entry.Enforce == policy.EnforceMonitor})
+		printJSON(doc)
+	}
+
+	// Remember phrases the LLM compiled into a builtin, so they resolve
+	// offline next time
+	if name := builtin.Equivalent(result.Policy); name != "" && !found {
+		if err := builtin.Learn(phrase, name); err == nil {
+			fmt.Printf("● Learned: \"%s\" means the builtin \"%s\"\n", phrase, name)
+		}
+	}
+}
+
+// confirmImpact shows what p would flag in the project and the recent
+// shell history, and reports whether to enforce it right away. When it
+// would flag anything, the user is asked; without a terminal to ask on, or
+// with --json, it is enforced, with a hint about --monitor.
+func confirmImpact(p *policy.Policy) bool {
+	root := "."
+	if path, err := config.Find(); err == nil {
+		root = filepath.Dir(path)
+	}
+	e, err := impact.Of(root, p, impact.History())
+	if err != nil {
+		return true
+	}
+
+	if e.Files > 0 {
+		fmt.Printf("● Covers %d existing file(s)\n", e.Files)
+	}
+	if e.Occurrences() == 0 {
+		return true
+	}
+	var where []string
+	if e.Content > 0 {
+		where = append(where, fmt.Sprintf("%d in %d file(s)", e.Content, e.ContentFiles))
+	}
+	if e.Commands > 0 {
+		where = append(where, fmt.Sprintf("%d of your last %d shell commands", e.Commands, e.Checked))
+	}
+	fmt.Printf("● This will flag ~%d existing occurrences %s\n", e.Occurrences(), dimStyle.Render("("+strings.Join(where, ", ")+")"))
+	for _, ex := range e.Examples {
+		fmt.Printf("    %s\n", dimStyle.Render(ex))
+	}
+
+	if !interactive() || jsonOutput {
+		fmt.Println("  Use --monitor to log them without blocking first.")
+		return true
+	}
+	fmt.Print("  [e]nforce now or [m]onitor first? [e] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "m", "monitor":
+		return false
+	}
+	return true
+}
+
+// newCompiler returns a compiler using the LLM the project's .veto picks,
+// if there is one.
+func newCompiler() (*compile.Compiler, error) {
+	var settings *config.Compiler
+	if path, err := config.Find(); err == nil {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		settings = cfg.Compiler
+	}
+	return compile.New(settings)
+}
+
+// addSelection adds every builtin in a category or tag, skipping ones an
+// existing policy already covers unless force is set.
+func addSelection(label string, force bool) {
+	var existing []string
+	if config.Exists() {
+		path, _ := config.Find()
+		cfg, err := config.Load(path)
+		if err != nil {
+			fail(err)
+		}
+		existing = cfg.Policies
+	}
+
+	doc := output.Add{Added: []output.Added{}, Skipped: []output.Skipped{}}
+	for _, name := range builtin.Select(label) {
+		if dup, reason := builtin.Duplicate(name, existing); dup != "" && !force {
+			fmt.Printf("● Already covered: %s (by %s)\n", name, dup)
+			doc.Skipped = append(doc.Skipped, output.Skipped{Policy: name, CoveredBy: dup, Reason: reason})
+			continue
+		}
+		if err := config.AddPolicy(name); err != nil {
+			fail(err)
+		}
+		existing = append(existing, name)
+		fmt.Printf("✓ Added: %s (builtin)\n", name)
+		fireEvent(events.Event{Name: events.OnPolicyAdded, Policy: name})
+		doc.Added = append(doc.Added, output.Added{Policy: name, Source: compile.SourceBuiltin})
+	}
+	fmt.Printf("\n%d %s builtin(s) added\n", len(doc.Added), label)
+	if jsonOutput {
+		printJSON(doc)
+	}
+}
+
+// resolveDuplicate asks whether to skip, merge or add a phrase an existing
+// policy already covers, and reports whether to go on adding it. Without a
+// terminal to ask on, or with --json, the phrase is skipped.
+func resolveDuplicate(policy, dup, reason string) bool {
+	fmt.Printf("● Already covered by: %s (%s)\n", dup, reason)
+	if !interactive() || jsonOutput {
+		fmt.Println("  Skipped. Use --force to add it anyway.")
+		return false
+	}
+
+	fmt.Print("  [s]kip, [m]erge into existing wording, or [a]dd anyway? [s] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "a", "add":
+		return true
+	case "m", "merge":
+		if err := config.RenamePolicy(dup, policy); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Merged: %s → %s\n", dup, policy)
+	default:
+		fmt.Println("  Skipped.")
+	}
+	return false
+}
+
+// interactive reports whether stdin is a terminal.
+func interactive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/engine"
+	"github.com/VulnZap/veto/internal/output"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runAudit handles `veto audit [dir]`, scanning the project, or the
+// directory given, for content its policies would block, and exiting 2
+// when there is any the baseline doesn't accept. --tail and --clear still show and clear the
+// TypeScript engine's action log.
+func runAudit(args []string) {
+	for _, arg := range args {
+		if arg == "--tail" || arg == "--clear" {
+			bridge, err := engine.NewBridge()
+			if err == nil {
+				err = bridge.Audit(args)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	format := fs.String("format", "pretty", "output format: pretty or json")
+	jobs := fs.Int("jobs", 0, "files to check at once (default one per CPU)")
+	all := fs.Bool("all", false, "also list violations the baseline accepts")
+	fs.Parse(args)
+	if jsonOutput {
+		*format = "json"
+	}
+	if *format != "pretty" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "✗ unknown format %q (want pretty or json)\n", *format)
+		os.Exit(1)
+	}
+
+	dir := fs.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	p, err := project.Resolve(abs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	if fs.Arg(0) == "" {
+		abs = p.Root
+	}
+
+	report, err := audit.Scan(abs, p.Set, *jobs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	if p.Baseline != nil {
+		if dir, err := filepath.Rel(p.Root, abs); err == nil && !strings.HasPrefix(dir, "..") {
+			if dir == "." {
+				dir = ""
+			}
+			p.Baseline.Apply(report.Findings, filepath.ToSlash(dir))
+		}
+	}
+
+	blocking := 0
+	for _, f := range report.Findings {
+		if f.Blocking() {
+			blocking++
+		}
+	}
+	if *format == "json" {
+		printJSON(auditDoc(report, blocking))
+	} else {
+		printAudit(report, blocking, *all)
+	}
+	if blocking > 0 {
+		os.Exit(2)
+	}
+}
+
+// auditDoc converts a report, of which blocking findings would be
+// blocked, to the document --format json prints.
+func auditDoc(report *audit.Report, blocking int) output.Audit {
+	doc := output.Audit{Root: report.Root, Files: report.Files, Blocking: blocking, Findings: []output.Finding{}}
+	for _, f := range report.Findings {
+		doc.Findings = append(doc.Findings, output.Finding{
+			File:      f.File,
+			Line:      f.Line,
+			Column:    f.Column,
+			Policy:    f.Policy,
+			Rule:      f.Rule,
+			Reason:    f.Reason,
+			Suggest:   f.Suggest,
+			Decision:  string(f.Decision),
+			Severity:  string(f.Severity),
+			Blocking:  f.Blocking(),
+			Monitored: f.Monitored,
+			Possible:  f.Possible,
+			Baselined: f.Baselined,
+			Text:      f.Text,
+		})
+	}
+	return doc
+}
+
+// printAudit lists findings as file:line:column with the rule that
+// matched, the offending line and the rule's suggestion. Findings the
+// baseline accepts are only counted unless all is set.
+func printAudit(report *audit.Report, blocking int, all bool) {
+	files := map[string]bool{}
+	baselined := 0
+	for _, f := range report.Findings {
+		if f.Baselined {
+			baselined++
+			if !all {
+				continue
+			}
+		}
+		files[f.File] = true
+		mark := "✗"
+		switch {
+		case f.Baselined:
+			mark = "="
+		case f.Monitored:
+			mark = "~"
+		case f.Possible:
+			mark = "?"
+		case !f.Blocking():
+			mark = "!"
+		}
+		detail := string(f.Decision) + ", " + f.Rule
+		if f.Baselined {
+			detail += ", baselined"
+		}
+		if f.Possible {
+			detail += ", unconfirmed AST match"
+		}
+		pos := f.File
+		if f.Line > 0 {
+			pos = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+		}
+		fmt.Printf("%s %s %s %s\n", pos, mark, f.Reason, dimStyle.Render("("+f.Policy+"; "+detail+")"))
+		if f.Text != "" {
+			fmt.Printf("    %s\n", dimStyle.Render(f.Text))
+		}
+		if f.Suggest != "" {
+			fmt.Printf("    Try: %s\n", f.Suggest)
+		}
+	}
+
+	shown := len(report.Findings)
+	if !all {
+		shown -= baselined
+	}
+	accepted := ""
+	if baselined > 0 {
+		accepted = fmt.Sprintf(" (%d more accepted by the baseline)", baselined)
+		if all {
+			accepted = fmt.Sprintf(" (%d accepted by the baseline)", baselined)
+		}
+	}
+	if shown == 0 && baselined > 0 {
+		fmt.Printf("✓ No new violations in %d files%s\n", report.Files, accepted)
+		return
+	}
+	if shown == 0 {
+		fmt.Printf("✓ No violations in %d files\n", report.Files)
+		return
+	}
+	fmt.Println()
+	summary := fmt.Sprintf("%d violation(s) in %d file(s), %d files scanned%s", shown, len(files), report.Files, accepted)
+	if blocking == 0 {
+		fmt.Printf("● %s; none blocking\n", summary)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✗ %s; %d blocking\n", summary, blocking)
+}
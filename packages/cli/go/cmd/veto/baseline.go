@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runBaseline handles `veto baseline [update|stats]`. Without a
+// subcommand it records the violations already in the project in
+// .veto-baseline.json, so audits and agent checks only flag new ones;
+// update records them again once some are fixed (or new ones are
+// accepted), and stats shows how far the baseline has burned down.
+func runBaseline(args []string) {
+	sub := ""
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "", "update", "stats":
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: veto baseline [update|stats]")
+		os.Exit(1)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	p, err := project.Resolve(cwd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	path := audit.BaselinePath(p.Root)
+	if sub == "" && p.Baseline != nil {
+		fmt.Fprintf(os.Stderr, "✗ %s already exists; run: veto baseline update\n", audit.BaselineName)
+		os.Exit(1)
+	}
+	if sub != "" && p.Baseline == nil {
+		fmt.Fprintf(os.Stderr, "✗ No %s yet; run: veto baseline\n", audit.BaselineName)
+		os.Exit(1)
+	}
+
+	report, err := audit.Scan(p.Root, p.Set, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	switch sub {
+	case "":
+		b := audit.NewBaseline(report.Findings, time.Now().UTC())
+		if err := b.Write(path); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Wrote %s: %d existing violation(s) accepted, %d files scanned\n", audit.BaselineName, b.Total(), report.Files)
+		fmt.Println("  Audits and agents now only flag new violations. Commit it with the code.")
+	case "update":
+		b := p.Baseline
+		fixed, added := b.Update(report.Findings, time.Now().UTC())
+		if err := b.Write(path); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Updated %s: %d fixed, %d newly accepted, %d remaining\n", audit.BaselineName, fixed, added, b.Total())
+	case "stats":
+		printBaselineStats(p.Baseline, report)
+	}
+}
+
+// printBaselineStats shows the baseline's burn-down: how many violations
+// it started with, how many it accepts now and how many of those the
+// code no longer has.
+func printBaselineStats(b *audit.Baseline, report *audit.Report) {
+	remaining := b.Apply(report.Findings, "")
+	initial := b.Initial()
+	fmt.Println("Accepted violations:")
+	for _, point := range b.History {
+		fmt.Printf("  %s  %d\n", point.At.Local().Format("2006-01-02"), point.Count)
+	}
+	fmt.Printf("  %-10s  %d\n", "now", remaining)
+	if fixed := b.Total() - remaining; fixed > 0 {
+		fmt.Printf("● %d fixed since the last update; run: veto baseline update\n", fixed)
+	}
+	if initial > 0 && remaining <= initial {
+		fmt.Printf("Burned down: %d of %d (%d%%)\n", initial-remaining, initial, (initial-remaining)*100/initial)
+	}
+
+	newer := 0
+	for _, f := range report.Findings {
+		if f.Blocking() {
+			newer++
+		}
+	}
+	if newer > 0 {
+		fmt.Printf("✗ %d new violation(s); run: veto audit\n", newer)
+	}
+}
+
+// baselineSummary describes the project's baseline for veto status, or
+// returns "" when it has none.
+func baselineSummary(root string) string {
+	b, err := audit.ReadBaseline(audit.BaselinePath(root))
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	if err != nil {
+		return err.Error()
+	}
+	initial := b.Initial()
+	if initial == 0 || b.Total() > initial {
+		return fmt.Sprintf("%d accepted violation(s)", b.Total())
+	}
+	return fmt.Sprintf("%d of %d accepted violations left (%d%% burned down)", b.Total(), initial, (initial-b.Total())*100/initial)
+}
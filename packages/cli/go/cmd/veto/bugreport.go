@@ -0,0 +1,161 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/crash"
+	"github.com/VulnZap/veto/internal/daemon"
+	"github.com/VulnZap/veto/internal/httpclient"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// crashGuard wraps the TUI model to record a crash report when Update or
+// View panics. The panic is re-raised so Bubble Tea restores the
+// terminal; main then saves the report.
+type crashGuard struct {
+	tea.Model
+	report **crash.Report
+}
+
+func (g crashGuard) Update(msg tea.Msg) (m tea.Model, cmd tea.Cmd) {
+	defer g.recover()
+	m, cmd = g.Model.Update(msg)
+	return crashGuard{m, g.report}, cmd
+}
+
+func (g crashGuard) View() string {
+	defer g.recover()
+	return g.Model.View()
+}
+
+func (g crashGuard) recover() {
+	if v := recover(); v != nil {
+		*g.report = crash.New(v, debug.Stack(), version, commit, os.Args[1:])
+		panic(v)
+	}
+}
+
+// runBugReport handles `veto bug-report`, writing an archive of
+// anonymized diagnostics to attach to an issue: version and platform,
+// agents and how they're enforced, settings, policy counts and recent
+// crash reports. Policy text and paths outside veto's own are left out,
+// and nothing is uploaded.
+func runBugReport(args []string) {
+	fs := flag.NewFlagSet("bug-report", flag.ExitOnError)
+	output := fs.String("output", "veto-bug-report-"+time.Now().Format("20060102-150405")+".tar.gz", "archive to write")
+	fs.Parse(args)
+
+	files := []bugReportFile{{"diagnostics.txt", diagnostics()}}
+	reports, _ := crash.List()
+	if len(reports) > 3 {
+		reports = reports[:3]
+	}
+	for _, path := range reports {
+		// Saved reports are already redacted; redact again in case one
+		// was edited by hand
+		if data, err := os.ReadFile(path); err == nil {
+			files = append(files, bugReportFile{"crashes/" + filepath.Base(path), crash.Redact(string(data))})
+		}
+	}
+
+	if err := writeBugReport(*output, files); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Wrote %s\n", *output)
+	fmt.Printf("  %s\n", dimStyle.Render(fmt.Sprintf("diagnostics and %d crash report(s); review it before sharing", len(reports))))
+	fmt.Printf("  Attach it to an issue: %s\n", crash.IssuesURL)
+}
+
+// diagnostics describes this installation without policy text, project
+// paths or credentials.
+func diagnostics() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "veto %s (%s, built %s)\n", version, orUnknown(shortCommit(commit)), orUnknown(date))
+	fmt.Fprintf(&b, "platform: %s\n", strings.TrimPrefix(update.AssetName(), "veto-"))
+	fmt.Fprintf(&b, "engine: %s, lock schema v%d\n\n", lock.EngineRE2, lock.Version)
+
+	root, _ := agent.ProjectRoot()
+	fmt.Fprintln(&b, "agents:")
+	for _, a := range agent.DetectInstalled() {
+		a := a
+		scopes := agent.SyncedScopes(&a, root)
+		fmt.Fprintf(&b, "  %s: %s, synced [%s]\n", a.ID, agent.Enforce(&a, root).Level, joinScopes(scopes))
+	}
+	fmt.Fprintf(&b, "daemon running: %v\n\n", daemon.Running())
+
+	if path, err := config.Find(); err == nil {
+		cfg, err := config.Load(path)
+		if err != nil {
+			fmt.Fprintf(&b, ".veto: %s\n", crash.Redact(err.Error()))
+		} else {
+			builtins := 0
+			for _, p := range cfg.Policies {
+				if builtin.Find(p) != nil {
+					builtins++
+				}
+			}
+			fmt.Fprintf(&b, ".veto: %s format, %d policies (%d builtin), %d rules, %d tests, mode %q\n",
+				cfg.Format, len(cfg.Policies), builtins, len(cfg.Rules), len(cfg.Tests), cfg.Mode)
+		}
+		if _, err := os.Stat(lock.Path(filepath.Dir(path))); err == nil {
+			fmt.Fprintf(&b, "%s: present\n", lock.FileName)
+		}
+	} else {
+		fmt.Fprintln(&b, ".veto: none")
+	}
+
+	if s, err := settings.Load(); err == nil {
+		fmt.Fprintf(&b, "\nsettings: channel %q, notifications %v, CA bundle set %v\n", s.Channel, s.Notify != nil, httpclient.CABundle() != "")
+	}
+	proxy := os.Getenv("HTTPS_PROXY") != "" || os.Getenv("https_proxy") != "" || os.Getenv("HTTP_PROXY") != "" || os.Getenv("http_proxy") != ""
+	fmt.Fprintf(&b, "proxy set: %v\n", proxy)
+	return b.String()
+}
+
+type bugReportFile struct {
+	name    string
+	content string
+}
+
+func writeBugReport(path string, files []bugReportFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, file := range files {
+		hdr := &tar.Header{Name: file.name, Size: int64(len(file.content)), Mode: 0600, ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(file.content)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/config"
+)
+
+// runBuiltins handles `veto builtins [category|tag]`, listing the builtin
+// catalog grouped by category and marking the ones the project enables.
+func runBuiltins(args []string) {
+	var enabled []string
+	if config.Exists() {
+		path, _ := config.Find()
+		if cfg, err := config.Load(path); err == nil {
+			enabled = cfg.Policies
+		}
+	}
+
+	filter := strings.ToLower(strings.Join(args, " "))
+	if filter != "" && !slices.Contains(builtin.Labels(), filter) {
+		fmt.Fprintf(os.Stderr, "✗ Unknown category or tag %q\n", filter)
+		fmt.Fprintf(os.Stderr, "  Known: %s\n", strings.Join(builtin.Labels(), ", "))
+		os.Exit(1)
+	}
+
+	for _, c := range builtin.Categories {
+		names := builtin.Select(string(c))
+		if filter != "" {
+			names = slices.DeleteFunc(names, func(n string) bool {
+				return !slices.Contains(builtin.Select(filter), n)
+			})
+		}
+		if len(names) == 0 {
+			continue
+		}
+		on := builtin.Enabled(string(c), enabled)
+		fmt.Printf("%s (%d/%d enabled)\n", orangeStyle.Render(strings.ToUpper(string(c))), len(on), len(builtin.Select(string(c))))
+		for _, name := range names {
+			b := builtin.Registry[name]
+			mark := " "
+			if slices.Contains(on, name) {
+				mark = "✓"
+			}
+			tags := ""
+			if len(b.Tags) > 0 {
+				tags = dimStyle.Render(" [" + strings.Join(b.Tags, ", ") + "]")
+			}
+			fmt.Printf("  %s %-26s %s%s\n", mark, name, b.Description, tags)
+		}
+		fmt.Println()
+	}
+	fmt.Println(`Enable a whole group with: veto add "all security builtins"`)
+}
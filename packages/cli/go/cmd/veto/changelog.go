@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/VulnZap/veto/internal/changelog"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// runChangelog handles `veto changelog [version]`, printing one release's
+// entry or the whole embedded changelog.
+func runChangelog(args []string) {
+	releases := changelog.All()
+	if len(args) > 0 {
+		r := changelog.Find(args[0])
+		if r == nil {
+			fmt.Fprintf(os.Stderr, "✗ No changelog entry for %s\n", args[0])
+			os.Exit(1)
+		}
+		releases = []changelog.Release{*r}
+	}
+	for i, r := range releases {
+		if i > 0 {
+			fmt.Println()
+		}
+		printRelease(r)
+	}
+}
+
+func printRelease(r changelog.Release) {
+	title := "v" + r.Version
+	if r.Version == changelog.Unreleased {
+		title = r.Version
+	}
+	fmt.Println(orangeStyle.Render(title))
+	printSection("Breaking", r.Breaking)
+	printSection("New builtins", r.Builtins)
+	printSection("New agents", r.Agents)
+	printSection("Changes", r.Changes)
+}
+
+func printSection(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("  %s\n", title)
+	for _, item := range items {
+		fmt.Printf("    • %s\n", item)
+	}
+}
+
+// pendingWhatsNew returns the releases the user hasn't seen since their
+// last upgrade. A fresh install records the current version and returns
+// nothing.
+func pendingWhatsNew() []changelog.Release {
+	s, err := settings.Load()
+	if err != nil {
+		return nil
+	}
+	if s.LastSeenVersion == "" || !update.Newer(version, s.LastSeenVersion) {
+		if s.LastSeenVersion != version {
+			markWhatsNewSeen()
+		}
+		return nil
+	}
+	pending := changelog.Between(s.LastSeenVersion, version)
+	if len(pending) == 0 {
+		markWhatsNewSeen()
+	}
+	return pending
+}
+
+// markWhatsNewSeen records that the current version's panel was shown.
+func markWhatsNewSeen() {
+	s, err := settings.Load()
+	if err != nil {
+		return
+	}
+	s.LastSeenVersion = version
+	_ = s.Save() // best effort; worst case the panel shows again
+}
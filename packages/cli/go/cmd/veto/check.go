@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/daemon"
+	"github.com/VulnZap/veto/internal/lsp"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/output"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/wsl"
+)
+
+// runCheck handles `veto check`, the entry point used by agent hooks.
+// Exits 2 when the action is blocked, with --json too.
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	action := fs.String("action", "", "action being performed: read, create, modify, rename, move, copy, delete or execute (default execute for commands, modify for files)")
+	file := fs.String("file", "", "file the action targets")
+	to := fs.String("to", "", "new path of the file, for rename and move, or where it is copied to")
+	command := fs.String("command", "", "shell command to validate")
+	agentID := fs.String("agent", os.Getenv("VETO_AGENT"), "agent ID making the request")
+	model := fs.String("model", os.Getenv("VETO_MODEL"), "model name driving the agent")
+	session := fs.String("session", os.Getenv("VETO_SESSION_ID"), "agent session ID")
+	trace := fs.Bool("trace", false, "print every matching policy and how it was resolved")
+	format := fs.String("format", "", "output format: editor-diagnostics prints the file's content rule violations as JSON")
+	stdin := fs.Bool("stdin", false, "read the file's content from stdin instead of disk (editor-diagnostics)")
+	fs.Parse(args)
+
+	if *format == formatEditorDiagnostics {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Usage: veto check --file <path> --format editor-diagnostics [--stdin]")
+			os.Exit(1)
+		}
+		if err := printEditorDiagnostics(*file, *stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *format != "" {
+		fmt.Fprintf(os.Stderr, "✗ unknown format %q (want %s)\n", *format, formatEditorDiagnostics)
+		os.Exit(1)
+	}
+
+	if *file == "" && *command == "" {
+		fmt.Fprintln(os.Stderr, "Usage: veto check [--file <path> [--action <action>] [--to <path>]] [--command <cmd>]")
+		os.Exit(1)
+	}
+
+	if *action == "" {
+		*action = string(policy.ActionModify)
+		if *command != "" {
+			*action = string(policy.ActionExecute)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	req := &policy.CheckRequest{
+		Action:      *action,
+		Target:      *file,
+		Destination: *to,
+		Command:     *command,
+		Cwd:         cwd,
+		Agent:       *agentID,
+		Model:       *model,
+		SessionID:   *session,
+		User:        currentUser(),
+		Mode:        config.EnvMode(),
+	}
+
+	result, err := checkRequest(req)
+	if err != nil {
+		fail(err)
+	}
+
+	recordDecision(req, result)
+	// Nobody is watching in unattended mode, so report every decision
+	if req.Mode == policy.ModeUnattended {
+		logDecision(req, result)
+	}
+	// Someone running veto check by hand is already looking at the answer
+	if !interactive() {
+		notifyResult(req, result)
+	}
+	fireBlock(req, result)
+
+	if *trace {
+		printTrace(result.Trace)
+	}
+
+	for _, hit := range result.Monitored {
+		fmt.Fprintf(os.Stderr, "~ Monitor: would %s (%s): %s\n", hit.Decision, hit.Policy, hit.Reason)
+	}
+
+	if jsonOutput {
+		printJSON(checkDoc(result))
+	} else if line := message.Terminal(result); line != "" {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	if !result.Allowed {
+		os.Exit(2)
+	}
+	if !jsonOutput {
+		fmt.Println("✓ Allowed")
+	}
+}
+
+// checkDoc converts a check's result to the document --json prints.
+func checkDoc(result *policy.CheckResult) output.Check {
+	doc := output.Check{
+		Allowed:   result.Allowed,
+		Decision:  string(result.Decision),
+		Policy:    result.Policy,
+		Rule:      result.Rule,
+		Reason:    result.Reason,
+		Suggest:   result.Suggest,
+		Monitored: []output.Monitored{},
+	}
+	for _, hit := range result.Monitored {
+		doc.Monitored = append(doc.Monitored, output.Monitored{Policy: hit.Policy, Decision: string(hit.Decision), Reason: hit.Reason})
+	}
+	return doc
+}
+
+// formatEditorDiagnostics prints LSP-shaped diagnostics for a file, for
+// editors that run linters as external tools (none-ls, JetBrains).
+const formatEditorDiagnostics = "editor-diagnostics"
+
+// printEditorDiagnostics writes the content rule violations in a file as
+// a JSON array of diagnostics (range, severity, message, code). Files
+// outside any project have none.
+func printEditorDiagnostics(file string, fromStdin bool) error {
+	var data []byte
+	var err error
+	if fromStdin {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(wsl.Path(file))
+	if err != nil {
+		return err
+	}
+	var matches []matcher.ContentMatch
+	p, err := project.Resolve(filepath.Dir(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if p != nil {
+		rel, err := filepath.Rel(p.Root, path)
+		if err != nil {
+			return err
+		}
+		matches = p.Set.FindContent(filepath.ToSlash(rel), string(data))
+	}
+
+	return output.Print(stdout, lsp.Diagnostics(string(data), matches))
+}
+
+// printTrace writes a result's decision chain to stderr.
+func printTrace(trace []policy.TraceStep) {
+	if len(trace) == 0 {
+		fmt.Fprintln(os.Stderr, "  (no policies matched)")
+		return
+	}
+	for i, step := range trace {
+		detail := string(step.Decision)
+		if step.Severity != "" {
+			detail += ", " + string(step.Severity)
+		}
+		if step.Locked {
+			detail += ", locked"
+		}
+		if step.Rule != "" {
+			detail += ", " + step.Rule
+		}
+		if detail != "" {
+			detail = " [" + detail + "]"
+		}
+		fmt.Fprintf(os.Stderr, "  %d. %-10s %s%s\n", i+1, step.Outcome, step.Policy, detail)
+	}
+}
+
+// logDecision writes a full description of a decision to stderr.
+func logDecision(req *policy.CheckRequest, result *policy.CheckResult) {
+	decision := result.Decision
+	if decision == "" {
+		decision = policy.DecisionAllow
+	}
+	subject := req.Command
+	if subject == "" {
+		subject = req.Target
+	}
+	fmt.Fprintf(os.Stderr, "veto: %s %s %q agent=%s session=%s user=%s",
+		decision, req.Action, subject, req.Agent, req.SessionID, req.User)
+	if result.Policy != "" {
+		fmt.Fprintf(os.Stderr, " policy=%q", result.Policy)
+	}
+	if result.Rule != "" {
+		fmt.Fprintf(os.Stderr, " rule=%s", result.Rule)
+	}
+	for _, hit := range result.Monitored {
+		fmt.Fprintf(os.Stderr, " monitor=%q", hit.Policy)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// checkRequest validates a request through the daemon when one is running,
+// falling back to loading the project in-process.
+func checkRequest(req *policy.CheckRequest) (*policy.CheckResult, error) {
+	// Windows-side agents in WSL report Windows paths
+	req.Target, req.Cwd = wsl.Path(req.Target), wsl.Path(req.Cwd)
+	req.Destination = wsl.Path(req.Destination)
+
+	if c, err := daemon.Dial(); err == nil {
+		defer c.Close()
+		return c.Check(req)
+	}
+	return project.CheckFrom(req)
+}
+
+// currentUser returns the OS username, or "" if it cannot be determined.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/coverage"
+)
+
+// runCoverage handles `veto coverage`, scoring the project's policies
+// against common agent risks and suggesting policies for each gap.
+func runCoverage(args []string) {
+	p, _ := loadProjectForMatch()
+	report := coverage.Run(p.Check)
+
+	fmt.Printf("Policy coverage: %d%%\n\n", report.Percent())
+	for _, s := range report.Scores {
+		fmt.Printf("  %-22s %s %d/%d\n", s.Category, coverageBar(s.Blocked, s.Total), s.Blocked, s.Total)
+	}
+
+	fmt.Println("\nBuiltins enabled:")
+	for _, c := range builtin.Categories {
+		on, total := len(builtin.Enabled(string(c), p.Config.Policies)), len(builtin.Select(string(c)))
+		fmt.Printf("  %-22s %s %d/%d\n", c, coverageBar(on, total), on, total)
+	}
+
+	if len(report.Gaps) == 0 {
+		fmt.Println("\n✓ Every risk in the catalog is covered")
+		return
+	}
+
+	fmt.Println("\nGaps:")
+	for _, g := range report.Gaps {
+		fmt.Printf("  ✗ %s: %s\n", g.Category, g.Probe.Description)
+	}
+
+	fmt.Println("\nTo close them:")
+	for _, fix := range report.Fixes() {
+		kind := "compiled by LLM"
+		if builtin.Find(fix) != nil {
+			kind = "builtin"
+		}
+		fmt.Printf("  veto add %q  (%s)\n", fix, kind)
+	}
+}
+
+// coverageBar draws blocked/total as a ten-cell bar.
+func coverageBar(blocked, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = blocked * 10 / total
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VulnZap/veto/internal/daemon"
+)
+
+// runDaemon handles `veto daemon <start|stop|status>`.
+func runDaemon(args []string) {
+	sub := "status"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+
+	switch sub {
+	case "start":
+		fmt.Printf("● veto daemon listening on %s\n", daemon.SocketPath())
+		if err := daemon.NewServer().ListenAndServe(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+
+	case "stop":
+		c, err := daemon.Dial()
+		if err != nil {
+			fmt.Println("● daemon not running")
+			return
+		}
+		defer c.Close()
+		if err := c.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ daemon stopped")
+
+	case "status":
+		c, err := daemon.Dial()
+		if err != nil {
+			fmt.Println("● daemon not running")
+			fmt.Println("  Run: veto daemon start")
+			return
+		}
+		defer c.Close()
+		status, err := c.Status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Daemon: pid %d, up %s\n", status.PID, time.Since(status.StartedAt).Round(time.Second))
+		fmt.Printf("Projects: %d\n", len(status.Projects))
+		for _, p := range status.Projects {
+			fmt.Printf("  ● %s\n", p.Root)
+			fmt.Printf("    %d policies • %d checks • %d blocked • %d monitor hits • last activity %s\n",
+				p.Policies, p.Checks, p.Blocked, p.Monitored, p.LastActivity.Format(time.DateTime))
+			if p.AuditSchedule != "" {
+				line := fmt.Sprintf("audits %s • next %s", p.AuditSchedule, p.NextAudit.Format(time.DateTime))
+				if !p.LastAudit.IsZero() {
+					line += fmt.Sprintf(" • last %s, %d new", p.LastAudit.Local().Format(time.DateTime), p.LastAuditNew)
+				}
+				fmt.Printf("    %s\n", line)
+			}
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: veto daemon <start|stop|status>")
+		os.Exit(1)
+	}
+}
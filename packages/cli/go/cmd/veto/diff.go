@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+)
+
+// runDiff handles `veto diff`, showing how the policies .veto compiles to
+// differ from those pinned in .veto.lock: new and removed patterns, and
+// changed settings like severity. --markdown formats the report for a PR
+// comment.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	markdown := fs.Bool("markdown", false, "print the report as markdown")
+	fs.Parse(args)
+
+	path, err := config.Find()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	previous, err := lock.Read(lock.Path(filepath.Dir(path)))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	var pinned []lock.Entry
+	if previous != nil {
+		pinned = previous.Policies
+	}
+
+	changes := lock.Diff(pinned, lockEntries(cfg, previous))
+	if *markdown {
+		fmt.Print(diffMarkdown(changes, previous == nil))
+		return
+	}
+
+	if previous == nil {
+		fmt.Printf("● No %s yet; every policy is new\n\n", lock.FileName)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("✓ Policies match %s\n", lock.FileName)
+		return
+	}
+	for _, c := range changes {
+		fmt.Printf("%s %s (%s)\n", diffMark(c.Kind), c.Source, c.Kind)
+		for _, r := range c.AddedRules {
+			fmt.Printf("    + %s\n", r)
+		}
+		for _, r := range c.RemovedRules {
+			fmt.Printf("    - %s\n", r)
+		}
+		for _, s := range c.Settings {
+			fmt.Printf("    ~ %s\n", s)
+		}
+	}
+	fmt.Printf("\nRun: veto lock to accept these changes\n")
+}
+
+func diffMark(kind lock.ChangeKind) string {
+	switch kind {
+	case lock.Added:
+		return "+"
+	case lock.Removed:
+		return "-"
+	}
+	return "~"
+}
+
+// diffMarkdown renders changes for a CI comment.
+func diffMarkdown(changes []lock.Change, noLock bool) string {
+	var b strings.Builder
+	b.WriteString("### veto policy changes\n\n")
+	if noLock {
+		fmt.Fprintf(&b, "No `%s` yet; every policy is new.\n\n", lock.FileName)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "Compiled policies match `%s`.\n", lock.FileName)
+		return b.String()
+	}
+	for _, c := range changes {
+		fmt.Fprintf(&b, "#### %s `%s`\n\n", strings.ToUpper(string(c.Kind[:1]))+string(c.Kind[1:]), c.Source)
+		if c.Description != "" && c.Description != c.Source {
+			fmt.Fprintf(&b, "%s\n\n", c.Description)
+		}
+		if len(c.AddedRules)+len(c.RemovedRules) > 0 {
+			b.WriteString("```diff\n")
+			for _, r := range c.AddedRules {
+				fmt.Fprintf(&b, "+ %s\n", r)
+			}
+			for _, r := range c.RemovedRules {
+				fmt.Fprintf(&b, "- %s\n", r)
+			}
+			b.WriteString("```\n\n")
+		}
+		for _, s := range c.Settings {
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+		if len(c.Settings) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
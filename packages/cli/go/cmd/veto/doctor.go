@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/httpclient"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// doctorEndpoints are the services veto reaches over the network.
+var doctorEndpoints = []struct {
+	name string
+	url  string
+}{
+	{"npm registry", update.NPMRegistryURL},
+	{"GitHub releases", update.GitHubURL},
+}
+
+// runDoctor handles `veto doctor`, showing the proxy and CA bundle
+// network calls use and testing that each service is reachable through
+// them. --ca-bundle saves a CA bundle to trust ("none" clears it).
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	caBundle := fs.String("ca-bundle", "", `PEM file of extra CA certificates to trust from now on ("none" to clear)`)
+	fs.Parse(args)
+
+	if *caBundle != "" {
+		if err := saveCABundle(*caBundle); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println("Network:")
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		value := os.Getenv(name)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if value == "" {
+			value = dimStyle.Render("(not set)")
+		}
+		fmt.Printf("  %-12s %s\n", name, redactProxy(value))
+	}
+	if path := httpclient.CABundle(); path != "" {
+		fmt.Printf("  %-12s %s\n", "CA bundle", path)
+	} else {
+		fmt.Printf("  %-12s %s\n", "CA bundle", dimStyle.Render("(system roots only)"))
+	}
+
+	fmt.Println("\nConnectivity:")
+	client := httpclient.New(10 * time.Second)
+	failed := 0
+	for _, e := range doctorEndpoints {
+		route := "direct"
+		if proxy, err := httpclient.Proxy(e.url); err == nil && proxy != nil {
+			route = "via " + redactProxy(proxy.String())
+		}
+		start := time.Now()
+		err := probe(client, e.url)
+		if err != nil {
+			failed++
+			fmt.Printf("  ✗ %-16s %s\n      %v\n", e.name, route, err)
+			continue
+		}
+		fmt.Printf("  ✓ %-16s %s, %dms\n", e.name, route, time.Since(start).Milliseconds())
+	}
+
+	if failed > 0 {
+		fmt.Println("\n  Behind a proxy? Set HTTPS_PROXY (and NO_PROXY for internal hosts).")
+		fmt.Println("  TLS errors? Trust your proxy's CA: veto doctor --ca-bundle <file.pem>")
+		os.Exit(1)
+	}
+}
+
+// probe requests url and reports an error unless the service answered.
+// Any HTTP status counts: the point is reaching it.
+func probe(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return fmt.Errorf("proxy wants credentials: put them in the proxy URL")
+	}
+	return nil
+}
+
+// saveCABundle checks path holds PEM certificates and saves it as the
+// CA bundle to trust.
+func saveCABundle(path string) error {
+	s, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if path == "none" {
+		s.CABundle = ""
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("CA bundle: %w", err)
+		}
+		if block, _ := pem.Decode(bytes.TrimSpace(data)); block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("CA bundle %s: no PEM certificates found", path)
+		}
+		if s.CABundle, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+	if s.CABundle == "" {
+		fmt.Println("✓ Cleared the CA bundle")
+	} else {
+		fmt.Printf("✓ Trusting certificates in %s\n", s.CABundle)
+	}
+	return nil
+}
+
+// redactProxy hides the password in a proxy URL.
+func redactProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
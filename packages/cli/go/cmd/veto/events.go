@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// fireBlock runs the on_block hooks of the project a request that wasn't
+// allowed came from. A failing hook is reported but never changes the
+// decision.
+func fireBlock(req *policy.CheckRequest, result *policy.CheckResult) {
+	if result.Allowed {
+		return
+	}
+	path, err := project.Find(req.Cwd)
+	if err != nil || path == project.MachinePath() {
+		return
+	}
+	if err := events.Fire(filepath.Dir(path), events.Block(req, result)); err != nil {
+		fmt.Fprintf(os.Stderr, "! hooks: %v\n", err)
+	}
+}
+
+// fireEvent runs the current project's hooks for e, reporting failures.
+func fireEvent(e events.Event) {
+	if err := fireProject(e); err != nil {
+		fmt.Fprintf(os.Stderr, "! hooks: %v\n", err)
+	}
+}
+
+// fireProject runs the current project's hooks for e.
+func fireProject(e events.Event) error {
+	root, err := agent.ProjectRoot()
+	if err != nil {
+		return nil
+	}
+	return events.Fire(root, e)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/agent"
+)
+
+// runGC handles `veto gc`: global agent configs written from projects
+// that are gone, or whose .veto dropped the installed policies, are
+// removed or resynced. --dry-run only lists them.
+func runGC(args []string) {
+	dryRun := false
+	for _, a := range args {
+		if a == "--dry-run" || a == "-n" {
+			dryRun = true
+		}
+	}
+
+	stale, err := agent.FindStale()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if len(stale) == 0 {
+		fmt.Println("✓ No stale global agent configs")
+		return
+	}
+
+	failed := false
+	for _, s := range stale {
+		name := s.Agent
+		if a := agent.Find(s.Agent); a != nil {
+			name = a.Name
+		}
+		detail := s.Reason
+		if len(s.Removed) > 0 {
+			detail += ": " + strings.Join(s.Removed, ", ")
+		}
+		if s.Root != "" {
+			detail += " (" + tildePath(s.Root) + ")"
+		}
+
+		switch {
+		case s.Unowned:
+			fmt.Printf("● %s: %s\n", name, detail)
+			fmt.Printf("  Run: veto uninstall %s --scope global to remove it\n", s.Agent)
+		case dryRun:
+			action := "resync"
+			if s.Gone {
+				action = "remove"
+			}
+			fmt.Printf("● %s: %s, would %s\n", name, detail, action)
+		default:
+			if err := agent.Clean(s); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", name, err)
+				failed = true
+				continue
+			}
+			action := "resynced"
+			if s.Gone {
+				action = "removed"
+			}
+			fmt.Printf("✓ %s: %s, %s\n", name, detail, action)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// hintsTTL is how long a session's hinted policies are remembered.
+const hintsTTL = 24 * time.Hour
+
+// hintedSession is the policies an agent session was already told about.
+type hintedSession struct {
+	Policies []string  `json:"policies"`
+	At       time.Time `json:"at"`
+}
+
+// hintsPath is where hinted policies are kept, per session.
+func hintsPath() string {
+	return filepath.Join(xdg.StateDir(), "hints.json")
+}
+
+// contextHints returns the policies that concern the file req touched,
+// as Markdown for the agent's context, leaving out those the session was
+// already told about so a long session isn't reminded on every read.
+func contextHints(req *policy.CheckRequest) string {
+	if req.Target == "" {
+		return ""
+	}
+	target := req.Target
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(req.Cwd, target)
+	}
+	p, err := project.Resolve(filepath.Dir(target))
+	if err != nil {
+		return ""
+	}
+	local := *req
+	local.Target = target
+	relevant := p.Relevant(&local)
+	if len(relevant) == 0 {
+		return ""
+	}
+
+	state := make(map[string]hintedSession)
+	if data, err := os.ReadFile(hintsPath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	now := time.Now()
+	for id, s := range state {
+		if now.Sub(s.At) > hintsTTL {
+			delete(state, id)
+		}
+	}
+	session := state[req.SessionID]
+	told := make(map[string]bool)
+	for _, d := range session.Policies {
+		told[d] = true
+	}
+	var fresh []*policy.Policy
+	for _, pol := range relevant {
+		if req.SessionID == "" || !told[pol.Description] {
+			fresh = append(fresh, pol)
+			session.Policies = append(session.Policies, pol.Description)
+		}
+	}
+	if len(fresh) == 0 {
+		return ""
+	}
+	if req.SessionID != "" {
+		session.At = now
+		state[req.SessionID] = session
+		if data, err := json.MarshalIndent(state, "", "  "); err == nil {
+			if os.MkdirAll(filepath.Dir(hintsPath()), 0755) == nil {
+				os.WriteFile(hintsPath(), data, 0644)
+			}
+		}
+	}
+	name := target
+	if rel, err := filepath.Rel(p.Root, target); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+	return message.Hints([]string{name}, fresh)
+}
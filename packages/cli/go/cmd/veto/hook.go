@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/hookproto"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/testrun"
+	"github.com/VulnZap/veto/internal/wsl"
+)
+
+// runHook handles `veto hook`: it reads an agent's hook payload from
+// stdin, checks the action it describes and writes the reply in the
+// shape that agent version expects.
+func runHook(args []string) {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	agentID := fs.String("agent", "", "agent that sent the payload (detected when empty)")
+	fs.Parse(args)
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		hookFail(nil, err)
+	}
+	h, err := hookproto.Parse(data, *agentID)
+	if err != nil {
+		hookFail(nil, err)
+	}
+
+	result := &policy.CheckResult{Allowed: true}
+	req := h.Request
+	req.Target, req.Cwd = wsl.Path(req.Target), wsl.Path(req.Cwd)
+	req.Destination = wsl.Path(req.Destination)
+	if req.Cwd == "" {
+		req.Cwd, _ = os.Getwd()
+	}
+	switch {
+	case h.Event == "PostToolUse":
+		// The edit was checked before it ran; now check what it did
+		if req.Action == string(policy.ActionModify) {
+			result = afterEdit(&req)
+		}
+		// Tell the agent which policies govern the file it just touched
+		if result.Allowed && (req.Action == string(policy.ActionModify) || req.Action == string(policy.ActionRead)) {
+			result.Hints = contextHints(&req)
+		}
+	case req.Action != "":
+		req.User = currentUser()
+		req.Mode = config.EnvMode()
+		if result, err = checkRequest(&req); err != nil {
+			hookFail(h, err)
+		}
+		switch {
+		case result.Decision != policy.DecisionDeny:
+		case result.Rewrite != "" && h.Rewrites():
+			// The agent runs the rewritten command itself, if it's allowed
+			if !allowed(&req, result.Rewrite) {
+				result.Rewrite = ""
+			}
+		case result.Rewrite != "":
+			runInstead(&req, result, result.Rewrite)
+		case result.RunInstead != "":
+			runInstead(&req, result, result.RunInstead)
+		}
+		recordDecision(&req, result)
+		if req.Mode == policy.ModeUnattended {
+			logDecision(&req, result)
+		}
+		notifyResult(&req, result)
+		fireBlock(&req, result)
+	}
+
+	out, err := h.Respond(result)
+	if err != nil {
+		hookFail(nil, err)
+	}
+	fmt.Println(string(out))
+}
+
+// hookFail blocks an action veto couldn't check, rather than letting it
+// through: it replies with a deny when the payload parsed, reports err
+// on stderr and exits 2, which agents treat as a block (1 isn't).
+func hookFail(h *hookproto.Hook, err error) {
+	fmt.Fprintf(os.Stderr, "✗ veto couldn't check this action: %v\n", err)
+	if h != nil {
+		deny := &policy.CheckResult{Reason: "veto couldn't check this action: " + err.Error(), Decision: policy.DecisionDeny}
+		if out, err := h.Respond(deny); err == nil {
+			fmt.Println(string(out))
+		}
+	}
+	os.Exit(2)
+}
+
+// afterEdit runs the test commands of the project an edit landed in and
+// reports tests that started failing or were skipped since the last run.
+func afterEdit(req *policy.CheckRequest) *policy.CheckResult {
+	p, err := project.Resolve(req.Cwd)
+	if err != nil {
+		return &policy.CheckResult{Allowed: true}
+	}
+	ran := make(map[string]bool)
+	for _, pol := range p.Policies {
+		cmd := pol.TestCommand
+		if cmd == "" || ran[cmd] || !pol.When.Matches(req) {
+			continue
+		}
+		ran[cmd] = true
+
+		prev := testrun.Last(p.Root, cmd)
+		cur := testrun.Run(p.Root, cmd)
+		if err := testrun.Save(p.Root, cmd, cur); err != nil {
+			fmt.Fprintf(os.Stderr, "! %v\n", err)
+		}
+		if why := testrun.Regression(prev, cur); why != "" {
+			return &policy.CheckResult{
+				Allowed:  false,
+				Reason:   why,
+				Suggest:  "fix the tests, or undo the edit, before moving on",
+				Decision: policy.DecisionDeny,
+				Policy:   pol.Description,
+			}
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
+
+// runInsteadTimeout keeps a replacement command inside the hook timeout
+// agents apply by default (60s).
+const runInsteadTimeout = 50 * time.Second
+
+// runInstead runs the replacement or rewrite a policy approved for a
+// blocked command and reports how it went in the block reason, so the
+// agent can carry on without retrying. The replacement is checked like
+// any other command first and skipped if a policy blocks it.
+func runInstead(req *policy.CheckRequest, result *policy.CheckResult, instead string) {
+	result.Rewrite = ""
+	if !allowed(req, instead) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), runInsteadTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", instead)
+	cmd.Dir = req.Cwd
+	out, err := cmd.CombinedOutput()
+
+	outcome := "it succeeded"
+	if err != nil {
+		outcome = "it failed (" + err.Error() + ")"
+	}
+	result.Reason += fmt.Sprintf(". veto ran `%s` instead and %s", instead, outcome)
+	if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); lines[0] != "" {
+		if len(lines) > 10 {
+			lines = lines[len(lines)-10:]
+		}
+		result.Reason += ":\n" + strings.Join(lines, "\n")
+	}
+	result.Suggest, result.Alternatives = "", nil
+}
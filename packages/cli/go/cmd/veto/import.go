@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/compile"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/packs"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runImport handles `veto import <source>`, fetching a policy pack and
+// adding it to the project's packs:, where its policies are merged after
+// the project's own whenever they load. Importing a pack already listed
+// fetches it again, and a registry pack imported without a version is
+// pinned to the latest. Phrases in the pack that aren't builtins are compiled
+// now, like veto add does, and a pack whose own tests: fail isn't
+// imported.
+func runImport(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto import <file.yaml|url|git+url[//path][#ref]|owner/pack[@version]>")
+		os.Exit(1)
+	}
+	source := filepath.ToSlash(args[0])
+	// A file on disk wins over a registry name that looks like its path
+	if kind, _ := packs.Kind(source); kind != packs.KindFile {
+		if info, err := os.Stat(args[0]); err == nil && !info.IsDir() {
+			source = "./" + source
+		}
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	pack, err := packs.Fetch(root, source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	cfg := pack.Config
+	compilePhrases(cfg.Policies)
+	if len(cfg.Tests) > 0 {
+		if n := runPolicyTests(pack.Name, project.Compile(cfg, nil), cfg.Tests); n > 0 {
+			fmt.Fprintf(os.Stderr, "✗ %s failed %d of its own test(s); not imported\n", pack.Name, n)
+			os.Exit(1)
+		}
+	}
+	if err := addPack(pack.Source); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	name := pack.Name
+	if pack.Version != "" {
+		name += "@" + pack.Version
+	}
+	fmt.Printf("✓ Imported %s %s\n", name, dimStyle.Render(fmt.Sprintf("(%d phrase(s), %d rule(s))", len(cfg.Policies), len(cfg.Rules))))
+	if pack.Source != source {
+		fmt.Printf("  Pinned as %s\n", pack.Source)
+	}
+	if pack.Description != "" {
+		fmt.Printf("  %s\n", dimStyle.Render(pack.Description))
+	}
+	for _, phrase := range cfg.Policies {
+		fmt.Printf("  • %s\n", phrase)
+	}
+	for _, r := range cfg.Rules {
+		fmt.Printf("  • %s %s\n", r.Description, dimStyle.Render("(rule)"))
+	}
+}
+
+// addPack lists source in the project's packs:, in place of another
+// version of the same pack.
+func addPack(source string) error {
+	var current []string
+	if path, err := config.Find(); err == nil {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		current = cfg.Packs
+	}
+
+	var updated []string
+	added := false
+	for _, p := range current {
+		if packs.Unpinned(p) != packs.Unpinned(source) {
+			updated = append(updated, p)
+		} else if !added {
+			updated = append(updated, source)
+			added = true
+		}
+	}
+	if !added {
+		updated = append(updated, source)
+	}
+	return config.SetPacks(updated)
+}
+
+// compilePhrases compiles the phrases from a pack or overlay that aren't
+// builtins or compiled already, so they're enforced rather than skipped.
+// Without an LLM they are listed instead.
+func compilePhrases(phrases []string) {
+	var pending []string
+	for _, phrase := range phrases {
+		if builtin.Find(phrase) == nil && compile.Cached(phrase) == nil {
+			pending = append(pending, phrase)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	fmt.Println("\nCompiling...")
+	c, err := newCompiler()
+	if err != nil {
+		fmt.Printf("● Not enforced until compiled: %v\n", err)
+		return
+	}
+	for _, phrase := range pending {
+		if _, err := c.Compile(phrase); err != nil {
+			fmt.Printf("● Couldn't compile %q: %v\n", phrase, err)
+			continue
+		}
+		fmt.Printf("✓ Compiled: %s\n", phrase)
+	}
+}
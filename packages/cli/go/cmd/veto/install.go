@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/githook"
+	"github.com/VulnZap/veto/internal/output"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// scopeFlag removes --scope <s> (or --scope=<s>, or the --project and
+// --global shorthands) from args, returning the scope (project by
+// default) and the remaining arguments.
+func scopeFlag(args []string) (agent.Scope, []string) {
+	scope := agent.ScopeProject
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--scope" && i+1 < len(args):
+			scope = agent.Scope(args[i+1])
+			i++
+		case strings.HasPrefix(a, "--scope="):
+			scope = agent.Scope(strings.TrimPrefix(a, "--scope="))
+		case a == "--project":
+			scope = agent.ScopeProject
+		case a == "--global":
+			scope = agent.ScopeGlobal
+		default:
+			rest = append(rest, a)
+		}
+	}
+	if scope != agent.ScopeProject && scope != agent.ScopeGlobal {
+		fmt.Fprintf(os.Stderr, "✗ Unknown scope %q (want project or global)\n", scope)
+		os.Exit(1)
+	}
+	return scope, rest
+}
+
+// runSyncCmd handles `veto sync [--env <name>]`, installing into every
+// detected agent. --env selects the checkout's environment, whose overlay
+// (.veto.<name>.yaml) adds to .veto wherever its policies load; --env none
+// goes back to .veto alone.
+func runSyncCmd(args []string) {
+	scope, args := scopeFlag(args)
+	env, setEnv := "", false
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--env" && i+1 < len(args):
+			env, setEnv = args[i+1], true
+			i++
+		case strings.HasPrefix(a, "--env="):
+			env, setEnv = strings.TrimPrefix(a, "--env="), true
+		default:
+			fmt.Fprintln(os.Stderr, "Usage: veto sync [--global] [--env <name>|none]")
+			os.Exit(1)
+		}
+	}
+	if !config.Exists() {
+		fail(errors.New("No .veto file found (run: veto init)"))
+	}
+	if setEnv {
+		if err := selectEnv(env); err != nil {
+			fail(err)
+		}
+	}
+	agents := agent.DetectInstalled()
+	if root, err := agent.ProjectRoot(); err == nil {
+		// DetectInstalled leaves out custom agents that don't load
+		if _, err := agent.LoadCustom(root); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Custom agents: %v\n", err)
+		}
+	}
+	if len(agents) == 0 {
+		fail(errors.New("No agents detected"))
+	}
+	e := events.Event{Name: events.OnSync, Scope: string(scope)}
+	doc := output.Sync{Scope: string(scope), Agents: []output.Synced{}}
+	if setEnv {
+		doc.Environment = env
+	}
+	for _, a := range agents {
+		synced := output.Synced{ID: a.ID, Name: a.Name}
+		if err := agent.Install(a.ID, scope); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", a.Name, err)
+			e.Failed = append(e.Failed, a.ID)
+			synced.Error = err.Error()
+		} else {
+			fmt.Printf("✓ %s (%s)\n", a.Name, scope)
+			e.Agents = append(e.Agents, a.ID)
+		}
+		doc.Agents = append(doc.Agents, synced)
+	}
+	if jsonOutput {
+		printJSON(doc)
+	}
+	if len(e.Agents) == 0 {
+		os.Exit(1)
+	}
+	fireEvent(e)
+}
+
+// selectEnv records env as the project's environment ("none" to clear
+// it), once its overlay is known to load, and compiles the overlay's
+// phrases.
+func selectEnv(env string) error {
+	path, err := config.Find()
+	if err != nil {
+		return err
+	}
+	root := filepath.Dir(path)
+	if env == "none" {
+		if err := project.SetEnv(root, ""); err != nil {
+			return err
+		}
+		fmt.Println("✓ Environment: none (.veto alone)")
+		return nil
+	}
+	o, _, err := project.LoadOverlay(root, env)
+	if err != nil {
+		return err
+	}
+	if err := project.SetEnv(root, env); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Environment: %s %s\n", env, dimStyle.Render(fmt.Sprintf("(+%d policies from %s)", len(o.Policies)+len(o.Rules), filepath.Base(project.OverlayPath(root, env)))))
+	if other := os.Getenv(project.EnvVar); other != "" && other != env {
+		fmt.Printf("! %s=%s overrides it in this shell\n", project.EnvVar, other)
+	}
+	compilePhrases(o.Policies)
+	return nil
+}
+
+// runInstall handles `veto install <agent>`. Installing a custom agent
+// globally writes outside the project, so it needs --trust, or a yes at
+// the terminal, the first time and whenever its definition changes.
+func runInstall(args []string) {
+	scope, args := scopeFlag(args)
+	trust := slices.Contains(args, "--trust")
+	args = slices.DeleteFunc(args, func(a string) bool { return a == "--trust" })
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto install <agent> [--scope project|global] [--trust]")
+		os.Exit(1)
+	}
+	if args[0] == "git" {
+		installGitHook()
+		return
+	}
+	err := agent.Install(args[0], scope)
+	var untrusted *agent.UntrustedError
+	if errors.As(err, &untrusted) && (trust || trusts(untrusted)) {
+		if err = agent.Trust(args[0]); err == nil {
+			err = agent.Install(args[0], scope)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Installed: %s (%s)\n", args[0], scope)
+}
+
+// trusts asks whoever is at the terminal whether to let a custom agent
+// from the repository write to its configDir. Without a terminal it
+// doesn't.
+func trusts(e *agent.UntrustedError) bool {
+	if !interactive() {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "! %s defines %s, which would write %s to %s\n", e.Source, e.Agent, strings.Join(e.Files, ", "), e.Dir)
+	fmt.Fprint(os.Stderr, "  Trust it? [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// runUninstall handles `veto uninstall <agent>`.
+func runUninstall(args []string) {
+	scope, args := scopeFlag(args)
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto uninstall <agent> [--scope project|global]")
+		os.Exit(1)
+	}
+	if args[0] == "git" {
+		uninstallGitHook()
+		return
+	}
+	r, err := agent.Uninstall(args[0], scope)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Uninstalled: %s (%s)\n", args[0], scope)
+	if r.Empty() {
+		fmt.Println(dimStyle.Render("  nothing of veto's was installed"))
+	}
+	for _, path := range r.Removed {
+		fmt.Printf("  removed %s\n", tildePath(path))
+	}
+	for _, path := range r.Reverted {
+		fmt.Printf("  reverted %s %s\n", tildePath(path), dimStyle.Render("(your settings kept)"))
+	}
+}
+
+// installGitHook handles `veto install git`, writing the pre-commit hook
+// that runs `veto verify --staged` in the current project's repository.
+func installGitHook() {
+	root, err := agent.ProjectRoot()
+	if err == nil {
+		var path string
+		if path, err = githook.Install(root); err == nil {
+			fmt.Printf("✓ Installed: git pre-commit hook %s\n", dimStyle.Render(tildePath(path)))
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+	os.Exit(1)
+}
+
+// uninstallGitHook handles `veto uninstall git`.
+func uninstallGitHook() {
+	root, err := agent.ProjectRoot()
+	if err == nil {
+		var path string
+		if path, err = githook.Uninstall(root); err == nil {
+			fmt.Printf("✓ Uninstalled: git pre-commit hook\n  removed %s\n", tildePath(path))
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+	os.Exit(1)
+}
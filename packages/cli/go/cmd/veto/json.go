@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/VulnZap/veto/internal/output"
+)
+
+// jsonOutput is set by the global --json flag: the commands in
+// jsonCommands print an output document instead of styled text.
+var jsonOutput bool
+
+// jsonCommands are the commands --json works with.
+var jsonCommands = map[string]bool{
+	"list": true, "status": true, "sync": true, "add": true, "audit": true, "check": true,
+}
+
+// stdout is where output documents are printed. With --json, os.Stdout
+// is pointed at stderr, so progress lines and hints can't get into the
+// document.
+var stdout io.Writer = os.Stdout
+
+// jsonFlag removes --json from args, wherever it is before a "--", and
+// reports whether it was there.
+func jsonFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for i, a := range args {
+		if a == "--" {
+			return append(rest, args[i:]...), found
+		}
+		if a == "--json" {
+			found = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	return rest, found
+}
+
+// printJSON prints an output document.
+func printJSON(v interface{}) {
+	output.Print(stdout, v)
+}
+
+// fail reports err and exits 1. With --json the error is printed as an
+// output.Error document too, so scripts always get one.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+	if jsonOutput {
+		printJSON(output.Error{Error: err.Error()})
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/packs"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runLint handles `veto lint`, reporting every policy pattern that isn't
+// RE2-safe along with a suggested rewrite, and running the tests listed
+// in .veto against the policies and each pack's tests against its own.
+func runLint(args []string) {
+	path, err := config.Find()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := 0
+	lf, err := lock.Read(lock.Path(filepath.Dir(path)))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("✗ %s\n    %v\n", lock.FileName, err)
+		problems++
+		lf = nil
+	}
+
+	policies, err := project.CompileWithPacks(cfg, lf, filepath.Dir(path))
+	if err != nil {
+		fmt.Printf("✗ packs\n    %v\n", err)
+		problems++
+		policies = project.Compile(cfg, lf)
+	}
+	for _, p := range policies {
+		errs := matcher.Validate(p)
+		if _, err := matcher.New(p); err != nil && len(errs) == 0 {
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			fmt.Printf("✓ %s\n", p.Description)
+			continue
+		}
+		fmt.Printf("✗ %s\n", p.Description)
+		for _, err := range errs {
+			var pe *matcher.PatternError
+			if errors.As(err, &pe) {
+				fmt.Printf("    %s: %s\n", pe.Pattern, pe.Problem)
+				if pe.Suggestion != "" {
+					fmt.Printf("    Try: %s\n", pe.Suggestion)
+				}
+			} else {
+				fmt.Printf("    %v\n", err)
+			}
+			problems++
+		}
+	}
+
+	if len(cfg.Tests) > 0 {
+		problems += runPolicyTests("", policies, cfg.Tests)
+	}
+	// A broken pack was reported above
+	loaded, _ := packs.Load(filepath.Dir(path), cfg.Packs)
+	for _, pack := range loaded {
+		if len(pack.Config.Tests) > 0 {
+			problems += runPolicyTests(pack.Name, project.Compile(pack.Config, lf), pack.Config.Tests)
+		}
+	}
+
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "\n✗ %d problem(s)\n", problems)
+		os.Exit(1)
+	}
+}
+
+// runPolicyTests checks tests against the compiled policies, printing
+// each failure, and returns the number of failures. pack names the pack
+// the tests come from, "" for the project's.
+func runPolicyTests(pack string, policies []*policy.Policy, tests []policy.Test) int {
+	if pack != "" {
+		pack += ": "
+	}
+	set, err := matcher.NewSet(policies)
+	if err != nil {
+		fmt.Printf("✗ %s%d test(s) not run: %v\n", pack, len(tests), err)
+		return 1
+	}
+	failures := set.RunTests(tests)
+	if len(failures) == 0 {
+		fmt.Printf("✓ %s%d test(s) passed\n", pack, len(tests))
+		return 0
+	}
+	for _, f := range failures {
+		by := ""
+		if f.Policy != "" {
+			by = " (" + f.Policy + ")"
+		}
+		fmt.Printf("✗ %s%s: expected %s, got %s%s\n", pack, f.Test.Name(), f.Test.Expect, f.Got, by)
+	}
+	return len(failures)
+}
+
+// runLock handles `veto lock`, pinning the compiled form of every policy
+// in .veto.lock. Patterns that aren't RE2-safe are rejected. Policies
+// registered with `veto lock add` have no phrase to recompile and keep
+// their pinned form.
+func runLock(args []string) {
+	if len(args) > 0 && args[0] == "add" {
+		runLockAdd(args[1:])
+		return
+	}
+
+	path, err := config.Find()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	lockPath := lock.Path(filepath.Dir(path))
+	previous, _ := lock.Read(lockPath)
+	entries := lockEntries(cfg, previous)
+	if err := lock.Write(lockPath, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		fmt.Fprintln(os.Stderr, "  Run: veto lint")
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Locked %d policies in %s\n", len(entries), lock.FileName)
+}
+
+// lockEntries compiles every policy in cfg for the lock file, keeping
+// the entries previous (which may be nil) registered with veto lock add.
+func lockEntries(cfg *config.VetoConfig, previous *lock.File) []lock.Entry {
+	registered := make(map[string]lock.Entry)
+	if previous != nil {
+		for _, e := range previous.Policies {
+			if e.Registered {
+				registered[e.Source] = e
+			}
+		}
+	}
+
+	// Rules follow the phrases and are written out already; only the
+	// phrases need pinning
+	compiled := project.Compile(cfg, nil)
+	var entries []lock.Entry
+	for i, phrase := range cfg.Policies {
+		e, ok := registered[phrase]
+		if !ok {
+			e = lock.Entry{Source: phrase, Policy: compiled[i]}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// runLockAdd handles `veto lock add <file.json>`, registering a policy
+// authored by a tool: either a policy object or a {"source", "policy"}
+// lock entry, read from the file or stdin ("-"). The source phrase
+// defaults to the policy's description.
+func runLockAdd(args []string) {
+	fs := flag.NewFlagSet("lock add", flag.ExitOnError)
+	source := fs.String("source", "", "phrase to register the policy under in .veto")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto lock add [--source phrase] <policy.json | ->")
+		os.Exit(1)
+	}
+
+	var data []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	var entry lock.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ invalid policy JSON: %v\n", err)
+		os.Exit(1)
+	}
+	if entry.Policy == nil {
+		entry.Policy = &policy.Policy{}
+		if err := json.Unmarshal(data, entry.Policy); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ invalid policy JSON: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *source != "" {
+		entry.Source = *source
+	}
+	if entry.Source == "" {
+		entry.Source = entry.Policy.Description
+	}
+
+	if err := project.Register(entry.Source, entry.Policy); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Registered %q in %s\n", entry.Source, lock.FileName)
+	fireEvent(events.Event{Name: events.OnPolicyAdded, Policy: entry.Source})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/output"
+)
+
+// runList handles `veto list`: the project's phrases, marking those that
+// name builtins, then its rules.
+func runList() {
+	doc := output.List{Policies: []output.Policy{}}
+	if !config.Exists() {
+		if jsonOutput {
+			printJSON(doc)
+			return
+		}
+		fmt.Println("No .veto file. Run: veto init")
+		return
+	}
+	path, _ := config.Find()
+	cfg, err := config.Load(path)
+	if err != nil {
+		fail(err)
+	}
+	doc.Config = path
+	for _, p := range cfg.Policies {
+		kind := output.KindPhrase
+		if builtin.Find(p) != nil {
+			kind = output.KindBuiltin
+		}
+		doc.Policies = append(doc.Policies, output.Policy{Name: p, Kind: kind})
+	}
+	for _, r := range cfg.Rules {
+		doc.Policies = append(doc.Policies, output.Policy{Name: r.Description, Kind: output.KindRule})
+	}
+	if jsonOutput {
+		printJSON(doc)
+		return
+	}
+
+	if len(doc.Policies) == 0 {
+		fmt.Println("No policies")
+		return
+	}
+	for _, p := range doc.Policies {
+		switch p.Kind {
+		case output.KindBuiltin:
+			fmt.Printf(" ⚡ %s\n", p.Name)
+		case output.KindRule:
+			fmt.Printf("   %s %s\n", p.Name, dimStyle.Render("(rule)"))
+		default:
+			fmt.Printf("   %s\n", p.Name)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// recordDecision appends a decision to the audit log of the project the
+// request came from. Requests outside any project aren't recorded.
+func recordDecision(req *policy.CheckRequest, result *policy.CheckResult) {
+	path, err := project.Find(req.Cwd)
+	if err != nil || path == project.MachinePath() {
+		return
+	}
+	if err := audit.Append(filepath.Dir(path), audit.NewEntry(req, result, time.Now())); err != nil {
+		fmt.Fprintf(os.Stderr, "! audit log: %v\n", err)
+	}
+}
+
+// runLog handles `veto log`, listing the project's recorded decisions,
+// newest last, filtered by agent, policy and time range. --effectiveness
+// reports how agents responded to each policy's blocks instead.
+func runLog(args []string) {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	agentID := fs.String("agent", "", "only requests from this agent")
+	policyName := fs.String("policy", "", "only decisions by policies whose description contains this")
+	since := fs.String("since", "", "only after this time: 24h, 7d, 2006-01-02 or RFC 3339")
+	until := fs.String("until", "", "only before this time")
+	blocked := fs.Bool("blocked", false, "only requests that weren't allowed")
+	limit := fs.Int("n", 50, "show at most this many of the most recent entries (0 for all)")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines")
+	effect := fs.Bool("effectiveness", false, "report per policy whether agents took the suggested alternative after a block")
+	fs.Parse(args)
+
+	path, err := config.Find()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	f := audit.Filter{Agent: *agentID, Policy: *policyName, Blocked: *blocked}
+	now := time.Now()
+	for _, t := range []struct {
+		value string
+		dest  *time.Time
+	}{{*since, &f.Since}, {*until, &f.Until}} {
+		if t.value == "" {
+			continue
+		}
+		if *t.dest, err = audit.ParseTime(t.value, now); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *effect {
+		// Responses to blocks are the requests after them, allowed or not
+		f.Blocked = false
+	}
+	entries, err := audit.Read(filepath.Dir(path), f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if *effect {
+		printEffectiveness(audit.Effectiveness(entries), *asJSON)
+		return
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			enc.Encode(e)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("No recorded decisions")
+		return
+	}
+	for _, e := range entries {
+		mark := "✓"
+		if !e.Allowed {
+			mark = "✗"
+		}
+		subject := e.Command
+		if subject == "" {
+			subject = e.File
+		}
+		agentName := e.Agent
+		if agentName == "" {
+			agentName = "-"
+		}
+		fmt.Printf("%s %s %-6s %-12s %-7s %s", dimStyle.Render(e.Time.Local().Format("2006-01-02 15:04:05")), mark, e.Decision, agentName, e.Action, subject)
+		if e.Policy != "" {
+			fmt.Print(dimStyle.Render("  (" + e.Policy + ")"))
+		}
+		fmt.Println()
+	}
+}
+
+// printEffectiveness prints how agents responded to each policy's blocks,
+// and points out suggestions agents tend to ignore.
+func printEffectiveness(effects []audit.Effect, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range effects {
+			enc.Encode(e)
+		}
+		return
+	}
+	if len(effects) == 0 {
+		fmt.Println("No blocks in agent sessions recorded")
+		return
+	}
+
+	fmt.Printf("%-40s %6s %10s %8s %10s\n", "POLICY", "BLOCKS", "COMPLIANT", "RETRIED", "TOOK ALT")
+	var notes []string
+	for _, e := range effects {
+		took := "-"
+		if e.Suggested > 0 {
+			took = fmt.Sprintf("%d/%d", e.Followed, e.Suggested)
+		}
+		fmt.Printf("%-40.40s %6d %9.0f%% %8d %10s\n", e.Policy, e.Blocks, 100*e.Compliance(), e.Retried, took)
+
+		// Too few blocks say little either way
+		switch {
+		case e.Suggested >= 3 && e.Adoption() < 0.34:
+			notes = append(notes, fmt.Sprintf("! %s: agents took the suggested command after %d of %d blocks; try rewording its suggestion", e.Policy, e.Followed, e.Suggested))
+		case e.Blocks >= 3 && e.Compliance() < 0.5:
+			notes = append(notes, fmt.Sprintf("! %s: agents ran into it again after %d of %d blocks; its reason or suggestion may not say what to do instead", e.Policy, e.Retried, e.Blocks))
+		}
+	}
+	fmt.Println()
+	fmt.Println(dimStyle.Render("Compliant: not blocked again by the policy within the session's next few requests."))
+	if len(notes) > 0 {
+		fmt.Println()
+		for _, n := range notes {
+			fmt.Println(n)
+		}
+	}
+}
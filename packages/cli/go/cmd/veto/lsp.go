@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/VulnZap/veto/internal/lsp"
+)
+
+// runLSP handles `veto lsp`, the language server an editor extension
+// starts to mark content rule violations as you type.
+func runLSP(args []string) {
+	if err := lsp.NewServer(os.Stdin, os.Stdout, version).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+}
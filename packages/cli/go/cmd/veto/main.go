@@ -3,27 +3,29 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 	"os"
-	"os/exec"
-	"runtime"
+	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/VulnZap/veto/internal/agent"
 	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/changelog"
 	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/crash"
 	"github.com/VulnZap/veto/internal/engine"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/update"
+	"github.com/VulnZap/veto/internal/workspace"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-const version = "3.0.0"
-
 // ══════════════════════════════════════════════════════════════════════════════
 // VETO BRANDING
 // ══════════════════════════════════════════════════════════════════════════════
@@ -155,6 +157,8 @@ const (
 	viewHelp
 	viewWelcome
 	viewUpdate
+	viewPlayground
+	viewProjects
 )
 
 type model struct {
@@ -170,6 +174,7 @@ type model struct {
 	quitting      bool
 
 	// Data
+	project  string // root of the project shown, "" outside one
 	policies []string
 	agents   []agent.Agent
 
@@ -177,7 +182,13 @@ type model struct {
 	message     string
 	messageType string // success, error, info
 	showWelcome bool
-	updateAvail string // new version if available
+	whatsNew    []changelog.Release // unseen releases since the last upgrade
+	tour        tour
+	playground  playground
+	picker      picker
+	updateAvail string          // new version if available
+	release     *update.Release // release behind updateAvail
+	updating    bool
 
 	// Components
 	input   textinput.Model
@@ -200,28 +211,25 @@ func newModel() model {
 	sp.Spinner = spinner.Dot
 	sp.Style = orangeStyle
 
-	// Load data
-	var policies []string
-	if config.Exists() {
-		if path, _ := config.Find(); path != "" {
-			if cfg, _ := config.Load(path); cfg != nil {
-				policies = cfg.Policies
-			}
-		}
+	m := model{
+		view:     viewDashboard,
+		whatsNew: pendingWhatsNew(),
+		input:    ti,
+		spinner:  sp,
 	}
-	agents := agent.DetectInstalled()
-
-	// Check if first run
-	showWelcome := !config.Exists() && len(agents) > 0
-
-	return model{
-		view:        viewDashboard,
-		policies:    policies,
-		agents:      agents,
-		showWelcome: showWelcome,
-		input:       ti,
-		spinner:     sp,
+	m.reload()
+
+	// Outside a project, pick one of those opened before; with none, this
+	// is a first run
+	switch {
+	case m.project != "":
+	case len(workspace.Recent()) > 0:
+		m.view = viewProjects
+		m.picker.loading = true
+	default:
+		m.showWelcome = len(m.agents) > 0
 	}
+	return m
 }
 
 // ══════════════════════════════════════════════════════════════════════════════
@@ -229,10 +237,11 @@ func newModel() model {
 // ══════════════════════════════════════════════════════════════════════════════
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(
-		textinput.Blink,
-		checkForUpdate,
-	)
+	cmds := []tea.Cmd{textinput.Blink, checkForUpdate}
+	if m.view == viewProjects {
+		cmds = append(cmds, loadProjects)
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -250,13 +259,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "enter", "y":
 				m.showWelcome = false
-				return m, runInit()
+				m.startTour()
+				return m, nil
 			case "n", "esc", "q":
 				m.showWelcome = false
 			}
 			return m, nil
 		}
 
+		if m.tour.active {
+			return m.updateTour(msg)
+		}
+
+		// What's new panel: any key dismisses it for good
+		if len(m.whatsNew) > 0 {
+			m.whatsNew = nil
+			markWhatsNewSeen()
+			return m, nil
+		}
+
 		// Text input mode
 		if m.view == viewAddPolicy && m.input.Focused() {
 			switch msg.String() {
@@ -280,6 +301,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.view == viewPlayground {
+			return m.updatePlayground(msg)
+		}
+		if m.view == viewProjects {
+			return m.updatePicker(msg)
+		}
+
+		// Update confirmation
+		if m.view == viewUpdate {
+			switch msg.String() {
+			case "enter", "y":
+				if !m.updating {
+					m.updating = true
+					return m, tea.Batch(m.spinner.Tick, runUpdate(m.release))
+				}
+			case "esc", "n", "q":
+				if !m.updating {
+					m.view = m.previousView
+				}
+			}
+			return m, nil
+		}
+
 		// Global keys
 		switch msg.String() {
 		case "q", "ctrl+c":
@@ -357,36 +401,45 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.handleEnter()
 		case "i":
 			return m, runInit()
+		case "t":
+			m.startTour()
+		case "p":
+			return m, m.openPlayground()
+		case "o":
+			return m, m.openPicker()
 		case "s":
 			return m, runSync()
 		case "u":
 			if m.updateAvail != "" {
-				return m, runUpdate()
+				m.previousView = m.view
+				m.view = viewUpdate
 			}
 		case "r":
-			// Refresh
-			m.agents = agent.DetectInstalled()
-			if config.Exists() {
-				if path, _ := config.Find(); path != "" {
-					if cfg, _ := config.Load(path); cfg != nil {
-						m.policies = cfg.Policies
-					}
-				}
-			}
+			m.reload()
 			m.message = "Refreshed"
 			m.messageType = "info"
 		}
 
 	// Messages
+	case projectsLoadedMsg:
+		m.picker = picker{repos: msg.repos}
+		if m.selectedIndex >= len(msg.repos) {
+			m.selectedIndex = 0
+		}
+
 	case updateCheckMsg:
-		if msg.newVersion != "" && msg.newVersion != version {
-			m.updateAvail = msg.newVersion
+		if msg.release != nil && update.Newer(msg.release.Version, version) {
+			m.updateAvail = msg.release.Version
+			m.release = msg.release
 		}
 
 	case policyCompiledMsg:
 		if msg.err != nil {
 			m.message = msg.err.Error()
 			m.messageType = "error"
+		} else if dup, _ := builtin.Duplicate(msg.policy, m.policies); dup != "" {
+			m.message = "Already covered by: " + dup
+			m.messageType = "error"
 		} else {
 			if err := config.AddPolicy(msg.policy); err != nil {
 				m.message = err.Error()
@@ -395,6 +448,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.policies = append(m.policies, msg.policy)
 				m.message = "Added: " + msg.policy
 				m.messageType = "success"
+				cmds = append(cmds, fireHooks(events.Event{Name: events.OnPolicyAdded, Policy: msg.policy}))
 			}
 		}
 		m.view = m.previousView
@@ -406,15 +460,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.message = "Initialized .veto"
 			m.messageType = "success"
-			// Reload
-			if path, _ := config.Find(); path != "" {
-				if cfg, _ := config.Load(path); cfg != nil {
-					m.policies = cfg.Policies
-				}
-			}
+			m.reload()
 		}
 
 	case syncDoneMsg:
+		if m.tour.active && m.tour.step == tourSync {
+			m.tour.syncing = false
+			m.tour.syncErr = msg.err
+			m.tour.synced = msg.count
+			if msg.err == nil {
+				m.advanceTour()
+			}
+			return m, nil
+		}
 		if msg.err != nil {
 			m.message = msg.err.Error()
 			m.messageType = "error"
@@ -426,6 +484,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.messageType = "success"
 		}
 
+	case hooksDoneMsg:
+		if msg.err != nil {
+			m.message = "Hook failed: " + msg.err.Error()
+			m.messageType = "error"
+		}
+
 	case policyDeletedMsg:
 		if msg.err != nil {
 			m.message = msg.err.Error()
@@ -443,6 +507,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case updateDoneMsg:
+		m.updating = false
+		if m.view == viewUpdate {
+			m.view = viewDashboard
+		}
 		if msg.err != nil {
 			m.message = "Update failed: " + msg.err.Error()
 			m.messageType = "error"
@@ -453,7 +521,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case spinner.TickMsg:
-		if m.view == viewCompiling {
+		if m.view == viewCompiling || m.updating || m.tour.syncing {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
@@ -470,6 +538,8 @@ func (m *model) navigateDown() {
 		max = len(m.policies)
 	case viewAgents:
 		max = len(m.agents)
+	case viewProjects:
+		max = len(m.picker.repos)
 	case viewDashboard:
 		max = 2 // policies, agents
 	}
@@ -485,6 +555,8 @@ func (m *model) navigateUp() {
 		max = len(m.policies)
 	case viewAgents:
 		max = len(m.agents)
+	case viewProjects:
+		max = len(m.picker.repos)
 	case viewDashboard:
 		max = 2
 	}
@@ -542,6 +614,12 @@ func (m model) View() string {
 	if m.showWelcome {
 		return m.renderWelcome()
 	}
+	if m.tour.active {
+		return m.renderTour()
+	}
+	if len(m.whatsNew) > 0 {
+		return m.renderWhatsNew()
+	}
 
 	// Build layout
 	header := m.renderHeader()
@@ -600,6 +678,12 @@ func (m model) renderContent() string {
 		content = m.renderCompiling()
 	case viewHelp:
 		content = m.renderHelp()
+	case viewUpdate:
+		content = m.renderUpdate()
+	case viewPlayground:
+		content = m.renderPlayground()
+	case viewProjects:
+		content = m.renderPicker()
 	}
 
 	// Center content
@@ -617,6 +701,12 @@ func (m model) renderDashboard() string {
 
 	cards := lipgloss.JoinHorizontal(lipgloss.Top, policyCard, "  ", agentCard)
 
+	// Which project the numbers are for
+	name := mutedStyle.Render("No project here · ") + keyStyle.Render("o") + mutedStyle.Render(" to open one")
+	if m.project != "" {
+		name = keyDescStyle.Render(filepath.Base(m.project)) + mutedStyle.Render("  "+tildePath(filepath.Dir(m.project)))
+	}
+
 	// Message
 	var msgView string
 	if m.message != "" {
@@ -637,6 +727,8 @@ func (m model) renderDashboard() string {
 
 	return lipgloss.JoinVertical(
 		lipgloss.Center,
+		name,
+		"",
 		cards,
 		msgView,
 		"\n",
@@ -663,6 +755,8 @@ func (m model) renderQuickActions() string {
 		{"a", "add"},
 		{"i", "init"},
 		{"s", "sync"},
+		{"p", "playground"},
+		{"o", "projects"},
 		{"?", "help"},
 		{"q", "quit"},
 	}
@@ -749,6 +843,8 @@ func (m model) renderAgents() string {
 		)
 	}
 
+	root, _ := agent.ProjectRoot()
+
 	var rows []string
 	for i, a := range m.agents {
 		prefix := "  "
@@ -760,6 +856,13 @@ func (m model) renderAgents() string {
 		}
 
 		status := successStyle.Render("●")
+		scopes := agent.SyncedScopes(&m.agents[i], root)
+		if len(scopes) == 0 {
+			status = mutedStyle.Render("○ not synced")
+		}
+		for _, scope := range scopes {
+			status += " " + tagStyle.Render(string(scope))
+		}
 		rows = append(rows, prefix+style.Render(a.Name)+" "+status)
 	}
 
@@ -826,6 +929,9 @@ func (m model) renderHelp() string {
   ` + keyStyle.Render("a") + `      ` + keyDescStyle.Render("Add policy") + `
   ` + keyStyle.Render("d/x") + `    ` + keyDescStyle.Render("Delete selected") + `
   ` + keyStyle.Render("i") + `      ` + keyDescStyle.Render("Initialize .veto") + `
+  ` + keyStyle.Render("t") + `      ` + keyDescStyle.Render("Onboarding tour") + `
+  ` + keyStyle.Render("p") + `      ` + keyDescStyle.Render("Policy playground") + `
+  ` + keyStyle.Render("o") + `      ` + keyDescStyle.Render("Switch project") + `
   ` + keyStyle.Render("s") + `      ` + keyDescStyle.Render("Sync to all agents") + `
   ` + keyStyle.Render("r") + `      ` + keyDescStyle.Render("Refresh") + `
   ` + keyStyle.Render("q") + `      ` + keyDescStyle.Render("Quit") + `
@@ -843,6 +949,84 @@ func (m model) renderHelp() string {
 	)
 }
 
+// maxNotesLines caps how much of the release notes the update view shows.
+const maxNotesLines = 12
+
+func (m model) renderUpdate() string {
+	width := min(64, m.width-4)
+
+	title := "Update to v" + m.updateAvail
+	if m.release != nil && m.release.Prerelease {
+		title += " " + tagStyle.Render("beta")
+	}
+
+	notes := mutedStyle.Render("No release notes published.")
+	if m.release != nil && m.release.Notes != "" {
+		lines := strings.Split(m.release.Notes, "\n")
+		if len(lines) > maxNotesLines {
+			lines = append(lines[:maxNotesLines], "…")
+		}
+		notes = strings.Join(lines, "\n")
+	}
+
+	footer := keyStyle.Render("enter") + " update   " + keyStyle.Render("esc") + " cancel"
+	if m.updating {
+		footer = m.spinner.View() + " Updating..."
+	}
+
+	rows := []string{
+		panelHeaderStyle.Render(title),
+		mutedStyle.Render("v" + version + " → v" + m.updateAvail),
+		"",
+		notes,
+		"",
+	}
+	if m.release != nil && m.release.URL != "" {
+		rows = append(rows, dimStyle.Render(m.release.URL), "")
+	}
+	rows = append(rows, footer)
+
+	return panelActiveStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+func (m model) renderWhatsNew() string {
+	width := min(64, m.width-4)
+
+	var rows []string
+	for _, r := range m.whatsNew {
+		rows = append(rows, orangeStyle.Render("v"+r.Version))
+		for _, s := range []struct {
+			title string
+			items []string
+		}{
+			{"Breaking changes", r.Breaking},
+			{"New builtins", r.Builtins},
+			{"New agents", r.Agents},
+			{"Improvements", r.Changes},
+		} {
+			if len(s.items) == 0 {
+				continue
+			}
+			rows = append(rows, "  "+titleStyle.Render(s.title))
+			for _, item := range s.items {
+				rows = append(rows, "  "+mutedStyle.Render("• "+item))
+			}
+		}
+		rows = append(rows, "")
+	}
+	rows = append(rows, dimStyle.Render("veto changelog for the full history"), "", keyStyle.Render("any key")+" continue")
+
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		panelActiveStyle.Width(width).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				append([]string{panelHeaderStyle.Render("What's new"), ""}, rows...)...,
+			),
+		),
+	)
+}
+
 func (m model) renderWelcome() string {
 	width := min(55, m.width-4)
 
@@ -860,8 +1044,8 @@ Detected ` + orangeStyle.Render(fmt.Sprintf("%d", len(m.agents))) + ` AI agents
 VETO lets you set policies that your AI agents
 must follow - like "no lodash" or "protect .env".
 
-Would you like to create a .veto config file
-with recommended defaults?
+Take a one-minute tour to pick a template,
+add your first rule and see a block in action?
 `
 
 	return lipgloss.Place(
@@ -879,6 +1063,12 @@ with recommended defaults?
 
 func (m model) renderStatusBar() string {
 	left := mutedStyle.Render("veto")
+	if m.project != "" {
+		left += mutedStyle.Render(" · ") + keyDescStyle.Render(tildePath(m.project))
+	}
+	if readonly.Enabled() {
+		left += mutedStyle.Render(" · ") + orangeStyle.Render("read-only")
+	}
 
 	var viewName string
 	switch m.view {
@@ -892,6 +1082,12 @@ func (m model) renderStatusBar() string {
 		viewName = "help"
 	case viewAddPolicy:
 		viewName = "add"
+	case viewUpdate:
+		viewName = "update"
+	case viewPlayground:
+		viewName = "playground"
+	case viewProjects:
+		viewName = "projects"
 	}
 
 	right := mutedStyle.Render(viewName)
@@ -924,8 +1120,9 @@ type policyDeletedMsg struct {
 	index int
 	err   error
 }
-type updateCheckMsg struct{ newVersion string }
+type updateCheckMsg struct{ release *update.Release }
 type updateDoneMsg struct{ err error }
+type hooksDoneMsg struct{ err error }
 type agentSyncedMsg struct {
 	name string
 	err  error
@@ -936,7 +1133,12 @@ func compilePolicy(policy string) tea.Cmd {
 		if builtin.Find(policy) != nil {
 			return policyCompiledMsg{policy: policy}
 		}
-		return policyCompiledMsg{policy: policy}
+		// Compiled now so enforcement finds it in the cache
+		c, err := newCompiler()
+		if err == nil {
+			_, err = c.Compile(policy)
+		}
+		return policyCompiledMsg{policy: policy, err: err}
 	}
 }
 
@@ -963,28 +1165,39 @@ func runSync() tea.Cmd {
 			return syncDoneMsg{err: fmt.Errorf("no agents detected")}
 		}
 
-		count := 0
+		e := events.Event{Name: events.OnSync, Scope: string(agent.ScopeProject)}
 		var lastErr error
 		for _, a := range agents {
-			if err := agent.Install(a.ID); err != nil {
+			if err := agent.Install(a.ID, agent.ScopeProject); err != nil {
 				lastErr = err
+				e.Failed = append(e.Failed, a.ID)
 			} else {
-				count++
+				e.Agents = append(e.Agents, a.ID)
 			}
 		}
 
 		// If nothing synced, report the error
-		if count == 0 && lastErr != nil {
+		if len(e.Agents) == 0 && lastErr != nil {
 			return syncDoneMsg{err: lastErr}
 		}
+		if err := fireProject(e); err != nil {
+			return syncDoneMsg{err: fmt.Errorf("synced to %d agent(s), but a hook failed: %w", len(e.Agents), err)}
+		}
 
-		return syncDoneMsg{count: count}
+		return syncDoneMsg{count: len(e.Agents)}
+	}
+}
+
+// fireHooks runs the current project's hooks for e off the UI loop.
+func fireHooks(e events.Event) tea.Cmd {
+	return func() tea.Msg {
+		return hooksDoneMsg{fireProject(e)}
 	}
 }
 
 func syncAgent(id string) tea.Cmd {
 	return func() tea.Msg {
-		err := agent.Install(id)
+		err := agent.Install(id, agent.ScopeProject)
 		if err == nil {
 			return syncDoneMsg{count: 1}
 		}
@@ -993,34 +1206,26 @@ func syncAgent(id string) tea.Cmd {
 }
 
 func checkForUpdate() tea.Msg {
-	client := &http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get("https://registry.npmjs.org/veto-cli/latest")
+	release, err := updateChecker().Latest(false)
 	if err != nil {
 		return updateCheckMsg{}
 	}
-	defer resp.Body.Close()
+	return updateCheckMsg{release: release}
+}
 
-	var data struct {
-		Version string `json:"version"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return updateCheckMsg{}
+func runUpdate(release *update.Release) tea.Cmd {
+	return func() tea.Msg {
+		return updateDoneMsg{err: updateChecker().Apply(release)}
 	}
-
-	return updateCheckMsg{newVersion: data.Version}
 }
 
-func runUpdate() tea.Cmd {
-	return func() tea.Msg {
-		var cmd *exec.Cmd
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("npm", "install", "-g", "veto-cli@latest")
-		} else {
-			cmd = exec.Command("npm", "install", "-g", "veto-cli@latest")
-		}
-		err := cmd.Run()
-		return updateDoneMsg{err: err}
+// updateChecker returns a checker for the user's release channel.
+func updateChecker() *update.Checker {
+	var channel string
+	if s, err := settings.Load(); err == nil {
+		channel = s.Channel
 	}
+	return update.NewChecker(channel)
 }
 
 func min(a, b int) int {
@@ -1036,14 +1241,43 @@ func min(a, b int) int {
 
 func main() {
 	args := os.Args[1:]
+	defer crash.Handle(version, commit, args)
+
+	if len(args) > 0 && args[0] == "--read-only" {
+		readonly.Enable()
+		args = args[1:]
+	}
+	if s, err := settings.Load(); err == nil && s.ReadOnly {
+		readonly.Enable()
+	}
+	args, jsonOutput = jsonFlag(args)
+	if jsonOutput {
+		if len(args) == 0 || !jsonCommands[args[0]] {
+			fmt.Fprintln(os.Stderr, "✗ --json works with list, status, sync, add, audit and check")
+			os.Exit(1)
+		}
+		stdout, os.Stdout = os.Stdout, os.Stderr
+	}
 
 	// No args = TUI
-	if len(args) == 0 {
+	if len(args) == 0 || args[0] == "tour" {
+		// The TUI owns the terminal; its status bar says it's read-only
+		readonly.Out = io.Discard
+		m := newModel()
+		if len(args) > 0 {
+			m.showWelcome = false
+			m.whatsNew = nil
+			m.startTour()
+		}
+		var report *crash.Report
 		p := tea.NewProgram(
-			newModel(),
+			crashGuard{m, &report},
 			tea.WithAltScreen(),
 		)
 		if _, err := p.Run(); err != nil {
+			if report != nil {
+				crash.Exit(report)
+			}
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -1051,9 +1285,12 @@ func main() {
 	}
 
 	// CLI
+	if readonly.Enabled() && args[0] != "hook" && args[0] != "lsp" && args[0] != "mcp" {
+		fmt.Fprintln(os.Stderr, dimStyle.Render("Read-only: showing what would change on disk without changing it"))
+	}
 	switch args[0] {
 	case "--version", "-v":
-		fmt.Printf("veto v%s\n", version)
+		printVersion()
 
 	case "--help", "-h", "help":
 		printHelp()
@@ -1070,125 +1307,34 @@ func main() {
 		fmt.Println("✓ Created .veto")
 
 	case "add":
-		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "Usage: veto add \"policy\"")
-			os.Exit(1)
-		}
-		policy := strings.Join(args[1:], " ")
-
-		if builtin.Find(policy) != nil {
-			if err := config.AddPolicy(policy); err != nil {
-				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Printf("✓ Added: %s (builtin)\n", policy)
-			return
-		}
-
-		bridge, err := engine.NewBridge()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
+		runAdd(args[1:])
 
-		fmt.Println("Compiling...")
-		result, err := bridge.Compile(policy)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-
-		if !result.Success {
-			fmt.Fprintf(os.Stderr, "✗ %s\n", result.Error)
-			os.Exit(1)
-		}
-
-		if err := config.AddPolicy(policy); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("✓ Added: %s\n", policy)
+	case "builtins":
+		runBuiltins(args[1:])
 
 	case "list":
-		if !config.Exists() {
-			fmt.Println("No .veto file. Run: veto init")
-			return
-		}
-		path, _ := config.Find()
-		cfg, err := config.Load(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		if len(cfg.Policies) == 0 {
-			fmt.Println("No policies")
-			return
-		}
-		for _, p := range cfg.Policies {
-			mark := " "
-			if builtin.Find(p) != nil {
-				mark = "⚡"
-			}
-			fmt.Printf(" %s %s\n", mark, p)
-		}
+		runList()
 
 	case "status":
-		agents := agent.DetectInstalled()
-		fmt.Printf("Agents: %d\n", len(agents))
-		for _, a := range agents {
-			fmt.Printf("  ● %s\n", a.Name)
-		}
-		if config.Exists() {
-			path, _ := config.Find()
-			cfg, _ := config.Load(path)
-			fmt.Printf("Policies: %d\n", len(cfg.Policies))
-		}
+		runStatus(args[1:])
+
+	case "repos":
+		runRepos(args[1:])
 
 	case "sync":
-		if !config.Exists() {
-			fmt.Fprintln(os.Stderr, "✗ No .veto file found")
-			fmt.Fprintln(os.Stderr, "  Run: veto init")
-			os.Exit(1)
-		}
-		agents := agent.DetectInstalled()
-		if len(agents) == 0 {
-			fmt.Fprintln(os.Stderr, "✗ No agents detected")
-			os.Exit(1)
-		}
-		synced := 0
-		for _, a := range agents {
-			if err := agent.Install(a.ID); err != nil {
-				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", a.Name, err)
-			} else {
-				fmt.Printf("✓ %s\n", a.Name)
-				synced++
-			}
-		}
-		if synced == 0 {
-			os.Exit(1)
-		}
+		runSyncCmd(args[1:])
+
+	case "watch":
+		runWatch(args[1:])
 
 	case "install":
-		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "Usage: veto install <agent>")
-			os.Exit(1)
-		}
-		if err := agent.Install(args[1]); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("✓ Installed: %s\n", args[1])
+		runInstall(args[1:])
 
 	case "uninstall":
-		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "Usage: veto uninstall <agent>")
-			os.Exit(1)
-		}
-		if err := agent.Uninstall(args[1]); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("✓ Uninstalled: %s\n", args[1])
+		runUninstall(args[1:])
+
+	case "gc":
+		runGC(args[1:])
 
 	case "explain":
 		if len(args) < 2 {
@@ -1205,27 +1351,80 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "baseline":
+		runBaseline(args[1:])
+
 	case "audit":
-		bridge, err := engine.NewBridge()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		if err := bridge.Audit(args[1:]); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
+		runAudit(args[1:])
+
+	case "check":
+		runCheck(args[1:])
+
+	case "hook":
+		runHook(args[1:])
+
+	case "run":
+		runRun(args[1:])
+
+	case "verify":
+		runVerify(args[1:])
+
+	case "match":
+		runMatch(args[1:])
+
+	case "match-cmd":
+		runMatchCmd(args[1:])
+
+	case "daemon":
+		runDaemon(args[1:])
+
+	case "lint":
+		runLint(args[1:])
+
+	case "lock":
+		runLock(args[1:])
+
+	case "diff":
+		runDiff(args[1:])
+
+	case "lsp":
+		runLSP(args[1:])
+
+	case "mcp":
+		runMCP(args[1:])
+
+	case "notify":
+		runNotify(args[1:])
+
+	case "focus":
+		runFocus(args[1:])
+
+	case "doctor":
+		runDoctor(args[1:])
+
+	case "pack":
+		runPack(args[1:])
+
+	case "import":
+		runImport(args[1:])
+
+	case "bug-report":
+		runBugReport(args[1:])
+
+	case "log":
+		runLog(args[1:])
+
+	case "coverage":
+		runCoverage(args[1:])
+
+	case "redteam":
+		runRedteam(args[1:])
 
 	case "update":
-		fmt.Println("Updating...")
-		cmd := exec.Command("npm", "install", "-g", "veto-cli@latest")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Println("✓ Updated! Run 'veto --version' to verify")
+		runUpdateCmd(args[1:])
+
+	case "changelog":
+		runChangelog(args[1:])
 
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown: %s\nRun: veto --help\n", args[0])
@@ -1239,12 +1438,51 @@ func printHelp() {
 
 ` + orangeStyle.Render("USAGE") + `
   veto                     Dashboard (TUI)
-  veto add "policy"        Add a policy
+  veto tour                Guided setup: template, first rule, demo, sync
+  veto add "policy"        Add a policy, showing what it would flag (--monitor)
   veto list                List policies
-  veto sync                Sync to all agents  
-  veto status              Show status
-  veto install <agent>     Install hooks
-  veto update              Update to latest version
+  veto builtins [label]    List builtins by category (security, style, ...)
+  veto sync [--global]     Sync to all agents (project config unless --global)
+  veto sync --env <name>   Apply the .veto.<name>.yaml overlay here (none to stop)
+  veto watch [--global]    Sync to all agents whenever .veto changes
+  veto status [--diff]     Show agents, enforcement and out-of-date syncs
+  veto repos               Overview of every project under ~/code (add|remove <dir>)
+  veto install <agent>     Install hooks (--scope project|global)
+  veto install git         Install a git pre-commit hook running veto verify --staged
+  veto gc [--dry-run]      Remove global agent configs left by other projects
+  veto check [flags]       Check a file or command against policies
+  veto hook [--agent id]   Answer an agent hook payload read from stdin
+  veto run -- <command>    Check a command and run it, or its approved replacement (-c as a shell)
+  veto log [flags]         Show recorded decisions (--agent, --policy, --since, --blocked)
+  veto log --effectiveness Show whether agents take each policy's suggestion after a block
+  veto audit [dir]         Scan the repository for content policies would block (--format json)
+  veto verify --staged     Check staged changes against file patterns and content rules
+  veto verify [dir]        Check the working tree for CI (--format sarif, --severity high)
+  veto baseline [update]   Accept existing violations so only new ones are flagged (stats: burn-down)
+  veto match <path>        Explain which policies match a file
+  veto match-cmd <cmd>     Explain which policies match a command
+  veto daemon <cmd>        Run or inspect the policy daemon
+  veto lint                Check policy patterns are RE2-safe
+  veto lock                Pin compiled policies in .veto.lock
+  veto lock add <file>     Register a policy built by a tool, as JSON
+  veto diff [--markdown]   Show how compiled policies changed since .veto.lock
+  veto lsp                 Serve policy diagnostics to editors over stdio
+  veto mcp                 Serve the policies for files to agents over MCP
+  veto coverage            Score policies against common risks and list gaps
+  veto redteam             Fire known attacks through enforcement and report leaks
+  veto update [flags]      Update to latest version (--check, --channel)
+  veto doctor              Test network access through the proxy (--ca-bundle)
+  veto bug-report          Bundle anonymized diagnostics and crash reports for an issue
+  veto import <source>     Import a policy pack (file, URL, git+URL or owner/pack[@version])
+  veto pack bundle         Package veto and compiled policies for offline machines
+  veto pack load <file>    Verify and install an offline bundle
+  veto pack publish [file] Publish a policy pack to the registry
+  veto pack search [query] Find policy packs in the registry
+  veto notify [on|off]     Desktop notifications for blocks (--severity, --asks, --quiet)
+  veto focus [on|off]      Only notify for critical blocks until turned off
+  veto changelog [version] Show what changed in each release
+  veto --read-only <cmd>   Print what a command would write without changing anything
+  veto <cmd> --json        Print JSON for scripts (list, status, sync, add, audit, check)
 
 ` + orangeStyle.Render("AGENTS") + `
   cc, claude-code    Claude Code
@@ -1252,6 +1490,10 @@ func printHelp() {
   cursor             Cursor
   windsurf           Windsurf  
   aider              Aider
+  copilot            GitHub Copilot (VS Code, Copilot CLI)
+  goose              Goose
+  q, amazon-q        Amazon Q Developer CLI
+  <id>               Custom agents defined in .veto.d/agents/*.yaml
 
 ` + orangeStyle.Render("EXAMPLES") + `
   veto add "no lodash"
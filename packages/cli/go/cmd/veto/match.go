@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runMatch handles `veto match <path>`, printing how every policy's
+// include and exclude globs evaluate the path.
+func runMatch(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto match <path>")
+		os.Exit(1)
+	}
+	p, cwd := loadProjectForMatch()
+
+	target := args[0]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(cwd, target)
+	}
+	rel, err := filepath.Rel(p.Root, target)
+	if err != nil {
+		rel = target
+	}
+	rel = filepath.ToSlash(rel)
+
+	fmt.Printf("Path: %s\n\n", rel)
+	for _, m := range p.Set.Matchers() {
+		pol := m.Policy()
+		// A nested .veto's policies see paths from its directory
+		path, ok := rel, true
+		if pol.Dir != "" {
+			path, ok = matcher.InDir(rel, pol.Dir)
+		}
+		if !ok {
+			fmt.Printf("%s %s\n    dir:     outside %s\n", matchMark(false), pol.Description, pol.Dir)
+			continue
+		}
+		e := m.ExplainFile(path)
+
+		fmt.Printf("%s %s\n", matchMark(e.Blocked), pol.Description)
+		switch {
+		case len(pol.Include) == 0:
+			fmt.Println("    include: (no file patterns)")
+		case e.Include == "":
+			fmt.Println("    include: no match")
+		default:
+			fmt.Printf("    include: %s\n", e.Include)
+		}
+		if e.Exclude != "" {
+			fmt.Printf("    exclude: %s (rescued)\n", e.Exclude)
+		}
+		if e.Blocked && m.Allowed(&policy.CheckRequest{Target: path}) {
+			fmt.Println("    allow:   matched an allow rule")
+		}
+	}
+
+	printFinal(p.Check(&policy.CheckRequest{Action: string(policy.ActionModify), Target: rel}))
+}
+
+// runMatchCmd handles `veto match-cmd <command>`, printing which command
+// rule of every policy matches the command.
+func runMatchCmd(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto match-cmd <command>")
+		os.Exit(1)
+	}
+	p, cwd := loadProjectForMatch()
+	cmd := strings.Join(args, " ")
+
+	fmt.Printf("Command: %s\n\n", cmd)
+	for _, m := range p.Set.Matchers() {
+		pol := m.Policy()
+		e := m.ExplainCommand(cmd)
+
+		fmt.Printf("%s %s\n", matchMark(e.Rule != nil || e.EnvRule != nil), pol.Description)
+		switch {
+		case len(pol.CommandRules) == 0:
+			fmt.Println("    rules:   (no command rules)")
+		case e.Rule == nil:
+			fmt.Println("    rules:   no match")
+		default:
+			fmt.Printf("    rules:   %s → %s\n", e.Pattern, e.Rule.Reason)
+		}
+		if e.EnvRule != nil {
+			fmt.Printf("    env:     exposes %s → %s\n", e.Env, e.EnvRule.Reason)
+		}
+		if (e.Rule != nil || e.EnvRule != nil) && m.Allowed(&policy.CheckRequest{Command: cmd, Cwd: relDir(p, cwd)}) {
+			fmt.Println("    allow:   matched an allow rule")
+		}
+	}
+
+	printFinal(p.Check(&policy.CheckRequest{Action: string(policy.ActionExecute), Command: cmd, Cwd: cwd}))
+}
+
+func loadProjectForMatch() (*project.Project, string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	p, err := project.Resolve(cwd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+	return p, cwd
+}
+
+func relDir(p *project.Project, dir string) string {
+	rel, err := filepath.Rel(p.Root, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
+
+func matchMark(matched bool) string {
+	if matched {
+		return "✗"
+	}
+	return "·"
+}
+
+func printFinal(result *policy.CheckResult) {
+	fmt.Println()
+	if result.Allowed {
+		fmt.Print("Decision: allowed")
+	} else {
+		fmt.Print("Decision: blocked")
+	}
+	if result.Decision != "" {
+		fmt.Printf(" (%s by %q)", result.Decision, result.Policy)
+	}
+	fmt.Println()
+}
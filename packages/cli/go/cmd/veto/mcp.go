@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/VulnZap/veto/internal/mcp"
+)
+
+// runMCP handles `veto mcp`, the MCP server an agent starts to fetch the
+// policies that concern the files it's working on.
+func runMCP(args []string) {
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if err := mcp.NewServer(os.Stdin, os.Stdout, dir, version).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VulnZap/veto/internal/notify"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/settings"
+)
+
+// runNotify handles `veto notify [on|off|test]`, configuring desktop
+// notifications for blocks and approval requests raised while an agent
+// runs, and the quiet hours when only critical blocks notify.
+func runNotify(args []string) {
+	sub := ""
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	severity := fs.String("severity", "", "lowest severity of block that notifies: low, medium, high or critical")
+	asks := fs.Bool("asks", true, "notify when a request waits for approval")
+	quiet := fs.String("quiet", "", `daily quiet hours when only critical blocks notify, like 22:00-08:00 ("off" to clear)`)
+	fs.Parse(args)
+
+	s, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	switch sub {
+	case "on":
+		// Keep earlier choices; only the flags given change them
+		n := &settings.Notifications{Asks: true}
+		if s.Notify != nil {
+			n = s.Notify
+		}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "severity":
+				n.Severity = policy.Severity(*severity)
+			case "asks":
+				n.Asks = *asks
+			case "quiet":
+				n.Quiet = *quiet
+			}
+		})
+		if n.Severity != "" && n.Severity.Rank() == 0 {
+			fmt.Fprintf(os.Stderr, "✗ Unknown severity %q (want low, medium, high or critical)\n", n.Severity)
+			os.Exit(1)
+		}
+		if n.Quiet == "off" {
+			n.Quiet = ""
+		}
+		if n.Quiet != "" {
+			if _, _, err := notify.ParseQuiet(n.Quiet); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+				os.Exit(1)
+			}
+		}
+		s.Notify = n
+	case "off":
+		s.Notify = nil
+	case "test":
+		if err := notify.Send("veto", "Notifications are working"); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Sent a test notification")
+		return
+	case "":
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: veto notify [on|off|test] [--severity s] [--asks=false] [--quiet HH:MM-HH:MM]")
+		os.Exit(1)
+	}
+
+	if sub != "" {
+		if err := s.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+	}
+	printNotifyStatus(s.Notify)
+}
+
+// printNotifyStatus describes the notification settings.
+func printNotifyStatus(n *settings.Notifications) {
+	if n == nil {
+		fmt.Println("● Desktop notifications are off")
+		return
+	}
+	threshold := n.Severity
+	if threshold == "" {
+		threshold = notify.DefaultSeverity
+	}
+	fmt.Printf("✓ Notifying for %s-severity blocks and above", threshold)
+	if n.Asks {
+		fmt.Print(", and approval requests")
+	}
+	fmt.Println()
+	if n.Quiet != "" {
+		fmt.Printf("  Quiet hours %s: critical blocks only\n", n.Quiet)
+	}
+	if n.Focus {
+		fmt.Println("  Focus is on: critical blocks only (veto focus off)")
+	}
+}
+
+// runFocus handles `veto focus [on|off]`, quickly holding notifications
+// to critical blocks and letting them through again.
+func runFocus(args []string) {
+	s, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if s.Notify == nil {
+		fmt.Println("● Desktop notifications are off; turn them on with: veto notify on")
+		return
+	}
+
+	switch {
+	case len(args) == 0:
+	case args[0] == "on" || args[0] == "off":
+		s.Notify.Focus = args[0] == "on"
+		if err := s.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: veto focus [on|off]")
+		os.Exit(1)
+	}
+	printNotifyStatus(s.Notify)
+}
+
+// notifyResult raises a desktop notification for a result when the user
+// turned them on and it is serious enough. Failures are ignored: a
+// missing notifier must never get in the way of the agent.
+func notifyResult(req *policy.CheckRequest, result *policy.CheckResult) {
+	s, err := settings.Load()
+	if err != nil || !notify.Wants(s.Notify, result, time.Now()) {
+		return
+	}
+	notify.Send(notify.Message(req, result))
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/bundle"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/packs"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/registry"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// runPack handles `veto pack <cmd>`.
+func runPack(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: veto pack bundle [--output file] | veto pack load <file> | veto pack publish [file] | veto pack search [query]")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "bundle":
+		runPackBundle(args[1:])
+	case "load":
+		runPackLoad(args[1:])
+	case "publish":
+		runPackPublish(args[1:])
+	case "search":
+		runPackSearch(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "✗ Unknown pack command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// runPackBundle handles `veto pack bundle`, writing an offline bundle for
+// air-gapped machines: this veto binary and, inside a project, its .veto
+// with a freshly compiled .veto.lock.
+func runPackBundle(args []string) {
+	fs := flag.NewFlagSet("pack bundle", flag.ExitOnError)
+	output := fs.String("output", "veto-offline.tar", "bundle file to write")
+	binary := fs.String("binary", "", "veto binary to bundle (default: this one)")
+	fs.Parse(args)
+
+	entries, err := bundleEntries(*binary)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	m := bundle.Manifest{Version: version, Platform: update.AssetName(), CreatedAt: time.Now().UTC()}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, m, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Wrote %s (%s)\n", *output, m.Platform)
+	for _, e := range entries {
+		fmt.Printf("  %s\n", dimStyle.Render(e.Name))
+	}
+	fmt.Printf("\n  On the offline machine: veto pack load %s\n", filepath.Base(*output))
+}
+
+// bundleEntries collects the files for an offline bundle.
+func bundleEntries(binary string) ([]bundle.Entry, error) {
+	if binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		binary = exe
+	}
+	data, err := os.ReadFile(binary)
+	if err != nil {
+		return nil, err
+	}
+	entries := []bundle.Entry{{Name: bundle.BinaryDir + binaryName(), Data: data, Mode: 0755}}
+
+	path, err := config.Find()
+	if err != nil {
+		return entries, nil
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Compile here so the offline machine doesn't have to
+	previous, _ := lock.Read(lock.Path(filepath.Dir(path)))
+	locked, err := lock.Marshal(lockEntries(cfg, previous))
+	if err != nil {
+		return nil, fmt.Errorf("%v\n  Run: veto lint", err)
+	}
+	return append(entries,
+		bundle.Entry{Name: bundle.ProjectDir + ".veto", Data: source, Mode: 0644},
+		bundle.Entry{Name: bundle.ProjectDir + lock.FileName, Data: locked, Mode: 0644},
+	), nil
+}
+
+// runPackLoad handles `veto pack load <file>`, verifying an offline bundle
+// and installing it without touching the network: the binary replaces
+// this one (or goes in --bin-dir) and the project files go in the current
+// directory.
+func runPackLoad(args []string) {
+	fs := flag.NewFlagSet("pack load", flag.ExitOnError)
+	binDir := fs.String("bin-dir", "", "install the binary here instead of replacing this one")
+	force := fs.Bool("force", false, "overwrite a .veto or .veto.lock that differs from the bundle's")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto pack load [--bin-dir dir] [--force] <file>")
+		os.Exit(1)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	m, files, err := bundle.Read(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Verified %d files (veto %s, %s)\n", len(m.Files), m.Version, m.Platform)
+
+	if err := loadBinary(files, m, *binDir); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadProject(files, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadBinary installs a bundled binary, skipping one built for another
+// platform.
+func loadBinary(files map[string][]byte, m *bundle.Manifest, binDir string) error {
+	if m.Platform != update.AssetName() {
+		fmt.Printf("● Skipped the binary: built for %s, this machine needs %s\n", m.Platform, update.AssetName())
+		return nil
+	}
+	data, ok := files[bundle.BinaryDir+binaryName()]
+	if !ok {
+		fmt.Println("● No binary in the bundle")
+		return nil
+	}
+
+	var dest string
+	if binDir != "" {
+		if err := readonly.MkdirAll(binDir, 0755); err != nil {
+			return err
+		}
+		dest = filepath.Join(binDir, binaryName())
+	} else {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dest = exe
+	}
+	if current, err := os.ReadFile(dest); err == nil && bytes.Equal(current, data) {
+		fmt.Printf("● %s is already veto %s\n", dest, m.Version)
+		return nil
+	}
+	if err := update.ReplaceExecutable(bytes.NewReader(data), dest); err != nil {
+		return fmt.Errorf("install %s: %w", dest, err)
+	}
+	fmt.Printf("✓ Installed veto %s to %s\n", m.Version, dest)
+	return nil
+}
+
+// loadProject writes a bundle's .veto and .veto.lock to the current
+// directory, leaving files that differ alone unless force is set.
+func loadProject(files map[string][]byte, force bool) error {
+	source, ok := files[bundle.ProjectDir+".veto"]
+	if !ok {
+		return nil
+	}
+	locked := files[bundle.ProjectDir+lock.FileName]
+	if locked != nil {
+		if _, err := lock.Parse(locked); err != nil {
+			return err
+		}
+	}
+
+	type file struct {
+		name string
+		data []byte
+	}
+	var writes []file
+	for _, f := range []file{{".veto", source}, {lock.FileName, locked}} {
+		if f.data == nil {
+			continue
+		}
+		current, err := os.ReadFile(f.name)
+		if err == nil && bytes.Equal(current, f.data) {
+			fmt.Printf("● %s is up to date\n", f.name)
+			continue
+		}
+		if err == nil && !force {
+			return fmt.Errorf("%s differs from the bundle's; rerun with --force to replace it", f.name)
+		}
+		writes = append(writes, f)
+	}
+	// Check both before writing either, so a refusal leaves the pair intact
+	for _, f := range writes {
+		if err := readonly.WriteFile(f.name, f.data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %s\n", f.name)
+	}
+	return nil
+}
+
+// runPackPublish handles `veto pack publish [file]`, uploading a policy
+// pack (veto-pack.yaml by default) to the registry under the name and
+// packVersion it declares.
+func runPackPublish(args []string) {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: veto pack publish [file]")
+		os.Exit(1)
+	}
+	file := packs.DefaultFile
+	if len(args) == 1 {
+		file = args[0]
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	pack, err := packs.Parse(file, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if pack.Name == file || pack.Version == "" {
+		fmt.Fprintf(os.Stderr, "✗ %s needs a name: (owner/pack) and a packVersion: (like 1.0.0) to publish\n", file)
+		os.Exit(1)
+	}
+
+	if err := registry.New().Publish(pack.Name, pack.Version, data); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		if errors.Is(err, registry.ErrExists) {
+			fmt.Fprintln(os.Stderr, "  Published versions can't change; bump packVersion.")
+		}
+		os.Exit(1)
+	}
+	ref := pack.Name + "@" + pack.Version
+	fmt.Printf("✓ Published %s %s\n", ref, dimStyle.Render(fmt.Sprintf("(%d phrase(s), %d rule(s))", len(pack.Config.Policies), len(pack.Config.Rules))))
+	fmt.Printf("\n  Import it with: veto import %s\n", ref)
+}
+
+// runPackSearch handles `veto pack search [query]`, listing the registry's
+// packs that match.
+func runPackSearch(args []string) {
+	query := strings.Join(args, " ")
+	results, err := registry.New().Search(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No packs match %q\n", query)
+		return
+	}
+	for _, r := range results {
+		fmt.Printf("  %-28s %-10s %s\n", r.Name, r.Latest, dimStyle.Render(r.Description))
+	}
+	fmt.Printf("\n  Import one with: veto import <name>[@version]\n")
+}
+
+// binaryName is the veto executable's file name on this platform.
+func binaryName() string {
+	if runtime.GOOS == "windows" {
+		return "veto.exe"
+	}
+	return "veto"
+}
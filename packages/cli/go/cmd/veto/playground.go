@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ══════════════════════════════════════════════════════════════════════════════
+// POLICY PLAYGROUND
+// ══════════════════════════════════════════════════════════════════════════════
+
+// playground is a sandbox that checks hypothetical commands and file edits
+// against the current policies as the user types.
+type playground struct {
+	fileMode bool // checking a file edit rather than a command
+	focus    int  // file mode: 0 = path, 1 = content
+	command  textinput.Model
+	path     textinput.Model
+	content  textarea.Model
+	set      *matcher.Set
+	source   string // where the policies came from
+	err      error
+}
+
+func newPlayground() playground {
+	cmd := textinput.New()
+	cmd.Placeholder = "npm install lodash"
+	cmd.Prompt = "$ "
+	cmd.PromptStyle = orangeStyle
+	cmd.CharLimit = 500
+	cmd.Width = 50
+
+	path := textinput.New()
+	path.Placeholder = "src/index.ts"
+	path.Prompt = "file: "
+	path.PromptStyle = orangeStyle
+	path.CharLimit = 200
+	path.Width = 44
+
+	content := textarea.New()
+	content.Placeholder = "paste file content..."
+	content.SetWidth(50)
+	content.SetHeight(6)
+	content.ShowLineNumbers = false
+
+	return playground{command: cmd, path: path, content: content}
+}
+
+// openPlayground loads the current project's policies and focuses the
+// command field.
+func (m *model) openPlayground() tea.Cmd {
+	m.previousView = m.view
+	m.view = viewPlayground
+	m.playground = newPlayground()
+	m.playground.set, m.playground.source, m.playground.err = loadPlaygroundSet(m.policies)
+	m.playground.command.Focus()
+	return textinput.Blink
+}
+
+// loadPlaygroundSet compiles the .veto governing the working directory,
+// falling back to the policies shown in the dashboard.
+func loadPlaygroundSet(policies []string) (*matcher.Set, string, error) {
+	if cwd, err := os.Getwd(); err == nil {
+		if p, err := project.Resolve(cwd); err == nil {
+			return p.Set, p.ConfigPath, nil
+		} else if config.Exists() {
+			return nil, "", err
+		}
+	}
+	set, err := matcher.NewSet(project.Compile(&config.VetoConfig{Policies: policies}, nil))
+	return set, "dashboard policies", err
+}
+
+func (m model) updatePlayground(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pg := &m.playground
+	switch msg.String() {
+	case "esc":
+		m.view = m.previousView
+		return m, nil
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "ctrl+t":
+		pg.fileMode = !pg.fileMode
+		pg.focus = 0
+		return m, pg.refocus()
+	case "tab", "shift+tab":
+		if pg.fileMode {
+			pg.focus = 1 - pg.focus
+			return m, pg.refocus()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch {
+	case !pg.fileMode:
+		pg.command, cmd = pg.command.Update(msg)
+	case pg.focus == 0:
+		pg.path, cmd = pg.path.Update(msg)
+	default:
+		pg.content, cmd = pg.content.Update(msg)
+	}
+	return m, cmd
+}
+
+// refocus moves the cursor to the active field.
+func (pg *playground) refocus() tea.Cmd {
+	pg.command.Blur()
+	pg.path.Blur()
+	pg.content.Blur()
+	switch {
+	case !pg.fileMode:
+		return pg.command.Focus()
+	case pg.focus == 0:
+		return pg.path.Focus()
+	default:
+		return pg.content.Focus()
+	}
+}
+
+// request builds the check request for the current input, or nil when
+// there is nothing to check yet.
+func (pg playground) request() *policy.CheckRequest {
+	if !pg.fileMode {
+		cmd := strings.TrimSpace(pg.command.Value())
+		if cmd == "" {
+			return nil
+		}
+		return &policy.CheckRequest{Action: string(policy.ActionExecute), Command: cmd}
+	}
+	path := strings.TrimSpace(pg.path.Value())
+	if path == "" {
+		return nil
+	}
+	return &policy.CheckRequest{Action: string(policy.ActionModify), Target: path, Content: pg.content.Value()}
+}
+
+func (m model) renderPlayground() string {
+	pg := m.playground
+	width := min(64, m.width-4)
+
+	mode := keyStyle.Render("command") + mutedStyle.Render(" · file")
+	if pg.fileMode {
+		mode = mutedStyle.Render("command · ") + keyStyle.Render("file")
+	}
+
+	rows := []string{panelHeaderStyle.Render("Playground"), mode, ""}
+	if pg.fileMode {
+		rows = append(rows, pg.path.View(), "", pg.content.View())
+	} else {
+		rows = append(rows, pg.command.View())
+	}
+	rows = append(rows, "", m.renderPlaygroundResult())
+
+	help := "ctrl+t command/file • esc back"
+	if pg.fileMode {
+		help = "tab path/content • " + help
+	}
+	rows = append(rows, "", mutedStyle.Render(help))
+	if pg.source != "" {
+		rows = append(rows, dimStyle.Render("policies: "+pg.source))
+	}
+
+	return panelActiveStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// renderPlaygroundResult shows the final decision and every policy that
+// matched, in the order the conflict resolution considered them.
+func (m model) renderPlaygroundResult() string {
+	pg := m.playground
+	if pg.err != nil {
+		return errorStyle.Render("✗ " + pg.err.Error())
+	}
+	req := pg.request()
+	if req == nil || pg.set == nil {
+		return mutedStyle.Render("Type something to see which policies would block it.")
+	}
+	if pg.set.Len() == 0 {
+		return mutedStyle.Render("No policies yet - press esc, then a to add one.")
+	}
+
+	result := pg.set.Check(req)
+	var lines []string
+	switch {
+	case result.Allowed && result.Decision == policy.DecisionWarn:
+		lines = append(lines, orangeStyle.Render("! Warning: "+result.Reason))
+	case result.Allowed:
+		lines = append(lines, successStyle.Render("✓ Allowed"))
+	case result.Decision == policy.DecisionAsk:
+		lines = append(lines, orangeStyle.Render("? Approval required: "+result.Reason))
+	default:
+		lines = append(lines, errorStyle.Render("✗ Blocked: "+result.Reason))
+	}
+	if result.Suggest != "" {
+		lines = append(lines, mutedStyle.Render("  Try: "+result.Suggest))
+	}
+
+	for _, step := range result.Trace {
+		mark := mutedStyle.Render("·")
+		if step.Outcome == policy.OutcomeApplied {
+			mark = orangeStyle.Render("▸")
+		}
+		outcome := string(step.Outcome)
+		if step.Rule != "" {
+			outcome += ", " + step.Rule
+		}
+		line := fmt.Sprintf("%s %s %s", mark, step.Policy, dimStyle.Render("("+outcome+")"))
+		lines = append(lines, line)
+		if step.Reason != "" && step.Reason != step.Policy {
+			lines = append(lines, "    "+mutedStyle.Render(step.Reason))
+		}
+	}
+	for _, hit := range result.Monitored {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("~ %s would %s (monitor)", hit.Policy, hit.Decision)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/workspace"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ══════════════════════════════════════════════════════════════════════════════
+// PROJECT PICKER
+// ══════════════════════════════════════════════════════════════════════════════
+
+// pickerBlocks is how far back the picker counts each project's blocks.
+const pickerBlocks = 7 * 24 * time.Hour
+
+// picker lists the projects the TUI can switch to: those opened recently,
+// then the rest under the workspace roots.
+type picker struct {
+	repos   []workspace.Repo
+	loading bool
+}
+
+type projectsLoadedMsg struct{ repos []workspace.Repo }
+
+// loadProjects finds and sums up the projects to pick from. Walking the
+// workspace roots can take a moment, so it runs off the UI loop.
+func loadProjects() tea.Msg {
+	roots := workspace.Recent()
+	for _, root := range workspace.Find(workspaceRoots(), workspace.MaxDepth) {
+		if !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	since := time.Now().Add(-pickerBlocks)
+	repos := make([]workspace.Repo, len(roots))
+	for i, root := range roots {
+		repos[i] = workspace.Summarize(root, since)
+	}
+	return projectsLoadedMsg{repos}
+}
+
+// currentProject is the directory of the .veto governing the working
+// directory, or "" outside a project.
+func currentProject() string {
+	if path, err := config.Find(); err == nil {
+		return filepath.Dir(path)
+	}
+	return ""
+}
+
+// openPicker shows the project picker.
+func (m *model) openPicker() tea.Cmd {
+	if m.view != viewProjects {
+		m.previousView = m.view
+	}
+	m.view = viewProjects
+	m.selectedIndex = 0
+	m.picker.loading = true
+	return loadProjects
+}
+
+func (m model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.view = viewDashboard
+		m.selectedIndex = 0
+	case "j", "down":
+		m.navigateDown()
+	case "k", "up":
+		m.navigateUp()
+	case "enter", " ":
+		if m.selectedIndex < len(m.picker.repos) {
+			m.switchProject(m.picker.repos[m.selectedIndex].Root)
+		}
+	case "r":
+		return m, m.openPicker()
+	}
+	return m, nil
+}
+
+// switchProject makes root the working directory, so every view and
+// action applies to it, and reloads the dashboard.
+func (m *model) switchProject(root string) {
+	if err := os.Chdir(root); err != nil {
+		m.message = err.Error()
+		m.messageType = "error"
+		return
+	}
+	m.reload()
+	m.view = viewDashboard
+	m.selectedIndex = 0
+	m.message = "Switched to " + tildePath(root)
+	m.messageType = "info"
+}
+
+// reload reads the current project's policies and the installed agents
+// again, and remembers the project for the picker.
+func (m *model) reload() {
+	m.policies = nil
+	if path, err := config.Find(); err == nil {
+		if cfg, _ := config.Load(path); cfg != nil {
+			m.policies = cfg.Policies
+		}
+	}
+	m.agents = agent.DetectInstalled()
+	m.project = currentProject()
+	if m.project != "" {
+		workspace.Remember(m.project)
+	}
+}
+
+func (m model) renderPicker() string {
+	width := min(72, m.width-4)
+	header := panelHeaderStyle.Render("Projects")
+
+	switch {
+	case m.picker.loading:
+		return panelActiveStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left,
+			header, "", mutedStyle.Render("Looking for projects...")))
+	case len(m.picker.repos) == 0:
+		return panelActiveStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left,
+			header, "",
+			mutedStyle.Render("No .veto projects found under "+strings.Join(tildePaths(workspaceRoots()), ", ")),
+			mutedStyle.Render("Add where yours live: veto repos add <dir>"),
+			"", mutedStyle.Render("esc back")))
+	}
+
+	nameWidth := 0
+	for _, r := range m.picker.repos {
+		nameWidth = max(nameWidth, len(tildePath(r.Root)))
+	}
+	nameWidth = min(nameWidth, width-34)
+
+	var rows []string
+	for i, r := range m.picker.repos {
+		prefix := "  "
+		style := itemStyle
+		if i == m.selectedIndex {
+			prefix = orangeStyle.Render("▸ ")
+			style = itemSelectedStyle
+		}
+		name := tildePath(r.Root)
+		if len(name) > nameWidth {
+			name = "…" + name[len(name)-nameWidth+1:]
+		}
+		current := " "
+		if r.Root == m.project {
+			current = successStyle.Render("●")
+		}
+
+		detail := fmt.Sprintf("%d policies", r.Policies)
+		if r.Policies == 1 {
+			detail = "1 policy"
+		}
+		if r.Error != "" {
+			detail = errorStyle.Render("invalid .veto")
+		}
+		switch {
+		case len(r.Agents) == 0:
+			detail += mutedStyle.Render(" · not synced")
+		case r.Stale:
+			detail += orangeStyle.Render(" · stale")
+		}
+		if r.Blocks > 0 {
+			detail += tagStyle.Render(fmt.Sprintf(" · %d blocks", r.Blocks))
+		}
+		rows = append(rows, prefix+current+" "+style.Render(fmt.Sprintf("%-*s", nameWidth, name))+"  "+detail)
+	}
+
+	help := mutedStyle.Render("↑↓ navigate • enter open • r rescan • esc back")
+	return panelActiveStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left,
+		header, "", strings.Join(rows, "\n"), "", help,
+		dimStyle.Render("blocks in the last 7 days")))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/daemon"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/redteam"
+)
+
+// runRedteam handles `veto redteam`, firing the attack corpus through the
+// same path agent hooks use and reporting what slipped through. Exits 1
+// when any attack was allowed.
+func runRedteam(args []string) {
+	p, cwd := loadProjectForMatch()
+
+	via := "in-process (no daemon running)"
+	if c, err := daemon.Dial(); err == nil {
+		c.Close()
+		via = "the daemon"
+	}
+	fmt.Printf("Red-teaming %s via %s\n\n", p.ConfigPath, via)
+
+	mode := config.EnvMode()
+	outcomes := redteam.Run(cwd, func(req *policy.CheckRequest) (*policy.CheckResult, error) {
+		req.Mode = mode
+		req.Agent = "redteam"
+		return checkRequest(req)
+	})
+
+	slipped := 0
+	category := ""
+	for _, o := range outcomes {
+		if o.Attack.Category != category {
+			category = o.Attack.Category
+			fmt.Printf("%s\n", category)
+		}
+		subject := o.Attack.Request.Command
+		if subject == "" {
+			subject = o.Attack.Request.Action + " " + o.Attack.Request.Target
+		}
+		switch {
+		case o.Err != nil:
+			slipped++
+			fmt.Printf("  ✗ %-36s error: %v\n", o.Attack.Description, o.Err)
+		case o.Blocked:
+			fmt.Printf("  ✓ %-36s blocked\n", o.Attack.Description)
+		default:
+			slipped++
+			fmt.Printf("  ✗ %-36s SLIPPED THROUGH: %s\n", o.Attack.Description, subject)
+			fmt.Printf("    counter: veto add %q\n", o.Attack.Counter)
+		}
+	}
+
+	fmt.Println()
+	if slipped > 0 {
+		fmt.Printf("✗ %d of %d attacks slipped through\n", slipped, len(outcomes))
+		os.Exit(1)
+	}
+	fmt.Printf("✓ All %d attacks blocked\n", len(outcomes))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/workspace"
+)
+
+// runRepos handles `veto repos`, a table of every veto project under the
+// user's workspace roots: its policies, which agents are synced and
+// whether that's out of date, and recent blocks. `veto repos add|remove
+// <dir>` changes the roots, which default to ~/code.
+func runRepos(args []string) {
+	if len(args) > 0 && (args[0] == "add" || args[0] == "remove") {
+		editWorkspaces(args[0], args[1:])
+		return
+	}
+	fs := flag.NewFlagSet("repos", flag.ExitOnError)
+	since := fs.String("since", "7d", "count blocks after this time: 24h, 7d, 2006-01-02 or RFC 3339")
+	depth := fs.Int("depth", workspace.MaxDepth, "how many directories below a root to look for projects")
+	asJSON := fs.Bool("json", false, "print one JSON object per project")
+	fs.Parse(args)
+
+	from, err := audit.ParseTime(*since, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	roots := fs.Args()
+	if len(roots) == 0 {
+		roots = workspaceRoots()
+	}
+
+	var repos []workspace.Repo
+	for _, root := range workspace.Find(roots, *depth) {
+		repos = append(repos, workspace.Summarize(root, from))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range repos {
+			enc.Encode(r)
+		}
+		return
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No .veto projects under %s\n", strings.Join(tildePaths(roots), ", "))
+		fmt.Println(dimStyle.Render("  Add where your projects live: veto repos add <dir>"))
+		return
+	}
+
+	width := len("REPO")
+	for _, r := range repos {
+		width = max(width, len(tildePath(r.Root)))
+	}
+	fmt.Printf("%-*s  %8s  %-7s  %6s  %s\n", width, "REPO", "POLICIES", "SYNC", "BLOCKS", "AGENTS")
+	stale := 0
+	for _, r := range repos {
+		policies := fmt.Sprint(r.Policies)
+		if r.Error != "" {
+			policies = "invalid"
+		}
+		sync := "fresh"
+		switch {
+		case len(r.Agents) == 0:
+			sync = "never"
+		case r.Stale:
+			sync = "stale"
+			stale++
+		}
+		agents := strings.Join(r.Agents, ", ")
+		if agents == "" {
+			agents = "-"
+		}
+		fmt.Printf("%-*s  %8s  %-7s  %6d  %s\n", width, tildePath(r.Root), policies, sync, r.Blocks, agents)
+	}
+	fmt.Println()
+	fmt.Println(dimStyle.Render(fmt.Sprintf("Blocks since %s. Stale: .veto changed after the last veto sync.", from.Local().Format("2006-01-02 15:04"))))
+	if stale > 0 {
+		fmt.Printf("! %d project(s) enforce outdated policies in their agents. Run veto sync in each.\n", stale)
+	}
+}
+
+// workspaceRoots returns the configured workspace roots, or ~/code.
+func workspaceRoots() []string {
+	if s, err := settings.Load(); err == nil && len(s.Workspaces) > 0 {
+		return s.Workspaces
+	}
+	home, _ := os.UserHomeDir()
+	return []string{filepath.Join(home, workspace.DefaultRoot)}
+}
+
+// editWorkspaces handles `veto repos add|remove <dir>`.
+func editWorkspaces(sub string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: veto repos %s <dir>\n", sub)
+		os.Exit(1)
+	}
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	s, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case sub == "add" && slices.Contains(s.Workspaces, dir):
+		fmt.Printf("● %s is already a workspace root\n", tildePath(dir))
+		return
+	case sub == "add":
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "✗ %s isn't a directory\n", dir)
+			os.Exit(1)
+		}
+		s.Workspaces = append(s.Workspaces, dir)
+	case !slices.Contains(s.Workspaces, dir):
+		fmt.Fprintf(os.Stderr, "✗ %s isn't a workspace root\n", dir)
+		os.Exit(1)
+	default:
+		s.Workspaces = slices.DeleteFunc(s.Workspaces, func(w string) bool { return w == dir })
+	}
+	if err := s.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if sub == "add" {
+		fmt.Printf("✓ Added workspace root %s\n", tildePath(dir))
+	} else {
+		fmt.Printf("✓ Removed workspace root %s\n", tildePath(dir))
+	}
+}
+
+func tildePaths(paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = tildePath(p)
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// runRun handles `veto run`: it checks a command against the policies
+// and runs it if they allow it, or the rewrite or replacement a policy
+// approved for it (rewrite or runInstead with autoRun), for agents
+// without a hook system. With -c it takes a command line like sh -c, so
+// it can stand in for an agent's shell. Exits 2 when the command is
+// blocked, and with the command's own status otherwise.
+func runRun(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	script := fs.String("c", "", "command line to run with sh, as in sh -c")
+	agentID := fs.String("agent", os.Getenv("VETO_AGENT"), "agent ID running the command")
+	session := fs.String("session", os.Getenv("VETO_SESSION_ID"), "agent session ID")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: veto run [--agent id] -- <command> [args...]")
+		fmt.Fprintln(os.Stderr, "       veto run [--agent id] -c <command line>")
+	}
+	fs.Parse(args)
+
+	words := fs.Args()
+	cmd := *script
+	if cmd == "" {
+		if len(words) == 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		cmd = shellJoin(words)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	req := &policy.CheckRequest{
+		Action:    string(policy.ActionExecute),
+		Command:   cmd,
+		Cwd:       cwd,
+		Agent:     *agentID,
+		SessionID: *session,
+		User:      currentUser(),
+		Mode:      config.EnvMode(),
+	}
+	result, err := checkRequest(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+
+	recordDecision(req, result)
+	if req.Mode == policy.ModeUnattended {
+		logDecision(req, result)
+	}
+	notifyResult(req, result)
+	fireBlock(req, result)
+	for _, hit := range result.Monitored {
+		fmt.Fprintf(os.Stderr, "~ Monitor: would %s (%s): %s\n", hit.Decision, hit.Policy, hit.Reason)
+	}
+
+	instead, ok := choose(result, func(c string) bool { return allowed(req, c) }, approved)
+	if !ok {
+		fmt.Fprintln(os.Stderr, message.Terminal(result))
+		os.Exit(2)
+	}
+	if instead != "" {
+		fmt.Fprintf(os.Stderr, "veto: running `%s` instead: %s\n", instead, result.Reason)
+		cmd, words = instead, nil
+	}
+
+	// Words run as given, so nothing is reinterpreted by a shell
+	var c *exec.Cmd
+	if len(words) > 0 {
+		c = exec.Command(words[0], words[1:]...)
+	} else {
+		c = exec.Command("sh", "-c", cmd)
+	}
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(127)
+	}
+}
+
+// allowed reports whether the policies allow cmd in place of the command
+// req made.
+func allowed(req *policy.CheckRequest, cmd string) bool {
+	alt := *req
+	alt.Command = cmd
+	result, err := checkRequest(&alt)
+	return err == nil && result.Allowed
+}
+
+// choose decides what veto run does with a checked command: run it
+// (ok), run the approved rewrite or replacement instead, or block it.
+// allowed checks the replacement against the policies, and approve asks
+// a human about ask decisions.
+func choose(result *policy.CheckResult, allowed func(string) bool, approve func(*policy.CheckResult) bool) (instead string, ok bool) {
+	instead = cmp.Or(result.Rewrite, result.RunInstead)
+	switch {
+	case result.Allowed:
+		return "", true
+	case result.Decision == policy.DecisionDeny && instead != "" && allowed(instead):
+		return instead, true
+	case result.Decision == policy.DecisionAsk && approve(result):
+		return "", true
+	}
+	return "", false
+}
+
+// approved asks whoever is at the terminal whether to run a command a
+// policy wants a human to approve. Without a terminal nobody can, so it
+// isn't.
+func approved(result *policy.CheckResult) bool {
+	if !interactive() {
+		return false
+	}
+	fmt.Fprintln(os.Stderr, message.Terminal(result))
+	fmt.Fprint(os.Stderr, "  Run it anyway? [y/N] ")
+	answer, err := readLine(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// readLine reads r up to the end of the line, a byte at a time so that
+// none of what follows is buffered away from the command that runs next
+// with the same stdin.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// shellJoin quotes words into the command line sh would split back into
+// them.
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		if w != "" && !strings.ContainsAny(w, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+			quoted[i] = w
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
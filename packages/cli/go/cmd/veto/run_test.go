@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"ls", "-la"}, "ls -la"},
+		{[]string{"git", "commit", "-m", "fix the bug"}, "git commit -m 'fix the bug'"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"echo", "$HOME", "*.go", "a;b", "~"}, "echo '$HOME' '*.go' 'a;b' '~'"},
+	}
+	for _, tt := range tests {
+		got := shellJoin(tt.words)
+		if got != tt.want {
+			t.Errorf("shellJoin(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+		// sh splits it back into the same words
+		out, err := exec.Command("sh", "-c", `printf '%s\n' `+got).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"); !reflect.DeepEqual(back, tt.words) {
+			t.Errorf("sh splits %q into %q, want %q", got, back, tt.words)
+		}
+	}
+}
+
+func TestChoose(t *testing.T) {
+	deny := func(r policy.CheckResult) *policy.CheckResult {
+		r.Decision = policy.DecisionDeny
+		return &r
+	}
+	tests := []struct {
+		name     string
+		result   *policy.CheckResult
+		allowed  bool // whether the replacement passes the policies
+		approved bool // the answer at the terminal
+		instead  string
+		ok       bool
+	}{
+		{"allow", &policy.CheckResult{Allowed: true}, false, false, "", true},
+		{"warn", &policy.CheckResult{Allowed: true, Decision: policy.DecisionWarn}, false, false, "", true},
+		{"deny", deny(policy.CheckResult{}), true, true, "", false},
+		{"rewrite", deny(policy.CheckResult{Rewrite: "pnpm install"}), true, false, "pnpm install", true},
+		{"run instead", deny(policy.CheckResult{RunInstead: "docker compose up"}), true, false, "docker compose up", true},
+		{"rewrite over run instead", deny(policy.CheckResult{Rewrite: "a", RunInstead: "b"}), true, false, "a", true},
+		{"blocked rewrite", deny(policy.CheckResult{Rewrite: "pnpm install"}), false, true, "", false},
+		{"ask approved", &policy.CheckResult{Decision: policy.DecisionAsk}, false, true, "", true},
+		{"ask refused", &policy.CheckResult{Decision: policy.DecisionAsk}, true, false, "", false},
+		// Only a denial runs a replacement; an ask still asks
+		{"ask with rewrite", &policy.CheckResult{Decision: policy.DecisionAsk, Rewrite: "a"}, true, false, "", false},
+	}
+	for _, tt := range tests {
+		instead, ok := choose(tt.result,
+			func(string) bool { return tt.allowed },
+			func(*policy.CheckResult) bool { return tt.approved })
+		if instead != tt.instead || ok != tt.ok {
+			t.Errorf("%s: choose() = %q, %v, want %q, %v", tt.name, instead, ok, tt.instead, tt.ok)
+		}
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	r := strings.NewReader("yes\nfor the command\n")
+	if answer, err := readLine(r); answer != "yes" || err != nil {
+		t.Errorf("readLine() = %q, %v, want yes", answer, err)
+	}
+	// The rest is left for the command
+	if rest, _ := io.ReadAll(r); string(rest) != "for the command\n" {
+		t.Errorf("left %q after the answer", rest)
+	}
+	if answer, err := readLine(strings.NewReader("y")); answer != "y" || err != io.EOF {
+		t.Errorf("readLine() without a newline = %q, %v", answer, err)
+	}
+}
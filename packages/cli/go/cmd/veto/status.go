@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/daemon"
+	"github.com/VulnZap/veto/internal/output"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/wsl"
+)
+
+// runStatus handles `veto status`: detected agents with the enforcement
+// actually active for each and the scopes it's synced at, whether what
+// was synced is out of date (--diff lists the policies), the daemon, and
+// the project's policies.
+func runStatus(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	diff := fs.Bool("diff", false, "list the policies each agent has out of date")
+	fs.Parse(args)
+	projectDir, _ := agent.ProjectRoot()
+
+	running := daemon.Running()
+	agents := agent.DetectInstalled()
+	if jsonOutput {
+		printJSON(statusDoc(projectDir, agents, running))
+		return
+	}
+	fmt.Printf("Agents: %d\n", len(agents))
+	var advisory []string
+	for i := range agents {
+		a := &agents[i]
+		e := agent.Enforce(a, projectDir)
+		level := e.Level.String()
+		if e.Level == agent.LevelHooks && running {
+			level += " (via daemon)"
+		}
+		mark := "●"
+		if e.Level.Advisory() {
+			mark = "!"
+			advisory = append(advisory, a.Name)
+		}
+		if scopes := agent.SyncedScopes(a, projectDir); len(scopes) > 0 {
+			level += fmt.Sprintf(" [%s]", joinScopes(scopes))
+		}
+		if e.Source != "" {
+			level += "  " + dimStyle.Render(tildePath(e.Source))
+		}
+		fmt.Printf("  %s %-12s %s\n", mark, a.Name, level)
+	}
+	if len(advisory) > 0 {
+		fmt.Printf("\n! Not enforced for %s: the agent can ignore these policies.\n", strings.Join(advisory, ", "))
+		fmt.Println("  Run: veto sync to install enforcement where the agent supports it")
+	}
+	printDrift(projectDir, *diff)
+
+	if running {
+		fmt.Println("Daemon: running")
+	} else {
+		fmt.Println("Daemon: not running")
+	}
+	if path, err := config.Find(); err == nil {
+		if cfg, err := config.Load(path); err == nil {
+			fmt.Printf("Policies: %d\n", len(cfg.Policies)+len(cfg.Rules))
+		}
+		if env := project.Env(filepath.Dir(path)); env != "" {
+			if o, _, err := project.LoadOverlay(filepath.Dir(path), env); err == nil {
+				fmt.Printf("Environment: %s %s\n", env, dimStyle.Render(fmt.Sprintf("(+%d policies from %s)", len(o.Policies)+len(o.Rules), filepath.Base(project.OverlayPath("", env)))))
+			} else {
+				fmt.Printf("Environment: %s ✗ %v\n", env, err)
+			}
+		}
+		if summary := baselineSummary(filepath.Dir(path)); summary != "" {
+			fmt.Printf("Baseline: %s\n", summary)
+		}
+	}
+	if cfg, err := config.Load(project.MachinePath()); err == nil {
+		fmt.Printf("Machine policies: %d, locked  %s\n", len(cfg.Policies)+len(cfg.Rules), dimStyle.Render(project.MachinePath()))
+	}
+}
+
+// enforcementNames are the output names of agent enforcement levels.
+var enforcementNames = map[agent.Level]string{
+	agent.LevelNone:         output.EnforcementNone,
+	agent.LevelInstructions: output.EnforcementInstructions,
+	agent.LevelDenyList:     output.EnforcementDenyList,
+	agent.LevelHooks:        output.EnforcementHooks,
+}
+
+// statusDoc is what `veto status --json` prints: the same as the text,
+// with drift always listed.
+func statusDoc(projectDir string, agents []agent.Agent, running bool) output.Status {
+	doc := output.Status{Agents: []output.Agent{}, Drift: []output.Drift{}, Daemon: running}
+	for i := range agents {
+		a := &agents[i]
+		e := agent.Enforce(a, projectDir)
+		out := output.Agent{
+			ID:          a.ID,
+			Name:        a.Name,
+			Enforcement: enforcementNames[e.Level],
+			Advisory:    e.Level.Advisory(),
+			ViaDaemon:   e.Level == agent.LevelHooks && running,
+			Source:      e.Source,
+			Scopes:      []string{},
+		}
+		for _, s := range agent.SyncedScopes(a, projectDir) {
+			out.Scopes = append(out.Scopes, string(s))
+		}
+		doc.Agents = append(doc.Agents, out)
+	}
+	drifts, _ := agent.ProjectDrift(projectDir)
+	for _, d := range drifts {
+		doc.Drift = append(doc.Drift, output.Drift{
+			Agent:    d.Agent,
+			Scope:    string(d.Scope),
+			SyncedAt: d.SyncedAt,
+			Added:    nonNil(d.Added),
+			Changed:  nonNil(d.Changed),
+			Removed:  nonNil(d.Removed),
+		})
+	}
+	if path, err := config.Find(); err == nil {
+		root := filepath.Dir(path)
+		doc.Project = root
+		if cfg, err := config.Load(path); err == nil {
+			doc.Policies = len(cfg.Policies) + len(cfg.Rules)
+		}
+		doc.Environment = project.Env(root)
+		doc.Baseline = baselineSummary(root)
+	}
+	if cfg, err := config.Load(project.MachinePath()); err == nil {
+		doc.MachinePolicies = len(cfg.Policies) + len(cfg.Rules)
+	}
+	return doc
+}
+
+// nonNil returns list, or an empty list for nil, so it prints as [].
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// printDrift reports, per agent synced from the project at root, whether
+// its policies are out of date, listing them when asked.
+func printDrift(root string, list bool) {
+	drifts, err := agent.ProjectDrift(root)
+	if err != nil {
+		fmt.Printf("Sync: ✗ %v\n", err)
+		return
+	}
+	if len(drifts) == 0 {
+		return
+	}
+	fmt.Println("Sync:")
+	stale := false
+	for _, d := range drifts {
+		name := fmt.Sprintf("%s (%s)", d.Agent, d.Scope)
+		since := dimStyle.Render("synced " + d.SyncedAt.Local().Format("2006-01-02 15:04"))
+		n := d.OutOfDate()
+		if n == 0 {
+			fmt.Printf("  ● %s: up to date  %s\n", name, since)
+			continue
+		}
+		stale = true
+		word := "policies"
+		if n == 1 {
+			word = "policy"
+		}
+		fmt.Printf("  ! %s: %d %s out of date  %s\n", name, n, word, since)
+		if !list {
+			continue
+		}
+		for _, p := range d.Added {
+			fmt.Printf("      + %s\n", p)
+		}
+		for _, p := range d.Changed {
+			fmt.Printf("      ~ %s\n", p)
+		}
+		for _, p := range d.Removed {
+			fmt.Printf("      - %s\n", p)
+		}
+	}
+	if stale {
+		hint := "  Run: veto sync to update them"
+		if !list {
+			hint += dimStyle.Render(" (veto status --diff lists the policies)")
+		}
+		fmt.Println(hint)
+	}
+}
+
+// tildePath shortens paths under the home directory. In WSL, paths under
+// the Windows profile are shown the way Windows shows them.
+func tildePath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	if home := wsl.WindowsHome(); home != "" && strings.HasPrefix(path, home+"/") {
+		return "%USERPROFILE%" + strings.TrimPrefix(wsl.Windows(path), wsl.Windows(home))
+	}
+	return path
+}
+
+func joinScopes(scopes []agent.Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ══════════════════════════════════════════════════════════════════════════════
+// ONBOARDING TOUR
+// ══════════════════════════════════════════════════════════════════════════════
+
+type tourStep int
+
+const (
+	tourAgents tourStep = iota
+	tourTemplate
+	tourPolicy
+	tourDemo
+	tourSync
+	tourDone
+)
+
+var tourStepNames = []string{"agents", "template", "policy", "demo", "sync", "done"}
+
+// tour is the state of the first-run guided tour.
+type tour struct {
+	active   bool
+	step     tourStep
+	template int
+	custom   string
+	demo     *tourDemoResult
+	syncing  bool
+	syncErr  error
+	synced   int
+	writeErr error
+}
+
+// tourDemoResult is a sample request the new policies block.
+type tourDemoResult struct {
+	request string
+	result  *policy.CheckResult
+}
+
+// startTour begins the tour at the first step.
+func (m *model) startTour() {
+	m.tour = tour{active: true}
+	recordTourStep(tourAgents)
+}
+
+// recordTourStep remembers how far the user got, so activation can be
+// measured from settings.
+func recordTourStep(step tourStep) {
+	s, err := settings.Load()
+	if err != nil {
+		return
+	}
+	s.TourStep = tourStepNames[step]
+	if step == tourDone {
+		s.TourCompleted = true
+	}
+	_ = s.Save() // best effort
+}
+
+func (m *model) advanceTour() {
+	m.tour.step++
+	recordTourStep(m.tour.step)
+}
+
+// tourPolicies is the chosen template plus the custom policy, if any.
+func (m model) tourPolicies() []string {
+	policies := append([]string{}, config.Templates[m.tour.template].Policies...)
+	if m.tour.custom != "" {
+		policies = append(policies, m.tour.custom)
+	}
+	return policies
+}
+
+func (m model) updateTour(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	switch m.tour.step {
+	case tourAgents:
+		switch key {
+		case "enter", "right", "l":
+			m.advanceTour()
+		case "esc", "q":
+			m.tour.active = false
+		}
+
+	case tourTemplate:
+		switch key {
+		case "j", "down":
+			m.tour.template = (m.tour.template + 1) % len(config.Templates)
+		case "k", "up":
+			m.tour.template = (m.tour.template + len(config.Templates) - 1) % len(config.Templates)
+		case "enter":
+			m.advanceTour()
+			m.input.Reset()
+			m.input.Focus()
+			return m, textinput.Blink
+		case "esc":
+			m.tour.active = false
+		}
+
+	case tourPolicy:
+		switch key {
+		case "enter":
+			m.tour.custom = strings.TrimSpace(m.input.Value())
+			m.input.Reset()
+			m.input.Blur()
+			m.tour.writeErr = m.writeTourConfig()
+			m.tour.demo = runTourDemo(m.tourPolicies())
+			m.advanceTour()
+		case "esc":
+			// Skip the custom policy
+			m.input.Reset()
+			m.input.Blur()
+			m.tour.writeErr = m.writeTourConfig()
+			m.tour.demo = runTourDemo(m.tourPolicies())
+			m.advanceTour()
+		default:
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+	case tourDemo:
+		switch key {
+		case "enter", "right", "l":
+			m.advanceTour()
+		case "esc":
+			m.tour.active = false
+		}
+
+	case tourSync:
+		switch key {
+		case "enter", "s":
+			if !m.tour.syncing {
+				m.tour.syncing = true
+				return m, tea.Batch(m.spinner.Tick, runSync())
+			}
+		case "esc":
+			m.advanceTour()
+		}
+
+	case tourDone:
+		m.tour.active = false
+	}
+	return m, nil
+}
+
+// writeTourConfig creates .veto from the tour's choices, or adds them to
+// an existing file.
+func (m *model) writeTourConfig() error {
+	policies := m.tourPolicies()
+	if !config.Exists() {
+		if err := config.CreateWith(policies); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range policies {
+			if err := config.AddPolicy(p); err != nil {
+				return err
+			}
+		}
+	}
+	if path, _ := config.Find(); path != "" {
+		if cfg, _ := config.Load(path); cfg != nil {
+			m.policies = cfg.Policies
+		}
+	}
+	return nil
+}
+
+// runTourDemo finds a request one of the policies blocks, preferring
+// file rules and falling back to command rules.
+func runTourDemo(policies []string) *tourDemoResult {
+	cfg := &config.VetoConfig{Policies: policies}
+	set, err := matcher.NewSet(project.Compile(cfg, nil))
+	if err != nil {
+		return nil
+	}
+	for _, p := range policies {
+		b := builtin.Find(p)
+		if b == nil {
+			continue
+		}
+		var reqs []*policy.CheckRequest
+		for _, pattern := range b.Include {
+			reqs = append(reqs, &policy.CheckRequest{Action: string(policy.ActionModify), Target: samplePath(pattern)})
+		}
+		for _, rule := range b.CommandRules {
+			for _, pattern := range rule.Block {
+				reqs = append(reqs, &policy.CheckRequest{Action: string(policy.ActionExecute), Command: sampleCommand(pattern)})
+			}
+		}
+		for _, req := range reqs {
+			if result := set.Check(req); !result.Allowed {
+				label := "edit " + req.Target
+				if req.Command != "" {
+					label = "$ " + req.Command
+				}
+				return &tourDemoResult{request: label, result: result}
+			}
+		}
+	}
+	return nil
+}
+
+// samplePath turns a file glob into a concrete path it matches.
+func samplePath(pattern string) string {
+	p := strings.ReplaceAll(pattern, "**/", "")
+	p = strings.ReplaceAll(p, "/**", "/file")
+	p = strings.ReplaceAll(p, "**", "x")
+	return strings.ReplaceAll(p, "*", "x")
+}
+
+// sampleCommand turns a command pattern into a concrete command it matches.
+func sampleCommand(pattern string) string {
+	return strings.TrimSpace(strings.ReplaceAll(pattern, "*", ""))
+}
+
+func (m model) renderTour() string {
+	width := min(62, m.width-4)
+
+	var body []string
+	switch m.tour.step {
+	case tourAgents:
+		body = append(body, "VETO sets rules your AI agents must follow.", "")
+		if len(m.agents) == 0 {
+			body = append(body, mutedStyle.Render("No agents detected yet - you can sync later."))
+		} else {
+			body = append(body, fmt.Sprintf("Detected %s agent(s):", orangeStyle.Render(fmt.Sprintf("%d", len(m.agents)))))
+			for _, a := range m.agents {
+				body = append(body, "  "+successStyle.Render("●")+" "+a.Name)
+			}
+		}
+		body = append(body, "", keyStyle.Render("enter")+" next   "+keyStyle.Render("esc")+" skip tour")
+
+	case tourTemplate:
+		body = append(body, "Pick a starting template:", "")
+		for i, t := range config.Templates {
+			prefix, style := "  ", itemStyle
+			if i == m.tour.template {
+				prefix, style = orangeStyle.Render("▸ "), itemSelectedStyle
+			}
+			body = append(body, prefix+style.Render(t.Name))
+			body = append(body, "    "+mutedStyle.Render(t.Description))
+		}
+		body = append(body, "", keyStyle.Render("↑↓")+" choose   "+keyStyle.Render("enter")+" next")
+
+	case tourPolicy:
+		body = append(body,
+			"Add a rule of your own, in plain English:",
+			"",
+			m.input.View(),
+			"",
+			renderCompilePreview(m.input.Value()),
+			"",
+			keyStyle.Render("enter")+" add   "+keyStyle.Render("esc")+" skip",
+		)
+
+	case tourDemo:
+		if m.tour.writeErr != nil {
+			body = append(body, errorStyle.Render("✗ "+m.tour.writeErr.Error()), "")
+		} else {
+			body = append(body, successStyle.Render("✓ .veto saved with "+fmt.Sprintf("%d", len(m.policies))+" policies"), "")
+		}
+		if d := m.tour.demo; d != nil {
+			body = append(body,
+				"Here's what an agent sees when it breaks a rule:",
+				"",
+				"  "+dimStyle.Render(d.request),
+				"  "+errorStyle.Render("✗ Blocked: "+d.result.Reason),
+			)
+			if d.result.Suggest != "" {
+				body = append(body, "  "+mutedStyle.Render("Try: "+d.result.Suggest))
+			}
+		} else {
+			body = append(body, mutedStyle.Render("Your policies apply as soon as agents are synced."))
+		}
+		body = append(body, "", keyStyle.Render("enter")+" next")
+
+	case tourSync:
+		body = append(body, "Last step: push your policies to your agents.", "")
+		switch {
+		case m.tour.syncing:
+			body = append(body, m.spinner.View()+" Syncing...")
+		case m.tour.syncErr != nil:
+			body = append(body, errorStyle.Render("✗ "+m.tour.syncErr.Error()), "", keyStyle.Render("s")+" retry   "+keyStyle.Render("esc")+" finish")
+		default:
+			body = append(body, keyStyle.Render("enter")+" sync   "+keyStyle.Render("esc")+" later")
+		}
+
+	case tourDone:
+		if m.tour.synced > 0 {
+			body = append(body, successStyle.Render(fmt.Sprintf("✓ Synced to %d agent(s)", m.tour.synced)))
+		}
+		body = append(body,
+			"You're all set.",
+			"",
+			mutedStyle.Render("Add more rules with ")+keyStyle.Render("a")+mutedStyle.Render(" or ")+dimStyle.Render("veto add \"policy\""),
+			"",
+			keyStyle.Render("any key")+" open dashboard",
+		)
+	}
+
+	progress := mutedStyle.Render(fmt.Sprintf("Step %d of %d", int(m.tour.step)+1, int(tourDone)+1))
+	return lipgloss.Place(
+		m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		panelActiveStyle.Width(width).Render(
+			lipgloss.JoinVertical(lipgloss.Left,
+				append([]string{panelHeaderStyle.Render("Getting started") + "  " + progress, ""}, body...)...,
+			),
+		),
+	)
+}
+
+// renderCompilePreview shows what a phrase compiles to as the user types.
+func renderCompilePreview(phrase string) string {
+	phrase = strings.TrimSpace(phrase)
+	if phrase == "" {
+		return mutedStyle.Render("e.g. no lodash · protect migrations · prefer bun")
+	}
+	b := builtin.Find(phrase)
+	if b == nil {
+		return mutedStyle.Render("Custom policy - compiled on sync")
+	}
+
+	var lines []string
+	lines = append(lines, tagStyle.Render("⚡")+" "+b.Description)
+	if len(b.Include) > 0 {
+		lines = append(lines, mutedStyle.Render("  files: "+strings.Join(truncateList(b.Include, 3), ", ")))
+	}
+	for _, rule := range b.CommandRules {
+		lines = append(lines, mutedStyle.Render("  commands: "+strings.Join(truncateList(rule.Block, 3), ", ")))
+	}
+	if len(b.ContentRules) > 0 {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  code: %d content rule(s)", len(b.ContentRules))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncateList(items []string, n int) []string {
+	if len(items) <= n {
+		return items
+	}
+	return append(append([]string{}, items[:n]...), "…")
+}
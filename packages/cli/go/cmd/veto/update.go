@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// runUpdateCmd handles `veto update`. --channel switches (and remembers)
+// the release channel; --check only reports whether an update exists.
+func runUpdateCmd(args []string) {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	channel := fs.String("channel", "", "release channel to follow: stable or beta")
+	checkOnly := fs.Bool("check", false, "report the latest release without installing it")
+	fs.Parse(args)
+
+	s, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if *channel != "" {
+		if *channel != update.ChannelStable && *channel != update.ChannelBeta {
+			fmt.Fprintf(os.Stderr, "✗ Unknown channel %q (want stable or beta)\n", *channel)
+			os.Exit(1)
+		}
+		if *channel != s.Channel {
+			s.Channel = *channel
+			if err := s.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("● Following the %s channel\n", *channel)
+		}
+	}
+
+	checker := update.NewChecker(s.Channel)
+	release, err := checker.Latest(true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if !update.Newer(release.Version, version) {
+		fmt.Printf("✓ veto v%s is up to date (%s channel)\n", version, checker.Channel)
+		return
+	}
+
+	fmt.Printf("● v%s → v%s (%s channel, %s install)\n", version, release.Version, checker.Channel, checker.Method)
+	if release.Notes != "" {
+		fmt.Printf("\n%s\n\n", release.Notes)
+	}
+	if release.URL != "" {
+		fmt.Printf("  %s\n", release.URL)
+	}
+	if *checkOnly {
+		return
+	}
+
+	fmt.Println("Updating...")
+	if err := checker.Apply(release); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✓ Updated! Run 'veto --version' to verify")
+}
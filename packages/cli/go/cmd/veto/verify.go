@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// runVerify handles `veto verify [--staged] [dir]`, the non-interactive
+// check for hooks and CI. With --staged, as the pre-commit hook `veto
+// install git` writes runs it, it checks the changes staged under the
+// project, or the directory given, against the policies' file patterns
+// and the lines they add against the content rules. Without it, it
+// audits the project's whole working tree, as `veto audit` does. Either
+// way it exits 2 when a finding would be blocked at --severity or above,
+// and --format sarif writes the findings for code scanning.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	staged := fs.Bool("staged", false, "check the changes staged for commit")
+	format := fs.String("format", "pretty", "output format: pretty, json or sarif")
+	severity := fs.String("severity", "low", "fail only on findings of this severity or higher")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: veto verify [--staged] [--format pretty|json|sarif] [--severity low|medium|high|critical] [dir]")
+	}
+	fs.Parse(args)
+	if *format != "pretty" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "✗ unknown format %q (want pretty, json or sarif)\n", *format)
+		os.Exit(1)
+	}
+	threshold := policy.Severity(*severity)
+	if threshold.Rank() == 0 {
+		fmt.Fprintf(os.Stderr, "✗ Unknown severity %q (want low, medium, high or critical)\n", *severity)
+		os.Exit(1)
+	}
+
+	dir := fs.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	p, err := project.Resolve(abs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		os.Exit(1)
+	}
+
+	var report *audit.Report
+	if *staged {
+		report, err = audit.ScanStaged(p.Root, p.Set)
+	} else {
+		report, err = audit.Scan(p.Root, p.Set, 0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+	if p.Baseline != nil {
+		p.Baseline.Apply(report.Findings, "")
+	}
+
+	blocking, failing := 0, 0
+	for _, f := range report.Findings {
+		if f.Blocking() {
+			blocking++
+		}
+		if f.Fails(threshold) {
+			failing++
+		}
+	}
+	switch *format {
+	case "json":
+		printJSON(auditDoc(report, blocking))
+	case "sarif":
+		printJSON(report.SARIF(version, audit.RepoPath(p.Root)))
+	default:
+		printAudit(report, blocking, false)
+	}
+	if failing > 0 {
+		if *staged {
+			fmt.Fprintln(os.Stderr, dimStyle.Render("  Fix the changes and stage them again"))
+		}
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/update"
+)
+
+// Build metadata, injected by the Makefile:
+//
+//	go build -ldflags "-X main.version=3.2.0 -X main.commit=abc1234 -X main.date=2026-01-02T15:04:05Z"
+//
+// A plain `go build` from a checkout keeps the default version and takes
+// the commit and its time from the VCS info Go embeds.
+var (
+	version = "0.0.0-dev"
+	commit  = ""
+	date    = ""
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	dirty := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if commit == "" {
+				commit = s.Value
+			}
+		case "vcs.time":
+			if date == "" {
+				date = s.Value
+			}
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if dirty && !strings.HasSuffix(commit, "-dirty") {
+		commit += "-dirty"
+	}
+}
+
+// printVersion handles `veto --version`: the release, the commit and date
+// it was built from, and the policy schema this engine reads and writes.
+func printVersion() {
+	fmt.Printf("veto v%s\n", version)
+	fmt.Printf("  commit:   %s\n", orUnknown(shortCommit(commit)))
+	fmt.Printf("  built:    %s\n", orUnknown(date))
+	fmt.Printf("  engine:   %s, lock schema v%d\n", lock.EngineRE2, lock.Version)
+	fmt.Printf("  platform: %s\n", strings.TrimPrefix(update.AssetName(), "veto-"))
+}
+
+// shortCommit abbreviates a full commit hash, keeping any -dirty suffix.
+func shortCommit(c string) string {
+	hash, suffix, _ := strings.Cut(c, "-")
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	if suffix != "" {
+		return hash + "-" + suffix
+	}
+	return hash
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/events"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/watch"
+)
+
+// runWatch handles `veto watch`: syncs every detected agent each time the
+// project's .veto files, lock, overlays or custom agents are edited,
+// printing a line per sync, until interrupted.
+func runWatch(args []string) {
+	scope, args := scopeFlag(args)
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: veto watch [--global]")
+		os.Exit(1)
+	}
+	path, err := config.Find()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "✗ No .veto file found")
+		fmt.Fprintln(os.Stderr, "  Run: veto init")
+		os.Exit(1)
+	}
+	root := filepath.Dir(path)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Printf("Watching %s for changes %s\n", tildePath(root), dimStyle.Render("(Ctrl+C to stop)"))
+	err = watch.Watch(ctx, root, func(files []string) {
+		fmt.Printf("%s %s %s\n", dimStyle.Render(time.Now().Format("15:04:05")), strings.Join(files, ", "), watchSync(root, scope))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// watchSync syncs every detected agent from the project at root and sums
+// up how it went in a line.
+func watchSync(root string, scope agent.Scope) string {
+	p, err := project.Load(filepath.Join(root, ".veto"))
+	if err != nil {
+		// Mid-edit; the next save will try again
+		return errorStyle.Render("✗ " + err.Error())
+	}
+	agents := agent.DetectInstalled()
+	if len(agents) == 0 {
+		return errorStyle.Render("✗ no agents detected")
+	}
+	e := events.Event{Name: events.OnSync, Scope: string(scope)}
+	var failures []string
+	for _, a := range agents {
+		if err := agent.Install(a.ID, scope); err != nil {
+			e.Failed = append(e.Failed, a.ID)
+			failures = append(failures, fmt.Sprintf("%s: %v", a.Name, err))
+		} else {
+			e.Agents = append(e.Agents, a.ID)
+		}
+	}
+	word := "policies"
+	if len(p.Policies) == 1 {
+		word = "policy"
+	}
+	line := fmt.Sprintf("→ synced %d %s to %s (%s)", len(p.Policies), word, strings.Join(e.Agents, ", "), scope)
+	if len(e.Agents) == 0 {
+		line = "→"
+	} else if err := fireProject(e); err != nil {
+		failures = append(failures, "hooks: "+err.Error())
+	}
+	if len(failures) > 0 {
+		line += errorStyle.Render(" ✗ " + strings.Join(failures, "; "))
+	}
+	return line
+}
@@ -3,8 +3,12 @@ package agent
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+
+	"github.com/VulnZap/veto/internal/wsl"
 )
 
 // Agent represents a supported AI coding assistant.
@@ -16,6 +20,9 @@ type Agent struct {
 	HasNative   bool // Whether native hook integration is supported
 	ConfigPath  string
 	Installed   bool
+	// Custom is the definition of an agent from the project's
+	// .veto.d/agents, nil for the built-in ones
+	Custom *Custom
 }
 
 // All supported agents
@@ -55,10 +62,55 @@ var All = []Agent{
 		Description: "Aider CLI coding assistant",
 		HasNative:   true,
 	},
+	{
+		ID:          "copilot",
+		Name:        "GitHub Copilot",
+		Aliases:     []string{"copilot", "gh-copilot", "github-copilot"},
+		Description: "GitHub Copilot agent in VS Code and the Copilot CLI",
+		HasNative:   false,
+	},
+	{
+		ID:          "goose",
+		Name:        "Goose",
+		Aliases:     []string{"goose"},
+		Description: "Block's Goose agent",
+		HasNative:   false,
+	},
+	{
+		ID:          "amazon-q",
+		Name:        "Amazon Q Developer",
+		Aliases:     []string{"q", "amazonq", "amazon-q"},
+		Description: "Amazon Q Developer CLI",
+		HasNative:   false,
+	},
 }
 
-// Find looks up an agent by ID or alias.
+// Find looks up an agent by ID or alias, among the built-in agents and
+// the current project's custom ones.
 func Find(idOrAlias string) *Agent {
+	root, err := ProjectRoot()
+	if err != nil {
+		return findBuiltin(idOrAlias)
+	}
+	return findIn(idOrAlias, root)
+}
+
+// findIn looks up an agent among the built-in agents and the custom ones
+// of the project at root.
+func findIn(idOrAlias, root string) *Agent {
+	if a := findBuiltin(idOrAlias); a != nil {
+		return a
+	}
+	custom, _ := LoadCustom(root)
+	for i := range custom {
+		if custom[i].ID == idOrAlias || slices.Contains(custom[i].Aliases, idOrAlias) {
+			return &custom[i]
+		}
+	}
+	return nil
+}
+
+func findBuiltin(idOrAlias string) *Agent {
 	for i := range All {
 		if All[i].ID == idOrAlias {
 			return &All[i]
@@ -72,10 +124,18 @@ func Find(idOrAlias string) *Agent {
 	return nil
 }
 
-// DetectInstalled finds agents that are installed on the system.
+// DetectInstalled finds agents that are installed on the system, custom
+// agents of the current project included. A project whose custom agents
+// can't be read gets the built-in ones only; veto sync reports why.
 func DetectInstalled() []Agent {
+	agents := All
+	if root, err := ProjectRoot(); err == nil {
+		if custom, err := LoadCustom(root); err == nil {
+			agents = append(slices.Clip(All), custom...)
+		}
+	}
 	var installed []Agent
-	for _, agent := range All {
+	for _, agent := range agents {
 		if isInstalled(agent) {
 			agent.Installed = true
 			installed = append(installed, agent)
@@ -84,114 +144,211 @@ func DetectInstalled() []Agent {
 	return installed
 }
 
-// isInstalled checks if an agent is installed by looking for its config.
+// commands are the executables of agents run from the command line,
+// looked up in PATH.
+var commands = map[string]string{
+	"claude-code": "claude",
+	"opencode":    "opencode",
+	"aider":       "aider",
+	"copilot":     "copilot",
+	"goose":       "goose",
+	"amazon-q":    "q",
+}
+
+// isInstalled checks if an agent is installed by looking for its command
+// in PATH or its config.
 func isInstalled(agent Agent) bool {
+	if agent.Custom != nil {
+		return agent.Custom.installed()
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return false
 	}
+	if name := commands[agent.ID]; name != "" {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
 
-	var paths []string
+	paths := installPaths(agent.ID, runtime.GOOS, home, os.Getenv)
+	if dir := windowsConfigDir(&agent); dir != "" {
+		paths = append(paths, dir)
+	}
+	for _, path := range paths {
+		// Some paths are globs over versioned directories
+		if matches, _ := filepath.Glob(path); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
 
-	switch agent.ID {
+// installPaths returns the files and directories an agent leaves on goos
+// once installed: its config, and on Windows its install directory.
+func installPaths(agentID, goos, home string, getenv func(string) string) []string {
+	paths := []string{configDirFor(agentID, goos, home, getenv)}
+	roaming, local := windowsDirs(home, getenv)
+
+	switch agentID {
 	case "claude-code":
-		// Check for Claude Code config
-		if runtime.GOOS == "darwin" {
-			paths = append(paths,
-				filepath.Join(home, "Library", "Application Support", "Claude"),
-				filepath.Join(home, ".claude"),
-			)
-		} else {
-			paths = append(paths,
-				filepath.Join(home, ".config", "claude"),
-				filepath.Join(home, ".claude"),
-			)
+		paths = append(paths, filepath.Join(home, ".claude"))
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(local, "AnthropicClaude"))
 		}
 
 	case "opencode":
-		paths = append(paths,
-			filepath.Join(home, ".config", "opencode"),
-		)
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(roaming, "opencode"), filepath.Join(local, "opencode"))
+		}
 
 	case "windsurf":
-		if runtime.GOOS == "darwin" {
-			paths = append(paths,
-				filepath.Join(home, "Library", "Application Support", "Windsurf"),
-				filepath.Join(home, ".windsurf"),
-			)
-		} else {
-			paths = append(paths,
-				filepath.Join(home, ".config", "Windsurf"),
-				filepath.Join(home, ".windsurf"),
-			)
+		paths = append(paths, filepath.Join(home, ".windsurf"))
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(local, "Programs", "Windsurf"))
 		}
 
 	case "cursor":
-		if runtime.GOOS == "darwin" {
-			paths = append(paths,
-				filepath.Join(home, "Library", "Application Support", "Cursor"),
-				filepath.Join(home, ".cursor"),
-			)
-		} else {
-			paths = append(paths,
-				filepath.Join(home, ".config", "Cursor"),
-				filepath.Join(home, ".cursor"),
-			)
+		paths = append(paths, filepath.Join(home, ".cursor"))
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(local, "Programs", "cursor"))
 		}
 
 	case "aider":
-		// Check for aider config or if aider command exists
-		paths = append(paths,
-			filepath.Join(home, ".aider.conf.yml"),
-		)
-		// Also check if aider is in PATH - simplified check
-		if _, err := os.Stat("/usr/local/bin/aider"); err == nil {
-			return true
+		// The config file rather than home, which always exists, and
+		// pipx's bin directory, which isn't always in PATH
+		paths = []string{filepath.Join(home, ".aider.conf.yml")}
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(home, ".local", "bin", "aider.exe"))
+		} else {
+			paths = append(paths, filepath.Join(home, ".local", "bin", "aider"))
 		}
-		if _, err := os.Stat(filepath.Join(home, ".local", "bin", "aider")); err == nil {
-			return true
+
+	case "copilot":
+		// VS Code's user dir exists without Copilot, so look for the
+		// Copilot Chat extension, the Copilot CLI's config and the gh
+		// extension instead
+		paths = []string{
+			filepath.Join(home, ".vscode", "extensions", "github.copilot-chat-*"),
+			filepath.Join(home, ".copilot"),
+		}
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(roaming, "GitHub CLI", "extensions", "gh-copilot"))
+		} else {
+			paths = append(paths, filepath.Join(home, ".local", "share", "gh", "extensions", "gh-copilot"))
 		}
-	}
 
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
-			return true
+	case "goose":
+		if goos == "windows" {
+			paths = append(paths, filepath.Join(local, "Programs", "Goose"))
 		}
-	}
 
-	return false
+	case "amazon-q":
+		if goos == "darwin" {
+			paths = append(paths, filepath.Join("/Applications", "Amazon Q.app"))
+		}
+	}
+	return paths
 }
 
-// GetConfigDir returns the configuration directory for an agent.
-func GetConfigDir(agent *Agent) string {
-	home, _ := os.UserHomeDir()
+// windowsDirs returns %APPDATA% and %LOCALAPPDATA%, or where they usually
+// are under home when they aren't set.
+func windowsDirs(home string, getenv func(string) string) (roaming, local string) {
+	roaming, local = getenv("APPDATA"), getenv("LOCALAPPDATA")
+	if roaming == "" {
+		roaming = filepath.Join(home, "AppData", "Roaming")
+	}
+	if local == "" {
+		local = filepath.Join(home, "AppData", "Local")
+	}
+	return roaming, local
+}
 
+// windowsConfigDir returns where a Windows-side editor keeps its config
+// when veto runs in WSL, or "" for agents that run inside the distro.
+// Cursor, Windsurf and VS Code usually run on Windows and open WSL repos
+// remotely.
+func windowsConfigDir(agent *Agent) string {
+	var app string
 	switch agent.ID {
-	case "claude-code":
-		if runtime.GOOS == "darwin" {
-			return filepath.Join(home, "Library", "Application Support", "Claude")
+	case "cursor":
+		app = "Cursor"
+	case "windsurf":
+		app = "Windsurf"
+	case "copilot":
+		app = filepath.Join("Code", "User")
+	default:
+		return ""
+	}
+	home := wsl.WindowsHome()
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, "AppData", "Roaming", app)
+}
+
+// GetConfigDir returns the configuration directory for an agent. In WSL,
+// editors installed only on the Windows side are configured there.
+func GetConfigDir(agent *Agent) string {
+	if agent.Custom != nil {
+		return agent.Custom.configDir()
+	}
+	dir := configDir(agent)
+	if win := windowsConfigDir(agent); win != "" {
+		if _, err := os.Stat(dir); err != nil {
+			if _, err := os.Stat(win); err == nil {
+				return win
+			}
 		}
-		return filepath.Join(home, ".config", "claude")
+	}
+	return dir
+}
 
-	case "opencode":
-		return filepath.Join(home, ".config", "opencode")
+func configDir(agent *Agent) string {
+	home, _ := os.UserHomeDir()
+	return configDirFor(agent.ID, runtime.GOOS, home, os.Getenv)
+}
 
+// configDirFor returns the configuration directory of an agent on goos.
+func configDirFor(agentID, goos, home string, getenv func(string) string) string {
+	// Desktop apps keep their config in the platform's application data
+	// directory
+	var app string
+	switch agentID {
+	case "claude-code":
+		app = "Claude"
 	case "windsurf":
-		if runtime.GOOS == "darwin" {
-			return filepath.Join(home, "Library", "Application Support", "Windsurf")
-		}
-		return filepath.Join(home, ".config", "Windsurf")
-
+		app = "Windsurf"
 	case "cursor":
-		if runtime.GOOS == "darwin" {
-			return filepath.Join(home, "Library", "Application Support", "Cursor")
+		app = "Cursor"
+	case "copilot":
+		// VS Code's user settings, where Copilot reads its own
+		app = filepath.Join("Code", "User")
+	case "opencode":
+		// OpenCode follows XDG everywhere, Windows included
+		return filepath.Join(home, ".config", "opencode")
+	case "goose":
+		if goos == "windows" {
+			roaming, _ := windowsDirs(home, getenv)
+			return filepath.Join(roaming, "Block", "goose", "config")
 		}
-		return filepath.Join(home, ".config", "Cursor")
-
-	case "aider":
-		return home // .aider.conf.yml in home
-
+		// ~/.config on macOS too
+		return filepath.Join(home, ".config", "goose")
+	case "amazon-q":
+		return filepath.Join(home, ".aws", "amazonq")
 	default:
+		// Aider reads .aider.conf.yml in home
 		return home
 	}
+	switch goos {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", app)
+	case "windows":
+		roaming, _ := windowsDirs(home, getenv)
+		return filepath.Join(roaming, app)
+	}
+	if agentID == "claude-code" {
+		return filepath.Join(home, ".config", "claude")
+	}
+	return filepath.Join(home, ".config", app)
 }
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWindowsPaths(t *testing.T) {
+	home := filepath.Join("C:", "Users", "me")
+	env := map[string]string{
+		"APPDATA":      filepath.Join("D:", "Profiles", "me", "Roaming"),
+		"LOCALAPPDATA": filepath.Join("D:", "Profiles", "me", "Local"),
+	}
+	getenv := func(k string) string { return env[k] }
+
+	dirs := map[string]string{
+		"claude-code": filepath.Join(env["APPDATA"], "Claude"),
+		"cursor":      filepath.Join(env["APPDATA"], "Cursor"),
+		"windsurf":    filepath.Join(env["APPDATA"], "Windsurf"),
+		"opencode":    filepath.Join(home, ".config", "opencode"),
+		"aider":       home,
+		"goose":       filepath.Join(env["APPDATA"], "Block", "goose", "config"),
+		"amazon-q":    filepath.Join(home, ".aws", "amazonq"),
+	}
+	for id, want := range dirs {
+		if got := configDirFor(id, "windows", home, getenv); got != want {
+			t.Errorf("%s config dir = %s, want %s", id, got, want)
+		}
+	}
+
+	want := []string{
+		filepath.Join(env["APPDATA"], "Cursor"),
+		filepath.Join(home, ".cursor"),
+		filepath.Join(env["LOCALAPPDATA"], "Programs", "cursor"),
+	}
+	if got := installPaths("cursor", "windows", home, getenv); !reflect.DeepEqual(got, want) {
+		t.Errorf("cursor install paths = %q, want %q", got, want)
+	}
+
+	// Without the variables, their usual places
+	getenv = func(string) string { return "" }
+	if got, want := configDirFor("windsurf", "windows", home, getenv), filepath.Join(home, "AppData", "Roaming", "Windsurf"); got != want {
+		t.Errorf("windsurf config dir = %s, want %s", got, want)
+	}
+}
+
+func TestUnixPaths(t *testing.T) {
+	home := "/home/me"
+	getenv := func(string) string { return "" }
+	tests := []struct{ id, goos, want string }{
+		{"claude-code", "linux", "/home/me/.config/claude"},
+		{"cursor", "linux", "/home/me/.config/Cursor"},
+		{"cursor", "darwin", "/home/me/Library/Application Support/Cursor"},
+		{"opencode", "darwin", "/home/me/.config/opencode"},
+		{"copilot", "linux", "/home/me/.config/Code/User"},
+		{"copilot", "darwin", "/home/me/Library/Application Support/Code/User"},
+		{"goose", "darwin", "/home/me/.config/goose"},
+		{"amazon-q", "linux", "/home/me/.aws/amazonq"},
+	}
+	for _, tt := range tests {
+		if got := configDirFor(tt.id, tt.goos, home, getenv); got != filepath.FromSlash(tt.want) {
+			t.Errorf("%s on %s: config dir = %s, want %s", tt.id, tt.goos, got, tt.want)
+		}
+	}
+	for _, p := range installPaths("aider", "linux", home, getenv) {
+		if p == home {
+			t.Errorf("aider counts as installed whenever home exists")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// aiderSync sets the keys a sync of the given test command writes in a
+// .aider.conf.yml, returning the file after.
+func aiderSync(t *testing.T, text, testCmd string) string {
+	t.Helper()
+	doc := parseAider(t, text)
+	conf := doc.Content[0]
+	setAiderKey(conf, "read-only", stringList([]string{".env", "secrets/**"}), doc.HeadComment)
+	setAiderKey(conf, "read", stringList([]string{"CONVENTIONS.md"}), doc.HeadComment)
+	setAiderKey(conf, "test-cmd", stringNode(testCmd), doc.HeadComment)
+	return encodeAider(t, doc)
+}
+
+// aiderUnsync removes veto's keys from a .aider.conf.yml.
+func aiderUnsync(t *testing.T, text string) string {
+	t.Helper()
+	doc := parseAider(t, text)
+	unsetAiderKeys(doc.Content[0], doc.HeadComment)
+	return encodeAider(t, doc)
+}
+
+func parseAider(t *testing.T, text string) *yaml.Node {
+	t.Helper()
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	if err := yaml.Unmarshal([]byte(text), doc); err != nil {
+		t.Fatalf("%v in:\n%s", err, text)
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	return doc
+}
+
+func encodeAider(t *testing.T, doc *yaml.Node) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestAiderKeysRoundTrip(t *testing.T) {
+	tests := []struct {
+		name, user string
+		// synced is the file after syncing "npm test" then "pnpm test"
+		synced string
+	}{
+		{
+			name: "other keys and comments",
+			user: "# my aider settings\nmodel: sonnet # fast\n# commit by hand\nauto-commits: false\n",
+			synced: "# my aider settings\nmodel: sonnet # fast\n# commit by hand\nauto-commits: false\n" +
+				"read-only: # managed by veto\n  - .env\n  - secrets/**\n" +
+				"read: # managed by veto\n  - CONVENTIONS.md\n" +
+				"test-cmd: pnpm test # managed by veto\n",
+		},
+		{
+			name: "the user's own list and command",
+			user: "read-only:\n  - docs/spec.md # the spec\n  - .env\ntest-cmd: make test\n",
+			// .env was the user's already, and their test command stays
+			synced: "read-only:\n  - docs/spec.md # the spec\n  - .env\n  - secrets/** # managed by veto\n" +
+				"test-cmd: make test\n" +
+				"read: # managed by veto\n  - CONVENTIONS.md\n",
+		},
+		{
+			name: "no config",
+			user: "",
+			synced: "read-only: # managed by veto\n  - .env\n  - secrets/**\n" +
+				"read: # managed by veto\n  - CONVENTIONS.md\n" +
+				"test-cmd: pnpm test # managed by veto\n",
+		},
+	}
+	for _, tt := range tests {
+		synced := aiderSync(t, aiderSync(t, tt.user, "npm test"), "pnpm test")
+		if synced != tt.synced {
+			t.Errorf("%s: after syncing:\n%s\nwant:\n%s", tt.name, synced, tt.synced)
+		}
+		// A sync that changes nothing leaves the file as it was
+		if again := aiderSync(t, synced, "pnpm test"); again != synced {
+			t.Errorf("%s: after syncing again:\n%s\nwant:\n%s", tt.name, again, synced)
+		}
+
+		want := tt.user
+		if want == "" {
+			want = "{}\n"
+		}
+		if got := aiderUnsync(t, synced); got != want {
+			t.Errorf("%s: after uninstalling:\n%s\nwant the user's:\n%s", tt.name, got, want)
+		}
+	}
+}
+
+func TestAiderConfigFromOlderVersions(t *testing.T) {
+	// Older versions wrote the file whole, with only read-only in it
+	old := "# Managed by veto\nread-only:\n  - .env\n  - build/**\n"
+	synced := aiderSync(t, old, "npm test")
+	want := "# Managed by veto\nread-only: # managed by veto\n  - .env\n  - secrets/**\n" +
+		"read: # managed by veto\n  - CONVENTIONS.md\n" +
+		"test-cmd: npm test # managed by veto\n"
+	if synced != want {
+		t.Errorf("after syncing:\n%s\nwant:\n%s", synced, want)
+	}
+	if got := aiderUnsync(t, synced); got != "{}\n" {
+		t.Errorf("after uninstalling:\n%s\nwant nothing left", got)
+	}
+}
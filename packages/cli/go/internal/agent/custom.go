@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+	"gopkg.in/yaml.v3"
+)
+
+// CustomDir holds a project's custom agent definitions, relative to its
+// root: one YAML file per agent.
+var CustomDir = filepath.Join(audit.DirName, "agents")
+
+// managedNote marks the files rendered from custom agent templates, which
+// must include it (as {{.Managed}}) unless they're merged into JSON.
+const managedNote = "managed by veto: rewritten by veto sync"
+
+// Custom defines an agent veto doesn't ship support for: how to detect it
+// and the files to render the project's policies into.
+//
+//	id: zed
+//	name: Zed
+//	detect:
+//	  commands: [zed]
+//	  paths: [~/.config/zed]
+//	configDir: ~/.config/zed
+//	files:
+//	  - path: .rules
+//	    template: |
+//	      # Project Policies ({{.Managed}})
+//	      {{.Instructions}}
+//	  - path: settings.json
+//	    merge: true
+//	    template: '{"agent": {"deny": {{json .Deny}}}}'
+type Custom struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Aliases     []string `yaml:"aliases"`
+	Description string   `yaml:"description"`
+	Detect      struct {
+		// Commands are looked up in PATH
+		Commands []string `yaml:"commands"`
+		// Paths may start with ~ and contain globs
+		Paths []string `yaml:"paths"`
+	} `yaml:"detect"`
+	// ConfigDir is where global installs write the files; without it
+	// the agent can only be installed into the project. The user has to
+	// trust the definition before veto writes there (see Trust)
+	ConfigDir string `yaml:"configDir"`
+	// ProjectDir is where project installs write them, relative to the
+	// project root (the root itself by default)
+	ProjectDir string       `yaml:"projectDir"`
+	Files      []CustomFile `yaml:"files"`
+
+	// Source is the definition's file
+	Source string `yaml:"-"`
+}
+
+// CustomFile is a file rendered from a Go template. Templates see:
+//
+//	.Instructions  the policy list, as in the instruction files veto writes
+//	.Policies      the compiled policies
+//	.Deny          blocked command globs, Windows forms included
+//	.Managed       the note marking the file as veto's
+//	.Scope, .Root  the install scope and project root
+//
+// and the functions json, regex (a command glob as an anchored regular
+// expression) and join.
+type CustomFile struct {
+	// Path is relative to the scope's directory
+	Path     string `yaml:"path"`
+	Template string `yaml:"template"`
+	// Merge renders a JSON object merged into the file, keeping the
+	// user's own settings, instead of writing the file whole
+	Merge bool `yaml:"merge"`
+
+	tmpl *template.Template
+}
+
+var customID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+var customFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"regex": commandRegex,
+	"join":  strings.Join,
+}
+
+// LoadCustom reads the custom agents defined in the project at root. A
+// project without any has none.
+func LoadCustom(root string) ([]Agent, error) {
+	paths, err := filepath.Glob(filepath.Join(root, CustomDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	more, _ := filepath.Glob(filepath.Join(root, CustomDir, "*.yml"))
+	paths = append(paths, more...)
+	slices.Sort(paths)
+
+	var agents []Agent
+	for _, path := range paths {
+		c, err := parseCustom(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, a := range agents {
+			if a.ID == c.ID {
+				return nil, fmt.Errorf("%s: agent %s is also defined in %s", path, c.ID, a.Custom.Source)
+			}
+		}
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		agents = append(agents, Agent{
+			ID:          c.ID,
+			Name:        name,
+			Aliases:     c.Aliases,
+			Description: c.Description,
+			Custom:      c,
+		})
+	}
+	return agents, nil
+}
+
+func parseCustom(path string) (*Custom, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Custom{Source: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return nil, err
+	}
+
+	if !customID.MatchString(c.ID) {
+		return nil, fmt.Errorf("id %q: want lowercase letters, digits and dashes", c.ID)
+	}
+	for _, name := range append([]string{c.ID}, c.Aliases...) {
+		if a := findBuiltin(name); a != nil {
+			return nil, fmt.Errorf("%s is already the built-in %s agent", name, a.Name)
+		}
+	}
+	if !local(c.ProjectDir) {
+		return nil, fmt.Errorf("projectDir %s is outside the project", c.ProjectDir)
+	}
+	if len(c.Files) == 0 {
+		return nil, fmt.Errorf("no files to write")
+	}
+	for i := range c.Files {
+		f := &c.Files[i]
+		if f.Path == "" || !local(f.Path) {
+			return nil, fmt.Errorf("file %q: want a path inside the agent's directory", f.Path)
+		}
+		f.tmpl, err = template.New(f.Path).Funcs(customFuncs).Option("missingkey=error").Parse(f.Template)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// local reports whether path stays inside the directory it's relative to.
+func local(path string) bool {
+	return path == "" || filepath.IsLocal(filepath.FromSlash(path))
+}
+
+// installed checks the agent's commands and paths. An agent without
+// either is always installed: the project defined it to be synced.
+func (c *Custom) installed() bool {
+	if len(c.Detect.Commands) == 0 && len(c.Detect.Paths) == 0 {
+		return true
+	}
+	for _, name := range c.Detect.Commands {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	for _, path := range c.Detect.Paths {
+		if matches, _ := filepath.Glob(expandHome(path)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// configDir returns the agent's global config dir, or "" without one.
+func (c *Custom) configDir() string {
+	if c.ConfigDir == "" {
+		return ""
+	}
+	return expandHome(c.ConfigDir)
+}
+
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != filepath.Separator) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// customData is what custom agent templates render.
+type customData struct {
+	Instructions string
+	Policies     []*policy.Policy
+	Deny         []string
+	Managed      string
+	Scope        Scope
+	Root         string
+}
+
+func installCustom(c *Custom, configDir string, scope Scope, policies []*policy.Policy, l lister) error {
+	if configDir == "" {
+		return fmt.Errorf("%s sets no configDir, so it can only be installed into the project", c.Source)
+	}
+	if scope == ScopeGlobal {
+		if err := c.checkTrusted(configDir); err != nil {
+			return err
+		}
+	}
+	for _, f := range c.Files {
+		path := filepath.Join(configDir, filepath.FromSlash(f.Path))
+		if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		list, err := l.list(filepath.Dir(path), policies)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		data := customData{
+			Instructions: list,
+			Policies:     policies,
+			Deny:         denyPatterns(policies),
+			Managed:      managedNote,
+			Scope:        scope,
+			Root:         l.root,
+		}
+		if err := f.tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("%s: %w", c.Source, err)
+		}
+
+		if f.Merge {
+			var gen map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &gen); err != nil {
+				return fmt.Errorf("%s: %s: want a JSON object to merge: %w", c.Source, f.Path, err)
+			}
+			if err := mergeJSON(path, gen); err != nil {
+				return err
+			}
+			continue
+		}
+		// Without the note veto couldn't tell its file from the user's
+		if !strings.Contains(buf.String(), "managed by veto") {
+			return fmt.Errorf("%s: %s: the template must include {{.Managed}}, e.g. in a comment", c.Source, f.Path)
+		}
+		if err := writeInstructions(path, buf.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func uninstallCustom(c *Custom, configDir string, r *Report) error {
+	if configDir == "" {
+		return nil
+	}
+	for _, f := range c.Files {
+		path := filepath.Join(configDir, filepath.FromSlash(f.Path))
+		if f.Merge {
+			if err := r.unmergeJSON(path); err != nil {
+				return err
+			}
+		} else {
+			r.remove(path)
+		}
+		r.removeAppendix(filepath.Dir(path))
+	}
+	return nil
+}
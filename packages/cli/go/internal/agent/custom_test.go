@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const zedAgent = `id: zed
+name: Zed
+aliases: [zed-ai]
+projectDir: .zed
+files:
+  - path: rules.md
+    template: |
+      <!-- {{.Managed}} -->
+      {{.Instructions}}
+  - path: settings.json
+    merge: true
+    template: '{"agent": {"deny": {{json .Deny}}, "first": {{index .Deny 0 | regex | json}}}}'
+`
+
+func writeCustom(t *testing.T, root, name, def string) {
+	t.Helper()
+	dir := filepath.Join(root, CustomDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCustomAgentInstall(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("no force push\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeCustom(t, root, "zed.yaml", zedAgent)
+	settings := filepath.Join(root, ".zed", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(settings), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settings, []byte(`{"theme": "One Dark"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := findIn("zed-ai", root)
+	if a == nil || a.ID != "zed" || a.Name != "Zed" {
+		t.Fatalf("findIn(zed-ai) = %+v", a)
+	}
+	if err := install("zed", ScopeProject, root); err != nil {
+		t.Fatal(err)
+	}
+	if !fileContains(filepath.Join(root, ".zed", "rules.md"), []string{"managed by veto", "force push"}) {
+		t.Error("rules.md is missing the note or the policies")
+	}
+	doc := readDoc(t, settings)
+	deny := doc["agent"].(map[string]interface{})["deny"].([]interface{})
+	if doc["theme"] != "One Dark" || len(deny) == 0 || !strings.HasPrefix(doc["agent"].(map[string]interface{})["first"].(string), "^git push") {
+		t.Errorf("settings.json = %v, want the user's theme and the deny list", doc)
+	}
+	if agents, _ := ProjectSync(root); !reflect.DeepEqual(agents, []string{"zed"}) {
+		t.Errorf("ProjectSync() agents = %q, want zed", agents)
+	}
+
+	r := &Report{}
+	if err := uninstallCustom(a.Custom, ProjectConfigDir(a, root), r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Removed) != 1 || !reflect.DeepEqual(r.Reverted, []string{settings}) {
+		t.Errorf("report = %+v, want rules.md removed and settings.json reverted", r)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".zed", "rules.md")); !os.IsNotExist(err) {
+		t.Errorf("rules.md left behind: %v", err)
+	}
+	if doc := readDoc(t, settings); !reflect.DeepEqual(doc, map[string]interface{}{"theme": "One Dark"}) {
+		t.Errorf("settings.json after uninstall = %v, want the user's own", doc)
+	}
+}
+
+func TestCustomAgentInvalid(t *testing.T) {
+	tests := map[string]string{
+		"built-in id":     "id: cursor\nfiles: [{path: a.md, template: x}]\n",
+		"no files":        "id: zed\n",
+		"outside":         "id: zed\nfiles: [{path: ../a.md, template: x}]\n",
+		"bad template":    "id: zed\nfiles: [{path: a.md, template: '{{.Instructions'}]\n",
+		"unknown field":   "id: zed\nfile: a.md\n",
+		"uppercase id":    "id: Zed\nfiles: [{path: a.md, template: x}]\n",
+		"outside project": "id: zed\nprojectDir: /etc\nfiles: [{path: a.md, template: x}]\n",
+	}
+	for name, def := range tests {
+		root := t.TempDir()
+		writeCustom(t, root, "zed.yaml", def)
+		if _, err := LoadCustom(root); err == nil {
+			t.Errorf("%s: LoadCustom accepted %q", name, def)
+		}
+	}
+
+	// Files veto can't recognize as its own later are refused
+	root := t.TempDir()
+	writeCustom(t, root, "zed.yaml", "id: zed\nfiles: [{path: a.md, template: '{{.Instructions}}'}]\n")
+	if err := install("zed", ScopeProject, root); err == nil || !strings.Contains(err.Error(), "{{.Managed}}") {
+		t.Errorf("install without the managed note: err = %v", err)
+	}
+	if err := install("zed", ScopeGlobal, root); err == nil {
+		t.Error("global install without a configDir succeeded")
+	}
+}
+
+func TestCustomAgentGlobalNeedsTrust(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("no force push\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configDir := filepath.Join(t.TempDir(), "zed")
+	writeCustom(t, root, "zed.yaml", zedAgent+"configDir: "+configDir+"\n")
+	t.Chdir(root)
+
+	var untrusted *UntrustedError
+	if err := install("zed", ScopeGlobal, root); !errors.As(err, &untrusted) || untrusted.Dir != configDir {
+		t.Fatalf("untrusted global install: err = %v, want an UntrustedError for %s", err, configDir)
+	}
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("untrusted install wrote to %s: %v", configDir, err)
+	}
+
+	if err := Trust("zed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := install("zed", ScopeGlobal, root); err != nil {
+		t.Fatalf("trusted global install: %v", err)
+	}
+	if !fileContains(filepath.Join(configDir, "rules.md"), []string{"managed by veto"}) {
+		t.Error("trusted install didn't write rules.md")
+	}
+
+	// A changed definition has to be trusted again
+	writeCustom(t, root, "zed.yaml", zedAgent+"configDir: "+filepath.Join(t.TempDir(), "elsewhere")+"\n")
+	if err := install("zed", ScopeGlobal, root); !errors.As(err, &untrusted) {
+		t.Errorf("changed definition: err = %v, want an UntrustedError", err)
+	}
+}
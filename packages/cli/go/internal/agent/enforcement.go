@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Level is how strongly veto's policies bind an agent.
+type Level int
+
+const (
+	// LevelNone means no veto configuration was found
+	LevelNone Level = iota
+	// LevelInstructions means policies only appear in prompt files the
+	// model is free to ignore
+	LevelInstructions
+	// LevelDenyList means a static deny list in the agent's own
+	// permission settings, which only catches listed patterns
+	LevelDenyList
+	// LevelHooks means every action is checked live by a veto hook
+	LevelHooks
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelInstructions:
+		return "instructions only"
+	case LevelDenyList:
+		return "static deny list"
+	case LevelHooks:
+		return "live hooks"
+	}
+	return "not synced"
+}
+
+// Advisory reports whether the agent can ignore policies at this level.
+func (l Level) Advisory() bool {
+	return l < LevelDenyList
+}
+
+// Enforcement is the strongest veto integration found for an agent.
+type Enforcement struct {
+	Level Level
+	// Source is the file that provides it
+	Source string
+}
+
+// probe is a file whose presence, with the given contents, shows an
+// integration at some level.
+type probe struct {
+	level Level
+	// path is relative to the home directory when it starts with "~/",
+	// to the agent's config dir when it starts with "@/", and to the
+	// project otherwise
+	path  string
+	needs []string
+}
+
+// probes lists where each agent's integrations live, covering both the
+// TypeScript and Go installers.
+var probes = map[string][]probe{
+	"claude-code": {
+		{LevelHooks, "~/.claude/settings.json", []string{"PreToolUse", "veto"}},
+		{LevelHooks, ".claude/settings.json", []string{"PreToolUse", "veto"}},
+		{LevelDenyList, "@/settings.json", []string{`"deny"`}},
+		{LevelDenyList, ".claude/settings.json", []string{`"deny"`}},
+		{LevelInstructions, "@/CLAUDE.md", []string{"veto"}},
+		{LevelInstructions, "CLAUDE.md", []string{"veto"}},
+	},
+	"opencode": {
+		{LevelHooks, "~/.config/opencode/plugin/veto-leash.ts", nil},
+		{LevelHooks, ".opencode/plugin/veto-leash.ts", nil},
+		{LevelDenyList, "@/opencode.json", []string{`"deny"`}},
+		{LevelDenyList, "opencode.json", []string{`"deny"`}},
+		{LevelInstructions, "@/AGENTS.md", []string{"veto"}},
+		{LevelInstructions, "AGENTS.md", []string{"veto"}},
+	},
+	"cursor": {
+		{LevelHooks, "~/.cursor/hooks.json", []string{"beforeShellExecution", "veto"}},
+		{LevelHooks, ".cursor/hooks.json", []string{"beforeShellExecution", "veto"}},
+		{LevelDenyList, "~/.cursor/cli-config.json", []string{`"deny"`}},
+		{LevelDenyList, "@/hooks.json", []string{`"deny"`}},
+		{LevelInstructions, ".cursorrules", []string{"veto"}},
+	},
+	"windsurf": {
+		{LevelHooks, "~/.codeium/windsurf/hooks.json", []string{"veto"}},
+		{LevelHooks, ".windsurf/hooks.json", []string{"veto"}},
+		{LevelDenyList, "@/cascade/hooks.json", []string{"deny_patterns"}},
+		{LevelInstructions, ".windsurfrules", []string{"veto"}},
+	},
+	"aider": {
+		// Aider has no hooks; read-only files are the strongest option
+		{LevelDenyList, "~/.aider.conf.yml", []string{"veto", "read-only"}},
+		{LevelDenyList, ".aider.conf.yml", []string{"veto", "read-only"}},
+		{LevelInstructions, "CONVENTIONS.md", []string{"veto"}},
+	},
+	"copilot": {
+		// Blocked commands always need approval, but aren't checked live
+		{LevelDenyList, "@/settings.json", []string{"chat.tools.terminal.autoApprove", "veto"}},
+		{LevelDenyList, ".vscode/settings.json", []string{"chat.tools.terminal.autoApprove", "veto"}},
+		{LevelInstructions, "@/prompts/veto.instructions.md", []string{"veto"}},
+		{LevelInstructions, ".github/copilot-instructions.md", []string{"veto"}},
+	},
+	"goose": {
+		// Goose permissions are per tool, not per command
+		{LevelInstructions, "@/.goosehints", []string{"veto"}},
+		{LevelInstructions, ".goosehints", []string{"veto"}},
+	},
+	"amazon-q": {
+		// Only in sessions using the veto agent (q chat --agent veto)
+		{LevelDenyList, "@/cli-agents/veto.json", []string{"deniedCommands"}},
+		{LevelDenyList, ".amazonq/cli-agents/veto.json", []string{"deniedCommands"}},
+		{LevelInstructions, "@/rules/veto.md", []string{"veto"}},
+		{LevelInstructions, ".amazonq/rules/veto.md", []string{"veto"}},
+	},
+}
+
+// Enforce reports the strongest integration found for an agent, looking
+// in its global config and in the project at projectDir.
+func Enforce(a *Agent, projectDir string) Enforcement {
+	home, _ := os.UserHomeDir()
+	best := Enforcement{}
+	for _, p := range probes[a.ID] {
+		if p.level <= best.Level {
+			continue
+		}
+		var path string
+		switch {
+		case strings.HasPrefix(p.path, "~/"):
+			path = filepath.Join(home, p.path[2:])
+		case strings.HasPrefix(p.path, "@/"):
+			path = filepath.Join(GetConfigDir(a), p.path[2:])
+		default:
+			path = filepath.Join(projectDir, p.path)
+		}
+		if fileContains(path, p.needs) {
+			best = Enforcement{Level: p.level, Source: path}
+		}
+	}
+	return best
+}
+
+// fileContains reports whether the file exists and contains every string.
+func fileContains(path string, needs []string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for _, n := range needs {
+		if !strings.Contains(string(data), n) {
+			return false
+		}
+	}
+	return true
+}
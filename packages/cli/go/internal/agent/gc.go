@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Record notes which project an agent's global config was last written
+// from. Each global install replaces the agent's previous config, so
+// there is at most one record per agent.
+type Record struct {
+	Agent string `json:"agent"`
+	// Root is the project directory the policies came from
+	Root string `json:"root"`
+	// Policies are the .veto phrases installed
+	Policies    []string  `json:"policies"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// RecordsPath returns the per-user file global installs are recorded in.
+func RecordsPath() string {
+	return filepath.Join(xdg.ConfigDir(), "installs.json")
+}
+
+// LoadRecords reads the recorded global installs. A missing file yields none.
+func LoadRecords() ([]Record, error) {
+	data, err := os.ReadFile(RecordsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func saveRecords(records []Record) error {
+	path := RecordsPath()
+	if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// record replaces the agent's global install record.
+func record(agentID, root string, policies []string) error {
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	records = slices.DeleteFunc(records, func(r Record) bool { return r.Agent == agentID })
+	records = append(records, Record{Agent: agentID, Root: root, Policies: policies, InstalledAt: time.Now()})
+	return saveRecords(records)
+}
+
+// Stale describes a global install that no longer matches its project.
+type Stale struct {
+	Agent string
+	Root  string
+	// Reason explains what changed in the project
+	Reason string
+	// Removed lists phrases no longer in the project's .veto
+	Removed []string
+	// Gone means the project or its .veto no longer exists, so the
+	// global config is removed rather than resynced
+	Gone bool
+	// Unowned means the config predates install records, so its project
+	// is unknown; these are only reported
+	Unowned bool
+}
+
+// FindStale checks every global install against its project.
+func FindStale() ([]Stale, error) {
+	records, err := LoadRecords()
+	if err != nil {
+		return nil, err
+	}
+	var stale []Stale
+	for _, r := range records {
+		s := Stale{Agent: r.Agent, Root: r.Root}
+		cfg, err := config.Load(filepath.Join(r.Root, ".veto"))
+		switch {
+		case err != nil && !dirExists(r.Root):
+			s.Reason, s.Gone = "project no longer exists", true
+		case err != nil:
+			s.Reason, s.Gone = "project has no readable .veto", true
+		default:
+			for _, phrase := range r.Policies {
+				if !slices.Contains(cfg.Policies, phrase) {
+					s.Removed = append(s.Removed, phrase)
+				}
+			}
+			if len(s.Removed) == 0 {
+				continue
+			}
+			s.Reason = "policies removed from .veto"
+		}
+		stale = append(stale, s)
+	}
+
+	for i := range All {
+		a := &All[i]
+		if slices.ContainsFunc(records, func(r Record) bool { return r.Agent == a.ID }) {
+			continue
+		}
+		marker, ok := markerFor(a, ScopeGlobal)
+		if ok && fileContains(filepath.Join(GetConfigDir(a), filepath.FromSlash(marker)), []string{"veto"}) {
+			stale = append(stale, Stale{Agent: a.ID, Reason: "written by an earlier veto without a project record", Unowned: true})
+		}
+	}
+	return stale, nil
+}
+
+// Clean fixes a stale install: configs of gone projects are removed, the
+// rest are resynced from their project's current .veto. Unowned configs
+// are left alone. A gone project's record is dropped even when its agent
+// can't be found any more, as a custom agent defined in it can't.
+func Clean(s Stale) error {
+	if s.Unowned {
+		return nil
+	}
+	if !s.Gone {
+		return install(s.Agent, ScopeGlobal, s.Root)
+	}
+
+	a := findIn(s.Agent, s.Root)
+	if a == nil {
+		a = Find(s.Agent)
+	}
+	if a != nil {
+		if err := uninstall(a, ScopeGlobal, GetConfigDir(a), s.Root, &Report{}); err != nil {
+			return err
+		}
+	}
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	return saveRecords(slices.DeleteFunc(records, func(r Record) bool { return r.Agent == s.Agent }))
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// goneProject installs agentID globally from a project that is then
+// deleted, returning its stale install.
+func goneProject(t *testing.T, agentID string) Stale {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("protect .env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := install(agentID, ScopeGlobal, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := FindStale()
+	if err != nil || len(stale) != 1 || !stale[0].Gone {
+		t.Fatalf("FindStale() = %+v, %v, want the deleted project", stale, err)
+	}
+	return stale[0]
+}
+
+func TestCleanKeepsUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	config := filepath.Join(home, ".aider.conf.yml")
+	user := "# my settings\nmodel: sonnet\n"
+	if err := os.WriteFile(config, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Clean(goneProject(t, "aider")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(config); err != nil || string(data) != user {
+		t.Errorf(".aider.conf.yml after clean = %q, %v, want the user's:\n%s", data, err, user)
+	}
+	if _, err := os.Stat(filepath.Join(home, "CONVENTIONS.md")); !os.IsNotExist(err) {
+		t.Errorf("CONVENTIONS.md left behind: %v", err)
+	}
+	if records, err := LoadRecords(); err != nil || len(records) != 0 {
+		t.Errorf("records after clean = %+v, %v, want none", records, err)
+	}
+}
+
+func TestCleanDropsUnknownAgent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	// A custom agent defined by a project that is gone, so is its definition
+	if err := saveRecords([]Record{{Agent: "acme", Root: filepath.Join(t.TempDir(), "gone")}}); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := FindStale()
+	if err != nil || len(stale) != 1 {
+		t.Fatalf("FindStale() = %+v, %v", stale, err)
+	}
+	if err := Clean(stale[0]); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := LoadRecords(); err != nil || len(records) != 0 {
+		t.Errorf("records after clean = %+v, %v, want none", records, err)
+	}
+}
@@ -1,157 +1,194 @@
 package agent
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/VulnZap/veto/internal/builtin"
 	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/matcher"
 	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/testrun"
+	"gopkg.in/yaml.v3"
 )
 
-// Install installs veto hooks for an agent.
-func Install(agentID string) error {
-	agent := Find(agentID)
-	if agent == nil {
-		return fmt.Errorf("unknown agent: %s", agentID)
-	}
-
-	switch agent.ID {
-	case "claude-code":
-		return installClaudeCode(agent)
-	case "opencode":
-		return installOpenCode(agent)
-	case "windsurf":
-		return installWindsurf(agent)
-	case "cursor":
-		return installCursor(agent)
-	case "aider":
-		return installAider(agent)
-	default:
-		return fmt.Errorf("agent %s not yet supported for installation", agent.ID)
+// Install installs veto hooks for an agent, into the current project's
+// agent config or the agent's global config depending on scope. Global
+// installs are recorded so `veto gc` can clean them up later.
+func Install(agentID string, scope Scope) error {
+	root, err := ProjectRoot()
+	if err != nil {
+		return err
 	}
+	return install(agentID, scope, root)
 }
 
-// Uninstall removes veto hooks for an agent.
-func Uninstall(agentID string) error {
-	agent := Find(agentID)
+// install writes the policies of the project at root into an agent's config.
+func install(agentID string, scope Scope, root string) error {
+	agent := findIn(agentID, root)
 	if agent == nil {
-		return fmt.Errorf("unknown agent: %s", agentID)
+		return unknownAgent(agentID, root)
 	}
-
-	switch agent.ID {
-	case "claude-code":
-		return uninstallClaudeCode(agent)
-	case "opencode":
-		return uninstallOpenCode(agent)
+	dir, err := scopeDir(agent, scope, root)
+	if err != nil {
+		return err
+	}
+	cfg, policies, err := loadPolicies(root)
+	if err != nil {
+		return err
+	}
+	l := newLister(cfg, root)
+
+	switch {
+	case agent.Custom != nil:
+		err = installCustom(agent.Custom, dir, scope, policies, l)
+	case agent.ID == "claude-code":
+		err = installClaudeCode(dir, policies, l)
+	case agent.ID == "opencode":
+		err = installOpenCode(dir, policies, l)
+		if err == nil && scope == ScopeProject {
+			err = installOpenCodeNested(root, l)
+		}
+	case agent.ID == "windsurf":
+		err = installWindsurf(dir, scope, policies)
+	case agent.ID == "cursor":
+		err = installCursor(dir, policies)
+	case agent.ID == "aider":
+		err = installAider(dir, scope, policies, l)
+	case agent.ID == "copilot":
+		err = installCopilot(dir, scope, policies, l)
+	case agent.ID == "goose":
+		err = installGoose(dir, policies, l)
+	case agent.ID == "amazon-q":
+		err = installAmazonQ(dir, scope, policies, l)
 	default:
-		return fmt.Errorf("uninstall not yet implemented for %s", agent.ID)
+		return fmt.Errorf("agent %s not yet supported for installation", agent.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if cfg != nil {
+		if err := recordSync(root, agent.ID, scope, policies); err != nil {
+			return err
+		}
+	}
+	if scope != ScopeGlobal {
+		return nil
 	}
+	var phrases []string
+	if cfg != nil {
+		phrases = cfg.Policies
+	}
+	return record(agent.ID, root, phrases)
 }
 
-// loadPolicies loads and compiles policies from .veto config.
-func loadPolicies() ([]*policy.Policy, error) {
-	if !config.Exists() {
-		return nil, nil
+// unknownAgent explains why agentID isn't an agent of the project at
+// root: a custom agent definition that doesn't load, or no such agent.
+func unknownAgent(agentID, root string) error {
+	if _, err := LoadCustom(root); err != nil {
+		return err
 	}
+	return fmt.Errorf("unknown agent: %s", agentID)
+}
 
-	path, err := config.Find()
-	if err != nil {
-		return nil, err
+// scopeDir returns the directory an agent's config is written to for the
+// project at root.
+func scopeDir(agent *Agent, scope Scope, root string) (string, error) {
+	switch scope {
+	case ScopeGlobal:
+		return GetConfigDir(agent), nil
+	case ScopeProject:
+		return ProjectConfigDir(agent, root), nil
 	}
+	return "", fmt.Errorf("unknown scope %q (want project or global)", scope)
+}
 
-	cfg, err := config.Load(path)
-	if err != nil {
-		return nil, err
+// ProjectRoot is the directory holding the .veto file, or the working
+// directory when there is none.
+func ProjectRoot() (string, error) {
+	if path, err := config.Find(); err == nil {
+		return filepath.Dir(path), nil
 	}
+	return os.Getwd()
+}
 
-	var policies []*policy.Policy
-	for _, policyStr := range cfg.Policies {
-		// Try builtins first
-		if b := builtin.Find(policyStr); b != nil {
-			policies = append(policies, b.ToPolicy(policy.ActionDelete))
-		} else {
-			// TODO: LLM compilation for non-builtins
-			// For now, create a basic policy
-			policies = append(policies, &policy.Policy{
-				Action:      policy.ActionDelete,
-				Description: policyStr,
-			})
-		}
+// loadPolicies loads and compiles the policies of the project at root.
+// A project without a .veto file has none.
+func loadPolicies(root string) (*config.VetoConfig, []*policy.Policy, error) {
+	path := filepath.Join(root, ".veto")
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, nil
 	}
 
-	return policies, nil
+	p, err := project.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.Config, p.Policies, nil
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
 // CLAUDE CODE
 // ═══════════════════════════════════════════════════════════════════════════════
 
-func installClaudeCode(agent *Agent) error {
-	policies, err := loadPolicies()
-	if err != nil {
+func installClaudeCode(configDir string, policies []*policy.Policy, l lister) error {
+	if err := readonly.MkdirAll(configDir, 0755); err != nil {
 		return err
 	}
 
-	configDir := GetConfigDir(agent)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	// Generate CLAUDE.md with policy rules
+	list, err := l.list(configDir, policies)
+	if err != nil {
 		return err
 	}
-
-	// Generate CLAUDE.md with policy rules
-	claudeMD := generateClaudeMD(policies)
+	claudeMD := generateClaudeMD(list)
 	claudePath := filepath.Join(configDir, "CLAUDE.md")
-	if err := os.WriteFile(claudePath, []byte(claudeMD), 0644); err != nil {
+	if err := writeInstructions(claudePath, claudeMD); err != nil {
 		return err
 	}
 
-	// Generate settings.json with permission rules
-	settingsPath := filepath.Join(configDir, "settings.json")
-	settings := generateClaudeSettings(policies)
-	settingsJSON, err := json.MarshalIndent(settings, "", "  ")
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(settingsPath, settingsJSON, 0644); err != nil {
-		return err
+	// Slash command and subagents so Claude can ask about policies itself
+	for _, name := range claudeDocNames {
+		path := filepath.Join(configDir, filepath.FromSlash(name))
+		if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := writeInstructions(path, claudeDocs[name]); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	// Merge permission rules into settings.json
+	return mergeJSON(filepath.Join(configDir, "settings.json"), generateClaudeSettings(policies))
 }
 
-func uninstallClaudeCode(agent *Agent) error {
-	configDir := GetConfigDir(agent)
-
+func uninstallClaudeCode(configDir string, r *Report) error {
 	// Remove veto-generated files
-	os.Remove(filepath.Join(configDir, "CLAUDE.md"))
+	r.remove(filepath.Join(configDir, "CLAUDE.md"))
+	r.removeAppendix(configDir)
+	for _, name := range claudeDocNames {
+		r.remove(filepath.Join(configDir, filepath.FromSlash(name)))
+	}
 
-	return nil
+	return r.unmergeJSON(filepath.Join(configDir, "settings.json"))
 }
 
-func generateClaudeMD(policies []*policy.Policy) string {
+// generateClaudeMD wraps a policy list (see lister.list) for CLAUDE.md.
+func generateClaudeMD(list string) string {
 	md := `# Project Policies (managed by veto)
 
 The following restrictions are enforced by veto:
 
 `
-	for _, p := range policies {
-		md += fmt.Sprintf("- %s\n", p.Description)
-
-		// Add command rules
-		for _, rule := range p.CommandRules {
-			md += fmt.Sprintf("  - BLOCKED commands: %v\n", rule.Block)
-			if rule.Suggest != "" {
-				md += fmt.Sprintf("    Use instead: %s\n", rule.Suggest)
-			}
-		}
-
-		// Add file patterns
-		if len(p.Include) > 0 {
-			md += fmt.Sprintf("  - Protected files: %v\n", p.Include)
-		}
-	}
+	md += list
 
 	md += `
 IMPORTANT: Before executing any command or modifying any file, check if it violates these policies.
@@ -160,76 +197,209 @@ If a command is blocked, suggest the alternative instead.
 	return md
 }
 
-func generateClaudeSettings(policies []*policy.Policy) map[string]interface{} {
-	var denyPatterns []string
+// claudeDocNames are the slash command and subagents written next to
+// CLAUDE.md, relative to the config dir.
+var claudeDocNames = []string{"commands/veto.md", "agents/veto-policies.md", "agents/veto-exceptions.md"}
+
+// claudeDocs query veto at run time rather than listing policies, so they
+// stay accurate between syncs.
+var claudeDocs = map[string]string{
+	"commands/veto.md": `---
+description: Show this project's veto policies, or check whether an action is allowed
+argument-hint: [command or file path]
+allowed-tools: Bash(veto list), Bash(veto match:*), Bash(veto match-cmd:*)
+---
+<!-- managed by veto: rewritten by veto sync -->
+
+Commands and file edits in this project are checked by veto. A blocked action
+stays blocked however it is rephrased, so check first instead of retrying.
+
+If no arguments were given, run ` + "`veto list`" + ` and summarize the policies in force.
+
+Otherwise check "$ARGUMENTS":
+- For a shell command, run ` + "`veto match-cmd \"$ARGUMENTS\"`" + `
+- For a file path, run ` + "`veto match \"$ARGUMENTS\"`" + `
+
+Report which policy matches, the reason, and the suggested alternative. If a
+policy blocks work the user asked for, hand off to the veto-exceptions agent.
+`,
+	"agents/veto-policies.md": `---
+name: veto-policies
+description: Answers whether a command or file change is allowed by this project's veto policies, and what to do instead. Use before running anything that might be blocked.
+tools: Bash
+---
+<!-- managed by veto: rewritten by veto sync -->
+
+You answer questions about the veto policies enforced in this project. You
+never run the action being asked about.
+
+- ` + "`veto list`" + ` shows the policies in force.
+- ` + "`veto match-cmd \"<command>\"`" + ` explains which policies match a shell command.
+- ` + "`veto match <path>`" + ` explains which policies match a file.
+
+Answer with whether the action is allowed, which policy decides it and why,
+and the alternative the policy suggests. Keep it short.
+`,
+	"agents/veto-exceptions.md": `---
+name: veto-exceptions
+description: Drafts an exception when a veto policy blocks an action the user asked for. Proposes the narrowest allow rule for the user to approve; never applies it.
+tools: Bash, Read
+---
+<!-- managed by veto: rewritten by veto sync -->
+
+An action the user asked for was blocked by a veto policy. Your job is to
+draft an exception the user can approve, not to get around the policy.
+
+1. Find the policy and rule that blocked it with ` + "`veto match-cmd \"<command>\"`" + `
+   or ` + "`veto match <path>`" + `.
+2. Read .veto to see how the policy is written.
+3. Propose the narrowest exception as an allow rule on that policy:
+
+   ` + "```yaml" + `
+   policies:
+     - policy: no force push
+       allow:
+         - commands: ["git push --force origin my-feature"]
+           reason: rewriting my own feature branch
+   ` + "```" + `
+
+   When the action should need a human each time, propose ` + "`decision: ask`" + `
+   instead. If the policy is ` + "`locked: true`" + `, say that it can only be
+   changed by whoever owns it.
+4. Return the proposal with one sentence on why it's needed, and stop.
+
+Never edit .veto, and never run the blocked action or a workaround for it.
+`,
+}
 
+// denyPatterns collects the policies' blocked command patterns for agents
+// that match commands themselves, with their PowerShell and cmd.exe forms
+// so the deny lists hold on Windows too.
+func denyPatterns(policies []*policy.Policy) []string {
+	var patterns []string
 	for _, p := range policies {
-		// Add command patterns to deny
 		for _, rule := range p.CommandRules {
-			denyPatterns = append(denyPatterns, rule.Block...)
+			for _, pattern := range rule.Block {
+				patterns = append(patterns, pattern)
+				patterns = append(patterns, matcher.WindowsPatterns(pattern)...)
+			}
 		}
 	}
+	return patterns
+}
 
-	return map[string]interface{}{
+// hintsTimeout bounds the PostToolUse hook when it only adds policy hints.
+const hintsTimeout = 10 * time.Second
+
+func generateClaudeSettings(policies []*policy.Policy) map[string]interface{} {
+	denyPatterns := denyPatterns(policies)
+
+	settings := map[string]interface{}{
 		"permissions": map[string]interface{}{
 			"bash": map[string]interface{}{
 				"deny": denyPatterns,
 			},
 		},
 	}
+
+	// After reads and edits, the hook tells Claude which policies concern
+	// the file, and policies with a test command run it
+	timeout := hintsTimeout
+	for _, p := range policies {
+		if p.TestCommand != "" {
+			timeout = testrun.Timeout + 10*time.Second
+			break
+		}
+	}
+	settings["hooks"] = map[string]interface{}{
+		"PostToolUse": []interface{}{
+			map[string]interface{}{
+				"matcher": "Read|Write|Edit|MultiEdit",
+				"hooks": []interface{}{
+					map[string]interface{}{
+						"type":    "command",
+						"command": "veto hook --agent claude-code",
+						"timeout": int(timeout.Seconds()),
+					},
+				},
+			},
+		},
+	}
+	return settings
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
 // OPENCODE
 // ═══════════════════════════════════════════════════════════════════════════════
 
-func installOpenCode(agent *Agent) error {
-	policies, err := loadPolicies()
-	if err != nil {
+func installOpenCode(configDir string, policies []*policy.Policy, l lister) error {
+	if err := readonly.MkdirAll(configDir, 0755); err != nil {
 		return err
 	}
 
-	configDir := GetConfigDir(agent)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	// Merge permissions into opencode.json
+	if err := mergeJSON(filepath.Join(configDir, "opencode.json"), generateOpenCodeConfig(policies)); err != nil {
 		return err
 	}
 
-	// Generate opencode.json config
-	configPath := filepath.Join(configDir, "opencode.json")
-	ocConfig := generateOpenCodeConfig(policies)
-	configJSON, err := json.MarshalIndent(ocConfig, "", "  ")
+	// Generate AGENTS.md
+	list, err := l.list(configDir, policies)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(configPath, configJSON, 0644); err != nil {
-		return err
-	}
-
-	// Generate AGENTS.md
-	agentsMD := generateAgentsMD(policies)
+	agentsMD := generateAgentsMD(list)
 	agentsPath := filepath.Join(configDir, "AGENTS.md")
-	if err := os.WriteFile(agentsPath, []byte(agentsMD), 0644); err != nil {
+	if err := writeInstructions(agentsPath, agentsMD); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func uninstallOpenCode(agent *Agent) error {
-	configDir := GetConfigDir(agent)
-	os.Remove(filepath.Join(configDir, "opencode.json"))
-	os.Remove(filepath.Join(configDir, "AGENTS.md"))
-	return nil
+func uninstallOpenCode(configDir string, r *Report) error {
+	r.remove(filepath.Join(configDir, "AGENTS.md"))
+	r.removeAppendix(configDir)
+	return r.unmergeJSON(filepath.Join(configDir, "opencode.json"))
 }
 
-func generateOpenCodeConfig(policies []*policy.Policy) map[string]interface{} {
-	var denyPatterns []string
-
-	for _, p := range policies {
-		for _, rule := range p.CommandRules {
-			denyPatterns = append(denyPatterns, rule.Block...)
+// installOpenCodeNested writes an AGENTS.md next to each nested .veto
+// below root, so OpenCode reads the policies of the package it's editing
+// rather than only the root's.
+func installOpenCodeNested(root string, l lister) error {
+	dirs, err := project.Nested(root)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		_, policies, err := loadPolicies(dir)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, dir)
+		list, err := l.list(dir, policies)
+		if err != nil {
+			return err
+		}
+		md := generateNestedAgentsMD(filepath.ToSlash(rel), list)
+		if err := writeInstructions(filepath.Join(dir, "AGENTS.md"), md); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// uninstallOpenCodeNested removes the AGENTS.md files veto wrote for
+// nested projects, leaving any the user wrote.
+func uninstallOpenCodeNested(root string, r *Report) {
+	dirs, _ := project.Nested(root)
+	for _, dir := range dirs {
+		r.remove(filepath.Join(dir, "AGENTS.md"))
+		r.removeAppendix(dir)
+	}
+}
+
+func generateOpenCodeConfig(policies []*policy.Policy) map[string]interface{} {
+	denyPatterns := denyPatterns(policies)
 
 	return map[string]interface{}{
 		"permission": map[string]interface{}{
@@ -240,15 +410,13 @@ func generateOpenCodeConfig(policies []*policy.Policy) map[string]interface{} {
 	}
 }
 
-func generateAgentsMD(policies []*policy.Policy) string {
+func generateAgentsMD(list string) string {
 	md := `# AGENTS.md (managed by veto)
 
 ## Enforced Policies
 
 `
-	for _, p := range policies {
-		md += fmt.Sprintf("- %s\n", p.Description)
-	}
+	md += list
 
 	md += `
 ## Rules
@@ -258,40 +426,54 @@ Before executing commands or modifying files, verify they don't violate the abov
 	return md
 }
 
+func generateNestedAgentsMD(dir, list string) string {
+	md := `# AGENTS.md (managed by veto)
+
+## Enforced Policies in ` + dir + `/
+
+These come from ` + dir + `/.veto and replace the repository root's policies
+for files in this directory.
+
+`
+	md += list
+
+	md += `
+## Rules
+
+Before executing commands or modifying files here, verify they don't violate the above policies.
+`
+	return md
+}
+
 // ═══════════════════════════════════════════════════════════════════════════════
 // WINDSURF
 // ═══════════════════════════════════════════════════════════════════════════════
 
-func installWindsurf(agent *Agent) error {
-	policies, err := loadPolicies()
-	if err != nil {
-		return err
+// windsurfHooks returns where Windsurf reads cascade hooks from: under
+// cascade/ globally, in .windsurf/ for a project.
+func windsurfHooks(configDir string, scope Scope) string {
+	if scope == ScopeGlobal {
+		return filepath.Join(configDir, "cascade", "hooks.json")
 	}
+	return filepath.Join(configDir, "hooks.json")
+}
 
-	configDir := GetConfigDir(agent)
-	cascadeDir := filepath.Join(configDir, "cascade")
-	if err := os.MkdirAll(cascadeDir, 0755); err != nil {
+func installWindsurf(configDir string, scope Scope, policies []*policy.Policy) error {
+	hooks := windsurfHooks(configDir, scope)
+	if err := readonly.MkdirAll(filepath.Dir(hooks), 0755); err != nil {
 		return err
 	}
 
-	// Generate cascade hooks
-	hooksPath := filepath.Join(cascadeDir, "hooks.json")
-	hooks := generateWindsurfHooks(policies)
-	hooksJSON, err := json.MarshalIndent(hooks, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(hooksPath, hooksJSON, 0644)
+	// Merge cascade hooks
+	return mergeJSON(hooks, generateWindsurfHooks(policies))
 }
 
-func generateWindsurfHooks(policies []*policy.Policy) map[string]interface{} {
-	var denyPatterns []string
+func uninstallWindsurf(configDir string, scope Scope, r *Report) error {
+	return r.unmergeJSON(windsurfHooks(configDir, scope))
+}
 
-	for _, p := range policies {
-		for _, rule := range p.CommandRules {
-			denyPatterns = append(denyPatterns, rule.Block...)
-		}
-	}
+func generateWindsurfHooks(policies []*policy.Policy) map[string]interface{} {
+	denyPatterns := denyPatterns(policies)
 
 	return map[string]interface{}{
 		"pre_run_command": map[string]interface{}{
@@ -304,67 +486,533 @@ func generateWindsurfHooks(policies []*policy.Policy) map[string]interface{} {
 // CURSOR
 // ═══════════════════════════════════════════════════════════════════════════════
 
-func installCursor(agent *Agent) error {
-	policies, err := loadPolicies()
+func installCursor(configDir string, policies []*policy.Policy) error {
+	if err := readonly.MkdirAll(configDir, 0755); err != nil {
+		return err
+	}
+
+	// Merge hooks.json
+	return mergeJSON(filepath.Join(configDir, "hooks.json"), generateCursorHooks(policies))
+}
+
+func uninstallCursor(configDir string, r *Report) error {
+	return r.unmergeJSON(filepath.Join(configDir, "hooks.json"))
+}
+
+func generateCursorHooks(policies []*policy.Policy) map[string]interface{} {
+	denyPatterns := denyPatterns(policies)
+
+	return map[string]interface{}{
+		"beforeShellExecution": map[string]interface{}{
+			"deny": denyPatterns,
+		},
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// AIDER
+// ═══════════════════════════════════════════════════════════════════════════════
+
+func installAider(configDir string, scope Scope, policies []*policy.Policy, l lister) error {
+	list, err := l.list(configDir, policies)
 	if err != nil {
 		return err
 	}
+	conventions := filepath.Join(configDir, "CONVENTIONS.md")
+	if err := writeInstructions(conventions, generateConventionsMD(list)); err != nil {
+		return err
+	}
+	// Project configs are committed, so they name the file relatively
+	read := "CONVENTIONS.md"
+	if scope == ScopeGlobal {
+		read = conventions
+	}
 
-	configDir := GetConfigDir(agent)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	var readOnly []string
+	for _, p := range policies {
+		// Content policies use Include to scope their rules; the files
+		// themselves stay editable
+		if len(p.ContentRules) == 0 {
+			readOnly = append(readOnly, p.Include...)
+		}
+	}
+
+	configPath := filepath.Join(configDir, ".aider.conf.yml")
+	doc, err := loadAiderConfig(configPath)
+	if err != nil {
 		return err
 	}
+	conf := doc.Content[0]
+	setAiderKey(conf, "read-only", stringList(readOnly), doc.HeadComment)
+	setAiderKey(conf, "read", stringList([]string{read}), doc.HeadComment)
+	testCmd, lintCmd := aiderCommands(policies)
+	if testCmd != "" {
+		setAiderKey(conf, "test-cmd", stringNode(testCmd), doc.HeadComment)
+	}
+	if lintCmd != "" {
+		setAiderKey(conf, "lint-cmd", stringNode(lintCmd), doc.HeadComment)
+	}
+
+	return writeAiderConfig(configPath, doc)
+}
 
-	// Generate hooks.json
-	hooksPath := filepath.Join(configDir, "hooks.json")
-	hooks := generateCursorHooks(policies)
-	hooksJSON, err := json.MarshalIndent(hooks, "", "  ")
+// uninstallAider removes CONVENTIONS.md and the .aider.conf.yml keys and
+// list items veto added, removing the config too if nothing else is left.
+func uninstallAider(configDir string, r *Report) error {
+	r.remove(filepath.Join(configDir, "CONVENTIONS.md"))
+	r.removeAppendix(configDir)
+
+	configPath := filepath.Join(configDir, ".aider.conf.yml")
+	if _, err := os.Stat(configPath); err != nil {
+		return nil
+	}
+	doc, err := loadAiderConfig(configPath)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(hooksPath, hooksJSON, 0644)
+	conf := doc.Content[0]
+	if !unsetAiderKeys(conf, doc.HeadComment) {
+		return nil
+	}
+	if len(conf.Content) == 0 {
+		r.Removed = append(r.Removed, configPath)
+		return readonly.Remove(configPath)
+	}
+	r.Reverted = append(r.Reverted, configPath)
+	return writeAiderConfig(configPath, doc)
 }
 
-func generateCursorHooks(policies []*policy.Policy) map[string]interface{} {
-	var denyPatterns []string
+func writeAiderConfig(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func generateConventionsMD(list string) string {
+	md := `# Conventions (managed by veto)
+
+These project policies are enforced by veto:
+
+`
+	md += list
+
+	md += `
+Follow them when writing code or suggesting shell commands, and use the suggested alternative instead of a blocked one.
+`
+	return md
+}
+
+// aiderMarker tags the .aider.conf.yml keys veto owns. Keys the user set
+// are left alone, except lists, which veto's entries are added to, each
+// tagged too.
+const aiderMarker = "managed by veto"
+
+// aiderManaged reports whether n carries aiderMarker.
+func aiderManaged(n *yaml.Node) bool {
+	return strings.Contains(strings.ToLower(n.HeadComment+n.LineComment), aiderMarker)
+}
+
+// aiderKeyManaged reports whether veto set the key k to v. The marker
+// veto puts on a key is read back on its value when that's a scalar on
+// the same line.
+func aiderKeyManaged(k, v *yaml.Node) bool {
+	return aiderManaged(k) || v.Kind == yaml.ScalarNode && aiderManaged(v)
+}
+
+// loadAiderConfig parses an existing .aider.conf.yml, keeping the user's
+// keys and comments, or starts an empty one.
+func loadAiderConfig(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping of aider options", path)
+	}
+	return doc, nil
+}
+
+// setAiderKey sets key in the mapping conf. Keys veto wrote are replaced;
+// a user's list gets the missing values appended and a user's scalar is
+// kept. fileComment is the document's head comment, which marks files
+// written whole by earlier versions.
+func setAiderKey(conf *yaml.Node, key string, value *yaml.Node, fileComment string) {
+	for i := 0; i+1 < len(conf.Content); i += 2 {
+		k, v := conf.Content[i], conf.Content[i+1]
+		if k.Value != key {
+			continue
+		}
+		switch {
+		case aiderKeyManaged(k, v) || strings.Contains(strings.ToLower(fileComment), aiderMarker):
+			k.LineComment = "# " + aiderMarker
+			conf.Content[i+1] = value
+		case v.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			// Replace the items veto added last time
+			v.Content = slices.DeleteFunc(v.Content, aiderManaged)
+			for _, item := range value.Content {
+				if !hasItem(v, item.Value) {
+					item.LineComment = "# " + aiderMarker
+					v.Content = append(v.Content, item)
+				}
+			}
+		}
+		return
+	}
+	conf.Content = append(conf.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key, LineComment: "# " + aiderMarker}, value)
+}
+
+// unsetAiderKeys removes the keys veto set in the mapping conf and the
+// items it added to the user's lists, reporting whether anything was.
+func unsetAiderKeys(conf *yaml.Node, fileComment string) bool {
+	wholeFile := strings.Contains(strings.ToLower(fileComment), aiderMarker)
+	changed := false
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(conf.Content); i += 2 {
+		k, v := conf.Content[i], conf.Content[i+1]
+		if wholeFile || aiderKeyManaged(k, v) {
+			changed = true
+			continue
+		}
+		if v.Kind == yaml.SequenceNode {
+			n := len(v.Content)
+			v.Content = slices.DeleteFunc(v.Content, aiderManaged)
+			changed = changed || len(v.Content) != n
+		}
+		kept = append(kept, k, v)
+	}
+	conf.Content = kept
+	return changed
+}
+
+func hasItem(seq *yaml.Node, value string) bool {
+	for _, n := range seq.Content {
+		if n.Value == value {
+			return true
+		}
+	}
+	return false
+}
 
+func stringNode(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+func stringList(items []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, s := range items {
+		seq.Content = append(seq.Content, stringNode(s))
+	}
+	return seq
+}
+
+// execPrefixes run a package's binary with each package manager.
+var execPrefixes = map[string]string{"pnpm": "pnpm exec ", "bun": "bunx ", "yarn": "yarn ", "npm": "npx "}
+
+// testRunners and linters map tools named in policy suggestions to the
+// command aider should run.
+var (
+	testRunners = map[string]string{"vitest": "vitest run", "jest": "jest", "pytest": "pytest"}
+	linters     = map[string]string{"eslint": "eslint .", "biome": "biome check .", "ruff": "ruff check ."}
+	// nodeTools are installed as packages and run through the package manager
+	nodeTools = map[string]bool{"vitest": true, "jest": true, "eslint": true, "biome": true}
+)
+
+// aiderCommands derives aider's test-cmd and lint-cmd from the tools the
+// policies suggest, e.g. "use vitest" with "prefer pnpm" gives
+// "pnpm exec vitest run". Either is "" when no policy names a tool.
+func aiderCommands(policies []*policy.Policy) (testCmd, lintCmd string) {
+	var pm, runner, linter string
 	for _, p := range policies {
-		for _, rule := range p.CommandRules {
-			denyPatterns = append(denyPatterns, rule.Block...)
+		for _, r := range p.CommandRules {
+			fields := strings.Fields(r.Suggestion())
+			if len(fields) == 0 {
+				continue
+			}
+			tool := fields[0]
+			switch {
+			case execPrefixes[tool] != "" && pm == "":
+				pm = tool
+			case testRunners[tool] != "" && runner == "":
+				runner = tool
+			case linters[tool] != "" && linter == "":
+				linter = tool
+			}
 		}
 	}
 
-	return map[string]interface{}{
-		"beforeShellExecution": map[string]interface{}{
-			"deny": denyPatterns,
-		},
+	exec := func(tool, cmd string) string {
+		switch {
+		case !nodeTools[tool]:
+			return cmd
+		case pm == "":
+			return execPrefixes["npm"] + cmd
+		}
+		return execPrefixes[pm] + cmd
+	}
+	switch {
+	case runner != "":
+		testCmd = exec(runner, testRunners[runner])
+	case pm != "":
+		testCmd = pm + " test"
 	}
+	if linter != "" {
+		lintCmd = exec(linter, linters[linter])
+	}
+	return testCmd, lintCmd
 }
 
 // ═══════════════════════════════════════════════════════════════════════════════
-// AIDER
+// GITHUB COPILOT
 // ═══════════════════════════════════════════════════════════════════════════════
 
-func installAider(agent *Agent) error {
-	policies, err := loadPolicies()
+// copilotFiles returns where Copilot reads instructions and settings from:
+// the repo's .github/copilot-instructions.md and .vscode/settings.json, or
+// an instructions file applying everywhere in VS Code's user prompts and
+// the user settings.
+func copilotFiles(configDir string, scope Scope) (instructions, settings string) {
+	if scope == ScopeGlobal {
+		return filepath.Join(configDir, "prompts", "veto.instructions.md"), filepath.Join(configDir, "settings.json")
+	}
+	return filepath.Join(configDir, ".github", "copilot-instructions.md"), filepath.Join(configDir, ".vscode", "settings.json")
+}
+
+func installCopilot(configDir string, scope Scope, policies []*policy.Policy, l lister) error {
+	instructions, settings := copilotFiles(configDir, scope)
+	if err := readonly.MkdirAll(filepath.Dir(instructions), 0755); err != nil {
+		return err
+	}
+	list, err := l.list(filepath.Dir(instructions), policies)
 	if err != nil {
 		return err
 	}
+	md := generateCopilotInstructions(list)
+	if scope == ScopeGlobal {
+		// User instruction files say which files they apply to
+		md = "---\napplyTo: \"**\"\n---\n" + md
+	}
+	if err := writeInstructions(instructions, md); err != nil {
+		return err
+	}
+	return mergeJSON(settings, generateCopilotSettings(policies))
+}
 
-	home, _ := os.UserHomeDir()
-	configPath := filepath.Join(home, ".aider.conf.yml")
+func uninstallCopilot(configDir string, scope Scope, r *Report) error {
+	instructions, settings := copilotFiles(configDir, scope)
+	r.remove(instructions)
+	r.removeAppendix(filepath.Dir(instructions))
+	return r.unmergeJSON(settings)
+}
 
-	// Generate read-only patterns
-	var readOnlyPatterns []string
-	for _, p := range policies {
-		readOnlyPatterns = append(readOnlyPatterns, p.Include...)
+func generateCopilotInstructions(list string) string {
+	md := `# Project Policies (managed by veto)
+
+Commands and file edits in this repository are checked against these policies:
+
+`
+	md += list
+
+	md += `
+Before running a command or changing a file, check it against these policies,
+and use the suggested alternative instead of a blocked command.
+`
+	return md
+}
+
+// generateCopilotSettings turns off auto-approval of blocked commands in
+// Copilot's agent mode, so they always need the user's approval.
+func generateCopilotSettings(policies []*policy.Policy) map[string]interface{} {
+	approve := make(map[string]interface{})
+	for _, pattern := range denyPatterns(policies) {
+		approve[copilotPattern(pattern)] = false
+	}
+	if len(approve) == 0 {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{"chat.tools.terminal.autoApprove": approve}
+}
+
+// copilotPattern converts a command glob into a key of VS Code's
+// chat.tools.terminal.autoApprove: a command prefix when the glob's only
+// wildcard is a final *, otherwise a /regex/ matching the whole command.
+func copilotPattern(pattern string) string {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && !strings.ContainsAny(prefix, "*?[]{}/") {
+		return strings.TrimSpace(prefix)
+	}
+	return "/" + strings.ReplaceAll(commandRegex(pattern), "/", `\/`) + "/"
+}
+
+// commandRegex converts a command glob into a regular expression matching
+// the whole command, for agents that take regexes.
+func commandRegex(pattern string) string {
+	var re strings.Builder
+	re.WriteString("^")
+	braces, class := 0, false
+	for i, r := range pattern {
+		switch {
+		case class && r == ']':
+			class = false
+			re.WriteRune(r)
+		case class && r == '!' && pattern[i-1] == '[':
+			re.WriteString("^")
+		case class:
+			re.WriteString(strings.ReplaceAll(string(r), `\`, `\\`))
+		case r == '[':
+			class = true
+			re.WriteRune(r)
+		case r == '*':
+			re.WriteString(".*")
+		case r == '?':
+			re.WriteString(".")
+		case r == '{':
+			braces++
+			re.WriteString("(")
+		case r == '}' && braces > 0:
+			braces--
+			re.WriteString(")")
+		case r == ',' && braces > 0:
+			re.WriteString("|")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	return re.String()
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// GOOSE
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// installGoose writes .goosehints, which Goose adds to every session. Its
+// permissions are per tool rather than per command, so there is no deny
+// list to write.
+func installGoose(configDir string, policies []*policy.Policy, l lister) error {
+	if err := readonly.MkdirAll(configDir, 0755); err != nil {
+		return err
+	}
+	list, err := l.list(configDir, policies)
+	if err != nil {
+		return err
+	}
+	return writeInstructions(filepath.Join(configDir, ".goosehints"), generateGooseHints(list))
+}
+
+func uninstallGoose(configDir string, r *Report) error {
+	r.remove(filepath.Join(configDir, ".goosehints"))
+	r.removeAppendix(configDir)
+	return nil
+}
+
+func generateGooseHints(list string) string {
+	md := `# Project Policies (managed by veto)
+
+These policies are enforced by veto:
+
+`
+	md += list
+
+	md += `
+Check commands and file changes against them first, and use the suggested alternative instead of a blocked command.
+`
+	return md
+}
+
+// ═══════════════════════════════════════════════════════════════════════════════
+// AMAZON Q DEVELOPER
+// ═══════════════════════════════════════════════════════════════════════════════
+
+// installAmazonQ writes the policies as a rule file, which Q adds to its
+// context, and a "veto" agent (q chat --agent veto) whose shell tool
+// refuses the blocked commands.
+func installAmazonQ(configDir string, scope Scope, policies []*policy.Policy, l lister) error {
+	rules := filepath.Join(configDir, "rules", "veto.md")
+	agentFile := filepath.Join(configDir, "cli-agents", "veto.json")
+	for _, dir := range []string{filepath.Dir(rules), filepath.Dir(agentFile)} {
+		if err := readonly.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	// The appendix stays out of rules/, which Q reads whole
+	list, err := l.list(configDir, policies)
+	if err != nil {
+		return err
+	}
+	if err := writeInstructions(rules, generateAmazonQRules(list)); err != nil {
+		return err
+	}
+
+	// Project agents run from the project root
+	resource := "file://.amazonq/rules/**/*.md"
+	if scope == ScopeGlobal {
+		resource = "file://" + filepath.ToSlash(rules)
+	}
+	data, err := json.MarshalIndent(generateAmazonQAgent(policies, resource), "", "  ")
+	if err != nil {
+		return err
 	}
+	return writeInstructions(agentFile, string(data)+"\n")
+}
+
+func uninstallAmazonQ(configDir string, r *Report) error {
+	r.remove(filepath.Join(configDir, "rules", "veto.md"))
+	r.remove(filepath.Join(configDir, "cli-agents", "veto.json"))
+	r.removeAppendix(configDir)
+	return nil
+}
+
+func generateAmazonQRules(list string) string {
+	md := `# Project Policies (managed by veto)
+
+Commands and file edits in this project are checked against these policies:
 
-	// Simple YAML generation
-	content := "# Managed by veto\nread-only:\n"
-	for _, pattern := range readOnlyPatterns {
-		content += fmt.Sprintf("  - %s\n", pattern)
+`
+	md += list
+
+	md += `
+Before running a command or changing a file, check it against these policies,
+and use the suggested alternative instead of a blocked command.
+`
+	return md
+}
+
+// generateAmazonQAgent returns the veto agent's config: every tool, the
+// policies as context, and the blocked commands as regexes the shell tool
+// refuses.
+func generateAmazonQAgent(policies []*policy.Policy, rules string) map[string]interface{} {
+	denied := []string{}
+	for _, pattern := range denyPatterns(policies) {
+		denied = append(denied, commandRegex(pattern))
 	}
+	return map[string]interface{}{
+		"name":        "veto",
+		"description": "Default tools with this project's veto policies (managed by veto: rewritten by veto sync)",
+		"tools":       []string{"*"},
+		"resources":   []string{rules, "file://README.md"},
+		"toolsSettings": map[string]interface{}{
+			"execute_bash": map[string]interface{}{"deniedCommands": denied},
+		},
+	}
+}
 
-	return os.WriteFile(configPath, []byte(content), 0644)
+// writeInstructions writes a veto-managed instructions file, refusing to
+// replace one the user wrote.
+func writeInstructions(path, content string) error {
+	if data, err := os.ReadFile(path); err == nil && !strings.Contains(string(data), "managed by veto") {
+		return fmt.Errorf("%s exists and isn't managed by veto; not overwriting it", path)
+	}
+	return readonly.WriteFile(path, []byte(content), 0644)
 }
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestAmazonQDeniedCommands(t *testing.T) {
+	policies := []*policy.Policy{builtin.Find("no force push").ToPolicy(policy.ActionExecute)}
+	settings := generateAmazonQAgent(policies, "file://.amazonq/rules/**/*.md")["toolsSettings"].(map[string]interface{})
+	denied := settings["execute_bash"].(map[string]interface{})["deniedCommands"].([]string)
+
+	matches := func(cmd string) bool {
+		for _, pattern := range denied {
+			if regexp.MustCompile(pattern).MatchString(cmd) {
+				return true
+			}
+		}
+		return false
+	}
+	for cmd, want := range map[string]bool{
+		"git push --force":                true,
+		"git push origin main --force":    true,
+		"git push -f origin main":         true,
+		"git push origin main":            false,
+		"echo git push --force in a note": false,
+	} {
+		if got := matches(cmd); got != want {
+			t.Errorf("%q denied = %t, want %t (patterns %q)", cmd, got, want, denied)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// DefaultBudget is how many tokens an instruction file's policy list may
+// take when .veto doesn't set instructions: budget.
+const DefaultBudget = 2000
+
+// AppendixName is the file the full rules are written to, next to an
+// instruction file whose policy list had to be condensed.
+const AppendixName = "veto-policies.md"
+
+// lister renders the policy lists of the instruction files one install
+// writes, within the project's token budget.
+type lister struct {
+	// root is the project directory, which agents resolve paths from
+	root   string
+	budget int
+}
+
+func newLister(cfg *config.VetoConfig, root string) lister {
+	l := lister{root: root, budget: DefaultBudget}
+	if cfg != nil && cfg.Instructions != nil && cfg.Instructions.Budget > 0 {
+		l.budget = cfg.Instructions.Budget
+	}
+	return l
+}
+
+// list returns the policy list for an instruction file written in dir:
+// every policy in full when that fits the budget, otherwise a condensed
+// list, with the full rules written to an appendix in dir. An appendix
+// left from an earlier sync is removed once the list fits again.
+func (l lister) list(dir string, policies []*policy.Policy) (string, error) {
+	full := message.Instructions(policies)
+	appendix := filepath.Join(dir, AppendixName)
+	if message.Tokens(full) <= l.budget {
+		removeAppendix(dir)
+		return full, nil
+	}
+	md := `# Policies (managed by veto)
+
+The full rules behind the condensed policy list in this directory's
+instruction file.
+
+` + full
+	if err := writeInstructions(appendix, md); err != nil {
+		return "", err
+	}
+	return message.Condensed(policies, l.budget, l.ref(appendix)), nil
+}
+
+// ref names path for an instruction file: relative to the project root
+// when it's inside the project, so committed files stay portable, and in
+// full otherwise.
+func (l lister) ref(path string) string {
+	if rel, err := filepath.Rel(l.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// removeAppendix removes the appendix veto wrote in dir, if any.
+func removeAppendix(dir string) {
+	appendix := filepath.Join(dir, AppendixName)
+	if fileContains(appendix, []string{"managed by veto"}) {
+		readonly.Remove(appendix)
+	}
+}
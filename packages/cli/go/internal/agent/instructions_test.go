@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestListWithinBudget(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".claude")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	var policies []*policy.Policy
+	for i := 0; i < 40; i++ {
+		policies = append(policies, &policy.Policy{
+			Description: fmt.Sprintf("no tool%d", i),
+			CommandRules: []policy.CommandRule{{
+				Block:   []string{fmt.Sprintf("tool%d *", i)},
+				Reason:  "tool" + fmt.Sprint(i) + " rewrites files outside the project and can't be undone",
+				Suggest: fmt.Sprintf("safe-tool%d", i),
+			}},
+		})
+	}
+	policies[39].Severity = policy.SeverityCritical
+
+	l := lister{root: root, budget: 200}
+	list, err := l.list(dir, policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := message.Tokens(list); tokens > 220 {
+		t.Errorf("condensed list takes %d tokens, want about 200:\n%s", tokens, list)
+	}
+	if !strings.HasPrefix(list, "Commands:\n- no tool39 (use safe-tool39)\n") {
+		t.Errorf("condensed list doesn't start with the critical policy:\n%s", list)
+	}
+	if !strings.Contains(list, "more policies") || !strings.Contains(list, "`.claude/"+AppendixName+"`") {
+		t.Errorf("condensed list doesn't point to the appendix:\n%s", list)
+	}
+	appendix := filepath.Join(dir, AppendixName)
+	if !fileContains(appendix, []string{"managed by veto", "**no tool0**", "safe-tool0"}) {
+		t.Error("appendix is missing the full rules")
+	}
+
+	// Once the list fits, it's inline in full and the appendix goes
+	l.budget = DefaultBudget * 10
+	if list, err = l.list(dir, policies); err != nil {
+		t.Fatal(err)
+	}
+	if list != message.Instructions(policies) {
+		t.Errorf("list within budget isn't the full list:\n%s", list)
+	}
+	if _, err := os.Stat(appendix); !os.IsNotExist(err) {
+		t.Errorf("appendix left behind: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// sentinelKey holds what veto wrote into an agent's JSON config, so a
+// later sync or uninstall can take exactly that back out and leave the
+// user's own settings alone.
+const sentinelKey = "_veto"
+
+// sentinel is the value stored under sentinelKey.
+type sentinel struct {
+	Note string `json:"note"`
+	// Managed maps dotted key paths to the value veto put there. For
+	// arrays, only the listed items are veto's.
+	Managed map[string]interface{} `json:"managed"`
+}
+
+const sentinelNote = "managed by veto: the keys listed here are rewritten by veto sync"
+
+// mergeJSON writes the keys of generated into the JSON object at path,
+// keeping everything else in the file. Values veto wrote last time are
+// replaced; arrays the user also added to keep the user's items. The
+// file is rewritten with keys in sorted order.
+func mergeJSON(path string, generated map[string]interface{}) error {
+	doc, err := readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	unmerge(doc)
+
+	// Round-trip so values compare equal to ones read back from disk
+	data, err := json.Marshal(generated)
+	if err != nil {
+		return err
+	}
+	var gen map[string]interface{}
+	if err := json.Unmarshal(data, &gen); err != nil {
+		return err
+	}
+
+	managed := make(map[string]interface{})
+	for _, leaf := range leaves(gen, nil) {
+		key := joinKey(leaf.path)
+		parent := ensure(doc, leaf.path[:len(leaf.path)-1])
+		name := leaf.path[len(leaf.path)-1]
+		if items, ok := leaf.value.([]interface{}); ok {
+			existing, _ := parent[name].([]interface{})
+			var added []interface{}
+			for _, item := range items {
+				if !containsValue(existing, item) {
+					existing = append(existing, item)
+					added = append(added, item)
+				}
+			}
+			if existing == nil {
+				existing = []interface{}{}
+			}
+			if added == nil {
+				added = []interface{}{}
+			}
+			parent[name] = existing
+			managed[key] = added
+			continue
+		}
+		if _, taken := parent[name]; taken {
+			// The user's value wins; veto doesn't own this key
+			continue
+		}
+		parent[name] = leaf.value
+		managed[key] = leaf.value
+	}
+	doc[sentinelKey] = sentinel{Note: sentinelNote, Managed: managed}
+	return writeJSONObject(path, doc)
+}
+
+// unmergeJSON takes what veto wrote back out of the JSON object at path,
+// removing the file if nothing else is left in it.
+func unmergeJSON(path string) error {
+	doc, err := readJSONObject(path)
+	if err != nil || len(doc) == 0 {
+		return err
+	}
+	if _, ok := doc[sentinelKey]; !ok {
+		return nil
+	}
+	unmerge(doc)
+	if len(doc) == 0 {
+		return readonly.Remove(path)
+	}
+	return writeJSONObject(path, doc)
+}
+
+// managedJSON reports whether the JSON file at path has veto's keys in it.
+func managedJSON(path string) bool {
+	doc, err := readJSONObject(path)
+	if err != nil {
+		return false
+	}
+	_, ok := doc[sentinelKey]
+	return ok
+}
+
+// unmerge removes the values recorded in doc's sentinel, and the sentinel.
+// Objects left empty along the way are removed too.
+func unmerge(doc map[string]interface{}) {
+	raw, ok := doc[sentinelKey]
+	delete(doc, sentinelKey)
+	if !ok {
+		return
+	}
+	data, _ := json.Marshal(raw)
+	var s sentinel
+	if json.Unmarshal(data, &s) != nil {
+		return
+	}
+	for key, value := range s.Managed {
+		path := splitKey(key)
+		parent := lookupObject(doc, path[:len(path)-1])
+		if parent == nil {
+			continue
+		}
+		name := path[len(path)-1]
+		if items, ok := value.([]interface{}); ok {
+			existing, _ := parent[name].([]interface{})
+			var kept []interface{}
+			for _, item := range existing {
+				if !containsValue(items, item) {
+					kept = append(kept, item)
+				}
+			}
+			if len(kept) > 0 {
+				parent[name] = kept
+				continue
+			}
+		} else if !reflect.DeepEqual(parent[name], value) {
+			// Changed by the user since; leave it to them
+			continue
+		}
+		delete(parent, name)
+		prune(doc, path[:len(path)-1])
+	}
+}
+
+// joinKey joins a key path with dots, escaping the dots and backslashes
+// in keys like VS Code's "chat.tools.terminal.autoApprove".
+func joinKey(path []string) string {
+	escaped := make([]string, len(path))
+	for i, k := range path {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, ".", `\.`).Replace(k)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// splitKey splits a key path written by joinKey.
+func splitKey(key string) []string {
+	var path []string
+	var cur strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			i++
+			cur.WriteByte(key[i])
+		case key[i] == '.':
+			path = append(path, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(key[i])
+		}
+	}
+	return append(path, cur.String())
+}
+
+type leaf struct {
+	path  []string
+	value interface{}
+}
+
+// leaves lists the non-object values in obj with their key paths.
+func leaves(obj map[string]interface{}, prefix []string) []leaf {
+	var out []leaf
+	for k, v := range obj {
+		path := append(append([]string(nil), prefix...), k)
+		if child, ok := v.(map[string]interface{}); ok && len(child) > 0 {
+			out = append(out, leaves(child, path)...)
+			continue
+		}
+		out = append(out, leaf{path, v})
+	}
+	return out
+}
+
+// ensure returns the object at path in doc, creating it (and replacing
+// non-object values in the way) as needed.
+func ensure(doc map[string]interface{}, path []string) map[string]interface{} {
+	obj := doc
+	for _, k := range path {
+		child, ok := obj[k].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			obj[k] = child
+		}
+		obj = child
+	}
+	return obj
+}
+
+// lookupObject returns the object at path in doc, or nil.
+func lookupObject(doc map[string]interface{}, path []string) map[string]interface{} {
+	obj := doc
+	for _, k := range path {
+		child, ok := obj[k].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = child
+	}
+	return obj
+}
+
+// prune removes empty objects along path, deepest first.
+func prune(doc map[string]interface{}, path []string) {
+	for i := len(path); i > 0; i-- {
+		parent := lookupObject(doc, path[:i-1])
+		if child, ok := parent[path[i-1]].(map[string]interface{}); !ok || len(child) > 0 {
+			return
+		}
+		delete(parent, path[i-1])
+	}
+}
+
+func containsValue(items []interface{}, v interface{}) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// readJSONObject reads the JSON object at path; a missing or empty file
+// is an empty object. Anything else that isn't an object is an error, so
+// a file veto can't parse is never overwritten.
+func readJSONObject(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s isn't a JSON object (%v); fix or remove it so veto can merge its settings", path, err)
+		}
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	return doc, nil
+}
+
+func writeJSONObject(path string, doc map[string]interface{}) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, append(data, '\n'), 0644)
+}
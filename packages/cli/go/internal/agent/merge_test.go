@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func readDoc(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMergeKeepsUserSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	user := `{"model": "opus", "permissions": {"allow": ["Bash(ls)"], "bash": {"deny": ["rm -rf /"]}}}`
+	if err := os.WriteFile(path, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deny := func(patterns ...string) map[string]interface{} {
+		return map[string]interface{}{"permissions": map[string]interface{}{"bash": map[string]interface{}{"deny": patterns}}}
+	}
+	if err := mergeJSON(path, deny("git push --force*", "rm -rf /")); err != nil {
+		t.Fatal(err)
+	}
+	// A second sync replaces veto's items instead of piling them up
+	if err := mergeJSON(path, deny("sudo *")); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := readDoc(t, path)
+	if doc["model"] != "opus" {
+		t.Errorf("model = %v, want the user's setting kept", doc["model"])
+	}
+	perms := doc["permissions"].(map[string]interface{})
+	if got := perms["allow"]; !reflect.DeepEqual(got, []interface{}{"Bash(ls)"}) {
+		t.Errorf("allow = %v, want the user's list kept", got)
+	}
+	got := perms["bash"].(map[string]interface{})["deny"]
+	if want := []interface{}{"rm -rf /", "sudo *"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deny = %v, want %v", got, want)
+	}
+
+	if err := unmergeJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	json.Unmarshal([]byte(user), &want)
+	if doc := readDoc(t, path); !reflect.DeepEqual(doc, want) {
+		t.Errorf("after uninstall = %v, want the user's file back %v", doc, want)
+	}
+}
+
+func TestUnmergeRemovesFileVetoCreated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	if err := mergeJSON(path, map[string]interface{}{"beforeShellExecution": map[string]interface{}{"deny": []string{"sudo *"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if !managedJSON(path) {
+		t.Fatal("merged file has no veto sentinel")
+	}
+	if err := unmergeJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file veto created was left behind: %v", err)
+	}
+}
+
+func TestMergeRefusesInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mergeJSON(path, map[string]interface{}{"a": 1}); err == nil {
+		t.Error("mergeJSON overwrote a file it couldn't parse")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{not json" {
+		t.Errorf("file changed to %q", data)
+	}
+}
+
+func TestMergeDottedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	user := `{"editor.fontSize": 14, "chat.tools.terminal.autoApprove": {"ls": true}}`
+	if err := os.WriteFile(path, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gen := generateCopilotSettings([]*policy.Policy{{CommandRules: []policy.CommandRule{{Block: []string{"git push --force*", "rm -rf {/,~}"}}}}})
+	if err := mergeJSON(path, gen); err != nil {
+		t.Fatal(err)
+	}
+	approve := readDoc(t, path)["chat.tools.terminal.autoApprove"].(map[string]interface{})
+	for _, key := range []string{"ls", "git push --force", `/^rm -rf (\/|~)$/`} {
+		if _, ok := approve[key]; !ok {
+			t.Errorf("autoApprove = %v, want %q", approve, key)
+		}
+	}
+
+	if err := unmergeJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	json.Unmarshal([]byte(user), &want)
+	if doc := readDoc(t, path); !reflect.DeepEqual(doc, want) {
+		t.Errorf("after uninstall = %v, want the user's file back %v", doc, want)
+	}
+}
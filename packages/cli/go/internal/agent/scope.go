@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Scope is where an agent's veto config is installed.
+type Scope string
+
+const (
+	// ScopeProject installs into the repo's own agent config (the default),
+	// so policies only apply to this project
+	ScopeProject Scope = "project"
+	// ScopeGlobal installs into the agent's per-user config, applying the
+	// policies to every project
+	ScopeGlobal Scope = "global"
+)
+
+// ProjectConfigDir returns the project-local config directory for an agent.
+func ProjectConfigDir(agent *Agent, root string) string {
+	if agent.Custom != nil {
+		return filepath.Join(root, filepath.FromSlash(agent.Custom.ProjectDir))
+	}
+	switch agent.ID {
+	case "claude-code":
+		return filepath.Join(root, ".claude")
+	case "cursor":
+		return filepath.Join(root, ".cursor")
+	case "windsurf":
+		return filepath.Join(root, ".windsurf")
+	case "amazon-q":
+		return filepath.Join(root, ".amazonq")
+	default:
+		// OpenCode, Aider and Goose read their config from the project
+		// root, and Copilot from .github/ and .vscode/ in it
+		return root
+	}
+}
+
+// markers are the files each installer writes, relative to the config dir.
+var markers = map[string]map[Scope]string{
+	"claude-code": {ScopeProject: "settings.json", ScopeGlobal: "settings.json"},
+	"opencode":    {ScopeProject: "opencode.json", ScopeGlobal: "opencode.json"},
+	"cursor":      {ScopeProject: "hooks.json", ScopeGlobal: "hooks.json"},
+	"windsurf":    {ScopeProject: "hooks.json", ScopeGlobal: "cascade/hooks.json"},
+	"aider":       {ScopeProject: ".aider.conf.yml", ScopeGlobal: ".aider.conf.yml"},
+	"copilot":     {ScopeProject: ".vscode/settings.json", ScopeGlobal: "settings.json"},
+	"goose":       {ScopeProject: ".goosehints", ScopeGlobal: ".goosehints"},
+	"amazon-q":    {ScopeProject: "cli-agents/veto.json", ScopeGlobal: "cli-agents/veto.json"},
+}
+
+// markerFor returns an agent's marker for scope: a custom agent's first
+// file.
+func markerFor(agent *Agent, scope Scope) (string, bool) {
+	if agent.Custom != nil {
+		if scope == ScopeGlobal && agent.Custom.ConfigDir == "" {
+			return "", false
+		}
+		return agent.Custom.Files[0].Path, true
+	}
+	marker, ok := markers[agent.ID][scope]
+	return marker, ok
+}
+
+// SyncedScopes reports the scopes an agent has veto config installed at
+// for the project at root.
+func SyncedScopes(agent *Agent, root string) []Scope {
+	var scopes []Scope
+	for _, scope := range []Scope{ScopeProject, ScopeGlobal} {
+		marker, ok := markerFor(agent, scope)
+		if !ok {
+			continue
+		}
+		dir := GetConfigDir(agent)
+		if scope == ScopeProject {
+			dir = ProjectConfigDir(agent, root)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(marker))
+		if filepath.Ext(path) == ".json" {
+			// The user may have their own settings in the file, unless
+			// veto wrote all of it
+			if managedJSON(path) || fileContains(path, []string{"managed by veto"}) {
+				scopes = append(scopes, scope)
+			}
+		} else if _, err := os.Stat(path); err == nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// ProjectSync reports the agents with veto config installed in the
+// project at root, and when it was last written, for telling whether a
+// sync predates changes to the policies.
+func ProjectSync(root string) (agents []string, at time.Time) {
+	known := All
+	if custom, err := LoadCustom(root); err == nil {
+		known = append(slices.Clip(All), custom...)
+	}
+	for i := range known {
+		a := &known[i]
+		marker, ok := markerFor(a, ScopeProject)
+		if !ok {
+			continue
+		}
+		path := filepath.Join(ProjectConfigDir(a, root), filepath.FromSlash(marker))
+		// The user may have their own config in the file
+		if !managedJSON(path) && !fileContains(path, []string{"veto"}) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		agents = append(agents, a.ID)
+		if info.ModTime().After(at) {
+			at = info.ModTime()
+		}
+	}
+	return agents, at
+}
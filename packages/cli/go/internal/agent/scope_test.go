@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// written lists the files under dir, slash-separated and relative to it,
+// leaving out veto's own project state.
+func written(t *testing.T, dir string) []string {
+	t.Helper()
+	var out []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if rel != ".veto" && !strings.HasPrefix(rel, ".veto.d/") {
+			out = append(out, rel)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestInstallScopes(t *testing.T) {
+	// The files each agent's installer writes, relative to the project
+	// and to the agent's global config dir
+	agents := []struct {
+		id              string
+		project, global []string
+	}{
+		{"claude-code",
+			[]string{".claude/CLAUDE.md", ".claude/agents/veto-exceptions.md", ".claude/agents/veto-policies.md", ".claude/commands/veto.md", ".claude/settings.json"},
+			[]string{"CLAUDE.md", "agents/veto-exceptions.md", "agents/veto-policies.md", "commands/veto.md", "settings.json"}},
+		{"cursor", []string{".cursor/hooks.json"}, []string{"hooks.json"}},
+		{"windsurf", []string{".windsurf/hooks.json"}, []string{"cascade/hooks.json"}},
+		{"aider", []string{".aider.conf.yml", "CONVENTIONS.md"}, []string{".aider.conf.yml", "CONVENTIONS.md"}},
+		{"copilot",
+			[]string{".github/copilot-instructions.md", ".vscode/settings.json"},
+			[]string{"prompts/veto.instructions.md", "settings.json"}},
+		{"amazon-q",
+			[]string{".amazonq/cli-agents/veto.json", ".amazonq/rules/veto.md"},
+			[]string{"cli-agents/veto.json", "rules/veto.md"}},
+	}
+	for _, tt := range agents {
+		for _, scopes := range [][]Scope{{ScopeProject}, {ScopeGlobal}, {ScopeProject, ScopeGlobal}} {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("protect .env\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, scope := range scopes {
+				if err := install(tt.id, scope, root); err != nil {
+					t.Fatalf("%s: install(%s): %v", tt.id, scope, err)
+				}
+			}
+
+			var wantProject, wantGlobal []string
+			var wantRecords int
+			for _, scope := range scopes {
+				if scope == ScopeProject {
+					wantProject = tt.project
+				} else {
+					wantGlobal, wantRecords = tt.global, 1
+				}
+			}
+			a := Find(tt.id)
+			if got := written(t, root); !reflect.DeepEqual(got, wantProject) {
+				t.Errorf("%s %v: project has %q, want %q", tt.id, scopes, got, wantProject)
+			}
+			if got := written(t, GetConfigDir(a)); !reflect.DeepEqual(got, wantGlobal) {
+				t.Errorf("%s %v: global config has %q, want %q", tt.id, scopes, got, wantGlobal)
+			}
+			// Nothing lands in home outside the agent's config dir
+			if got := written(t, home); len(got) != len(wantGlobal) {
+				t.Errorf("%s %v: home has %q, want only %q", tt.id, scopes, got, wantGlobal)
+			}
+			if got := SyncedScopes(a, root); !reflect.DeepEqual(got, scopes) {
+				t.Errorf("%s %v: SyncedScopes() = %v", tt.id, scopes, got)
+			}
+			// Only global installs are recorded for `veto gc`
+			if records, err := LoadRecords(); err != nil || len(records) != wantRecords {
+				t.Errorf("%s %v: records = %+v, %v, want %d", tt.id, scopes, records, err, wantRecords)
+			}
+		}
+	}
+}
+
+func TestInstallUnknownScope(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	if err := install("cursor", Scope("machine"), root); err == nil {
+		t.Error("install with an unknown scope succeeded")
+	}
+	if got := written(t, root); got != nil {
+		t.Errorf("project has %q after a failed install", got)
+	}
+}
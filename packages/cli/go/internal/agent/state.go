@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// StateName is the file in the project's .veto.d that records what was
+// last synced to each agent.
+const StateName = "state.json"
+
+// Synced records the policies last written into one agent's config.
+type Synced struct {
+	Agent    string    `json:"agent"`
+	Scope    Scope     `json:"scope"`
+	SyncedAt time.Time `json:"syncedAt"`
+	// Policies maps each policy's description to a hash of its compiled
+	// form
+	Policies map[string]string `json:"policies"`
+}
+
+// StatePath returns where the sync state of the project at root is kept.
+func StatePath(root string) string {
+	return filepath.Join(root, audit.DirName, StateName)
+}
+
+// LoadState reads what was last synced from the project at root. A
+// project that was never synced has nothing recorded.
+func LoadState(root string) ([]Synced, error) {
+	data, err := os.ReadFile(StatePath(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state struct {
+		Agents []Synced `json:"agents"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", StatePath(root), err)
+	}
+	return state.Agents, nil
+}
+
+func saveState(root string, synced []Synced) error {
+	if _, err := audit.DataDir(root); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(struct {
+		Agents []Synced `json:"agents"`
+	}{synced}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(StatePath(root), append(data, '\n'), 0644)
+}
+
+// recordSync replaces the state recorded for an agent at scope with the
+// policies just written.
+func recordSync(root, agentID string, scope Scope, policies []*policy.Policy) error {
+	state, err := LoadState(root)
+	if err != nil {
+		return err
+	}
+	state = slices.DeleteFunc(state, func(s Synced) bool { return s.Agent == agentID && s.Scope == scope })
+	state = append(state, Synced{Agent: agentID, Scope: scope, SyncedAt: time.Now(), Policies: hashPolicies(policies)})
+	return saveState(root, state)
+}
+
+// forgetSync drops the state recorded for an agent at scope.
+func forgetSync(root, agentID string, scope Scope) error {
+	state, err := LoadState(root)
+	if err != nil {
+		return err
+	}
+	kept := slices.DeleteFunc(slices.Clone(state), func(s Synced) bool { return s.Agent == agentID && s.Scope == scope })
+	if len(kept) == len(state) {
+		return nil
+	}
+	return saveState(root, kept)
+}
+
+// hashPolicies keys each policy's hash by its description. A description
+// used twice gets a "#2" suffix, and so on.
+func hashPolicies(policies []*policy.Policy) map[string]string {
+	hashes := make(map[string]string, len(policies))
+	for _, p := range policies {
+		key := p.Description
+		for n := 2; hashes[key] != ""; n++ {
+			key = fmt.Sprintf("%s #%d", p.Description, n)
+		}
+		data, _ := json.Marshal(p)
+		sum := sha256.Sum256(data)
+		hashes[key] = hex.EncodeToString(sum[:8])
+	}
+	return hashes
+}
+
+// Drift is how the policies synced to an agent differ from the project's
+// current ones, by description.
+type Drift struct {
+	Synced
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// OutOfDate counts the policies the agent has wrong or is missing.
+func (d *Drift) OutOfDate() int {
+	return len(d.Added) + len(d.Changed) + len(d.Removed)
+}
+
+// ProjectDrift compares what was last synced to each agent from the
+// project at root with its current policies, in the order recorded.
+func ProjectDrift(root string) ([]Drift, error) {
+	state, err := LoadState(root)
+	if err != nil || len(state) == 0 {
+		return nil, err
+	}
+	_, policies, err := loadPolicies(root)
+	if err != nil {
+		return nil, err
+	}
+	current := hashPolicies(policies)
+
+	var drifts []Drift
+	for _, s := range state {
+		d := Drift{Synced: s}
+		for name, hash := range current {
+			switch old, ok := s.Policies[name]; {
+			case !ok:
+				d.Added = append(d.Added, name)
+			case old != hash:
+				d.Changed = append(d.Changed, name)
+			}
+		}
+		for name := range s.Policies {
+			if _, ok := current[name]; !ok {
+				d.Removed = append(d.Removed, name)
+			}
+		}
+		slices.Sort(d.Added)
+		slices.Sort(d.Changed)
+		slices.Sort(d.Removed)
+		drifts = append(drifts, d)
+	}
+	return drifts, nil
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectDrift(t *testing.T) {
+	root := t.TempDir()
+	writeVeto := func(veto string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, ".veto"), []byte(veto), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeVeto("no force push\nprotect .env\n")
+	if err := install("goose", ScopeProject, root); err != nil {
+		t.Fatal(err)
+	}
+	drifts, err := ProjectDrift(root)
+	if err != nil || len(drifts) != 1 || drifts[0].Agent != "goose" || drifts[0].OutOfDate() != 0 {
+		t.Fatalf("ProjectDrift() after sync = %+v, %v", drifts, err)
+	}
+
+	writeVeto("no force push\nno lodash\n")
+	drifts, err = ProjectDrift(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := drifts[0]; len(d.Added) != 1 || len(d.Removed) != 1 || len(d.Changed) != 0 || d.OutOfDate() != 2 {
+		t.Errorf("drift after editing .veto = %+v, want one added and one removed", d)
+	}
+
+	// Syncing again catches up; uninstalling forgets the agent
+	if err := install("goose", ScopeProject, root); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, _ := ProjectDrift(root); drifts[0].OutOfDate() != 0 {
+		t.Errorf("drift after resync = %+v", drifts[0])
+	}
+	if err := forgetSync(root, "goose", ScopeProject); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, err := ProjectDrift(root); err != nil || len(drifts) != 0 {
+		t.Errorf("ProjectDrift() after uninstall = %+v, %v", drifts, err)
+	}
+}
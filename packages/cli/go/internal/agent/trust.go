@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// UntrustedError is returned for a global install of a custom agent the
+// user hasn't trusted. Its definition comes from the repository, and a
+// global install writes wherever its configDir says, so the user has to
+// approve it first (see Trust).
+type UntrustedError struct {
+	Agent  string
+	Source string
+	// Dir is where the install would write
+	Dir   string
+	Files []string
+}
+
+func (e *UntrustedError) Error() string {
+	return fmt.Sprintf("%s would write to %s, outside the project, as %s says; check it, then run: veto install %s --global --trust", e.Agent, e.Dir, e.Source, e.Agent)
+}
+
+// trusted is a custom agent definition the user approved, as it was then.
+type trusted struct {
+	Source string `json:"source"`
+	SHA256 string `json:"sha256"`
+}
+
+// TrustedPath returns the per-user file recording the custom agents
+// trusted to install globally.
+func TrustedPath() string {
+	return filepath.Join(xdg.ConfigDir(), "trusted-agents.json")
+}
+
+func loadTrusted() ([]trusted, error) {
+	data, err := os.ReadFile(TrustedPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []trusted
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %w", TrustedPath(), err)
+	}
+	return list, nil
+}
+
+// fingerprint identifies a custom agent's definition file and content,
+// so changing it asks for trust again.
+func (c *Custom) fingerprint() (trusted, error) {
+	source, err := filepath.Abs(c.Source)
+	if err != nil {
+		return trusted{}, err
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return trusted{}, err
+	}
+	sum := sha256.Sum256(data)
+	return trusted{Source: source, SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// checkTrusted returns an UntrustedError unless the user trusted the
+// agent's definition as it is now.
+func (c *Custom) checkTrusted(configDir string) error {
+	f, err := c.fingerprint()
+	if err != nil {
+		return err
+	}
+	list, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(list, f) {
+		return nil
+	}
+	e := &UntrustedError{Agent: c.ID, Source: c.Source, Dir: configDir}
+	for _, file := range c.Files {
+		e.Files = append(e.Files, file.Path)
+	}
+	return e
+}
+
+// Trust lets the current project's custom agent agentID install
+// globally, as its definition is now.
+func Trust(agentID string) error {
+	root, err := ProjectRoot()
+	if err != nil {
+		return err
+	}
+	a := findIn(agentID, root)
+	if a == nil || a.Custom == nil {
+		return fmt.Errorf("%s is not one of the project's custom agents", agentID)
+	}
+	f, err := a.Custom.fingerprint()
+	if err != nil {
+		return err
+	}
+	list, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	// Only the definition's latest content is trusted
+	list = slices.DeleteFunc(list, func(t trusted) bool { return t.Source == f.Source })
+	list = append(list, f)
+	if err := readonly.MkdirAll(filepath.Dir(TrustedPath()), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(TrustedPath(), append(data, '\n'), 0644)
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// Report lists what an uninstall took out of an agent's config.
+type Report struct {
+	// Removed are files veto wrote, now deleted
+	Removed []string
+	// Reverted are files shared with the user's own settings, with only
+	// what veto added taken back out
+	Reverted []string
+}
+
+// Empty reports whether there was nothing of veto's to take out.
+func (r *Report) Empty() bool {
+	return len(r.Removed) == 0 && len(r.Reverted) == 0
+}
+
+// Uninstall removes veto hooks for an agent from the given scope. Only
+// what veto wrote is taken out: files it generated, and its keys and list
+// items in configs it merged into.
+func Uninstall(agentID string, scope Scope) (*Report, error) {
+	root, err := ProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	agent := findIn(agentID, root)
+	if agent == nil {
+		return nil, unknownAgent(agentID, root)
+	}
+	dir, err := scopeDir(agent, scope, root)
+	if err != nil {
+		return nil, err
+	}
+	if err := forgetSync(root, agent.ID, scope); err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	return r, uninstall(agent, scope, dir, root, r)
+}
+
+// uninstall takes veto's config for scope out of dir, the agent's config
+// dir for that scope in the project at root.
+func uninstall(agent *Agent, scope Scope, dir, root string, r *Report) error {
+	if agent.Custom != nil {
+		return uninstallCustom(agent.Custom, dir, r)
+	}
+	switch agent.ID {
+	case "claude-code":
+		return uninstallClaudeCode(dir, r)
+	case "opencode":
+		if scope == ScopeProject {
+			uninstallOpenCodeNested(root, r)
+		}
+		return uninstallOpenCode(dir, r)
+	case "windsurf":
+		return uninstallWindsurf(dir, scope, r)
+	case "cursor":
+		return uninstallCursor(dir, r)
+	case "aider":
+		return uninstallAider(dir, r)
+	case "copilot":
+		return uninstallCopilot(dir, scope, r)
+	case "goose":
+		return uninstallGoose(dir, r)
+	case "amazon-q":
+		return uninstallAmazonQ(dir, r)
+	default:
+		return fmt.Errorf("uninstall not yet implemented for %s", agent.ID)
+	}
+}
+
+// remove deletes the file at path if veto wrote it, leaving one the user
+// wrote.
+func (r *Report) remove(path string) {
+	if !fileContains(path, []string{"managed by veto"}) {
+		return
+	}
+	if err := readonly.Remove(path); err == nil {
+		r.Removed = append(r.Removed, path)
+	}
+}
+
+// removeAppendix removes the appendix veto wrote in dir, if any.
+func (r *Report) removeAppendix(dir string) {
+	r.remove(filepath.Join(dir, AppendixName))
+}
+
+// unmergeJSON takes veto's keys back out of the JSON config at path (see
+// unmergeJSON), recording whether the file was removed or kept.
+func (r *Report) unmergeJSON(path string) error {
+	doc, err := readJSONObject(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := doc[sentinelKey]; !ok {
+		return nil
+	}
+	unmerge(doc)
+	if err := unmergeJSON(path); err != nil {
+		return err
+	}
+	if len(doc) == 0 {
+		r.Removed = append(r.Removed, path)
+	} else {
+		r.Reverted = append(r.Reverted, path)
+	}
+	return nil
+}
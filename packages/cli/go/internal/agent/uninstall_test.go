@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestUninstallHooks(t *testing.T) {
+	policies := []*policy.Policy{builtin.Find("no force push").ToPolicy(policy.ActionExecute)}
+	dir := t.TempDir()
+	if err := installCursor(dir, policies); err != nil {
+		t.Fatal(err)
+	}
+	if err := installWindsurf(dir, ScopeGlobal, policies); err != nil {
+		t.Fatal(err)
+	}
+	// The user's own cascade hooks stay
+	windsurf := filepath.Join(dir, "cascade", "hooks.json")
+	doc := readDoc(t, windsurf)
+	doc["post_write_code"] = map[string]interface{}{"command": "make fmt"}
+	if err := writeJSONObject(windsurf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Report{}
+	if err := uninstallCursor(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := uninstallWindsurf(dir, ScopeGlobal, r); err != nil {
+		t.Fatal(err)
+	}
+	want := &Report{Removed: []string{filepath.Join(dir, "hooks.json")}, Reverted: []string{windsurf}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("report = %+v, want %+v", r, want)
+	}
+	if doc := readDoc(t, windsurf); len(doc) != 1 || doc["post_write_code"] == nil {
+		t.Errorf("cascade hooks after uninstall = %v, want only the user's", doc)
+	}
+
+	// A second uninstall finds nothing of veto's
+	r = &Report{}
+	if err := uninstallWindsurf(dir, ScopeGlobal, r); err != nil || !r.Empty() {
+		t.Errorf("second uninstall = %+v, %v", r, err)
+	}
+}
+
+func TestUninstallAider(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".aider.conf.yml")
+	user := "model: sonnet\nread-only:\n  - docs/spec.md\n"
+	if err := os.WriteFile(config, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	protect := builtin.Find("protect .env").ToPolicy(policy.ActionModify)
+	if err := installAider(dir, ScopeProject, []*policy.Policy{protect}, lister{root: dir, budget: DefaultBudget}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(config)
+	if !strings.Contains(string(data), ".env") || !strings.Contains(string(data), "read: # managed by veto") {
+		t.Fatalf(".aider.conf.yml after install:\n%s", data)
+	}
+
+	r := &Report{}
+	if err := uninstallAider(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Report{Removed: []string{filepath.Join(dir, "CONVENTIONS.md")}, Reverted: []string{config}}); !reflect.DeepEqual(r, want) {
+		t.Errorf("report = %+v, want %+v", r, want)
+	}
+	if data, _ := os.ReadFile(config); string(data) != user {
+		t.Errorf(".aider.conf.yml after uninstall:\n%s\nwant the user's:\n%s", data, user)
+	}
+}
@@ -0,0 +1,230 @@
+// Package audit keeps a project's record of every policy decision: what
+// each agent tried, and what was allowed or blocked and by which policy.
+// Entries are JSON lines in .veto.d/audit.log at the project root, next to
+// .veto; the directory ignores itself so the log never gets committed.
+//
+// Scan audits the repository itself: the content already in it that the
+// policies would stop an agent from writing.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// DirName is the per-project directory for veto's local data.
+const DirName = ".veto.d"
+
+// FileName is the audit log in DirName.
+const FileName = "audit.log"
+
+// maxSize is the size past which the log is rotated to audit.log.1,
+// replacing the previous one.
+const maxSize = 10 << 20
+
+// Entry is one decision.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Agent   string    `json:"agent,omitempty"`
+	Model   string    `json:"model,omitempty"`
+	Session string    `json:"session,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Action  string    `json:"action"`
+	Command string    `json:"command,omitempty"`
+	File    string    `json:"file,omitempty"`
+	Allowed bool      `json:"allowed"`
+	// Decision is the matching policy's decision, allow when none matched
+	Decision policy.Decision `json:"decision"`
+	Policy   string          `json:"policy,omitempty"`
+	Rule     string          `json:"rule,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+	// Suggest and Alternatives are what the block told the agent to do
+	// instead, for telling whether it did (see Effectiveness)
+	Suggest      string   `json:"suggest,omitempty"`
+	Alternatives []string `json:"alternatives,omitempty"`
+	// Monitored lists monitor-mode policies that would have acted
+	Monitored []string `json:"monitored,omitempty"`
+}
+
+// NewEntry describes a request and its result.
+func NewEntry(req *policy.CheckRequest, result *policy.CheckResult, now time.Time) Entry {
+	e := Entry{
+		Time:     now.UTC(),
+		Agent:    req.Agent,
+		Model:    req.Model,
+		Session:  req.SessionID,
+		User:     req.User,
+		Action:   req.Action,
+		Command:  req.Command,
+		File:     req.Target,
+		Allowed:  result.Allowed,
+		Decision: result.Decision,
+		Policy:   result.Policy,
+		Rule:     result.Rule,
+		Reason:   result.Reason,
+	}
+	if e.Decision == "" {
+		e.Decision = policy.DecisionAllow
+	}
+	if !result.Allowed {
+		e.Suggest = result.Suggest
+		for _, a := range result.Alternatives {
+			e.Alternatives = append(e.Alternatives, a.Command)
+		}
+	}
+	for _, hit := range result.Monitored {
+		e.Monitored = append(e.Monitored, hit.Policy)
+	}
+	return e
+}
+
+// Path returns the audit log of the project at root.
+func Path(root string) string {
+	return filepath.Join(root, DirName, FileName)
+}
+
+// Append adds an entry to the audit log of the project at root.
+func Append(root string, e Entry) error {
+	if _, err := DataDir(root); err != nil {
+		return err
+	}
+
+	path := Path(root)
+	if info, err := os.Stat(path); err == nil && info.Size() > maxSize {
+		os.Rename(path, path+".1")
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// One write per line, so concurrent hooks don't interleave
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DataDir creates the DirName directory of the project at root, ignored
+// by git, and returns it.
+func DataDir(root string) (string, error) {
+	dir := filepath.Join(root, DirName)
+	if err := readonly.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		readonly.WriteFile(ignore, []byte("# Local veto data, not for version control\n*\n"), 0644)
+	}
+	return dir, nil
+}
+
+// Filter selects entries. Zero fields match everything.
+type Filter struct {
+	// Agent matches the agent ID exactly
+	Agent string
+	// Policy matches a substring of the deciding or monitoring policy,
+	// ignoring case
+	Policy string
+	Since  time.Time
+	Until  time.Time
+	// Blocked keeps only requests that weren't allowed
+	Blocked bool
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Entry) bool {
+	if f.Agent != "" && e.Agent != f.Agent {
+		return false
+	}
+	if f.Policy != "" && !matchPolicy(e, strings.ToLower(f.Policy)) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return !f.Blocked || !e.Allowed
+}
+
+func matchPolicy(e Entry, want string) bool {
+	if strings.Contains(strings.ToLower(e.Policy), want) {
+		return true
+	}
+	for _, m := range e.Monitored {
+		if strings.Contains(strings.ToLower(m), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns the entries in the audit log of the project at root that
+// pass f, oldest first, including the rotated log. Lines that can't be
+// parsed are skipped.
+func Read(root string, f Filter) ([]Entry, error) {
+	var entries []Entry
+	for _, path := range []string{Path(root) + ".1", Path(root)} {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 4<<20)
+		for scanner.Scan() {
+			var e Entry
+			if json.Unmarshal(scanner.Bytes(), &e) != nil {
+				continue
+			}
+			if f.Match(e) {
+				entries = append(entries, e)
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// ParseTime reads a point in time for a filter: a duration back from now
+// ("90m", "24h", "7d"), a date ("2026-01-02", local time) or an RFC 3339
+// timestamp.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want a duration like 24h or 7d, a date like 2006-01-02, or RFC 3339)", s)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	root := t.TempDir()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	requests := []struct {
+		req    policy.CheckRequest
+		result policy.CheckResult
+	}{
+		{policy.CheckRequest{Agent: "claude-code", Action: "execute", Command: "git push --force"},
+			policy.CheckResult{Decision: policy.DecisionDeny, Policy: "Prevent git force push", Rule: "commandRules[0]"}},
+		{policy.CheckRequest{Agent: "cursor", Action: "modify", Target: "src/app.ts"},
+			policy.CheckResult{Allowed: true}},
+		{policy.CheckRequest{Agent: "claude-code", Action: "modify", Target: ".env"},
+			policy.CheckResult{Decision: policy.DecisionDeny, Policy: "Environment files (secrets)"}},
+	}
+	for i, r := range requests {
+		if err := Append(root, NewEntry(&r.req, &r.result, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, DirName, ".gitignore")); err != nil {
+		t.Errorf("no .gitignore in %s: %v", DirName, err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"agent", Filter{Agent: "claude-code"}, 2},
+		{"policy", Filter{Policy: "force PUSH"}, 1},
+		{"blocked", Filter{Blocked: true}, 2},
+		{"since", Filter{Since: start.Add(time.Hour)}, 2},
+		{"until", Filter{Until: start.Add(time.Hour)}, 1},
+	}
+	for _, tt := range tests {
+		entries, err := Read(root, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != tt.want {
+			t.Errorf("%s: %d entries, want %d", tt.name, len(entries), tt.want)
+		}
+	}
+
+	entries, _ := Read(root, Filter{})
+	if e := entries[1]; e.Decision != policy.DecisionAllow || e.File != "src/app.ts" {
+		t.Errorf("allowed entry = %+v", e)
+	}
+	if e := entries[0]; e.Rule != "commandRules[0]" {
+		t.Errorf("blocked entry = %+v, want its rule", e)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"24h":                  now.Add(-24 * time.Hour),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2026-03-01T08:00:00Z": time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		got, err := ParseTime(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseTime("last tuesday", now); err == nil {
+		t.Error("ParseTime accepted nonsense")
+	}
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// BaselineName is the file in a project's root recording the violations
+// that were there when the baseline was taken. Like a lint baseline, it
+// lets policies apply to new code without failing on old code first.
+const BaselineName = ".veto-baseline.json"
+
+// BaselineFormat is the current baseline file format.
+const BaselineFormat = 1
+
+// Baseline is the set of existing violations audits and checks accept.
+// Violations are matched by file, policy, rule and line text rather than
+// line number, so edits elsewhere in a file don't disturb them. Locked
+// policies' violations are never accepted, so a project's baseline can't
+// hide what machine policies forbid.
+type Baseline struct {
+	Format  int       `json:"format"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	// Entries are the accepted violations, sorted by file
+	Entries []BaselineEntry `json:"entries"`
+	// History is how many violations were accepted when the baseline
+	// was taken and after each update, oldest first
+	History []BaselinePoint `json:"history"`
+}
+
+// BaselineEntry is an accepted violation, Count times in its file.
+type BaselineEntry struct {
+	File   string `json:"file"`
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
+	Text   string `json:"text"`
+	Count  int    `json:"count"`
+}
+
+// BaselinePoint is the size of a baseline at a point in time.
+type BaselinePoint struct {
+	At    time.Time `json:"at"`
+	Count int       `json:"count"`
+}
+
+// BaselinePath returns the baseline file for the project at root.
+func BaselinePath(root string) string {
+	return filepath.Join(root, BaselineName)
+}
+
+// ReadBaseline reads a baseline file.
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", BaselineName, err)
+	}
+	if b.Format > BaselineFormat {
+		return nil, fmt.Errorf("%s: format %d is newer than this veto supports (%d); run: veto update", BaselineName, b.Format, BaselineFormat)
+	}
+	return &b, nil
+}
+
+// Write writes the baseline to path.
+func (b *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// NewBaseline returns a baseline accepting findings, as of now. Possible
+// AST matches aren't violations yet and are left out, as are locked
+// policies' findings.
+func NewBaseline(findings []Finding, now time.Time) *Baseline {
+	b := &Baseline{Format: BaselineFormat, Created: now}
+	b.Update(findings, now)
+	return b
+}
+
+// Update replaces the accepted violations with findings, as of now, and
+// reports how many accepted ones were fixed since and how many new ones
+// are accepted.
+func (b *Baseline) Update(findings []Finding, now time.Time) (fixed, added int) {
+	previous := b.counts()
+	current := map[BaselineEntry]int{}
+	for _, f := range findings {
+		if !f.Possible && !f.Locked {
+			current[entryKey(f.File, f)]++
+		}
+	}
+	for key, n := range previous {
+		if c := current[key]; c < n {
+			fixed += n - c
+		}
+	}
+
+	b.Entries = []BaselineEntry{}
+	total := 0
+	for key, n := range current {
+		if p := previous[key]; n > p {
+			added += n - p
+		}
+		key.Count = n
+		b.Entries = append(b.Entries, key)
+		total += n
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		x, y := b.Entries[i], b.Entries[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		if x.Policy != y.Policy {
+			return x.Policy < y.Policy
+		}
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		return x.Text < y.Text
+	})
+	b.Updated = now
+	b.History = append(b.History, BaselinePoint{At: now, Count: total})
+	return fixed, added
+}
+
+// Total is how many violations the baseline accepts.
+func (b *Baseline) Total() int {
+	n := 0
+	for _, e := range b.Entries {
+		n += e.Count
+	}
+	return n
+}
+
+// Initial is how many violations the baseline accepted when it was
+// taken.
+func (b *Baseline) Initial() int {
+	if len(b.History) == 0 {
+		return b.Total()
+	}
+	return b.History[0].Count
+}
+
+// Apply marks the findings the baseline accepts as Baselined, each entry
+// up to its count, and returns how many it marked. Locked policies'
+// findings are never marked. dir is where the scan
+// that found them started, relative to the project root ("" for the
+// root itself).
+func (b *Baseline) Apply(findings []Finding, dir string) int {
+	left := b.counts()
+	marked := 0
+	for i := range findings {
+		f := &findings[i]
+		if f.Locked {
+			continue
+		}
+		file := f.File
+		if dir != "" {
+			file = dir + "/" + file
+		}
+		key := entryKey(file, *f)
+		if left[key] > 0 {
+			left[key]--
+			f.Baselined = true
+			marked++
+		}
+	}
+	return marked
+}
+
+// Mask returns content with the lines the baseline accepts for a
+// policy's rule in file blanked, each accepted text up to its count, so
+// checking the rule only finds new violations. file is slash-separated
+// and relative to the project root. Callers don't mask content for
+// locked policies (see Baseline).
+func (b *Baseline) Mask(policy, rule, file, content string) string {
+	left := map[string]int{}
+	for _, e := range b.Entries {
+		if e.File == file && e.Policy == policy && e.Rule == rule {
+			left[e.Text] += e.Count
+		}
+	}
+	if len(left) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	masked := false
+	for i, line := range lines {
+		text := lineText(line)
+		if left[text] > 0 {
+			left[text]--
+			lines[i] = ""
+			masked = true
+		}
+	}
+	if !masked {
+		return content
+	}
+	return strings.Join(lines, "\n")
+}
+
+// counts returns each entry's count by key.
+func (b *Baseline) counts() map[BaselineEntry]int {
+	counts := map[BaselineEntry]int{}
+	for _, e := range b.Entries {
+		key := e
+		key.Count = 0
+		counts[key] += e.Count
+	}
+	return counts
+}
+
+// entryKey identifies a finding in file in the baseline.
+func entryKey(file string, f Finding) BaselineEntry {
+	return BaselineEntry{File: file, Policy: f.Policy, Rule: f.Rule, Text: f.Text}
+}
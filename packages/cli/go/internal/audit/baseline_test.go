@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func finding(file, text string) Finding {
+	return Finding{File: file, Policy: "No console.log", Rule: "contentRules[0]", Text: text, Decision: "deny"}
+}
+
+func TestBaseline(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBaseline([]Finding{
+		finding("src/a.ts", "console.log(x)"),
+		finding("src/a.ts", "console.log(x)"),
+		finding("src/b.ts", "console.log(y)"),
+		{File: "src/c.ts", Text: "eval(x)", Possible: true},
+	}, start)
+	if b.Total() != 3 || len(b.Entries) != 2 {
+		t.Fatalf("baseline = %+v", b.Entries)
+	}
+
+	path := filepath.Join(t.TempDir(), BaselineName)
+	if err := b.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Accepted up to each entry's count, wherever the line moved to
+	findings := []Finding{
+		finding("src/a.ts", "console.log(x)"),
+		finding("src/a.ts", "console.log(x)"),
+		finding("src/a.ts", "console.log(x)"),
+		finding("src/b.ts", "console.log(z)"),
+	}
+	if n := b.Apply(findings, ""); n != 2 || !findings[0].Baselined || findings[2].Baselined || !findings[2].Blocking() || findings[3].Baselined {
+		t.Errorf("Apply marked %d: %+v", n, findings)
+	}
+	sub := []Finding{finding("b.ts", "console.log(y)")}
+	if b.Apply(sub, "src") != 1 {
+		t.Error("Apply from a subdirectory didn't match")
+	}
+
+	fixed, added := b.Update(findings[:1], start.Add(24*time.Hour))
+	if fixed != 2 || added != 0 || b.Total() != 1 || b.Initial() != 3 || len(b.History) != 2 {
+		t.Errorf("Update = %d fixed, %d added; total %d, initial %d, history %+v", fixed, added, b.Total(), b.Initial(), b.History)
+	}
+}
+
+func TestBaselineMask(t *testing.T) {
+	b := NewBaseline([]Finding{
+		finding("src/a.ts", "console.log(x)"),
+		{File: "src/a.ts", Policy: "No debug", Rule: "contentRules[1]", Text: "console.log(x)"},
+	}, time.Now())
+
+	content := "  console.log(x)\nrun()\nconsole.log(x)\n"
+	got := b.Mask("No console.log", "contentRules[0]", "src/a.ts", content)
+	if strings.Count(got, "console.log(x)") != 1 || !strings.Contains(got, "run()") {
+		t.Errorf("Mask = %q, want one of the two lines left", got)
+	}
+	for _, key := range [][3]string{
+		{"No console.log", "contentRules[0]", "src/b.ts"},
+		{"No console.log", "contentRules[1]", "src/a.ts"},
+		{"No eval", "contentRules[0]", "src/a.ts"},
+	} {
+		if got := b.Mask(key[0], key[1], key[2], content); got != content {
+			t.Errorf("Mask%q = %q, want it unmasked", key, got)
+		}
+	}
+}
+
+func TestBaselineSkipsLocked(t *testing.T) {
+	locked := finding("src/a.ts", "console.log(x)")
+	locked.Locked = true
+	b := NewBaseline([]Finding{locked, finding("src/a.ts", "console.log(y)")}, time.Now())
+	if b.Total() != 1 || b.Entries[0].Text != "console.log(y)" {
+		t.Errorf("baseline = %+v, want only the unlocked finding", b.Entries)
+	}
+
+	// Nor does an entry added by hand accept a locked finding
+	b.Entries = append(b.Entries, entryKey("src/a.ts", locked))
+	b.Entries[1].Count = 1
+	findings := []Finding{locked}
+	if n := b.Apply(findings, ""); n != 0 || findings[0].Baselined {
+		t.Errorf("Apply marked a locked finding: %+v", findings)
+	}
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// followUpWindow bounds how long after a block an agent's requests count
+// as its response to it.
+const followUpWindow = 10 * time.Minute
+
+// followUps is how many of the session's next requests are looked at.
+const followUps = 3
+
+// Effect sums up how agents responded to one policy's blocks, to show
+// which suggestions they follow and which they ignore or work around.
+// Each block counts once, as the first that applies of: the agent ran a
+// suggested command, it was blocked by the policy again, it went on to
+// something else, or the session made no further request.
+type Effect struct {
+	Policy string `json:"policy"`
+	// Blocks counts the policy's denials in sessions that can be
+	// followed (entries without a session can't)
+	Blocks int `json:"blocks"`
+	// Suggested counts the blocks that offered a command to run instead
+	Suggested int `json:"suggested"`
+	// Followed counts blocks after which the agent ran a suggested command
+	Followed int `json:"followed"`
+	// Retried counts blocks the agent ran into again
+	Retried int `json:"retried"`
+	// Other counts blocks after which the agent did something else
+	Other int `json:"other"`
+	// Ended counts blocks the session didn't follow with anything
+	Ended int `json:"ended"`
+}
+
+// Compliance is the share of blocks the agent didn't run into again, from
+// 0 to 1.
+func (e Effect) Compliance() float64 {
+	if e.Blocks == 0 {
+		return 0
+	}
+	return float64(e.Blocks-e.Retried) / float64(e.Blocks)
+}
+
+// Adoption is the share of blocks offering a command after which the
+// agent ran it, from 0 to 1.
+func (e Effect) Adoption() float64 {
+	if e.Suggested == 0 {
+		return 0
+	}
+	return float64(e.Followed) / float64(e.Suggested)
+}
+
+// Effectiveness correlates each denial in entries (oldest first, as Read
+// returns them) with the requests the same agent session made next, and
+// sums up the responses per policy, most blocks first.
+func Effectiveness(entries []Entry) []Effect {
+	sessions := make(map[string][]Entry)
+	for _, e := range entries {
+		if e.Session != "" {
+			key := e.Agent + "\x00" + e.Session
+			sessions[key] = append(sessions[key], e)
+		}
+	}
+
+	effects := make(map[string]*Effect)
+	for _, session := range sessions {
+		for i, e := range session {
+			if e.Allowed || e.Decision != policy.DecisionDeny || e.Policy == "" {
+				continue
+			}
+			effect := effects[e.Policy]
+			if effect == nil {
+				effect = &Effect{Policy: e.Policy}
+				effects[e.Policy] = effect
+			}
+			effect.Blocks++
+			suggested := suggestedCommands(e)
+			if len(suggested) > 0 {
+				effect.Suggested++
+			}
+
+			var next []Entry
+			for _, n := range session[i+1:] {
+				if len(next) == followUps || n.Time.Sub(e.Time) > followUpWindow {
+					break
+				}
+				next = append(next, n)
+			}
+			switch {
+			case followed(next, suggested):
+				effect.Followed++
+			case retried(next, e.Policy):
+				effect.Retried++
+			case len(next) > 0:
+				effect.Other++
+			default:
+				effect.Ended++
+			}
+		}
+	}
+
+	var out []Effect
+	for _, e := range effects {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Blocks != out[j].Blocks {
+			return out[i].Blocks > out[j].Blocks
+		}
+		return out[i].Policy < out[j].Policy
+	})
+	return out
+}
+
+// suggestedCommands returns the commands a block offered: its
+// alternatives, or its suggestion when that reads as commands ("pnpm add
+// or yarn add") rather than advice ("Use a logging library").
+func suggestedCommands(e Entry) []string {
+	if len(e.Alternatives) > 0 {
+		return e.Alternatives
+	}
+	var commands []string
+	for _, s := range strings.Split(e.Suggest, " or ") {
+		s = strings.TrimSpace(s)
+		if s == "" || !strings.ContainsAny(s[:1], "abcdefghijklmnopqrstuvwxyz./") {
+			return nil
+		}
+		commands = append(commands, s)
+	}
+	return commands
+}
+
+// followed reports whether an allowed request in next ran one of the
+// suggested commands: its first words (up to two, without "{args}")
+// begin the request's command.
+func followed(next []Entry, suggested []string) bool {
+	for _, n := range next {
+		if !n.Allowed || n.Command == "" {
+			continue
+		}
+		have := strings.Fields(n.Command)
+		for _, s := range suggested {
+			want := strings.Fields(strings.ReplaceAll(s, "{args}", ""))
+			if len(want) > 2 {
+				want = want[:2]
+			}
+			if len(want) > 0 && len(have) >= len(want) && strings.Join(have[:len(want)], " ") == strings.Join(want, " ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retried reports whether the policy blocked a request in next.
+func retried(next []Entry, pol string) bool {
+	for _, n := range next {
+		if !n.Allowed && n.Policy == pol {
+			return true
+		}
+	}
+	return false
+}
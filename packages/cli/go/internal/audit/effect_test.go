@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestEffectiveness(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	n := 0
+	entry := func(session, command string, pol string, suggest ...string) Entry {
+		n++
+		e := Entry{Time: start.Add(time.Duration(n) * time.Minute), Agent: "claude-code", Session: session, Action: "execute", Command: command, Allowed: pol == "", Decision: policy.DecisionAllow}
+		if pol != "" {
+			e.Decision, e.Policy = policy.DecisionDeny, pol
+			if len(suggest) > 0 {
+				e.Suggest = suggest[0]
+			}
+		}
+		return e
+	}
+	const npm, push = "Use pnpm", "Prevent git force push"
+	entries := []Entry{
+		// Took the alternative
+		entry("a", "npm install zod", npm, "pnpm add"),
+		entry("a", "pnpm add zod", ""),
+		// Rephrased into the same block, then gave up
+		entry("b", "npm i zod", npm, "pnpm add"),
+		entry("b", "npm install --save zod", npm, "pnpm add"),
+		// Advice rather than a command, followed by something else
+		entry("c", "git push --force", push, "Push to a new branch instead"),
+		entry("c", "git status", ""),
+		// Nothing after it
+		entry("d", "git push -f", push, "Push to a new branch instead"),
+		// No session to follow
+		entry("", "npm ci", npm, "pnpm add"),
+	}
+	// Too late to be a response
+	late := entry("d", "git push origin main", "")
+	late.Time = late.Time.Add(time.Hour)
+	entries = append(entries, late)
+
+	got := Effectiveness(entries)
+	want := []Effect{
+		{Policy: npm, Blocks: 3, Suggested: 3, Followed: 1, Retried: 1, Ended: 1},
+		{Policy: push, Blocks: 2, Other: 1, Ended: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Effectiveness() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("effect %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if c := got[0].Compliance(); c < 0.66 || c > 0.67 {
+		t.Errorf("compliance = %v, want 2/3", c)
+	}
+}
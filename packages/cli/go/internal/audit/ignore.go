@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+
+	"github.com/VulnZap/veto/internal/matcher"
+)
+
+// ignoreRule is one pattern from a .gitignore.
+type ignoreRule struct {
+	// base is the directory of the .gitignore, relative to the scan root
+	// ("" at the root)
+	base    string
+	glob    glob.Glob
+	negate  bool
+	dirOnly bool
+	// top is set for a pattern like /build, which names a single entry
+	// of base rather than names at any depth
+	top bool
+}
+
+// ignorer is the .gitignore rules in effect in a directory, outermost
+// first, so later rules win.
+type ignorer []ignoreRule
+
+// ignored reports whether git ignores rel, a slash-separated path
+// relative to the scan root.
+func (ig ignorer) ignored(rel string, dir bool) bool {
+	ignored := false
+	for _, r := range ig {
+		if r.dirOnly && !dir {
+			continue
+		}
+		p := rel
+		if r.base != "" {
+			p = strings.TrimPrefix(rel, r.base+"/")
+		}
+		if r.top && strings.Contains(p, "/") {
+			continue
+		}
+		if r.glob.Match(p) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// with returns the rules in effect below base, adding those of the
+// ignore file at path, if there is one. A pattern with a slash is
+// anchored to base; one without matches names at any depth below it.
+func (ig ignorer) with(path, base string) ignorer {
+	f, err := os.Open(path)
+	if err != nil {
+		return ig
+	}
+	defer f.Close()
+
+	rules := append(ignorer(nil), ig...)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			r.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		if strings.HasPrefix(line, "/") {
+			line = line[1:]
+			r.top = !strings.Contains(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if r.glob, err = matcher.CompilePath(line); err != nil {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// rootIgnorer returns the rules in effect at the scan root: the
+// repository's .git/info/exclude and the root .gitignore.
+func rootIgnorer(root string) ignorer {
+	var ig ignorer
+	ig = ig.with(filepath.Join(root, ".git", "info", "exclude"), "")
+	return ig.with(filepath.Join(root, ".gitignore"), "")
+}
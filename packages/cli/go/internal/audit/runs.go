@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunsDir is the directory in DirName holding scheduled audit runs, one
+// JSON file each.
+const RunsDir = "audits"
+
+// keepRuns is how many runs are kept; older ones are removed.
+const keepRuns = 30
+
+// runTimeFormat names run files, so they sort by time.
+const runTimeFormat = "20060102T150405Z"
+
+// Run is the result of a scheduled audit.
+type Run struct {
+	At time.Time `json:"at"`
+	// Files is how many files were scanned
+	Files int `json:"files"`
+	// Violations are the blocking findings the baseline doesn't accept
+	Violations []Finding `json:"violations"`
+	// New are the violations the previous run didn't have, or all of
+	// them for the first run
+	New []Finding `json:"new"`
+}
+
+// NewRun records a scan of the project as of now, after its baseline
+// (if any) has been applied, and compares it with the previous run.
+func NewRun(report *Report, previous *Run, now time.Time) *Run {
+	run := &Run{At: now.UTC(), Files: report.Files, Violations: []Finding{}, New: []Finding{}}
+	for _, f := range report.Findings {
+		if f.Blocking() {
+			run.Violations = append(run.Violations, f)
+		}
+	}
+
+	// Like the baseline, match by text rather than line number
+	seen := map[BaselineEntry]int{}
+	if previous != nil {
+		for _, f := range previous.Violations {
+			seen[entryKey(f.File, f)]++
+		}
+	}
+	for _, f := range run.Violations {
+		key := entryKey(f.File, f)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		run.New = append(run.New, f)
+	}
+	return run
+}
+
+// SaveRun stores a run for the project at root, removing the oldest
+// past the last 30.
+func SaveRun(root string, run *Run) error {
+	dir, err := DataDir(root)
+	if err != nil {
+		return err
+	}
+	dir = filepath.Join(dir, RunsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, run.At.UTC().Format(runTimeFormat)+".json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	names, err := runFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > keepRuns {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+	return nil
+}
+
+// LastRun returns the latest run stored for the project at root, or nil
+// when there are none.
+func LastRun(root string) (*Run, error) {
+	dir := filepath.Join(root, DirName, RunsDir)
+	names, err := runFiles(dir)
+	if errors.Is(err, os.ErrNotExist) || len(names) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, names[len(names)-1]))
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("%s: %w", names[len(names)-1], err)
+	}
+	return &run, nil
+}
+
+// runFiles returns the run files in dir, oldest first.
+func runFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") && !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
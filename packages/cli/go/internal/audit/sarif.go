@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"path"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// SARIF is a SARIF 2.1.0 log, the format code scanning tools (GitHub
+// code scanning among them) read. Only the parts veto fills are modeled.
+type SARIF struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string          `json:"id"`
+	ShortDescription     sarifText       `json:"shortDescription"`
+	Help                 *sarifText      `json:"help,omitempty"`
+	DefaultConfiguration sarifConfig     `json:"defaultConfiguration"`
+	Properties           sarifProperties `json:"properties"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	// SecuritySeverity is the 0.0-10.0 score GitHub ranks alerts by
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifText          `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+// securitySeverity scores severities for GitHub, which calls 9.0 and up
+// critical, 7.0 high, 4.0 medium and anything lower low.
+var securitySeverity = map[policy.Severity]string{
+	policy.SeverityCritical: "9.5",
+	policy.SeverityHigh:     "8.0",
+	policy.SeverityMedium:   "5.0",
+	policy.SeverityLow:      "2.0",
+}
+
+// level is a finding's SARIF level: error for blocking findings of high
+// severity and up, warning for other blocking ones, and note for those
+// that wouldn't block.
+func level(f Finding) string {
+	switch {
+	case !f.Blocking():
+		return "note"
+	case f.Severity.Rank() >= policy.SeverityHigh.Rank():
+		return "error"
+	}
+	return "warning"
+}
+
+// SARIF converts a report to a SARIF log from veto at version. Each
+// policy rule that matched becomes a SARIF rule, identified as
+// "<policy>/<rule>". Paths are relative to the scan root, which
+// code scanning takes to be the repository's (%SRCROOT%); dir is the
+// root's path within it ("" when it is the repository). Findings the
+// baseline accepts are reported as suppressed.
+func (r *Report) SARIF(version, dir string) *SARIF {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "veto",
+			InformationURI: "https://github.com/VulnZap/veto",
+			Version:        version,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	index := map[string]int{}
+	for _, f := range r.Findings {
+		id := f.Policy + "/" + f.Rule
+		i, ok := index[id]
+		if !ok {
+			i = len(run.Tool.Driver.Rules)
+			index[id] = i
+			rule := sarifRule{
+				ID:                   id,
+				ShortDescription:     sarifText{Text: f.Reason},
+				DefaultConfiguration: sarifConfig{Level: level(f)},
+				Properties: sarifProperties{
+					SecuritySeverity: securitySeverity[f.Severity],
+					Tags:             []string{string(f.Severity)},
+				},
+			}
+			if f.Suggest != "" {
+				rule.Help = &sarifText{Text: f.Suggest}
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: path.Join(dir, f.File), URIBaseID: "%SRCROOT%"}}
+		if f.Line > 0 {
+			loc.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+		}
+		result := sarifResult{
+			RuleID:    id,
+			RuleIndex: i,
+			Level:     level(f),
+			Message:   sarifText{Text: f.Reason + " (" + f.Policy + ")"},
+			Locations: []sarifLocation{{PhysicalLocation: loc}},
+		}
+		if f.Baselined {
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: "accepted in " + BaselineName}}
+		}
+		run.Results = append(run.Results, result)
+	}
+	return &SARIF{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestSARIF(t *testing.T) {
+	report := &Report{Findings: []Finding{
+		{File: ".env", Policy: "Protect .env", Rule: "include", Reason: "secrets", Decision: policy.DecisionDeny, Severity: policy.SeverityCritical},
+		{File: "src/a.ts", Line: 3, Column: 5, Policy: "No console.log", Rule: "content-0", Reason: "use the logger", Suggest: "log.info", Decision: policy.DecisionDeny, Severity: policy.SeverityMedium},
+		{File: "src/b.ts", Line: 1, Column: 1, Policy: "No console.log", Rule: "content-0", Reason: "use the logger", Decision: policy.DecisionDeny, Severity: policy.SeverityMedium, Baselined: true},
+		{File: "src/c.ts", Line: 2, Column: 1, Policy: "No any", Rule: "content-0", Reason: "type it", Decision: policy.DecisionDeny, Severity: policy.SeverityLow, Monitored: true},
+	}}
+
+	log := report.SARIF("1.2.3", "apps/web")
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || run.Tool.Driver.Version != "1.2.3" {
+		t.Fatalf("rules = %+v", run.Tool.Driver.Rules)
+	}
+	if r := run.Tool.Driver.Rules[0]; r.ID != "Protect .env/include" || r.Properties.SecuritySeverity != "9.5" {
+		t.Errorf("rule 0 = %+v", r)
+	}
+
+	want := []struct {
+		level, uri string
+		line, rule int
+		suppressed bool
+	}{
+		{"error", "apps/web/.env", 0, 0, false},
+		{"warning", "apps/web/src/a.ts", 3, 1, false},
+		{"note", "apps/web/src/b.ts", 1, 1, true},
+		{"note", "apps/web/src/c.ts", 2, 2, false},
+	}
+	for i, w := range want {
+		r := run.Results[i]
+		loc := r.Locations[0].PhysicalLocation
+		line := 0
+		if loc.Region != nil {
+			line = loc.Region.StartLine
+		}
+		if r.Level != w.level || loc.ArtifactLocation.URI != w.uri || line != w.line || r.RuleIndex != w.rule || (len(r.Suppressions) > 0) != w.suppressed {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+	}
+
+	if _, err := json.Marshal(log); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFails(t *testing.T) {
+	f := Finding{Decision: policy.DecisionDeny, Severity: policy.SeverityMedium}
+	if !f.Fails(policy.SeverityLow) || !f.Fails(policy.SeverityMedium) || f.Fails(policy.SeverityHigh) {
+		t.Error("medium finding fails the wrong thresholds")
+	}
+	f.Monitored = true
+	if f.Fails(policy.SeverityLow) {
+		t.Error("monitored finding fails")
+	}
+}
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// maxScanSize is the size past which files aren't scanned; they're
+// rarely code and an agent wouldn't rewrite them whole.
+const maxScanSize = 1 << 20
+
+// maxTextLen bounds a finding's Text, in characters, for minified files.
+const maxTextLen = 160
+
+// Finding is a violation already in the repository: a content rule
+// match, or a place an AST rule may match (see matcher.FindAST).
+type Finding struct {
+	// File is slash-separated and relative to the scan root
+	File string `json:"file"`
+	// Line and Column start at 1; Column counts characters. Both are 0
+	// for findings about the file as a whole (see ScanStaged)
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Policy string `json:"policy"`
+	// Rule identifies the rule in its policy (see policy.RuleID)
+	Rule    string `json:"rule"`
+	Reason  string `json:"reason"`
+	Suggest string `json:"suggest,omitempty"`
+	// Decision is what the policy would do with an agent writing this
+	Decision policy.Decision `json:"decision"`
+	// Severity is the policy's, medium for policies without one
+	Severity  policy.Severity `json:"severity"`
+	Monitored bool            `json:"monitored,omitempty"`
+	// Locked is set for findings of locked policies, which a baseline
+	// never accepts
+	Locked bool `json:"locked,omitempty"`
+	// Possible is set for AST rule findings, which the Go engine can't
+	// confirm without parsing the file
+	Possible bool `json:"possible,omitempty"`
+	// Text is the line the finding is on, trimmed and cut short after
+	// maxTextLen characters
+	Text string `json:"text"`
+	// Baselined is set for violations the project's baseline accepts
+	Baselined bool `json:"baselined,omitempty"`
+}
+
+// Report is the result of a scan.
+type Report struct {
+	Root string `json:"root"`
+	// Files is how many files were scanned
+	Files    int       `json:"files"`
+	Findings []Finding `json:"findings"`
+}
+
+// Blocking reports whether a finding is something the policies would
+// stop an agent from writing: denied or asked about, enforced, not
+// merely a possible AST match, and not accepted by the baseline.
+func (f Finding) Blocking() bool {
+	return !f.Monitored && !f.Possible && !f.Baselined && (f.Decision == policy.DecisionDeny || f.Decision == policy.DecisionAsk)
+}
+
+// Fails reports whether a finding is blocking and at least as severe as
+// threshold; every blocking finding fails an empty threshold.
+func (f Finding) Fails(threshold policy.Severity) bool {
+	return f.Blocking() && f.Severity.Rank() >= threshold.Rank()
+}
+
+// rated returns a policy's severity, or medium for policies without one,
+// as notifications rate them.
+func rated(s policy.Severity) policy.Severity {
+	if s == "" {
+		return policy.SeverityMedium
+	}
+	return s
+}
+
+// Scan checks every file under root against set's content and AST
+// rules, as if an agent were writing it, and reports the violations
+// already there. Files git ignores, .git, veto's own data and baseline,
+// binary files and files over 1 MiB are skipped. Files are checked by workers
+// goroutines, or one per CPU when workers < 1; findings are sorted by
+// file and position.
+func Scan(root string, set *matcher.Set, workers int) (*Report, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.ReadDir(root); err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	files := make(chan string)
+	results := make(chan []Finding)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range files {
+				if findings, ok := scanFile(root, rel, set); ok {
+					results <- findings
+				}
+			}
+		}()
+	}
+	go func() {
+		walk(root, "", rootIgnorer(root), files)
+		close(files)
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &Report{Root: root, Findings: []Finding{}}
+	for findings := range results {
+		report.Files++
+		report.Findings = append(report.Findings, findings...)
+	}
+	sortFindings(report.Findings)
+	return report, nil
+}
+
+// Files lists the files under root Scan walks, slash-separated and
+// relative to root: those git doesn't ignore, outside .git and veto's
+// own data and baseline.
+func Files(root string) []string {
+	files := make(chan string)
+	go func() {
+		walk(root, "", rootIgnorer(root), files)
+		close(files)
+	}()
+	var list []string
+	for f := range files {
+		list = append(list, f)
+	}
+	return list
+}
+
+// walk sends the files under dir (relative to root) that git doesn't
+// ignore. Directories that can't be read are skipped, as are symlinks.
+func walk(root, dir string, ig ignorer, files chan<- string) {
+	abs := filepath.Join(root, filepath.FromSlash(dir))
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return
+	}
+	if dir != "" {
+		ig = ig.with(filepath.Join(abs, ".gitignore"), dir)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		rel := name
+		if dir != "" {
+			rel = dir + "/" + name
+		}
+		switch {
+		case e.Type()&os.ModeSymlink != 0:
+		case e.IsDir():
+			if name != ".git" && name != DirName && !ig.ignored(rel, true) {
+				walk(root, rel, ig, files)
+			}
+		case rel == BaselineName:
+		case e.Type().IsRegular() && !ig.ignored(rel, false):
+			files <- rel
+		}
+	}
+}
+
+// scanFile checks one file, reporting false when it wasn't scanned.
+func scanFile(root, rel string, set *matcher.Set) ([]Finding, bool) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if info, err := os.Stat(path); err != nil || info.Size() > maxScanSize {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, false
+	}
+	content := string(data)
+
+	var findings []Finding
+	add := func(m matcher.ContentMatch, reason, suggest string) {
+		line, col, text := locate(content, m.Start)
+		findings = append(findings, Finding{
+			File:      rel,
+			Line:      line,
+			Column:    col,
+			Policy:    m.Policy.Description,
+			Rule:      m.ID,
+			Reason:    reason,
+			Suggest:   suggest,
+			Decision:  m.Decision,
+			Severity:  rated(m.Policy.Severity),
+			Monitored: m.Monitored,
+			Locked:    m.Policy.Locked,
+			Possible:  m.AST != nil,
+			Text:      text,
+		})
+	}
+	for _, m := range set.FindContent(rel, content) {
+		add(m, m.Rule.Reason, m.Rule.Suggest)
+	}
+	for _, m := range set.FindAST(rel, content) {
+		add(m, m.AST.Reason, m.AST.Suggest)
+	}
+	return findings, true
+}
+
+// locate returns the line and column of a byte offset in content, and
+// the line's text as Finding.Text has it.
+func locate(content string, offset int) (line, col int, text string) {
+	start := strings.LastIndexByte(content[:offset], '\n') + 1
+	end := strings.IndexByte(content[offset:], '\n')
+	if end < 0 {
+		end = len(content)
+	} else {
+		end += offset
+	}
+	line = strings.Count(content[:start], "\n") + 1
+	col = utf8.RuneCountInString(content[start:offset]) + 1
+	return line, col, lineText(content[start:end])
+}
+
+// lineText is a line as Finding.Text has it: trimmed and cut short after
+// maxTextLen characters.
+func lineText(line string) string {
+	text := strings.TrimSpace(line)
+	if r := []rune(text); len(r) > maxTextLen {
+		text = string(r[:maxTextLen]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":          "dist/\n*.log\n/top.ts\n",
+		"src/app.ts":          "import x from 'y'\n\nconsole.log(x) // debug\n",
+		"src/ok.ts":           "logger.info('fine')\n",
+		"src/.gitignore":      "gen/\n!keep.log\n",
+		"src/keep.log":        "console.log('kept')\n",
+		"src/gen/out.ts":      "console.log('generated')\n",
+		"src/top.ts":          "console.log('not the root one')\n",
+		"top.ts":              "console.log('ignored')\n",
+		"dist/bundle.js":      "console.log('built')\n",
+		"debug.log":           "console.log('logged')\n",
+		"src/db.ts":           "const q = 'SELECT ' + table\n",
+		"assets/logo.bin":     "console.log\x00\x01",
+		".git/hooks/x.ts":     "console.log('git')\n",
+		DirName + "/notes.ts": "console.log('veto')\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set, err := matcher.NewSet([]*policy.Policy{
+		{
+			Action:      policy.ActionModify,
+			Description: "No console.log",
+			ContentRules: []policy.ContentRule{
+				{Pattern: `console\.log\(`, Reason: "use the logger", Suggest: "logger.info()"},
+			},
+		},
+		{
+			Action:      policy.ActionModify,
+			Description: "No raw SQL",
+			Decision:    policy.DecisionWarn,
+			ASTRules: []policy.ASTRule{
+				{ID: "sql-concat", Query: "(binary_expression)", Languages: []string{"typescript"}, Reason: "build queries with the query builder", RegexPreFilter: "SELECT"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Scan(root, set, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type where struct {
+		file      string
+		line, col int
+	}
+	want := []where{{"src/app.ts", 3, 1}, {"src/db.ts", 1, 12}, {"src/keep.log", 1, 1}, {"src/top.ts", 1, 1}}
+	if len(report.Findings) != len(want) {
+		t.Fatalf("findings = %+v, want %v", report.Findings, want)
+	}
+	for i, f := range report.Findings {
+		if got := (where{f.File, f.Line, f.Column}); got != want[i] {
+			t.Errorf("finding %d at %v, want %v", i, got, want[i])
+		}
+	}
+
+	app := report.Findings[0]
+	if app.Rule != "contentRules[0]" || app.Decision != policy.DecisionDeny || app.Text != "console.log(x) // debug" || !app.Blocking() {
+		t.Errorf("src/app.ts finding = %+v", app)
+	}
+	if sql := report.Findings[1]; sql.Rule != "astRules[0]" || !sql.Possible || sql.Blocking() {
+		t.Errorf("src/db.ts finding = %+v, want a possible AST match", sql)
+	}
+	// Every file not ignored, including the .gitignores themselves
+	if report.Files != 7 {
+		t.Errorf("scanned %d files, want 7", report.Files)
+	}
+}
@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// hunkRe matches a unified diff hunk header, capturing where the hunk
+// starts in the new file and how many lines it has there.
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// ScanStaged checks the changes staged in the git repository holding
+// root, as a pre-commit hook sees them: files added, modified or deleted
+// under root against the file patterns of set's policies that protect
+// files, and the lines they add against its content and AST rules.
+// Findings about a file as a whole have no line. Unlike Scan, content
+// that was already committed isn't reported, nor is content nobody
+// staged.
+func ScanStaged(root string, set *matcher.Set) (*Report, error) {
+	out, err := git(root, "diff", "--cached", "--relative", "--no-renames", "--name-status", "-z")
+	if err != nil {
+		return nil, err
+	}
+	protecting, err := protectingSet(set)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Root: root, Findings: []Finding{}}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, rel := fields[i], fields[i+1]
+		action := policy.ActionModify
+		switch status[0] {
+		case 'A':
+			action = policy.ActionCreate
+		case 'D':
+			action = policy.ActionDelete
+		case 'M', 'T':
+		default:
+			continue
+		}
+		report.Files++
+
+		result := protecting.Check(&policy.CheckRequest{Action: string(action), Target: rel, Mode: policy.ModeInteractive})
+		if !result.Allowed {
+			report.Findings = append(report.Findings, Finding{
+				File:     rel,
+				Policy:   result.Policy,
+				Rule:     result.Rule,
+				Reason:   result.Reason,
+				Suggest:  result.Suggest,
+				Decision: result.Decision,
+				Severity: traceSeverity(result, result.Policy),
+			})
+		}
+		for _, hit := range result.Monitored {
+			report.Findings = append(report.Findings, Finding{
+				File:      rel,
+				Policy:    hit.Policy,
+				Rule:      hit.Rule,
+				Reason:    hit.Reason,
+				Decision:  hit.Decision,
+				Severity:  traceSeverity(result, hit.Policy),
+				Monitored: true,
+			})
+		}
+
+		if action == policy.ActionDelete || rel == BaselineName {
+			continue
+		}
+		findings, err := scanStagedFile(root, rel, set)
+		if err != nil {
+			return nil, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	sortFindings(report.Findings)
+	return report, nil
+}
+
+// traceSeverity returns the severity of the named policy in a result's
+// trace, rated as findings are.
+func traceSeverity(r *policy.CheckResult, name string) policy.Severity {
+	for _, step := range r.Trace {
+		if step.Policy == name && step.Severity != "" {
+			return step.Severity
+		}
+	}
+	return rated("")
+}
+
+// protectingSet returns the policies in set that protect files by their
+// patterns. A content policy's include only scopes its rules (see
+// Matcher.Concerns), so its findings are the content it flags.
+func protectingSet(set *matcher.Set) (*matcher.Set, error) {
+	var policies []*policy.Policy
+	for _, m := range set.Matchers() {
+		if p := m.Policy(); len(p.ContentRules) == 0 && len(p.ASTRules) == 0 {
+			policies = append(policies, p)
+		}
+	}
+	return matcher.NewSet(policies)
+}
+
+// scanStagedFile reports the content and AST rule matches on the lines
+// the staged version of a file adds. Binary files and files over 1 MiB
+// are skipped, as Scan skips them.
+func scanStagedFile(root, rel string, set *matcher.Set) ([]Finding, error) {
+	data, err := git(root, "show", ":./"+rel)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxScanSize || strings.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, nil
+	}
+	diff, err := git(root, "diff", "--cached", "--no-color", "--no-ext-diff", "-U0", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+	added := addedLines(diff)
+
+	var findings []Finding
+	add := func(m matcher.ContentMatch, reason, suggest string) {
+		line, col, text := locate(data, m.Start)
+		last := line + strings.Count(data[m.Start:max(m.Start, m.End)], "\n")
+		if !added.overlaps(line, last) {
+			return
+		}
+		findings = append(findings, Finding{
+			File:      rel,
+			Line:      line,
+			Column:    col,
+			Policy:    m.Policy.Description,
+			Rule:      m.ID,
+			Reason:    reason,
+			Suggest:   suggest,
+			Decision:  m.Decision,
+			Severity:  rated(m.Policy.Severity),
+			Monitored: m.Monitored,
+			Possible:  m.AST != nil,
+			Text:      text,
+		})
+	}
+	for _, m := range set.FindContent(rel, data) {
+		add(m, m.Rule.Reason, m.Rule.Suggest)
+	}
+	for _, m := range set.FindAST(rel, data) {
+		add(m, m.AST.Reason, m.AST.Suggest)
+	}
+	return findings, nil
+}
+
+// lineRanges are [first, last] line ranges, inclusive.
+type lineRanges [][2]int
+
+// overlaps reports whether any range shares a line with first..last.
+func (r lineRanges) overlaps(first, last int) bool {
+	for _, lr := range r {
+		if lr[0] <= last && first <= lr[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// addedLines returns the lines of the new file a -U0 diff adds, from its
+// hunk headers.
+func addedLines(diff string) lineRanges {
+	var added lineRanges
+	for _, line := range strings.Split(diff, "\n") {
+		m := hunkRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count > 0 {
+			added = append(added, [2]int{start, start + count - 1})
+		}
+	}
+	return added
+}
+
+// RepoPath returns root's slash-separated path within the git repository
+// holding it, "" when root is the repository's top or not in one.
+func RepoPath(root string) string {
+	out, err := git(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSpace(out), "/")
+}
+
+// git runs git in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// sortFindings sorts findings by file and position.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// gitRepo creates a git repository in a temporary directory.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	run(t, root, "git", "init", "-q")
+	run(t, root, "git", "config", "user.email", "test@example.com")
+	run(t, root, "git", "config", "user.name", "Test")
+	return root
+}
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, out)
+	}
+}
+
+func write(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanStaged(t *testing.T) {
+	root := gitRepo(t)
+	write(t, root, "src/app.ts", "console.log('old')\nconst a = 1\n")
+	write(t, root, "src/old.test.ts", "it('works')\n")
+	write(t, root, "src/untouched.ts", "console.log('committed')\n")
+	run(t, root, "git", "add", "-A")
+	run(t, root, "git", "commit", "-qm", "init")
+
+	write(t, root, "src/app.ts", "console.log('old')\nconst a = 2\nconsole.log(a)\n")
+	write(t, root, ".env", "TOKEN=x\n")
+	write(t, root, "src/unstaged.ts", "console.log('not staged')\n")
+	run(t, root, "git", "add", "src/app.ts", ".env")
+	run(t, root, "git", "rm", "-q", "src/old.test.ts")
+	// Fixed in the working tree, but the commit takes what is staged
+	write(t, root, "src/app.ts", "const a = 2\n")
+
+	set, err := matcher.NewSet([]*policy.Policy{
+		{
+			Action:      policy.ActionModify,
+			Description: "No console.log",
+			ContentRules: []policy.ContentRule{
+				{Pattern: `console\.log\(`, Reason: "use the logger"},
+			},
+		},
+		{Action: policy.ActionModify, Description: "Protect .env", Include: []string{".env"}},
+		{Action: policy.ActionDelete, Description: "Keep tests", Include: []string{"**/*.test.ts"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ScanStaged(root, set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 3 {
+		t.Errorf("scanned %d files, want 3", report.Files)
+	}
+	want := []struct {
+		file string
+		line int
+	}{
+		{".env", 0},
+		{"src/app.ts", 3},
+		{"src/old.test.ts", 0},
+	}
+	if len(report.Findings) != len(want) {
+		t.Fatalf("findings = %+v, want %d", report.Findings, len(want))
+	}
+	for i, w := range want {
+		f := report.Findings[i]
+		if f.File != w.file || f.Line != w.line || !f.Blocking() {
+			t.Errorf("finding %d = %s:%d (%s, blocking %v), want %s:%d blocking", i, f.File, f.Line, f.Reason, f.Blocking(), w.file, w.line)
+		}
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	diff := "diff --git a/x b/x\n@@ -1,0 +1,2 @@\n+a\n+b\n@@ -5 +7 @@\n-c\n+d\n@@ -9,2 +10,0 @@\n-e\n-f\n"
+	added := addedLines(diff)
+	for _, tt := range []struct {
+		first, last int
+		want        bool
+	}{
+		{1, 1, true}, {2, 2, true}, {3, 6, false}, {7, 7, true}, {6, 8, true}, {10, 10, false},
+	} {
+		if got := added.overlaps(tt.first, tt.last); got != tt.want {
+			t.Errorf("overlaps(%d, %d) = %v, want %v", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// budgetRe matches change budget declarations: "change budget: 20",
+// "max 20 files per session", "limit changes to 20 files".
+var budgetRe = regexp.MustCompile(`^(?:change budget:?\s*(\d+)(?:\s+files?)?|(?:max|limit changes to)\s+(\d+)\s+(?:changed\s+)?files?(?:\s+per session)?)$`)
+
+// ChangeBudget returns the number of files a change budget phrase allows
+// per session, or 0 when the phrase isn't one.
+func ChangeBudget(phrase string) int {
+	m := budgetRe.FindStringSubmatch(normalize(phrase))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1] + m[2])
+	return n
+}
+
+// Budget builds the builtin for a change budget: once an agent session has
+// modified or deleted max distinct files, each further file needs a human.
+// Sessions are tracked by the daemon.
+func Budget(max int) *Builtin {
+	return &Builtin{
+		Description: fmt.Sprintf("Ask before changing more than %d files in one session", max),
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "refactoring"},
+		MaxFiles:    max,
+	}
+}
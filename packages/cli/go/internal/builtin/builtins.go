@@ -1,17 +1,77 @@
 // Package builtin provides predefined policies for common restrictions.
 package builtin
 
-import "github.com/VulnZap/veto/internal/policy"
+import (
+	"sort"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Category groups builtins by what they protect.
+type Category string
+
+const (
+	CategorySecurity     Category = "security"
+	CategoryStyle        Category = "style"
+	CategoryWorkflow     Category = "workflow"
+	CategoryDependencies Category = "dependencies"
+)
+
+// Categories lists the categories in display order.
+var Categories = []Category{CategorySecurity, CategoryWorkflow, CategoryDependencies, CategoryStyle}
 
 // Builtin is a predefined policy template.
 type Builtin struct {
-	Include      []string
-	Exclude      []string
-	Description  string
+	Include     []string
+	Exclude     []string
+	Description string
+	Category    Category
+	// Tags are finer-grained labels (e.g., "git", "typescript", "secrets")
+	Tags         []string
 	CommandRules []policy.CommandRule
 	ContentRules []policy.ContentRule
+	EnvRules     []policy.EnvRule
+	HeaderRules  []policy.HeaderRule
+	// StructureRules limit the length of files and functions
+	StructureRules []policy.StructureRule
+	// ShrinkRules limit how much of a file a write may remove
+	ShrinkRules []policy.ShrinkRule
+	BlockOpaque bool
+	// MaxFiles is a per-session change budget (0 = none)
+	MaxFiles int
+	// Allowlist and Allow make the builtin an allowlist (see
+	// policy.Policy.Allowlist)
+	Allowlist bool
+	Allow     []policy.AllowRule
+	// Decision is what the builtin does on a match when .veto doesn't
+	// say (empty means deny)
+	Decision policy.Decision
+	// Action, when set, is the operation the builtin's Include stops
+	// whatever the phrase's verb says (empty means the verb decides)
+	Action policy.Action
+}
+
+// pnpmAlternatives are offered in place of npm and yarn installs.
+var pnpmAlternatives = []policy.Alternative{
+	{Command: "pnpm install", Description: "Install the dependencies in package.json"},
+	{Command: "pnpm add {args}", Description: "Add the packages named", Safety: "check the package names before adding them"},
 }
 
+// infraDirs are where "infra safety" looks for infrastructure code.
+var infraDirs = []string{
+	"infra/**", "**/infra/**", "infrastructure/**", "**/infrastructure/**",
+	"terraform/**", "**/terraform/**", "cloudformation/**", "**/cloudformation/**",
+	"iac/**", "deploy/**", "deployments/**",
+}
+
+// terraformFiles and cloudFormationFiles are the file types "infra
+// safety" checks.
+var (
+	terraformFiles      = []string{"*.tf", "*.tf.json"}
+	cloudFormationFiles = []string{"*.yaml", "*.yml"}
+)
+
 // Registry maps builtin names to their definitions.
 var Registry = map[string]Builtin{
 	// ═══════════════════════════════════════════════════════════════════════
@@ -26,6 +86,8 @@ var Registry = map[string]Builtin{
 		},
 		Exclude:     []string{"test-results.*", "test-output.*", "**/coverage/**", "*.log", "*.xml"},
 		Description: "Test source files (not artifacts)",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "testing"},
 	},
 
 	"config": {
@@ -37,17 +99,23 @@ var Registry = map[string]Builtin{
 		},
 		Exclude:     []string{},
 		Description: "Configuration files",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files"},
 	},
 
 	".env": {
 		Include:     []string{".env", ".env.*", "**/.env", "**/.env.*"},
 		Exclude:     []string{".env.example", ".env.template", ".env.sample"},
 		Description: "Environment files (secrets)",
+		Category:    CategorySecurity,
+		Tags:        []string{"files", "secrets"},
 	},
 	"env": {
 		Include:     []string{".env", ".env.*", "**/.env", "**/.env.*"},
 		Exclude:     []string{".env.example", ".env.template", ".env.sample"},
 		Description: "Environment files (secrets)",
+		Category:    CategorySecurity,
+		Tags:        []string{"files", "secrets"},
 	},
 
 	"migrations": {
@@ -57,6 +125,8 @@ var Registry = map[string]Builtin{
 		},
 		Exclude:     []string{},
 		Description: "Database migrations",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "database"},
 	},
 
 	"lock files": {
@@ -66,18 +136,88 @@ var Registry = map[string]Builtin{
 		},
 		Exclude:     []string{},
 		Description: "Dependency lock files",
+		Category:    CategoryDependencies,
+		Tags:        []string{"files"},
 	},
 
+	"preserve tests": {
+		// Tests may be edited, so the files are only kept from deletion;
+		// the content rules catch skipping them
+		Include: []string{
+			"**/*.test.*", "**/*.spec.*", "**/*_test.go", "**/test_*.py",
+			"**/__tests__/**", "tests/**",
+		},
+		Action:      policy.ActionDelete,
+		Description: "Keep tests: don't delete or skip them",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"testing"},
+		CommandRules: []policy.CommandRule{
+			{
+				Block: []string{
+					"rm *.test.*", "rm *.spec.*", "rm *_test.go", "rm *test_*.py", "rm *__tests__*",
+					"git rm *.test.*", "git rm *.spec.*", "git rm *_test.go", "git rm *test_*.py", "git rm *__tests__*",
+				},
+				Reason:  "Deleting tests hides regressions",
+				Suggest: "fix or update the test instead",
+			},
+		},
+		ContentRules: []policy.ContentRule{
+			{
+				Pattern:   `\b(?:it|test|describe)\.skip\s*\(|\bx(?:it|test|describe)\s*\(`,
+				FileTypes: []string{"*.test.*", "*.spec.*", "**/__tests__/**"},
+				Reason:    "Skipping tests hides regressions",
+				Suggest:   "fix or update the test instead",
+			},
+			{
+				Pattern:   `@(?:pytest\.mark\.skip|unittest\.skip)`,
+				FileTypes: []string{"test_*.py", "*_test.py"},
+				Reason:    "Skipping tests hides regressions",
+				Suggest:   "fix or update the test instead",
+			},
+			{
+				Pattern:   `\bt\.Skip(?:f|Now)?\(`,
+				FileTypes: []string{"*_test.go"},
+				Reason:    "Skipping tests hides regressions",
+				Suggest:   "fix or update the test instead",
+			},
+		},
+	},
+
+	"generated files": {
+		Include: []string{
+			"*.pb.go", "*_pb2.py", "*_pb2_grpc.py", "*.gen.go", "*.gen.ts",
+			"*.generated.*", "*_generated.go",
+		},
+		Exclude:     []string{},
+		Description: "Generated code",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "codegen"},
+		HeaderRules: []policy.HeaderRule{
+			{
+				Markers: []string{"Code generated", "@generated", "DO NOT EDIT", "<auto-generated"},
+				Lines:   10,
+				Reason:  "File is generated",
+				Suggest: "change the generator or its input and regenerate",
+			},
+		},
+	},
+
+	"protect data files": *Shrink(defaultShrink),
+
 	"node_modules": {
 		Include:     []string{"node_modules/**", "**/node_modules/**"},
 		Exclude:     []string{},
 		Description: "Node modules directory",
+		Category:    CategoryDependencies,
+		Tags:        []string{"files", "javascript"},
 	},
 
 	".md files": {
 		Include:     []string{"*.md", "**/*.md"},
 		Exclude:     []string{},
 		Description: "Markdown files",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "docs"},
 	},
 
 	// ═══════════════════════════════════════════════════════════════════════
@@ -88,16 +228,21 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use pnpm instead of npm/yarn",
+		Category:    CategoryDependencies,
+		Tags:        []string{"package-manager", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
-				Block:   []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*"},
-				Suggest: "pnpm install",
-				Reason:  "Project uses pnpm",
+				Block:        []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*"},
+				Suggest:      "pnpm install",
+				Alternatives: pnpmAlternatives,
+				Reason:       "Project uses pnpm",
+				RunInstead:   "pnpm install",
 			},
 			{
-				Block:   []string{"yarn", "yarn install", "yarn add*"},
-				Suggest: "pnpm add",
-				Reason:  "Project uses pnpm",
+				Block:        []string{"yarn", "yarn install", "yarn add*"},
+				Suggest:      "pnpm add",
+				Alternatives: pnpmAlternatives,
+				Reason:       "Project uses pnpm",
 			},
 		},
 	},
@@ -105,16 +250,21 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use pnpm instead of npm/yarn",
+		Category:    CategoryDependencies,
+		Tags:        []string{"package-manager", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
-				Block:   []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*"},
-				Suggest: "pnpm install",
-				Reason:  "Project uses pnpm",
+				Block:        []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*"},
+				Suggest:      "pnpm install",
+				Alternatives: pnpmAlternatives,
+				Reason:       "Project uses pnpm",
+				RunInstead:   "pnpm install",
 			},
 			{
-				Block:   []string{"yarn", "yarn install", "yarn add*"},
-				Suggest: "pnpm add",
-				Reason:  "Project uses pnpm",
+				Block:        []string{"yarn", "yarn install", "yarn add*"},
+				Suggest:      "pnpm add",
+				Alternatives: pnpmAlternatives,
+				Reason:       "Project uses pnpm",
 			},
 		},
 	},
@@ -123,6 +273,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use bun instead of npm/pnpm/yarn",
+		Category:    CategoryDependencies,
+		Tags:        []string{"package-manager", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*", "npm run*"},
@@ -145,6 +297,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use bun instead of npm/pnpm/yarn",
+		Category:    CategoryDependencies,
+		Tags:        []string{"package-manager", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*", "npm run*"},
@@ -168,6 +322,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use yarn instead of npm",
+		Category:    CategoryDependencies,
+		Tags:        []string{"package-manager", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"npm install*", "npm i *", "npm i", "npm ci", "npm add*"},
@@ -181,6 +337,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Prevent sudo commands",
+		Category:    CategorySecurity,
+		Tags:        []string{"shell"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:  []string{"sudo *"},
@@ -193,6 +351,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Prevent git force push",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"git"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:  []string{"git push --force*", "git push -f*", "git push * --force*", "git push * -f*"},
@@ -205,11 +365,17 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Prevent git hard reset",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"git"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"git reset --hard*"},
 				Suggest: "git reset --soft or git stash",
-				Reason:  "Hard reset can lose uncommitted work",
+				Alternatives: []policy.Alternative{
+					{Command: "git stash", Description: "Set uncommitted changes aside", Safety: "git stash pop brings them back"},
+					{Command: "git reset --soft {args}", Description: "Move HEAD and keep the changes staged"},
+				},
+				Reason: "Hard reset can lose uncommitted work",
 			},
 		},
 	},
@@ -218,6 +384,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use vitest instead of jest",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"testing", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"jest*", "npx jest*", "npm run jest*", "pnpm jest*"},
@@ -230,6 +398,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use vitest instead of jest",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"testing", "javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"jest*", "npx jest*", "npm run jest*", "pnpm jest*"},
@@ -243,6 +413,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use pytest instead of unittest",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"testing", "python"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"python -m unittest*", "python3 -m unittest*"},
@@ -256,6 +428,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Prevent piping curl output to bash",
+		Category:    CategorySecurity,
+		Tags:        []string{"shell", "network"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:  []string{"curl * | bash*", "curl * | sh*", "wget * | bash*", "wget * | sh*"},
@@ -264,15 +438,227 @@ var Registry = map[string]Builtin{
 		},
 	},
 
+	"no obfuscated commands": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Block commands that can't be analyzed",
+		Category:    CategorySecurity,
+		Tags:        []string{"shell"},
+		BlockOpaque: true,
+	},
+
+	"protect env vars": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Keep secret environment variables off command lines",
+		Category:    CategorySecurity,
+		Tags:        []string{"shell", "secrets"},
+		EnvRules: []policy.EnvRule{
+			{
+				Names: []string{
+					"*_TOKEN", "*_SECRET", "*_SECRET_*", "*_API_KEY",
+					"*_PASSWORD", "*_PRIVATE_KEY", "DATABASE_URL",
+				},
+				Reason:  "Secret environment variables can't be printed, exported or sent",
+				Suggest: "let the tool read the variable from the environment itself",
+			},
+		},
+	},
+
+	"protect git config": {
+		Include: []string{
+			".gitconfig", "**/.gitconfig", "**/.config/git/**", "**/.git-credentials",
+			".git/config", "**/.ssh/**",
+		},
+		Exclude:     []string{},
+		Description: "Keep agents out of git config, credentials and SSH keys",
+		Category:    CategorySecurity,
+		Tags:        []string{"git", "secrets"},
+		CommandRules: []policy.CommandRule{
+			{
+				Block: []string{
+					"git config --global*", "git config * --global*",
+					"git config --system*", "git config * --system*",
+				},
+				Reason:  "Global git config applies to every repository on the machine",
+				Suggest: "set it in this repository's config, or ask the user to",
+			},
+			{
+				Block: []string{
+					"git config *credential.*", "git config *credential *",
+					"git credential*", "git credential-*",
+				},
+				Reason: "Credential helpers decide where git keeps and sends passwords",
+			},
+			{
+				Block:  []string{"ssh-keygen*", "ssh-add*", "ssh-copy-id*"},
+				Reason: "SSH keys are the user's to create and hand out",
+			},
+		},
+	},
+
+	"no publishing": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before publishing packages, images or releases",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"release"},
+		CommandRules: publishRules,
+		Decision:     policy.DecisionAsk,
+	},
+	"no npm publish": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before publishing to the npm registry",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"publishing", "javascript"},
+		CommandRules: []policy.CommandRule{npmPublish},
+		Decision:     policy.DecisionAsk,
+	},
+	"no cargo publish": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before publishing crates",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"publishing", "rust"},
+		CommandRules: []policy.CommandRule{cargoPublish},
+		Decision:     policy.DecisionAsk,
+	},
+	"no pypi upload": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before uploading to PyPI",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"publishing", "python"},
+		CommandRules: []policy.CommandRule{pypiUpload},
+		Decision:     policy.DecisionAsk,
+	},
+	"no github releases": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before creating GitHub releases",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"publishing", "git"},
+		CommandRules: []policy.CommandRule{githubRelease},
+		Decision:     policy.DecisionAsk,
+	},
+	"no docker push": {
+		Include:      []string{},
+		Exclude:      []string{},
+		Description:  "Ask before pushing images to registries not allowlisted",
+		Category:     CategoryWorkflow,
+		Tags:         []string{"publishing", "docker"},
+		CommandRules: []policy.CommandRule{dockerPush},
+		Decision:     policy.DecisionAsk,
+	},
+
+	"allowlist git remotes": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Only add git remotes on allowlisted hosts",
+		Category:    CategorySecurity,
+		Tags:        []string{"git", "network"},
+		CommandRules: []policy.CommandRule{
+			{
+				Block:      []string{"git remote add *", "git remote set-url *"},
+				AllowHosts: []string{"github.com", "gitlab.com", "bitbucket.org"},
+				Reason:     "Git remotes may only point at allowlisted hosts",
+				Suggest:    "add the host to the policy's allowHosts in .veto",
+			},
+		},
+	},
+
+	"protect ci workflows": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Block dangerous additions to CI workflows",
+		Category:    CategorySecurity,
+		Tags:        []string{"ci", "secrets"},
+		ContentRules: []policy.ContentRule{
+			{
+				Pattern:   `(?s)\bpull_request_target\b.*\bref:\s*['"]?(?:\$\{\{\s*github\.(?:event\.pull_request\.head\.(?:sha|ref)|head_ref)\s*\}\}|refs/pull/)`,
+				FileTypes: []string{"*.yml", "*.yaml"},
+				Include:   []string{".github/workflows/**"},
+				Reason:    "pull_request_target runs with the repository's secrets; checking out the pull request's code runs it with them",
+				Suggest:   "use the pull_request trigger to build untrusted code, or don't check out the head ref",
+			},
+			{
+				Pattern:   `^\s*(?:-\s+)?run:[^\n]*\$\{\{\s*secrets\.|^\s*[^\s:#'"-][^\s:]*\s[^\n]*\$\{\{\s*secrets\.|\btoJSON\(\s*secrets\s*\)`,
+				FileTypes: []string{"*.yml", "*.yaml"},
+				Include:   []string{".github/workflows/**", ".github/actions/**"},
+				Reason:    "Secrets expanded into a run step become part of the script, where logs and injected commands can read them",
+				Suggest:   "pass the secret through env: and use it as $NAME in the script",
+			},
+			{
+				Pattern:   `\b(?:curl|wget)\b[^\n|]*\|\s*(?:sudo\s+)?(?:ba|z)?sh\b|\b(?:ba)?sh\s+<\(\s*(?:curl|wget)\b`,
+				FileTypes: []string{"*.yml", "*.yaml"},
+				Include:   []string{".github/workflows/**", ".github/actions/**", ".gitlab-ci.yml", ".gitlab/**"},
+				Reason:    "Piping a download to a shell in CI runs whatever the server sends, with the pipeline's secrets",
+				Suggest:   "download the script, check its checksum, then run it; or use a pinned action or image",
+			},
+		},
+	},
+
+	"infra safety": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Block destructive or public settings in infrastructure code",
+		Category:    CategorySecurity,
+		Tags:        []string{"infra", "cloud"},
+		ContentRules: []policy.ContentRule{
+			{
+				Pattern:   `\bforce_destroy\s*=\s*true\b|"force_destroy"\s*:\s*true\b`,
+				FileTypes: terraformFiles,
+				Include:   infraDirs,
+				Reason:    "force_destroy deletes everything in the resource when it is destroyed, with no way back",
+				Suggest:   "leave force_destroy unset and empty the resource deliberately",
+			},
+			{
+				Pattern:   `\bacl\s*=\s*"(?:public-read|public-read-write|authenticated-read)"|\b(?:block_public_acls|block_public_policy|ignore_public_acls|restrict_public_buckets)\s*=\s*false\b`,
+				FileTypes: terraformFiles,
+				Include:   infraDirs,
+				Reason:    "Public S3 ACLs expose the bucket's objects to anyone",
+				Suggest:   "keep the bucket private and serve objects through a CDN or presigned URLs",
+			},
+			{
+				Pattern:   `\bAccessControl:\s*['"]?(?:PublicRead|PublicReadWrite|AuthenticatedRead)\b|\b(?:BlockPublicAcls|BlockPublicPolicy|IgnorePublicAcls|RestrictPublicBuckets):\s*['"]?false\b`,
+				FileTypes: cloudFormationFiles,
+				Include:   infraDirs,
+				Reason:    "Public S3 ACLs expose the bucket's objects to anyone",
+				Suggest:   "keep the bucket private and serve objects through a CDN or presigned URLs",
+			},
+			{
+				Pattern:   `\bingress\s*\{[^}]*"(?:0\.0\.0\.0/0|::/0)"|\btype\s*=\s*"ingress"[^}]*"(?:0\.0\.0\.0/0|::/0)"|"(?:0\.0\.0\.0/0|::/0)"[^}]*\btype\s*=\s*"ingress"|aws_vpc_security_group_ingress_rule"[^{]*\{[^}]*"(?:0\.0\.0\.0/0|::/0)"`,
+				FileTypes: terraformFiles,
+				Include:   infraDirs,
+				Reason:    "Ingress from 0.0.0.0/0 opens the port to the whole internet",
+				Suggest:   "allow the CIDR ranges or security groups that need access",
+			},
+			{
+				Pattern:   `SecurityGroupIngress\b[^\n]*(?:\n[ \t]+[^\n]*){0,12}?\n[ \t-]+CidrIp(?:v6)?:\s*['"]?(?:0\.0\.0\.0/0|::/0)`,
+				FileTypes: cloudFormationFiles,
+				Include:   infraDirs,
+				Reason:    "Ingress from 0.0.0.0/0 opens the port to the whole internet",
+				Suggest:   "allow the CIDR ranges or security groups that need access",
+			},
+		},
+	},
+
 	"use docker compose": {
 		Include:     []string{},
 		Exclude:     []string{},
 		Description: "Use docker compose v2 instead of docker-compose",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"docker"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"docker-compose *"},
 				Suggest: "docker compose",
-				Reason:  "Use docker compose v2 syntax",
+				Alternatives: []policy.Alternative{
+					{Command: "docker compose {args}", Description: "The same command in docker compose v2"},
+				},
+				Reason:     "Use docker compose v2 syntax",
+				RunInstead: "docker compose {args}",
 			},
 		},
 	},
@@ -285,6 +671,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx", "**/*.mts", "**/*.mjs"},
 		Exclude:     []string{},
 		Description: "Prefer native methods over lodash",
+		Category:    CategoryDependencies,
+		Tags:        []string{"javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block: []string{
@@ -311,6 +699,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Use date-fns or native Date instead of moment.js",
+		Category:    CategoryDependencies,
+		Tags:        []string{"javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:   []string{"npm install moment*", "npm i moment", "pnpm add moment*", "bun add moment*", "yarn add moment*"},
@@ -333,6 +723,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Use modern DOM APIs instead of jQuery",
+		Category:    CategoryDependencies,
+		Tags:        []string{"javascript"},
 		CommandRules: []policy.CommandRule{
 			{
 				Block:  []string{"npm install jquery*", "pnpm add jquery*", "bun add jquery*", "yarn add jquery*"},
@@ -354,6 +746,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"src/**/*.ts", "src/**/*.js", "src/**/*.tsx", "src/**/*.jsx"},
 		Exclude:     []string{"**/*.test.*", "**/*.spec.*", "**/test/**", "**/tests/**", "**/__tests__/**"},
 		Description: "No console.log in production code",
+		Category:    CategoryStyle,
+		Tags:        []string{"javascript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `console\.log\s*\(`,
@@ -369,6 +763,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"src/**/*.ts", "src/**/*.js", "src/**/*.tsx", "src/**/*.jsx"},
 		Exclude:     []string{"**/*.test.*", "**/*.spec.*", "**/test/**", "**/tests/**"},
 		Description: "No console statements in production code",
+		Category:    CategoryStyle,
+		Tags:        []string{"javascript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `console\.\w+\s*\(`,
@@ -384,6 +780,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "No debugger statements",
+		Category:    CategoryStyle,
+		Tags:        []string{"javascript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `\bdebugger\b`,
@@ -398,6 +796,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Use functional React components with hooks",
+		Category:    CategoryStyle,
+		Tags:        []string{"react"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `class\s+\w+\s+extends\s+(?:React\.)?(?:Component|PureComponent)`,
@@ -412,6 +812,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Use functional React components",
+		Category:    CategoryStyle,
+		Tags:        []string{"react"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `class\s+\w+\s+extends\s+(?:React\.)?(?:Component|PureComponent)`,
@@ -427,6 +829,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.tsx"},
 		Exclude:     []string{"**/*.d.ts", "**/types/**", "**/@types/**"},
 		Description: "Avoid any type in TypeScript",
+		Category:    CategoryStyle,
+		Tags:        []string{"typescript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `:\s*any\b`,
@@ -455,6 +859,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.tsx"},
 		Exclude:     []string{"**/*.d.ts"},
 		Description: "Comprehensive any type detection",
+		Category:    CategoryStyle,
+		Tags:        []string{"typescript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `:\s*any\b|as\s+any\b|<any>|Array<any>|Record<\w+,\s*any>`,
@@ -469,6 +875,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.tsx"},
 		Exclude:     []string{"**/*.d.ts"},
 		Description: "Enforce strict TypeScript typing",
+		Category:    CategoryStyle,
+		Tags:        []string{"typescript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `:\s*any\b|as\s+any\b|<any>|\|\s*any\b|&\s*any\b`,
@@ -483,6 +891,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Prevent use of eval() and similar unsafe constructs",
+		Category:    CategorySecurity,
+		Tags:        []string{"javascript"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `\beval\s*\(`,
@@ -505,6 +915,8 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "Prevent direct innerHTML assignment (XSS risk)",
+		Category:    CategorySecurity,
+		Tags:        []string{"javascript", "xss"},
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `\.innerHTML\s*=`,
@@ -527,6 +939,7 @@ var Registry = map[string]Builtin{
 		Include:     []string{"**/*.ts", "**/*.js", "**/*.tsx", "**/*.jsx"},
 		Exclude:     []string{},
 		Description: "No TODO comments in committed code",
+		Category:    CategoryStyle,
 		ContentRules: []policy.ContentRule{
 			{
 				Pattern:   `//\s*TODO\b|/\*\s*TODO\b`,
@@ -540,6 +953,55 @@ var Registry = map[string]Builtin{
 			},
 		},
 	},
+
+	"no wip commits": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "No WIP commit messages",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"git", "commits"},
+		ContentRules: []policy.ContentRule{
+			{
+				Pattern:   `(?i)^\s*(?:\[wip\]|\(wip\)|wip\b)\s*:?\s*`,
+				FileTypes: []string{policy.CommitMessage},
+				Reason:    "Commit messages should describe finished work",
+				Suggest:   "say what the commit changes; squash work in progress before committing",
+			},
+		},
+	},
+
+	"conventional commits": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "Commit messages follow Conventional Commits",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"git", "commits"},
+		StructureRules: []policy.StructureRule{
+			{
+				FileTypes:     []string{policy.CommitMessage},
+				RequireHeader: `\A(?:feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(?:\([\w./-]+\))?!?: \S`,
+				HeaderLines:   1,
+				Reason:        "Commit subjects start with a Conventional Commits type",
+				Suggest:       `start the subject with a type, as in "fix(parser): handle empty input"`,
+			},
+		},
+	},
+
+	"no ai attribution": {
+		Include:     []string{},
+		Exclude:     []string{},
+		Description: "No AI attribution in commit messages",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"git", "commits"},
+		ContentRules: []policy.ContentRule{
+			{
+				Pattern:   `(?i)^.*(?:co-authored-by:|generated (?:with|by)|authored by).*\b(?:claude|anthropic|copilot|chatgpt|openai|gpt-\d|codex|gemini|cursor|windsurf|aider|devin|amazon q|ai assistant)\b.*$`,
+				FileTypes: []string{policy.CommitMessage},
+				Reason:    "Commits are attributed to the people who make them",
+				Suggest:   "drop the attribution lines",
+			},
+		},
+	},
 }
 
 // Aliases maps common phrases to builtin names.
@@ -561,6 +1023,45 @@ var Aliases = map[string]string{
 	"block force push":          "no force push",
 	"no git force push":         "no force push",
 	"docker compose v2":         "use docker compose",
+	"protect secrets in env":    "protect env vars",
+	"protect api keys":          "protect env vars",
+	"don't leak env vars":       "protect env vars",
+	"protect gitconfig":         "protect git config",
+	"no global git config":      "protect git config",
+	"protect git credentials":   "protect git config",
+	"protect ssh keys":          "protect git config",
+	"protect ssh":               "protect git config",
+	"allowlist remotes":         "allowlist git remotes",
+	"restrict git remotes":      "allowlist git remotes",
+	"no untrusted remotes":      "allowlist git remotes",
+	"no publish":                "no publishing",
+	"don't publish":             "no publishing",
+	"ask before publishing":     "no publishing",
+	"no releases":               "no publishing",
+	"block npm publish":         "no npm publish",
+	"no crates.io publish":      "no cargo publish",
+	"no twine upload":           "no pypi upload",
+	"no pypi publish":           "no pypi upload",
+	"no gh release":             "no github releases",
+	"no image push":             "no docker push",
+	"protect workflows":         "protect ci workflows",
+	"protect ci":                "protect ci workflows",
+	"protect github actions":    "protect ci workflows",
+	"secure ci workflows":       "protect ci workflows",
+	"safe ci workflows":         "protect ci workflows",
+	"infra safety pack":         "infra safety",
+	"iac safety":                "infra safety",
+	"terraform safety":          "infra safety",
+	"safe infrastructure":       "infra safety",
+	"no public s3 buckets":      "infra safety",
+	"no truncating data files":  "protect data files",
+	"don't truncate data files": "protect data files",
+	"protect fixtures":          "protect data files",
+	"protect test fixtures":     "protect data files",
+	"protect datasets":          "protect data files",
+	"no obfuscation":            "no obfuscated commands",
+	"no encoded commands":       "no obfuscated commands",
+	"block opaque commands":     "no obfuscated commands",
 	"avoid lodash":              "no lodash",
 	"ban lodash":                "no lodash",
 	"native methods":            "no lodash",
@@ -588,23 +1089,77 @@ var Aliases = map[string]string{
 	"no todo comments":          "no todos",
 	"clean todos":               "no todos",
 	"resolve todos":             "no todos",
+
+	// Tests
+	"don't skip tests":  "preserve tests",
+	"no skipped tests":  "preserve tests",
+	"keep tests":        "preserve tests",
+	"test preservation": "preserve tests",
+
+	// Commits
+	"no wip":                       "no wip commits",
+	"no work in progress commits":  "no wip commits",
+	"use conventional commits":     "conventional commits",
+	"conventional commit messages": "conventional commits",
+	"no co-authored-by":            "no ai attribution",
+	"no ai co-authors":             "no ai attribution",
+	"no ai attribution in commits": "no ai attribution",
+
+	// Generated code
+	"don't edit generated files": "generated files",
+	"generated code":             "generated files",
+	"protect generated code":     "generated files",
+	"no edits to generated code": "generated files",
 }
 
 // Find looks up a builtin by name, handling aliases and variations.
 func Find(phrase string) *Builtin {
+	if dir := ProtectedDir(phrase); dir != "" {
+		return Dir(dir)
+	}
+	if n := ChangeBudget(phrase); n > 0 {
+		return Budget(n)
+	}
+	if n, function := StructureLimit(phrase); n > 0 {
+		return Structure(n, function)
+	}
+	if n := ShrinkLimit(phrase); n > 0 {
+		return Shrink(n)
+	}
+	if dir := OnlyDir(phrase); dir != "" {
+		return Only(dir)
+	}
+	name := Resolve(phrase)
+	if name == "" {
+		return nil
+	}
+	b := Registry[name]
+	return &b
+}
+
+// Resolve returns the registry name a phrase refers to, or "" if it
+// doesn't match a builtin.
+func Resolve(phrase string) string {
 	// Normalize
 	normalized := normalize(phrase)
 
 	// Check aliases first
 	if aliased, ok := Aliases[normalized]; ok {
-		if b, ok := Registry[aliased]; ok {
-			return &b
+		if _, ok := Registry[aliased]; ok {
+			return aliased
+		}
+	}
+
+	// Then phrases learned from earlier LLM compilations
+	if learnedName, ok := Learned()[normalized]; ok {
+		if _, ok := Registry[learnedName]; ok {
+			return learnedName
 		}
 	}
 
 	// Direct match
-	if b, ok := Registry[normalized]; ok {
-		return &b
+	if name := registryName(normalized); name != "" {
+		return name
 	}
 
 	// Check with common prefix/suffix variations
@@ -619,39 +1174,87 @@ func Find(phrase string) *Builtin {
 	}
 
 	for _, v := range variations {
-		if b, ok := Registry[v]; ok {
-			return &b
+		if name := registryName(v); name != "" {
+			return name
+		}
+	}
+
+	// Non-English phrases
+	if name := translate(normalized); name != "" {
+		return name
+	}
+
+	// Partial match, longest name first so "no console.log" wins over
+	// "no console"
+	for _, key := range Names() {
+		lower := normalize(key)
+		if contains(normalized, lower) || contains(lower, normalized) {
+			return key
 		}
 	}
 
-	// Partial match
-	for key, b := range Registry {
-		if contains(normalized, key) || contains(key, normalized) {
-			return &b
+	return ""
+}
+
+// registryName returns the registry name matching a normalized phrase,
+// ignoring case ("no innerhtml" finds "no innerHTML"), or "".
+func registryName(normalized string) string {
+	if _, ok := Registry[normalized]; ok {
+		return normalized
+	}
+	// In a fixed order, in case two names differ only in case
+	for _, name := range Names() {
+		if normalize(name) == normalized {
+			return name
 		}
 	}
+	return ""
+}
 
-	return nil
+// Names returns the registry names, longest first, then alphabetically.
+func Names() []string {
+	names := make([]string, 0, len(Registry))
+	for name := range Registry {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
 }
 
-// ToPolicy converts a Builtin to a Policy.
+// ToPolicy converts a Builtin to a Policy that stops action, or the
+// builtin's own Action when it has one.
 func (b *Builtin) ToPolicy(action policy.Action) *policy.Policy {
+	if b.Action != "" {
+		action = b.Action
+	}
 	return &policy.Policy{
-		Action:       action,
-		Include:      b.Include,
-		Exclude:      b.Exclude,
-		Description:  b.Description,
-		CommandRules: b.CommandRules,
-		ContentRules: b.ContentRules,
+		Action:         action,
+		Include:        b.Include,
+		Exclude:        b.Exclude,
+		Description:    b.Description,
+		CommandRules:   b.CommandRules,
+		ContentRules:   b.ContentRules,
+		EnvRules:       b.EnvRules,
+		HeaderRules:    b.HeaderRules,
+		StructureRules: b.StructureRules,
+		ShrinkRules:    b.ShrinkRules,
+		BlockOpaque:    b.BlockOpaque,
+		MaxFiles:       b.MaxFiles,
+		Allowlist:      b.Allowlist,
+		Allow:          b.Allow,
+		Decision:       b.Decision,
 	}
 }
 
 // normalize cleans up input for matching.
 func normalize(s string) string {
-	// Convert to lowercase, remove don't/do not variations
-	result := s
-	// Simple normalization - a real implementation would be more thorough
-	return result
+	// Lowercase and collapse whitespace
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
 }
 
 func stripPrefix(s, prefix string) string {
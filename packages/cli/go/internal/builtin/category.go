@@ -0,0 +1,73 @@
+package builtin
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// Select returns the builtins whose category or one of whose tags is
+// label, sorted, with one name per distinct policy (".env" and "env" are
+// the same builtin).
+func Select(label string) []string {
+	var names []string
+	for name, b := range Registry {
+		if string(b.Category) == label || slices.Contains(b.Tags, label) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var distinct []string
+	for _, name := range names {
+		if !slices.ContainsFunc(distinct, func(d string) bool { return sameBuiltin(d, name) }) {
+			distinct = append(distinct, name)
+		}
+	}
+	return distinct
+}
+
+// Labels returns every category and tag in use, categories first.
+func Labels() []string {
+	var labels []string
+	for _, c := range Categories {
+		labels = append(labels, string(c))
+	}
+	var tags []string
+	for _, b := range Registry {
+		for _, t := range b.Tags {
+			if !slices.Contains(tags, t) && !slices.Contains(labels, t) {
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return append(labels, tags...)
+}
+
+var selectionRe = regexp.MustCompile(`^(?:enable |add )?all (\S+) (?:builtins|policies|rules)$`)
+
+// Selection recognizes phrases like "all security builtins" and returns
+// the category or tag they select.
+func Selection(phrase string) (string, bool) {
+	m := selectionRe.FindStringSubmatch(normalize(phrase))
+	if m == nil || !slices.Contains(Labels(), m[1]) {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Enabled returns which of the builtins selected by label the phrases
+// already turn on.
+func Enabled(label string, phrases []string) []string {
+	var enabled []string
+	for _, name := range Select(label) {
+		for _, p := range phrases {
+			if r := Resolve(p); r != "" && sameBuiltin(r, name) {
+				enabled = append(enabled, name)
+				break
+			}
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,111 @@
+package builtin
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// DirPrefix starts a do-not-touch directory declaration such as
+// "protect dir: infra/".
+const DirPrefix = "protect dir:"
+
+// ProtectedDir returns the directory declared by a "protect dir:" phrase,
+// cleaned and relative to the project root, or "" when the phrase isn't a
+// declaration or names a path outside the project.
+func ProtectedDir(phrase string) string {
+	phrase = strings.TrimSpace(phrase)
+	if len(phrase) < len(DirPrefix) || !strings.EqualFold(phrase[:len(DirPrefix)], DirPrefix) {
+		return ""
+	}
+	return cleanDir(phrase[len(DirPrefix):])
+}
+
+// onlyDirRe matches phrases limiting changes to a directory: "only edit
+// files under src/", "only touch docs".
+var onlyDirRe = regexp.MustCompile(`(?i)^only (?:edit|change|modify|touch|write)(?: files?)?(?: (?:under|in|inside|within))? (\S+)$`)
+
+// OnlyDir returns the directory an "only edit files under <dir>" phrase
+// limits changes to, cleaned and relative to the project root, or ""
+// when the phrase isn't one or names a path outside the project.
+func OnlyDir(phrase string) string {
+	m := onlyDirRe.FindStringSubmatch(strings.Join(strings.Fields(phrase), " "))
+	if m == nil {
+		return ""
+	}
+	return cleanDir(m[1])
+}
+
+// cleanDir cleans a directory named in a phrase, returning "" for one
+// outside the project.
+func cleanDir(dir string) string {
+	dir = strings.Trim(strings.TrimSpace(dir), `"'`)
+	if dir == "" || strings.HasPrefix(dir, "/") {
+		return ""
+	}
+	dir = path.Clean(strings.ReplaceAll(dir, "\\", "/"))
+	if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+		return ""
+	}
+	return dir
+}
+
+// Dir builds the builtin for a protected directory: every file below it,
+// plus shell commands that delete, move or write into it. Command rules
+// match the directory as written relative to the project root ("infra/x",
+// "./infra/x") and as the tail of an absolute path ("/repo/infra/x"),
+// since the check doesn't know where the project lives.
+func Dir(dir string) *Builtin {
+	forms := []string{dir, "./" + dir, "/*/" + dir}
+	reason := dir + "/ is protected"
+	return &Builtin{
+		Include:     []string{dir + "/**"},
+		Description: "Do not touch " + dir + "/",
+		Category:    CategorySecurity,
+		Tags:        []string{"files"},
+		CommandRules: []policy.CommandRule{
+			{Block: argPatterns([]string{"rm", "rmdir"}, forms), Reason: reason + ": don't delete files in it"},
+			{Block: argPatterns([]string{"mv"}, forms), Reason: reason + ": don't move files in or out of it"},
+			{Block: redirectPatterns(forms), Reason: reason + ": don't write files in it"},
+		},
+	}
+}
+
+// Only builds the builtin for an allowlist of one directory: the agent
+// may change files below it and nowhere else.
+func Only(dir string) *Builtin {
+	return &Builtin{
+		Description: "Only change files under " + dir + "/",
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files"},
+		Allowlist:   true,
+		Allow:       []policy.AllowRule{{Paths: []string{dir + "/**"}}},
+	}
+}
+
+// argPatterns matches each command given a path form as any argument:
+// the directory itself or something below it, first or later, last or
+// followed by more arguments.
+func argPatterns(commands, forms []string) []string {
+	var patterns []string
+	for _, cmd := range commands {
+		for _, f := range forms {
+			patterns = append(patterns,
+				cmd+" "+f, cmd+" "+f+" *", cmd+" "+f+"/*",
+				cmd+" * "+f, cmd+" * "+f+" *", cmd+" * "+f+"/*")
+		}
+	}
+	return patterns
+}
+
+// redirectPatterns matches output redirection (>, >>, 2>) and tee into
+// the directory.
+func redirectPatterns(forms []string) []string {
+	var patterns []string
+	for _, f := range forms {
+		patterns = append(patterns, "*>"+f+"/*", "*> "+f+"/*", "tee "+f+"/*", "tee * "+f+"/*")
+	}
+	return patterns
+}
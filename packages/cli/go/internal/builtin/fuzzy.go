@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"sort"
+	"strings"
+)
+
+// Thresholds for treating two phrases as the same policy.
+const (
+	aliasSimilarity  = 0.75 // phrase vs. an alias or builtin name
+	phraseSimilarity = 0.6  // phrase vs. an existing policy
+)
+
+// fillerWords carry intent ("block", "don't") rather than the subject of a
+// policy, so they're ignored when comparing phrases.
+var fillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "any": true, "all": true,
+	"no": true, "not": true, "don't": true, "dont": true, "do": true, "never": true,
+	"block": true, "prevent": true, "avoid": true, "ban": true, "stop": true,
+	"disallow": true, "forbid": true, "deny": true, "protect": true,
+	"use": true, "prefer": true, "please": true, "of": true, "in": true,
+	"to": true, "for": true, "with": true, "allow": true,
+}
+
+// tokens splits a phrase into the stemmed words that identify its subject.
+func tokens(phrase string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(normalize(phrase)) {
+		word = strings.Trim(word, ",;:!?\"'()")
+		if word == "" || fillerWords[word] {
+			continue
+		}
+		set[stem(word)] = true
+	}
+	return set
+}
+
+// stem strips common English suffixes so "pushes", "pushing" and "push"
+// compare equal.
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// Similarity scores how alike two policy phrases are, from 0 to 1, by the
+// overlap of their subject words.
+func Similarity(a, b string) float64 {
+	ta, tb := tokens(a), tokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// resolveFuzzy resolves a phrase to a builtin name, falling back to the
+// closest alias or builtin name when there's no exact match.
+func resolveFuzzy(phrase string) string {
+	if name := Resolve(phrase); name != "" {
+		return name
+	}
+	aliases := make([]string, 0, len(Aliases))
+	for alias := range Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	best, score := "", aliasSimilarity
+	for _, alias := range aliases {
+		if s := Similarity(phrase, alias); s >= score && (best == "" || s > score) {
+			best, score = Aliases[alias], s
+		}
+	}
+	for _, name := range Names() {
+		if s := Similarity(phrase, name); s >= score && (best == "" || s > score) {
+			best, score = name, s
+		}
+	}
+	return best
+}
+
+// sameBuiltin reports whether two builtin names enforce the same policy,
+// such as ".env" and "env".
+func sameBuiltin(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ba, okA := Registry[a]
+	bb, okB := Registry[b]
+	return okA && okB && ba.Description == bb.Description
+}
+
+// Duplicate reports which of existing, if any, already covers phrase, and
+// why. Phrases are duplicates when they resolve to the same builtin, or
+// when their wording is close enough to mean the same thing.
+func Duplicate(phrase string, existing []string) (match, reason string) {
+	name := resolveFuzzy(phrase)
+	for _, e := range existing {
+		if strings.EqualFold(strings.TrimSpace(e), strings.TrimSpace(phrase)) {
+			return e, "same policy"
+		}
+		if name != "" {
+			if other := resolveFuzzy(e); other != "" && sameBuiltin(name, other) {
+				return e, "both use the builtin \"" + name + "\""
+			}
+		}
+	}
+	for _, e := range existing {
+		if Similarity(phrase, e) >= phraseSimilarity {
+			return e, "similar wording"
+		}
+	}
+	return "", ""
+}
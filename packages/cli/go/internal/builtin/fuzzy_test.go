@@ -0,0 +1,72 @@
+package builtin
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"no force push", "no force push", 1},
+		// Filler words and plural/-ing endings are ignored
+		{"no force push", "block force pushes", 1},
+		{"No Force Push", "never force pushing", 1},
+		{"no lodash", "no lodash imports", 0.5},
+		{"protect .env", "no lodash", 0},
+		// Phrases made only of filler have no subject to compare
+		{"don't", "don't", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestResolveFuzzy(t *testing.T) {
+	tests := []struct {
+		phrase string
+		want   string
+	}{
+		{"no force push", "no force push"},
+		// Names with capitals resolve whatever the phrase's case
+		{"no innerHTML", "no innerHTML"},
+		{"NO INNERHTML", "no innerHTML"},
+		{"No InnerHTML please", "no innerHTML"},
+		// Close to an alias
+		{"prevent force pushes", "no force push"},
+		{"ban the lodash", "no lodash"},
+		// Below the alias threshold
+		{"lodash utility helpers", ""},
+		{"keep the api stable", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveFuzzy(tt.phrase); got != tt.want {
+			t.Errorf("resolveFuzzy(%q) = %q, want %q", tt.phrase, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	tests := []struct {
+		phrase     string
+		existing   []string
+		wantMatch  string
+		wantReason string
+	}{
+		{"No InnerHTML", []string{"no innerHTML"}, "no innerHTML", "same policy"},
+		{"no xss", []string{"prefer pnpm", "no innerHTML"}, "no innerHTML", `both use the builtin "no innerHTML"`},
+		{"protect the env file", []string{"protect .env"}, "protect .env", `both use the builtin "env"`},
+		{"keep the api stable", []string{"keep the api stable forever"}, "keep the api stable forever", "similar wording"},
+		// 2 of 5 subject words shared is under the phrase threshold
+		{"keep the api stable", []string{"api must stay stable"}, "", ""},
+		{"no lodash", []string{"protect .env", "prefer pnpm"}, "", ""},
+		{"keep the api stable", nil, "", ""},
+	}
+	for _, tt := range tests {
+		match, reason := Duplicate(tt.phrase, tt.existing)
+		if match != tt.wantMatch || reason != tt.wantReason {
+			t.Errorf("Duplicate(%q) = %q, %q; want %q, %q", tt.phrase, match, reason, tt.wantMatch, tt.wantReason)
+		}
+	}
+}
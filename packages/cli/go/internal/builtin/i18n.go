@@ -0,0 +1,250 @@
+package builtin
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Phrases maps non-English phrases to builtin names, by language code.
+// A phrase matches when it appears anywhere in the input, so entries are
+// the distinctive part of a policy ("archivos de prueba", "テストファイル")
+// rather than full sentences. Phrases the tables miss are compiled by the
+// LLM, which handles any language, and then remembered by Learn.
+var Phrases = map[string]map[string]string{
+	"es": {
+		"archivos de prueba":        "test files",
+		"ficheros de prueba":        "test files",
+		"pruebas":                   "test files",
+		"archivos de entorno":       ".env",
+		"proteger .env":             ".env",
+		"migraciones":               "migrations",
+		"archivos de bloqueo":       "lock files",
+		"preferir pnpm":             "prefer pnpm",
+		"usar pnpm":                 "prefer pnpm",
+		"preferir bun":              "prefer bun",
+		"usar bun":                  "prefer bun",
+		"preferir yarn":             "prefer yarn",
+		"usar yarn":                 "prefer yarn",
+		"sin sudo":                  "no sudo",
+		"no usar sudo":              "no sudo",
+		"push forzado":              "no force push",
+		"forzar push":               "no force push",
+		"reset duro":                "no hard reset",
+		"no usar lodash":            "no lodash",
+		"sin lodash":                "no lodash",
+		"no usar moment":            "no moment",
+		"sin moment":                "no moment",
+		"no usar jquery":            "no jquery",
+		"sin jquery":                "no jquery",
+		"sin console.log":           "no console.log",
+		"sin debugger":              "no debugger",
+		"sin any":                   "no any",
+		"no usar any":               "no any",
+		"sin eval":                  "no eval",
+		"no usar eval":              "no eval",
+		"componentes de clase":      "no class components",
+		"comentarios todo":          "no todos",
+		"tipos estrictos":           "strict types",
+		"archivos de configuración": "config",
+	},
+	"pt": {
+		"arquivos de teste":        "test files",
+		"testes":                   "test files",
+		"arquivos de ambiente":     ".env",
+		"proteger .env":            ".env",
+		"migrações":                "migrations",
+		"arquivos de lock":         "lock files",
+		"preferir pnpm":            "prefer pnpm",
+		"usar pnpm":                "prefer pnpm",
+		"preferir bun":             "prefer bun",
+		"usar bun":                 "prefer bun",
+		"preferir yarn":            "prefer yarn",
+		"sem sudo":                 "no sudo",
+		"não usar sudo":            "no sudo",
+		"push forçado":             "no force push",
+		"sem lodash":               "no lodash",
+		"não usar lodash":          "no lodash",
+		"sem moment":               "no moment",
+		"sem jquery":               "no jquery",
+		"sem console.log":          "no console.log",
+		"sem debugger":             "no debugger",
+		"sem any":                  "no any",
+		"sem eval":                 "no eval",
+		"componentes de classe":    "no class components",
+		"tipos estritos":           "strict types",
+		"arquivos de configuração": "config",
+	},
+	"fr": {
+		"fichiers de test":          "test files",
+		"les tests":                 "test files",
+		"fichiers d'environnement":  ".env",
+		"protéger .env":             ".env",
+		"fichiers de verrouillage":  "lock files",
+		"préférer pnpm":             "prefer pnpm",
+		"utiliser pnpm":             "prefer pnpm",
+		"préférer bun":              "prefer bun",
+		"utiliser bun":              "prefer bun",
+		"préférer yarn":             "prefer yarn",
+		"pas de sudo":               "no sudo",
+		"push forcé":                "no force push",
+		"pas de lodash":             "no lodash",
+		"pas de moment":             "no moment",
+		"pas de jquery":             "no jquery",
+		"pas de console.log":        "no console.log",
+		"pas de debugger":           "no debugger",
+		"pas de any":                "no any",
+		"pas d'any":                 "no any",
+		"pas de eval":               "no eval",
+		"pas d'eval":                "no eval",
+		"composants de classe":      "no class components",
+		"types stricts":             "strict types",
+		"fichiers de configuration": "config",
+	},
+	"de": {
+		"testdateien":           "test files",
+		"tests schützen":        "test files",
+		".env schützen":         ".env",
+		"umgebungsdateien":      ".env",
+		"migrationen":           "migrations",
+		"lockdateien":           "lock files",
+		"pnpm bevorzugen":       "prefer pnpm",
+		"pnpm verwenden":        "prefer pnpm",
+		"bun bevorzugen":        "prefer bun",
+		"bun verwenden":         "prefer bun",
+		"yarn bevorzugen":       "prefer yarn",
+		"kein sudo":             "no sudo",
+		"force-push":            "no force push",
+		"kein force push":       "no force push",
+		"kein lodash":           "no lodash",
+		"kein moment":           "no moment",
+		"kein jquery":           "no jquery",
+		"kein console.log":      "no console.log",
+		"kein debugger":         "no debugger",
+		"kein any":              "no any",
+		"kein eval":             "no eval",
+		"klassenkomponenten":    "no class components",
+		"strikte typen":         "strict types",
+		"konfigurationsdateien": "config",
+	},
+	"ja": {
+		"テストファイル":       "test files",
+		"テスト":           "test files",
+		".envを守る":       ".env",
+		".envを保護":       ".env",
+		"環境変数ファイル":      ".env",
+		"マイグレーション":      "migrations",
+		"ロックファイル":       "lock files",
+		"pnpmを使う":       "prefer pnpm",
+		"pnpmを優先":       "prefer pnpm",
+		"bunを使う":        "prefer bun",
+		"bunを優先":        "prefer bun",
+		"yarnを使う":       "prefer yarn",
+		"sudo禁止":        "no sudo",
+		"sudoを使わない":     "no sudo",
+		"強制プッシュ":        "no force push",
+		"force push禁止":  "no force push",
+		"lodash禁止":      "no lodash",
+		"lodashを使わない":   "no lodash",
+		"moment禁止":      "no moment",
+		"jquery禁止":      "no jquery",
+		"console.log禁止": "no console.log",
+		"debugger禁止":    "no debugger",
+		"any禁止":         "no any",
+		"anyを使わない":      "no any",
+		"eval禁止":        "no eval",
+		"クラスコンポーネント":    "no class components",
+		"厳密な型":          "strict types",
+		"設定ファイル":        "config",
+	},
+	"zh": {
+		"测试文件":          "test files",
+		"保护.env":        ".env",
+		"保护 .env":       ".env",
+		"环境变量文件":        ".env",
+		"迁移文件":          "migrations",
+		"数据库迁移":         "migrations",
+		"锁文件":           "lock files",
+		"使用pnpm":        "prefer pnpm",
+		"使用 pnpm":       "prefer pnpm",
+		"使用bun":         "prefer bun",
+		"使用 bun":        "prefer bun",
+		"使用yarn":        "prefer yarn",
+		"禁止sudo":        "no sudo",
+		"禁止 sudo":       "no sudo",
+		"强制推送":          "no force push",
+		"禁止lodash":      "no lodash",
+		"禁止 lodash":     "no lodash",
+		"禁止moment":      "no moment",
+		"禁止jquery":      "no jquery",
+		"禁止console.log": "no console.log",
+		"禁止debugger":    "no debugger",
+		"禁止any":         "no any",
+		"禁止 any":        "no any",
+		"禁止eval":        "no eval",
+		"类组件":           "no class components",
+		"严格类型":          "strict types",
+		"配置文件":          "config",
+	},
+}
+
+// phrase is one entry of Phrases, flattened for matching.
+type phrase struct {
+	text string
+	name string
+}
+
+var (
+	phrasesOnce sync.Once
+	phraseList  []phrase
+)
+
+// translate resolves a non-English phrase to a builtin name, or "".
+// The longest matching table phrase wins.
+func translate(normalized string) string {
+	phrasesOnce.Do(func() {
+		for _, table := range Phrases {
+			for text, name := range table {
+				phraseList = append(phraseList, phrase{normalize(text), name})
+			}
+		}
+		sort.Slice(phraseList, func(i, j int) bool {
+			if len(phraseList[i].text) != len(phraseList[j].text) {
+				return len(phraseList[i].text) > len(phraseList[j].text)
+			}
+			return phraseList[i].text < phraseList[j].text
+		})
+	})
+	for _, p := range phraseList {
+		if containsWord(normalized, p.text) {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// containsWord reports whether sub appears in s without running into a
+// neighbouring Latin letter or digit, so "sin any" doesn't match inside
+// "basin anything". Scripts without spaces match anywhere.
+func containsWord(s, sub string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], sub)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(sub)
+		if !wordByte(s, start-1) && !wordByte(s, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// wordByte reports whether s[i] is an ASCII letter or digit.
+func wordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,86 @@
+package builtin
+
+import "testing"
+
+func TestResolveTranslated(t *testing.T) {
+	tests := []struct {
+		lang   string
+		phrase string
+		want   string
+	}{
+		{"es", "No borrar los archivos de prueba", "test files"},
+		{"es", "proteger .env siempre", ".env"},
+		{"es", "no usar sudo en el servidor", "no sudo"},
+		{"pt", "Proteger os arquivos de teste", "test files"},
+		{"pt", "não usar lodash no projeto", "no lodash"},
+		{"pt", "sem console.log", "no console.log"},
+		{"fr", "Ne pas supprimer les fichiers de test", "test files"},
+		{"fr", "pas d'eval", "no eval"},
+		{"fr", "préférer pnpm à npm", "prefer pnpm"},
+		{"de", "Testdateien nicht löschen", "test files"},
+		{"de", "kein Force Push auf main", "no force push"},
+		{"de", ".env schützen", ".env"},
+		{"ja", "テストファイルを削除しない", "test files"},
+		{"ja", ".envを守る", ".env"},
+		{"ja", "強制プッシュ禁止", "no force push"},
+		{"zh", "不要删除测试文件", "test files"},
+		{"zh", "禁止 sudo", "no sudo"},
+		{"zh", "使用pnpm而不是npm", "prefer pnpm"},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.phrase); got != tt.want {
+			t.Errorf("%s %q: Resolve = %q, want %q", tt.lang, tt.phrase, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateLongestPhraseWins(t *testing.T) {
+	// "テスト" alone also means test files, but "設定ファイル" is longer
+	// than either and names another builtin
+	if got := translate(normalize("テスト用の設定ファイル")); got != "config" {
+		t.Errorf("translate = %q, want config", got)
+	}
+}
+
+func TestTranslateNearMisses(t *testing.T) {
+	// Table phrases inside longer Latin words don't match
+	for _, phrase := range []string{
+		"basin anything",      // "sin any"
+		"contestes",           // "testes"
+		"comprobapruebas",     // "pruebas"
+		"kein anymore",        // "kein any"
+		"pas de evaluation",   // "pas de eval"
+		"reusar pnpmx",        // "usar pnpm"
+		"force-pushed",        // "force-push"
+		"testdateienordner",   // "testdateien"
+		"the pruebas2 folder", // "pruebas"
+	} {
+		if got := translate(normalize(phrase)); got != "" {
+			t.Errorf("translate(%q) = %q, want no match", phrase, got)
+		}
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		s, sub string
+		want   bool
+	}{
+		{"sin any", "sin any", true},
+		{"usa sin any aquí", "sin any", true},
+		{"basin anything", "sin any", false},
+		// A later occurrence on word boundaries still counts
+		{"basin any, sin any", "sin any", true},
+		{"pruebas.", "pruebas", true},
+		{"(pruebas)", "pruebas", true},
+		{"pruebas1", "pruebas", false},
+		// Scripts without spaces match anywhere
+		{"不要删除测试文件夹", "测试文件", true},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := containsWord(tt.s, tt.sub); got != tt.want {
+			t.Errorf("containsWord(%q, %q) = %v, want %v", tt.s, tt.sub, got, tt.want)
+		}
+	}
+}
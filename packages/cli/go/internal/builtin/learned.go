@@ -0,0 +1,107 @@
+package builtin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// learned holds phrase → builtin mappings remembered from earlier LLM
+// compilations, read from learnedPath on first use and again after Learn
+// writes the store.
+var (
+	learnedMu   sync.Mutex
+	learned     map[string]string
+	learnedPath string
+)
+
+// LearnedPath returns the per-user file learned aliases are stored in.
+func LearnedPath() string {
+	return filepath.Join(xdg.ConfigDir(), "aliases.json")
+}
+
+// Learned returns the learned aliases. A missing or unreadable store
+// yields none.
+func Learned() map[string]string {
+	learnedMu.Lock()
+	defer learnedMu.Unlock()
+	path := LearnedPath()
+	if learned == nil || learnedPath != path {
+		learned, learnedPath = map[string]string{}, path
+		if data, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(data, &learned)
+		}
+		if learned == nil {
+			learned = map[string]string{}
+		}
+	}
+	return learned
+}
+
+// Learn records that phrase means the builtin name, so later lookups
+// resolve it without the LLM.
+func Learn(phrase, name string) error {
+	phrase = normalize(phrase)
+	if _, ok := Registry[name]; !ok || phrase == "" || Resolve(phrase) == name {
+		return nil
+	}
+	aliases := map[string]string{phrase: name}
+	for p, n := range Learned() {
+		if p != phrase {
+			aliases[p] = n
+		}
+	}
+
+	path := LearnedPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, append(data, '\n'), 0644)
+
+	// Read the store again on next use, whether or not the write landed
+	learnedMu.Lock()
+	learned = nil
+	learnedMu.Unlock()
+	return err
+}
+
+// Equivalent returns the builtin that enforces exactly the same file,
+// command and content rules as p, or "" if none does.
+func Equivalent(p *policy.Policy) string {
+	include, commands, content := ruleSets(p.Include, p.CommandRules, p.ContentRules)
+	if len(include)+len(commands)+len(content) == 0 {
+		return ""
+	}
+	for _, name := range Names() {
+		b := Registry[name]
+		bi, bc, bp := ruleSets(b.Include, b.CommandRules, b.ContentRules)
+		if slices.Equal(include, bi) && slices.Equal(commands, bc) && slices.Equal(content, bp) {
+			return name
+		}
+	}
+	return ""
+}
+
+// ruleSets flattens a policy's patterns into sorted lists for comparison.
+func ruleSets(include []string, commands []policy.CommandRule, content []policy.ContentRule) (files, blocks, patterns []string) {
+	files = append(files, include...)
+	for _, r := range commands {
+		blocks = append(blocks, r.Block...)
+	}
+	for _, r := range content {
+		patterns = append(patterns, r.Pattern)
+	}
+	slices.Sort(files)
+	slices.Sort(blocks)
+	slices.Sort(patterns)
+	return files, blocks, patterns
+}
@@ -0,0 +1,111 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestLearnRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	const phrase = "Guard The Dotfile With Our Keys"
+	if name := Resolve(phrase); name != "" {
+		t.Fatalf("%q already resolves to %q", phrase, name)
+	}
+
+	if err := Learn(phrase, ".env"); err != nil {
+		t.Fatal(err)
+	}
+	// The same process sees the new alias straight away
+	if name := Resolve(phrase); name != ".env" {
+		t.Errorf("after Learn: %q resolves to %q, want .env", phrase, name)
+	}
+
+	data, err := os.ReadFile(LearnedPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"guard the dotfile with our keys": ".env"`) {
+		t.Errorf("store = %s, want the normalized phrase", data)
+	}
+
+	// Another process reads it back from the store
+	learnedMu.Lock()
+	learned = nil
+	learnedMu.Unlock()
+	if name := Resolve(phrase); name != ".env" {
+		t.Errorf("after reload: %q resolves to %q, want .env", phrase, name)
+	}
+
+	// A second alias keeps the first
+	if err := Learn("skip the old date library", "no moment"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Learned(); got["guard the dotfile with our keys"] != ".env" || got["skip the old date library"] != "no moment" {
+		t.Errorf("Learned() = %v, want both aliases", got)
+	}
+}
+
+func TestLearnSkips(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	// Unknown builtins, empty phrases and phrases that already resolve
+	// aren't stored
+	for _, tt := range []struct{ phrase, name string }{
+		{"guard the dotfile", "no such builtin"},
+		{"   ", ".env"},
+		{"protect .env", ".env"},
+	} {
+		if err := Learn(tt.phrase, tt.name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(LearnedPath()); !os.IsNotExist(err) {
+		t.Errorf("store written: %v", err)
+	}
+	if got := Learned(); len(got) != 0 {
+		t.Errorf("Learned() = %v, want none", got)
+	}
+}
+
+func TestLearnedCorruptStore(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(LearnedPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(LearnedPath(), []byte("null"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Learned(); got == nil || len(got) != 0 {
+		t.Errorf("Learned() = %#v, want an empty map", got)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	lodash := Registry["no lodash"]
+	block := lodash.CommandRules[0].Block
+	reversed := []policy.CommandRule{{Reason: "other wording"}}
+	for i := len(block) - 1; i >= 0; i-- {
+		reversed[0].Block = append(reversed[0].Block, block[i])
+	}
+
+	tests := []struct {
+		name   string
+		policy *policy.Policy
+		want   string
+	}{
+		{"same rules", lodash.ToPolicy(policy.ActionModify), "no lodash"},
+		{"patterns in another order", &policy.Policy{Include: lodash.Include, CommandRules: reversed, ContentRules: lodash.ContentRules}, "no lodash"},
+		{"same files, other description", &policy.Policy{Include: Registry[".env"].Include, Description: "secrets"}, ".env"},
+		{"a subset of the rules", &policy.Policy{Include: lodash.Include, CommandRules: lodash.CommandRules}, ""},
+		{"no rules", &policy.Policy{Description: "anything"}, ""},
+		{"different patterns", &policy.Policy{Include: []string{"secrets/**"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := Equivalent(tt.policy); got != tt.want {
+			t.Errorf("%s: Equivalent = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
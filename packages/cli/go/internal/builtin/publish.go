@@ -0,0 +1,53 @@
+package builtin
+
+import "github.com/VulnZap/veto/internal/policy"
+
+// Publishing can't be taken back: a published version stays taken even
+// once it is yanked, and pushed images and releases are pulled at once.
+// The publishing builtins ask for approval rather than deny, so a human
+// can still say yes.
+
+var npmPublish = policy.CommandRule{
+	Block: []string{
+		"npm publish*", "pnpm publish*", "yarn publish*", "yarn npm publish*",
+		"bun publish*", "npx lerna publish*", "npx changeset publish*",
+	},
+	Reason:  "Publishing to the npm registry can't be undone",
+	Suggest: "npm publish --dry-run, or ask the user to publish",
+}
+
+var cargoPublish = policy.CommandRule{
+	Block:   []string{"cargo publish*"},
+	Reason:  "Versions published to crates.io can't be deleted",
+	Suggest: "cargo publish --dry-run, or ask the user to publish",
+}
+
+var pypiUpload = policy.CommandRule{
+	Block: []string{
+		"twine upload*", "python -m twine upload*", "python3 -m twine upload*",
+		"uv publish*", "poetry publish*", "flit publish*", "hatch publish*",
+	},
+	Reason:  "Files uploaded to PyPI can't be replaced, even after deleting them",
+	Suggest: "upload to TestPyPI, or ask the user to publish",
+}
+
+var githubRelease = policy.CommandRule{
+	Block:   []string{"gh release create*", "gh release upload*"},
+	Reason:  "Releases notify watchers and are downloaded as soon as they're created",
+	Suggest: "gh release create --draft, or ask the user to release",
+}
+
+// dockerPush lets pushes to local registries through; add a registry to
+// the policy's allowHosts to allow pushing there.
+var dockerPush = policy.CommandRule{
+	Block: []string{
+		"docker push *", "docker image push *", "podman push *",
+		"docker buildx build * --push*", "docker buildx bake * --push*",
+	},
+	AllowHosts: []string{"localhost", "127.0.0.1"},
+	Reason:     "Pushed images are pulled by whoever deploys the tag",
+	Suggest:    "add the registry to the policy's allowHosts in .veto, or ask the user to push",
+}
+
+// publishRules are the rules "no publishing" combines.
+var publishRules = []policy.CommandRule{npmPublish, cargoPublish, pypiUpload, githubRelease, dockerPush}
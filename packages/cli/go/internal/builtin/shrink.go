@@ -0,0 +1,64 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// defaultShrink is how much of a data file "protect data files" lets a
+// write remove, in percent.
+const defaultShrink = 50
+
+// dataFiles are the file types "protect data files" keeps from being
+// truncated.
+var dataFiles = []string{
+	"*.csv", "*.tsv", "*.parquet", "*.jsonl", "*.ndjson", "*.avro", "*.orc",
+	"*.arrow", "*.feather", "*.xlsx", "*.sqlite", "*.db",
+}
+
+// fixtureDirs hold test data, kept whatever its file type.
+var fixtureDirs = []string{
+	"fixtures/**", "**/fixtures/**", "testdata/**", "**/testdata/**",
+}
+
+// shrinkRe matches shrink limit declarations: "don't shrink data files
+// by more than 20%", "protect data files from shrinking over 20%".
+var shrinkRe = regexp.MustCompile(`^(?:(?:don't|do not|never) (?:shrink|truncate) data files|protect data files from (?:shrinking|truncation))(?: by)? (?:more than|over) (\d+) ?%$`)
+
+// ShrinkLimit returns the share of a data file, in percent, a shrink
+// limit phrase lets a write remove, or 0 when the phrase isn't one.
+func ShrinkLimit(phrase string) int {
+	m := shrinkRe.FindStringSubmatch(normalize(phrase))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// Shrink builds the builtin for a shrink limit: a write may not remove
+// more than percent of a data file or a file under a fixtures
+// directory, going by its size before and after.
+func Shrink(percent int) *Builtin {
+	rule := func(fileTypes, include []string) policy.ShrinkRule {
+		return policy.ShrinkRule{
+			FileTypes:  fileTypes,
+			Include:    include,
+			MaxPercent: percent,
+			Reason:     "agents truncate data files they rewrite from a sample",
+			Suggest:    "append or edit rows in place instead of rewriting the file, or regenerate it with the script that built it",
+		}
+	}
+	return &Builtin{
+		Description: fmt.Sprintf("Keep writes from removing more than %d%% of a data file", percent),
+		Category:    CategoryWorkflow,
+		Tags:        []string{"files", "data"},
+		ShrinkRules: []policy.ShrinkRule{
+			rule(dataFiles, nil),
+			rule(nil, fixtureDirs),
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// structureRe matches length limit declarations: "max 500 lines per
+// file", "limit functions to 80 lines", "keep files under 400 lines".
+var structureRe = regexp.MustCompile(`^(?:(?:max|at most)\s+(\d+)\s+lines?\s+(?:per|in an?|in each)\s+(file|function)|(?:limit|keep)\s+(files|functions)\s+(?:to|under)\s+(\d+)\s+lines?)$`)
+
+// StructureLimit returns the line limit a length limit phrase sets and
+// whether it is for files or functions, or 0 when the phrase isn't one.
+func StructureLimit(phrase string) (lines int, function bool) {
+	m := structureRe.FindStringSubmatch(normalize(phrase))
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1] + m[4])
+	return n, m[2] == "function" || m[3] == "functions"
+}
+
+// Structure builds the builtin for a length limit: content an agent
+// writes may not be longer than max lines, or have a function that is.
+func Structure(max int, function bool) *Builtin {
+	rule := policy.StructureRule{
+		MaxLines: max,
+		Reason:   "agents should split long files",
+		Suggest:  "split the code into smaller files by responsibility",
+	}
+	description := fmt.Sprintf("Keep files to %d lines", max)
+	if function {
+		rule = policy.StructureRule{
+			MaxFunctionLines: max,
+			Reason:           "agents should split long functions",
+			Suggest:          "extract helpers so each function does one thing",
+		}
+		description = fmt.Sprintf("Keep functions to %d lines", max)
+	}
+	return &Builtin{
+		Description:    description,
+		Category:       CategoryStyle,
+		Tags:           []string{"structure", "refactoring"},
+		StructureRules: []policy.StructureRule{rule},
+	}
+}
@@ -0,0 +1,174 @@
+// Package bundle reads and writes offline bundles: tar files carrying
+// everything veto needs on an air-gapped machine. A bundle holds the veto
+// binary (builtins are compiled into it), policy packs, and a project's
+// .veto with a precompiled .veto.lock, so nothing has to be fetched or
+// compiled on arrival. manifest.json comes first and records every
+// file's SHA-256, and reading a bundle fails unless the files match it
+// exactly.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Format is the current bundle format.
+const Format = 1
+
+// ManifestName is the bundle entry listing its contents.
+const ManifestName = "manifest.json"
+
+// Well-known entries and directories in a bundle.
+const (
+	// BinaryDir holds the veto executable
+	BinaryDir = "bin/"
+	// ProjectDir holds .veto and .veto.lock
+	ProjectDir = "project/"
+	// PacksDir holds policy packs
+	PacksDir = "packs/"
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Format int `json:"format"`
+	// Version of veto that made the bundle
+	Version string `json:"version"`
+	// Platform the bundled binary is built for, as a release asset name
+	Platform  string    `json:"platform,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []File    `json:"files"`
+}
+
+// File is one entry in a bundle.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Mode is the file's permission bits
+	Mode int64 `json:"mode"`
+}
+
+// Entry is a file to put in a bundle.
+type Entry struct {
+	Name string
+	Data []byte
+	Mode int64
+}
+
+// Write writes a bundle of entries to w, filling in m's files.
+func Write(w io.Writer, m Manifest, entries []Entry) error {
+	m.Format = Format
+	m.Files = nil
+	for _, e := range entries {
+		if err := checkName(e.Name); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, File{Name: e.Name, Size: int64(len(e.Data)), SHA256: digest(e.Data), Mode: e.Mode})
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	put := func(name string, data []byte, mode int64) error {
+		hdr := &tar.Header{Name: name, Size: int64(len(data)), Mode: mode, ModTime: m.CreatedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := put(ManifestName, append(manifest, '\n'), 0644); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := put(e.Name, e.Data, e.Mode); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// Read reads a bundle from r and verifies every file against its
+// manifest, returning the manifest and the files' contents by name.
+func Read(r io.Reader) (*Manifest, map[string][]byte, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a veto bundle: %w", err)
+	}
+	if hdr.Name != ManifestName {
+		return nil, nil, fmt.Errorf("not a veto bundle: starts with %s, not %s", hdr.Name, ManifestName)
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", ManifestName, err)
+	}
+	if m.Format != Format {
+		return nil, nil, fmt.Errorf("unsupported bundle format %d (this veto reads %d)", m.Format, Format)
+	}
+	want := make(map[string]File, len(m.Files))
+	for _, f := range m.Files {
+		if err := checkName(f.Name); err != nil {
+			return nil, nil, err
+		}
+		want[f.Name] = f
+	}
+
+	files := make(map[string][]byte, len(m.Files))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		f, ok := want[hdr.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s isn't listed in the manifest", hdr.Name)
+		}
+		if _, dup := files[hdr.Name]; dup {
+			return nil, nil, fmt.Errorf("%s appears twice", hdr.Name)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, io.LimitReader(tr, f.Size+1)); err != nil {
+			return nil, nil, err
+		}
+		if int64(buf.Len()) != f.Size || digest(buf.Bytes()) != f.SHA256 {
+			return nil, nil, fmt.Errorf("%s doesn't match its checksum; the bundle is corrupt or was modified", hdr.Name)
+		}
+		files[hdr.Name] = buf.Bytes()
+	}
+	for name := range want {
+		if _, ok := files[name]; !ok {
+			return nil, nil, fmt.Errorf("%s is missing from the bundle", name)
+		}
+	}
+	return &m, files, nil
+}
+
+// checkName rejects entry names that could escape the directory a bundle
+// is unpacked into.
+func checkName(name string) error {
+	if name == "" || name == ManifestName || path.IsAbs(name) || strings.Contains(name, `\`) ||
+		path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid bundle entry name %q", name)
+	}
+	return nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
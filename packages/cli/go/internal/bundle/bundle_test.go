@@ -0,0 +1,65 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sample(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := Write(&buf, Manifest{Version: "1.0.0", CreatedAt: time.Unix(0, 0)}, []Entry{
+		{Name: BinaryDir + "veto", Data: []byte("\x7fELF..."), Mode: 0755},
+		{Name: ProjectDir + ".veto", Data: []byte("protect .env\n"), Mode: 0644},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	m, files, err := Read(bytes.NewReader(sample(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != "1.0.0" || len(m.Files) != 2 {
+		t.Errorf("manifest = %+v", m)
+	}
+	if string(files[ProjectDir+".veto"]) != "protect .env\n" {
+		t.Errorf("files = %q", files)
+	}
+}
+
+func TestReadRejectsTampering(t *testing.T) {
+	data := sample(t)
+	i := bytes.Index(data, []byte("protect .env"))
+	tampered := bytes.Clone(data)
+	copy(tampered[i:], "protect .xyz")
+	if _, _, err := Read(bytes.NewReader(tampered)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Read(tampered) = %v, want a checksum error", err)
+	}
+
+	// An entry the manifest doesn't list
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1024]) // drop the end-of-archive blocks
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "extra", Size: 1, Mode: 0644})
+	tw.Write([]byte("x"))
+	tw.Close()
+	if _, _, err := Read(&buf); err == nil || !strings.Contains(err.Error(), "isn't listed") {
+		t.Errorf("Read(extra entry) = %v, want an unlisted entry error", err)
+	}
+}
+
+func TestWriteRejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"../x", "/etc/passwd", "a/../../b", `bin\veto`, ManifestName} {
+		err := Write(&bytes.Buffer{}, Manifest{}, []Entry{{Name: name}})
+		if err == nil {
+			t.Errorf("Write(%q) = nil, want an error", name)
+		}
+	}
+}
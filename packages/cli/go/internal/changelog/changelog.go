@@ -0,0 +1,91 @@
+// Package changelog parses the changelog embedded in the veto binary.
+package changelog
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/update"
+)
+
+//go:embed CHANGELOG.md
+var source string
+
+// Unreleased is the heading used for changes not yet in a release.
+const Unreleased = "Unreleased"
+
+// Release is one version's entry.
+type Release struct {
+	Version  string
+	Builtins []string
+	Agents   []string
+	Breaking []string
+	Changes  []string
+}
+
+// Empty reports whether the entry lists nothing.
+func (r Release) Empty() bool {
+	return len(r.Builtins)+len(r.Agents)+len(r.Breaking)+len(r.Changes) == 0
+}
+
+// All returns every entry, newest first, including Unreleased.
+func All() []Release {
+	return parse(source)
+}
+
+// Find returns the entry for a version, or nil.
+func Find(version string) *Release {
+	version = strings.TrimPrefix(version, "v")
+	for _, r := range All() {
+		if r.Version == version {
+			return &r
+		}
+	}
+	return nil
+}
+
+// Between returns released entries newer than from and no newer than to,
+// newest first.
+func Between(from, to string) []Release {
+	var out []Release
+	for _, r := range All() {
+		if r.Version == Unreleased {
+			continue
+		}
+		if update.Newer(r.Version, from) && !update.Newer(r.Version, to) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func parse(text string) []Release {
+	var releases []Release
+	var section *[]string
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "## "):
+			releases = append(releases, Release{Version: strings.TrimPrefix(strings.TrimPrefix(line, "## "), "v")})
+			section = nil
+		case strings.HasPrefix(line, "### ") && len(releases) > 0:
+			r := &releases[len(releases)-1]
+			switch strings.ToLower(strings.TrimPrefix(line, "### ")) {
+			case "builtins":
+				section = &r.Builtins
+			case "agents":
+				section = &r.Agents
+			case "breaking":
+				section = &r.Breaking
+			default:
+				section = &r.Changes
+			}
+		case strings.HasPrefix(line, "- ") && section != nil:
+			*section = append(*section, strings.TrimPrefix(line, "- "))
+		}
+	}
+	return releases
+}
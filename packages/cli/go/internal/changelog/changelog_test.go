@@ -0,0 +1,125 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+)
+
+const fixture = `# Changelog
+
+## Unreleased
+
+- something coming
+
+## 3.10.0
+
+### Builtins
+
+- protect lock files
+
+### Agents
+
+- aider
+
+## v3.9.1
+
+### Breaking
+
+- drop node 16
+
+### Fixes
+
+- fix a crash
+
+## 3.9.0
+
+- not in a section
+
+## 3.1.0
+
+### Changes
+
+- first
+`
+
+// withSource parses text instead of the embedded changelog for the rest
+// of the test.
+func withSource(t *testing.T, text string) {
+	t.Helper()
+	saved := source
+	source = text
+	t.Cleanup(func() { source = saved })
+}
+
+func versions(releases []Release) []string {
+	var out []string
+	for _, r := range releases {
+		out = append(out, r.Version)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	releases := parse(fixture)
+	want := []Release{
+		{Version: Unreleased},
+		{Version: "3.10.0", Builtins: []string{"protect lock files"}, Agents: []string{"aider"}},
+		{Version: "3.9.1", Breaking: []string{"drop node 16"}, Changes: []string{"fix a crash"}},
+		{Version: "3.9.0"},
+		{Version: "3.1.0", Changes: []string{"first"}},
+	}
+	if !reflect.DeepEqual(releases, want) {
+		t.Errorf("parse() = %+v, want %+v", releases, want)
+	}
+	if !releases[0].Empty() || releases[1].Empty() {
+		t.Errorf("Empty() wrong for %+v", releases[:2])
+	}
+}
+
+func TestBetween(t *testing.T) {
+	withSource(t, fixture)
+	for _, tt := range []struct {
+		from, to string
+		want     []string
+	}{
+		// 3.10.0 is newer than 3.9.x, though it sorts before it as text
+		{"3.9.1", "3.10.0", []string{"3.10.0"}},
+		{"3.1.0", "3.10.0", []string{"3.10.0", "3.9.1", "3.9.0"}},
+		{"3.0.0", "3.9.1", []string{"3.9.1", "3.9.0", "3.1.0"}},
+		{"3.9.0", "3.9.0", nil},
+		{"3.10.0", "3.9.0", nil},
+		// Versions without an entry of their own still bound the range
+		{"3.2.0", "3.9.5", []string{"3.9.1", "3.9.0"}},
+		{"3.10.0", "4.0.0", nil},
+	} {
+		if got := versions(Between(tt.from, tt.to)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Between(%s, %s) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	withSource(t, fixture)
+	for _, v := range []string{"3.9.1", "v3.9.1"} {
+		if r := Find(v); r == nil || r.Version != "3.9.1" || len(r.Breaking) != 1 {
+			t.Errorf("Find(%s) = %+v, want the 3.9.1 entry", v, r)
+		}
+	}
+	for _, v := range []string{"3.9.2", "3.9", ""} {
+		if r := Find(v); r != nil {
+			t.Errorf("Find(%q) = %+v, want nil", v, r)
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	releases := All()
+	if len(releases) == 0 || releases[0].Version != Unreleased {
+		t.Fatalf("embedded changelog starts %q, want %s first", versions(releases), Unreleased)
+	}
+	for _, r := range releases[1:] {
+		if r.Version == Unreleased {
+			t.Errorf("%s appears twice", Unreleased)
+		}
+	}
+}
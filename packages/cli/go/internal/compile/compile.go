@@ -0,0 +1,278 @@
+// Package compile turns .veto phrases into policies without the
+// TypeScript engine: a builtin when one matches, else the policy the
+// phrase last compiled to on this machine, else an LLM (see package llm).
+package compile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/llm"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Where a compiled policy came from, besides a provider's name.
+const (
+	SourceBuiltin = "builtin"
+	SourceCache   = "cache"
+)
+
+// maxRetries bounds the retries of a rate limited or overloaded request.
+const maxRetries = 4
+
+// Result is a compiled phrase.
+type Result struct {
+	Policy *policy.Policy
+	// Source is SourceBuiltin, SourceCache or the provider's name
+	Source string
+}
+
+// Compiler compiles phrases.
+type Compiler struct {
+	// Provider is the LLM to ask, nil when none is selected
+	Provider llm.Provider
+	// CachePath is where compiled phrases are kept ("" disables caching)
+	CachePath string
+	// Backoff is the wait before the first retry, doubling each time
+	Backoff time.Duration
+}
+
+// New returns a compiler using the default cache and the provider cfg
+// (which may be nil) or the environment selects; see llm.Select.
+func New(cfg *config.Compiler) (*Compiler, error) {
+	var s llm.Settings
+	if cfg != nil {
+		s = llm.Settings{Provider: cfg.Provider, Model: cfg.Model, URL: cfg.URL}
+	}
+	p, err := llm.Select(s)
+	if err != nil {
+		return nil, err
+	}
+	return &Compiler{Provider: p, CachePath: CachePath(), Backoff: 4 * time.Second}, nil
+}
+
+// CachePath returns the per-user cache of compiled phrases.
+func CachePath() string {
+	return filepath.Join(xdg.CacheDir(), "compiled.json")
+}
+
+// Compile compiles a phrase.
+func (c *Compiler) Compile(phrase string) (*Result, error) {
+	normalized := normalize(phrase)
+	action, target := Action(normalized)
+	for _, s := range []string{target, normalized} {
+		if b := builtin.Find(s); b != nil {
+			return &Result{Policy: b.ToPolicy(action), Source: SourceBuiltin}, nil
+		}
+	}
+	if p := readCache(c.CachePath, normalized); p != nil {
+		return &Result{Policy: p, Source: SourceCache}, nil
+	}
+
+	if c.Provider == nil {
+		vars := llm.KeyVars()
+		return nil, fmt.Errorf("%q isn't a builtin and no LLM is set up to compile it; set %s or %s, or compiler: ollama in .veto for a local model, or see `veto builtins`",
+			phrase, strings.Join(vars[:len(vars)-1], ", "), vars[len(vars)-1])
+	}
+	p, err := c.ask(phrase, action)
+	if err != nil {
+		return nil, err
+	}
+	if c.CachePath != "" {
+		writeCache(c.CachePath, normalized, p)
+	}
+	return &Result{Policy: p, Source: c.Provider.Name()}, nil
+}
+
+// ask has the provider compile a phrase, retrying while it is rate
+// limited or overloaded.
+func (c *Compiler) ask(phrase string, action policy.Action) (*policy.Policy, error) {
+	prompt := fmt.Sprintf("The user has indicated the action should be: %q\n\nRestriction: %q", action, phrase)
+	for attempt := 0; ; attempt++ {
+		text, err := c.Provider.Complete(systemPrompt, prompt)
+		var apiErr *llm.APIError
+		if errors.As(err, &apiErr) && apiErr.Temporary() && attempt < maxRetries {
+			time.Sleep(c.Backoff << attempt)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Parse(text, action)
+	}
+}
+
+// Parse reads a model's reply as a policy. Only the rules are taken from
+// it; decisions, severities and the like stay with .veto. The policy
+// must be valid and enforceable by both engines.
+func Parse(text string, action policy.Action) (*policy.Policy, error) {
+	text = strings.TrimSpace(text)
+	if _, rest, ok := strings.Cut(text, "```"); ok {
+		rest = strings.TrimPrefix(rest, "json")
+		text, _, _ = strings.Cut(rest, "```")
+	}
+
+	var reply policy.Policy
+	if err := json.Unmarshal([]byte(text), &reply); err != nil {
+		return nil, fmt.Errorf("model reply isn't a policy: %w", err)
+	}
+	p := &policy.Policy{
+		Action:       reply.Action,
+		Include:      reply.Include,
+		Exclude:      reply.Exclude,
+		Description:  reply.Description,
+		CommandRules: reply.CommandRules,
+		ContentRules: reply.ContentRules,
+		ASTRules:     reply.ASTRules,
+	}
+	if p.Action == "" {
+		p.Action = action
+	}
+	if err := valid(p); err != nil {
+		return nil, fmt.Errorf("model compiled an unusable policy: %w", err)
+	}
+	return p, nil
+}
+
+// valid checks a policy and every pattern in it.
+func valid(p *policy.Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if errs := matcher.Validate(p); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	_, err := matcher.New(p)
+	return err
+}
+
+// Cached returns the policy a phrase last compiled to on this machine,
+// or nil if it hasn't been compiled.
+func Cached(phrase string) *policy.Policy {
+	return readCache(CachePath(), normalize(phrase))
+}
+
+func normalize(phrase string) string {
+	return strings.ToLower(strings.TrimSpace(phrase))
+}
+
+// cacheKey matches the TypeScript compiler's cache keys.
+func cacheKey(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func readCacheFile(path string) map[string]*policy.Policy {
+	cache := make(map[string]*policy.Policy)
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// readCache returns the cached policy for a phrase. Entries that no
+// longer validate, say after an edit by hand, are ignored.
+func readCache(path, normalized string) *policy.Policy {
+	if path == "" {
+		return nil
+	}
+	p := readCacheFile(path)[cacheKey(normalized)]
+	if p == nil || valid(p) != nil {
+		return nil
+	}
+	return p
+}
+
+// writeCache saves a compiled phrase. Failures are ignored: the cache
+// only saves another LLM call.
+func writeCache(path, normalized string, p *policy.Policy) {
+	cache := readCacheFile(path)
+	cache[cacheKey(normalized)] = p
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+		os.WriteFile(path, append(data, '\n'), 0644)
+	}
+}
+
+var commandPreference = []*regexp.Regexp{
+	regexp.MustCompile(`\b(prefer|use)\s+(pnpm|bun|yarn|npm)\b`),
+	regexp.MustCompile(`\b(pnpm|bun|yarn)\s+(over|not|instead)`),
+	regexp.MustCompile(`\bno\s+(sudo|force.?push|hard.?reset)\b`),
+	regexp.MustCompile(`\b(vitest|jest|pytest)\s+(over|not|instead)`),
+	regexp.MustCompile(`\buse\s+(vitest|pytest|docker.?compose)\b`),
+	regexp.MustCompile(`\bno\s+curl\b`),
+}
+
+var actionPrefixes = []struct {
+	re     *regexp.Regexp
+	action policy.Action
+}{
+	{regexp.MustCompile(`^(don'?t\s+)?(delete|remove|rm)\s+`), policy.ActionDelete},
+	{regexp.MustCompile(`^(don'?t\s+)?(modify|edit|change|update|write|touch)\s+`), policy.ActionModify},
+	{regexp.MustCompile(`^(don'?t\s+)?(run|execute|running|executing)\s+`), policy.ActionExecute},
+	{regexp.MustCompile(`^(don'?t\s+)?(read|view|access)\s+`), policy.ActionRead},
+	{regexp.MustCompile(`^(don'?t\s+)?(create)\s+(new\s+)?`), policy.ActionCreate},
+	{regexp.MustCompile(`^(don'?t\s+)?(rename)\s+`), policy.ActionRename},
+	{regexp.MustCompile(`^(don'?t\s+)?(move|mv)\s+`), policy.ActionMove},
+	{regexp.MustCompile(`^(don'?t\s+)?(copy|cp|duplicate)\s+`), policy.ActionCopy},
+	{regexp.MustCompile(`^(protect|preserve|keep|save)\s+`), policy.ActionModify},
+	// Tool preferences default to execute
+	{regexp.MustCompile(`^(prefer|use)\s+`), policy.ActionExecute},
+	{regexp.MustCompile(`^no\s+(running|executing)\s+`), policy.ActionExecute},
+	// "no X" is about files unless X is a command preference
+	{regexp.MustCompile(`^no\s+`), ""},
+}
+
+var (
+	leadingFiller  = regexp.MustCompile(`^(any|all|the)\s+`)
+	trailingFiller = regexp.MustCompile(`\s+(files?|directories?|folders?)$`)
+)
+
+// Action infers what a normalized phrase restricts and what it's about,
+// the way the TypeScript compiler does: "don't delete the tests" is
+// delete on "tests", "prefer pnpm" is execute. Unlike it, Action also
+// knows create, rename, move and copy.
+func Action(normalized string) (policy.Action, string) {
+	isCommand := false
+	for _, re := range commandPreference {
+		if re.MatchString(normalized) {
+			isCommand = true
+			break
+		}
+	}
+	action := policy.ActionModify
+	if isCommand {
+		action = policy.ActionExecute
+	}
+
+	target := normalized
+	for _, prefix := range actionPrefixes {
+		if loc := prefix.re.FindStringIndex(normalized); loc != nil {
+			if prefix.action != "" {
+				action = prefix.action
+			}
+			target = strings.TrimSpace(normalized[loc[1]:])
+			break
+		}
+	}
+	if !isCommand {
+		target = leadingFiller.ReplaceAllString(target, "")
+		target = strings.TrimSpace(trailingFiller.ReplaceAllString(target, ""))
+	}
+	return action, target
+}
@@ -0,0 +1,142 @@
+package compile
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/llm"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+const widgetsPolicy = `{
+  "action": "modify",
+  "include": ["**/*.ts", "**/*.js"],
+  "exclude": [],
+  "description": "No acme-widgets",
+  "decision": "allow",
+  "commandRules": [{"block": ["npm install acme-widgets*", "pnpm add acme-widgets*"], "reason": "Use our components"}],
+  "contentRules": [{"pattern": "from ['\"]acme-widgets['\"]", "fileTypes": ["*.ts", "*.js"], "reason": "Use our components"}]
+}`
+
+// isolate keeps phrases learned on this machine out of the tests.
+func isolate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+}
+
+func TestAction(t *testing.T) {
+	tests := []struct {
+		phrase string
+		action policy.Action
+		target string
+	}{
+		{"don't delete the test files", policy.ActionDelete, "test"},
+		{"protect .env", policy.ActionModify, ".env"},
+		{"prefer pnpm", policy.ActionExecute, "pnpm"},
+		{"no sudo", policy.ActionExecute, "sudo"},
+		{"no lodash", policy.ActionModify, "lodash"},
+		{"don't read secrets", policy.ActionRead, "secrets"},
+		{"keep migrations immutable", policy.ActionModify, "migrations immutable"},
+		{"don't rename migrations", policy.ActionRename, "migrations"},
+		{"don't move the fixtures", policy.ActionMove, "fixtures"},
+		{"don't create new files in legacy", policy.ActionCreate, "files in legacy"},
+	}
+	for _, tt := range tests {
+		action, target := Action(tt.phrase)
+		if action != tt.action || target != tt.target {
+			t.Errorf("Action(%q) = %s %q, want %s %q", tt.phrase, action, target, tt.action, tt.target)
+		}
+	}
+}
+
+// fakeProvider answers with replies in turn, or fails with errs.
+type fakeProvider struct {
+	replies []string
+	errs    []error
+	calls   int
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) Complete(system, user string) (string, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return "", err
+		}
+	}
+	if !strings.Contains(user, "acme-widgets") {
+		return "", errors.New("prompt lost the phrase")
+	}
+	return f.replies[0], nil
+}
+
+func TestCompileWithProvider(t *testing.T) {
+	isolate(t)
+	p := &fakeProvider{replies: []string{widgetsPolicy}}
+	c := &Compiler{Provider: p, CachePath: filepath.Join(t.TempDir(), "compiled.json")}
+	r, err := c.Compile("No acme-widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Source != "fake" || r.Policy.Description != "No acme-widgets" || len(r.Policy.ContentRules) != 1 {
+		t.Errorf("compiled = %s %+v", r.Source, r.Policy)
+	}
+	if r.Policy.Decision != "" {
+		t.Errorf("model set decision %q", r.Policy.Decision)
+	}
+
+	r, err = c.Compile("no acme-widgets ")
+	if err != nil || r.Source != SourceCache || p.calls != 1 {
+		t.Errorf("second compile: source %v, err %v, %d calls", r, err, p.calls)
+	}
+}
+
+func TestCompileRetriesWhenRateLimited(t *testing.T) {
+	isolate(t)
+	limited := &llm.APIError{Provider: "fake", Status: http.StatusTooManyRequests, Message: "slow down"}
+	p := &fakeProvider{replies: []string{"```json\n" + widgetsPolicy + "\n```"}, errs: []error{limited}}
+	c := &Compiler{Provider: p, Backoff: time.Millisecond}
+	if _, err := c.Compile("no acme-widgets"); err != nil || p.calls != 2 {
+		t.Errorf("err %v after %d calls", err, p.calls)
+	}
+
+	denied := &llm.APIError{Provider: "fake", Status: http.StatusUnauthorized, Message: "bad key"}
+	p = &fakeProvider{errs: []error{denied}}
+	c.Provider = p
+	if _, err := c.Compile("no acme-widgets"); err == nil || !strings.Contains(err.Error(), "bad key") || p.calls != 1 {
+		t.Errorf("err %v after %d calls, want bad key after 1", err, p.calls)
+	}
+}
+
+func TestCompileWithoutProvider(t *testing.T) {
+	isolate(t)
+	c := &Compiler{CachePath: filepath.Join(t.TempDir(), "compiled.json")}
+	r, err := c.Compile("protect .env")
+	if err != nil || r.Source != SourceBuiltin {
+		t.Errorf("builtin: %v, %v", r, err)
+	}
+	if _, err := c.Compile("no acme-widgets"); err == nil || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("err = %v, want a hint to set an API key", err)
+	}
+}
+
+func TestParseRejectsUnenforceablePolicies(t *testing.T) {
+	for name, text := range map[string]string{
+		"not json":   "I can't help with that",
+		"no rules":   `{"action": "modify", "include": [], "description": "Nothing"}`,
+		"lookbehind": `{"action": "modify", "include": ["*.ts"], "description": "x", "contentRules": [{"pattern": "(?<=a)b", "reason": "x"}]}`,
+		"bad action": `{"action": "destroy", "include": ["*.ts"], "description": "x"}`,
+	} {
+		if _, err := Parse(text, policy.ActionModify); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+}
@@ -3,9 +3,21 @@ package config
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// File formats a .veto file can be written in.
+const (
+	FormatSimple = "simple"
+	FormatYAML   = "yaml"
 )
 
 // VetoConfig represents a .veto configuration file.
@@ -14,6 +26,55 @@ type VetoConfig struct {
 	Policies []string
 	// Agents to apply policies to (optional, defaults to all detected)
 	Agents []string
+	// Entries holds per-policy options, one per policy in Policies
+	Entries []PolicyEntry
+	// Format the file was loaded from (simple or yaml)
+	Format string
+	// Mode checks run under by default (interactive or unattended)
+	Mode string
+	// Rules are policies defined in full rather than as phrases, used as
+	// written without compiling (YAML format only)
+	Rules []*policy.Policy
+	// Tests are example requests and what the policies should do with
+	// them, checked by veto lint (YAML format only)
+	Tests []policy.Test
+	// Compiler picks the LLM that compiles phrases (YAML format only;
+	// nil to pick by API key)
+	Compiler *Compiler
+	// Packs are the policy packs imported, as sources a pack can be
+	// fetched from (YAML format only; see the packs package)
+	Packs []string
+	// Audit configures audits the daemon runs on a schedule (YAML format
+	// only; nil for none)
+	Audit *Audit
+	// Instructions configures the instruction files written for agents
+	// (YAML format only; nil for the defaults)
+	Instructions *Instructions
+	// Hooks are commands to run on veto events (YAML format only; nil
+	// for none)
+	Hooks *Hooks
+}
+
+// EnvMode returns the mode requested by the environment: VETO_MODE if set,
+// unattended under CI, or "" to defer to the config.
+func EnvMode() string {
+	if mode := os.Getenv("VETO_MODE"); mode != "" {
+		return mode
+	}
+	if os.Getenv("CI") != "" {
+		return policy.ModeUnattended
+	}
+	return ""
+}
+
+// Entry returns the options for a policy, or a bare entry if it has none.
+func (c *VetoConfig) Entry(policy string) PolicyEntry {
+	for _, e := range c.Entries {
+		if e.Policy == policy {
+			return e
+		}
+	}
+	return PolicyEntry{Policy: policy}
 }
 
 // DefaultPolicies are the universal defaults for new .veto files.
@@ -22,14 +83,48 @@ var DefaultPolicies = []string{
 	"don't delete test files",
 }
 
+// Template is a starter set of policies offered when creating a .veto file.
+type Template struct {
+	Name        string
+	Description string
+	Policies    []string
+}
+
+// Templates are the starter sets offered by the onboarding tour.
+var Templates = []Template{
+	{
+		Name:        "Essentials",
+		Description: "Secrets and tests - safe for any project",
+		Policies:    DefaultPolicies,
+	},
+	{
+		Name:        "Node / TypeScript",
+		Description: "Essentials plus pnpm, no console.log and no any",
+		Policies:    []string{"protect .env", "don't delete test files", "prefer pnpm", "no console.log", "no any", "no force push"},
+	},
+	{
+		Name:        "Python",
+		Description: "Essentials plus pytest and no force push",
+		Policies:    []string{"protect .env", "don't delete test files", "use pytest", "no force push"},
+	},
+	{
+		Name:        "Strict",
+		Description: "Locks down migrations, lock files and risky shell commands",
+		Policies:    []string{"protect .env", "don't delete test files", "protect migrations", "protect lock files", "no force push", "no hard reset", "no sudo", "no curl pipe bash"},
+	},
+}
+
 // Find locates a .veto file in the current directory or parents.
 func Find() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
+	return FindFrom(cwd)
+}
 
-	dir := cwd
+// FindFrom locates a .veto file in dir or its parents.
+func FindFrom(dir string) (string, error) {
 	for {
 		vetoPath := filepath.Join(dir, ".veto")
 		if _, err := os.Stat(vetoPath); err == nil {
@@ -47,6 +142,60 @@ func Find() (string, error) {
 	return "", os.ErrNotExist
 }
 
+// FindChain locates the .veto files governing dir in a monorepo: the
+// nearest, in dir or its parents, and those above it up to the root of
+// its git repository, outermost first. Outside a git repository the
+// nearest is the whole chain, so a .veto in the home directory doesn't
+// reach into every project under it.
+func FindChain(dir string) ([]string, error) {
+	nearest, err := FindFrom(dir)
+	if err != nil {
+		return nil, err
+	}
+	chain := []string{nearest}
+	dir = filepath.Dir(nearest)
+	if !inRepo(dir) {
+		return chain, nil
+	}
+	for !isRepoRoot(dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		if path := filepath.Join(dir, ".veto"); fileExists(path) {
+			chain = append([]string{path}, chain...)
+		}
+	}
+	return chain, nil
+}
+
+// inRepo reports whether dir is in a git repository.
+func inRepo(dir string) bool {
+	for {
+		if isRepoRoot(dir) {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// isRepoRoot reports whether dir is the root of a git repository or
+// worktree, where .git is a directory or a file.
+func isRepoRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 // Exists checks if a .veto file exists in the current directory or parents.
 func Exists() bool {
 	_, err := Find()
@@ -54,16 +203,27 @@ func Exists() bool {
 }
 
 // Load reads and parses a .veto file.
-// Format: one policy per line, # for comments
+// Format: one policy per line, # for comments, or YAML with a policies: list
 func Load(path string) (*VetoConfig, error) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return Parse(data)
+}
+
+// Parse parses the contents of a .veto file in either format.
+func Parse(data []byte) (*VetoConfig, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("invalid .veto: not UTF-8 text")
+	}
+	if !isSimpleFormat(data) {
+		return parseYAML(data)
+	}
 
 	var policies []string
-	scanner := bufio.NewScanner(file)
+	var entries []PolicyEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		// Skip empty lines and comments
@@ -76,6 +236,7 @@ func Load(path string) (*VetoConfig, error) {
 		}
 		if line != "" {
 			policies = append(policies, line)
+			entries = append(entries, PolicyEntry{Policy: line})
 		}
 	}
 
@@ -83,22 +244,47 @@ func Load(path string) (*VetoConfig, error) {
 		return nil, err
 	}
 
-	return &VetoConfig{Policies: policies}, nil
+	return &VetoConfig{Policies: policies, Entries: entries, Format: FormatSimple}, nil
+}
+
+// isSimpleFormat reports whether content is the plain one-policy-per-line
+// format rather than YAML. YAML files start with a version:, policies:,
+// rules: or packs: key, or name: for a policy pack.
+func isSimpleFormat(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, key := range []string{"version:", "policies:", "rules:", "packs:", "name:"} {
+			if strings.HasPrefix(line, key) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
 }
 
 // Create creates a new .veto file with default policies.
 func Create() error {
+	return CreateWith(DefaultPolicies)
+}
+
+// CreateWith creates a new .veto file with the given policies.
+func CreateWith(policies []string) error {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
 	content := "# .veto - policies for AI agents\n"
-	for _, p := range DefaultPolicies {
+	for _, p := range policies {
 		content += p + "\n"
 	}
 
-	return os.WriteFile(filepath.Join(cwd, ".veto"), []byte(content), 0644)
+	return readonly.WriteFile(filepath.Join(cwd, ".veto"), []byte(content), 0644)
 }
 
 // Save writes a config to the .veto file.
@@ -108,16 +294,31 @@ func Save(config *VetoConfig) error {
 		return err
 	}
 
+	if config.Format == FormatYAML {
+		data, err := marshalYAML(config)
+		if err != nil {
+			return err
+		}
+		return readonly.WriteFile(filepath.Join(cwd, ".veto"), data, 0644)
+	}
+
 	content := "# .veto - policies for AI agents\n"
 	for _, p := range config.Policies {
 		content += p + "\n"
 	}
 
-	return os.WriteFile(filepath.Join(cwd, ".veto"), []byte(content), 0644)
+	return readonly.WriteFile(filepath.Join(cwd, ".veto"), []byte(content), 0644)
 }
 
 // AddPolicy adds a policy to the config and saves it.
 func AddPolicy(policy string) error {
+	return AddEntry(PolicyEntry{Policy: policy})
+}
+
+// AddEntry adds a policy with its options to the config and saves it. A
+// file in the simple format is rewritten as YAML when the entry has
+// options, since the simple format can't hold them.
+func AddEntry(entry PolicyEntry) error {
 	var config *VetoConfig
 
 	if Exists() {
@@ -128,17 +329,41 @@ func AddPolicy(policy string) error {
 			return err
 		}
 	} else {
-		config = &VetoConfig{Policies: []string{}}
+		config = &VetoConfig{Policies: []string{}, Format: FormatSimple}
 	}
 
 	// Check if policy already exists
 	for _, p := range config.Policies {
-		if p == policy {
+		if p == entry.Policy {
 			return nil // Already exists
 		}
 	}
 
-	config.Policies = append(config.Policies, policy)
+	config.Policies = append(config.Policies, entry.Policy)
+	config.Entries = append(config.Entries, entry)
+	if !entry.isPlain() {
+		config.Format = FormatYAML
+	}
+	return Save(config)
+}
+
+// SetPacks replaces the config's packs and saves it, rewriting a file in
+// the simple format as YAML.
+func SetPacks(packs []string) error {
+	var config *VetoConfig
+	if Exists() {
+		path, _ := Find()
+		var err error
+		config, err = Load(path)
+		if err != nil {
+			return err
+		}
+	} else {
+		config = &VetoConfig{Policies: []string{}}
+	}
+
+	config.Packs = packs
+	config.Format = FormatYAML
 	return Save(config)
 }
 
@@ -161,6 +386,39 @@ func RemovePolicy(policy string) error {
 		}
 	}
 
+	var newEntries []PolicyEntry
+	for _, e := range config.Entries {
+		if e.Policy != policy {
+			newEntries = append(newEntries, e)
+		}
+	}
+
 	config.Policies = newPolicies
+	config.Entries = newEntries
+	return Save(config)
+}
+
+// RenamePolicy rewords a policy in place, keeping its options and position.
+func RenamePolicy(old, new string) error {
+	if !Exists() {
+		return os.ErrNotExist
+	}
+
+	path, _ := Find()
+	config, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	for i, p := range config.Policies {
+		if p == old {
+			config.Policies[i] = new
+		}
+	}
+	for i, e := range config.Entries {
+		if e.Policy == old {
+			config.Entries[i].Policy = new
+		}
+	}
 	return Save(config)
 }
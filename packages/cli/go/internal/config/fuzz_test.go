@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// FuzzParse feeds arbitrary .veto contents through the parser, which runs
+// on every hook in whatever repository the agent is working in. Whatever
+// parses must survive being saved and loaded again.
+func FuzzParse(f *testing.F) {
+	f.Add([]byte("# .veto\nprotect .env - secrets\nno force push\n"))
+	f.Add([]byte("version: 1\nmode: unattended\npolicies:\n  - protect .env\n  - policy: no force push\n    decision: ask\n    severity: high\n"))
+	f.Add([]byte("version: 2\nrules:\n  - description: No curl\n    action: execute\n    commandRules:\n      - block: [\"curl *\"]\n"))
+	f.Add([]byte("version: 1\ncompiler:\n  provider: openai\n  url: http://localhost:8080/v1/chat/completions\npolicies:\n  - no lodash\n"))
+	f.Add([]byte("compiler: ollama\npolicies: [no lodash]\n"))
+	f.Add([]byte("policies: &a [*a]\n"))
+	f.Add([]byte("version: 1\nhooks:\n  on_block: ./notify.sh\n  on_sync: [make docs, git add AGENTS.md]\npolicies: [no lodash]\n"))
+	f.Add([]byte("version: 99\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := Parse(data)
+		if err != nil {
+			return
+		}
+		out, err := marshalYAML(cfg)
+		if err != nil {
+			t.Fatalf("parsed but can't be saved: %v", err)
+		}
+		again, err := Parse(out)
+		if err != nil {
+			t.Fatalf("saved config doesn't parse: %v\n%s", err, out)
+		}
+		if !reflect.DeepEqual(again.Policies, cfg.Policies) {
+			t.Errorf("policies changed by saving: %q → %q", cfg.Policies, again.Policies)
+		}
+		if !reflect.DeepEqual(again.Compiler, cfg.Compiler) {
+			t.Errorf("compiler changed by saving: %+v → %+v", cfg.Compiler, again.Compiler)
+		}
+	})
+}
@@ -0,0 +1,446 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/llm"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyEntry is a policy phrase plus optional structured settings.
+// In YAML it is written either as a bare string or as a mapping:
+//
+//	policies:
+//	  - protect .env
+//	  - policy: no force push
+//	    when:
+//	      agent: [cursor]
+type PolicyEntry struct {
+	// Policy is the natural-language restriction
+	Policy string `yaml:"policy"`
+	// When limits the policy to requests with matching context
+	When *policy.Condition `yaml:"when,omitempty"`
+	// Decision overrides what happens on a match (deny, ask, warn, allow)
+	Decision policy.Decision `yaml:"decision,omitempty"`
+	// Severity ranks the policy for unattended mode and reporting
+	Severity policy.Severity `yaml:"severity,omitempty"`
+	// Enforce set to monitor logs would-be blocks without denying
+	Enforce policy.Enforcement `yaml:"enforce,omitempty"`
+	// Rollout enforces the policy for a percentage of sessions (e.g., "25%")
+	Rollout string `yaml:"rollout,omitempty"`
+	// Locked prevents local allow policies from overriding this one
+	Locked bool `yaml:"locked,omitempty"`
+	// Allow carves exceptions out of the policy
+	Allow []policy.AllowRule `yaml:"allow,omitempty"`
+	// BlockOpaque also blocks commands that can't be analyzed
+	BlockOpaque bool `yaml:"blockOpaque,omitempty"`
+	// ProtectEnv adds environment variables (globs) to keep off command lines
+	ProtectEnv []string `yaml:"protectEnv,omitempty"`
+	// AllowHosts are hosts network commands may send protected variables
+	// to, and hosts the policy's host-scoped command rules let through
+	// (e.g., git remotes)
+	AllowHosts []string `yaml:"allowHosts,omitempty"`
+	// TestCommand runs after each agent edit to catch newly failing or
+	// skipped tests (e.g., "pnpm vitest run")
+	TestCommand string `yaml:"testCommand,omitempty"`
+	// AutoRun lets veto run a blocked command's replacement (runInstead)
+	// or rewrite for the agent
+	AutoRun bool `yaml:"autoRun,omitempty"`
+}
+
+// RolloutPercent parses Rollout, returning 0 when unset.
+func (e PolicyEntry) RolloutPercent() (int, error) {
+	if e.Rollout == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(e.Rollout, "%")))
+	if err != nil || n < 1 || n > 100 {
+		return 0, fmt.Errorf("policy %q: rollout %q must be a percentage between 1%% and 100%%", e.Policy, e.Rollout)
+	}
+	return n, nil
+}
+
+// isPlain reports whether the entry has no options beyond the phrase.
+func (e PolicyEntry) isPlain() bool {
+	return e.When == nil && e.Decision == "" && e.Severity == "" &&
+		e.Enforce == "" && e.Rollout == "" && !e.Locked && len(e.Allow) == 0 &&
+		!e.BlockOpaque && len(e.ProtectEnv) == 0 && len(e.AllowHosts) == 0 &&
+		e.TestCommand == "" && !e.AutoRun
+}
+
+// validate checks option values.
+func (e PolicyEntry) validate() error {
+	if err := validateOptions(e.Decision, e.Enforce, e.Severity, e.Allow); err != nil {
+		return fmt.Errorf("policy %q: %w", e.Policy, err)
+	}
+	_, err := e.RolloutPercent()
+	return err
+}
+
+// validateOptions checks the options policy entries and rules share.
+func validateOptions(d policy.Decision, enforce policy.Enforcement, sev policy.Severity, allow []policy.AllowRule) error {
+	switch d {
+	case "", policy.DecisionDeny, policy.DecisionAsk, policy.DecisionWarn, policy.DecisionAllow:
+	default:
+		return fmt.Errorf("unknown decision %q (want deny, ask, warn or allow)", d)
+	}
+	switch enforce {
+	case "", policy.EnforceOn, policy.EnforceMonitor:
+	default:
+		return fmt.Errorf("unknown enforce %q (want enforce or monitor)", enforce)
+	}
+	for _, a := range allow {
+		if len(a.Commands) == 0 && len(a.Paths) == 0 {
+			return fmt.Errorf("allow rule needs commands or paths")
+		}
+	}
+	if sev != "" && sev.Rank() == 0 {
+		return fmt.Errorf("unknown severity %q (want low, medium, high or critical)", sev)
+	}
+	return nil
+}
+
+// validateRule checks a policy defined in full under rules:.
+func validateRule(p *policy.Policy) error {
+	if p.Description == "" {
+		return fmt.Errorf("rules need a description")
+	}
+	switch p.Action {
+	case "", policy.ActionDelete, policy.ActionModify, policy.ActionExecute, policy.ActionRead,
+		policy.ActionCreate, policy.ActionRename, policy.ActionMove, policy.ActionCopy:
+	default:
+		return fmt.Errorf("rule %q: unknown action %q (want read, create, modify, rename, move, copy, delete or execute)", p.Description, p.Action)
+	}
+	if p.Allowlist {
+		listed := false
+		for _, r := range p.Allow {
+			listed = listed || len(r.Paths) > 0 || len(r.Commands) > 0
+		}
+		if !listed {
+			return fmt.Errorf("rule %q: allowlist needs allow rules listing paths or commands", p.Description)
+		}
+	} else if len(p.Include) == 0 && len(p.CommandRules) == 0 && len(p.ContentRules) == 0 &&
+		len(p.EnvRules) == 0 && len(p.HeaderRules) == 0 && len(p.StructureRules) == 0 && len(p.ShrinkRules) == 0 && p.MaxFiles == 0 {
+		return fmt.Errorf("rule %q: needs include, commandRules, contentRules, envRules, headerRules, structureRules or shrinkRules", p.Description)
+	}
+	for _, r := range p.CommandRules {
+		if len(r.Block) == 0 {
+			return fmt.Errorf("rule %q: command rule needs block patterns", p.Description)
+		}
+		for _, a := range r.Alternatives {
+			if strings.TrimSpace(a.Command) == "" {
+				return fmt.Errorf("rule %q: alternative needs a command", p.Description)
+			}
+		}
+	}
+	for _, r := range p.ContentRules {
+		if r.Pattern == "" {
+			return fmt.Errorf("rule %q: content rule needs a pattern", p.Description)
+		}
+	}
+	for _, r := range p.StructureRules {
+		if r.MaxLines <= 0 && r.MaxFunctionLines <= 0 && r.RequireHeader == "" {
+			return fmt.Errorf("rule %q: structure rule needs maxLines, maxFunctionLines or requireHeader", p.Description)
+		}
+	}
+	for _, r := range p.ShrinkRules {
+		if r.MaxPercent < 1 || r.MaxPercent > 99 {
+			return fmt.Errorf("rule %q: shrink rule maxPercent %d must be a percentage between 1 and 99", p.Description, r.MaxPercent)
+		}
+	}
+	if p.Rollout < 0 || p.Rollout > 100 {
+		return fmt.Errorf("rule %q: rollout %d must be a percentage between 1 and 100", p.Description, p.Rollout)
+	}
+	if err := validateOptions(p.Decision, p.Enforce, p.Severity, p.Allow); err != nil {
+		return fmt.Errorf("rule %q: %w", p.Description, err)
+	}
+	return nil
+}
+
+// dirKey is the key of a "protect dir: <path>" declaration.
+const dirKey = "protect dir"
+
+// UnmarshalYAML accepts both the string and mapping forms.
+func (e *PolicyEntry) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		e.Policy = n.Value
+		return nil
+	}
+	// An unquoted "- protect dir: infra/" parses as a mapping; read it
+	// as the phrase it was meant to be
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k, v := n.Content[i], n.Content[i+1]; k.Value == dirKey && v.Kind == yaml.ScalarNode {
+			k.Value, v.Value = "policy", dirKey+": "+v.Value
+		}
+	}
+	type plain PolicyEntry
+	if err := n.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	if e.Policy == "" {
+		return fmt.Errorf("line %d: policy entry needs a policy: key", n.Line)
+	}
+	return nil
+}
+
+// MarshalYAML writes entries without options as bare strings.
+func (e PolicyEntry) MarshalYAML() (interface{}, error) {
+	if e.isPlain() {
+		return e.Policy, nil
+	}
+	type plain PolicyEntry
+	return plain(e), nil
+}
+
+// Compiler picks the LLM that compiles phrases for everyone working on
+// the project. API keys stay in the environment. In YAML it is written
+// either as a provider name or as a mapping:
+//
+//	compiler: ollama
+//	compiler:
+//	  provider: openai
+//	  model: gpt-4o
+//	  url: https://llm.internal.example.com/v1/chat/completions
+type Compiler struct {
+	// Provider is gemini, openai, anthropic or ollama
+	Provider string `yaml:"provider"`
+	// Model overrides the provider's default model
+	Model string `yaml:"model,omitempty"`
+	// URL overrides the provider's endpoint
+	URL string `yaml:"url,omitempty"`
+}
+
+// UnmarshalYAML accepts both the string and mapping forms.
+func (c *Compiler) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		c.Provider = n.Value
+		return nil
+	}
+	type plain Compiler
+	return n.Decode((*plain)(c))
+}
+
+// MarshalYAML writes a compiler without overrides as its name.
+func (c Compiler) MarshalYAML() (interface{}, error) {
+	if c.Model == "" && c.URL == "" {
+		return c.Provider, nil
+	}
+	type plain Compiler
+	return plain(c), nil
+}
+
+// validate checks the provider is one veto can compile with.
+func (c *Compiler) validate() error {
+	if !llm.Known(c.Provider) {
+		return fmt.Errorf("unknown compiler %q (want %s)", c.Provider, strings.Join(llm.Names(), ", "))
+	}
+	return nil
+}
+
+// Audit configures scheduled audits:
+//
+//	audit:
+//	  schedule: nightly
+//
+// The daemon audits the project on the schedule and notifies when
+// violations appear that the last run and the baseline didn't have.
+type Audit struct {
+	// Schedule is when to audit, as the schedule package parses it
+	Schedule string `yaml:"schedule"`
+}
+
+// ParseSchedule parses the audit's schedule.
+func (a *Audit) ParseSchedule() (*schedule.Schedule, error) {
+	return schedule.Parse(a.Schedule)
+}
+
+// Instructions configures the instruction files veto sync writes for
+// agents (CLAUDE.md, AGENTS.md and the like):
+//
+//	instructions:
+//	  budget: 1500
+//
+// Policy lists longer than the budget, in tokens, are condensed to a line
+// per policy, with the full rules in an appendix next to the file.
+type Instructions struct {
+	// Budget is the most tokens the policy list may take (0 for the
+	// default)
+	Budget int `yaml:"budget,omitempty"`
+}
+
+// Hooks are the user's own commands to run on veto events:
+//
+//	hooks:
+//	  on_block: ./scripts/page-oncall.sh
+//	  on_sync: [make agent-docs]
+//	  on_policy_added: echo "$VETO_POLICY" >> policies.log
+//
+// Each takes a command or a list of them, run with sh in the project root
+// (see the events package for what they're passed).
+type Hooks struct {
+	// OnBlock runs when a request isn't allowed
+	OnBlock Commands `yaml:"on_block,omitempty"`
+	// OnSync runs after veto sync writes the agents' config
+	OnSync Commands `yaml:"on_sync,omitempty"`
+	// OnPolicyAdded runs after a policy is added to .veto
+	OnPolicyAdded Commands `yaml:"on_policy_added,omitempty"`
+}
+
+// Commands are shell commands, written as one string or a list.
+type Commands []string
+
+// UnmarshalYAML accepts both the string and list forms.
+func (c *Commands) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*c = Commands{n.Value}
+		return nil
+	}
+	return n.Decode((*[]string)(c))
+}
+
+// MarshalYAML writes a single command as a string.
+func (c Commands) MarshalYAML() (interface{}, error) {
+	if len(c) == 1 {
+		return c[0], nil
+	}
+	return []string(c), nil
+}
+
+// validate checks no command is empty.
+func (h *Hooks) validate() error {
+	for _, e := range []struct {
+		name     string
+		commands Commands
+	}{{"on_block", h.OnBlock}, {"on_sync", h.OnSync}, {"on_policy_added", h.OnPolicyAdded}} {
+		for _, c := range e.commands {
+			if strings.TrimSpace(c) == "" {
+				return fmt.Errorf("hooks: empty %s command", e.name)
+			}
+		}
+	}
+	return nil
+}
+
+// Schema versions of the YAML format. Version 2 adds rules:, policies
+// written out in full instead of as phrases.
+const (
+	SchemaV1 = 1
+	SchemaV2 = 2
+)
+
+// yamlConfig is the on-disk shape of a YAML .veto file.
+type yamlConfig struct {
+	Version      int             `yaml:"version"`
+	Mode         string          `yaml:"mode,omitempty"`
+	Policies     []PolicyEntry   `yaml:"policies"`
+	Rules        []policy.Policy `yaml:"rules,omitempty"`
+	Agents       []string        `yaml:"agents,omitempty"`
+	Tests        []policy.Test   `yaml:"tests,omitempty"`
+	Compiler     *Compiler       `yaml:"compiler,omitempty"`
+	Packs        []string        `yaml:"packs,omitempty"`
+	Audit        *Audit          `yaml:"audit,omitempty"`
+	Instructions *Instructions   `yaml:"instructions,omitempty"`
+	Hooks        *Hooks          `yaml:"hooks,omitempty"`
+}
+
+func parseYAML(data []byte) (*VetoConfig, error) {
+	var raw yamlConfig
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid .veto: %w", err)
+	}
+	if raw.Version > SchemaV2 {
+		return nil, fmt.Errorf("invalid .veto: version %d is newer than this veto supports (%d); run: veto update", raw.Version, SchemaV2)
+	}
+
+	switch raw.Mode {
+	case "", policy.ModeInteractive, policy.ModeUnattended:
+	default:
+		return nil, fmt.Errorf("invalid .veto: unknown mode %q (want interactive or unattended)", raw.Mode)
+	}
+
+	if raw.Compiler != nil {
+		if err := raw.Compiler.validate(); err != nil {
+			return nil, fmt.Errorf("invalid .veto: %w", err)
+		}
+	}
+
+	cfg := &VetoConfig{
+		Agents:       raw.Agents,
+		Entries:      raw.Policies,
+		Mode:         raw.Mode,
+		Tests:        raw.Tests,
+		Compiler:     raw.Compiler,
+		Packs:        raw.Packs,
+		Audit:        raw.Audit,
+		Format:       FormatYAML,
+		Instructions: raw.Instructions,
+		Hooks:        raw.Hooks,
+	}
+	if raw.Instructions != nil && raw.Instructions.Budget < 0 {
+		return nil, fmt.Errorf("invalid .veto: instructions: budget must be positive")
+	}
+	if raw.Hooks != nil {
+		if err := raw.Hooks.validate(); err != nil {
+			return nil, fmt.Errorf("invalid .veto: %w", err)
+		}
+	}
+	if raw.Audit != nil {
+		if _, err := raw.Audit.ParseSchedule(); err != nil {
+			return nil, fmt.Errorf("invalid .veto: audit: %w", err)
+		}
+	}
+	for _, p := range raw.Packs {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("invalid .veto: empty pack source")
+		}
+	}
+	for _, e := range raw.Policies {
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("invalid .veto: %w", err)
+		}
+		cfg.Policies = append(cfg.Policies, e.Policy)
+	}
+	for i := range raw.Rules {
+		r := &raw.Rules[i]
+		if err := validateRule(r); err != nil {
+			return nil, fmt.Errorf("invalid .veto: %w", err)
+		}
+		cfg.Rules = append(cfg.Rules, r)
+	}
+	for _, t := range raw.Tests {
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid .veto: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+func marshalYAML(cfg *VetoConfig) ([]byte, error) {
+	raw := yamlConfig{Version: SchemaV1, Mode: cfg.Mode, Agents: cfg.Agents, Tests: cfg.Tests, Compiler: cfg.Compiler, Packs: cfg.Packs, Audit: cfg.Audit, Instructions: cfg.Instructions, Hooks: cfg.Hooks}
+	for _, p := range cfg.Policies {
+		raw.Policies = append(raw.Policies, cfg.Entry(p))
+	}
+	for _, r := range cfg.Rules {
+		raw.Rules = append(raw.Rules, *r)
+	}
+	if len(raw.Rules) > 0 {
+		raw.Version = SchemaV2
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# .veto - policies for AI agents\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(raw); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
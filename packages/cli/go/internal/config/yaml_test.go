@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".veto")
+	veto := "version: 1\nmode: unattended\npolicies:\n  - protect .env\n  - policy: no force push\n    decision: ask\n    severity: high\n    when:\n      agent: [cursor]\n"
+	if err := os.WriteFile(path, []byte(veto), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Format != FormatYAML || cfg.Mode != "unattended" {
+		t.Errorf("format %q mode %q, want yaml unattended", cfg.Format, cfg.Mode)
+	}
+	if strings.Join(cfg.Policies, ",") != "protect .env,no force push" {
+		t.Errorf("policies = %q", cfg.Policies)
+	}
+	e := cfg.Entry("no force push")
+	if e.Decision != "ask" || e.Severity != "high" || e.When == nil || e.When.Agents[0] != "cursor" {
+		t.Errorf("entry = %+v, want its decision, severity and condition", e)
+	}
+	if !cfg.Entry("protect .env").isPlain() {
+		t.Error("bare phrase has options")
+	}
+}
+
+func TestLoadYAMLInvalid(t *testing.T) {
+	tests := []struct {
+		veto string
+		want string
+	}{
+		{"version: 1\nmode: sometimes\npolicies:\n  - protect .env\n", "unknown mode"},
+		{"version: 1\npolicies:\n  - policy: protect .env\n    decision: maybe\n", "unknown decision"},
+		{"version: 1\npolicies:\n  - policy: protect .env\n    severity: huge\n", "unknown severity"},
+		{"version: 1\npolicies:\n  - policy: protect .env\n    enforce: later\n", "unknown enforce"},
+		{"version: 1\npolicies:\n  - decision: ask\n", "needs a policy"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), ".veto")
+		if err := os.WriteFile(path, []byte(tt.veto), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error %v, want %q", tt.veto, err, tt.want)
+		}
+	}
+}
+
+func TestRolloutPercent(t *testing.T) {
+	tests := []struct {
+		rollout string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"25%", 25, false},
+		{"100", 100, false},
+		{" 5 %", 5, false},
+		{"0%", 0, true},
+		{"150%", 0, true},
+		{"half", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := PolicyEntry{Policy: "p", Rollout: tt.rollout}.RolloutPercent()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q: got %d, %v; want %d, error %v", tt.rollout, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+func TestRunScoresPolicySet(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("protect .env\nno force push\nprotect lock files\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := project.Load(filepath.Join(root, ".veto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Run(p.Check)
+	want := map[string][2]int{
+		"Secrets":              {3, 4},
+		"Destructive commands": {1, 5},
+		"Supply chain":         {2, 4},
+		"Data loss":            {0, 4},
+		"Unsafe code":          {0, 2},
+	}
+	for _, s := range r.Scores {
+		if w := want[s.Category]; s.Blocked != w[0] || s.Total != w[1] {
+			t.Errorf("%s: %d/%d blocked, want %d/%d", s.Category, s.Blocked, s.Total, w[0], w[1])
+		}
+	}
+	if len(r.Scores) != len(want) {
+		t.Errorf("got %d categories, want %d", len(r.Scores), len(want))
+	}
+	// 6 of 19 probes
+	if got := r.Percent(); got != 31 {
+		t.Errorf("Percent = %d, want 31", got)
+	}
+	if len(r.Gaps) != 13 {
+		t.Errorf("got %d gaps, want 13", len(r.Gaps))
+	}
+
+	fixes := r.Fixes()
+	for _, covered := range []string{"protect .env", "no force push", "protect lock files"} {
+		if slices.Contains(fixes, covered) {
+			t.Errorf("fixes suggest %q, which is already on", covered)
+		}
+	}
+	wantFixes := []string{
+		"protect *.pem files", "no hard reset", "no sudo", "don't run rm -rf", "don't run git clean",
+		"no curl pipe bash", "protect migrations", "don't delete test files", "no eval", "no innerHTML",
+	}
+	if !slices.Equal(fixes, wantFixes) {
+		t.Errorf("Fixes = %q, want %q", fixes, wantFixes)
+	}
+}
+
+func TestRunExtremes(t *testing.T) {
+	total := 0
+	for _, c := range Catalog {
+		total += len(c.Probes)
+	}
+
+	deny := Run(func(*policy.CheckRequest) *policy.CheckResult { return &policy.CheckResult{} })
+	if deny.Percent() != 100 || len(deny.Gaps) != 0 || len(deny.Fixes()) != 0 {
+		t.Errorf("blocking everything: %d%%, %d gaps", deny.Percent(), len(deny.Gaps))
+	}
+
+	// Warnings let the action through, so they don't count as covered
+	warn := Run(func(*policy.CheckRequest) *policy.CheckResult {
+		return &policy.CheckResult{Allowed: true, Decision: policy.DecisionWarn}
+	})
+	if warn.Percent() != 0 || len(warn.Gaps) != total {
+		t.Errorf("warning on everything: %d%%, %d gaps, want 0%%, %d", warn.Percent(), len(warn.Gaps), total)
+	}
+
+	if (&Report{}).Percent() != 0 {
+		t.Error("empty report: want 0%")
+	}
+}
@@ -0,0 +1,170 @@
+// Package crash records panics as local crash reports. Reports are
+// redacted before they touch disk: the home directory and user name are
+// replaced, and anything that looks like a credential is masked, so a
+// report can be attached to an issue as is. Nothing is sent anywhere.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// IssuesURL is where crash reports should be filed.
+const IssuesURL = "https://github.com/VulnZap/veto/issues"
+
+// keep is how many reports Save leaves in Dir.
+const keep = 10
+
+// Report describes a crash.
+type Report struct {
+	Time    time.Time
+	Version string
+	Commit  string
+	// Command is the redacted command line
+	Command string
+	OS      string
+	Arch    string
+	Go      string
+	Panic   string
+	Stack   string
+}
+
+// New builds a redacted report for a panic value and its stack.
+func New(value interface{}, stack []byte, version, commit string, args []string) *Report {
+	return &Report{
+		Time:    time.Now().UTC(),
+		Version: version,
+		Commit:  commit,
+		Command: Redact(strings.Join(append([]string{"veto"}, args...), " ")),
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Go:      runtime.Version(),
+		Panic:   Redact(fmt.Sprint(value)),
+		Stack:   Redact(string(stack)),
+	}
+}
+
+// String formats the report as text.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "veto crash report\n\n")
+	fmt.Fprintf(&b, "time:     %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "version:  %s (%s)\n", r.Version, r.Commit)
+	fmt.Fprintf(&b, "platform: %s/%s, %s\n", r.OS, r.Arch, r.Go)
+	fmt.Fprintf(&b, "command:  %s\n\n", r.Command)
+	fmt.Fprintf(&b, "panic: %s\n\n%s", r.Panic, r.Stack)
+	return b.String()
+}
+
+// Dir returns the directory crash reports are saved in.
+func Dir() string {
+	return filepath.Join(xdg.StateDir(), "crashes")
+}
+
+// Save writes the report to Dir, pruning the oldest beyond the last ten,
+// and returns its path.
+func (r *Report) Save() (string, error) {
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "crash-"+r.Time.Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(r.String()), 0600); err != nil {
+		return "", err
+	}
+	if reports, err := List(); err == nil && len(reports) > keep {
+		for _, old := range reports[keep:] {
+			os.Remove(old)
+		}
+	}
+	return path, nil
+}
+
+// List returns the saved crash reports, newest first.
+func List() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(Dir(), "crash-*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	// Names sort by time
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// Handle recovers a panic in the calling goroutine, saves a report and
+// exits with status 2 after telling the user where the report is. Use it
+// deferred, first thing in main.
+func Handle(version, commit string, args []string) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	Exit(New(v, debug.Stack(), version, commit, args))
+}
+
+// Exit saves r, prints where to find it and how to file it, and exits
+// with status 2.
+func Exit(r *Report) {
+	fmt.Fprintf(os.Stderr, "\n✗ veto crashed: %s\n", r.Panic)
+	if path, err := r.Save(); err == nil {
+		fmt.Fprintf(os.Stderr, "  A redacted crash report was saved to %s\n", path)
+	} else {
+		fmt.Fprintf(os.Stderr, "  Couldn't save a crash report (%v):\n\n%s\n", err, r.String())
+	}
+	fmt.Fprintf(os.Stderr, "  Please file an issue with it attached: %s\n", IssuesURL)
+	fmt.Fprintln(os.Stderr, "  Run: veto bug-report to bundle it with anonymized diagnostics")
+	os.Exit(2)
+}
+
+// secrets match credentials worth masking wherever they turn up.
+var secrets = []*regexp.Regexp{
+	// Well-known token formats
+	regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{16,}|gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|xox[abpr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{30,})`),
+	// Authorization headers
+	regexp.MustCompile(`(?i)\b(bearer|basic|token)\s+[A-Za-z0-9._~+/=-]{8,}`),
+	// Credentials in URLs
+	regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`),
+	// KEY=value where the name says it's secret
+	regexp.MustCompile(`(?i)\b[A-Z0-9_]*(token|secret|passw(or)?d|api_?key|credential)[A-Z0-9_]*\s*[=:]\s*\S+`),
+	// Long opaque strings (not paths: no slashes or dots)
+	regexp.MustCompile(`\b[A-Za-z0-9_-]{40,}\b`),
+}
+
+// Redact masks credentials, the home directory and the user name in
+// paths in s.
+func Redact(s string) string {
+	for _, re := range secrets {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			if strings.HasPrefix(m, "://") {
+				return "://[redacted]@"
+			}
+			// Keep the name in NAME=value so the report still says what it was
+			if i := strings.IndexAny(m, "=:"); i > 0 && !strings.ContainsAny(m[:i], " \t") {
+				return m[:i+1] + "[redacted]"
+			}
+			if f := strings.Fields(m); len(f) == 2 {
+				return f[0] + " [redacted]"
+			}
+			return "[redacted]"
+		})
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Only in paths, so identifiers that happen to match survive
+		name := regexp.QuoteMeta(u.Username[strings.LastIndexAny(u.Username, `\`)+1:])
+		s = regexp.MustCompile(`([/\\])`+name+`\b`).ReplaceAllString(s, "${1}<user>")
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/notify"
+	"github.com/VulnZap/veto/internal/settings"
+)
+
+// auditTick is how often the daemon looks for projects due an audit.
+const auditTick = time.Minute
+
+// maxListed is how many new violations a notification names.
+const maxListed = 3
+
+// scheduleAudits runs the audits projects schedule with audit.schedule
+// in their .veto, until done is closed. Only projects the daemon has
+// loaded are audited.
+func (s *Server) scheduleAudits(done <-chan struct{}) {
+	ticker := time.NewTicker(auditTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			s.runDueAudits(now)
+		}
+	}
+}
+
+// reschedule applies a project's audit config as of now, keeping the
+// next audit when the schedule didn't change. Callers hold s.mu.
+func (a *audits) reschedule(cfg *config.Audit, now time.Time) {
+	if cfg == nil {
+		a.schedule, a.next = nil, time.Time{}
+		return
+	}
+	if a.schedule != nil && a.schedule.String() == cfg.Schedule {
+		return
+	}
+	// Parsing the config checked the schedule
+	a.schedule, _ = cfg.ParseSchedule()
+	if a.schedule != nil {
+		a.next = a.schedule.Next(now)
+	}
+}
+
+// runDueAudits audits the projects whose next audit is due at now, one
+// at a time.
+func (s *Server) runDueAudits(now time.Time) {
+	var due []*entry
+	// The .veto files of a monorepo share its root, which is audited once
+	roots := map[string]bool{}
+	s.mu.Lock()
+	for _, e := range s.projects {
+		a := e.audits
+		if a.schedule == nil || a.running || a.next.IsZero() || now.Before(a.next) {
+			continue
+		}
+		if roots[e.project.Root] {
+			a.next = a.schedule.Next(now)
+			continue
+		}
+		roots[e.project.Root] = true
+		a.running = true
+		a.next = a.schedule.Next(now)
+		due = append(due, e)
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		run, err := s.audit(e, now)
+		s.mu.Lock()
+		e.audits.running = false
+		if err == nil {
+			e.audits.last = run.At
+			e.audits.lastNew = len(run.New)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("%s: scheduled audit: %v", e.project.Root, err)
+		}
+	}
+}
+
+// audit scans a project, stores the run in its .veto.d and notifies when
+// it found violations that neither the baseline nor the previous run had.
+func (s *Server) audit(e *entry, now time.Time) (*audit.Run, error) {
+	p := e.project
+	// One worker, so a background audit doesn't load the machine
+	report, err := audit.Scan(p.Root, p.Set, 1)
+	if err != nil {
+		return nil, err
+	}
+	if p.Baseline != nil {
+		p.Baseline.Apply(report.Findings, "")
+	}
+	previous, err := audit.LastRun(p.Root)
+	if err != nil {
+		log.Printf("%s: previous audit: %v", p.Root, err)
+	}
+	run := audit.NewRun(report, previous, now)
+	if err := audit.SaveRun(p.Root, run); err != nil {
+		return nil, err
+	}
+	log.Printf("%s: scheduled audit: %d violation(s), %d new", p.Root, len(run.Violations), len(run.New))
+
+	if len(run.New) > 0 {
+		title, body := auditMessage(p.Root, run)
+		send := s.Notify
+		if send == nil {
+			send = notifyAudit
+		}
+		send(title, body)
+	}
+	return run, nil
+}
+
+// auditMessage describes a run's new violations for a notification.
+func auditMessage(root string, run *audit.Run) (title, body string) {
+	title = fmt.Sprintf("veto found %d new violation(s) in %s", len(run.New), filepath.Base(root))
+	for i, f := range run.New {
+		if i == maxListed {
+			body += fmt.Sprintf("\n…and %d more; run: veto audit", len(run.New)-maxListed)
+			break
+		}
+		if i > 0 {
+			body += "\n"
+		}
+		body += fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Policy)
+	}
+	return title, body
+}
+
+// notifyAudit raises a desktop notification when the user turned them
+// on, outside focus mode and the quiet hours.
+func notifyAudit(title, body string) {
+	s, err := settings.Load()
+	if err != nil || s.Notify == nil || notify.Hushed(s.Notify, time.Now()) {
+		return
+	}
+	if err := notify.Send(title, body); err != nil {
+		log.Printf("notify: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestScheduledAudits(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".veto", `version: 2
+rules:
+  - description: no debugger statements
+    contentRules:
+      - pattern: '\bdebugger\b'
+        fileTypes: ["*.ts"]
+audit:
+  schedule: hourly
+`)
+	write("app.ts", "debugger;\n")
+
+	var sent []string
+	s := NewServer()
+	s.Notify = func(title, body string) { sent = append(sent, title+"\n"+body) }
+	if _, err := s.Check(&policy.CheckRequest{Action: "modify", Cwd: root, Target: "README.md"}); err != nil {
+		t.Fatal(err)
+	}
+	status := s.Status().Projects[0]
+	if status.AuditSchedule != "hourly" || status.NextAudit.IsZero() {
+		t.Fatalf("status = %+v, want an hourly audit scheduled", status)
+	}
+	next := status.NextAudit
+
+	// Not due yet
+	s.runDueAudits(next.Add(-time.Second))
+	if run, _ := audit.LastRun(root); run != nil {
+		t.Fatal("audited before the schedule")
+	}
+
+	// The first run reports what the baseline doesn't accept
+	s.runDueAudits(next)
+	if len(sent) != 1 || !strings.Contains(sent[0], "1 new violation") || !strings.Contains(sent[0], "app.ts:1") {
+		t.Fatalf("notifications = %q, want app.ts's violation", sent)
+	}
+	status = s.Status().Projects[0]
+	if !status.NextAudit.After(next) || status.LastAuditNew != 1 {
+		t.Errorf("status after audit = %+v", status)
+	}
+
+	// Unchanged, the next run has nothing new to say
+	next = status.NextAudit
+	s.runDueAudits(next)
+	if len(sent) != 1 {
+		t.Errorf("notified again without new violations: %q", sent[1:])
+	}
+
+	write("other.ts", "x()\ndebugger;\n")
+	next = s.Status().Projects[0].NextAudit
+	s.runDueAudits(next)
+	if len(sent) != 2 || !strings.Contains(sent[1], "other.ts:2") || strings.Contains(sent[1], "app.ts") {
+		t.Errorf("notifications = %q, want only other.ts's violation", sent)
+	}
+	run, err := audit.LastRun(root)
+	if err != nil || run == nil || len(run.Violations) != 2 || len(run.New) != 1 {
+		t.Errorf("last run = %+v, %v", run, err)
+	}
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Client talks to a running daemon.
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// Dial connects to the daemon on SocketPath.
+func Dial() (*Client, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(), time.Second)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return &Client{conn: conn, scanner: scanner}, nil
+}
+
+// Running reports whether a daemon is accepting connections.
+func Running() bool {
+	c, err := Dial()
+	if err != nil {
+		return false
+	}
+	c.Close()
+	return true
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Check asks the daemon to validate a request.
+func (c *Client) Check(req *policy.CheckRequest) (*policy.CheckResult, error) {
+	resp, err := c.do(Request{Op: OpCheck, Check: req})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// Status returns the daemon's loaded projects.
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.do(Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Stop asks the daemon to shut down.
+func (c *Client) Stop() error {
+	_, err := c.do(Request{Op: OpStop})
+	return err
+}
+
+func (c *Client) do(req Request) (*Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("daemon closed connection")
+	}
+
+	var resp Response
+	if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,450 @@
+// Package daemon runs a per-user background checker that serves many projects.
+//
+// Clients send newline-delimited JSON requests over a unix socket. Each check
+// carries the path it targets; the daemon resolves the governing .veto file,
+// caches the compiled policy set, and reloads it when the file changes.
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/VulnZap/veto/internal/schedule"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Operations understood by the daemon.
+const (
+	OpCheck  = "check"
+	OpStatus = "status"
+	OpStop   = "stop"
+)
+
+// Request is a single message sent to the daemon.
+type Request struct {
+	Op    string               `json:"op"`
+	Check *policy.CheckRequest `json:"check,omitempty"`
+}
+
+// Response is the daemon's reply to a Request.
+type Response struct {
+	Result *policy.CheckResult `json:"result,omitempty"`
+	Status *Status             `json:"status,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// Status describes a running daemon.
+type Status struct {
+	PID       int             `json:"pid"`
+	StartedAt time.Time       `json:"startedAt"`
+	Projects  []ProjectStatus `json:"projects"`
+}
+
+// ProjectStatus describes one loaded project.
+type ProjectStatus struct {
+	Root         string    `json:"root"`
+	Policies     int       `json:"policies"`
+	LoadedAt     time.Time `json:"loadedAt"`
+	LastActivity time.Time `json:"lastActivity"`
+	Checks       int       `json:"checks"`
+	Blocked      int       `json:"blocked"`
+	Monitored    int       `json:"monitored"`
+	// AuditSchedule is the project's audit.schedule, if it has one
+	AuditSchedule string `json:"auditSchedule,omitempty"`
+	// NextAudit is when the next scheduled audit runs
+	NextAudit time.Time `json:"nextAudit,omitempty"`
+	// LastAudit is when the last scheduled audit ran, and LastAuditNew
+	// how many new violations it found
+	LastAudit    time.Time `json:"lastAudit,omitempty"`
+	LastAuditNew int       `json:"lastAuditNew,omitempty"`
+}
+
+// SocketPath returns the per-user socket the daemon listens on.
+func SocketPath() string {
+	return filepath.Join(xdg.RuntimeDir(), "daemon.sock")
+}
+
+// Server resolves and caches project policy sets for incoming checks.
+type Server struct {
+	mu        sync.Mutex
+	projects  map[string]*entry // config path -> loaded project
+	startedAt time.Time
+	listener  net.Listener
+	// Notify reports new violations found by scheduled audits; nil
+	// raises a desktop notification when the user turned them on
+	Notify func(title, body string)
+}
+
+type entry struct {
+	project      *project.Project
+	loadedAt     time.Time
+	lastActivity time.Time
+	checks       int
+	blocked      int
+	monitored    int
+	// sessions holds per-session state, by session ID
+	sessions map[string]*session
+	// audits is the project's scheduled audit state, kept across reloads
+	audits *audits
+}
+
+// audits is when a project is audited and how the last audit went.
+// Fields are guarded by Server.mu.
+type audits struct {
+	// schedule is when to audit (nil for never) and next when that is
+	schedule *schedule.Schedule
+	next     time.Time
+	running  bool
+	// last is when the last audit ran and lastNew how many new
+	// violations it found
+	last    time.Time
+	lastNew int
+}
+
+// session is what the daemon remembers about one agent session.
+type session struct {
+	// files are the files the session changed, by absolute path, for
+	// change budgets
+	files map[string]bool
+	// renamed maps files the session renamed or moved to where they
+	// were first, by absolute path, so the original path's policies
+	// follow them
+	renamed  map[string]string
+	lastSeen time.Time
+}
+
+// sessionTTL is how long an idle session's state is kept.
+const sessionTTL = 24 * time.Hour
+
+// NewServer creates a daemon server with an empty project cache.
+func NewServer() *Server {
+	return &Server{
+		projects:  make(map[string]*entry),
+		startedAt: time.Now(),
+	}
+}
+
+// ListenAndServe listens on SocketPath and serves until stopped.
+func (s *Server) ListenAndServe() error {
+	path := SocketPath()
+	// Only the user may reach the socket, even when the directory
+	// already existed with looser permissions. Chmod fails on a
+	// directory another user owns.
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("securing %s: %w", dir, err)
+	}
+
+	// Refuse to steal the socket from a live daemon
+	if c, err := Dial(); err == nil {
+		c.Close()
+		return fmt.Errorf("daemon already running on %s", path)
+	}
+	os.Remove(path)
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Stop is called, and runs the
+// loaded projects' scheduled audits meanwhile.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.scheduleAudits(done)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Stop closes the listener, ending Serve.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		s.listener.Close()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	enc := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			enc.Encode(Response{Error: "invalid request: " + err.Error()})
+			continue
+		}
+
+		switch req.Op {
+		case OpCheck:
+			if req.Check == nil {
+				enc.Encode(Response{Error: "check request missing"})
+				continue
+			}
+			result, err := s.Check(req.Check)
+			if err != nil {
+				enc.Encode(Response{Error: err.Error()})
+				continue
+			}
+			enc.Encode(Response{Result: result})
+		case OpStatus:
+			status := s.Status()
+			enc.Encode(Response{Status: &status})
+		case OpStop:
+			enc.Encode(Response{})
+			s.Stop()
+			return
+		default:
+			enc.Encode(Response{Error: "unknown op: " + req.Op})
+		}
+	}
+}
+
+// Check resolves the project for a request and validates it.
+// Requests outside any project are allowed.
+func (s *Server) Check(req *policy.CheckRequest) (*policy.CheckResult, error) {
+	local := *req
+	local.Target = project.Absolute(local.Target, local.Cwd)
+	local.Destination = project.Absolute(local.Destination, local.Cwd)
+
+	dir := requestDir(&local)
+	if dir == "" {
+		return nil, fmt.Errorf("request needs an absolute target or cwd")
+	}
+
+	e, err := s.resolve(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &policy.CheckResult{Allowed: true}, nil
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	sess := e.session(local.SessionID)
+	budget := sess != nil && matcher.Changes(&local) && e.project.Set.TracksChanges()
+	if budget {
+		local.SessionFiles = len(sess.files)
+		if !sess.files[local.Target] {
+			local.SessionFiles++
+		}
+	}
+	origin := ""
+	if sess != nil {
+		origin = sess.renamed[local.Target]
+	}
+	s.mu.Unlock()
+
+	result := e.project.Check(&local)
+	if origin != "" && result.Allowed {
+		// A file renamed earlier in the session is still protected by
+		// the policies on the path it came from
+		original := local
+		original.Target = origin
+		if r := e.project.Check(&original); !r.Allowed || result.Decision == "" && r.Decision != "" {
+			r.Reason = fmt.Sprintf("%s (%s was renamed from %s)", r.Reason, e.relative(local.Target), e.relative(origin))
+			result = r
+		}
+	}
+	if local.Mode == policy.ModeUnattended {
+		log.Printf("%s: %s %s %s%s -> allowed=%t decision=%s policy=%q",
+			e.project.Root, local.Agent, local.Action, local.Command, local.Target,
+			result.Allowed, result.Decision, result.Policy)
+	}
+
+	s.mu.Lock()
+	e.lastActivity = time.Now()
+	e.checks++
+	if !result.Allowed {
+		e.blocked++
+	}
+	if len(result.Monitored) > 0 {
+		e.monitored++
+	}
+	// Changes going ahead, or put to a human, count against the budget
+	goesAhead := result.Allowed || result.Decision == policy.DecisionAsk
+	if budget && goesAhead {
+		sess.files[local.Target] = true
+	}
+	if sess != nil && goesAhead {
+		sess.recordMoves(&local)
+	}
+	s.mu.Unlock()
+
+	return result, nil
+}
+
+// session returns the state of a session, or nil for requests without
+// one. Callers hold s.mu.
+func (e *entry) session(id string) *session {
+	if id == "" {
+		return nil
+	}
+	now := time.Now()
+	if e.sessions == nil {
+		e.sessions = make(map[string]*session)
+	}
+	sess, ok := e.sessions[id]
+	if !ok {
+		for other, old := range e.sessions {
+			if now.Sub(old.lastSeen) > sessionTTL {
+				delete(e.sessions, other)
+			}
+		}
+		sess = &session{files: make(map[string]bool), renamed: make(map[string]string)}
+		e.sessions[id] = sess
+	}
+	sess.lastSeen = now
+	return sess
+}
+
+// recordMoves remembers the files a request renames or moves, directly
+// or with mv in a command, keyed by where they end up. A file moved
+// again keeps its first path. Callers hold s.mu.
+func (sess *session) recordMoves(req *policy.CheckRequest) {
+	record := func(from, to string) {
+		if origin, ok := sess.renamed[from]; ok {
+			delete(sess.renamed, from)
+			from = origin
+		}
+		if from != to {
+			sess.renamed[to] = from
+		}
+	}
+	switch policy.Action(req.Action) {
+	case policy.ActionRename, policy.ActionMove:
+		if req.Target != "" && req.Destination != "" {
+			record(req.Target, req.Destination)
+		}
+	}
+	if req.Command != "" && filepath.IsAbs(req.Cwd) {
+		for _, f := range matcher.Analyze(req.Command).Files {
+			if f.Action == policy.ActionRename || f.Action == policy.ActionMove {
+				record(project.Absolute(f.Path, req.Cwd), project.Absolute(f.To, req.Cwd))
+			}
+		}
+	}
+}
+
+// relative returns path relative to the project root, for messages.
+func (e *entry) relative(path string) string {
+	if rel, err := filepath.Rel(e.project.Root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// resolve returns the cached project for dir, loading or reloading as needed.
+func (s *Server) resolve(dir string) (*entry, error) {
+	path, err := project.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.projects[path]
+	if ok && !old.project.Stale() {
+		return old, nil
+	}
+
+	p, err := project.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	e := &entry{project: p, loadedAt: now, lastActivity: now, audits: &audits{}}
+	if ok {
+		e.checks = old.checks
+		e.blocked = old.blocked
+		e.monitored = old.monitored
+		e.sessions = old.sessions
+		e.audits = old.audits
+	}
+	e.audits.reschedule(p.Config.Audit, now)
+	s.projects[path] = e
+	return e, nil
+}
+
+// Status reports the daemon's loaded projects, most recently active first.
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{PID: os.Getpid(), StartedAt: s.startedAt}
+	for _, e := range s.projects {
+		status.Projects = append(status.Projects, ProjectStatus{
+			Root:         e.project.Root,
+			Policies:     len(e.project.Policies),
+			LoadedAt:     e.loadedAt,
+			LastActivity: e.lastActivity,
+			Checks:       e.checks,
+			Blocked:      e.blocked,
+			Monitored:    e.monitored,
+			NextAudit:    e.audits.next,
+			LastAudit:    e.audits.last,
+			LastAuditNew: e.audits.lastNew,
+		})
+		if e.audits.schedule != nil {
+			status.Projects[len(status.Projects)-1].AuditSchedule = e.audits.schedule.String()
+		}
+	}
+	sort.Slice(status.Projects, func(i, j int) bool {
+		return status.Projects[i].LastActivity.After(status.Projects[j].LastActivity)
+	})
+	return status
+}
+
+// requestDir picks the directory used to locate a request's project.
+func requestDir(req *policy.CheckRequest) string {
+	if req.Target != "" && filepath.IsAbs(req.Target) {
+		return filepath.Dir(req.Target)
+	}
+	if req.Cwd != "" && filepath.IsAbs(req.Cwd) {
+		return req.Cwd
+	}
+	return ""
+}
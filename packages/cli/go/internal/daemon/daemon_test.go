@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+func TestServerChecksEachProject(t *testing.T) {
+	guarded, open := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(guarded, ".veto"), []byte("protect .env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(open, ".veto"), []byte("prefer pnpm\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	check := func(req policy.CheckRequest) *policy.CheckResult {
+		t.Helper()
+		result, err := s.Check(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if r := check(policy.CheckRequest{Action: "modify", Target: filepath.Join(guarded, ".env")}); r.Allowed {
+		t.Errorf("guarded .env: %+v, want blocked", r)
+	}
+	if r := check(policy.CheckRequest{Action: "modify", Target: filepath.Join(open, ".env")}); !r.Allowed {
+		t.Errorf("open .env: %+v, want allowed", r)
+	}
+	// Relative targets resolve against the agent's working directory
+	if r := check(policy.CheckRequest{Action: "modify", Target: ".env", Cwd: guarded}); r.Allowed {
+		t.Errorf("relative .env: %+v, want blocked", r)
+	}
+	if _, err := s.Check(&policy.CheckRequest{Action: "modify", Target: ".env"}); err == nil {
+		t.Error("relative target without cwd: want an error")
+	}
+
+	status := s.Status()
+	if len(status.Projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(status.Projects))
+	}
+	for _, p := range status.Projects {
+		if p.Root == guarded && (p.Checks != 2 || p.Blocked != 2) {
+			t.Errorf("guarded project: %+v, want 2 checks, 2 blocked", p)
+		}
+	}
+}
+
+func TestRenamedFilesStayProtected(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	veto := "version: 1\npolicies:\n  - policy: protect .env\n    decision: ask\n"
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte(veto), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	check := func(req policy.CheckRequest) *policy.CheckResult {
+		t.Helper()
+		req.Cwd = root
+		result, err := s.Check(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// Approved by a human, the rename goes ahead
+	if r := check(policy.CheckRequest{SessionID: "s1", Action: "rename", Target: ".env", Destination: "env.bak"}); r.Decision != policy.DecisionAsk {
+		t.Fatalf("rename: %+v, want ask", r)
+	}
+	r := check(policy.CheckRequest{SessionID: "s1", Action: "modify", Target: "env.bak"})
+	if r.Allowed || !strings.Contains(r.Reason, "env.bak was renamed from .env") {
+		t.Errorf("edit after rename: %+v, want .env's policy", r)
+	}
+	// Moved on with mv, it keeps the first path's protection
+	check(policy.CheckRequest{SessionID: "s1", Action: "execute", Command: "mv env.bak backup/secrets"})
+	if r := check(policy.CheckRequest{SessionID: "s1", Action: "modify", Target: "backup/secrets"}); r.Allowed {
+		t.Errorf("edit after mv: %+v, want .env's policy", r)
+	}
+
+	// Other sessions didn't rename anything
+	if r := check(policy.CheckRequest{SessionID: "s2", Action: "modify", Target: "env.bak"}); !r.Allowed {
+		t.Errorf("other session: %+v, want allowed", r)
+	}
+}
+
+func TestSubdirectoryRequestsMatchInProcess(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("protect dir: infra/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"infra", "docs"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewServer()
+
+	tests := []struct {
+		name    string
+		req     policy.CheckRequest
+		allowed bool
+	}{
+		{"target in the protected dir", policy.CheckRequest{Action: "modify", Target: "main.tf", Cwd: filepath.Join(root, "infra")}, false},
+		{"destination in the protected dir", policy.CheckRequest{Action: "move", Target: "a.md", Destination: "../infra/a.md", Cwd: filepath.Join(root, "docs")}, false},
+		{"target elsewhere", policy.CheckRequest{Action: "modify", Target: "main.tf", Cwd: filepath.Join(root, "docs")}, true},
+	}
+	for _, tt := range tests {
+		req := tt.req
+		daemon, err := s.Check(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = tt.req
+		inProcess, err := project.CheckFrom(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if daemon.Allowed != tt.allowed || inProcess.Allowed != tt.allowed {
+			t.Errorf("%s: daemon allowed=%v, in-process allowed=%v, want %v", tt.name, daemon.Allowed, inProcess.Allowed, tt.allowed)
+		}
+	}
+}
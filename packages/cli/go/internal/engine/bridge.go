@@ -1,25 +1,14 @@
-// Package engine bridges to the TypeScript engine for complex operations.
+// Package engine bridges to the TypeScript engine for the commands not
+// yet ported to Go. Policies compile natively, in package compile.
 package engine
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 )
 
-// CompileResult is the result of compiling a policy.
-type CompileResult struct {
-	Success     bool   `json:"success"`
-	Policy      string `json:"policy,omitempty"`
-	Description string `json:"description,omitempty"`
-	Error       string `json:"error,omitempty"`
-	IsBuiltin   bool   `json:"isBuiltin,omitempty"`
-}
-
 // SyncResult is the result of syncing policies to an agent.
 type SyncResult struct {
 	Success bool   `json:"success"`
@@ -73,7 +62,7 @@ func NewBridge() (*Bridge, error) {
 	// Find node
 	nodeCmd := "node"
 	if _, err := exec.LookPath("node"); err != nil {
-		return nil, fmt.Errorf("Node.js required for policy compilation. Install from https://nodejs.org")
+		return nil, fmt.Errorf("Node.js required for this command. Install from https://nodejs.org")
 	}
 
 	return &Bridge{
@@ -82,53 +71,6 @@ func NewBridge() (*Bridge, error) {
 	}, nil
 }
 
-// Compile compiles a policy restriction using the TypeScript engine.
-func (b *Bridge) Compile(restriction string) (*CompileResult, error) {
-	// Create a small Node script that compiles and outputs JSON
-	script := fmt.Sprintf(`
-		const { compile } = require('%s/compiler/index.js');
-		(async () => {
-			try {
-				const policy = await compile(%s);
-				console.log(JSON.stringify({
-					success: true,
-					policy: %s,
-					description: policy.description,
-					isBuiltin: policy._builtin || false
-				}));
-			} catch (err) {
-				console.log(JSON.stringify({
-					success: false,
-					error: err.message
-				}));
-			}
-		})();
-	`, b.distDir, jsonString(restriction), jsonString(restriction))
-
-	cmd := exec.Command(b.nodeCmd, "-e", script)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		// Check if it's a missing API key error
-		if strings.Contains(stderr.String(), "GEMINI_API_KEY") {
-			return &CompileResult{
-				Success: false,
-				Error:   "GEMINI_API_KEY not set. Get a free key at https://aistudio.google.com/apikey",
-			}, nil
-		}
-		return nil, fmt.Errorf("compilation failed: %s", stderr.String())
-	}
-
-	var result CompileResult
-	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse result: %v", err)
-	}
-
-	return &result, nil
-}
-
 // Add adds a policy using the TypeScript CLI.
 func (b *Bridge) Add(restriction string) error {
 	cmd := exec.Command(b.nodeCmd, filepath.Join(b.distDir, "cli.js"), "add", restriction)
@@ -201,9 +143,3 @@ func (b *Bridge) Audit(args []string) error {
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
-
-// jsonString escapes a string for use in JavaScript.
-func jsonString(s string) string {
-	b, _ := json.Marshal(s)
-	return string(b)
-}
@@ -0,0 +1,184 @@
+// Package events runs the commands a project's hooks: section sets for
+// veto events, so users can wire up integrations veto doesn't ship.
+//
+// Each command runs with sh in the project root. It gets the event as JSON
+// on stdin and its fields as VETO_* environment variables (VETO_EVENT,
+// VETO_PROJECT, VETO_POLICY and so on; lists are comma-separated).
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Events, named as in .veto.
+const (
+	OnBlock       = "on_block"
+	OnSync        = "on_sync"
+	OnPolicyAdded = "on_policy_added"
+)
+
+// Timeout bounds each command. on_block commands run while the agent
+// waits for its hook reply, so they should be quick or background
+// themselves.
+const Timeout = 10 * time.Second
+
+// blockTimeout bounds an on_block event's commands together, keeping
+// them, after any command veto hook ran in place of the blocked one,
+// inside the agent's hook timeout. Commands left when it runs out are
+// skipped.
+var blockTimeout = 5 * time.Second
+
+// Event is what hook commands are told about.
+type Event struct {
+	Name    string    `json:"event"`
+	Project string    `json:"project"`
+	Time    time.Time `json:"time"`
+
+	// The blocked request (on_block)
+	Agent    string          `json:"agent,omitempty"`
+	Session  string          `json:"session,omitempty"`
+	Action   string          `json:"action,omitempty"`
+	Command  string          `json:"command,omitempty"`
+	File     string          `json:"file,omitempty"`
+	Decision policy.Decision `json:"decision,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+	Suggest  string          `json:"suggest,omitempty"`
+
+	// Policy blocked the request (on_block) or was added (on_policy_added)
+	Policy string `json:"policy,omitempty"`
+
+	// Agents were synced, or failed to (on_sync)
+	Agents []string `json:"agents,omitempty"`
+	Failed []string `json:"failed,omitempty"`
+	Scope  string   `json:"scope,omitempty"`
+}
+
+// Block returns the on_block event for a request that wasn't allowed.
+func Block(req *policy.CheckRequest, result *policy.CheckResult) Event {
+	return Event{
+		Name:     OnBlock,
+		Agent:    req.Agent,
+		Session:  req.SessionID,
+		Action:   req.Action,
+		Command:  req.Command,
+		File:     req.Target,
+		Decision: result.Decision,
+		Reason:   result.Reason,
+		Suggest:  result.Suggest,
+		Policy:   result.Policy,
+	}
+}
+
+// env returns the event's fields as environment variables.
+func (e Event) env() []string {
+	vars := []struct{ name, value string }{
+		{"EVENT", e.Name},
+		{"PROJECT", e.Project},
+		{"TIME", e.Time.Format(time.RFC3339)},
+		{"AGENT", e.Agent},
+		{"SESSION", e.Session},
+		{"ACTION", e.Action},
+		{"COMMAND", e.Command},
+		{"FILE", e.File},
+		{"DECISION", string(e.Decision)},
+		{"REASON", e.Reason},
+		{"SUGGEST", e.Suggest},
+		{"POLICY", e.Policy},
+		{"AGENTS", strings.Join(e.Agents, ",")},
+		{"FAILED", strings.Join(e.Failed, ",")},
+		{"SCOPE", e.Scope},
+	}
+	var env []string
+	for _, v := range vars {
+		if v.value != "" {
+			env = append(env, "VETO_"+v.name+"="+v.value)
+		}
+	}
+	return env
+}
+
+// Fire runs the commands the .veto at root sets for the event, one after
+// another, on_block's within blockTimeout in all. A project without
+// hooks for it runs nothing. The error
+// describes every command that failed, with the end of its output.
+func Fire(root string, e Event) error {
+	cfg, err := config.Load(filepath.Join(root, ".veto"))
+	if err != nil || cfg.Hooks == nil {
+		return nil
+	}
+	var commands config.Commands
+	switch e.Name {
+	case OnBlock:
+		commands = cfg.Hooks.OnBlock
+	case OnSync:
+		commands = cfg.Hooks.OnSync
+	case OnPolicyAdded:
+		commands = cfg.Hooks.OnPolicyAdded
+	default:
+		return fmt.Errorf("unknown event %q", e.Name)
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	e.Project = root
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if e.Name == OnBlock {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, blockTimeout)
+		defer cancel()
+	}
+	var errs []error
+	for _, command := range commands {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: skipped, out of time after %s", e.Name, command, blockTimeout))
+			continue
+		}
+		if err := run(ctx, root, command, data, e.env()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", e.Name, command, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func run(ctx context.Context, root, command string, stdin []byte, env []string) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = root
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), env...)
+	// Don't wait on children of the command that outlive it
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("timed out after %s", time.Since(start).Round(100*time.Millisecond))
+	case err == nil:
+		return nil
+	}
+	if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); lines[0] != "" {
+		return fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func project(t *testing.T, veto string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte(veto), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestFireBlock(t *testing.T) {
+	root := project(t, "version: 1\nhooks:\n  on_block: cat > event.json; echo \"$VETO_EVENT $VETO_POLICY $VETO_COMMAND\" > env.txt\npolicies: [no force push]\n")
+	req := &policy.CheckRequest{Agent: "claude-code", Action: "execute", Command: "git push --force"}
+	result := &policy.CheckResult{Decision: policy.DecisionDeny, Policy: "no force push", Reason: "rewrites history"}
+	if err := Fire(root, Block(req, result)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != OnBlock || e.Project != root || e.Command != "git push --force" || e.Reason != "rewrites history" || e.Time.IsZero() {
+		t.Errorf("stdin event = %+v", e)
+	}
+	env, err := os.ReadFile(filepath.Join(root, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(env)), "on_block no force push git push --force"; got != want {
+		t.Errorf("env = %q, want %q", got, want)
+	}
+}
+
+func TestFireOnlyMatchingEvent(t *testing.T) {
+	root := project(t, "version: 1\nhooks:\n  on_sync: [touch synced]\npolicies: [no lodash]\n")
+	if err := Fire(root, Event{Name: OnPolicyAdded, Policy: "no lodash"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "synced")); !os.IsNotExist(err) {
+		t.Error("on_sync hook ran for on_policy_added")
+	}
+	if err := Fire(root, Event{Name: OnSync, Agents: []string{"cursor"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "synced")); err != nil {
+		t.Error("on_sync hook didn't run")
+	}
+}
+
+func TestFireReportsFailures(t *testing.T) {
+	root := project(t, "version: 1\nhooks:\n  on_policy_added: [echo boom >&2; exit 3, touch after]\npolicies: [no lodash]\n")
+	err := Fire(root, Event{Name: OnPolicyAdded, Policy: "no lodash"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Fire() = %v, want the failing command's output", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "after")); err != nil {
+		t.Error("a failing command stopped the ones after it")
+	}
+}
+
+func TestFireBlockWithinBudget(t *testing.T) {
+	old := blockTimeout
+	blockTimeout = 300 * time.Millisecond
+	t.Cleanup(func() { blockTimeout = old })
+
+	root := project(t, "version: 1\nhooks:\n  on_block: [sleep 5, touch after]\npolicies: [no lodash]\n")
+	start := time.Now()
+	err := Fire(root, Event{Name: OnBlock, Policy: "no lodash"})
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("Fire() took %s, want it cut off after %s", took, blockTimeout)
+	}
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "skipped") {
+		t.Errorf("Fire() = %v, want the slow command timed out and the next skipped", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "after")); err == nil {
+		t.Error("a command ran after the budget ran out")
+	}
+}
+
+func TestFireWithoutHooks(t *testing.T) {
+	if err := Fire(project(t, "policies: [no lodash]\n"), Event{Name: OnBlock}); err != nil {
+		t.Errorf("Fire() = %v", err)
+	}
+	if err := Fire(t.TempDir(), Event{Name: OnBlock}); err != nil {
+		t.Errorf("Fire() without .veto = %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package githook installs the git pre-commit hook that runs `veto verify
+// --staged`, so content the policies forbid is caught at commit time even
+// when an agent got it past the runtime checks (a tool without hooks, a
+// shell veto doesn't see, a human pasting it in).
+package githook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// Marker identifies a hook veto wrote, so it is replaced or removed only
+// when it is veto's.
+const Marker = "# Installed by veto"
+
+// ErrNotInstalled is returned by Uninstall when there is no veto hook.
+var ErrNotInstalled = errors.New("no veto pre-commit hook installed")
+
+// Path returns the pre-commit hook of the git repository holding root,
+// wherever core.hooksPath puts it.
+func Path(root string) (string, error) {
+	out, err := git(root, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return path, nil
+}
+
+// Script returns the hook for the project at root, which it runs from
+// the repository's top level, as git runs hooks.
+func Script(root string) (string, error) {
+	top, err := git(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		// git reports the top level with symlinks resolved
+		root = resolved
+	}
+	verify := "veto verify --staged"
+	if rel, err := filepath.Rel(strings.TrimSpace(top), root); err == nil && rel != "." {
+		verify += " '" + strings.ReplaceAll(filepath.ToSlash(rel), "'", `'\''`) + "'"
+	}
+	return `#!/bin/sh
+` + Marker + `: checks staged changes against the project's policies.
+# Remove it with: veto uninstall git
+if ! command -v veto >/dev/null 2>&1; then
+	echo "veto: not found on PATH, so the commit can't be checked (git commit --no-verify skips the check)" >&2
+	exit 1
+fi
+exec ` + verify + `
+`, nil
+}
+
+// Install writes the pre-commit hook for the project at root and
+// returns its path. A hook veto didn't write is left alone.
+func Install(root string) (string, error) {
+	path, err := Path(root)
+	if err != nil {
+		return "", err
+	}
+	if data, err := os.ReadFile(path); err == nil && !bytes.Contains(data, []byte(Marker)) {
+		return "", fmt.Errorf("%s already exists; add `veto verify --staged` to it", path)
+	}
+	script, err := Script(root)
+	if err != nil {
+		return "", err
+	}
+	if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := readonly.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Uninstall removes the pre-commit hook veto wrote for the project at
+// root and returns its path.
+func Uninstall(root string) (string, error) {
+	path, err := Path(root)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.Contains(data, []byte(Marker)) {
+		return "", ErrNotInstalled
+	}
+	return path, readonly.Remove(path)
+}
+
+// git runs git in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
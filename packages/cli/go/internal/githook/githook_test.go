@@ -0,0 +1,93 @@
+package githook
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitInit(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	if out, err := exec.Command("git", "-C", root, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	return root
+}
+
+func TestInstall(t *testing.T) {
+	root := gitInit(t)
+	project := filepath.Join(root, "apps", "web")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := Install(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mustResolve(t, filepath.Dir(path)), mustResolve(t, filepath.Join(root, ".git", "hooks")); got != want || filepath.Base(path) != "pre-commit" {
+		t.Errorf("hook at %s, want pre-commit in %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "exec veto verify --staged 'apps/web'\n") {
+		t.Errorf("hook doesn't verify the project:\n%s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode()&0111 == 0 {
+		t.Error("hook isn't executable")
+	}
+
+	// Reinstalling replaces veto's hook
+	if _, err := Install(project); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if _, err := Uninstall(project); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("hook not removed")
+	}
+	if _, err := Uninstall(project); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("second uninstall = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestInstallKeepsOtherHooks(t *testing.T) {
+	root := gitInit(t)
+	path, err := Path(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nnpm run lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(root); err == nil {
+		t.Error("Install replaced a hook veto didn't write")
+	}
+	if _, err := Uninstall(root); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Uninstall = %v, want ErrNotInstalled", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "#!/bin/sh\nnpm run lint\n" {
+		t.Errorf("hook changed:\n%s", data)
+	}
+}
+
+func mustResolve(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
@@ -0,0 +1,167 @@
+package hookproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// claudeCodePayload is a PreToolUse or PostToolUse payload. Version 1
+// sends only the session, transcript and tool; version 2 adds the event
+// name, working directory and permission mode, reads its PreToolUse
+// decision from hookSpecificOutput, and sends PostToolUse with the tool's
+// response.
+type claudeCodePayload struct {
+	SessionID      string          `json:"session_id"`
+	TranscriptPath string          `json:"transcript_path"`
+	Cwd            string          `json:"cwd,omitempty"`
+	HookEventName  string          `json:"hook_event_name,omitempty"`
+	PermissionMode string          `json:"permission_mode,omitempty"`
+	ToolName       string          `json:"tool_name"`
+	ToolInput      json.RawMessage `json:"tool_input"`
+	ToolResponse   json.RawMessage `json:"tool_response,omitempty"`
+}
+
+// claudeCodeToolInput covers the fields of the tools veto enforces.
+type claudeCodeToolInput struct {
+	Command    string `json:"command"`
+	FilePath   string `json:"file_path"`
+	Content    string `json:"content"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+	Edits      []struct {
+		OldString  string `json:"old_string"`
+		NewString  string `json:"new_string"`
+		ReplaceAll bool   `json:"replace_all"`
+	} `json:"edits"`
+}
+
+func sniffClaudeCode(fields map[string]json.RawMessage) bool {
+	return has(fields, "session_id", "tool_name", "tool_input")
+}
+
+var claudeCodeV1 = &Adapter{
+	Agent:   "claude-code",
+	Version: "1",
+	Fields:  []string{"session_id", "transcript_path", "tool_name", "tool_input"},
+	detect: func(fields map[string]json.RawMessage) bool {
+		return !has(fields, "hook_event_name")
+	},
+	parse: func(data []byte) (*Hook, error) {
+		return parseClaudeCode(data, "1")
+	},
+	respond: func(h *Hook, r *policy.CheckResult) interface{} {
+		// Version 1 only understands approve and block
+		if r.Allowed {
+			return map[string]string{"decision": "approve"}
+		}
+		return map[string]string{"decision": "block", "reason": message.Hook(r)}
+	},
+}
+
+var claudeCodeV2 = &Adapter{
+	Agent:   "claude-code",
+	Version: "2",
+	Fields: []string{"session_id", "transcript_path", "cwd", "hook_event_name",
+		"permission_mode", "tool_name", "tool_input", "tool_response"},
+	detect: func(fields map[string]json.RawMessage) bool {
+		return has(fields, "hook_event_name", "cwd")
+	},
+	parse: func(data []byte) (*Hook, error) {
+		return parseClaudeCode(data, "2")
+	},
+	respond: func(h *Hook, r *policy.CheckResult) interface{} {
+		// The tool already ran; a block feeds the reason back to Claude
+		if h.Event == "PostToolUse" {
+			if r.Allowed && r.Hints != "" {
+				return map[string]interface{}{"hookSpecificOutput": map[string]string{
+					"hookEventName":     h.Event,
+					"additionalContext": r.Hints,
+				}}
+			}
+			if r.Allowed {
+				return map[string]string{}
+			}
+			return map[string]string{"decision": "block", "reason": message.Hook(r)}
+		}
+		out := map[string]interface{}{
+			"hookEventName":      h.Event,
+			"permissionDecision": "allow",
+		}
+		switch {
+		case !r.Allowed && r.Rewrite != "":
+			// Claude runs the rewritten command instead
+			out["permissionDecisionReason"] = "veto rewrote the command to `" + r.Rewrite + "`: " + r.Reason
+			out["updatedInput"] = rewritten(h.Input, r.Rewrite)
+		case !r.Allowed && r.Decision == policy.DecisionAsk:
+			out["permissionDecision"] = "ask"
+			out["permissionDecisionReason"] = message.Hook(r)
+		case !r.Allowed:
+			out["permissionDecision"] = "deny"
+			out["permissionDecisionReason"] = message.Hook(r)
+		}
+		return map[string]interface{}{"hookSpecificOutput": out}
+	},
+	rewrites: true,
+}
+
+// rewritten returns a Bash tool input with its command replaced, keeping
+// the other fields (description, timeout) as sent.
+func rewritten(input json.RawMessage, command string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	json.Unmarshal(input, &fields) // decoded once already by parse
+	fields["command"] = command
+	return fields
+}
+
+func parseClaudeCode(data []byte, version string) (*Hook, error) {
+	var p claudeCodePayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("claude-code v%s hook payload: %w", version, err)
+	}
+	event := p.HookEventName
+	if event == "" {
+		event = "PreToolUse"
+	}
+	h := &Hook{Agent: "claude-code", Version: version, Event: event, Tool: p.ToolName, Input: p.ToolInput}
+
+	var in claudeCodeToolInput
+	if len(p.ToolInput) > 0 {
+		// Tool inputs change with every tool, so extra fields are fine
+		if err := json.Unmarshal(p.ToolInput, &in); err != nil {
+			return nil, fmt.Errorf("claude-code v%s hook payload: tool_input: %w", version, err)
+		}
+	}
+
+	req := policy.CheckRequest{Agent: "claude-code", SessionID: p.SessionID, Cwd: p.Cwd}
+	done := event == "PostToolUse"
+	switch p.ToolName {
+	case "Bash":
+		req.Action, req.Command = string(policy.ActionExecute), in.Command
+	case "Write":
+		req.Action, req.Target, req.Content = string(policy.ActionModify), in.FilePath, in.Content
+		req.Resize = written(in.Content, done)
+	case "Edit":
+		req.Action, req.Target, req.Content = string(policy.ActionModify), in.FilePath, in.NewString
+		req.Resize = edited(done, edit{in.OldString, in.NewString, in.ReplaceAll})
+	case "MultiEdit":
+		var parts []string
+		var edits []edit
+		for _, e := range in.Edits {
+			parts = append(parts, e.NewString)
+			edits = append(edits, edit{e.OldString, e.NewString, e.ReplaceAll})
+		}
+		req.Action, req.Target, req.Content = string(policy.ActionModify), in.FilePath, strings.Join(parts, "\n")
+		req.Resize = edited(done, edits...)
+	case "Read":
+		req.Action, req.Target = string(policy.ActionRead), in.FilePath
+	default:
+		return h, nil
+	}
+	h.Request = req
+	return h, nil
+}
@@ -0,0 +1,96 @@
+package hookproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// cursorPayload is the union of Cursor's hook events. Every event carries
+// the conversation and workspace; the rest depends on hook_event_name.
+type cursorPayload struct {
+	HookEventName  string          `json:"hook_event_name"`
+	ConversationID string          `json:"conversation_id"`
+	GenerationID   string          `json:"generation_id"`
+	WorkspaceRoots []string        `json:"workspace_roots"`
+	Command        string          `json:"command,omitempty"`
+	Cwd            string          `json:"cwd,omitempty"`
+	FilePath       string          `json:"file_path,omitempty"`
+	Content        string          `json:"content,omitempty"`
+	Attachments    json.RawMessage `json:"attachments,omitempty"`
+	Edits          []struct {
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	} `json:"edits,omitempty"`
+	ToolName  string          `json:"tool_name,omitempty"`
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+	URL       string          `json:"url,omitempty"`
+}
+
+func sniffCursor(fields map[string]json.RawMessage) bool {
+	return has(fields, "conversation_id") || has(fields, "workspace_roots")
+}
+
+var cursorV1 = &Adapter{
+	Agent:   "cursor",
+	Version: "1",
+	Fields: []string{"hook_event_name", "conversation_id", "generation_id", "workspace_roots",
+		"command", "cwd", "file_path", "content", "attachments", "edits", "tool_name", "tool_input", "url"},
+	detect: func(fields map[string]json.RawMessage) bool {
+		return has(fields, "hook_event_name", "conversation_id")
+	},
+	parse: parseCursor,
+	respond: func(h *Hook, r *policy.CheckResult) interface{} {
+		out := map[string]string{"permission": "allow"}
+		switch {
+		case !r.Allowed && r.Decision == policy.DecisionAsk:
+			out["permission"] = "ask"
+		case !r.Allowed:
+			out["permission"] = "deny"
+		}
+		if !r.Allowed {
+			out["user_message"] = message.Hook(r)
+			out["agent_message"] = message.Hook(r)
+		}
+		return out
+	},
+}
+
+func parseCursor(data []byte) (*Hook, error) {
+	var p cursorPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("cursor v1 hook payload: %w", err)
+	}
+	h := &Hook{Agent: "cursor", Version: "1", Event: p.HookEventName, Tool: p.ToolName}
+
+	cwd := p.Cwd
+	if cwd == "" && len(p.WorkspaceRoots) > 0 {
+		cwd = p.WorkspaceRoots[0]
+	}
+	req := policy.CheckRequest{Agent: "cursor", SessionID: p.ConversationID, Cwd: cwd}
+	switch p.HookEventName {
+	case "beforeShellExecution":
+		req.Action, req.Command = string(policy.ActionExecute), p.Command
+	case "beforeReadFile":
+		req.Action, req.Target = string(policy.ActionRead), p.FilePath
+	case "afterFileEdit":
+		var parts []string
+		var edits []edit
+		for _, e := range p.Edits {
+			parts = append(parts, e.NewString)
+			edits = append(edits, edit{old: e.OldString, new: e.NewString})
+		}
+		req.Action, req.Target, req.Content = string(policy.ActionModify), p.FilePath, strings.Join(parts, "\n")
+		// The edit is already on disk
+		req.Resize = edited(true, edits...)
+	case "beforeMCPExecution", "beforeSubmitPrompt", "stop":
+		return h, nil
+	default:
+		return nil, fmt.Errorf("cursor v1 hook payload: unknown hook_event_name %q", p.HookEventName)
+	}
+	h.Request = req
+	return h, nil
+}
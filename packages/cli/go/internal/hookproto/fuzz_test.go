@@ -0,0 +1,43 @@
+package hookproto
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// FuzzParse feeds arbitrary payloads to the hook parser, seeded with the
+// golden payloads. Anything an agent sends must either parse into a hook
+// that can be answered with valid JSON or be rejected with an error.
+func FuzzParse(f *testing.F) {
+	payloads, _ := filepath.Glob("testdata/*.json")
+	for _, path := range payloads {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, "")
+	}
+	f.Add([]byte(`{"tool_name":"Bash","tool_input":null}`), "claude-code")
+	f.Add([]byte(`[]`), "cursor")
+
+	deny := &policy.CheckResult{Reason: "blocked", Decision: policy.DecisionDeny}
+	f.Fuzz(func(t *testing.T, data []byte, agent string) {
+		h, err := Parse(data, agent)
+		if err != nil {
+			return
+		}
+		for _, r := range []*policy.CheckResult{{Allowed: true}, deny} {
+			reply, err := h.Respond(r)
+			if err != nil {
+				t.Fatalf("parsed %s %s hook can't be answered: %v", h.Agent, h.Version, err)
+			}
+			if !json.Valid(reply) {
+				t.Fatalf("invalid reply %q", reply)
+			}
+		}
+	})
+}
@@ -0,0 +1,231 @@
+// Package hookproto parses the JSON payloads agent hooks send to veto and
+// formats the responses they expect. Each agent's schema is handled by
+// versioned adapters, detected by the fields each version must send, so
+// a release that drops or renames those fails with a descriptive error
+// instead of being silently misread. Fields a release adds are ignored.
+package hookproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Hook is a parsed hook payload.
+type Hook struct {
+	// Agent and Version identify the adapter that parsed the payload
+	Agent   string
+	Version string
+	// Event is the hook event (e.g., "PreToolUse", "beforeShellExecution")
+	Event string
+	// Tool is the agent's name for the tool being run ("" for shell hooks)
+	Tool string
+	// Request is the action to check; empty when the tool isn't enforced
+	Request policy.CheckRequest
+	// Input is the tool's input as sent, for replies that change it
+	Input json.RawMessage
+}
+
+// Adapter parses one version of an agent's hook payload.
+type Adapter struct {
+	Agent   string
+	Version string
+	// Fields are the top-level fields this version is known to send;
+	// others are ignored, and only reported when no version matches
+	Fields []string
+	// detect reports whether a payload's fields belong to this version
+	detect func(fields map[string]json.RawMessage) bool
+	// parse decodes a payload already known to match this version
+	parse func(data []byte) (*Hook, error)
+	// respond builds the reply the agent expects for a result
+	respond func(h *Hook, r *policy.CheckResult) interface{}
+	// rewrites is set when the reply can change the command the agent
+	// runs, for results with a Rewrite
+	rewrites bool
+}
+
+// schema groups the adapters of one agent.
+type schema struct {
+	agent string
+	// sniff reports whether a payload comes from this agent at all
+	sniff    func(fields map[string]json.RawMessage) bool
+	adapters []*Adapter
+}
+
+// schemas lists supported agents, newest adapter first.
+var schemas = []schema{
+	{"claude-code", sniffClaudeCode, []*Adapter{claudeCodeV2, claudeCodeV1}},
+	{"cursor", sniffCursor, []*Adapter{cursorV1}},
+	{"opencode", sniffOpenCode, []*Adapter{openCodeV1}},
+}
+
+// Adapters returns every supported adapter.
+func Adapters() []*Adapter {
+	var all []*Adapter
+	for _, s := range schemas {
+		all = append(all, s.adapters...)
+	}
+	return all
+}
+
+// UnknownVersionError reports a payload from a known agent whose shape
+// matches none of the supported versions.
+type UnknownVersionError struct {
+	Agent string
+	// Fields are the payload's top-level fields
+	Fields []string
+	// Unexpected are fields no supported version sends
+	Unexpected []string
+	Supported  []string
+}
+
+func (e *UnknownVersionError) Error() string {
+	msg := fmt.Sprintf("unsupported %s hook payload (supported versions: %s)",
+		e.Agent, strings.Join(e.Supported, ", "))
+	if len(e.Unexpected) > 0 {
+		msg += fmt.Sprintf(": unexpected fields %s", strings.Join(e.Unexpected, ", "))
+	} else {
+		msg += fmt.Sprintf(": got fields %s", strings.Join(e.Fields, ", "))
+	}
+	return msg + "; the agent may be newer than this veto, try `veto update`"
+}
+
+// Parse detects which agent and version sent a hook payload and parses
+// it, ignoring fields the version isn't known to send. A non-empty agent
+// restricts detection to that agent's schemas.
+func Parse(data []byte, agent string) (*Hook, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("hook payload is not a JSON object: %w", err)
+	}
+
+	var known []string
+	for _, s := range schemas {
+		known = append(known, s.agent)
+		if agent != "" && agent != s.agent {
+			continue
+		}
+		if !s.sniff(fields) {
+			if agent == "" {
+				continue
+			}
+			// Without the agent's own fields, no version can be told apart
+			return nil, unknownVersion(s, fields)
+		}
+		for _, a := range s.adapters {
+			if a.detect(fields) {
+				return a.parse(data)
+			}
+		}
+		return nil, unknownVersion(s, fields)
+	}
+	if agent != "" {
+		return nil, fmt.Errorf("no hook schema for agent %q (supported: %s)", agent, strings.Join(known, ", "))
+	}
+	return nil, fmt.Errorf("unrecognized hook payload with fields %s (supported agents: %s)",
+		strings.Join(keys(fields), ", "), strings.Join(known, ", "))
+}
+
+// Respond builds the reply the hook's agent expects for a result.
+func (h *Hook) Respond(r *policy.CheckResult) ([]byte, error) {
+	for _, a := range Adapters() {
+		if a.Agent == h.Agent && a.Version == h.Version {
+			return json.Marshal(a.respond(h, r))
+		}
+	}
+	return nil, fmt.Errorf("no %s adapter for version %s", h.Agent, h.Version)
+}
+
+// Rewrites reports whether the hook's reply can have the agent run a
+// rewritten command in place of the one it asked to run.
+func (h *Hook) Rewrites() bool {
+	for _, a := range Adapters() {
+		if a.Agent == h.Agent && a.Version == h.Version {
+			return a.rewrites && h.Event == "PreToolUse" && h.Request.Command != ""
+		}
+	}
+	return false
+}
+
+func unknownVersion(s schema, fields map[string]json.RawMessage) error {
+	e := &UnknownVersionError{Agent: s.agent, Fields: keys(fields)}
+	var all []string
+	for _, a := range s.adapters {
+		e.Supported = append(e.Supported, a.Version)
+		all = append(all, a.Fields...)
+	}
+	e.Unexpected = unexpected(fields, all)
+	return e
+}
+
+// unexpected returns the fields not listed in allowed, sorted.
+func unexpected(fields map[string]json.RawMessage, allowed []string) []string {
+	var out []string
+	for _, k := range keys(fields) {
+		if !contains(allowed, k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// has reports whether all of names are present.
+func has(fields map[string]json.RawMessage, names ...string) bool {
+	for _, n := range names {
+		if _, ok := fields[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func keys(fields map[string]json.RawMessage) []string {
+	out := make([]string, 0, len(fields))
+	for k := range fields {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// written is the resize of a write replacing a whole file with content,
+// or nil once the write is done, when what it replaced is gone.
+func written(content string, done bool) *policy.Resize {
+	if done {
+		return nil
+	}
+	return &policy.Resize{Whole: true, Added: int64(len(content))}
+}
+
+// edit is a string an edit tool replaces, and its replacement.
+type edit struct {
+	old, new string
+	// all is set when every occurrence is replaced, not just one
+	all bool
+}
+
+// edited is the resize of edits each replacing a string once, or nil
+// when one replaces every occurrence, which the payload doesn't count.
+func edited(done bool, edits ...edit) *policy.Resize {
+	r := &policy.Resize{Done: done}
+	for _, e := range edits {
+		if e.all {
+			return nil
+		}
+		r.Removed += int64(len(e.old))
+		r.Added += int64(len(e.new))
+	}
+	return r
+}
@@ -0,0 +1,213 @@
+package hookproto
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+var update = flag.Bool("update", false, "rewrite .golden files")
+
+// golden is what a payload parses to and the replies sent back for it.
+type golden struct {
+	Agent   string
+	Version string
+	Event   string
+	Tool    string
+	Request policy.CheckRequest
+	Allow   json.RawMessage
+	Deny    json.RawMessage
+}
+
+func TestGoldenPayloads(t *testing.T) {
+	payloads, err := filepath.Glob("testdata/*.json")
+	if err != nil || len(payloads) == 0 {
+		t.Fatalf("no payloads in testdata: %v", err)
+	}
+	deny := &policy.CheckResult{Allowed: false, Reason: "no force push", Suggest: "git push --force-with-lease", Decision: policy.DecisionDeny}
+
+	for _, path := range payloads {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, err := Parse(data, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := h.Agent + "-v" + h.Version + "-"; !strings.HasPrefix(name, want) {
+				t.Errorf("detected %s v%s, want the adapter named by %s", h.Agent, h.Version, name)
+			}
+
+			g := golden{Agent: h.Agent, Version: h.Version, Event: h.Event, Tool: h.Tool, Request: h.Request}
+			if g.Allow, err = h.Respond(&policy.CheckResult{Allowed: true}); err != nil {
+				t.Fatal(err)
+			}
+			if g.Deny, err = h.Respond(deny); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.MarshalIndent(g, "", "  ")
+
+			goldenPath := strings.TrimSuffix(path, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(goldenPath, append(got, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if strings.TrimSpace(string(want)) != string(got) {
+				t.Errorf("parsed payload differs from %s:\n%s", goldenPath, got)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	data, err := os.ReadFile("testdata/claude-code-v2-bash.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := Parse(data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Rewrites() {
+		t.Fatal("claude-code v2 Bash hook can't rewrite")
+	}
+	out, err := h.Respond(&policy.CheckResult{
+		Allowed:  false,
+		Reason:   "no force push",
+		Rewrite:  "git push --force-with-lease origin main",
+		Decision: policy.DecisionDeny,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply struct {
+		HookSpecificOutput struct {
+			PermissionDecision string
+			UpdatedInput       map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(out, &reply); err != nil {
+		t.Fatal(err)
+	}
+	got := reply.HookSpecificOutput
+	if got.PermissionDecision != "allow" || got.UpdatedInput["command"] != "git push --force-with-lease origin main" || got.UpdatedInput["timeout"] != 120000.0 {
+		t.Errorf("rewrite reply = %s", out)
+	}
+
+	for _, name := range []string{"claude-code-v1-bash", "claude-code-v2-write"} {
+		data, err := os.ReadFile("testdata/" + name + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h, err := Parse(data, ""); err != nil || h.Rewrites() {
+			t.Errorf("%s: rewrites = %v, %v; want false", name, h.Rewrites(), err)
+		}
+	}
+}
+
+func TestUnknownVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		agent   string
+		want    string
+	}{
+		{
+			name:    "claude-code event without cwd",
+			payload: `{"session_id":"a","transcript_path":"t","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{}}`,
+			want:    "unsupported claude-code hook payload (supported versions: 2, 1): got fields hook_event_name, session_id, tool_input, tool_name, transcript_path",
+		},
+		{
+			name:    "claude-code event without cwd and a new field",
+			payload: `{"session_id":"a","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{},"tool_use_id":"x"}`,
+			want:    "unexpected fields tool_use_id",
+		},
+		{
+			name:    "cursor without event",
+			payload: `{"conversation_id":"c","command":"ls"}`,
+			want:    "unsupported cursor hook payload",
+		},
+		{
+			name:    "unknown cursor event",
+			payload: `{"hook_event_name":"beforeTeleport","conversation_id":"c","generation_id":"g","workspace_roots":[]}`,
+			want:    `unknown hook_event_name "beforeTeleport"`,
+		},
+		{
+			name:    "wrong field type",
+			payload: `{"tool":"bash","sessionID":1,"callID":"c","args":{}}`,
+			want:    "opencode v1 hook payload: json: cannot unmarshal number",
+		},
+		{
+			name:    "unknown agent",
+			payload: `{"event":"run","input":"ls"}`,
+			want:    "unrecognized hook payload with fields event, input (supported agents: claude-code, cursor, opencode)",
+		},
+		{
+			name:    "forced agent",
+			payload: `{"tool":"bash","args":{}}`,
+			agent:   "claude-code",
+			want:    "unsupported claude-code hook payload",
+		},
+		{
+			name:    "not json",
+			payload: `tool=bash`,
+			want:    "hook payload is not a JSON object",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.payload), tt.agent)
+			if err == nil {
+				t.Fatal("parsed, want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	_, err := Parse([]byte(tests[0].payload), "")
+	var unknown *UnknownVersionError
+	if !errors.As(err, &unknown) || unknown.Agent != "claude-code" {
+		t.Errorf("error %v, want *UnknownVersionError for claude-code", err)
+	}
+}
+
+func TestNewFieldsIgnored(t *testing.T) {
+	tests := []struct {
+		payload string
+		agent   string
+		version string
+		command string
+	}{
+		{`{"session_id":"a","transcript_path":"t","cwd":"/","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"},"tool_use_id":"x"}`, "claude-code", "2", "ls"},
+		{`{"session_id":"a","transcript_path":"t","tool_name":"Bash","tool_input":{"command":"ls"},"tool_use_id":"x"}`, "claude-code", "1", "ls"},
+		{`{"hook_event_name":"beforeShellExecution","conversation_id":"c","generation_id":"g","workspace_roots":[],"command":"ls","cwd":"/","user_email":"a@b"}`, "cursor", "1", "ls"},
+		{`{"tool":"bash","sessionID":"s","callID":"c","args":{"command":"ls"},"messageID":"m"}`, "opencode", "1", "ls"},
+	}
+	for _, tt := range tests {
+		h, err := Parse([]byte(tt.payload), "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.payload, err)
+			continue
+		}
+		if h.Agent != tt.agent || h.Version != tt.version || h.Request.Command != tt.command {
+			t.Errorf("%s: parsed %s v%s %q, want %s v%s %q", tt.payload, h.Agent, h.Version, h.Request.Command, tt.agent, tt.version, tt.command)
+		}
+	}
+}
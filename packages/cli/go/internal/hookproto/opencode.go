@@ -0,0 +1,85 @@
+package hookproto
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// openCodePayload is what the OpenCode plugin forwards from its
+// tool.execute.before hook: the tool, its call and its arguments.
+type openCodePayload struct {
+	Tool      string          `json:"tool"`
+	SessionID string          `json:"sessionID"`
+	CallID    string          `json:"callID"`
+	Cwd       string          `json:"cwd,omitempty"`
+	Args      json.RawMessage `json:"args"`
+}
+
+// openCodeArgs covers the arguments of the tools veto enforces.
+type openCodeArgs struct {
+	Command    string `json:"command"`
+	FilePath   string `json:"filePath"`
+	Content    string `json:"content"`
+	OldString  string `json:"oldString"`
+	NewString  string `json:"newString"`
+	ReplaceAll bool   `json:"replaceAll"`
+}
+
+func sniffOpenCode(fields map[string]json.RawMessage) bool {
+	return has(fields, "tool", "args")
+}
+
+var openCodeV1 = &Adapter{
+	Agent:   "opencode",
+	Version: "1",
+	Fields:  []string{"tool", "sessionID", "callID", "cwd", "args"},
+	detect: func(fields map[string]json.RawMessage) bool {
+		return has(fields, "sessionID", "callID")
+	},
+	parse: parseOpenCode,
+	respond: func(h *Hook, r *policy.CheckResult) interface{} {
+		out := map[string]interface{}{"allow": r.Allowed}
+		if !r.Allowed {
+			out["reason"] = message.Hook(r)
+		}
+		// The plugin is veto's own, so it takes the alternatives as data
+		if !r.Allowed && len(r.Alternatives) > 0 {
+			out["alternatives"] = r.Alternatives
+		}
+		return out
+	},
+}
+
+func parseOpenCode(data []byte) (*Hook, error) {
+	var p openCodePayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("opencode v1 hook payload: %w", err)
+	}
+	// Arguments differ per tool, so only the envelope is strict
+	var args openCodeArgs
+	if err := json.Unmarshal(p.Args, &args); err != nil {
+		return nil, fmt.Errorf("opencode v1 hook payload: args: %w", err)
+	}
+	h := &Hook{Agent: "opencode", Version: "1", Event: "tool.execute.before", Tool: p.Tool}
+
+	req := policy.CheckRequest{Agent: "opencode", SessionID: p.SessionID, RequestID: p.CallID, Cwd: p.Cwd}
+	switch p.Tool {
+	case "bash":
+		req.Action, req.Command = string(policy.ActionExecute), args.Command
+	case "write":
+		req.Action, req.Target, req.Content = string(policy.ActionModify), args.FilePath, args.Content
+		req.Resize = written(args.Content, false)
+	case "edit":
+		req.Action, req.Target, req.Content = string(policy.ActionModify), args.FilePath, args.NewString
+		req.Resize = edited(false, edit{args.OldString, args.NewString, args.ReplaceAll})
+	case "read":
+		req.Action, req.Target = string(policy.ActionRead), args.FilePath
+	default:
+		return h, nil
+	}
+	h.Request = req
+	return h, nil
+}
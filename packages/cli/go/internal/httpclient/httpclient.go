@@ -0,0 +1,95 @@
+// Package httpclient builds the HTTP clients veto's network calls use, so
+// every call goes through the proxy the environment names (HTTPS_PROXY,
+// HTTP_PROXY, NO_PROXY) and trusts a custom CA bundle on corporate
+// networks that intercept TLS.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/VulnZap/veto/internal/settings"
+)
+
+// CABundleEnv names a PEM file of extra CA certificates to trust.
+const CABundleEnv = "VETO_CA_BUNDLE"
+
+// CABundle returns the extra CA bundle to trust, or "" for none: the
+// VETO_CA_BUNDLE variable, the caBundle setting, or NODE_EXTRA_CA_CERTS
+// so one setting covers the npm package too.
+func CABundle() string {
+	if path := os.Getenv(CABundleEnv); path != "" {
+		return path
+	}
+	if s, err := settings.Load(); err == nil && s.CABundle != "" {
+		return s.CABundle
+	}
+	return os.Getenv("NODE_EXTRA_CA_CERTS")
+}
+
+// New returns a client with the given timeout. When the CA bundle can't
+// be loaded every request fails with that error, instead of a TLS error
+// that doesn't say why.
+func New(timeout time.Duration) *http.Client {
+	transport, err := Transport()
+	if err != nil {
+		return &http.Client{Timeout: timeout, Transport: failing{err}}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Transport returns an HTTP transport using the environment's proxy and
+// trusting the system roots plus the CA bundle.
+func Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	path := CABundle()
+	if path == "" {
+		return t, nil
+	}
+	pool, err := certPool(path)
+	if err != nil {
+		return nil, err
+	}
+	t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return t, nil
+}
+
+// certPool returns the system roots plus the certificates in the PEM file
+// at path.
+func certPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// Proxy returns the proxy a request to rawURL goes through, or nil when
+// it connects directly.
+func Proxy(rawURL string) (*url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// failing is a transport whose every request fails with err.
+type failing struct{ err error }
+
+func (f failing) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
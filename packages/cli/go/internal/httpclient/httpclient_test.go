@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NODE_EXTRA_CA_CERTS", "")
+	t.Setenv(CABundleEnv, "")
+}
+
+func TestCABundle(t *testing.T) {
+	isolate(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// The test server's certificate is self-signed, like an intercepting proxy's
+	if _, err := New(5 * time.Second).Get(srv.URL); err == nil {
+		t.Fatal("request to an untrusted server succeeded")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(CABundleEnv, bundle)
+	resp, err := New(5 * time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with the CA bundle: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestBadCABundleFailsClearly(t *testing.T) {
+	isolate(t)
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(CABundleEnv, bundle)
+
+	_, err := New(time.Second).Get("https://example.invalid")
+	if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Errorf("Get() = %v, want the CA bundle error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package impact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyLimit is how many of the most recent commands are taken from
+// each shell history.
+const historyLimit = 1000
+
+// History returns the commands recently run in the user's shells: the
+// last historyLimit of $HISTFILE and of zsh's, bash's and fish's default
+// history files. Histories that don't exist or can't be read are
+// skipped, so it returns nil when there are none.
+func History() []string {
+	home, _ := os.UserHomeDir()
+	paths := []string{os.Getenv("HISTFILE")}
+	if home != "" {
+		paths = append(paths,
+			filepath.Join(home, ".zsh_history"),
+			filepath.Join(home, ".bash_history"),
+			filepath.Join(home, ".local", "share", "fish", "fish_history"))
+	}
+
+	var commands []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if path == "" || err != nil || seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		lines := parseHistory(string(data), filepath.Base(abs) == "fish_history")
+		if len(lines) > historyLimit {
+			lines = lines[len(lines)-historyLimit:]
+		}
+		commands = append(commands, lines...)
+	}
+	return commands
+}
+
+// parseHistory reads the commands in a history file: one per line for
+// bash (skipping its #timestamp lines), ": <time>:<duration>;command"
+// lines for zsh's extended history, and "- cmd: command" entries for
+// fish.
+func parseHistory(data string, fish bool) []string {
+	var commands []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case fish:
+			cmd, ok := strings.CutPrefix(line, "- cmd: ")
+			if !ok {
+				continue
+			}
+			line = strings.ReplaceAll(cmd, `\n`, "\n")
+		case strings.HasPrefix(line, ": ") && strings.Contains(line, ";"):
+			_, line, _ = strings.Cut(line, ";")
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
@@ -0,0 +1,91 @@
+// Package impact estimates how much a new policy would flag before it is
+// added: matches in the files already in the project and in the
+// commands recently run in the user's shell. A policy that would flag a
+// lot is better started in monitor mode.
+package impact
+
+import (
+	"fmt"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// maxExamples is how many occurrences an estimate keeps to show.
+const maxExamples = 3
+
+// Estimate is what a policy would flag in a project.
+type Estimate struct {
+	// Content is how many content and AST rule matches the files have
+	Content int
+	// ContentFiles is how many files those are in
+	ContentFiles int
+	// Commands is how many of the Checked shell commands it would stop
+	Commands int
+	Checked  int
+	// Files is how many existing files the policy's patterns cover, for
+	// policies without content rules
+	Files int
+	// Scanned is how many files were read for content
+	Scanned int
+	// Examples are a few occurrences: file:line and the line, or a
+	// command
+	Examples []string
+}
+
+// Occurrences is how many existing things the policy would flag.
+func (e *Estimate) Occurrences() int {
+	return e.Content + e.Commands
+}
+
+// Of estimates what p would flag in the project at root and among
+// commands, such as the ones History returns.
+func Of(root string, p *policy.Policy, commands []string) (*Estimate, error) {
+	set, err := matcher.NewSet([]*policy.Policy{p})
+	if err != nil {
+		return nil, err
+	}
+	e := &Estimate{Checked: len(commands)}
+
+	content := len(p.ContentRules) > 0 || len(p.ASTRules) > 0
+	if content {
+		report, err := audit.Scan(root, set, 0)
+		if err != nil {
+			return nil, err
+		}
+		e.Scanned = report.Files
+		files := map[string]bool{}
+		for _, f := range report.Findings {
+			e.Content++
+			files[f.File] = true
+			e.example(fmt.Sprintf("%s:%d  %s", f.File, f.Line, f.Text))
+		}
+		e.ContentFiles = len(files)
+	}
+
+	// A content policy's patterns only pick the files its rules apply to
+	if !content && len(p.Include) > 0 {
+		m := set.Matchers()[0]
+		for _, f := range audit.Files(root) {
+			if m.ExplainFile(f).Blocked {
+				e.Files++
+			}
+		}
+	}
+
+	for _, cmd := range commands {
+		req := &policy.CheckRequest{Action: string(policy.ActionExecute), Command: cmd, Cwd: root}
+		if !set.Check(req).Allowed {
+			e.Commands++
+			e.example("$ " + cmd)
+		}
+	}
+	return e, nil
+}
+
+func (e *Estimate) example(s string) {
+	if len(e.Examples) < maxExamples {
+		e.Examples = append(e.Examples, s)
+	}
+}
@@ -0,0 +1,73 @@
+package impact
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestOf(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "dist/\n",
+		"src/a.ts":       "console.log(1)\nconsole.log(2)\n",
+		"src/b.ts":       "console.log(3)\n",
+		"src/c.ts":       "logger.info(4)\n",
+		"dist/bundle.js": "console.log(5)\n",
+		".env":           "TOKEN=x\n",
+		"app/.env":       "TOKEN=y\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logs := &policy.Policy{
+		Action:       policy.ActionModify,
+		Description:  "No console.log",
+		ContentRules: []policy.ContentRule{{Pattern: `console\.log\(`, Reason: "use the logger"}},
+	}
+	e, err := Of(root, logs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Content != 3 || e.ContentFiles != 2 || e.Occurrences() != 3 || len(e.Examples) != 3 {
+		t.Errorf("console.log estimate = %+v", e)
+	}
+
+	env := &policy.Policy{Action: policy.ActionModify, Description: "Environment files", Include: []string{"**/.env"}}
+	history := []string{"cat .env", "mv app/.env app/.env.bak", "ls", "echo x > .env", "git status"}
+	e, err = Of(root, env, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Files != 2 || e.Commands != 2 || e.Checked != 5 || e.Content != 0 {
+		t.Errorf(".env estimate = %+v", e)
+	}
+	if want := []string{"$ mv app/.env app/.env.bak", "$ echo x > .env"}; !reflect.DeepEqual(e.Examples, want) {
+		t.Errorf("examples = %q, want %q", e.Examples, want)
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	tests := []struct {
+		data string
+		fish bool
+		want []string
+	}{
+		{"ls\n#1700000000\ngit status\n\n", false, []string{"ls", "git status"}},
+		{": 1700000000:0;git push --force\n: 1700000001:3;make\n", false, []string{"git push --force", "make"}},
+		{"- cmd: rm -rf dist\n  when: 1700000000\n- cmd: echo a\\nb\n", true, []string{"rm -rf dist", "echo a\nb"}},
+	}
+	for _, tt := range tests {
+		if got := parseHistory(tt.data, tt.fish); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseHistory(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
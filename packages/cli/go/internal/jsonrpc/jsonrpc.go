@@ -0,0 +1,177 @@
+// Package jsonrpc is the JSON-RPC 2.0 plumbing veto's MCP and language
+// servers share: reading and writing messages in either server's
+// framing, answering requests, and loading the projects they ask about.
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// Error codes.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is a JSON-RPC 2.0 request, notification or response.
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error. Conn.Read returns one with CodeParseError
+// for a message that isn't JSON, to be answered rather than given up on.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// InvalidParams is the error for params that don't decode.
+func InvalidParams(err error) *Error {
+	return &Error{Code: CodeInvalidParams, Message: err.Error()}
+}
+
+// MethodNotFound is the error for a request for an unknown method.
+func MethodNotFound(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: "unknown method " + method}
+}
+
+// Framing is how messages are delimited on the stream.
+type Framing int
+
+const (
+	// Lines puts one message on each line, as MCP does on stdio
+	Lines Framing = iota
+	// Headers precedes each message with a Content-Length header, as
+	// LSP does
+	Headers
+)
+
+// Conn reads and writes messages on a stream.
+type Conn struct {
+	in      *bufio.Reader
+	out     io.Writer
+	framing Framing
+}
+
+// NewConn returns a connection reading messages from r and writing them
+// to w, framed by framing.
+func NewConn(r io.Reader, w io.Writer, framing Framing) *Conn {
+	return &Conn{in: bufio.NewReader(r), out: w, framing: framing}
+}
+
+// Read returns the next message, or io.EOF once the stream ends.
+func (c *Conn) Read() (*Message, error) {
+	var body []byte
+	switch c.framing {
+	case Lines:
+		for len(body) == 0 {
+			line, err := c.in.ReadBytes('\n')
+			body = bytes.TrimSpace(line)
+			// A last line without a newline is still a message
+			if err != nil && (len(body) == 0 || !errors.Is(err, io.EOF)) {
+				return nil, err
+			}
+		}
+	case Headers:
+		header, err := textproto.NewReader(c.in).ReadMIMEHeader()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+		}
+		body = make([]byte, length)
+		if _, err := io.ReadFull(c.in, body); err != nil {
+			return nil, err
+		}
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, &Error{Code: CodeParseError, Message: err.Error()}
+	}
+	return &msg, nil
+}
+
+// Write sends a message.
+func (c *Conn) Write(msg Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if c.framing == Headers {
+		_, err = fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+		return err
+	}
+	_, err = c.out.Write(append(body, '\n'))
+	return err
+}
+
+// Notify sends a notification.
+func (c *Conn) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.Write(Message{Method: method, Params: data})
+}
+
+// Reply answers msg with result, or rerr if it isn't nil. Notifications
+// get no answer, and a request without a result is answered with null.
+func (c *Conn) Reply(msg *Message, result any, rerr *Error) error {
+	switch {
+	case msg.ID == nil:
+		return nil
+	case rerr != nil:
+		return c.Write(Message{ID: msg.ID, Error: rerr})
+	case result == nil:
+		result = json.RawMessage("null")
+	}
+	return c.Write(Message{ID: msg.ID, Result: result})
+}
+
+// Projects caches the projects a server's requests are about, by .veto
+// path.
+type Projects map[string]*project.Project
+
+// Find returns the project governing dir, reloading it when its .veto
+// changed.
+func (ps Projects) Find(dir string) (*project.Project, error) {
+	path, err := project.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := ps[path]; ok && !p.Stale() {
+		return p, nil
+	}
+	p, err := project.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	ps[path] = p
+	return p, nil
+}
@@ -0,0 +1,73 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFraming(t *testing.T) {
+	for _, framing := range []Framing{Lines, Headers} {
+		var buf bytes.Buffer
+		w := NewConn(nil, &buf, framing)
+		id := json.RawMessage("1")
+		w.Write(Message{ID: &id, Method: "ping"})
+		w.Notify("note", map[string]string{"text": "a\nb"})
+
+		r := NewConn(&buf, nil, framing)
+		var methods []string
+		for {
+			msg, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("framing %d: Read() = %v", framing, err)
+			}
+			if msg.JSONRPC != "2.0" {
+				t.Errorf("framing %d: jsonrpc = %q", framing, msg.JSONRPC)
+			}
+			methods = append(methods, msg.Method)
+		}
+		if strings.Join(methods, ",") != "ping,note" {
+			t.Errorf("framing %d: read %q, want ping and note", framing, methods)
+		}
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	c := NewConn(strings.NewReader("\n{\"method\":\"a\"}\nnot json\n{\"method\":\"b\"}"), nil, Lines)
+	if msg, err := c.Read(); err != nil || msg.Method != "a" {
+		t.Errorf("Read() = %+v, %v, want a, skipping the blank line", msg, err)
+	}
+	var perr *Error
+	if _, err := c.Read(); !errors.As(err, &perr) || perr.Code != CodeParseError {
+		t.Errorf("Read() of a bad line = %v, want a parse error", err)
+	}
+	// The bad line doesn't stop the stream, nor does a missing last newline
+	if msg, err := c.Read(); err != nil || msg.Method != "b" {
+		t.Errorf("Read() = %+v, %v, want b", msg, err)
+	}
+	if _, err := c.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() at the end = %v, want EOF", err)
+	}
+}
+
+func TestReply(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConn(nil, &buf, Lines)
+	id := json.RawMessage("7")
+	c.Reply(&Message{Method: "note"}, "ignored", nil)
+	c.Reply(&Message{ID: &id, Method: "shutdown"}, nil, nil)
+	c.Reply(&Message{ID: &id, Method: "nope"}, nil, MethodNotFound("nope"))
+
+	want := `{"jsonrpc":"2.0","id":7,"result":null}
+{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"unknown method nope"}}
+`
+	if buf.String() != want {
+		t.Errorf("replies:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
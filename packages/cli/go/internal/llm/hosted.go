@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Gemini is Google's Gemini API.
+type Gemini struct{ Settings }
+
+func (g *Gemini) Name() string { return "gemini" }
+
+func (g *Gemini) Complete(system, user string) (string, error) {
+	url := strings.TrimSuffix(g.URL, "/") + "/" + g.Model + ":generateContent"
+	body, err := post(g.Client, g.Name(), url, http.Header{"X-Goog-Api-Key": {g.Key}}, map[string]interface{}{
+		"systemInstruction": map[string]interface{}{"parts": []map[string]string{{"text": system}}},
+		"contents":          []map[string]interface{}{{"role": "user", "parts": []map[string]string{{"text": user}}}},
+		"generationConfig": map[string]interface{}{
+			"temperature":      0,
+			"maxOutputTokens":  4096,
+			"responseMimeType": "application/json",
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+			FinishReason string `json:"finishReason"`
+		} `json:"candidates"`
+	}
+	if err := decode(g.Name(), body, &r); err != nil {
+		return "", err
+	}
+	if len(r.Candidates) == 0 {
+		return reply(g.Name(), "")
+	}
+	var b strings.Builder
+	c := r.Candidates[0]
+	for _, part := range c.Content.Parts {
+		b.WriteString(part.Text)
+	}
+	if b.Len() == 0 && c.FinishReason != "" && c.FinishReason != "STOP" {
+		return "", fmt.Errorf("empty %s response (finish reason %s)", g.Name(), c.FinishReason)
+	}
+	return reply(g.Name(), b.String())
+}
+
+// OpenAI is the OpenAI chat completions API, or any server compatible
+// with it.
+type OpenAI struct{ Settings }
+
+func (o *OpenAI) Name() string { return "openai" }
+
+func (o *OpenAI) Complete(system, user string) (string, error) {
+	header := http.Header{}
+	if o.Key != "" {
+		header.Set("Authorization", "Bearer "+o.Key)
+	}
+	body, err := post(o.Client, o.Name(), o.URL, header, map[string]interface{}{
+		"model":           o.Model,
+		"temperature":     0,
+		"response_format": map[string]string{"type": "json_object"},
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := decode(o.Name(), body, &r); err != nil {
+		return "", err
+	}
+	if len(r.Choices) == 0 {
+		return reply(o.Name(), "")
+	}
+	return reply(o.Name(), r.Choices[0].Message.Content)
+}
+
+// Anthropic is Anthropic's messages API.
+type Anthropic struct{ Settings }
+
+func (a *Anthropic) Name() string { return "anthropic" }
+
+func (a *Anthropic) Complete(system, user string) (string, error) {
+	header := http.Header{"X-Api-Key": {a.Key}, "Anthropic-Version": {"2023-06-01"}}
+	body, err := post(a.Client, a.Name(), a.URL, header, map[string]interface{}{
+		"model":       a.Model,
+		"max_tokens":  4096,
+		"temperature": 0,
+		"system":      system,
+		"messages":    []map[string]string{{"role": "user", "content": user}},
+	})
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := decode(a.Name(), body, &r); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			b.WriteString(c.Text)
+		}
+	}
+	return reply(a.Name(), b.String())
+}
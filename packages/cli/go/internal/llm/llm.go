@@ -0,0 +1,246 @@
+// Package llm talks to the language models that compile .veto phrases:
+// Gemini, OpenAI, Anthropic, or a local model served by Ollama. The
+// provider is picked by the compiler: setting in .veto, the VETO_COMPILER
+// variables, or else whichever hosted provider has an API key set.
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/httpclient"
+)
+
+// Provider is a model that completes prompts.
+type Provider interface {
+	// Name is the provider's name, as selected in settings
+	Name() string
+	// Complete returns the model's reply to the user prompt under the
+	// system prompt
+	Complete(system, user string) (string, error)
+}
+
+// Settings select a provider and override its defaults.
+type Settings struct {
+	// Provider is gemini, openai, anthropic or ollama
+	Provider string
+	// Model is the model to ask ("" for the provider's default)
+	Model string
+	// URL is the API endpoint ("" for the provider's default)
+	URL string
+	// Key is the API key ("" to read the provider's key variable)
+	Key string
+	// Client makes the requests (nil for one with a minute's timeout)
+	Client *http.Client
+}
+
+// Environment variables that override the compiler: setting.
+const (
+	ProviderEnv = "VETO_COMPILER"
+	ModelEnv    = "VETO_COMPILER_MODEL"
+	URLEnv      = "VETO_COMPILER_URL"
+)
+
+// providers are detected in order; the first hosted provider whose key
+// variable is set is used. Ollama has no key and is only used when
+// selected.
+var providers = []struct {
+	name, keyEnv, model, url string
+	build                    func(Settings) Provider
+}{
+	{"gemini", "GEMINI_API_KEY", "gemini-2.5-flash", "https://generativelanguage.googleapis.com/v1beta/models",
+		func(s Settings) Provider { return &Gemini{s} }},
+	{"openai", "OPENAI_API_KEY", "gpt-4o-mini", "https://api.openai.com/v1/chat/completions",
+		func(s Settings) Provider { return &OpenAI{s} }},
+	{"anthropic", "ANTHROPIC_API_KEY", "claude-3-5-haiku-latest", "https://api.anthropic.com/v1/messages",
+		func(s Settings) Provider { return &Anthropic{s} }},
+	{"ollama", "", "llama3.1", "",
+		func(s Settings) Provider { return &Ollama{s} }},
+}
+
+// Names lists the providers in detection order.
+func Names() []string {
+	var names []string
+	for _, p := range providers {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// Known reports whether name is a provider.
+func Known(name string) bool {
+	for _, p := range providers {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyVars lists the environment variables that select a hosted provider.
+func KeyVars() []string {
+	var vars []string
+	for _, p := range providers {
+		if p.keyEnv != "" {
+			vars = append(vars, p.keyEnv)
+		}
+	}
+	return vars
+}
+
+// New returns the provider s names, filling in its defaults. Hosted
+// providers need an API key unless s points them at another URL, such as
+// a proxy or an OpenAI-compatible local server.
+func New(s Settings) (Provider, error) {
+	for _, p := range providers {
+		if p.name != s.Provider {
+			continue
+		}
+		if s.Model == "" {
+			s.Model = p.model
+		}
+		if s.URL == "" {
+			s.URL = p.url
+			if p.name == "ollama" {
+				s.URL = ollamaURL()
+			}
+			if s.Key == "" && p.keyEnv != "" {
+				if s.Key = os.Getenv(p.keyEnv); s.Key == "" {
+					return nil, fmt.Errorf("compiler %s needs %s set", p.name, p.keyEnv)
+				}
+			}
+		} else if s.Key == "" && p.keyEnv != "" {
+			s.Key = os.Getenv(p.keyEnv)
+		}
+		if s.Client == nil {
+			s.Client = httpclient.New(time.Minute)
+		}
+		return p.build(s), nil
+	}
+	return nil, fmt.Errorf("unknown compiler %q (want %s)", s.Provider, strings.Join(Names(), ", "))
+}
+
+// Select returns the provider to compile with: the one s names, with
+// VETO_COMPILER, VETO_COMPILER_MODEL and VETO_COMPILER_URL taking
+// precedence, else the first hosted provider with an API key set. It
+// returns nil, nil when nothing selects a provider.
+func Select(s Settings) (Provider, error) {
+	if name := os.Getenv(ProviderEnv); name != "" && name != s.Provider {
+		s = Settings{Provider: name, Client: s.Client}
+	}
+	if model := os.Getenv(ModelEnv); model != "" {
+		s.Model = model
+	}
+	if url := os.Getenv(URLEnv); url != "" {
+		s.URL = url
+	}
+	if s.Provider != "" {
+		return New(s)
+	}
+	for _, p := range providers {
+		if p.keyEnv != "" && os.Getenv(p.keyEnv) != "" {
+			s.Provider = p.name
+			return New(s)
+		}
+	}
+	return nil, nil
+}
+
+// APIError is an unsuccessful response from a provider.
+type APIError struct {
+	Provider string
+	Status   int
+	Message  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s API returned %d", e.Provider, e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Temporary reports whether the request may succeed if retried: the
+// provider is rate limiting or overloaded.
+func (e *APIError) Temporary() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status == http.StatusServiceUnavailable ||
+		e.Status == 529 // Anthropic: overloaded
+}
+
+// post sends payload as JSON and returns the body of a successful
+// response.
+func post(client *http.Client, provider, url string, header http.Header, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{Provider: provider, Status: resp.StatusCode, Message: errorMessage(body)}
+	}
+	return body, nil
+}
+
+// decode unmarshals a successful response, or reports it invalid.
+func decode(provider string, body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// reply returns the text of a response, or an error when it has none.
+func reply(provider, text string) (string, error) {
+	if text == "" {
+		return "", fmt.Errorf("empty %s response", provider)
+	}
+	return text, nil
+}
+
+// errorMessage pulls the message out of an error response. The hosted
+// providers nest it as {"error": {"message": ...}}, Ollama sends
+// {"error": "..."}.
+func errorMessage(body []byte) string {
+	var r struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &r) == nil && len(r.Error) > 0 {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		var flat string
+		if json.Unmarshal(r.Error, &nested) == nil && nested.Message != "" {
+			return nested.Message
+		}
+		if json.Unmarshal(r.Error, &flat) == nil && flat != "" {
+			return flat
+		}
+	}
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
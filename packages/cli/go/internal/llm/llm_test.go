@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// response wraps text the way each provider returns a completion.
+func response(provider, text string) interface{} {
+	switch provider {
+	case "gemini":
+		return map[string]interface{}{"candidates": []interface{}{map[string]interface{}{
+			"content":      map[string]interface{}{"parts": []interface{}{map[string]string{"text": text}}},
+			"finishReason": "STOP",
+		}}}
+	case "openai":
+		return map[string]interface{}{"choices": []interface{}{map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": text},
+		}}}
+	case "ollama":
+		return map[string]interface{}{"message": map[string]string{"role": "assistant", "content": text}, "done": true}
+	default:
+		return map[string]interface{}{"content": []interface{}{map[string]string{"type": "text", "text": text}}}
+	}
+}
+
+// clearEnv unsets every variable that selects a provider.
+func clearEnv(t *testing.T) {
+	for _, v := range append(KeyVars(), ProviderEnv, ModelEnv, URLEnv, "OLLAMA_HOST") {
+		t.Setenv(v, "")
+	}
+}
+
+func TestProviders(t *testing.T) {
+	clearEnv(t)
+	auth := map[string]func(*http.Request) string{
+		"gemini":    func(r *http.Request) string { return r.Header.Get("x-goog-api-key") },
+		"openai":    func(r *http.Request) string { return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") },
+		"anthropic": func(r *http.Request) string { return r.Header.Get("x-api-key") },
+		"ollama":    func(r *http.Request) string { return "secret" },
+	}
+	paths := map[string]string{"gemini": "/test-model:generateContent", "ollama": "/api/chat"}
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := auth[name](r); got != "secret" {
+					t.Errorf("API key = %q", got)
+				}
+				if want := paths[name]; want != "" && r.URL.Path != want {
+					t.Errorf("path = %s, want %s", r.URL.Path, want)
+				}
+				var body map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("request body: %v", err)
+				}
+				if m, ok := body["model"]; ok && m != "test-model" {
+					t.Errorf("model = %v", m)
+				}
+				json.NewEncoder(w).Encode(response(name, `{"ok": true}`))
+			}))
+			defer srv.Close()
+
+			p, err := New(Settings{Provider: name, Model: "test-model", URL: srv.URL, Key: "secret", Client: srv.Client()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Name() != name {
+				t.Errorf("Name() = %q", p.Name())
+			}
+			if text, err := p.Complete("system", "user"); err != nil || text != `{"ok": true}` {
+				t.Errorf("Complete() = %q, %v", text, err)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	clearEnv(t)
+	bodies := map[string]string{
+		"openai": `{"error": {"message": "slow down"}}`,
+		"ollama": `{"error": "slow down"}`,
+	}
+	for name, body := range bodies {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(body))
+		}))
+		p, _ := New(Settings{Provider: name, URL: srv.URL, Client: srv.Client()})
+		_, err := p.Complete("system", "user")
+		apiErr, ok := err.(*APIError)
+		if !ok || !apiErr.Temporary() || apiErr.Message != "slow down" {
+			t.Errorf("%s: err = %v, want a temporary API error", name, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestSelect(t *testing.T) {
+	clearEnv(t)
+	if p, err := Select(Settings{}); p != nil || err != nil {
+		t.Errorf("nothing set: %v, %v", p, err)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "k1")
+	t.Setenv("ANTHROPIC_API_KEY", "k2")
+	if p, _ := Select(Settings{}); p == nil || p.Name() != "openai" {
+		t.Errorf("by key: %v, want openai", p)
+	}
+	if p, _ := Select(Settings{Provider: "anthropic"}); p == nil || p.Name() != "anthropic" {
+		t.Errorf("by setting: %v, want anthropic", p)
+	}
+
+	t.Setenv("OLLAMA_HOST", "gpu-box:11434")
+	p, err := Select(Settings{Provider: "ollama"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := p.(*Ollama); o.URL != "http://gpu-box:11434" || o.Model != "llama3.1" {
+		t.Errorf("ollama = %+v", o.Settings)
+	}
+
+	// The environment wins over .veto
+	t.Setenv(ProviderEnv, "gemini")
+	if _, err := Select(Settings{Provider: "ollama"}); err == nil || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("gemini without a key: %v", err)
+	}
+	t.Setenv(ProviderEnv, "ollama")
+	t.Setenv(ModelEnv, "qwen2.5-coder")
+	if p, _ := Select(Settings{Provider: "openai"}); p == nil || p.(*Ollama).Model != "qwen2.5-coder" {
+		t.Errorf("VETO_COMPILER=ollama: %v", p)
+	}
+
+	if _, err := New(Settings{Provider: "palm"}); err == nil {
+		t.Error("New accepted an unknown provider")
+	}
+}
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Ollama is a model served locally by Ollama. Nothing leaves the machine
+// and no key is needed.
+type Ollama struct{ Settings }
+
+func (o *Ollama) Name() string { return "ollama" }
+
+func (o *Ollama) Complete(system, user string) (string, error) {
+	body, err := post(o.Client, o.Name(), strings.TrimSuffix(o.URL, "/")+"/api/chat", http.Header{}, map[string]interface{}{
+		"model":   o.Model,
+		"stream":  false,
+		"format":  "json",
+		"options": map[string]interface{}{"temperature": 0},
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := decode(o.Name(), body, &r); err != nil {
+		return "", err
+	}
+	return reply(o.Name(), r.Message.Content)
+}
+
+// ollamaURL is where Ollama listens: OLLAMA_HOST, as the Ollama CLI reads
+// it, or its default address.
+func ollamaURL() string {
+	host := os.Getenv("OLLAMA_HOST")
+	if host == "" {
+		return "http://localhost:11434"
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return host
+}
@@ -0,0 +1,230 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// ChangeKind says whether a policy was added, removed or changed.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is how one policy differs between two lock files.
+type Change struct {
+	// Source is the .veto phrase
+	Source string
+	Kind   ChangeKind
+	// Description of the policy, from the newer side when it has one
+	Description string
+	// AddedRules and RemovedRules are the patterns only on one side,
+	// labeled by kind ("command npm *", "include .env")
+	AddedRules   []string
+	RemovedRules []string
+	// Settings are changed options, like "severity: high → critical"
+	Settings []string
+}
+
+// Diff compares the policies pinned in old with those in new, matching
+// entries by source phrase. Unchanged policies are left out; added ones
+// come in new's order, then removed ones in old's.
+func Diff(old, new []Entry) []Change {
+	before := make(map[string]*policy.Policy, len(old))
+	for _, e := range old {
+		before[e.Source] = e.Policy
+	}
+	seen := make(map[string]bool, len(new))
+
+	var changes []Change
+	for _, e := range new {
+		seen[e.Source] = true
+		prev, ok := before[e.Source]
+		if !ok {
+			changes = append(changes, Change{Source: e.Source, Kind: Added, Description: describe(e.Policy), AddedRules: rules(e.Policy)})
+			continue
+		}
+		c := Change{Source: e.Source, Kind: Changed, Description: describe(e.Policy)}
+		c.AddedRules, c.RemovedRules = difference(rules(e.Policy), rules(prev))
+		c.Settings = settingChanges(prev, e.Policy)
+		if len(c.AddedRules)+len(c.RemovedRules)+len(c.Settings) > 0 {
+			changes = append(changes, c)
+		}
+	}
+	for _, e := range old {
+		if !seen[e.Source] {
+			changes = append(changes, Change{Source: e.Source, Kind: Removed, Description: describe(e.Policy), RemovedRules: rules(e.Policy)})
+		}
+	}
+	return changes
+}
+
+func describe(p *policy.Policy) string {
+	if p == nil {
+		return ""
+	}
+	return p.Description
+}
+
+// rules lists every pattern in a policy, labeled by what it matches.
+func rules(p *policy.Policy) []string {
+	if p == nil {
+		return nil
+	}
+	var out []string
+	add := func(label string, values ...string) {
+		for _, v := range values {
+			out = append(out, label+" "+v)
+		}
+	}
+	add("include", p.Include...)
+	add("exclude", p.Exclude...)
+	for _, r := range p.CommandRules {
+		add("command host", r.AllowHosts...)
+		if r.RunInstead != "" || r.Rewrite != "" {
+			var extra []string
+			if r.RunInstead != "" {
+				extra = append(extra, "runs "+r.RunInstead)
+			}
+			if r.Rewrite != "" {
+				extra = append(extra, "rewrites to "+r.Rewrite)
+			}
+			for _, b := range r.Block {
+				out = append(out, "command "+b+" ("+strings.Join(extra, ", ")+")")
+			}
+			continue
+		}
+		add("command", r.Block...)
+	}
+	for _, r := range p.ContentRules {
+		rule := "content /" + r.Pattern + "/"
+		if len(r.FileTypes) > 0 {
+			rule += " in " + strings.Join(r.FileTypes, ",")
+		}
+		if len(r.Include) > 0 {
+			rule += " under " + strings.Join(r.Include, ",")
+		}
+		if len(r.Exclude) > 0 {
+			rule += " except " + strings.Join(r.Exclude, ",")
+		}
+		out = append(out, rule)
+		add("content exception", r.Exceptions...)
+	}
+	for _, r := range p.EnvRules {
+		add("env", r.Names...)
+		add("env host", r.AllowHosts...)
+	}
+	for _, r := range p.HeaderRules {
+		add("header", r.Markers...)
+	}
+	for _, r := range p.StructureRules {
+		rule := fmt.Sprintf("structure maxLines=%d maxFunctionLines=%d", r.MaxLines, r.MaxFunctionLines)
+		if r.RequireHeader != "" {
+			rule += " header /" + r.RequireHeader + "/"
+		}
+		if len(r.FileTypes) > 0 {
+			rule += " in " + strings.Join(r.FileTypes, ",")
+		}
+		out = append(out, rule)
+	}
+	for _, r := range p.ShrinkRules {
+		rule := fmt.Sprintf("shrink maxPercent=%d", r.MaxPercent)
+		if len(r.FileTypes) > 0 {
+			rule += " in " + strings.Join(r.FileTypes, ",")
+		}
+		if len(r.Include) > 0 {
+			rule += " under " + strings.Join(r.Include, ",")
+		}
+		out = append(out, rule)
+	}
+	for _, r := range p.ASTRules {
+		out = append(out, "ast "+r.ID+" in "+strings.Join(r.Languages, ","))
+	}
+	for _, r := range p.Allow {
+		add("allow command", r.Commands...)
+		add("allow path", r.Paths...)
+	}
+	return out
+}
+
+// difference returns the items only in a and only in b.
+func difference(a, b []string) (onlyA, onlyB []string) {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			onlyB = append(onlyB, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			onlyA = append(onlyA, s)
+		}
+	}
+	return onlyA, onlyB
+}
+
+// settingChanges lists the options that differ between two versions of
+// a policy, as "name: old → new".
+func settingChanges(a, b *policy.Policy) []string {
+	if a == nil || b == nil {
+		return nil
+	}
+	var out []string
+	setting := func(name, was, is string) {
+		if was != is {
+			out = append(out, fmt.Sprintf("%s: %s → %s", name, orNone(was), orNone(is)))
+		}
+	}
+	decision := func(d policy.Decision) string {
+		if d == "" {
+			return string(policy.DecisionDeny)
+		}
+		return string(d)
+	}
+	enforce := func(e policy.Enforcement) string {
+		if e == "" {
+			return string(policy.EnforceOn)
+		}
+		return string(e)
+	}
+	setting("description", a.Description, b.Description)
+	setting("action", string(a.Action), string(b.Action))
+	setting("decision", decision(a.Decision), decision(b.Decision))
+	setting("severity", string(a.Severity), string(b.Severity))
+	setting("enforce", enforce(a.Enforce), enforce(b.Enforce))
+	setting("rollout", strconv.Itoa(a.Rollout), strconv.Itoa(b.Rollout))
+	setting("locked", strconv.FormatBool(a.Locked), strconv.FormatBool(b.Locked))
+	setting("blockOpaque", strconv.FormatBool(a.BlockOpaque), strconv.FormatBool(b.BlockOpaque))
+	setting("maxFiles", strconv.Itoa(a.MaxFiles), strconv.Itoa(b.MaxFiles))
+	setting("testCommand", a.TestCommand, b.TestCommand)
+	setting("autoRun", strconv.FormatBool(a.AutoRun), strconv.FormatBool(b.AutoRun))
+	setting("when", condition(a.When), condition(b.When))
+	return out
+}
+
+func condition(c *policy.Condition) string {
+	if c == nil {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package lock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestDiff(t *testing.T) {
+	npm := &policy.Policy{
+		Description:  "No npm",
+		Action:       policy.ActionExecute,
+		CommandRules: []policy.CommandRule{policy.NewCommandRule("use pnpm", "npm install*", "npm i *")},
+		Severity:     policy.SeverityMedium,
+	}
+	env := &policy.Policy{Description: "Env files", Action: policy.ActionModify, Include: []string{".env"}}
+	old := []Entry{{Source: "prefer pnpm", Policy: npm}, {Source: "protect .env", Policy: env}}
+
+	npm2 := *npm
+	npm2.CommandRules = []policy.CommandRule{policy.NewCommandRule("use pnpm", "npm install*", "npm ci*")}
+	npm2.Severity = policy.SeverityHigh
+	tests := &policy.Policy{Description: "Tests", Action: policy.ActionDelete, Include: []string{"**/*.test.ts"}}
+	changes := Diff(old, []Entry{{Source: "prefer pnpm", Policy: &npm2}, {Source: "protect tests", Policy: tests}})
+
+	want := []Change{
+		{
+			Source: "prefer pnpm", Kind: Changed, Description: "No npm",
+			AddedRules: []string{"command npm ci*"}, RemovedRules: []string{"command npm i *"},
+			Settings: []string{"severity: medium → high"},
+		},
+		{Source: "protect tests", Kind: Added, Description: "Tests", AddedRules: []string{"include **/*.test.ts"}},
+		{Source: "protect .env", Kind: Removed, Description: "Env files", RemovedRules: []string{"include .env"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff() =\n%+v\nwant\n%+v", changes, want)
+	}
+
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("Diff of identical entries = %+v, want none", changes)
+	}
+}
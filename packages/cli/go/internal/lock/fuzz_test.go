@@ -0,0 +1,41 @@
+package lock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// FuzzParse checks that lock files, which arrive with the repository, are
+// either rejected or survive being written back unchanged.
+func FuzzParse(f *testing.F) {
+	valid, err := Marshal([]Entry{{
+		Source: "protect .env",
+		Policy: &policy.Policy{Description: "Env files", Action: policy.ActionModify, Include: []string{"**/.env"}},
+	}})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid)
+	f.Add([]byte(`{"version":1,"engine":"re2","policies":[{"source":"x","policy":null}]}`))
+	f.Add([]byte(`{"version":1,"engine":"pcre"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		lf, err := Parse(data)
+		if err != nil {
+			return
+		}
+		out, err := Marshal(lf.Policies)
+		if err != nil {
+			t.Fatalf("parsed but can't be written: %v", err)
+		}
+		again, err := Parse(out)
+		if err != nil {
+			t.Fatalf("written lock file doesn't parse: %v\n%s", err, out)
+		}
+		if !reflect.DeepEqual(again.Policies, lf.Policies) {
+			t.Errorf("policies changed by writing:\n%s", out)
+		}
+	})
+}
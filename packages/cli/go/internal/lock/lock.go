@@ -0,0 +1,154 @@
+// Package lock reads and writes .veto.lock, the compiled form of a
+// project's policies. Both the Go and TypeScript engines load the lock
+// file, so every pattern in it must be RE2-safe.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// FileName is the lock file written next to .veto.
+const FileName = ".veto.lock"
+
+// Version is the current lock file format. Version 1 files are still
+// read (see Parse).
+const Version = 2
+
+// EngineRE2 marks a lock file whose patterns were validated as RE2-safe.
+const EngineRE2 = "re2"
+
+// File is the on-disk shape of .veto.lock.
+type File struct {
+	Version  int     `json:"version"`
+	Engine   string  `json:"engine"`
+	Policies []Entry `json:"policies"`
+}
+
+// Entry pins the compiled policy for one .veto phrase.
+type Entry struct {
+	// Source is the phrase as written in .veto
+	Source string `json:"source"`
+	// Policy is what the phrase compiled to
+	Policy *policy.Policy `json:"policy"`
+	// Registered entries were built in code rather than compiled from the
+	// phrase, so relocking keeps them as they are
+	Registered bool `json:"registered,omitempty"`
+}
+
+// Path returns the lock file location for a project root.
+func Path(root string) string {
+	return filepath.Join(root, FileName)
+}
+
+// Validate checks every pattern in the entries, joining all failures.
+func Validate(entries []Entry) error {
+	var errs []error
+	for _, e := range entries {
+		if e.Policy == nil {
+			errs = append(errs, fmt.Errorf("%q: no compiled policy", e.Source))
+			continue
+		}
+		for _, err := range matcher.Validate(e.Policy) {
+			errs = append(errs, fmt.Errorf("%q: %w", e.Source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Write validates entries and writes them to path.
+func Write(path string, entries []Entry) error {
+	data, err := Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, data, 0644)
+}
+
+// Marshal validates entries and returns them as lock file contents.
+func Marshal(entries []Entry) ([]byte, error) {
+	if err := Validate(entries); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(File{Version: Version, Engine: EngineRE2, Policies: entries}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Read loads and validates the lock file at path.
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates lock file contents.
+func Parse(data []byte) (*File, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	switch f.Version {
+	case Version:
+	case 1:
+		upgradeV1(f.Policies)
+		f.Version = Version
+	default:
+		return nil, fmt.Errorf("invalid %s: unsupported version %d", FileName, f.Version)
+	}
+	if f.Engine != EngineRE2 {
+		return nil, fmt.Errorf("invalid %s: engine %q (want %q)", FileName, f.Engine, EngineRE2)
+	}
+	if err := Validate(f.Policies); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return &f, nil
+}
+
+// upgradeV1 reads version 1 entries the way they were enforced. Before
+// version 2 a policy's file patterns stopped every change whatever its
+// action, and builtins were pinned as delete, so delete now reads as
+// modify; relocking records the action each phrase asks for.
+func upgradeV1(entries []Entry) {
+	for _, e := range entries {
+		if e.Policy != nil && e.Policy.Action == policy.ActionDelete {
+			e.Policy.Action = policy.ActionModify
+		}
+	}
+}
+
+// Put replaces the entry with e's source, or appends e.
+func Put(entries []Entry, e Entry) []Entry {
+	for i := range entries {
+		if entries[i].Source == e.Source {
+			entries[i] = e
+			return entries
+		}
+	}
+	return append(entries, e)
+}
+
+// Lookup returns the pinned policy for a phrase, or nil. A nil File has
+// no policies.
+func (f *File) Lookup(source string) *policy.Policy {
+	if f == nil {
+		return nil
+	}
+	for _, e := range f.Policies {
+		if e.Source == source {
+			return e.Policy
+		}
+	}
+	return nil
+}
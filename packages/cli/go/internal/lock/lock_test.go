@@ -0,0 +1,65 @@
+package lock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestParseUpgradesV1(t *testing.T) {
+	f, err := Parse([]byte(`{"version": 1, "engine": "re2", "policies": [
+		{"source": "protect .env", "policy": {"action": "delete", "include": ["**/.env"], "description": "Environment files"}},
+		{"source": "no sudo", "policy": {"action": "execute", "description": "No sudo", "commandRules": [{"block": ["sudo *"], "reason": "no"}]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != Version {
+		t.Errorf("Version = %d, want %d", f.Version, Version)
+	}
+	// Version 1 delete policies stopped every change
+	if got := f.Lookup("protect .env").Action; got != policy.ActionModify {
+		t.Errorf("protect .env action = %s, want modify", got)
+	}
+	if got := f.Lookup("no sudo").Action; got != policy.ActionExecute {
+		t.Errorf("no sudo action = %s, want execute", got)
+	}
+
+	if _, err := Parse([]byte(`{"version": 3, "engine": "re2", "policies": []}`)); err == nil {
+		t.Error("parsed a version from the future")
+	}
+}
+
+func TestBuiltPolicyRoundTrip(t *testing.T) {
+	p, err := policy.NewBuilder("Keep migrations immutable").
+		Action(policy.ActionDelete).
+		Protect("db/migrations/**").
+		Except("db/migrations/README.md").
+		Commands(policy.NewCommandRule("migrations are applied", "rm db/migrations/*")).
+		Content(policy.NewContentRule(`DROP\s+TABLE`, "no dropping tables", "*.sql")).
+		Env(policy.NewEnvRule("keep the URL secret", "DATABASE_URL")).
+		Allow(policy.AllowRule{Paths: []string{"db/migrations/scratch/**"}}).
+		When(&policy.Condition{Agents: []string{"cursor"}}).
+		Decision(policy.DecisionAsk).
+		Severity(policy.SeverityHigh).
+		Locked().
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Marshal([]Entry{{Source: "migrations", Policy: p, Registered: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(Marshal()) = %v", err)
+	}
+	if got := f.Lookup("migrations"); !reflect.DeepEqual(got, p) {
+		t.Errorf("round trip changed the policy:\n got %+v\nwant %+v", got, p)
+	}
+	if !f.Policies[0].Registered {
+		t.Error("round trip lost Registered")
+	}
+}
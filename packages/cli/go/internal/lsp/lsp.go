@@ -0,0 +1,361 @@
+// Package lsp serves veto's policies to editors over the Language Server
+// Protocol on stdin and stdout. Open documents get a diagnostic for every
+// content rule they break, and hovering one shows the policy's reason and
+// suggestion, so an editor extension reuses the Go matcher rather than
+// reimplementing rules.
+//
+// Besides the standard methods, the server answers veto/check with the
+// CheckResult for a policy.CheckRequest, for extensions that check
+// commands or paths.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/jsonrpc"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// MethodCheck is the veto-specific method checking a policy.CheckRequest.
+const MethodCheck = "veto/check"
+
+// Server answers one editor session.
+type Server struct {
+	conn    *jsonrpc.Conn
+	version string
+
+	// docs holds open documents' text by URI
+	docs     map[string]string
+	projects jsonrpc.Projects
+	// shutdown is set once the editor asks the server to stop
+	shutdown bool
+}
+
+// NewServer returns a server reading requests from r and writing replies
+// to w. version is reported to the editor.
+func NewServer(r io.Reader, w io.Writer, version string) *Server {
+	return &Server{
+		conn:     jsonrpc.NewConn(r, w, jsonrpc.Headers),
+		version:  version,
+		docs:     make(map[string]string),
+		projects: make(jsonrpc.Projects),
+	}
+}
+
+// Serve handles messages until the editor sends exit or closes the
+// stream. Exiting without a shutdown request first is an error, as the
+// protocol asks.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var perr *jsonrpc.Error
+		if errors.As(err, &perr) {
+			s.conn.Write(jsonrpc.Message{Error: perr})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		result, rerr := s.dispatch(msg)
+		s.conn.Reply(msg, result, rerr)
+	}
+}
+
+func (s *Server) dispatch(msg *jsonrpc.Message) (any, *jsonrpc.Error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": 1, // full document on every change
+				"hoverProvider":    true,
+			},
+			"serverInfo": map[string]string{"name": "veto", "version": s.version},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   documentID `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+	case "textDocument/didSave":
+		var p struct {
+			TextDocument documentID `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		// A saved .veto changes what every open document breaks
+		if filepath.Base(uriPath(p.TextDocument.URI)) == ".veto" {
+			for uri, text := range s.docs {
+				s.update(uri, text)
+			}
+		}
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument documentID `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		delete(s.docs, p.TextDocument.URI)
+		s.publish(p.TextDocument.URI, nil)
+	case "textDocument/hover":
+		var p struct {
+			TextDocument documentID `json:"textDocument"`
+			Position     Position   `json:"position"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		if h := s.hover(p.TextDocument.URI, p.Position); h != nil {
+			return h, nil
+		}
+	case MethodCheck:
+		var req policy.CheckRequest
+		if err := json.Unmarshal(msg.Params, &req); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		result, err := s.check(&req)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInternalError, Message: err.Error()}
+		}
+		return result, nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+	default:
+		if msg.ID != nil {
+			return nil, jsonrpc.MethodNotFound(msg.Method)
+		}
+	}
+	return nil, nil
+}
+
+type documentID struct {
+	URI string `json:"uri"`
+}
+
+// Diagnostic severities.
+const (
+	severityError   = 1
+	severityWarning = 2
+	severityInfo    = 3
+	severityHint    = 4
+)
+
+// Position is a zero-based line and UTF-16 column.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is a content rule violation shown in the editor.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// Diagnostics converts content rule matches in text to diagnostics. The
+// result is never nil, so it encodes as a JSON array.
+func Diagnostics(text string, matches []matcher.ContentMatch) []Diagnostic {
+	diags := []Diagnostic{}
+	for _, m := range matches {
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: position(text, m.Start), End: position(text, m.End)},
+			Severity: severity(m),
+			Code:     m.Policy.Description,
+			Source:   "veto",
+			Message:  m.Rule.Reason,
+		})
+	}
+	return diags
+}
+
+// update stores a document's text and publishes its diagnostics.
+func (s *Server) update(uri, text string) {
+	s.docs[uri] = text
+	s.publish(uri, Diagnostics(text, s.matches(uri, text)))
+}
+
+func (s *Server) publish(uri string, diags []Diagnostic) {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	s.conn.Notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": diags})
+}
+
+// hover describes the violation under pos, or returns nil.
+func (s *Server) hover(uri string, pos Position) any {
+	text, ok := s.docs[uri]
+	if !ok {
+		return nil
+	}
+	at := offset(text, pos)
+	for _, m := range s.matches(uri, text) {
+		if at < m.Start || at > m.End {
+			continue
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "**veto: %s** (%s)\n\n%s", m.Policy.Description, m.Decision, m.Rule.Reason)
+		if m.Rule.Suggest != "" {
+			fmt.Fprintf(&b, "\n\nTry: %s", m.Rule.Suggest)
+		}
+		return map[string]any{
+			"contents": map[string]string{"kind": "markdown", "value": b.String()},
+			"range":    Range{Start: position(text, m.Start), End: position(text, m.End)},
+		}
+	}
+	return nil
+}
+
+// matches returns the content rule matches in a document, or nothing
+// when it isn't a file in a project with a .veto.
+func (s *Server) matches(uri, text string) []matcher.ContentMatch {
+	path := uriPath(uri)
+	if path == "" {
+		return nil
+	}
+	p, err := s.projects.Find(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(p.Root, path)
+	if err != nil {
+		rel = path
+	}
+	return p.Set.FindContent(filepath.ToSlash(rel), text)
+}
+
+// check validates a request against the project governing it.
+func (s *Server) check(req *policy.CheckRequest) (*policy.CheckResult, error) {
+	dir := req.Cwd
+	if filepath.IsAbs(req.Target) {
+		dir = filepath.Dir(req.Target)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("request needs an absolute target or cwd")
+	}
+	p, err := s.projects.Find(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return &policy.CheckResult{Allowed: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Check(req), nil
+}
+
+func severity(m matcher.ContentMatch) int {
+	if m.Monitored {
+		return severityHint
+	}
+	switch m.Decision {
+	case policy.DecisionAsk:
+		return severityWarning
+	case policy.DecisionWarn:
+		return severityInfo
+	}
+	return severityError
+}
+
+// uriPath returns the local path of a file:// URI, or "".
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	// file:///C:/x on Windows
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// position converts a byte offset in text to an LSP position.
+func position(text string, offset int) Position {
+	var pos Position
+	for i, r := range text {
+		if i >= offset {
+			break
+		}
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character += utf16Len(r)
+	}
+	return pos
+}
+
+// offset converts an LSP position to a byte offset in text, clamped to
+// the end of its line.
+func offset(text string, pos Position) int {
+	line, col := 0, 0
+	for i, r := range text {
+		if line == pos.Line && (col >= pos.Character || r == '\n') {
+			return i
+		}
+		if r == '\n' {
+			line++
+			continue
+		}
+		if line == pos.Line {
+			col += utf16Len(r)
+		}
+	}
+	return len(text)
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
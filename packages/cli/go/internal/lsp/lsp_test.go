@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/jsonrpc"
+)
+
+func frame(t *testing.T, msgs ...map[string]any) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		m["jsonrpc"] = "2.0"
+		body, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return &buf
+}
+
+// replies reads every message the server wrote.
+func replies(t *testing.T, out *bytes.Buffer) []jsonrpc.Message {
+	t.Helper()
+	conn := jsonrpc.NewConn(out, nil, jsonrpc.Headers)
+	var msgs []jsonrpc.Message
+	for {
+		msg, err := conn.Read()
+		if err != nil {
+			return msgs
+		}
+		msgs = append(msgs, *msg)
+	}
+}
+
+func TestSession(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("no console.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(root, "src", "app.ts"))}).String()
+	text := "const x = 1\n// héllo\nconsole.log(x)\n"
+
+	in := frame(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "typescript", "version": 1, "text": text},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/hover", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri}, "position": map[string]int{"line": 2, "character": 3},
+		}},
+		map[string]any{"id": 3, "method": "veto/check", "params": map[string]any{
+			"action": "execute", "command": "ls", "cwd": root,
+		}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out bytes.Buffer
+	if err := NewServer(in, &out, "1.2.3").Serve(); err != nil {
+		t.Fatalf("Serve() = %v", err)
+	}
+
+	msgs := replies(t, &out)
+	if len(msgs) != 5 {
+		t.Fatalf("got %d messages, want 5: %+v", len(msgs), msgs)
+	}
+
+	var diags struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if msgs[1].Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("message 2 is %q, want diagnostics", msgs[1].Method)
+	}
+	if err := json.Unmarshal(msgs[1].Params, &diags); err != nil {
+		t.Fatal(err)
+	}
+	if len(diags.Diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags.Diagnostics))
+	}
+	d := diags.Diagnostics[0]
+	if d.Range.Start != (Position{Line: 2, Character: 0}) || d.Range.End != (Position{Line: 2, Character: 12}) {
+		t.Errorf("range = %+v", d.Range)
+	}
+	if d.Severity != severityError || !strings.Contains(d.Message, "console.log") {
+		t.Errorf("diagnostic = %+v", d)
+	}
+
+	hover, _ := json.Marshal(msgs[2].Result)
+	if !strings.Contains(string(hover), "No console.log in production code") {
+		t.Errorf("hover = %s, want the policy", hover)
+	}
+
+	check, _ := json.Marshal(msgs[3].Result)
+	if !strings.Contains(string(check), `"allowed":true`) {
+		t.Errorf("veto/check = %s, want allowed", check)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	in := frame(t, map[string]any{"method": "exit"})
+	if err := NewServer(in, &bytes.Buffer{}, "").Serve(); err == nil {
+		t.Error("Serve() = nil, want an error")
+	}
+}
+
+func TestPositions(t *testing.T) {
+	text := "a😀b\nxyz"
+	for _, tc := range []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{1, Position{0, 1}},
+		{5, Position{0, 3}}, // the emoji is two UTF-16 units
+		{7, Position{1, 0}},
+		{9, Position{1, 2}},
+	} {
+		if got := position(text, tc.offset); got != tc.pos {
+			t.Errorf("position(%d) = %+v, want %+v", tc.offset, got, tc.pos)
+		}
+		if got := offset(text, tc.pos); got != tc.offset {
+			t.Errorf("offset(%+v) = %d, want %d", tc.pos, got, tc.offset)
+		}
+	}
+}
+
+func TestDiagnosticsEncodeAsArray(t *testing.T) {
+	data, err := json.Marshal(Diagnostics("", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("Diagnostics with no matches = %s, want []", data)
+	}
+}
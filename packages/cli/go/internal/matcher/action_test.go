@@ -0,0 +1,59 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestFileActions(t *testing.T) {
+	ops := []policy.Action{policy.ActionRead, policy.ActionCreate, policy.ActionModify,
+		policy.ActionRename, policy.ActionMove, policy.ActionDelete}
+	// Which of ops each policy action blocks, in the same order
+	tests := map[policy.Action]string{
+		policy.ActionRead:    "RCMNVD",
+		policy.ActionModify:  "-CMNVD",
+		policy.ActionExecute: "-CMNVD",
+		"":                   "-CMNVD",
+		policy.ActionDelete:  "---NVD",
+		policy.ActionRename:  "---NV-",
+		policy.ActionMove:    "----V-",
+		policy.ActionCreate:  "-C----",
+	}
+	for action, want := range tests {
+		m, err := New(&policy.Policy{Action: action, Description: "tests", Include: []string{"**/*.test.ts"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []byte("------")
+		for i, op := range ops {
+			if !m.Check(&policy.CheckRequest{Action: string(op), Target: "src/a.test.ts"}).Allowed {
+				got[i] = want[i]
+			}
+		}
+		if string(got) != want {
+			t.Errorf("%q policy blocks %s, want %s", action, got, want)
+		}
+	}
+}
+
+func TestRenameOntoProtectedPath(t *testing.T) {
+	m, err := New(&policy.Policy{Action: policy.ActionModify, Description: "Environment files", Include: []string{"**/.env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req     policy.CheckRequest
+		allowed bool
+	}{
+		{policy.CheckRequest{Action: "rename", Target: "app/.env", Destination: "app/env.bak"}, false},
+		{policy.CheckRequest{Action: "move", Target: "/tmp/evil", Destination: "app/.env"}, false},
+		{policy.CheckRequest{Action: "rename", Target: "app/a.txt", Destination: "app/b.txt"}, true},
+		{policy.CheckRequest{Action: "read", Target: "app/.env"}, true},
+	}
+	for _, tt := range tests {
+		if got := m.Check(&tt.req); got.Allowed != tt.allowed {
+			t.Errorf("%s %s → %s: allowed = %v, want %v", tt.req.Action, tt.req.Target, tt.req.Destination, got.Allowed, tt.allowed)
+		}
+	}
+}
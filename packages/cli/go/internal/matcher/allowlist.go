@@ -0,0 +1,104 @@
+package matcher
+
+import (
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// checkListedFile checks a file against an allowlist policy: files its
+// include patterns don't cover, or its exclude patterns rescue, are
+// outside the allowlist's reach; the rest must match a file allow rule.
+// path is normalized.
+func (m *Matcher) checkListedFile(path string) *policy.CheckResult {
+	if len(m.includeGlobs) > 0 && !matchAnyGlob(m.includeGlobs, path) || matchAnyGlob(m.excludeGlobs, path) {
+		return &policy.CheckResult{Allowed: true}
+	}
+	listed := false
+	for _, rule := range m.allowRules {
+		if len(rule.commands) > 0 || len(rule.paths) == 0 {
+			continue
+		}
+		listed = true
+		if matchAnyGlob(rule.paths, path) {
+			return &policy.CheckResult{Allowed: true}
+		}
+	}
+	if !listed {
+		return &policy.CheckResult{Allowed: true}
+	}
+	return &policy.CheckResult{
+		Allowed: false,
+		Reason:  m.policy.Description + " (" + path + " isn't on the allowlist)",
+		Suggest: "allowed: " + strings.Join(m.listed(false), ", "),
+		Rule:    policy.RuleAllowlist,
+	}
+}
+
+// checkListedCommands checks each command Analyze unpacked from a
+// command line against an allowlist policy's command allow rules, in
+// cwd. Commands that can't be analyzed can't be shown to be on the list
+// and are blocked.
+func (m *Matcher) checkListedCommands(a *Analysis, cwd string) *policy.CheckResult {
+	if cwd = NormalizePath(cwd); cwd == "." {
+		cwd = ""
+	}
+	var rules []allowRule
+	for _, rule := range m.allowRules {
+		if len(rule.commands) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return &policy.CheckResult{Allowed: true}
+	}
+	block := func(reason string) *policy.CheckResult {
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  m.policy.Description + " (" + reason + ")",
+			Suggest: "allowed: " + strings.Join(m.listed(true), ", "),
+			Rule:    policy.RuleAllowlist,
+		}
+	}
+	if len(a.Opaque) > 0 {
+		return block("can't tell what " + a.Opaque[0] + " runs")
+	}
+	for _, part := range a.Commands {
+		if !listedCommand(rules, part, cwd) {
+			return block(part + " isn't on the allowlist")
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
+
+// listedCommand reports whether a command, or one of its alias
+// expansions, matches one of rules in cwd.
+func listedCommand(rules []allowRule, cmd, cwd string) bool {
+	variations := expandAliases(cmd)
+	for _, rule := range rules {
+		if len(rule.paths) > 0 && !matchAnyGlob(rule.paths, cwd) {
+			continue
+		}
+		for _, v := range variations {
+			if matchAnyCommand(rule.commands, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listed returns the patterns an allowlist permits, commands or files,
+// for suggestions.
+func (m *Matcher) listed(commands bool) []string {
+	var patterns []string
+	for _, rule := range m.policy.Allow {
+		switch {
+		case commands && len(rule.Commands) > 0:
+			patterns = append(patterns, rule.Commands...)
+		case !commands && len(rule.Commands) == 0:
+			patterns = append(patterns, rule.Paths...)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,74 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// languages maps file extensions to the languages AST rules name, as
+// the TS engine detects them.
+var languages = map[string]string{
+	".ts": "typescript", ".mts": "typescript", ".cts": "typescript", ".tsx": "tsx",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "jsx",
+	".py": "python", ".pyw": "python", ".pyi": "python",
+	".go": "go", ".rs": "rust",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".cc": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hxx": "cpp",
+	".java": "java", ".kt": "kotlin", ".kts": "kotlin",
+	".rb": "ruby", ".rake": "ruby", ".gemspec": "ruby", ".php": "php",
+	".sh": "bash", ".bash": "bash", ".zsh": "bash",
+}
+
+// dialects are languages parsed with another's rules.
+var dialects = map[string]string{"tsx": "typescript", "jsx": "javascript"}
+
+// Language returns the language of a file for AST rules, or "" when the
+// TS engine doesn't parse it.
+func Language(file string) string {
+	return languages[strings.ToLower(path.Ext(file))]
+}
+
+// speaks reports whether an AST rule for langs applies to lang. A rule
+// naming no languages applies to all of them.
+func speaks(langs []string, lang string) bool {
+	if len(langs) == 0 {
+		return true
+	}
+	for _, l := range langs {
+		if l == lang || l == dialects[lang] {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAST reports where the policy's AST rules may match content. The Go
+// engine doesn't parse code, so each occurrence of a rule's
+// regexPreFilter, the text the TS engine requires before running the
+// query, stands for a possible match. Rules without one, and files in
+// languages the TS engine doesn't parse, are skipped.
+func (m *Matcher) FindAST(file, content string) []ContentMatch {
+	lang := Language(file)
+	if lang == "" {
+		return nil
+	}
+	var matches []ContentMatch
+	for i := range m.policy.ASTRules {
+		rule := &m.policy.ASTRules[i]
+		text := rule.RegexPreFilter
+		if text == "" || !speaks(rule.Languages, lang) {
+			continue
+		}
+		for from := 0; ; {
+			j := strings.Index(content[from:], text)
+			if j < 0 {
+				break
+			}
+			start := from + j
+			matches = append(matches, ContentMatch{Start: start, End: start + len(text), Policy: m.policy, AST: rule, ID: policy.RuleID(policy.RuleAST, i)})
+			from = start + len(text)
+		}
+	}
+	return matches
+}
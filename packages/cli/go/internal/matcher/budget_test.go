@@ -0,0 +1,63 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestChangeBudgetPhrase(t *testing.T) {
+	tests := map[string]int{
+		"change budget: 20":           20,
+		"Change budget 5 files":       5,
+		"max 20 files per session":    20,
+		"max 3 changed files":         3,
+		"limit changes to 50 files":   50,
+		"max files":                   0,
+		"no force push":               0,
+		"change budget: twenty files": 0,
+	}
+	for phrase, want := range tests {
+		if got := builtin.ChangeBudget(phrase); got != want {
+			t.Errorf("ChangeBudget(%q) = %d, want %d", phrase, got, want)
+		}
+	}
+}
+
+func TestChangeBudget(t *testing.T) {
+	b := builtin.Find("max 2 files per session")
+	if b == nil || b.MaxFiles != 2 {
+		t.Fatalf("max 2 files per session compiled to %+v", b)
+	}
+	p := b.ToPolicy(policy.ActionModify)
+	p.Decision = policy.DecisionAsk
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req  policy.CheckRequest
+		want bool
+	}{
+		{policy.CheckRequest{Action: "modify", Target: "a.go", SessionFiles: 1}, true},
+		{policy.CheckRequest{Action: "modify", Target: "b.go", SessionFiles: 2}, true},
+		{policy.CheckRequest{Action: "modify", Target: "c.go", SessionFiles: 3}, false},
+		{policy.CheckRequest{Action: "delete", Target: "d.go", SessionFiles: 4}, false},
+		{policy.CheckRequest{Action: "read", Target: "e.go", SessionFiles: 5}, true},
+		{policy.CheckRequest{Action: "execute", Command: "go test ./...", SessionFiles: 5}, true},
+		// Without a daemon counting, nothing is over budget
+		{policy.CheckRequest{Action: "modify", Target: "f.go"}, true},
+	}
+	for _, tt := range tests {
+		result := s.Check(&tt.req)
+		if result.Allowed != tt.want {
+			t.Errorf("%s %s with %d session files: allowed = %t, want %t (%s)",
+				tt.req.Action, tt.req.Target, tt.req.SessionFiles, result.Allowed, tt.want, result.Reason)
+		}
+		if !result.Allowed && result.Decision != policy.DecisionAsk {
+			t.Errorf("%s: decision %q, want ask", tt.req.Target, result.Decision)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// commandAliases maps short command forms to their full forms so a rule
+// written against one also catches the other. Mirrors the TS engine.
+var commandAliases = []struct {
+	alias      string
+	expansions []string
+}{
+	// npm
+	{"npm i", []string{"npm install"}},
+	{"npm ci", []string{"npm clean-install"}},
+	{"npm r", []string{"npm remove", "npm uninstall"}},
+	{"npm rm", []string{"npm remove", "npm uninstall"}},
+	{"npm un", []string{"npm uninstall"}},
+	{"npm ls", []string{"npm list"}},
+	// yarn
+	{"yarn", []string{"yarn install"}},
+	// pnpm
+	{"pnpm i", []string{"pnpm install"}},
+	{"pnpm rm", []string{"pnpm remove"}},
+	// bun
+	{"bun i", []string{"bun install"}},
+	{"bun a", []string{"bun add"}},
+	{"bun rm", []string{"bun remove"}},
+	// git
+	{"git co", []string{"git checkout"}},
+	{"git ci", []string{"git commit"}},
+	{"git st", []string{"git status"}},
+	{"git br", []string{"git branch"}},
+}
+
+// expandAliases returns the normalized command plus its alias expansions.
+func expandAliases(cmd string) []string {
+	normalized := strings.TrimSpace(strings.ToLower(cmd))
+	expanded := []string{normalized}
+	for _, a := range commandAliases {
+		if suffix, ok := strings.CutPrefix(normalized, a.alias); ok {
+			for _, e := range a.expansions {
+				expanded = append(expanded, e+suffix)
+			}
+		}
+	}
+	return expanded
+}
+
+var subshell = regexp.MustCompile(`^(?:bash|sh|zsh)\s+(?:-c\s+)?["'](.+)["']$`)
+
+// SplitCommands splits a shell command line into its individual commands
+// on &&, ||, ;, | and newlines, outside quotes and subshells. The inner
+// command of `bash -c "..."` is split and appended as well.
+func SplitCommands(full string) []string {
+	var expanded []string
+	for _, cmd := range splitShell(full, true) {
+		expanded = append(expanded, cmd)
+		if m := subshell.FindStringSubmatch(cmd); m != nil {
+			expanded = append(expanded, SplitCommands(m[1])...)
+		}
+	}
+	return expanded
+}
+
+// splitShell splits a command line on &&, ||, ; and newlines, and on |
+// when pipes is set, outside quotes and subshells.
+func splitShell(full string, pipes bool) []string {
+	var commands []string
+	var current strings.Builder
+	depth := 0
+	var inQuote byte
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			commands = append(commands, s)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(full); i++ {
+		c := full[i]
+
+		if (c == '"' || c == '\'') && (i == 0 || full[i-1] != '\\') {
+			if inQuote == c {
+				inQuote = 0
+			} else if inQuote == 0 {
+				inQuote = c
+			}
+			current.WriteByte(c)
+			continue
+		}
+
+		if inQuote == 0 {
+			switch c {
+			case '(', '{':
+				depth++
+			case ')', '}':
+				depth--
+			}
+		}
+
+		if depth == 0 && inQuote == 0 {
+			next := byte(0)
+			if i+1 < len(full) {
+				next = full[i+1]
+			}
+			switch {
+			case c == '&' && next == '&', c == '|' && next == '|':
+				flush()
+				i++
+				continue
+			case c == ';', c == '\n':
+				flush()
+				continue
+			case c == '|' && pipes:
+				flush()
+				continue
+			}
+		}
+
+		current.WriteByte(c)
+	}
+	flush()
+	return commands
+}
+
+// normalizeCommand lowercases a command and collapses whitespace.
+func normalizeCommand(cmd string) string {
+	return strings.Join(strings.Fields(strings.ToLower(cmd)), " ")
+}
+
+// commandPattern is a compiled command rule pattern with the TS engine's
+// semantics:
+//
+//   - "npm install" matches exactly or as a prefix ("npm install lodash")
+//   - "npm install*" matches from the start of the command
+//   - "*lodash*" matches anywhere
+//
+// Matching is case-insensitive and whitespace-insensitive.
+type commandPattern struct {
+	literal string    // the normalized pattern
+	prefix  string    // text that must start the command
+	rest    glob.Glob // matches what follows prefix; nil matches anything
+	wild    bool      // pattern contains wildcards
+}
+
+func compileCommand(pattern string) (*commandPattern, error) {
+	p := normalizeCommand(pattern)
+	cp := &commandPattern{literal: p, wild: strings.ContainsAny(p, "*?")}
+	if !cp.wild {
+		return cp, nil
+	}
+
+	// Leading "*" (or only "?") patterns glob the whole command; otherwise
+	// the literal text before the first "*" must start the command.
+	if i := strings.Index(p, "*"); i > 0 {
+		cp.prefix, p = p[:i], p[i:]
+	}
+	if p == "*" {
+		return cp, nil
+	}
+	// Commands aren't paths: "*" also spans the slashes in URLs and paths
+	g, err := compileGlob(p)
+	if err != nil {
+		return nil, err
+	}
+	cp.rest = g
+	return cp, nil
+}
+
+// Match reports whether cmd matches the pattern.
+func (c *commandPattern) Match(cmd string) bool {
+	cmd = normalizeCommand(cmd)
+	if cmd == c.literal {
+		return true
+	}
+	if !c.wild {
+		return strings.HasPrefix(cmd, c.literal+" ")
+	}
+	rest, ok := strings.CutPrefix(cmd, c.prefix)
+	if !ok {
+		return false
+	}
+	return c.rest == nil || c.rest.Match(rest)
+}
@@ -0,0 +1,229 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Commit is a git commit run with its message on the command line.
+type Commit struct {
+	// Args are the command's words without the message options (and
+	// -F - for a message read from stdin), for rebuilding it with
+	// another message
+	Args []string
+	// Message is the -m paragraphs joined as git joins them, followed by
+	// any --trailer lines
+	Message string
+}
+
+// gitValueOptions are git's global options that take a separate value.
+var gitValueOptions = map[string]bool{"-C": true, "-c": true, "--git-dir": true, "--work-tree": true, "--namespace": true, "--exec-path": true}
+
+// commitValueOptions are git commit's options that take a separate
+// value, other than the message ones.
+var commitValueOptions = map[string]bool{
+	"--author": true, "--date": true, "--template": true, "--reuse-message": true,
+	"--reedit-message": true, "--fixup": true, "--squash": true, "--cleanup": true,
+	"--pathspec-from-file": true,
+}
+
+// gitSubcommand returns the index of git's subcommand in words, past its
+// global options, or -1 when words don't run git.
+func gitSubcommand(words []string) int {
+	if len(words) == 0 || path.Base(words[0]) != "git" {
+		return -1
+	}
+	for i := 1; i < len(words); i++ {
+		switch w := words[i]; {
+		case gitValueOptions[w]:
+			i++
+		case !strings.HasPrefix(w, "-"):
+			return i
+		}
+	}
+	return -1
+}
+
+// commitMessage returns the commit a git commit command makes with a
+// message given by -m, --message or --trailer, or with stdin (-F -)
+// when it reads the message from there.
+func commitMessage(words []string) (c Commit, stdin, ok bool) {
+	sub := gitSubcommand(words)
+	if sub < 0 || words[sub] != "commit" {
+		return Commit{}, false, false
+	}
+	var paragraphs, trailers []string
+	c.Args = append(c.Args, words[:sub+1]...)
+	rest := words[sub+1:]
+	for i := 0; i < len(rest); i++ {
+		w := rest[i]
+		next := func() string {
+			if i+1 < len(rest) {
+				i++
+				return rest[i]
+			}
+			return ""
+		}
+		name, value, attached := strings.Cut(w, "=")
+		switch {
+		case w == "--":
+			c.Args = append(c.Args, rest[i:]...)
+			i = len(rest)
+		case name == "--message":
+			if !attached {
+				value = next()
+			}
+			paragraphs = append(paragraphs, value)
+		case name == "--trailer":
+			if !attached {
+				value = next()
+			}
+			trailers = append(trailers, value)
+		case name == "--file":
+			if !attached {
+				value = next()
+			}
+			if value == "-" {
+				stdin = true
+			} else {
+				c.Args = append(c.Args, "--file="+value)
+			}
+		case strings.HasPrefix(w, "--"):
+			c.Args = append(c.Args, w)
+			if commitValueOptions[w] {
+				c.Args = append(c.Args, next())
+			}
+		case strings.HasPrefix(w, "-") && len(w) > 1:
+			// A cluster of short options, the last of which may take the
+			// rest of the word or the next one as its value
+			var flags strings.Builder
+		cluster:
+			for j := 1; j < len(w); j++ {
+				if !strings.ContainsRune("mFCct", rune(w[j])) {
+					flags.WriteByte(w[j])
+					continue
+				}
+				opt := w[j]
+				value := w[j+1:]
+				if value == "" {
+					value = next()
+				}
+				switch opt {
+				case 'm':
+					paragraphs = append(paragraphs, value)
+				case 'F':
+					if value == "-" {
+						stdin = true
+					} else {
+						c.Args = append(c.Args, "-F", value)
+					}
+				default:
+					c.Args = append(c.Args, "-"+string(opt), value)
+				}
+				break cluster
+			}
+			if flags.Len() > 0 {
+				c.Args = append(c.Args, "-"+flags.String())
+			}
+		default:
+			c.Args = append(c.Args, w)
+		}
+	}
+	c.Message = strings.Join(paragraphs, "\n\n")
+	if len(trailers) > 0 {
+		if c.Message != "" {
+			c.Message += "\n\n"
+		}
+		c.Message += strings.Join(trailers, "\n")
+	}
+	return c, stdin, c.Message != "" || stdin
+}
+
+// names reports whether patterns name file explicitly.
+func names(patterns []string, file string) bool {
+	return len(patterns) > 0 && matchFileTypes(file, patterns)
+}
+
+// checkCommit checks a commit's message against the content and
+// structure rules for policy.CommitMessage. When removing what a content
+// rule matched leaves a message the policy allows, the corrected command
+// is suggested.
+func (m *Matcher) checkCommit(c Commit) *policy.CheckResult {
+	result := m.checkMessage(c.Message)
+	if result.Allowed {
+		return result
+	}
+	result.Reason = "Commit message: " + result.Reason
+	if fixed, ok := m.correct(c.Message); ok {
+		cmd := joinWords(append(c.Args[:len(c.Args):len(c.Args)], "-m", fixed))
+		result.Suggest = cmd
+		result.Alternatives = []policy.Alternative{{
+			Command:     cmd,
+			Description: "Commit with the offending text removed from the message",
+		}}
+	}
+	return result
+}
+
+// checkMessage checks a commit message against the rules for
+// policy.CommitMessage.
+func (m *Matcher) checkMessage(msg string) *policy.CheckResult {
+	for i, rule := range m.policy.ContentRules {
+		if !names(rule.FileTypes, policy.CommitMessage) || !m.contentRules[i].applies(policy.CommitMessage) {
+			continue
+		}
+		// Messages aren't code, so they're matched as written
+		if m.contentRules[i].find(msg, ModeFast) >= 0 {
+			return &policy.CheckResult{
+				Allowed: false,
+				Reason:  rule.Reason,
+				Suggest: rule.Suggest,
+				Rule:    policy.RuleID(policy.RuleContent, i),
+			}
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(msg, "\n"), "\n")
+	for i, rule := range m.policy.StructureRules {
+		if !names(rule.FileTypes, policy.CommitMessage) {
+			continue
+		}
+		if result := m.checkShape(i, policy.CommitMessage, lines, true); !result.Allowed {
+			return result
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
+
+// correct removes what the content rules for policy.CommitMessage match
+// from msg, line by line, dropping lines left empty. It reports false
+// when nothing is left or the policy still blocks the result.
+func (m *Matcher) correct(msg string) (string, bool) {
+	var out []string
+	for _, line := range strings.Split(msg, "\n") {
+		fixed := line
+		for i, rule := range m.policy.ContentRules {
+			if !names(rule.FileTypes, policy.CommitMessage) || !m.contentRules[i].applies(policy.CommitMessage) {
+				continue
+			}
+			locs := m.contentRules[i].findAll(fixed, ModeFast, -1)
+			for j := len(locs) - 1; j >= 0; j-- {
+				fixed = fixed[:locs[j][0]] + fixed[locs[j][1]:]
+			}
+		}
+		if strings.TrimSpace(fixed) == "" && strings.TrimSpace(line) != "" {
+			continue
+		}
+		// A blank line only separates paragraphs
+		if strings.TrimSpace(fixed) == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, strings.TrimRight(fixed, " \t"))
+	}
+	fixed := strings.TrimSpace(strings.Join(out, "\n"))
+	if fixed == "" || fixed == strings.TrimSpace(msg) {
+		return "", false
+	}
+	return fixed, m.checkMessage(fixed).Allowed
+}
@@ -0,0 +1,90 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestCommitMessageArgs(t *testing.T) {
+	tests := []struct {
+		cmd     string
+		message string
+		args    []string
+	}{
+		{`git commit -m "fix: a"`, "fix: a", []string{"git", "commit"}},
+		{`git commit -am 'fix: a' -m 'body'`, "fix: a\n\nbody", []string{"git", "commit", "-a"}},
+		{`git -C sub commit --message="fix: a" --no-verify`, "fix: a", []string{"git", "-C", "sub", "commit", "--no-verify"}},
+		{`git commit -mfix --author "A <a@b>"`, "fix", []string{"git", "commit", "--author", "A <a@b>"}},
+		{`git commit -m x --trailer "Co-authored-by: B <b@c>"`, "x\n\nCo-authored-by: B <b@c>", []string{"git", "commit"}},
+		{`git commit -m x -- a.go`, "x", []string{"git", "commit", "--", "a.go"}},
+	}
+	for _, tt := range tests {
+		a := Analyze(tt.cmd)
+		if len(a.Commits) != 1 {
+			t.Errorf("%s: %d commits, want 1", tt.cmd, len(a.Commits))
+			continue
+		}
+		if c := a.Commits[0]; c.Message != tt.message || !reflect.DeepEqual(c.Args, tt.args) {
+			t.Errorf("%s = %q %q, want %q %q", tt.cmd, c.Message, c.Args, tt.message, tt.args)
+		}
+	}
+
+	for _, cmd := range []string{"git commit", "git commit --amend --no-edit", "git log -m", "echo git commit -m x"} {
+		if a := Analyze(cmd); len(a.Commits) != 0 {
+			t.Errorf("%s: found commits %+v", cmd, a.Commits)
+		}
+	}
+
+	a := Analyze("git commit -F - <<'EOF'\nWIP: parser\n\nmore\nEOF")
+	if len(a.Commits) != 1 || a.Commits[0].Message != "WIP: parser\n\nmore" {
+		t.Errorf("heredoc message = %+v", a.Commits)
+	}
+}
+
+func TestCommitMessagePolicies(t *testing.T) {
+	var policies []*policy.Policy
+	for _, phrase := range []string{"no wip commits", "conventional commits", "no ai attribution", "no console.log"} {
+		b := builtin.Find(phrase)
+		if b == nil {
+			t.Fatalf("no builtin for %q", phrase)
+		}
+		policies = append(policies, b.ToPolicy(policy.ActionModify))
+	}
+	s, err := NewSet(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cmd     string
+		allowed bool
+		suggest string
+	}{
+		{`git commit -m "fix(parser): handle empty input"`, true, ""},
+		{`git commit -m "feat!: drop v1" -m "Removes console.log calls."`, true, ""},
+		{`git commit -m "update stuff"`, false, ""},
+		{`git commit -am "WIP: fix: handle empty input"`, false, `git commit -a -m 'fix: handle empty input'`},
+		{`git commit -m "[wip] half done"`, false, ""},
+		{`git commit -m "fix: wipe the cache on logout"`, true, ""},
+		{
+			"git commit -m 'fix: a' -m 'Details.' -m 'Co-Authored-By: Claude <noreply@anthropic.com>'",
+			false, "git commit -m 'fix: a\n\nDetails.'",
+		},
+		{`git commit -m "fix: a" --trailer "Co-authored-by: GitHub Copilot <x@y>"`, false, `git commit -m 'fix: a'`},
+		{`git commit -m "fix: a" --trailer "Co-authored-by: Ada <ada@example.com>"`, true, ""},
+		{"git commit -F - <<EOF\nchore: bump\n\n🤖 Generated with Claude Code\nEOF", false, `git commit -m 'chore: bump'`},
+	}
+	for _, tt := range tests {
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd})
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s: allowed = %v (%s), want %v", tt.cmd, result.Allowed, result.Reason, tt.allowed)
+			continue
+		}
+		if tt.suggest != "" && result.Suggest != tt.suggest {
+			t.Errorf("%s: suggest = %q, want %q", tt.cmd, result.Suggest, tt.suggest)
+		}
+	}
+}
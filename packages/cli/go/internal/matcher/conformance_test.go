@@ -0,0 +1,54 @@
+package matcher
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// conformanceFixtures is shared with the TS engine's test suite.
+const conformanceFixtures = "../../../test/fixtures/conformance.json"
+
+type conformanceSuite struct {
+	Policies map[string]*policy.Policy `json:"policies"`
+	Cases    []struct {
+		Name     string              `json:"name"`
+		Policy   string              `json:"policy"`
+		Request  policy.CheckRequest `json:"request"`
+		Expected string              `json:"expected"`
+	} `json:"cases"`
+}
+
+// TestConformance runs the fixtures both engines must agree on.
+func TestConformance(t *testing.T) {
+	data, err := os.ReadFile(conformanceFixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite conformanceSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range suite.Cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			p, ok := suite.Policies[tt.Policy]
+			if !ok {
+				t.Fatalf("unknown policy %q", tt.Policy)
+			}
+			m, err := New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := "allow"
+			if !m.Check(&tt.Request).Allowed {
+				got = "deny"
+			}
+			if got != tt.Expected {
+				t.Errorf("got %s, want %s", got, tt.Expected)
+			}
+		})
+	}
+}
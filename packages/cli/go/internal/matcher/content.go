@@ -0,0 +1,183 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// Content rule modes.
+const (
+	// ModeFast matches the raw content (the default)
+	ModeFast = "fast"
+	// ModeStrict strips comments and strings before matching
+	ModeStrict = "strict"
+)
+
+// exceptionContext is how many bytes around a match are checked against
+// a rule's exceptions.
+const exceptionContext = 100
+
+// contentRule is a compiled policy.ContentRule.
+type contentRule struct {
+	pattern    *regexp.Regexp
+	exceptions []*regexp.Regexp
+	fileTypes  []string
+	include    []glob.Glob
+	exclude    []glob.Glob
+}
+
+// applies reports whether a content rule checks the file at p: its file
+// types match, p is under one of its include globs (when it has any) and
+// under none of its exclude globs.
+func (r *contentRule) applies(p string) bool {
+	if !matchFileTypes(p, r.fileTypes) {
+		return false
+	}
+	p = NormalizePath(p)
+	if len(r.include) > 0 && !matchAnyGlob(r.include, p) {
+		return false
+	}
+	return !matchAnyGlob(r.exclude, p)
+}
+
+// find returns the index of the first match in content that isn't
+// excused by an exception, or -1.
+func (r *contentRule) find(content, mode string) int {
+	if locs := r.findAll(content, mode, 1); len(locs) > 0 {
+		return locs[0][0]
+	}
+	return -1
+}
+
+// findAll returns the start and end of up to n matches in content that
+// aren't excused by an exception (all of them when n < 0).
+func (r *contentRule) findAll(content, mode string, n int) [][]int {
+	processed := content
+	if mode == ModeStrict {
+		processed = stripStrings(stripComments(content))
+	}
+	var locs [][]int
+	for _, loc := range r.pattern.FindAllStringIndex(processed, -1) {
+		if len(locs) == n {
+			break
+		}
+		if !r.excepted(content, loc[0], loc[1]) {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+// excepted reports whether an exception matches near content[start:end].
+func (r *contentRule) excepted(content string, start, end int) bool {
+	if len(r.exceptions) == 0 {
+		return false
+	}
+	from := max(0, start-exceptionContext)
+	to := min(len(content), end+exceptionContext)
+	context := content[from:to]
+	for _, ex := range r.exceptions {
+		if ex.MatchString(context) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComments replaces // and /* */ comments with spaces, keeping
+// newlines so line numbers are preserved. String and template literals
+// are left intact.
+func stripComments(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	var inString byte
+	inTemplate := false
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		var next byte
+		if i+1 < len(content) {
+			next = content[i+1]
+		}
+
+		switch {
+		case inString != 0 && c == '\\':
+			b.WriteByte(c)
+			if i+1 < len(content) {
+				b.WriteByte(next)
+			}
+			i++
+		case inString == 0 && !inTemplate && (c == '"' || c == '\'' || c == '`'):
+			if c == '`' {
+				inTemplate = true
+			} else {
+				inString = c
+			}
+			b.WriteByte(c)
+		case inString != 0 && c == inString:
+			inString = 0
+			b.WriteByte(c)
+		case inTemplate:
+			if c == '`' && (i == 0 || content[i-1] != '\\') {
+				inTemplate = false
+			}
+			b.WriteByte(c)
+		case inString != 0:
+			b.WriteByte(c)
+		case c == '/' && next == '/':
+			for i < len(content) && content[i] != '\n' {
+				b.WriteByte(' ')
+				i++
+			}
+			i--
+		case c == '/' && next == '*':
+			b.WriteString("  ")
+			i += 2
+			for ; i < len(content); i++ {
+				if content[i] == '*' && i+1 < len(content) && content[i+1] == '/' {
+					b.WriteString("  ")
+					i++
+					break
+				}
+				if content[i] == '\n' {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// stripStrings blanks the contents of string literals, keeping the quotes
+// and newlines.
+func stripStrings(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	var inString byte
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString != 0 && c == '\\':
+			b.WriteString("  ")
+			i++
+		case inString == 0 && (c == '"' || c == '\'' || c == '`'):
+			inString = c
+			b.WriteByte(c)
+		case inString != 0 && c == inString:
+			inString = 0
+			b.WriteByte(c)
+		case inString != 0 && c != '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestContentRuleScope(t *testing.T) {
+	rule := policy.NewContentRule(`console\.log`, "use the logger", "*.ts")
+	rule.Include = []string{"src/**"}
+	rule.Exclude = []string{"src/dev/**"}
+	m, err := New(&policy.Policy{Description: "No console in src", Action: policy.ActionModify, ContentRules: []policy.ContentRule{rule}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{
+		"src/app.ts":           true,
+		"src/api/handler.ts":   true,
+		"./src/app.ts":         true,
+		"src/app.js":           false,
+		"scripts/seed.ts":      false,
+		"src/dev/fixtures.ts":  false,
+		"test/src/helper.ts":   false,
+		`src\windows\paths.ts`: true,
+	} {
+		if blocked := !m.CheckContent(path, "console.log(1)").Allowed; blocked != want {
+			t.Errorf("%s: blocked = %t, want %t", path, blocked, want)
+		}
+		if concerns := m.Concerns(path); concerns != want {
+			t.Errorf("Concerns(%s) = %t, want %t", path, concerns, want)
+		}
+		if found := len(m.FindContent(path, "console.log(1)")) > 0; found != want {
+			t.Errorf("FindContent(%s) found = %t, want %t", path, found, want)
+		}
+	}
+}
+
+func TestContentRuleScopeInvalid(t *testing.T) {
+	rule := policy.NewContentRule(`debugger`, "remove it")
+	rule.Include = []string{"src/{"}
+	if _, err := New(&policy.Policy{Description: "No debugger", ContentRules: []policy.ContentRule{rule}}); err == nil {
+		t.Error("New() accepted an unclosed include glob")
+	}
+}
@@ -0,0 +1,371 @@
+package matcher
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// copiers copy files to a destination given the way mv's is.
+var copiers = map[string]bool{"cp": true, "scp": true, "rsync": true}
+
+// readers print the files they're given, so redirecting their output
+// copies the files. Their first argument may be a pattern or script
+// rather than a file, which only matters if it names a protected file.
+var readers = map[string]bool{
+	"cat": true, "tac": true, "head": true, "tail": true, "nl": true, "less": true, "more": true,
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "sed": true, "awk": true,
+	"cut": true, "sort": true, "uniq": true, "paste": true, "column": true, "jq": true, "yq": true,
+	"base64": true, "xxd": true, "od": true, "hexdump": true, "strings": true,
+	"gzip": true, "bzip2": true, "xz": true, "zstd": true,
+}
+
+// senders send what they read on stdin to the host they're given.
+var senders = map[string]bool{"nc": true, "ncat": true, "netcat": true, "socat": true, "telnet": true}
+
+// redirectRe matches a redirection: >, >>, >|, 2>, &> or <, followed by
+// its file or alone, with the file in the next word.
+var redirectRe = regexp.MustCompile(`^([0-9]*|&)(>>?|>\||<)(.*)$`)
+
+// redirections splits a command's redirections off its words: the files
+// it reads with <, the files its output goes to with >, >> or &>, and
+// the files only its errors go to with 2>. Redirections to descriptors
+// (2>&1), devices and process substitutions read and write no files, and
+// heredocs are dropped.
+func redirections(words []string) (args, in, out, errs []string) {
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if strings.HasPrefix(w, "<<") {
+			if w == "<<" || w == "<<-" || w == "<<<" {
+				i++
+			}
+			continue
+		}
+		m := redirectRe.FindStringSubmatch(w)
+		if m == nil || strings.HasPrefix(m[3], "(") {
+			args = append(args, w)
+			continue
+		}
+		file := m[3]
+		if file == "" && i+1 < len(words) {
+			i++
+			file = words[i]
+		}
+		switch {
+		case file == "" || strings.HasPrefix(file, "&") || device(file):
+		case m[2] == "<":
+			in = append(in, file)
+		case m[1] == "" || m[1] == "1" || m[1] == "&":
+			out = append(out, file)
+		default:
+			errs = append(errs, file)
+		}
+	}
+	return args, in, out, errs
+}
+
+// device reports whether a path is a device such as /dev/null rather
+// than a file.
+func device(p string) bool {
+	return strings.HasPrefix(p, "/dev/")
+}
+
+// piped returns the files a pipeline writes through redirection or
+// sends over the network. Files its commands print, or read with <, are
+// copied to the files written with > or tee by the same command or one
+// after it, and sent by a later nc or curl -d @-; a file written with
+// nothing printed from files, or only errors, is modified.
+func piped(stages [][]string) []FileAccess {
+	var sources []string
+	var files []FileAccess
+	for _, words := range stages {
+		args, in, out, errs := redirections(words)
+		for _, f := range errs {
+			files = append(files, FileAccess{Path: f, Action: policy.ActionModify})
+		}
+		args = elevated(args)
+		sources = append(sources, in...)
+		sources = append(sources, printed(args)...)
+		if sends(args) {
+			for _, src := range sources {
+				files = append(files, sent(src))
+			}
+		}
+		if len(args) > 0 && path.Base(args[0]) == "tee" {
+			for _, f := range operands(args[1:]) {
+				if !device(f) {
+					out = append(out, f)
+				}
+			}
+		}
+		for _, to := range out {
+			if len(sources) == 0 {
+				files = append(files, FileAccess{Path: to, Action: policy.ActionModify})
+			}
+			for _, src := range sources {
+				files = append(files, copied(src, to))
+			}
+		}
+	}
+	return files
+}
+
+// printed returns the files a command prints to its output: a reader's
+// files, what tar or zip archive to stdout, and dd's input file when it
+// has no output file.
+func printed(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	switch name := path.Base(args[0]); {
+	case readers[name]:
+		return operands(args[1:])
+	case name == "tar" || name == "zip":
+		if archive, sources, _ := archiveArgs(name, args[1:]); archive == "-" || name == "tar" && archive == "" {
+			return sources
+		}
+	case name == "dd":
+		if from, to := ddArgs(args[1:]); from != "" && to == "" {
+			return []string{from}
+		}
+	}
+	return nil
+}
+
+// operands returns the arguments that aren't options.
+func operands(args []string) []string {
+	var files []string
+	options := true
+	for _, arg := range args {
+		switch {
+		case options && arg == "--":
+			options = false
+		case !options || !strings.HasPrefix(arg, "-"):
+			files = append(files, arg)
+		}
+	}
+	return files
+}
+
+// copied describes copying from's content to to.
+func copied(from, to string) FileAccess {
+	return FileAccess{Path: from, Action: policy.ActionCopy, To: to}
+}
+
+// copiedFiles returns the files a cp, scp or rsync with the given
+// arguments copies. A recursive copy takes what's in its sources too,
+// checked as dir/* so patterns like secrets/** match copying secrets/.
+func copiedFiles(args []string) []FileAccess {
+	files := transferred(args, copied)
+	if !recursive(args) {
+		return files
+	}
+	var all []FileAccess
+	for _, f := range files {
+		all = append(all, f, copied(path.Join(f.Path, "*"), path.Join(f.To, "*")))
+	}
+	return all
+}
+
+// recursive reports whether cp-like arguments copy directories with
+// everything in them: -r, -R, -a or their long forms.
+func recursive(args []string) bool {
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case arg == "--recursive" || arg == "--archive":
+			return true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rRa"):
+			return true
+		}
+	}
+	return false
+}
+
+// archived describes putting sources in an archive, with what's in them
+// when the archiver recurses into directories.
+func archived(sources []string, archive string, recursive bool) []FileAccess {
+	var files []FileAccess
+	for _, src := range sources {
+		files = append(files, copied(src, archive))
+		if recursive {
+			files = append(files, copied(path.Join(src, "*"), archive))
+		}
+	}
+	return files
+}
+
+// archiveArgs reads the archive a tar or zip command creates and the
+// files it puts in it. The archive is "-" when it goes to stdout, and
+// "" when the command creates none (tar without -c, which extracts or
+// lists). tar recurses into directories; zip only with -r.
+func archiveArgs(name string, args []string) (archive string, sources []string, recursive bool) {
+	if name == "zip" {
+		for _, arg := range args {
+			switch {
+			case arg == "-x" || arg == "-i" || arg == "--exclude" || arg == "--include":
+				// File patterns follow, not files
+				return archive, sources, recursive
+			case arg == "--recurse-paths" || strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "r"):
+				recursive = true
+			case arg == "-" && archive == "" || !strings.HasPrefix(arg, "-"):
+				if archive == "" {
+					archive = arg
+				} else {
+					sources = append(sources, arg)
+				}
+			}
+		}
+		return archive, sources, recursive
+	}
+
+	create := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--create":
+			create = true
+		case arg == "--file" || arg == "-C" || arg == "--directory":
+			if i+1 < len(args) {
+				i++
+				if arg == "--file" {
+					archive = args[i]
+				}
+			}
+		case strings.HasPrefix(arg, "--file="):
+			archive = strings.TrimPrefix(arg, "--file=")
+		case strings.HasPrefix(arg, "--"):
+		case strings.HasPrefix(arg, "-") || i == 0 && isLetters(arg):
+			// Short options, the first group possibly without its dash
+			// (tar czf backup.tgz ...); f takes the archive
+			flags := strings.TrimPrefix(arg, "-")
+			create = create || strings.Contains(flags, "c")
+			if j := strings.IndexByte(flags, 'f'); j >= 0 {
+				if j+1 < len(flags) && strings.HasPrefix(arg, "-") {
+					archive = flags[j+1:]
+				} else if i+1 < len(args) {
+					i++
+					archive = args[i]
+				}
+			}
+		default:
+			sources = append(sources, arg)
+		}
+	}
+	if !create {
+		return "", nil, false
+	}
+	return archive, sources, true
+}
+
+// isLetters reports whether s is only ASCII letters.
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ddArgs returns the if= and of= files of a dd command.
+func ddArgs(args []string) (from, to string) {
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "if="); ok {
+			from = v
+		} else if v, ok := strings.CutPrefix(arg, "of="); ok {
+			to = v
+		}
+	}
+	return from, to
+}
+
+// sent describes sending a file's content over the network, a copy with
+// no destination on this machine.
+func sent(file string) FileAccess {
+	return FileAccess{Path: file, Action: policy.ActionCopy}
+}
+
+// sends reports whether a command sends its stdin over the network: a
+// sender, or a curl uploading "-".
+func sends(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	name := path.Base(args[0])
+	if senders[name] {
+		return true
+	}
+	if name != "curl" {
+		return false
+	}
+	_, stdin := uploads(args[1:])
+	return stdin
+}
+
+// uploadedFiles returns the files a curl with the given arguments sends.
+func uploadedFiles(args []string) []FileAccess {
+	files, _ := uploads(args)
+	accesses := make([]FileAccess, len(files))
+	for i, f := range files {
+		accesses[i] = sent(f)
+	}
+	return accesses
+}
+
+// uploads reads the files curl arguments send: @file request data and
+// form fields (name=@file or name=<file), and -T uploads. stdin reports
+// an upload of "-", curl's standard input.
+func uploads(args []string) (files []string, stdin bool) {
+	add := func(f string) {
+		if f == "-" {
+			stdin = true
+		} else if f != "" {
+			files = append(files, f)
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		flag, value, attached := args[i], "", false
+		if strings.HasPrefix(flag, "--") {
+			flag, value, attached = strings.Cut(flag, "=")
+		} else if len(flag) > 2 && strings.HasPrefix(flag, "-") {
+			flag, value, attached = flag[:2], flag[2:], true
+		}
+		switch flag {
+		case "-d", "--data", "--data-binary", "--data-ascii", "--data-urlencode", "--json",
+			"-F", "--form", "-T", "--upload-file":
+		default:
+			continue
+		}
+		if !attached {
+			if i+1 >= len(args) {
+				break
+			}
+			i++
+			value = args[i]
+		}
+		switch flag {
+		case "-T", "--upload-file":
+			add(value)
+		case "-F", "--form":
+			// name=@file;type=... attaches a file, name=<file inlines one
+			if _, v, ok := strings.Cut(value, "="); ok && (strings.HasPrefix(v, "@") || strings.HasPrefix(v, "<")) {
+				f, _, _ := strings.Cut(v[1:], ";")
+				add(f)
+			}
+		case "--data-urlencode":
+			// @file or name@file
+			if j := strings.IndexByte(value, '@'); j >= 0 && !strings.Contains(value[:j], "=") {
+				add(value[j+1:])
+			}
+		default:
+			if strings.HasPrefix(value, "@") {
+				add(value[1:])
+			}
+		}
+	}
+	return files, stdin
+}
@@ -0,0 +1,129 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestProtectedDirPhrase(t *testing.T) {
+	tests := map[string]string{
+		"protect dir: infra/":        "infra",
+		"Protect dir: ./infra":       "infra",
+		"protect dir: 'deploy/k8s/'": "deploy/k8s",
+		`protect dir: infra\prod`:    "infra/prod",
+		"protect dir: /etc":          "",
+		"protect dir: ../shared":     "",
+		"protect dir: .":             "",
+		"protect .env":               "",
+	}
+	for phrase, want := range tests {
+		if got := builtin.ProtectedDir(phrase); got != want {
+			t.Errorf("ProtectedDir(%q) = %q, want %q", phrase, got, want)
+		}
+	}
+}
+
+func TestProtectedDir(t *testing.T) {
+	b := builtin.Find("protect dir: infra/")
+	if b == nil {
+		t.Fatal("protect dir: infra/ didn't compile")
+	}
+	m, err := New(b.ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"infra", "infra/main.tf", "infra/modules/vpc/main.tf", "./infra/prod/db.tf", "INFRA/main.tf"} {
+		if m.CheckFile(path, policy.ActionModify).Allowed {
+			t.Errorf("file %q: allowed, want blocked", path)
+		}
+	}
+	for _, path := range []string{"infrastructure.md", "infra.tf", "src/infra/x.ts", "docs/infra/README.md"} {
+		if !m.CheckFile(path, policy.ActionModify).Allowed {
+			t.Errorf("file %q: blocked, want allowed", path)
+		}
+	}
+
+	blocked := []string{
+		"rm -rf infra",
+		"rm -rf infra/",
+		"rm infra/modules/vpc/main.tf",
+		"rm -f ./infra/main.tf",
+		"rm -rf /home/dev/repo/infra",
+		"rm /home/dev/repo/infra/modules/vpc/main.tf",
+		"rm -rf infra build",
+		"rm -rf build ./infra dist",
+		"rmdir infra/modules",
+		"mv infra/main.tf /tmp/",
+		"mv /tmp/main.tf infra/",
+		"mv -f new.tf ./infra/modules/vpc/main.tf",
+		"echo 'x' > infra/main.tf",
+		"echo x >> ./infra/modules/vpc/main.tf",
+		"terraform output >/home/dev/repo/infra/out.json",
+		"cat secrets 2> infra/log",
+		"echo x | tee infra/main.tf",
+		"echo x | tee -a ./infra/main.tf",
+		"cd src && rm -rf ../x; rm -r infra",
+		`bash -c "rm -rf infra"`,
+	}
+	for _, cmd := range blocked {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+
+	allowed := []string{
+		"ls infra",
+		"cat infra/main.tf",
+		"terraform -chdir=infra plan",
+		"rm -rf infrastructure",
+		"rm infra.tf",
+		"rm -rf src/infra",
+		"mv docs/infra.md docs/old.md",
+		"echo x > infra.log",
+		"tee out.log",
+	}
+	for _, cmd := range allowed {
+		if !m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: blocked, want allowed", cmd)
+		}
+	}
+
+	// Copies out of the directory leave it untouched, so they're asked about
+	if got := m.CheckCommand("cat infra/main.tf > /tmp/main.tf"); got.Allowed || got.Decision != policy.DecisionAsk {
+		t.Errorf("copy out of infra/: %+v, want ask", got)
+	}
+}
+
+func TestProtectedDirFromSubdirectory(t *testing.T) {
+	b := builtin.Find("protect dir: infra/")
+	m, err := New(b.ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cwd, cmd string
+		allowed  bool
+	}{
+		{"infra", "rm main.tf", false},
+		{"infra/modules", "rm -rf vpc", false},
+		{"infra", "echo x > ./main.tf", false},
+		{"infra", "mv main.tf ../main.tf", false},
+		{"docs", "rm ../infra/main.tf", false},
+		{"docs", "cp notes.md ../infra/", false},
+		{".", "rm main.tf", true},
+		{"", "rm main.tf", true},
+		{"docs", "rm main.tf", true},
+		{"infra", "rm ../main.tf", true},
+		{"infra", "cat main.tf", true},
+	}
+	for _, tt := range tests {
+		req := &policy.CheckRequest{Action: string(policy.ActionExecute), Command: tt.cmd, Cwd: tt.cwd}
+		if got := m.Check(req); got.Allowed != tt.allowed {
+			t.Errorf("%q in %q: allowed = %v, want %v (%s)", tt.cmd, tt.cwd, got.Allowed, tt.allowed, got.Reason)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// removedFiles returns the files an rm, rmdir or unlink with the given
+// arguments deletes. A recursive rm takes what's in them too, checked as
+// dir/* as copiedFiles does.
+func removedFiles(args []string) []FileAccess {
+	var files []FileAccess
+	deep := recursive(args)
+	for _, p := range operands(args) {
+		files = append(files, FileAccess{Path: p, Action: policy.ActionDelete})
+		if deep {
+			files = append(files, FileAccess{Path: path.Join(p, "*"), Action: policy.ActionDelete})
+		}
+	}
+	return files
+}
+
+// truncatedFiles returns the files a truncate with the given arguments
+// changes the size of, skipping the values of -s and -r.
+func truncatedFiles(args []string) []FileAccess {
+	var files []FileAccess
+	options := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case options && arg == "--":
+			options = false
+		case options && (arg == "-s" || arg == "-r" || arg == "--size" || arg == "--reference"):
+			i++
+		case !options || !strings.HasPrefix(arg, "-"):
+			files = append(files, FileAccess{Path: arg, Action: policy.ActionModify})
+		}
+	}
+	return files
+}
+
+// editedFiles returns the files a sed or perl command edits in place
+// with -i (-i.bak, --in-place), or nil when it doesn't. The script is
+// the first operand unless -e or -f gave it; BSD sed's separate backup
+// suffix, as in sed -i "" ..., is skipped.
+func editedFiles(words []string) []FileAccess {
+	if len(words) == 0 {
+		return nil
+	}
+	name := path.Base(words[0])
+	if name != "sed" && name != "perl" {
+		return nil
+	}
+	args := words[1:]
+	inPlace, script := false, false
+	var files []string
+	options := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !options || !strings.HasPrefix(arg, "-") || arg == "-":
+			files = append(files, arg)
+		case arg == "--":
+			options = false
+		case arg == "--in-place" || strings.HasPrefix(arg, "--in-place="):
+			inPlace = true
+		case arg == "--expression" || arg == "--file":
+			script = true
+			i++
+		case strings.HasPrefix(arg, "--expression=") || strings.HasPrefix(arg, "--file="):
+			script = true
+		case strings.HasPrefix(arg, "--"):
+		default:
+			// Flags cluster (-pi, -ni.bak): -i takes the rest as its
+			// suffix, -e and -f the rest or the next word as the script
+			flags := arg[1:]
+			for j, c := range flags {
+				if c == 'i' {
+					inPlace = true
+					if name == "sed" && j == len(flags)-1 && i+1 < len(args) && args[i+1] == "" {
+						i++
+					}
+					break
+				}
+				if c == 'e' || c == 'E' && name == "perl" || c == 'f' && name == "sed" {
+					script = true
+					if j == len(flags)-1 {
+						i++
+					}
+					break
+				}
+			}
+		}
+	}
+	if !inPlace {
+		return nil
+	}
+	if !script && len(files) > 0 {
+		files = files[1:]
+	}
+	edited := make([]FileAccess, len(files))
+	for i, f := range files {
+		edited[i] = FileAccess{Path: f, Action: policy.ActionModify}
+	}
+	return edited
+}
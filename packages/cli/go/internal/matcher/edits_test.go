@@ -0,0 +1,74 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestEditedFiles(t *testing.T) {
+	del := func(p string) FileAccess { return FileAccess{Path: p, Action: policy.ActionDelete} }
+	mod := func(p string) FileAccess { return FileAccess{Path: p, Action: policy.ActionModify} }
+	tests := map[string][]FileAccess{
+		"rm .env":                          {del(".env")},
+		"rm -f a.txt b.txt":                {del("a.txt"), del("b.txt")},
+		"rm -rf infra":                     {del("infra"), del("infra/*")},
+		"rm -- -x":                         {del("-x")},
+		"sudo rm /etc/hosts":               {del("/etc/hosts")},
+		"rmdir build":                      {del("build")},
+		"unlink src/a.test.ts":             {del("src/a.test.ts")},
+		"truncate -s 0 app.log":            {mod("app.log")},
+		"truncate --size=0 a b":            {mod("a"), mod("b")},
+		"truncate -r ref.txt out.txt":      {mod("out.txt")},
+		"sed -i 's/a/b/' main.go":          {mod("main.go")},
+		"sed -i.bak -e 's/a/b/' a.go b.go": {mod("a.go"), mod("b.go")},
+		"sed -i '' 's/a/b/' main.go":       {mod("main.go")},
+		"sed --in-place -f fix.sed go.mod": {mod("go.mod")},
+		"sed -ni '/x/p' notes.md":          {mod("notes.md")},
+		"sed -E -i 's/a+/b/' x.txt":        {mod("x.txt")},
+		"perl -pi -e 's/a/b/' .env":        {mod(".env")},
+		"perl -i.bak -pe 's/a/b/' a b":     {mod("a"), mod("b")},
+		"sed 's/a/b/' main.go":             nil,
+		"sed -n '1p' main.go":              nil,
+		"perl -pe 's/a/b/' .env":           nil,
+		"rm":                               nil,
+	}
+	for cmd, want := range tests {
+		if got := Analyze(cmd).Files; !reflect.DeepEqual(got, want) {
+			t.Errorf("Analyze(%q).Files = %+v, want %+v", cmd, got, want)
+		}
+	}
+}
+
+func TestEditCommands(t *testing.T) {
+	env, err := New(&policy.Policy{Action: policy.ActionModify, Description: "Environment files", Include: []string{"**/.env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, err := New(&policy.Policy{Action: policy.ActionDelete, Description: "Test files", Include: []string{"**/*.test.ts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		m       *Matcher
+		cmd     string
+		allowed bool
+	}{
+		{env, "rm .env", false},
+		{env, "truncate -s 0 app/.env", false},
+		{env, "sed -i 's/KEY=.*/KEY=x/' .env", false},
+		{env, "perl -pi -e 's/a/b/' .env", false},
+		{env, "sed 's/KEY=.*/KEY=x/' .env", true},
+		{env, "rm .env.example.bak", true},
+		{tests, "rm src/a.test.ts", false},
+		{tests, "unlink src/a.test.ts", false},
+		{tests, "sed -i 's/a/b/' src/a.test.ts", true},
+		{tests, "rm src/a.ts", true},
+	}
+	for _, tt := range cases {
+		if got := tt.m.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd}).Allowed; got != tt.allowed {
+			t.Errorf("%s: %q allowed = %v, want %v", tt.m.policy.Description, tt.cmd, got, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package matcher
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/gobwas/glob"
+)
+
+// envRule is a compiled policy.EnvRule.
+type envRule struct {
+	names []glob.Glob
+	hosts []glob.Glob
+}
+
+func compileEnvRule(rule policy.EnvRule) (envRule, error) {
+	var r envRule
+	for _, name := range rule.Names {
+		g, err := compileGlob(strings.ToUpper(name))
+		if err != nil {
+			return r, err
+		}
+		r.names = append(r.names, g)
+	}
+	hosts, err := compileHosts(rule.AllowHosts)
+	r.hosts = hosts
+	return r, err
+}
+
+// compileHosts compiles host globs, in which * stops at dots.
+func compileHosts(patterns []string) ([]glob.Glob, error) {
+	var hosts []glob.Glob
+	for _, host := range patterns {
+		g, err := compileGlob(strings.ToLower(host), '.')
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, g)
+	}
+	return hosts, nil
+}
+
+// networkTools send their arguments to the hosts they're given.
+var networkTools = map[string]bool{
+	"curl": true, "wget": true, "http": true, "https": true, "xh": true,
+}
+
+// dumpers print every environment variable when run without arguments.
+var dumpers = map[string]bool{"env": true, "printenv": true, "set": true}
+
+// windowsVarRe matches PowerShell ($env:NAME) and cmd.exe (%NAME%)
+// variable references.
+var windowsVarRe = regexp.MustCompile(`(?i)\$env:([A-Za-z_][A-Za-z0-9_]*)|%([A-Za-z_][A-Za-z0-9_]*)%`)
+
+// envDrive lists the environment in PowerShell: Get-ChildItem env:, and
+// its dir and ls aliases.
+var envDrive = regexp.MustCompile(`(?i)^(?:get-childitem|gci|dir|ls)\s+env:\\?\s*$`)
+
+// hostRe matches a bare host argument like example.com/path or
+// localhost:5000/app.
+var hostRe = regexp.MustCompile(`^(?:localhost|[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+)(?::\d+)?(?:/.*)?$`)
+
+// scpRe matches the scp-style addresses git and ssh take, like
+// git@github.com:org/repo.git, capturing the host.
+var scpRe = regexp.MustCompile(`^[A-Za-z0-9._-]+@([A-Za-z0-9.-]+):`)
+
+// protects reports whether the rule protects the variable name.
+func (r envRule) protects(name string) bool {
+	name = strings.ToUpper(name)
+	for _, g := range r.names {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendsToAllowed reports whether words are a network command whose
+// destinations are all allowlisted.
+func (r envRule) sendsToAllowed(words []string) bool {
+	return networkTools[path.Base(words[0])] && onlyHosts(r.hosts, words[1:])
+}
+
+// onlyHosts reports whether words name at least one host, as a URL, an
+// scp-style git address (git@host:path) or a bare host, and every host
+// they name matches one of hosts.
+func onlyHosts(hosts []glob.Glob, words []string) bool {
+	if len(hosts) == 0 {
+		return false
+	}
+	var named []string
+	for _, w := range words {
+		if strings.Contains(w, "://") {
+			if u, err := url.Parse(w); err == nil {
+				named = append(named, u.Hostname())
+			}
+		} else if m := scpRe.FindStringSubmatch(w); m != nil {
+			named = append(named, m[1])
+		} else if hostRe.MatchString(w) {
+			host, _, _ := strings.Cut(w, "/")
+			host, _, _ = strings.Cut(host, ":")
+			named = append(named, host)
+		}
+	}
+	if len(named) == 0 {
+		return false
+	}
+	for _, h := range named {
+		if !matchAnyGlob(hosts, strings.ToLower(h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// exposedEnv returns the index of the first env rule a command exposes a
+// protected variable to, and the variable, or -1 and "". A variable is
+// exposed when a command references it, except in network commands to
+// allowlisted hosts; exporting a protected name or dumping the whole
+// environment exposes it too.
+func (m *Matcher) exposedEnv(commands []string) (int, string) {
+	for _, cmd := range commands {
+		words := shellFields(cmd)
+		if len(words) == 0 || len(splitShell(cmd, true)) > 1 {
+			// Whole pipelines are covered by their parts
+			continue
+		}
+		var names []string
+		for _, ref := range variableRe.FindAllStringSubmatch(cmd, -1) {
+			names = append(names, ref[1]+ref[2])
+		}
+		for _, ref := range windowsVarRe.FindAllStringSubmatch(cmd, -1) {
+			names = append(names, ref[1]+ref[2])
+		}
+		switch path.Base(words[0]) {
+		case "export", "printenv":
+			for _, w := range words[1:] {
+				name, _, _ := strings.Cut(w, "=")
+				names = append(names, name)
+			}
+		}
+		program := unwrap(words)
+
+		for i, r := range m.envRules {
+			if len(r.names) == 0 {
+				continue
+			}
+			if len(words) == 1 && dumpers[path.Base(words[0])] || envDrive.MatchString(cmd) {
+				return i, "the whole environment"
+			}
+			for _, name := range names {
+				if r.protects(name) && (len(program) == 0 || !r.sendsToAllowed(program)) {
+					return i, "$" + name
+				}
+			}
+		}
+	}
+	return -1, ""
+}
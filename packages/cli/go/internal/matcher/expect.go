@@ -0,0 +1,28 @@
+package matcher
+
+import "github.com/VulnZap/veto/internal/policy"
+
+// TestFailure is a policy test whose expectation didn't hold.
+type TestFailure struct {
+	Test policy.Test
+	// Got is what the policies did (allow, warn, ask or deny)
+	Got string
+	// Policy is the policy that decided, if any
+	Policy string
+}
+
+// RunTests checks each test's request against the set and returns the
+// tests whose expectation didn't hold. Tests run in interactive mode, so
+// ask decisions stay asks.
+func (s *Set) RunTests(tests []policy.Test) []TestFailure {
+	var failures []TestFailure
+	for _, t := range tests {
+		req := t.Request()
+		req.Mode = policy.ModeInteractive
+		result := s.Check(req)
+		if !t.Passes(result) {
+			failures = append(failures, TestFailure{Test: t, Got: policy.Verdict(result), Policy: result.Policy})
+		}
+	}
+	return failures
+}
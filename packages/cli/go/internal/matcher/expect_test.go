@@ -0,0 +1,67 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestRunTests(t *testing.T) {
+	set, err := NewSet([]*policy.Policy{
+		commandPolicy("no npm", "npm *", policy.DecisionDeny),
+		commandPolicy("careful with curl", "curl *", policy.DecisionWarn),
+		commandPolicy("confirm pushes", "git push*", policy.DecisionAsk),
+		{Description: "protect env", Action: policy.ActionModify, Include: []string{".env"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []policy.Test{
+		{Command: "npm i lodash", Expect: policy.ExpectBlock},
+		{Command: "npm i lodash", Expect: "deny"},
+		{Command: "curl example.com", Expect: policy.ExpectAllow},
+		{Command: "curl example.com", Expect: "warn"},
+		{Command: "git push origin main", Expect: policy.ExpectBlock},
+		{Command: "git push origin main", Expect: "ask"},
+		{Command: "pnpm i lodash", Expect: policy.ExpectAllow},
+		{File: ".env", Content: "X=1", Expect: policy.ExpectBlock},
+		{File: "README.md", Expect: policy.ExpectAllow},
+	}
+	if failures := set.RunTests(tests); len(failures) != 0 {
+		for _, f := range failures {
+			t.Errorf("%s: expected %s, got %s", f.Test.Name(), f.Test.Expect, f.Got)
+		}
+	}
+
+	failures := set.RunTests([]policy.Test{
+		{Command: "npm i lodash", Expect: policy.ExpectAllow},
+		{Command: "git push", Expect: "deny"},
+	})
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2", len(failures))
+	}
+	if f := failures[0]; f.Got != "deny" || f.Policy != "no npm" {
+		t.Errorf("failure = %+v, want deny by no npm", f)
+	}
+	if f := failures[1]; f.Got != "ask" {
+		t.Errorf("failure got %q, want ask", f.Got)
+	}
+}
+
+func TestTestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		test policy.Test
+		ok   bool
+	}{
+		{policy.Test{Command: "ls", Expect: "allow"}, true},
+		{policy.Test{File: "a.go", Expect: "warn"}, true},
+		{policy.Test{Expect: "block"}, false},
+		{policy.Test{Command: "ls", File: "a.go", Expect: "block"}, false},
+		{policy.Test{Command: "ls", Expect: "maybe"}, false},
+	} {
+		if err := tc.test.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%+v: Validate() = %v, want ok=%v", tc.test, err, tc.ok)
+		}
+	}
+}
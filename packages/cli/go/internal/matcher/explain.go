@@ -0,0 +1,146 @@
+package matcher
+
+import "github.com/VulnZap/veto/internal/policy"
+
+// FileExplanation describes how a policy's globs evaluated a path.
+type FileExplanation struct {
+	// Include is the first include pattern that matched ("" if none)
+	Include string
+	// Exclude is the exclude pattern that rescued the path ("" if none)
+	Exclude string
+	// Blocked is true when the path is included and not excluded
+	Blocked bool
+}
+
+// CommandExplanation describes how a policy's command rules evaluated a command.
+type CommandExplanation struct {
+	// Pattern is the block pattern that matched ("" if none)
+	Pattern string
+	// Rule is the matching command rule (nil if none)
+	Rule *policy.CommandRule
+	// Env is the protected variable the command exposes ("" if none)
+	Env string
+	// EnvRule is the env rule protecting it (nil if none)
+	EnvRule *policy.EnvRule
+}
+
+// ContentMatch is one place file content matches a content rule, or may
+// match an AST rule (see FindAST).
+type ContentMatch struct {
+	// Start and End are byte offsets of the match in the content
+	Start, End int
+	// Policy and Rule are what matched; AST is set instead of Rule for
+	// AST rules
+	Policy *policy.Policy
+	Rule   *policy.ContentRule
+	AST    *policy.ASTRule
+	// ID identifies the rule in its policy (see policy.RuleID)
+	ID string
+	// Decision is what the set would do with the content (Set.FindContent only)
+	Decision policy.Decision
+	// Monitored is true when the policy only reports violations
+	Monitored bool
+}
+
+// Policy returns the policy the matcher was compiled from.
+func (m *Matcher) Policy() *policy.Policy {
+	return m.policy
+}
+
+// ExplainFile reports which include and exclude patterns matched a path.
+func (m *Matcher) ExplainFile(path string) FileExplanation {
+	path = NormalizePath(path)
+	var e FileExplanation
+	for i, g := range m.includeGlobs {
+		if g.Match(path) {
+			e.Include = m.policy.Include[i]
+			break
+		}
+	}
+	if e.Include == "" {
+		return e
+	}
+	for i, g := range m.excludeGlobs {
+		if g.Match(path) {
+			e.Exclude = m.policy.Exclude[i]
+			return e
+		}
+	}
+	e.Blocked = true
+	return e
+}
+
+// ExplainCommand reports which command rule pattern matched a command.
+func (m *Matcher) ExplainCommand(cmd string) CommandExplanation {
+	a := Analyze(cmd)
+	var e CommandExplanation
+	if i, j, _ := m.matchCommands(a.Commands); i >= 0 {
+		e.Rule = &m.policy.CommandRules[i]
+		e.Pattern = e.Rule.Block[j]
+	}
+	if i, name := m.exposedEnv(a.Commands); i >= 0 {
+		e.Env, e.EnvRule = name, &m.policy.EnvRules[i]
+	}
+	return e
+}
+
+// FindContent reports every match of the policy's content rules in
+// content, for editors marking violations before an agent writes them.
+func (m *Matcher) FindContent(path, content string) []ContentMatch {
+	var matches []ContentMatch
+	for i := range m.policy.ContentRules {
+		rule := &m.policy.ContentRules[i]
+		if !m.contentRules[i].applies(path) {
+			continue
+		}
+		for _, loc := range m.contentRules[i].findAll(content, rule.Mode, -1) {
+			matches = append(matches, ContentMatch{Start: loc[0], End: loc[1], Policy: m.policy, Rule: rule, ID: policy.RuleID(policy.RuleContent, i)})
+		}
+	}
+	return matches
+}
+
+// FindContent reports content rule matches in content across the set,
+// in policy order, as if the content were written to path in interactive
+// mode. Allow policies, policies whose conditions don't hold or that
+// don't apply to the path's directory, and policies whose allow rules
+// exempt the path are skipped.
+func (s *Set) FindContent(path, content string) []ContentMatch {
+	return s.find(path, content, (*Matcher).FindContent)
+}
+
+// FindAST reports where AST rules across the set may match content,
+// skipping the policies FindContent does.
+func (s *Set) FindAST(path, content string) []ContentMatch {
+	return s.find(path, content, (*Matcher).FindAST)
+}
+
+// find collects a matcher's findings in content across the set, with
+// the decision each policy would take.
+func (s *Set) find(path, content string, find func(*Matcher, string, string) []ContentMatch) []ContentMatch {
+	path = NormalizePath(path)
+	req := &policy.CheckRequest{Action: string(policy.ActionModify), Target: path, Mode: policy.ModeInteractive}
+	scoped, deepest := s.scope(req)
+	var matches []ContentMatch
+	for i, m := range s.matchers {
+		p := m.policy
+		local := scoped[i]
+		if local == nil || !p.Locked && deepest[p.Description] != p.Dir {
+			continue
+		}
+		if p.Decision == policy.DecisionAllow || !p.When.Matches(local) || m.Allowed(local) {
+			continue
+		}
+		for _, cm := range find(m, local.Target, content) {
+			cm.Decision = decide(p, false)
+			cm.Monitored = p.Enforce == policy.EnforceMonitor
+			matches = append(matches, cm)
+		}
+	}
+	return matches
+}
+
+// Matchers returns the set's matchers in policy order.
+func (s *Set) Matchers() []*Matcher {
+	return s.matchers
+}
@@ -0,0 +1,55 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestExplainFile(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Include: []string{"*.lock", ".env"},
+		Exclude: []string{"keep.lock"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want FileExplanation
+	}{
+		{"yarn.lock", FileExplanation{Include: "*.lock", Blocked: true}},
+		{".env", FileExplanation{Include: ".env", Blocked: true}},
+		{"keep.lock", FileExplanation{Include: "*.lock", Exclude: "keep.lock"}},
+		{"main.go", FileExplanation{}},
+	}
+	for _, tt := range tests {
+		if got := m.ExplainFile(tt.path); got != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExplainCommand(t *testing.T) {
+	m, err := New(&policy.Policy{
+		CommandRules: []policy.CommandRule{
+			{Block: []string{"npm install*", "npm i *"}, Reason: "Project uses pnpm"},
+			{Block: []string{"yarn*"}, Reason: "Project uses pnpm"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := m.ExplainCommand("  npm i lodash ")
+	if e.Pattern != "npm install*" || e.Rule == nil || e.Rule.Reason != "Project uses pnpm" {
+		t.Errorf("npm i: %+v, want npm install* through the alias", e)
+	}
+	if e := m.ExplainCommand("yarn add x"); e.Pattern != "yarn*" {
+		t.Errorf("yarn: %+v, want yarn*", e)
+	}
+	if e := m.ExplainCommand("pnpm add x"); e.Pattern != "" || e.Rule != nil {
+		t.Errorf("pnpm: %+v, want no match", e)
+	}
+}
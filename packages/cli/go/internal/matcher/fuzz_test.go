@@ -0,0 +1,147 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Fuzz targets for the enforcement path. Patterns come from .veto files in
+// repositories veto may not trust, and commands and paths come from
+// agents, so none of these may panic or hang on any input. Run one with
+// e.g. go test -run '^$' -fuzz FuzzCompilePath ./internal/matcher
+
+func FuzzCompilePath(f *testing.F) {
+	for _, b := range builtin.Registry {
+		for _, p := range b.Include {
+			f.Add(p, ".env")
+		}
+	}
+	f.Add("**/migrations/**", "db/migrations/001.sql")
+	f.Add("src/{a,b}/**/*.[jt]s", "src/a/x/y.ts")
+	f.Add("[!", "x")
+	f.Add("**/**/**/", "a//b")
+
+	f.Fuzz(func(t *testing.T, pattern, path string) {
+		g, err := CompilePath(pattern)
+		if err != nil {
+			return
+		}
+		if g.Match(path) != g.Match(strings.ToUpper(path)) && isASCII(path) {
+			t.Errorf("%q: case of %q changes the match", pattern, path)
+		}
+	})
+}
+
+func FuzzValidatePattern(f *testing.F) {
+	for _, b := range builtin.Registry {
+		for _, r := range b.ContentRules {
+			f.Add(r.Pattern)
+		}
+	}
+	f.Add(`(a+)+`)
+	f.Add(`\1`)
+	f.Add(`(?<=x)y`)
+	f.Add(`[[:alpha:]`)
+
+	f.Fuzz(func(t *testing.T, pattern string) {
+		if ValidatePattern(pattern) != nil {
+			return
+		}
+		// Anything ValidatePattern accepts must compile in both forms the
+		// content matcher uses
+		re, err := compilePattern(pattern)
+		if err != nil {
+			t.Fatalf("%q passed validation but doesn't compile: %v", pattern, err)
+		}
+		multiline(re)
+	})
+}
+
+func FuzzCommandPattern(f *testing.F) {
+	for _, b := range builtin.Registry {
+		for _, r := range b.CommandRules {
+			for _, p := range r.Block {
+				f.Add(p, p+" --verbose")
+			}
+		}
+	}
+	f.Add("*lodash*", "npm install lodash")
+	f.Add("rm -rf [", "rm -rf [")
+	f.Add("?", "")
+
+	f.Fuzz(func(t *testing.T, pattern, cmd string) {
+		if n := normalizeCommand(cmd); normalizeCommand(n) != n {
+			t.Errorf("normalizeCommand(%q) isn't idempotent", cmd)
+		}
+		cp, err := compileCommand(pattern)
+		if err != nil {
+			return
+		}
+		if cp.Match(cmd) != cp.Match(normalizeCommand(cmd)) {
+			t.Errorf("%q: normalizing %q changes the match", pattern, cmd)
+		}
+	})
+}
+
+func FuzzSplitCommands(f *testing.F) {
+	f.Add(`git add . && git push --force`)
+	f.Add(`bash -c "rm -rf / || (echo 'done;')"`)
+	f.Add(`echo "unterminated && rm -rf /`)
+	f.Add(`((((`)
+	f.Add(`a | b |& c; d` + "\n" + `e`)
+
+	f.Fuzz(func(t *testing.T, full string) {
+		for _, cmd := range SplitCommands(full) {
+			if cmd == "" || cmd != strings.TrimSpace(cmd) {
+				t.Errorf("SplitCommands(%q) returned untrimmed command %q", full, cmd)
+			}
+		}
+	})
+}
+
+func FuzzAnalyze(f *testing.F) {
+	f.Add(`echo cm0gLXJmIC8= | base64 -d | sh`)
+	f.Add(`x=rm; $x -rf /`)
+	f.Add(`eval "$(printf 'rm -rf /')"`)
+	f.Add("sh <<EOF\nrm -rf /\nEOF")
+	f.Add(`bash -c 'bash -c "bash -c \"$(echo $(echo x))\""'`)
+
+	f.Fuzz(func(t *testing.T, full string) {
+		Analyze(full)
+	})
+}
+
+// FuzzCheck runs fuzzed requests through every builtin policy, the way a
+// hook does.
+func FuzzCheck(f *testing.F) {
+	var policies []*policy.Policy
+	for _, name := range builtin.Names() {
+		b := builtin.Find(name)
+		policies = append(policies, b.ToPolicy(policy.ActionExecute), b.ToPolicy(policy.ActionModify))
+	}
+	set, err := NewSet(policies)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add("execute", "git push --force origin main", "", "")
+	f.Add("modify", "", "src/../.env", "API_KEY=sk-123")
+	f.Add("modify", "", "C:\\repo\\.env", "")
+	f.Add("execute", "$(echo cm0gLXJmIC8= | base64 -d)", "", "")
+
+	f.Fuzz(func(t *testing.T, action, command, target, content string) {
+		set.Check(&policy.CheckRequest{Action: action, Command: command, Target: target, Content: content})
+	})
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestProtectGitConfig(t *testing.T) {
+	m, err := New(builtin.Find("protect git config").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{".gitconfig", "../../.gitconfig", "../../.ssh/authorized_keys", "../.config/git/config", ".git/config", "../../.git-credentials"} {
+		if m.CheckFile(path, policy.ActionModify).Allowed {
+			t.Errorf("file %q: allowed, want blocked", path)
+		}
+	}
+	for _, path := range []string{"src/git.ts", ".gitignore", "docs/ssh.md", ".github/workflows/ci.yml"} {
+		if !m.CheckFile(path, policy.ActionModify).Allowed {
+			t.Errorf("file %q: blocked, want allowed", path)
+		}
+	}
+
+	blocked := []string{
+		`git config --global user.email "agent@example.com"`,
+		"git config --system core.editor vim",
+		"git config credential.helper store",
+		"git config --local credential.https://example.com.username bot",
+		"echo 'url=https://x' | git credential approve",
+		"ssh-keygen -t ed25519 -f ~/.ssh/agent",
+		"echo 'ssh-ed25519 AAAA' >> ~/.ssh/authorized_keys",
+		"cat >> ~/.gitconfig <<EOF\n[alias]\nco = checkout\nEOF",
+	}
+	for _, cmd := range blocked {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+
+	allowed := []string{
+		"git config user.name",
+		"git config --get remote.origin.url",
+		"git config --list --show-origin",
+		"git status",
+	}
+	for _, cmd := range allowed {
+		if result := m.CheckCommand(cmd); !result.Allowed {
+			t.Errorf("%q: blocked (%s), want allowed", cmd, result.Reason)
+		}
+	}
+}
+
+func TestAllowlistGitRemotes(t *testing.T) {
+	p := builtin.Find("allowlist git remotes").ToPolicy(policy.ActionExecute)
+	p.CommandRules[0].AllowHosts = append(p.CommandRules[0].AllowHosts, "*.corp.example")
+	m, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocked := []string{
+		"git remote add upstream https://evil.example/org/repo.git",
+		"git remote add upstream git@evil.example:org/repo.git",
+		"git remote set-url origin ssh://git@github.com.evil.example/org/repo",
+		"git remote add a https://github.com/org/a && git remote add b https://evil.example/b",
+		"git remote add local ../other",
+		"git remote add mirror https://corp.example/repo",
+	}
+	for _, cmd := range blocked {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+
+	allowed := []string{
+		"git remote add upstream https://github.com/org/repo.git",
+		"git remote add upstream git@github.com:org/repo.git",
+		"git remote set-url origin ssh://git@gitlab.com/org/repo",
+		"git remote add mirror https://git.corp.example/repo",
+		"git remote -v",
+		"git remote remove upstream",
+	}
+	for _, cmd := range allowed {
+		if result := m.CheckCommand(cmd); !result.Allowed {
+			t.Errorf("%q: blocked (%s), want allowed", cmd, result.Reason)
+		}
+	}
+}
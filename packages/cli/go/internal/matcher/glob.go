@@ -0,0 +1,192 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// CompilePath compiles a file glob with doublestar semantics:
+//
+//   - "*" matches within a single path segment
+//   - "**" matches across segments
+//   - "**/" matches zero or more leading directories, so "**/.env"
+//     also matches a root-level ".env"
+//   - a trailing "/**" also matches the directory itself
+//   - a pattern without "/" also matches the path's base name, so "*.md"
+//     matches "docs/README.md"
+//   - matching is case-insensitive
+//
+// These match the TS engine's micromatch options (basename, dot, nocase).
+// gobwas/glob requires the separator before and after "**", so each
+// optional "**/" is expanded into an alternative without it, which is why
+// a pattern may have at most maxDoublestars of them.
+func CompilePath(pattern string) (glob.Glob, error) {
+	lower := repeatedDoublestar.ReplaceAllString(strings.ToLower(pattern), "${1}**/")
+	if n := len(doublestarSegment.FindAllStringIndex(lower, -1)); n > maxDoublestars {
+		return nil, fmt.Errorf("%q has %d \"**/\" segments (at most %d)", pattern, n, maxDoublestars)
+	}
+	var globs anyGlob
+	for _, alt := range expandDoublestar(lower) {
+		g, err := compileGlob(alt, '/')
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	return pathGlob{globs: globs, base: !strings.Contains(pattern, "/")}, nil
+}
+
+// maxDoublestars bounds the "**/" segments in a path pattern. Each one
+// doubles the alternatives expandDoublestar produces.
+const maxDoublestars = 8
+
+var (
+	doublestarSegment = regexp.MustCompile(`(^|/)\*\*/`)
+	// "**/**/" matches the same paths as "**/"
+	repeatedDoublestar = regexp.MustCompile(`(^|/)\*\*/(?:\*\*/)+`)
+)
+
+// compileGlob is glob.Compile, but rejects unclosed brace groups and
+// groups whose alternatives are all empty ("{}", "{,}"). gobwas/glob
+// accepts those and then panics matching them.
+func compileGlob(pattern string, separators ...rune) (glob.Glob, error) {
+	if err := checkBraces(pattern); err != nil {
+		return nil, fmt.Errorf("%q: %w", pattern, err)
+	}
+	return glob.Compile(pattern, separators...)
+}
+
+func checkBraces(pattern string) error {
+	type group struct {
+		nonEmpty bool // some alternative so far has content
+		alt      int  // length of the current alternative
+	}
+	var groups []group
+	content := func() {
+		if len(groups) > 0 {
+			groups[len(groups)-1].alt++
+		}
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+			content()
+		case '[':
+			// Character classes can't contain groups
+			if j := strings.IndexByte(pattern[i+1:], ']'); j >= 0 {
+				i += j + 1
+			}
+			content()
+		case '{':
+			groups = append(groups, group{})
+		case ',', '}':
+			if len(groups) == 0 {
+				content()
+				continue
+			}
+			g := &groups[len(groups)-1]
+			g.nonEmpty = g.nonEmpty || g.alt > 0
+			g.alt = 0
+			if pattern[i] == ',' {
+				continue
+			}
+			if !g.nonEmpty {
+				return errors.New("empty {} group")
+			}
+			groups = groups[:len(groups)-1]
+			content()
+		default:
+			content()
+		}
+	}
+	if len(groups) > 0 {
+		return errors.New(`unclosed "{"`)
+	}
+	return nil
+}
+
+// pathGlob applies CompilePath's case folding and base name matching.
+type pathGlob struct {
+	globs anyGlob
+	base  bool
+}
+
+func (p pathGlob) Match(s string) bool {
+	s = strings.ToLower(s)
+	if p.globs.Match(s) {
+		return true
+	}
+	return p.base && strings.Contains(s, "/") && p.globs.Match(path.Base(s))
+}
+
+// anyGlob matches when any of its globs match.
+type anyGlob []glob.Glob
+
+func (a anyGlob) Match(s string) bool {
+	for _, g := range a {
+		if g.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandDoublestar returns pattern plus every variant with optional
+// "**/" segments and a trailing "/**" removed.
+func expandDoublestar(pattern string) []string {
+	var alts []string
+
+	var expand func(prefix, rest string)
+	expand = func(prefix, rest string) {
+		i := doublestarIndex(prefix, rest)
+		if i < 0 {
+			alts = append(alts, prefix+rest)
+			return
+		}
+		expand(prefix+rest[:i+3], rest[i+3:]) // "**/" matches directories
+		expand(prefix+rest[:i], rest[i+3:])   // "**/" matches nothing
+	}
+	expand("", pattern)
+
+	for _, alt := range alts {
+		if strings.HasSuffix(alt, "/**") && len(alt) > 3 {
+			alts = append(alts, strings.TrimSuffix(alt, "/**"))
+		}
+	}
+	return dedupe(alts)
+}
+
+// doublestarIndex finds the first "**/" in rest that starts a path segment,
+// or -1 if there is none.
+func doublestarIndex(prefix, rest string) int {
+	for i := 0; i+3 <= len(rest); i++ {
+		if rest[i:i+3] != "**/" {
+			continue
+		}
+		if i > 0 && rest[i-1] == '/' {
+			return i
+		}
+		if i == 0 && (prefix == "" || strings.HasSuffix(prefix, "/")) {
+			return i
+		}
+	}
+	return -1
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	var out []string
+	for _, s := range items {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
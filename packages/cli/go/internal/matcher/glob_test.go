@@ -0,0 +1,169 @@
+package matcher
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+)
+
+func TestCompilePath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{
+			pattern: "**/.env",
+			match:   []string{".env", "a/.env", "a/b/.env"},
+			noMatch: []string{".envrc", "a/.env.local", "a.env"},
+		},
+		{
+			pattern: ".env",
+			match:   []string{".env", "a/.env", ".ENV"},
+			noMatch: []string{".env/x", "a.env"},
+		},
+		{
+			pattern: "**/migrations/**",
+			match:   []string{"migrations", "migrations/001.sql", "db/migrations/001.sql", "a/b/migrations/x/y.sql"},
+			noMatch: []string{"migrations.txt", "db/migration/001.sql"},
+		},
+		{
+			pattern: "src/**/*.ts",
+			match:   []string{"src/a.ts", "src/x/a.ts", "src/x/y/a.ts"},
+			noMatch: []string{"lib/a.ts", "a.ts", "src/a.tsx"},
+		},
+		{
+			pattern: "*.md",
+			match:   []string{"README.md", "docs/README.md", "README.MD"},
+			noMatch: []string{"README.mdx"},
+		},
+		{
+			pattern: "docs/*.md",
+			match:   []string{"docs/README.md"},
+			noMatch: []string{"README.md", "x/docs/README.md"},
+		},
+		{
+			pattern: "**/*.md",
+			match:   []string{"README.md", "docs/README.md", "a/b/c.md"},
+			noMatch: []string{"README.mdx"},
+		},
+		{
+			pattern: "a/**/b/**/c",
+			match:   []string{"a/b/c", "a/x/b/c", "a/b/y/c", "a/x/b/y/z/c"},
+			noMatch: []string{"a/c", "b/c"},
+		},
+		{
+			pattern: "node_modules/**",
+			match:   []string{"node_modules", "node_modules/x/index.js"},
+			noMatch: []string{"src/node_modules/x.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			g, err := CompilePath(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.match {
+				if !g.Match(p) {
+					t.Errorf("%q should match %q", tt.pattern, p)
+				}
+			}
+			for _, p := range tt.noMatch {
+				if g.Match(p) {
+					t.Errorf("%q should not match %q", tt.pattern, p)
+				}
+			}
+		})
+	}
+}
+
+// TestBuiltinPatternEquivalence checks every include/exclude pattern in the
+// builtin registry: a "**/X" pattern must match X at the root and nested,
+// and must agree with the bare "X" pattern on root-level paths.
+func TestBuiltinPatternEquivalence(t *testing.T) {
+	patterns := make(map[string]bool)
+	for _, b := range builtin.Registry {
+		for _, p := range b.Include {
+			patterns[p] = true
+		}
+		for _, p := range b.Exclude {
+			patterns[p] = true
+		}
+	}
+
+	var sorted []string
+	for p := range patterns {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	for _, pattern := range sorted {
+		t.Run(pattern, func(t *testing.T) {
+			g, err := CompilePath(pattern)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+
+			sample := samplePath(pattern)
+			if !g.Match(sample) {
+				t.Errorf("%q should match its own sample %q", pattern, sample)
+			}
+
+			rest, ok := strings.CutPrefix(pattern, "**/")
+			if !ok {
+				return
+			}
+			if !g.Match("nested/dir/" + sample) {
+				t.Errorf("%q should match nested %q", pattern, "nested/dir/"+sample)
+			}
+
+			bare, err := CompilePath(rest)
+			if err != nil {
+				t.Fatalf("compile %q: %v", rest, err)
+			}
+			if bare.Match(sample) != g.Match(sample) {
+				t.Errorf("%q and %q disagree on root-level %q", pattern, rest, sample)
+			}
+		})
+	}
+}
+
+// samplePath builds a concrete path a pattern should match by dropping
+// optional "**/" segments and filling wildcards.
+func samplePath(pattern string) string {
+	p := strings.ReplaceAll(pattern, "**/", "")
+	p = strings.ReplaceAll(p, "/**", "/deep/file.txt")
+	p = strings.ReplaceAll(p, "**", "x")
+	return strings.ReplaceAll(p, "*", "x")
+}
+
+func TestCompilePathDoublestarLimit(t *testing.T) {
+	// Repeated "**/" collapses, so this is still one optional segment
+	g, err := CompilePath(strings.Repeat("**/", 40) + ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Match(".env") || !g.Match("a/b/.env") {
+		t.Error("collapsed doublestar pattern doesn't match")
+	}
+	if _, err := CompilePath(strings.Repeat("**/a/", 30) + ".env"); err == nil {
+		t.Error("pattern with 30 doublestar segments compiled")
+	}
+}
+
+func TestCompileGlobBraces(t *testing.T) {
+	for _, p := range []string{"0{", "0{}", "a{,}", "{a,{}}", "x{a,b"} {
+		if _, err := compileGlob(p); err == nil {
+			t.Errorf("compileGlob(%q) accepted a group gobwas/glob can't match", p)
+		}
+	}
+	for _, p := range []string{"{a,b}", "a{,b}", `\{`, "[{]", "a}", "{a,{b,c}}"} {
+		if _, err := compileGlob(p); err != nil {
+			t.Errorf("compileGlob(%q): %v", p, err)
+		}
+	}
+}
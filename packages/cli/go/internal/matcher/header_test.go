@@ -0,0 +1,52 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestGeneratedFileHeaders(t *testing.T) {
+	m, err := New(builtin.Find("don't edit generated files").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated := map[string]string{
+		"go":       "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: api.proto\n\npackage api\n",
+		"facebook": "/**\n * @generated SignedSource<<abc>>\n */\nexport const x = 1;\n",
+		"csharp":   "//------------------------------------------------------------------------------\n// <auto-generated>\n//     This code was generated by a tool.\n",
+		"late":     "#!/usr/bin/env python\n# -*- coding: utf-8 -*-\n\n\n\n\n\n\n\n# DO NOT EDIT: generated by make schema\n",
+	}
+	for name, header := range generated {
+		req := &policy.CheckRequest{Action: "modify", Target: "src/api.ts", Header: header}
+		if m.Check(req).Allowed {
+			t.Errorf("%s header: edit allowed, want blocked", name)
+		}
+		req.Action = "read"
+		if !m.Check(req).Allowed {
+			t.Errorf("%s header: read blocked, want allowed", name)
+		}
+	}
+
+	handwritten := map[string]string{
+		"plain":    "package api\n\nfunc Handler() {}\n",
+		"too deep": strings.Repeat("\n", 10) + "// Code generated by hand, apparently\n",
+		"no file":  "",
+	}
+	for name, header := range handwritten {
+		req := &policy.CheckRequest{Action: "modify", Target: "src/api.ts", Header: header}
+		if !m.Check(req).Allowed {
+			t.Errorf("%s header: edit blocked, want allowed", name)
+		}
+	}
+
+	// Well-known generated names are blocked without reading the file
+	for _, path := range []string{"api/v1/service.pb.go", "schema_pb2.py", "src/types.generated.ts"} {
+		if m.CheckFile(path, policy.ActionModify).Allowed {
+			t.Errorf("%q: allowed, want blocked", path)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestInfraSafety(t *testing.T) {
+	m, err := New(builtin.Find("infra safety").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		blocked bool
+	}{
+		{"force destroy", "infra/s3.tf", "resource \"aws_s3_bucket\" \"logs\" {\n  bucket        = \"logs\"\n  force_destroy = true\n}\n", true},
+		{"force destroy json", "terraform/main.tf.json", `{"resource": {"aws_s3_bucket": {"x": {"force_destroy": true}}}}`, true},
+		{"force destroy off", "infra/s3.tf", "  force_destroy = false\n", false},
+		{"public acl", "infra/modules/site/main.tf", "  acl = \"public-read\"\n", true},
+		{"public access block off", "infra/s3.tf", "  block_public_acls   = false\n", true},
+		{"private acl", "infra/s3.tf", "  acl = \"private\"\n", false},
+		{"open ingress", "infra/sg.tf", "resource \"aws_security_group\" \"web\" {\n  ingress {\n    from_port   = 22\n    to_port     = 22\n    protocol    = \"tcp\"\n    cidr_blocks = [\"0.0.0.0/0\"]\n  }\n}\n", true},
+		{"open ingress rule", "infra/sg.tf", "resource \"aws_security_group_rule\" \"ssh\" {\n  cidr_blocks = [\"0.0.0.0/0\"]\n  type        = \"ingress\"\n}\n", true},
+		{"open ipv6 ingress rule", "infra/sg.tf", "resource \"aws_vpc_security_group_ingress_rule\" \"ssh\" {\n  cidr_ipv6 = \"::/0\"\n}\n", true},
+		{"open egress", "infra/sg.tf", "  egress {\n    cidr_blocks = [\"0.0.0.0/0\"]\n  }\n", false},
+		{"private ingress", "infra/sg.tf", "  ingress {\n    cidr_blocks = [\"10.0.0.0/8\"]\n  }\n", false},
+		{"outside infra dirs", "examples/s3.tf", "  force_destroy = true\n", false},
+		{"cfn public bucket", "cloudformation/site.yaml", "Resources:\n  Site:\n    Type: AWS::S3::Bucket\n    Properties:\n      AccessControl: PublicRead\n", true},
+		{"cfn open ingress", "infra/stack.yml", "      SecurityGroupIngress:\n        - IpProtocol: tcp\n          FromPort: 22\n          ToPort: 22\n          CidrIp: 0.0.0.0/0\n", true},
+		{"cfn open egress", "infra/stack.yml", "      SecurityGroupIngress:\n        - CidrIp: 10.0.0.0/8\nOutputs:\n  x: 1\n      SecurityGroupEgress:\n        - CidrIp: 0.0.0.0/0\n", false},
+	}
+	for _, tt := range tests {
+		result := m.Check(&policy.CheckRequest{Action: "modify", Target: tt.path, Content: tt.content})
+		if result.Allowed == tt.blocked {
+			t.Errorf("%s: allowed = %v (%s), want %v", tt.name, result.Allowed, result.Reason, !tt.blocked)
+		}
+	}
+}
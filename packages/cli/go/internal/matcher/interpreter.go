@@ -0,0 +1,159 @@
+package matcher
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// InlineScript is code passed to an interpreter on the command line, as in
+// `python -c "..."` or `node -e "..."`.
+type InlineScript struct {
+	// Language is the file extension the code would have ("py", "js", "rb", "pl")
+	Language string
+	Code     string
+}
+
+// Path is the pseudo file name content rules see for the script, so
+// fileTypes like "*.py" apply.
+func (s InlineScript) Path() string {
+	return "inline." + s.Language
+}
+
+// FileAccess is a file an inline script deletes or writes, or a command
+// deletes, edits, renames, moves or links to.
+type FileAccess struct {
+	Path   string
+	Action policy.Action
+	// To is where a renamed or moved file ends up, or where a copied
+	// file's content is written; empty for content sent over the network
+	To string
+	// Link is a symlink or hardlink made to the file, through which it
+	// can be changed (Action is modify)
+	Link string
+}
+
+// interpreter describes how a language runtime takes inline code.
+type interpreter struct {
+	language string
+	flags    []string
+}
+
+var interpreters = map[string]interpreter{
+	"python":  {"py", []string{"-c"}},
+	"python2": {"py", []string{"-c"}},
+	"python3": {"py", []string{"-c"}},
+	"node":    {"js", []string{"-e", "--eval", "-p", "--print"}},
+	"nodejs":  {"js", []string{"-e", "--eval", "-p", "--print"}},
+	"bun":     {"js", []string{"-e", "--eval", "-p", "--print"}},
+	"deno":    {"js", []string{"eval"}},
+	"ruby":    {"rb", []string{"-e"}},
+	"perl":    {"pl", []string{"-e", "-E"}},
+}
+
+// inlineCode returns the code an interpreter invocation runs inline.
+func inlineCode(words []string) (InlineScript, bool) {
+	it, ok := interpreters[path.Base(words[0])]
+	if !ok {
+		return InlineScript{}, false
+	}
+	for i, w := range words[1:] {
+		for _, flag := range it.flags {
+			if w == flag && i+2 < len(words) {
+				return InlineScript{Language: it.language, Code: words[i+2]}, true
+			}
+		}
+	}
+	return InlineScript{}, false
+}
+
+// interpreterFor returns the language of an interpreter reading a script
+// from stdin, as in `python3 - <<EOF`.
+func interpreterFor(words []string) (string, bool) {
+	if len(words) == 0 {
+		return "", false
+	}
+	it, ok := interpreters[path.Base(words[0])]
+	return it.language, ok
+}
+
+// A string literal in any of the supported languages.
+const literal = `(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'|` + "`([^`]*)`" + `)`
+
+var (
+	// Calls that run a shell command given as one string
+	shellCallRe = regexp.MustCompile(
+		`(?:os\.system|os\.popen|subprocess\.(?:run|call|Popen|check_output|check_call|getoutput|getstatusoutput)|` +
+			`(?:child_process\.)?exec(?:Sync)?|\bsystem)\s*\(\s*` + literal)
+	// Calls that take the command as a list of arguments
+	argvCallRe = regexp.MustCompile(
+		`(?:subprocess\.(?:run|call|Popen|check_output|check_call)|(?:child_process\.)?(?:spawn|execFile)(?:Sync)?)\s*\(\s*` +
+			`(?:` + literal + `\s*,\s*)?\[([^\]]*)\]`)
+	// Backtick command substitution in Ruby and Perl
+	backtickRe = regexp.MustCompile("`([^`]+)`")
+
+	quotedRe = regexp.MustCompile(literal)
+)
+
+// fileCalls are calls whose first string argument is a file they delete
+// or write.
+var fileCalls = []struct {
+	re     *regexp.Regexp
+	action policy.Action
+}{
+	{regexp.MustCompile(`(?:os\.(?:remove|unlink|rmdir)|shutil\.rmtree|(?:fs|\))(?:\.promises)?\.(?:unlink|rm|rmdir)(?:Sync)?|` +
+		`File\.(?:delete|unlink)|FileUtils\.(?:rm|rm_r|rm_rf|rm_f|remove_dir)|\bunlink)\s*\(\s*` + literal), policy.ActionDelete},
+	{regexp.MustCompile(`Path\s*\(\s*` + literal + `\s*\)\s*\.(?:unlink|rmdir)\s*\(`), policy.ActionDelete},
+	{regexp.MustCompile(`\bopen\s*\(\s*` + literal + `\s*,\s*['"][wax]`), policy.ActionModify},
+	{regexp.MustCompile(`(?:writeFile|appendFile|createWriteStream)(?:Sync)?\s*\(\s*` + literal), policy.ActionModify},
+	{regexp.MustCompile(`File\.write\s*\(\s*` + literal), policy.ActionModify},
+	{regexp.MustCompile(`Path\s*\(\s*` + literal + `\s*\)\s*\.write_(?:text|bytes)\s*\(`), policy.ActionModify},
+}
+
+// inspectScript finds the shell commands an inline script runs and the
+// files it deletes or writes.
+func (a *Analysis) inspectScript(s InlineScript, vars map[string]string, depth int) {
+	a.Scripts = append(a.Scripts, s)
+
+	for _, m := range shellCallRe.FindAllStringSubmatch(s.Code, -1) {
+		a.walk(firstGroup(m[1:]), copyVars(vars), depth+1)
+	}
+	for _, m := range argvCallRe.FindAllStringSubmatch(s.Code, -1) {
+		var argv []string
+		if program := firstGroup(m[1:4]); program != "" {
+			argv = append(argv, program)
+		}
+		for _, q := range quotedRe.FindAllStringSubmatch(m[4], -1) {
+			argv = append(argv, firstGroup(q[1:]))
+		}
+		if len(argv) > 0 {
+			cmd := joinWords(argv)
+			a.add(cmd)
+			a.run(argv, copyVars(vars), depth+1)
+		}
+	}
+	if s.Language == "rb" || s.Language == "pl" {
+		for _, m := range backtickRe.FindAllStringSubmatch(s.Code, -1) {
+			a.walk(m[1], copyVars(vars), depth+1)
+		}
+	}
+
+	for _, call := range fileCalls {
+		for _, m := range call.re.FindAllStringSubmatch(s.Code, -1) {
+			if p := firstGroup(m[1:]); p != "" {
+				a.Files = append(a.Files, FileAccess{Path: p, Action: call.action})
+			}
+		}
+	}
+}
+
+// firstGroup returns the first non-empty capture group.
+func firstGroup(groups []string) string {
+	for _, g := range groups {
+		if g != "" {
+			return g
+		}
+	}
+	return ""
+}
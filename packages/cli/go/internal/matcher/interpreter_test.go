@@ -0,0 +1,75 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestInlineScriptCommands(t *testing.T) {
+	m, err := New(commandPolicy("no rm -rf", "rm -rf*", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	push, err := New(commandPolicy("no force push", "git push --force*", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocked := []string{
+		`python3 -c "import os; os.system('rm -rf .')"`,
+		`python -c "import subprocess; subprocess.run(['rm', '-rf', '/'])"`,
+		`ruby -e 'system("rm -rf .")'`,
+		"perl -e '`rm -rf .`'",
+		"python3 - <<EOF\nimport os\nos.system('rm -rf /')\nEOF",
+	}
+	for _, cmd := range blocked {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+	if push.CheckCommand(`node -e "require('child_process').execSync('git push --force origin main')"`).Allowed {
+		t.Error("node execSync of a force push allowed, want blocked")
+	}
+
+	for _, cmd := range []string{`python3 -c "print('rm -rf is dangerous')"`, `node -e "console.log(1)"`} {
+		if !m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: blocked, want allowed", cmd)
+		}
+	}
+}
+
+func TestInlineScriptContentAndFiles(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Description: "no eval",
+		ContentRules: []policy.ContentRule{
+			{Pattern: `\beval\s*\(`, FileTypes: []string{"*.js"}, Reason: "eval is not allowed"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CheckCommand(`node -e "eval(process.argv[1])"`).Allowed {
+		t.Error("node -e eval allowed, want blocked by content rule")
+	}
+	if !m.CheckCommand(`python3 -c "eval('1')"`).Allowed {
+		t.Error("content rule for *.js applied to python")
+	}
+
+	env, err := New(&policy.Policy{Description: "protect .env", Include: []string{".env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []string{
+		`python -c "import os; os.remove('.env')"`,
+		`python3 -c "open('.env', 'w').write('')"`,
+		`node -e "require('fs').unlinkSync('.env')"`,
+	} {
+		if env.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+	if !env.CheckCommand(`python3 -c "print(open('.env').read())"`).Allowed {
+		t.Error("reading .env blocked by a delete/modify check")
+	}
+}
@@ -2,34 +2,41 @@
 package matcher
 
 import (
-	"path/filepath"
-	"regexp"
+	"fmt"
+	"path"
 	"strings"
 
+	"github.com/VulnZap/veto/internal/policy"
 	"github.com/gobwas/glob"
-	"github.com/vulnzap/leash/internal/policy"
 )
 
 // Matcher validates actions against a policy.
 type Matcher struct {
-	policy         *policy.Policy
-	includeGlobs   []glob.Glob
-	excludeGlobs   []glob.Glob
-	commandGlobs   map[int][]glob.Glob    // index in CommandRules -> compiled globs
-	contentRegexes map[int]*regexp.Regexp // index in ContentRules -> compiled regex
+	policy       *policy.Policy
+	includeGlobs []glob.Glob
+	excludeGlobs []glob.Glob
+	commandRules [][]*commandPattern // parallel to CommandRules
+	commandHosts [][]glob.Glob       // parallel to CommandRules
+	contentRules []contentRule       // parallel to ContentRules
+	envRules     []envRule           // parallel to EnvRules
+	structure    []structureRule     // parallel to StructureRules
+	shrinkPaths  [][]glob.Glob       // parallel to ShrinkRules
+	allowRules   []allowRule
+}
+
+// allowRule is a compiled policy.AllowRule.
+type allowRule struct {
+	commands []*commandPattern
+	paths    []glob.Glob
 }
 
 // New creates a matcher for the given policy.
 func New(p *policy.Policy) (*Matcher, error) {
-	m := &Matcher{
-		policy:         p,
-		commandGlobs:   make(map[int][]glob.Glob),
-		contentRegexes: make(map[int]*regexp.Regexp),
-	}
+	m := &Matcher{policy: p}
 
 	// Compile include patterns
 	for _, pattern := range p.Include {
-		g, err := glob.Compile(pattern, '/')
+		g, err := CompilePath(pattern)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +45,7 @@ func New(p *policy.Policy) (*Matcher, error) {
 
 	// Compile exclude patterns
 	for _, pattern := range p.Exclude {
-		g, err := glob.Compile(pattern, '/')
+		g, err := CompilePath(pattern)
 		if err != nil {
 			return nil, err
 		}
@@ -46,42 +53,124 @@ func New(p *policy.Policy) (*Matcher, error) {
 	}
 
 	// Compile command rule patterns
-	for i, rule := range p.CommandRules {
-		var globs []glob.Glob
+	for _, rule := range p.CommandRules {
+		var patterns []*commandPattern
 		for _, pattern := range rule.Block {
-			g, err := glob.Compile(pattern)
+			cp, err := compileCommand(pattern)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, cp)
+		}
+		hosts, err := compileHosts(rule.AllowHosts)
+		if err != nil {
+			return nil, err
+		}
+		m.commandRules = append(m.commandRules, patterns)
+		m.commandHosts = append(m.commandHosts, hosts)
+	}
+
+	// Compile allow rule patterns
+	for _, rule := range p.Allow {
+		var ar allowRule
+		for _, pattern := range rule.Commands {
+			cp, err := compileCommand(pattern)
 			if err != nil {
 				return nil, err
 			}
-			globs = append(globs, g)
+			ar.commands = append(ar.commands, cp)
 		}
-		m.commandGlobs[i] = globs
+		for _, pattern := range rule.Paths {
+			g, err := CompilePath(pattern)
+			if err != nil {
+				return nil, err
+			}
+			ar.paths = append(ar.paths, g)
+		}
+		m.allowRules = append(m.allowRules, ar)
 	}
 
 	// Compile content rule patterns
-	for i, rule := range p.ContentRules {
-		re, err := regexp.Compile(rule.Pattern)
+	for _, rule := range p.ContentRules {
+		re, err := compilePattern(rule.Pattern)
 		if err != nil {
 			return nil, err
 		}
-		m.contentRegexes[i] = re
+		cr := contentRule{pattern: multiline(re), fileTypes: rule.FileTypes}
+		for _, ex := range rule.Exceptions {
+			exRe, err := compilePattern(ex)
+			if err != nil {
+				return nil, err
+			}
+			cr.exceptions = append(cr.exceptions, exRe)
+		}
+		if cr.include, err = compilePaths(rule.Include); err != nil {
+			return nil, err
+		}
+		if cr.exclude, err = compilePaths(rule.Exclude); err != nil {
+			return nil, err
+		}
+		m.contentRules = append(m.contentRules, cr)
+	}
+
+	// Compile required headers
+	for _, rule := range p.StructureRules {
+		var sr structureRule
+		if rule.RequireHeader != "" {
+			re, err := compilePattern(rule.RequireHeader)
+			if err != nil {
+				return nil, err
+			}
+			sr.header = multiline(re)
+		}
+		m.structure = append(m.structure, sr)
+	}
+
+	// Compile where shrink rules apply
+	for _, rule := range p.ShrinkRules {
+		include, err := compilePaths(rule.Include)
+		if err != nil {
+			return nil, err
+		}
+		m.shrinkPaths = append(m.shrinkPaths, include)
+	}
+
+	// Compile protected environment variables
+	for _, rule := range p.EnvRules {
+		er, err := compileEnvRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		m.envRules = append(m.envRules, er)
 	}
 
 	return m, nil
 }
 
-// CheckFile validates if a file operation is allowed.
-func (m *Matcher) CheckFile(path string) *policy.CheckResult {
+// CheckFile validates if an operation on a file is allowed. Only the
+// operations the policy's action covers are checked against its file
+// patterns (see policy.Action.Covers). Copying a file the policy only
+// keeps from changes is blocked with Decision ask. An allowlist blocks
+// the files its allow rules don't list instead.
+func (m *Matcher) CheckFile(path string, op policy.Action) *policy.CheckResult {
+	if !m.policy.Action.Covers(op) {
+		return &policy.CheckResult{Allowed: true}
+	}
+	path = NormalizePath(path)
+	if m.policy.Allowlist {
+		return m.checkListedFile(path)
+	}
+
 	// Check if file matches include patterns
-	included := false
-	for _, g := range m.includeGlobs {
+	included := -1
+	for i, g := range m.includeGlobs {
 		if g.Match(path) {
-			included = true
+			included = i
 			break
 		}
 	}
 
-	if !included {
+	if included < 0 {
 		return &policy.CheckResult{Allowed: true}
 	}
 
@@ -93,54 +182,264 @@ func (m *Matcher) CheckFile(path string) *policy.CheckResult {
 	}
 
 	// File matches policy - block it
-	return &policy.CheckResult{
+	result := &policy.CheckResult{
 		Allowed: false,
 		Reason:  m.policy.Description,
+		Rule:    policy.RuleID(policy.RuleInclude, included),
+	}
+	// Copying a file kept from changes is often harmless (a backup, a
+	// template), so a human decides; files kept from being read or
+	// copied stay blocked
+	if op == policy.ActionCopy && m.policy.Action != policy.ActionRead && m.policy.Action != policy.ActionCopy {
+		result.Decision = policy.DecisionAsk
 	}
+	return result
 }
 
-// CheckCommand validates if a command is allowed.
+// checkMove validates renaming, moving or copying a file: it leaves its
+// path, or its content does, as op, and takes over the one it's moved or
+// copied to, which is creating that file as far as the policy is
+// concerned.
+func (m *Matcher) checkMove(from, to string, op policy.Action) *policy.CheckResult {
+	if result := m.CheckFile(from, op); !result.Allowed {
+		return result
+	}
+	return m.CheckFile(to, policy.ActionCreate)
+}
+
+// checkLink validates making a symlink or hardlink to a file. Whatever
+// can write the link writes the file, so linking to it is modifying it,
+// and the link itself is a new file.
+func (m *Matcher) checkLink(target, link string) *policy.CheckResult {
+	if result := m.CheckFile(target, policy.ActionModify); !result.Allowed {
+		return result
+	}
+	return m.CheckFile(link, policy.ActionCreate)
+}
+
+// CheckCommand validates if a command is allowed. Inline code passed to
+// interpreters (python -c, node -e) is also checked against the content
+// rules, and the files it deletes or writes against the file patterns.
+// Commit messages are checked against the rules for policy.CommitMessage.
 func (m *Matcher) CheckCommand(cmd string) *policy.CheckResult {
-	cmd = strings.TrimSpace(cmd)
+	return m.checkCommand(cmd, "")
+}
 
-	for i, globs := range m.commandGlobs {
-		for _, g := range globs {
-			if g.Match(cmd) {
-				rule := m.policy.CommandRules[i]
-				return &policy.CheckResult{
-					Allowed: false,
-					Reason:  rule.Reason,
-					Suggest: rule.Suggest,
+// checkCommand is CheckCommand for a command run in cwd, relative to the
+// project root, which decides the allow rules an allowlist applies.
+func (m *Matcher) checkCommand(cmd, cwd string) *policy.CheckResult {
+	a := Analyze(cmd)
+	if m.policy.Allowlist {
+		if result := m.checkListedCommands(a, cwd); !result.Allowed {
+			return result
+		}
+	}
+	if i, j, part := m.matchCommands(a.Commands); i >= 0 {
+		rule := m.policy.CommandRules[i]
+		result := &policy.CheckResult{
+			Allowed:      false,
+			Reason:       rule.Reason,
+			Suggest:      rule.Suggestion(),
+			Alternatives: alternatives(rule.Alternatives, rule.Block[j], part),
+			Rule:         policy.RuleID(policy.RuleCommand, i),
+		}
+		if rule.RunInstead != "" && m.policy.AutoRun {
+			// The rest of the line (a cd, the commands after it) runs too
+			if sub, ok := replacement(rule.RunInstead, rule.Block[j], part); ok {
+				if line, ok := splice(cmd, part, sub); ok {
+					result.RunInstead = line
 				}
 			}
 		}
+		if rule.Rewrite != "" {
+			if line, ok := m.rewrite(cmd, part, rule.Block[j], rule.Rewrite); ok {
+				if m.policy.AutoRun {
+					result.Rewrite = line
+				} else if rule.Suggest == "" && len(rule.Alternatives) == 0 {
+					result.Suggest = line
+				}
+			}
+		}
+		return result
+	}
+	if i, name := m.exposedEnv(a.Commands); i >= 0 {
+		rule := m.policy.EnvRules[i]
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  rule.Reason + " (" + name + ")",
+			Suggest: rule.Suggest,
+			Rule:    policy.RuleID(policy.RuleEnv, i),
+		}
+	}
+	for _, s := range a.Scripts {
+		if result := m.checkPatterns(s.Path(), s.Code, nil); !result.Allowed {
+			result.Reason = "Inline " + s.Language + " script: " + result.Reason
+			return result
+		}
+	}
+	for _, c := range a.Commits {
+		if result := m.checkCommit(c); !result.Allowed {
+			return result
+		}
+	}
+	for _, f := range a.Files {
+		// Paths in the command are relative to where it runs
+		f.Path, f.To, f.Link = fromCwd(cwd, f.Path), fromCwd(cwd, f.To), fromCwd(cwd, f.Link)
+		if f.Link != "" {
+			if result := m.checkLink(f.Path, f.Link); !result.Allowed {
+				result.Reason = "Command would link " + f.Link + " to " + f.Path + ": " + result.Reason
+				return result
+			}
+			continue
+		}
+		if f.To != "" {
+			if result := m.checkMove(f.Path, f.To, f.Action); !result.Allowed {
+				result.Reason = "Command would " + string(f.Action) + " " + f.Path + " to " + f.To + ": " + result.Reason
+				return result
+			}
+			continue
+		}
+		if result := m.CheckFile(f.Path, f.Action); !result.Allowed {
+			result.Reason = "Command would " + string(f.Action) + " " + f.Path + ": " + result.Reason
+			return result
+		}
+	}
+	if m.policy.BlockOpaque && len(a.Opaque) > 0 {
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  "Command can't be analyzed: " + a.Opaque[0],
+			Suggest: "run the command directly, without eval, variables or encoded payloads",
+			Rule:    policy.RuleBlockOpaque,
+		}
 	}
-
 	return &policy.CheckResult{Allowed: true}
 }
 
-// CheckContent validates if file content is allowed.
-func (m *Matcher) CheckContent(path, content string) *policy.CheckResult {
-	for i, rule := range m.policy.ContentRules {
-		// Check if file type matches
-		matched := false
-		for _, ft := range rule.FileTypes {
-			if matchFileType(path, ft) {
-				matched = true
-				break
+// matchCommands returns the indexes of the first command rule and block
+// pattern matching one of the commands Analyze unpacked from a command
+// line, and that command, or -1, -1, "". Each command, plus its alias
+// expansions, is checked against every rule; a rule with AllowHosts
+// skips commands naming only those hosts.
+func (m *Matcher) matchCommands(commands []string) (rule, pattern int, command string) {
+	for _, part := range commands {
+		variations := expandAliases(part)
+		for i, patterns := range m.commandRules {
+			if onlyHosts(m.commandHosts[i], shellFields(part)) {
+				continue
+			}
+			for j, cp := range patterns {
+				for _, v := range variations {
+					if cp.Match(v) {
+						return i, j, part
+					}
+				}
 			}
 		}
-		if !matched {
+	}
+	return -1, -1, ""
+}
+
+// replacement expands a RunInstead template for the command it replaces:
+// "{args}" becomes the words after the block pattern's literal prefix.
+// It reports false when a template without "{args}" would drop arguments
+// the agent passed, since running it wouldn't do what was asked.
+func replacement(template, pattern, cmd string) (string, bool) {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		prefix = pattern[:i]
+	}
+	words := strings.Fields(cmd)
+	n := min(len(strings.Fields(prefix)), len(words))
+	args := strings.Join(words[n:], " ")
+	if !strings.Contains(template, "{args}") {
+		return template, args == ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(template, "{args}", args)), true
+}
+
+// rewrite applies a command rule's Rewrite to the command line it
+// blocked: the words pattern matched literally in part are replaced,
+// keeping the rest of the line. "{args}" in the rewrite stands for the
+// arguments after them, as in RunInstead. It reports false when part
+// isn't in the line as written (it was unwrapped from a script or
+// variable) or the rewritten line would still be blocked by a command
+// rule.
+func (m *Matcher) rewrite(line, part, pattern, rewrite string) (string, bool) {
+	if !strings.Contains(rewrite, "{args}") {
+		rewrite += " {args}"
+	}
+	sub, _ := replacement(rewrite, pattern, part)
+	line, ok := splice(line, part, sub)
+	if !ok {
+		return "", false
+	}
+	if i, _, _ := m.matchCommands(Analyze(line).Commands); i >= 0 {
+		return "", false
+	}
+	return line, true
+}
+
+// splice replaces part, a command Analyze found in line, with sub. It
+// reports false when part isn't in the line as written.
+func splice(line, part, sub string) (string, bool) {
+	i := strings.Index(line, part)
+	if i < 0 {
+		return "", false
+	}
+	return line[:i] + sub + line[i+len(part):], true
+}
+
+// alternatives expands the "{args}" in a command rule's alternatives for
+// the command the block pattern matched. A template without "{args}" is
+// offered as written, and one with it is left out when the command had
+// no arguments to pass on.
+func alternatives(alts []policy.Alternative, pattern, cmd string) []policy.Alternative {
+	var expanded []policy.Alternative
+	for _, a := range alts {
+		if strings.Contains(a.Command, "{args}") {
+			bare := strings.TrimSpace(strings.ReplaceAll(a.Command, "{args}", ""))
+			if a.Command, _ = replacement(a.Command, pattern, cmd); a.Command == bare {
+				continue
+			}
+		}
+		expanded = append(expanded, a)
+	}
+	return expanded
+}
+
+// CheckContent validates if file content is allowed, taking content as
+// the whole file.
+func (m *Matcher) CheckContent(path, content string) *policy.CheckResult {
+	if result := m.checkPatterns(path, content, nil); !result.Allowed {
+		return result
+	}
+	return m.checkStructure(path, content, true)
+}
+
+// Mask returns content with what a policy's rule accepts blanked, so the
+// rule only flags what's new (see Set.CheckMasked).
+type Mask func(p *policy.Policy, rule, content string) string
+
+// checkPatterns checks content against the policy's content rules, each
+// masked by mask if it isn't nil.
+func (m *Matcher) checkPatterns(path, content string, mask Mask) *policy.CheckResult {
+	for i, rule := range m.policy.ContentRules {
+		if !m.contentRules[i].applies(path) {
 			continue
 		}
+		id := policy.RuleID(policy.RuleContent, i)
+		text := content
+		if mask != nil {
+			text = mask(m.policy, id, content)
+		}
 
-		// Check content against pattern
-		re := m.contentRegexes[i]
-		if re.MatchString(content) {
+		// Check content against pattern, skipping excepted matches
+		if m.contentRules[i].find(text, rule.Mode) >= 0 {
 			return &policy.CheckResult{
 				Allowed: false,
 				Reason:  rule.Reason,
 				Suggest: rule.Suggest,
+				Rule:    id,
 			}
 		}
 	}
@@ -148,25 +447,95 @@ func (m *Matcher) CheckContent(path, content string) *policy.CheckResult {
 	return &policy.CheckResult{Allowed: true}
 }
 
+// CheckHeader validates the opening lines of an existing file against the
+// policy's header rules.
+func (m *Matcher) CheckHeader(header string) *policy.CheckResult {
+	for i, rule := range m.policy.HeaderRules {
+		lines := strings.SplitN(header, "\n", rule.Lines+1)
+		if len(lines) > rule.Lines {
+			lines = lines[:rule.Lines]
+		}
+		top := strings.Join(lines, "\n")
+		for _, marker := range rule.Markers {
+			if strings.Contains(top, marker) {
+				return &policy.CheckResult{
+					Allowed: false,
+					Reason:  rule.Reason + " (" + marker + ")",
+					Suggest: rule.Suggest,
+					Rule:    policy.RuleID(policy.RuleHeader, i),
+				}
+			}
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
+
 // Check performs all relevant checks for a request.
+// Allow rules are evaluated first and short-circuit the blocking rules.
+// An allowlist's allow rules are checked by CheckFile and CheckCommand
+// instead, command by command.
 func (m *Matcher) Check(req *policy.CheckRequest) *policy.CheckResult {
+	return m.check(req, nil)
+}
+
+// check is Check with the content rules masked by mask, if not nil.
+func (m *Matcher) check(req *policy.CheckRequest, mask Mask) *policy.CheckResult {
+	if !m.policy.Allowlist && m.Allowed(req) {
+		return &policy.CheckResult{Allowed: true}
+	}
+
 	// Check command if present
 	if req.Command != "" {
-		if result := m.CheckCommand(req.Command); !result.Allowed {
+		if result := m.checkCommand(req.Command, req.Cwd); !result.Allowed {
 			return result
 		}
 	}
 
 	// Check file if present
 	if req.Target != "" {
-		if result := m.CheckFile(req.Target); !result.Allowed {
+		op := policy.Action(req.Action)
+		result := m.CheckFile(req.Target, op)
+		if req.Destination != "" && (op == policy.ActionRename || op == policy.ActionMove || op == policy.ActionCopy) {
+			result = m.checkMove(req.Target, req.Destination, op)
+		}
+		if !result.Allowed {
+			return result
+		}
+	}
+
+	// Check the session's change budget
+	if m.policy.MaxFiles > 0 && req.SessionFiles > m.policy.MaxFiles && Changes(req) {
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Session would change %d files, over the budget of %d", req.SessionFiles, m.policy.MaxFiles),
+			Suggest: "review the changes so far, or split the work into smaller sessions",
+			Rule:    policy.RuleMaxFiles,
+		}
+	}
+
+	// Check the existing file's header when it is being changed
+	if req.Header != "" && req.Target != "" && req.Action != string(policy.ActionRead) {
+		if result := m.CheckHeader(req.Header); !result.Allowed {
+			return result
+		}
+	}
+
+	// Check how much of the file a write removes
+	if req.Resize != nil && req.Target != "" {
+		if result := m.checkShrink(req.Target, req.Resize, req.Size); !result.Allowed {
 			return result
 		}
 	}
 
 	// Check content if present
 	if req.Content != "" && req.Target != "" {
-		if result := m.CheckContent(req.Target, req.Content); !result.Allowed {
+		if result := m.checkPatterns(req.Target, req.Content, mask); !result.Allowed {
+			return result
+		}
+		// An edit's content is only what it changes, so only a new file
+		// is checked for its header
+		whole := req.Action == string(policy.ActionCreate)
+		if result := m.checkStructure(req.Target, req.Content, whole); !result.Allowed {
 			return result
 		}
 	}
@@ -174,18 +543,128 @@ func (m *Matcher) Check(req *policy.CheckRequest) *policy.CheckResult {
 	return &policy.CheckResult{Allowed: true}
 }
 
-// matchFileType checks if a file path matches a file type pattern.
-func matchFileType(path, pattern string) bool {
-	// Simple extension matching
-	if strings.HasPrefix(pattern, "*.") {
-		ext := filepath.Ext(path)
-		return ext == pattern[1:] || ext == "."+pattern[2:]
+// Changes reports whether a request creates, modifies, renames, moves,
+// copies or deletes a file.
+func Changes(req *policy.CheckRequest) bool {
+	if req.Target == "" {
+		return false
+	}
+	switch policy.Action(req.Action) {
+	case policy.ActionCreate, policy.ActionModify, policy.ActionRename, policy.ActionMove, policy.ActionCopy, policy.ActionDelete:
+		return true
 	}
+	return false
+}
 
-	// Use glob for more complex patterns
-	g, err := glob.Compile(pattern, '/')
-	if err != nil {
-		return false
+// Allowed reports whether one of the policy's allow rules permits the request.
+// Commands are matched against their working directory, files against
+// their own path.
+func (m *Matcher) Allowed(req *policy.CheckRequest) bool {
+	for _, rule := range m.allowRules {
+		if len(rule.commands) == 0 && len(rule.paths) == 0 {
+			continue
+		}
+		if len(rule.commands) > 0 {
+			if req.Command == "" || !matchAnyCommand(rule.commands, req.Command) {
+				continue
+			}
+		}
+		if len(rule.paths) > 0 {
+			path := req.Target
+			if req.Command != "" {
+				path = req.Cwd
+			}
+			if path = NormalizePath(path); path == "." {
+				path = ""
+			}
+			if !matchAnyGlob(rule.paths, path) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// matchAnyGlob reports whether s matches one of globs.
+func matchAnyGlob(globs []glob.Glob, s string) bool {
+	for _, g := range globs {
+		if g.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePaths compiles path globs (see CompilePath).
+func compilePaths(patterns []string) ([]glob.Glob, error) {
+	var globs []glob.Glob
+	for _, pattern := range patterns {
+		g, err := CompilePath(pattern)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+// matchAnyCommand reports whether cmd matches one of patterns.
+func matchAnyCommand(patterns []*commandPattern, cmd string) bool {
+	for _, cp := range patterns {
+		if cp.Match(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFileTypes reports whether a file path matches one of a content
+// rule's file type patterns. An empty list matches every file.
+func matchFileTypes(p string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	p = strings.ReplaceAll(p, "\\", "/")
+	base := path.Base(p)
+	for _, pattern := range patterns {
+		// Simple extension matching
+		if strings.HasPrefix(pattern, "*.") && !strings.ContainsAny(pattern[1:], "/*?[{") {
+			if strings.HasSuffix(base, pattern[1:]) {
+				return true
+			}
+			continue
+		}
+
+		// Use glob for more complex patterns
+		g, err := CompilePath(pattern)
+		if err == nil && (g.Match(p) || g.Match(base)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizePath converts a path to the slash-separated, cleaned form
+// policies are matched against: backslashes become slashes, "." and ".."
+// segments are resolved, and trailing slashes are dropped.
+func NormalizePath(p string) string {
+	if p == "" {
+		return "."
+	}
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
+
+// fromCwd returns p, a path a command names, relative to the project
+// root rather than to cwd, the root-relative directory the command runs
+// in. Empty and absolute paths, and those starting at home or a
+// variable, are left alone.
+func fromCwd(cwd, p string) string {
+	if cwd = NormalizePath(cwd); cwd == "." || p == "" || strings.HasPrefix(p, "~") || strings.HasPrefix(p, "$") {
+		return p
+	}
+	if p = NormalizePath(p); path.IsAbs(p) {
+		return p
 	}
-	return g.Match(path) || g.Match(filepath.Base(path))
+	return path.Join(cwd, p)
 }
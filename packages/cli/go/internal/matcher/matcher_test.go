@@ -0,0 +1,114 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestCheckFile(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Action:      policy.ActionModify,
+		Include:     []string{".env", "**/.env"},
+		Exclude:     []string{".env.example"},
+		Description: "Environment files",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{".env", false},
+		{"config/.env", false},
+		{".env.example", true},
+		{"main.go", true},
+	}
+	for _, tt := range tests {
+		result := m.Check(&policy.CheckRequest{Action: "modify", Target: tt.target})
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s: allowed = %v, want %v", tt.target, result.Allowed, tt.allowed)
+		}
+		if !result.Allowed && result.Reason != "Environment files" {
+			t.Errorf("%s: reason = %q, want the policy description", tt.target, result.Reason)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Description: "prefer pnpm",
+		CommandRules: []policy.CommandRule{
+			{Block: []string{"npm install*"}, Reason: "Project uses pnpm", Suggest: "pnpm install"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := m.Check(&policy.CheckRequest{Action: "execute", Command: "  npm install lodash"})
+	if result.Allowed || result.Reason != "Project uses pnpm" || result.Suggest != "pnpm install" {
+		t.Errorf("npm install: %+v, want blocked with the rule's reason and suggestion", result)
+	}
+	if result := m.Check(&policy.CheckRequest{Action: "execute", Command: "pnpm install lodash"}); !result.Allowed {
+		t.Errorf("pnpm install: %+v, want allowed", result)
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Description: "no console.log",
+		ContentRules: []policy.ContentRule{
+			{Pattern: `console\.log\(`, FileTypes: []string{"*.ts"}, Reason: "Use the logger"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target  string
+		content string
+		allowed bool
+	}{
+		{"src/a.ts", "console.log(x)", false},
+		{"src/a.ts", "logger.info(x)", true},
+		{"src/a.py", "console.log(x)", true},
+	}
+	for _, tt := range tests {
+		result := m.Check(&policy.CheckRequest{Action: "modify", Target: tt.target, Content: tt.content})
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s %q: allowed = %v, want %v", tt.target, tt.content, result.Allowed, tt.allowed)
+		}
+	}
+}
+
+func TestNewRejectsBadPatterns(t *testing.T) {
+	if _, err := New(&policy.Policy{Include: []string{"[a-"}}); err == nil {
+		t.Error("bad include glob: want an error")
+	}
+	if _, err := New(&policy.Policy{ContentRules: []policy.ContentRule{{Pattern: "(", FileTypes: []string{"*.ts"}}}}); err == nil {
+		t.Error("bad content pattern: want an error")
+	}
+}
+
+func TestSetCheck(t *testing.T) {
+	s, err := NewSet([]*policy.Policy{
+		{Action: policy.ActionModify, Include: []string{"*.lock"}, Description: "lock files"},
+		{Action: policy.ActionModify, Include: []string{".env"}, Description: "env files"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len = %d, want 2", s.Len())
+	}
+	if result := s.Check(&policy.CheckRequest{Action: "modify", Target: ".env"}); result.Allowed || result.Reason != "env files" {
+		t.Errorf(".env: %+v, want blocked by env files", result)
+	}
+	if result := s.Check(&policy.CheckRequest{Action: "modify", Target: "README.md"}); !result.Allowed {
+		t.Errorf("README.md: %+v, want allowed", result)
+	}
+}
@@ -0,0 +1,70 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestUnattendedEscalation(t *testing.T) {
+	rule := func(d policy.Decision, s policy.Severity) *policy.Policy {
+		p := commandRule("git push --force*")
+		p.Decision = d
+		p.Severity = s
+		return p
+	}
+
+	tests := []struct {
+		name         string
+		policy       *policy.Policy
+		mode         string
+		wantAllowed  bool
+		wantDecision policy.Decision
+	}{
+		{"deny by default", rule("", ""), policy.ModeInteractive, false, policy.DecisionDeny},
+		{"ask waits for a human", rule(policy.DecisionAsk, ""), policy.ModeInteractive, false, policy.DecisionAsk},
+		{"ask denies unattended", rule(policy.DecisionAsk, ""), policy.ModeUnattended, false, policy.DecisionDeny},
+		{"warn allows", rule(policy.DecisionWarn, policy.SeverityHigh), policy.ModeInteractive, true, policy.DecisionWarn},
+		{"low warn stays a warning unattended", rule(policy.DecisionWarn, policy.SeverityLow), policy.ModeUnattended, true, policy.DecisionWarn},
+		{"high warn denies unattended", rule(policy.DecisionWarn, policy.SeverityHigh), policy.ModeUnattended, false, policy.DecisionDeny},
+	}
+	for _, tt := range tests {
+		s, err := NewSet([]*policy.Policy{tt.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: "git push --force origin main", Mode: tt.mode})
+		if result.Allowed != tt.wantAllowed || result.Decision != tt.wantDecision {
+			t.Errorf("%s: allowed=%v decision=%q, want allowed=%v decision=%q",
+				tt.name, result.Allowed, result.Decision, tt.wantAllowed, tt.wantDecision)
+		}
+	}
+}
+
+func TestSetModeDefault(t *testing.T) {
+	p := commandRule("git push --force*")
+	p.Decision = policy.DecisionAsk
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetMode(policy.ModeUnattended)
+
+	req := &policy.CheckRequest{Action: "execute", Command: "git push --force"}
+	if result := s.Check(req); result.Decision != policy.DecisionDeny {
+		t.Errorf("unattended set: decision %q, want deny", result.Decision)
+	}
+	// The request's own mode wins over the set's
+	req.Mode = policy.ModeInteractive
+	if result := s.Check(req); result.Decision != policy.DecisionAsk {
+		t.Errorf("interactive request: decision %q, want ask", result.Decision)
+	}
+}
+
+// commandRule returns a policy blocking commands matching pattern.
+func commandRule(pattern string) *policy.Policy {
+	return &policy.Policy{
+		Description:  pattern,
+		CommandRules: []policy.CommandRule{{Block: []string{pattern}, Reason: "blocked"}},
+	}
+}
@@ -0,0 +1,40 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestMonitorMode(t *testing.T) {
+	monitored := commandRule("rm -rf*")
+	monitored.Enforce = policy.EnforceMonitor
+	enforced := commandRule("rm -rf /*")
+
+	s, err := NewSet([]*policy.Policy{monitored, enforced})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the monitored policy matches: allowed, with the hit reported
+	result := s.Check(&policy.CheckRequest{Action: "execute", Command: "rm -rf build"})
+	if !result.Allowed {
+		t.Errorf("rm -rf build: %+v, want allowed", result)
+	}
+	if len(result.Monitored) != 1 || result.Monitored[0].Policy != "rm -rf*" || result.Monitored[0].Decision != policy.DecisionDeny {
+		t.Errorf("rm -rf build: monitored %+v, want the monitor policy's deny", result.Monitored)
+	}
+
+	// An enforced policy still blocks, and carries the monitor hits
+	result = s.Check(&policy.CheckRequest{Action: "execute", Command: "rm -rf /etc"})
+	if result.Allowed || result.Policy != "rm -rf /*" {
+		t.Errorf("rm -rf /etc: %+v, want blocked by the enforced policy", result)
+	}
+	if len(result.Monitored) != 1 {
+		t.Errorf("rm -rf /etc: monitored %+v, want one hit", result.Monitored)
+	}
+
+	if result := s.Check(&policy.CheckRequest{Action: "execute", Command: "ls"}); !result.Allowed || len(result.Monitored) != 0 {
+		t.Errorf("ls: %+v, want allowed without hits", result)
+	}
+}
@@ -0,0 +1,123 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// movedFiles returns the files an mv (or git mv) with the given
+// arguments renames or moves.
+func movedFiles(args []string) []FileAccess {
+	return transferred(args, moved)
+}
+
+// transferred returns the files mv-like arguments take somewhere else,
+// as describe describes them. The destination is the last argument, or
+// the -t directory; with several sources, or a destination ending in a
+// slash, it is a directory the sources keep their names in.
+func transferred(args []string, describe func(from, to string) FileAccess) []FileAccess {
+	var paths []string
+	dir := ""
+	options := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !options || !strings.HasPrefix(arg, "-") || arg == "-":
+			paths = append(paths, arg)
+		case arg == "--":
+			options = false
+		case arg == "-t" || arg == "--target-directory":
+			if i+1 < len(args) {
+				i++
+				dir = args[i]
+			}
+		case strings.HasPrefix(arg, "--target-directory="):
+			dir = strings.TrimPrefix(arg, "--target-directory=")
+		}
+	}
+
+	sources := paths
+	if dir == "" {
+		if len(paths) < 2 {
+			return nil
+		}
+		sources, dir = paths[:len(paths)-1], paths[len(paths)-1]
+		if len(sources) == 1 && !strings.HasSuffix(dir, "/") {
+			return []FileAccess{describe(sources[0], dir)}
+		}
+	}
+	var files []FileAccess
+	for _, src := range sources {
+		files = append(files, describe(src, path.Join(dir, path.Base(NormalizePath(src)))))
+	}
+	return files
+}
+
+// moved describes renaming or moving from to to: a rename when the file
+// stays in its directory.
+func moved(from, to string) FileAccess {
+	action := policy.ActionMove
+	if path.Dir(NormalizePath(from)) == path.Dir(NormalizePath(to)) {
+		action = policy.ActionRename
+	}
+	return FileAccess{Path: from, Action: action, To: to}
+}
+
+// linkedFiles returns the files an ln with the given arguments links to,
+// with the links it makes: ln TARGET LINK, ln TARGET (a link of the same
+// name here), ln TARGET... DIR or ln -t DIR TARGET.... A symlink's
+// relative target is resolved from the link's directory, since that's
+// where it points from.
+func linkedFiles(args []string) []FileAccess {
+	var paths []string
+	dir, symbolic := "", false
+	options := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case !options || !strings.HasPrefix(arg, "-") || arg == "-":
+			paths = append(paths, arg)
+		case arg == "--":
+			options = false
+		case arg == "-t" || arg == "--target-directory":
+			if i+1 < len(args) {
+				i++
+				dir = args[i]
+			}
+		case strings.HasPrefix(arg, "--target-directory="):
+			dir = strings.TrimPrefix(arg, "--target-directory=")
+		case arg == "--symbolic":
+			symbolic = true
+		case !strings.HasPrefix(arg, "--") && strings.Contains(arg, "s"):
+			symbolic = true
+		}
+	}
+
+	targets := paths
+	var links []string
+	switch {
+	case dir != "":
+	case len(paths) == 1:
+		links = []string{path.Base(NormalizePath(paths[0]))}
+	case len(paths) == 2 && !strings.HasSuffix(paths[1], "/"):
+		targets, links = paths[:1], paths[1:]
+	case len(paths) > 1:
+		targets, dir = paths[:len(paths)-1], paths[len(paths)-1]
+	}
+	if links == nil {
+		for _, t := range targets {
+			links = append(links, path.Join(dir, path.Base(NormalizePath(t))))
+		}
+	}
+
+	var files []FileAccess
+	for i, t := range targets {
+		if symbolic && !path.IsAbs(t) {
+			t = path.Join(path.Dir(links[i]), t)
+		}
+		files = append(files, FileAccess{Path: t, Action: policy.ActionModify, Link: links[i]})
+	}
+	return files
+}
@@ -0,0 +1,84 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestMovedFiles(t *testing.T) {
+	tests := map[string][]FileAccess{
+		"mv .env env.bak":                     {{Path: ".env", Action: policy.ActionRename, To: "env.bak"}},
+		"mv -f .env /tmp/x":                   {{Path: ".env", Action: policy.ActionMove, To: "/tmp/x"}},
+		"mv a b backup/":                      {{Path: "a", Action: policy.ActionMove, To: "backup/a"}, {Path: "b", Action: policy.ActionMove, To: "backup/b"}},
+		"mv -t /tmp src/.env":                 {{Path: "src/.env", Action: policy.ActionMove, To: "/tmp/.env"}},
+		"git mv db/migrations/1.sql db/1.sql": {{Path: "db/migrations/1.sql", Action: policy.ActionMove, To: "db/1.sql"}},
+		"mv -- -x y":                          {{Path: "-x", Action: policy.ActionRename, To: "y"}},
+		"sudo mv ./.env .env2":                {{Path: "./.env", Action: policy.ActionRename, To: ".env2"}},
+		"mv onlyone":                          nil,
+	}
+	for cmd, want := range tests {
+		if got := Analyze(cmd).Files; !reflect.DeepEqual(got, want) {
+			t.Errorf("Analyze(%q).Files = %+v, want %+v", cmd, got, want)
+		}
+	}
+}
+
+func TestMoveCommands(t *testing.T) {
+	env, err := New(&policy.Policy{Action: policy.ActionModify, Description: "Environment files", Include: []string{"**/.env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests, err := New(&policy.Policy{Action: policy.ActionDelete, Description: "Test files", Include: []string{"**/*.test.ts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		m       *Matcher
+		cmd     string
+		allowed bool
+	}{
+		{env, "mv .env env.bak", false},
+		{env, "cd app && mv .env ../env.bak", false},
+		{env, "mv /tmp/evil app/.env", false},
+		{env, "mv notes.txt todo.txt", true},
+		{tests, "git mv src/a.test.ts src/a.ts", false},
+		{tests, "mv src/a.ts src/b.ts", true},
+	}
+	for _, tt := range cases {
+		if got := tt.m.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd}).Allowed; got != tt.allowed {
+			t.Errorf("%s: allowed = %v, want %v", tt.cmd, got, tt.allowed)
+		}
+	}
+}
+
+func TestLinkCommands(t *testing.T) {
+	links := map[string][]FileAccess{
+		"ln -s .env public/config":     {{Path: "public/.env", Action: policy.ActionModify, Link: "public/config"}},
+		"ln -sf ../.env public/config": {{Path: ".env", Action: policy.ActionModify, Link: "public/config"}},
+		"ln .env backup":               {{Path: ".env", Action: policy.ActionModify, Link: "backup"}},
+		"ln -s /etc/passwd":            {{Path: "/etc/passwd", Action: policy.ActionModify, Link: "passwd"}},
+		"ln a b dir/":                  {{Path: "a", Action: policy.ActionModify, Link: "dir/a"}, {Path: "b", Action: policy.ActionModify, Link: "dir/b"}},
+	}
+	for cmd, want := range links {
+		if got := Analyze(cmd).Files; !reflect.DeepEqual(got, want) {
+			t.Errorf("Analyze(%q).Files = %+v, want %+v", cmd, got, want)
+		}
+	}
+
+	env, err := New(&policy.Policy{Action: policy.ActionModify, Description: "Environment files", Include: []string{".env", "**/.env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cmd, allowed := range map[string]bool{
+		"ln -s ../.env public/config":    false,
+		"ln .env env.txt":                false,
+		"ln -s /tmp/x app/.env":          false,
+		"ln -s ../README.md docs/readme": true,
+	} {
+		if got := env.Check(&policy.CheckRequest{Action: "execute", Command: cmd}).Allowed; got != allowed {
+			t.Errorf("%s: allowed = %v, want %v", cmd, got, allowed)
+		}
+	}
+}
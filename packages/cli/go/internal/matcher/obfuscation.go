@@ -0,0 +1,500 @@
+package matcher
+
+import (
+	"encoding/base64"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Analysis is a command line unpacked into everything it would run, so
+// rules also see commands hidden behind wrappers and encodings.
+type Analysis struct {
+	// Commands to match, starting with the plain split of the line and
+	// followed by commands found inside bash -c, heredocs, base64
+	// payloads, $(...) substitutions and variables
+	Commands []string
+	// Opaque describes constructs whose effect can't be determined
+	// without running them, e.g. a variable used as the command name
+	Opaque []string
+	// Scripts is inline code run by interpreters, as in python -c
+	Scripts []InlineScript
+	// Files are files those scripts delete or write, files mv renames
+	// or moves, files ln links to, files rm deletes, files truncate and
+	// sed -i change, files copied, archived or written through
+	// redirection, and files sent over the network
+	Files []FileAccess
+	// Commits are git commits made with a message on the command line
+	// or in a heredoc
+	Commits []Commit
+}
+
+// substituted stands in for the output of a command substitution.
+const substituted = "<output>"
+
+// maxAnalysisDepth bounds how many layers of wrapping are unpacked.
+const maxAnalysisDepth = 5
+
+// shells are interpreters that run their -c argument or stdin as a script.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// wrappers run their arguments as a command.
+var wrappers = map[string]bool{"command": true, "exec": true, "nohup": true, "builtin": true, "time": true, "nice": true}
+
+var (
+	assignmentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+	variableRe   = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	heredocRe    = regexp.MustCompile(`<<-?\s*(['"]?)([A-Za-z_][A-Za-z0-9_]*)(['"]?)`)
+	herestringRe = regexp.MustCompile(`<<<\s*(.+)$`)
+	base64Re     = regexp.MustCompile(`^base64\s+(?:.*\s)?(?:-d|--decode|-D)\b`)
+)
+
+// Analyze unpacks a command line. Known wrappers and encodings are
+// decoded and their inner commands added to Commands; anything that can't
+// be decoded statically is reported in Opaque.
+func Analyze(full string) *Analysis {
+	a := &Analysis{}
+	a.walk(full, map[string]string{}, 0)
+	return a
+}
+
+func (a *Analysis) add(cmd string) {
+	if cmd = strings.TrimSpace(cmd); cmd != "" {
+		a.Commands = append(a.Commands, cmd)
+	}
+}
+
+func (a *Analysis) opaque(why string) {
+	for _, o := range a.Opaque {
+		if o == why {
+			return
+		}
+	}
+	a.Opaque = append(a.Opaque, why)
+}
+
+// walk analyzes a script: heredocs first, then each pipeline.
+func (a *Analysis) walk(script string, vars map[string]string, depth int) {
+	if depth > maxAnalysisDepth {
+		a.opaque("commands nested too deeply to analyze")
+		return
+	}
+	script = a.heredocs(script, vars, depth)
+
+	for _, pipeline := range splitShell(script, false) {
+		parts := splitShell(pipeline, true)
+		var stages [][]string
+		for _, part := range parts {
+			stages = append(stages, a.command(part, vars, depth))
+		}
+		a.Files = append(a.Files, piped(stages)...)
+		if len(parts) > 1 {
+			// Whole pipelines too, for rules like "curl * | bash*"
+			a.add(pipeline)
+			a.decodePipeline(parts, vars, depth)
+		}
+	}
+}
+
+// heredocs removes heredoc bodies from a script, analyzing the bodies fed
+// to a shell or interpreter as scripts of their own.
+func (a *Analysis) heredocs(script string, vars map[string]string, depth int) string {
+	if !strings.Contains(script, "<<") {
+		return script
+	}
+	lines := strings.Split(script, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		out = append(out, line)
+
+		if m := herestringRe.FindStringSubmatch(line); m != nil {
+			a.feed(line[:strings.Index(line, "<<<")], unquote(strings.TrimSpace(m[1])), vars, depth)
+			continue
+		}
+		m := heredocRe.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		delim := line[m[4]:m[5]]
+		var body []string
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != delim; i++ {
+			body = append(body, lines[i])
+		}
+		a.feed(line[:m[0]], strings.Join(body, "\n"), vars, depth)
+	}
+	return strings.Join(out, "\n")
+}
+
+// feed analyzes text redirected into the command before a heredoc: as a
+// script when that command is a shell, as inline code when it's an
+// interpreter, and not at all otherwise.
+func (a *Analysis) feed(before, text string, vars map[string]string, depth int) {
+	parts := splitShell(before, true)
+	if len(parts) == 0 {
+		return
+	}
+	words := unwrap(shellFields(parts[len(parts)-1]))
+	if len(words) == 0 {
+		return
+	}
+	if shells[path.Base(words[0])] {
+		a.walk(text, vars, depth+1)
+	} else if lang, ok := interpreterFor(words); ok {
+		a.inspectScript(InlineScript{Language: lang, Code: text}, vars, depth)
+	} else if c, stdin, ok := commitMessage(words); ok && stdin {
+		c.Message = text
+		a.Commits = append(a.Commits, c)
+	}
+}
+
+// command analyzes one simple command, returning its words after
+// expansion.
+func (a *Analysis) command(part string, vars map[string]string, depth int) []string {
+	words := shellFields(part)
+
+	// A bare assignment sets a variable for later commands
+	exported := len(words) > 0 && words[0] == "export"
+	if exported {
+		words = words[1:]
+	}
+	if len(words) == 1 && assignmentRe.MatchString(words[0]) {
+		name, value, _ := strings.Cut(words[0], "=")
+		vars[name] = a.substitute(expand(value, vars), vars, depth)
+		if exported {
+			// Exports reach child processes, so rules still see them
+			a.add(expand(part, vars))
+		}
+		return nil
+	}
+
+	a.add(part)
+	if posix := posixForm(part); posix != "" {
+		a.add(posix)
+	}
+
+	expanded := a.substitute(expand(part, vars), vars, depth)
+	words = unwrap(shellFields(expanded))
+	if plain := joinWords(words); normalizeCommand(plain) != normalizeCommand(part) {
+		a.add(plain)
+	}
+	args, _, _, _ := redirections(words)
+	a.run(args, vars, depth)
+	return words
+}
+
+// run analyzes the program a command runs and anything it runs in turn.
+func (a *Analysis) run(words []string, vars map[string]string, depth int) {
+	if len(words) == 0 {
+		return
+	}
+	if strings.HasPrefix(words[0], "$") {
+		a.opaque("variable used as a command: " + words[0])
+	}
+
+	// perl -pi -e is inline code too, so in-place edits come first
+	a.Files = append(a.Files, editedFiles(words)...)
+	if script, ok := inlineCode(words); ok {
+		a.inspectScript(script, vars, depth)
+		return
+	}
+
+	switch name := path.Base(words[0]); {
+	case shells[name]:
+		if script, ok := shellScript(words[1:]); ok {
+			a.walk(script, copyVars(vars), depth+1)
+		}
+		for _, w := range words[1:] {
+			if strings.HasPrefix(w, "<(") {
+				a.opaque("process substitution run as a script")
+			}
+		}
+	case windowsShell(name):
+		script, ok := windowsScript(words[1:])
+		if !ok {
+			a.opaque("encoded PowerShell command that can't be decoded")
+			return
+		}
+		a.walk(script, copyVars(vars), depth+1)
+	case name == "mv":
+		a.Files = append(a.Files, movedFiles(words[1:])...)
+	case name == "git" && len(words) > 1 && words[1] == "mv":
+		a.Files = append(a.Files, movedFiles(words[2:])...)
+	case name == "git":
+		// A message read from stdin is picked up with the heredoc
+		if c, stdin, ok := commitMessage(words); ok && !stdin {
+			a.Commits = append(a.Commits, c)
+		}
+	case name == "rm" || name == "rmdir" || name == "unlink":
+		a.Files = append(a.Files, removedFiles(words[1:])...)
+	case name == "curl":
+		a.Files = append(a.Files, uploadedFiles(words[1:])...)
+	case name == "truncate":
+		a.Files = append(a.Files, truncatedFiles(words[1:])...)
+	case name == "ln":
+		a.Files = append(a.Files, linkedFiles(words[1:])...)
+	case copiers[name]:
+		a.Files = append(a.Files, copiedFiles(words[1:])...)
+	case name == "tar" || name == "zip":
+		if archive, sources, recursive := archiveArgs(name, words[1:]); archive != "" && archive != "-" {
+			a.Files = append(a.Files, archived(sources, archive, recursive)...)
+		}
+	case name == "dd":
+		if from, to := ddArgs(words[1:]); from != "" && to != "" && !device(to) {
+			a.Files = append(a.Files, copied(from, to))
+		}
+	case name == "sudo":
+		rest := elevated(words)
+		a.add(joinWords(rest))
+		a.run(rest, vars, depth+1)
+	case name == "eval" || name == "source" && len(words) > 1 && strings.HasPrefix(words[1], "<("):
+		script := strings.Join(words[1:], " ")
+		if strings.Contains(script, "$") || strings.HasPrefix(script, "<(") {
+			a.opaque(name + " of a value that can't be resolved")
+			return
+		}
+		a.walk(script, copyVars(vars), depth+1)
+	}
+}
+
+// elevated returns the command sudo runs, without sudo and its options,
+// or words unchanged when they don't start with sudo.
+func elevated(words []string) []string {
+	if len(words) == 0 || path.Base(words[0]) != "sudo" {
+		return words
+	}
+	rest := words[1:]
+	for len(rest) > 0 && strings.HasPrefix(rest[0], "-") {
+		if rest[0] == "-u" || rest[0] == "-g" {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			rest = rest[1:]
+		}
+	}
+	return unwrap(rest)
+}
+
+// joinWords rebuilds a command from words, quoting words with spaces.
+func joinWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		switch {
+		case !strings.ContainsAny(w, " \t\n"):
+			quoted[i] = w
+		case strings.Contains(w, "'"):
+			quoted[i] = `"` + w + `"`
+		default:
+			quoted[i] = "'" + w + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// substitute replaces $(echo ...) and `echo ...` with their output and
+// analyzes every other command substitution as a command of its own.
+func (a *Analysis) substitute(s string, vars map[string]string, depth int) string {
+	for {
+		start, end, inner := findSubstitution(s)
+		if start < 0 {
+			return s
+		}
+		words := shellFields(inner)
+		if len(words) > 0 && (words[0] == "echo" || words[0] == "printf") {
+			args := words[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				args = args[1:]
+			}
+			s = s[:start] + strings.Join(args, " ") + s[end:]
+			continue
+		}
+		a.walk(inner, copyVars(vars), depth+1)
+		if strings.TrimSpace(s[:start]) == "" {
+			a.opaque("command substitution used as a command")
+		}
+		s = s[:start] + substituted + s[end:]
+	}
+}
+
+// findSubstitution locates the first $(...) or `...` in s.
+func findSubstitution(s string) (start, end int, inner string) {
+	if i := strings.Index(s, "$("); i >= 0 {
+		depth := 0
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i, j + 1, s[i+2 : j]
+				}
+			}
+		}
+	}
+	if i := strings.Index(s, "`"); i >= 0 {
+		if j := strings.Index(s[i+1:], "`"); j >= 0 {
+			return i, i + j + 2, s[i+1 : i+1+j]
+		}
+	}
+	return -1, -1, ""
+}
+
+// decodePipeline analyzes base64 payloads piped into a shell.
+func (a *Analysis) decodePipeline(parts []string, vars map[string]string, depth int) {
+	for i, part := range parts {
+		if !base64Re.MatchString(normalizeCommand(part)) || !pipesToShell(parts[i+1:]) {
+			continue
+		}
+		payload, ok := "", false
+		if m := herestringRe.FindStringSubmatch(part); m != nil {
+			payload, ok = unquote(strings.TrimSpace(m[1])), true
+		} else if i > 0 {
+			payload, ok = echoed(expand(parts[i-1], vars))
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+		if !ok || err != nil {
+			a.opaque("encoded payload piped to a shell")
+			continue
+		}
+		a.walk(string(decoded), copyVars(vars), depth+1)
+	}
+}
+
+// pipesToShell reports whether one of the remaining pipeline stages is a shell.
+func pipesToShell(rest []string) bool {
+	for _, part := range rest {
+		words := unwrap(shellFields(part))
+		if len(words) > 0 && shells[path.Base(words[0])] {
+			return true
+		}
+	}
+	return false
+}
+
+// echoed returns what an echo or printf command prints.
+func echoed(part string) (string, bool) {
+	words := shellFields(part)
+	if len(words) < 2 || (words[0] != "echo" && words[0] != "printf") {
+		return "", false
+	}
+	args := words[1:]
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	return strings.Join(args, " "), len(args) > 0
+}
+
+// shellScript returns the -c argument of a shell invocation.
+func shellScript(args []string) (string, bool) {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			continue
+		}
+		if strings.Contains(arg, "c") && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// unwrap strips prefixes that don't change which program runs: env and
+// its assignments, leading VAR=value assignments, wrappers like nohup,
+// Quotes and backslashes in the command name ("\rm", "'rm'") are already
+// gone after shellFields.
+func unwrap(words []string) []string {
+	for len(words) > 0 {
+		w := words[0]
+		switch {
+		case assignmentRe.MatchString(w):
+			words = words[1:]
+		case w == "env" || path.Base(w) == "env":
+			words = words[1:]
+			for len(words) > 0 && (strings.HasPrefix(words[0], "-") || assignmentRe.MatchString(words[0])) {
+				words = words[1:]
+			}
+		case wrappers[w]:
+			words = words[1:]
+			for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+				words = words[1:]
+			}
+		default:
+			return words
+		}
+	}
+	return words
+}
+
+// expand substitutes known variables.
+func expand(s string, vars map[string]string) string {
+	return variableRe.ReplaceAllStringFunc(s, func(ref string) string {
+		m := variableRe.FindStringSubmatch(ref)
+		name := m[1] + m[2]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// shellFields splits a command into words the way a shell would, removing
+// quotes and backslash escapes. Text inside $(...) stays in one word.
+func shellFields(s string) []string {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			current.WriteByte(s[i])
+			inWord = true
+		case (c == '"' || c == '\'') && depth == 0:
+			quote = c
+			inWord = true
+		case (c == ' ' || c == '\t' || c == '\n') && depth == 0:
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			if c == '(' {
+				depth++
+			} else if c == ')' && depth > 0 {
+				depth--
+			}
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// unquote strips one layer of matching quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func copyVars(vars map[string]string) map[string]string {
+	c := make(map[string]string, len(vars))
+	for k, v := range vars {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,141 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// PatternError describes a content pattern that can't be enforced
+// identically by the Go (RE2) and TypeScript engines.
+type PatternError struct {
+	Pattern    string
+	Problem    string
+	Suggestion string
+}
+
+func (e *PatternError) Error() string {
+	msg := fmt.Sprintf("pattern %q: %s", e.Pattern, e.Problem)
+	if e.Suggestion != "" {
+		msg += "; " + e.Suggestion
+	}
+	return msg
+}
+
+// unsupported lists constructs other engines accept but RE2 rejects.
+var unsupported = []struct {
+	token      string
+	problem    string
+	suggestion string
+}{
+	{"(?<=", "lookbehind is not supported", "match the preceding text as part of the pattern"},
+	{"(?<!", "negative lookbehind is not supported", "move the negative case into the rule's exceptions"},
+	{"(?=", "lookahead is not supported", "match the following text directly or drop the assertion"},
+	{"(?!", "negative lookahead is not supported", "move the negative case into the rule's exceptions"},
+	{"(?>", "atomic groups are not supported", "use a plain group (...)"},
+	{"*+", "possessive quantifiers are not supported", "use a plain quantifier (*)"},
+	{"++", "possessive quantifiers are not supported", "use a plain quantifier (+)"},
+	{"?+", "possessive quantifiers are not supported", "use a plain quantifier (?)"},
+}
+
+var backreference = regexp.MustCompile(`\\[1-9]`)
+
+// ValidatePattern checks that a content pattern uses RE2-safe syntax that
+// both engines evaluate the same way, returning a *PatternError with a
+// suggested rewrite when it doesn't.
+func ValidatePattern(pattern string) error {
+	for _, u := range unsupported {
+		if strings.Contains(pattern, u.token) {
+			return &PatternError{Pattern: pattern, Problem: u.problem, Suggestion: u.suggestion}
+		}
+	}
+	if loc := backreference.FindStringIndex(pattern); loc != nil && !escaped(pattern, loc[0]) {
+		return &PatternError{
+			Pattern:    pattern,
+			Problem:    "backreferences are not supported",
+			Suggestion: `list the alternatives explicitly, e.g. ("[^"]*"|'[^']*')`,
+		}
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return &PatternError{Pattern: pattern, Problem: err.Error()}
+	}
+	if nestedQuantifier(re, false) {
+		return &PatternError{
+			Pattern:    pattern,
+			Problem:    "nested unbounded quantifiers backtrack catastrophically in the TypeScript engine",
+			Suggestion: "remove the inner quantifier, e.g. (a+)+ → a+",
+		}
+	}
+	return nil
+}
+
+// escaped reports whether the backslash at i is itself escaped.
+func escaped(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// nestedQuantifier reports whether an unbounded repetition contains
+// another unbounded repetition.
+func nestedQuantifier(re *syntax.Regexp, inside bool) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus ||
+		(re.Op == syntax.OpRepeat && re.Max == -1)
+	if unbounded && inside {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if nestedQuantifier(sub, inside || unbounded) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks every regex in a policy, returning one error per
+// pattern that isn't RE2-safe.
+func Validate(p *policy.Policy) []error {
+	var errs []error
+	check := func(pattern string) {
+		if pattern == "" {
+			return
+		}
+		if err := ValidatePattern(pattern); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, rule := range p.ContentRules {
+		check(rule.Pattern)
+		for _, ex := range rule.Exceptions {
+			check(ex)
+		}
+	}
+	for _, rule := range p.ASTRules {
+		check(rule.RegexPreFilter)
+	}
+	for _, rule := range p.StructureRules {
+		check(rule.RequireHeader)
+	}
+	return errs
+}
+
+// compilePattern validates and compiles a content pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.Compile(pattern)
+}
+
+// multiline returns re with ^ and $ matching at line boundaries, like the
+// TS engine's "gm" flags.
+func multiline(re *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile("(?m)" + re.String())
+}
@@ -0,0 +1,49 @@
+package matcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{`console\.log\s*\(`, true},
+		{`(?:import\s+.*\s+from\s+['"]lodash)`, true},
+		{`\\1`, true}, // escaped backslash followed by 1
+		{`foo(?!bar)`, false},
+		{`foo(?=bar)`, false},
+		{`(?<=a)b`, false},
+		{`(?<!a)b`, false},
+		{`(['"]).*\1`, false},
+		{`(a+)+`, false},
+		{`(\w+\s*)*$`, false},
+		{`(?>a)`, false},
+		{`a{2,}b`, true},
+		{`(ab){1,3}`, true},
+		{`[`, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePattern(tt.pattern)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidatePattern(%q) = %v, want ok=%v", tt.pattern, err, tt.ok)
+		}
+		var pe *PatternError
+		if err != nil && !errors.As(err, &pe) {
+			t.Errorf("ValidatePattern(%q) returned %T, want *PatternError", tt.pattern, err)
+		}
+	}
+}
+
+func TestBuiltinPatternsAreRE2Safe(t *testing.T) {
+	for name, b := range builtin.Registry {
+		for _, err := range Validate(b.ToPolicy("")) {
+			t.Errorf("builtin %q: %v", name, err)
+		}
+	}
+}
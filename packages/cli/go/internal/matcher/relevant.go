@@ -0,0 +1,70 @@
+package matcher
+
+import "github.com/VulnZap/veto/internal/policy"
+
+// Concerns reports whether the policy has anything to say about changes
+// to a file: it protects the file, checks its content, shape, size or
+// headers, or is an allowlist whose reach includes it. Command rules
+// concern commands, not files, and don't count.
+func (m *Matcher) Concerns(path string) bool {
+	p := m.policy
+	path = NormalizePath(path)
+	if len(m.includeGlobs) > 0 && !matchAnyGlob(m.includeGlobs, path) {
+		// Content policies use include to scope their rules
+		return false
+	}
+	if p.Allowlist {
+		return len(m.listed(false)) > 0 && !matchAnyGlob(m.excludeGlobs, path)
+	}
+	for i := range p.ContentRules {
+		if m.contentRules[i].applies(path) {
+			return true
+		}
+	}
+	for _, rule := range p.StructureRules {
+		if matchFileTypes(path, rule.FileTypes) {
+			return true
+		}
+	}
+	for i, rule := range p.ShrinkRules {
+		if matchFileTypes(path, rule.FileTypes) && (len(m.shrinkPaths[i]) == 0 || matchAnyGlob(m.shrinkPaths[i], path)) {
+			return true
+		}
+	}
+	if lang := Language(path); lang != "" {
+		for _, rule := range p.ASTRules {
+			if speaks(rule.Languages, lang) {
+				return true
+			}
+		}
+	}
+	if len(p.HeaderRules) > 0 {
+		return true
+	}
+	return len(m.includeGlobs) > 0 && len(p.ContentRules) == 0 && !matchAnyGlob(m.excludeGlobs, path)
+}
+
+// Relevant returns the policies in the set that concern a file (see
+// Matcher.Concerns), in policy order, for telling an agent what applies
+// to the files it is working on. Allow policies, policies whose
+// conditions don't hold for req and policies that don't apply to the
+// file's directory are left out. req gives the context; its target is
+// the file.
+func (s *Set) Relevant(req *policy.CheckRequest) []*policy.Policy {
+	scoped, deepest := s.scope(req)
+	var relevant []*policy.Policy
+	for i, m := range s.matchers {
+		p := m.policy
+		local := scoped[i]
+		if local == nil || !p.Locked && deepest[p.Description] != p.Dir {
+			continue
+		}
+		if p.Decision == policy.DecisionAllow || !p.When.Matches(local) || m.Allowed(local) && !p.Allowlist {
+			continue
+		}
+		if m.Concerns(local.Target) {
+			relevant = append(relevant, p)
+		}
+	}
+	return relevant
+}
@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestRelevant(t *testing.T) {
+	var policies []*policy.Policy
+	for _, name := range []string{".env", "no console.log", "no force push", "migrations"} {
+		policies = append(policies, builtin.Find(name).ToPolicy(policy.ActionModify))
+	}
+	set, err := NewSet(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{".env", []string{policies[0].Description}},
+		{"src/app.ts", []string{policies[1].Description}},
+		{"db/migrate/001_users.rb", []string{policies[3].Description}},
+		{"README.md", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range set.Relevant(&policy.CheckRequest{Action: "modify", Target: tt.target}) {
+			got = append(got, p.Description)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Relevant(%s) = %q, want %q", tt.target, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Relevant(%s) = %q, want %q", tt.target, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package matcher
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestRollout(t *testing.T) {
+	p := commandRule("npm publish*")
+
+	for _, percent := range []int{0, 100} {
+		p.Rollout = percent
+		if !inRollout(p, &policy.CheckRequest{SessionID: "any"}) {
+			t.Errorf("rollout %d%%: want every session enforced", percent)
+		}
+	}
+
+	p.Rollout = 25
+	enforced := 0
+	for i := 0; i < 1000; i++ {
+		req := &policy.CheckRequest{SessionID: fmt.Sprintf("session-%d", i)}
+		in := inRollout(p, req)
+		if in {
+			enforced++
+		}
+		// A session gets the same answer on every check
+		if inRollout(p, req) != in {
+			t.Fatalf("session-%d: inconsistent rollout", i)
+		}
+	}
+	if enforced < 200 || enforced > 300 {
+		t.Errorf("25%% rollout enforced %d of 1000 sessions", enforced)
+	}
+}
+
+func TestRolloutMonitorsTheRest(t *testing.T) {
+	p := commandRule("npm publish*")
+	p.Rollout = 50
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blocked, monitored int
+	for i := 0; i < 100; i++ {
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: "npm publish", SessionID: fmt.Sprintf("s%d", i)})
+		if !result.Allowed {
+			blocked++
+		} else if len(result.Monitored) == 1 {
+			monitored++
+		}
+	}
+	if blocked == 0 || monitored == 0 || blocked+monitored != 100 {
+		t.Errorf("blocked %d, monitored %d: want every session either blocked or monitored", blocked, monitored)
+	}
+}
@@ -0,0 +1,198 @@
+package matcher
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRunInstead(t *testing.T) {
+	tests := []struct {
+		phrase, cmd, want string
+	}{
+		{"prefer pnpm", "npm install", "pnpm install"},
+		{"prefer pnpm", "npm i", "pnpm install"},
+		{"prefer pnpm", "cd web && npm ci", "cd web && pnpm install"},
+		{"prefer pnpm", "npm ci && npm test", "pnpm install && npm test"},
+		{"use docker compose", "cd infra; docker-compose up", "cd infra; docker compose up"},
+		// Expanded from a variable, so there's nothing to replace in place
+		{"prefer pnpm", "pm=npm; $pm ci", ""},
+		// Running plain "pnpm install" would drop the package
+		{"prefer pnpm", "npm install lodash", ""},
+		{"use docker compose", "docker-compose up -d", "docker compose up -d"},
+		{"use docker compose", "docker-compose -f dev.yml logs api", "docker compose -f dev.yml logs api"},
+	}
+	for _, tt := range tests {
+		b := builtin.Find(tt.phrase)
+		if b == nil {
+			t.Fatalf("no builtin for %q", tt.phrase)
+		}
+		p := b.ToPolicy(policy.ActionExecute)
+		p.AutoRun = true
+		s, err := NewSet([]*policy.Policy{p})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd})
+		if result.Allowed {
+			t.Errorf("%s: %q allowed", tt.phrase, tt.cmd)
+		}
+		if result.RunInstead != tt.want {
+			t.Errorf("%s: %q runs %q instead, want %q", tt.phrase, tt.cmd, result.RunInstead, tt.want)
+		}
+	}
+}
+
+func TestRunInsteadNeedsAutoRun(t *testing.T) {
+	p := builtin.Find("prefer pnpm").ToPolicy(policy.ActionExecute)
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := s.Check(&policy.CheckRequest{Action: "execute", Command: "npm install"})
+	if result.Allowed || result.RunInstead != "" {
+		t.Errorf("without autoRun got %+v", result)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	p := &policy.Policy{
+		Action:      policy.ActionExecute,
+		Description: "Use pnpm",
+		AutoRun:     true,
+		CommandRules: []policy.CommandRule{
+			{Block: []string{"npm install*", "npm i *"}, Reason: "Project uses pnpm", Rewrite: "pnpm install"},
+			{Block: []string{"npx *"}, Reason: "Project uses pnpm", Rewrite: "pnpm dlx {args}"},
+			// A rewrite the rule would block again is never offered
+			{Block: []string{"yarn*"}, Reason: "Project uses pnpm", Rewrite: "yarn --silent"},
+		},
+	}
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ cmd, want string }{
+		{"npm install", "pnpm install"},
+		{"npm install lodash --save-dev", "pnpm install lodash --save-dev"},
+		{"cd web && npm i react && npm test", "cd web && pnpm install react && npm test"},
+		{"sudo npm install -g tsx", "sudo pnpm install -g tsx"},
+		{"npx prettier --write .", "pnpm dlx prettier --write ."},
+		{"yarn add react", ""},
+		{`bash -c "npm install"`, `bash -c "pnpm install"`},
+		// Expanded from a variable, so there's nothing to rewrite in place
+		{"pm=npm; $pm install", ""},
+	}
+	for _, tt := range tests {
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd})
+		if result.Allowed {
+			t.Errorf("%q allowed", tt.cmd)
+		}
+		if result.Rewrite != tt.want {
+			t.Errorf("%q rewrites to %q, want %q", tt.cmd, result.Rewrite, tt.want)
+		}
+	}
+
+	// Without autoRun the rewrite is only suggested
+	p.AutoRun = false
+	result := s.Check(&policy.CheckRequest{Action: "execute", Command: "npm install lodash"})
+	if result.Rewrite != "" || result.Suggest != "pnpm install lodash" {
+		t.Errorf("without autoRun got rewrite %q, suggest %q", result.Rewrite, result.Suggest)
+	}
+}
+
+func TestAlternatives(t *testing.T) {
+	tests := []struct {
+		phrase, cmd string
+		want        []string
+	}{
+		{"prefer pnpm", "npm install lodash", []string{"pnpm install", "pnpm add lodash"}},
+		// Nothing to add
+		{"prefer pnpm", "npm ci", []string{"pnpm install"}},
+		{"use docker compose", "docker-compose up -d", []string{"docker compose up -d"}},
+		{"no hard reset", "git reset --hard HEAD~1", []string{"git stash", "git reset --soft HEAD~1"}},
+		// Free-text suggestions offer no commands
+		{"no force push", "git push --force", nil},
+	}
+	for _, tt := range tests {
+		s, err := NewSet([]*policy.Policy{builtin.Find(tt.phrase).ToPolicy(policy.ActionExecute)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := s.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd})
+		var got []string
+		for _, a := range result.Alternatives {
+			got = append(got, a.Command)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %q offers %q, want %q", tt.phrase, tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestStructuredSuggest(t *testing.T) {
+	const rules = `
+- block: ["terraform apply*"]
+  reason: Apply from CI
+  suggest:
+    - command: terraform plan {args}
+      description: Show what would change
+      safety: read-only, but needs state access
+    - make plan
+- block: ["rm -rf /"]
+  reason: No
+  suggest: think again
+`
+	var fromYAML []policy.CommandRule
+	if err := yaml.Unmarshal([]byte(rules), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON []policy.CommandRule
+	if err := json.Unmarshal([]byte(`[{"block":["terraform apply*"],"reason":"Apply from CI","suggest":[{"command":"terraform plan {args}","description":"Show what would change","safety":"read-only, but needs state access"},"make plan"]},{"block":["rm -rf /"],"reason":"No","suggest":"think again"}]`), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	var roundTrip []policy.CommandRule
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []policy.CommandRule{
+		{
+			Block:  []string{"terraform apply*"},
+			Reason: "Apply from CI",
+			Alternatives: []policy.Alternative{
+				{Command: "terraform plan {args}", Description: "Show what would change", Safety: "read-only, but needs state access"},
+				{Command: "make plan"},
+			},
+		},
+		{Block: []string{"rm -rf /"}, Reason: "No", Suggest: "think again"},
+	}
+	for name, got := range map[string][]policy.CommandRule{"yaml": fromYAML, "json": fromJSON, "round trip": roundTrip} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+	if got := want[0].Suggestion(); got != "terraform plan or make plan" {
+		t.Errorf("Suggestion() = %q", got)
+	}
+
+	s, err := NewSet([]*policy.Policy{{Action: policy.ActionExecute, Description: "no applies", CommandRules: fromYAML}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := s.Check(&policy.CheckRequest{Action: "execute", Command: "terraform apply -target=module.db"})
+	if len(result.Alternatives) != 2 || result.Alternatives[0].Command != "terraform plan -target=module.db" {
+		t.Errorf("alternatives = %+v", result.Alternatives)
+	}
+	if result.Suggest != "terraform plan or make plan" {
+		t.Errorf("suggest = %q", result.Suggest)
+	}
+}
@@ -0,0 +1,86 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// scope returns req with its paths relative to dir, for a policy limited
+// to dir (see policy.Policy.Dir), or false when req is outside dir. A
+// request is inside dir when its target is, or, without a target, when
+// its working directory is. Paths outside dir are rewritten with ../ so
+// the policy still sees where they lead.
+func scope(req *policy.CheckRequest, dir string) (*policy.CheckRequest, bool) {
+	if dir == "" {
+		return req, true
+	}
+	at := req.Target
+	if at == "" {
+		at = req.Cwd
+	}
+	if _, ok := InDir(at, dir); !ok {
+		return nil, false
+	}
+	local := *req
+	local.Target = rebase(local.Target, dir)
+	local.Destination = rebase(local.Destination, dir)
+	local.Cwd = rebase(local.Cwd, dir)
+	return &local, true
+}
+
+// InDir returns p, relative to the project root, relative to dir
+// instead, and whether it is inside dir.
+func InDir(p, dir string) (string, bool) {
+	if p == "" || path.IsAbs(NormalizePath(p)) {
+		return "", false
+	}
+	p = NormalizePath(p)
+	if p == dir {
+		return ".", true
+	}
+	if rest, ok := strings.CutPrefix(p, dir+"/"); ok {
+		return rest, true
+	}
+	return "", false
+}
+
+// rebase returns p, relative to the project root, relative to dir. Empty
+// and absolute paths are left alone.
+func rebase(p, dir string) string {
+	if p == "" || path.IsAbs(NormalizePath(p)) {
+		return p
+	}
+	if rest, ok := InDir(p, dir); ok {
+		return rest
+	}
+	from, to := strings.Split(dir, "/"), strings.Split(NormalizePath(p), "/")
+	for len(from) > 0 && len(to) > 0 && from[0] == to[0] {
+		from, to = from[1:], to[1:]
+	}
+	return path.Clean(strings.Repeat("../", len(from)) + strings.Join(to, "/"))
+}
+
+// scope returns req as each of the set's policies sees it, nil for those
+// it is outside of (see scope), and, by policy description, the deepest
+// directory among the policies in scope that a nearer .veto can
+// override: those that aren't locked. A policy in a shallower directory
+// is overridden by one of the same description in a deeper one.
+func (s *Set) scope(req *policy.CheckRequest) (scoped []*policy.CheckRequest, deepest map[string]string) {
+	scoped = make([]*policy.CheckRequest, len(s.matchers))
+	for i, m := range s.matchers {
+		scoped[i], _ = scope(req, m.policy.Dir)
+	}
+	deepest = map[string]string{}
+	for i, m := range s.matchers {
+		p := m.policy
+		if scoped[i] == nil || p.Locked {
+			continue
+		}
+		if d, ok := deepest[p.Description]; !ok || len(p.Dir) > len(d) {
+			deepest[p.Description] = p.Dir
+		}
+	}
+	return scoped, deepest
+}
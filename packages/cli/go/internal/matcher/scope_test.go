@@ -0,0 +1,58 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestScopedPolicies(t *testing.T) {
+	s, err := NewSet([]*policy.Policy{
+		{Action: policy.ActionModify, Include: []string{"*.lock"}, Description: "protect lock files", Locked: true},
+		{Action: policy.ActionModify, Include: []string{"**/secrets/**"}, Description: "protect secrets"},
+		{Action: policy.ActionModify, Include: []string{"secrets/**"}, Description: "protect secrets", Decision: policy.DecisionAllow, Dir: "apps/web"},
+		{Action: policy.ActionModify, Include: []string{"*.lock"}, Description: "protect lock files", Decision: policy.DecisionAllow, Dir: "apps/web"},
+		{Action: policy.ActionModify, Include: []string{"config/*.json"}, Description: "protect config", Dir: "apps/api"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{"secrets/key.pem", false},
+		// The nearer policy overrides the root's in its directory
+		{"apps/web/secrets/key.pem", true},
+		{"apps/api/secrets/key.pem", false},
+		// but never a locked one
+		{"apps/web/yarn.lock", false},
+		// Patterns are relative to the policy's directory
+		{"apps/api/config/db.json", false},
+		{"config/db.json", true},
+		{"apps/api-old/config/db.json", true},
+		{"apps/web/config/db.json", true},
+	}
+	for _, tt := range tests {
+		r := s.Check(&policy.CheckRequest{Action: "modify", Target: tt.target})
+		if r.Allowed != tt.allowed {
+			t.Errorf("%s: allowed = %t, want %t (%s)", tt.target, r.Allowed, tt.allowed, r.Reason)
+		}
+	}
+}
+
+func TestRebase(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"apps/api/src/x.go", "src/x.go"},
+		{"apps/api", "."},
+		{"apps/web/x.go", "../web/x.go"},
+		{"x.go", "../../x.go"},
+		{"/etc/passwd", "/etc/passwd"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := rebase(tt.path, "apps/api"); got != tt.want {
+			t.Errorf("rebase(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
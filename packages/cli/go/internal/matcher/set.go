@@ -0,0 +1,319 @@
+package matcher
+
+import (
+	"hash/fnv"
+	"path"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Set validates actions against a group of policies.
+type Set struct {
+	matchers []*Matcher
+	mode     string
+}
+
+// NewSet compiles a matcher for every policy in the set.
+func NewSet(policies []*policy.Policy) (*Set, error) {
+	s := &Set{mode: policy.ModeInteractive}
+	for _, p := range policies {
+		m, err := New(p)
+		if err != nil {
+			return nil, err
+		}
+		s.matchers = append(s.matchers, m)
+	}
+	return s, nil
+}
+
+// Len returns the number of policies in the set.
+func (s *Set) Len() int {
+	return len(s.matchers)
+}
+
+// ReadsHeaders reports whether any policy has header rules, so callers
+// only read target files from disk when a check needs them.
+func (s *Set) ReadsHeaders() bool {
+	for _, m := range s.matchers {
+		if len(m.policy.HeaderRules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadsSizes reports whether any policy has shrink rules, so callers
+// only look up the size of target files when a check needs it.
+func (s *Set) ReadsSizes() bool {
+	for _, m := range s.matchers {
+		if len(m.policy.ShrinkRules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TracksChanges reports whether any policy has a change budget, so the
+// daemon only counts a session's changed files when a check needs them.
+func (s *Set) TracksChanges() bool {
+	for _, m := range s.matchers {
+		if m.policy.MaxFiles > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMode sets the default mode for requests that don't specify one.
+func (s *Set) SetMode(mode string) {
+	s.mode = mode
+}
+
+// Check validates a request against every policy and resolves conflicts
+// between the policies that match:
+//
+//  1. Policies whose When condition doesn't match the request are
+//     skipped, as are policies limited to a directory (Policy.Dir) the
+//     request is outside of, and policies a nearer .veto overrides: one
+//     in a deeper directory with the same description.
+//  2. Monitor-mode (and out-of-rollout) matches never act; they are
+//     reported in Monitored.
+//  3. An explicit allow overrides other matches at the same or a lower
+//     level: a local allow overrides local policies, a locked allow
+//     overrides everything. Locked policies are never overridden by local
+//     allows.
+//  4. Of the remaining matches the most severe decision wins
+//     (deny > ask > warn), then the higher severity, then set order.
+//
+// Policies are evaluated in the order NewSet was given them, and within
+// a policy its rules in the order they're written, so the same request
+// always gets the same decision, reason and rule. Every match and how it
+// was resolved is recorded in Trace, in set order.
+func (s *Set) Check(req *policy.CheckRequest) *policy.CheckResult {
+	return s.CheckMasked(req, nil)
+}
+
+// CheckMasked is Check with each content rule checking the request's
+// content as mask returns it, so a baseline can accept what a rule finds
+// in the file already.
+func (s *Set) CheckMasked(req *policy.CheckRequest, mask Mask) *policy.CheckResult {
+	unattended := req.Mode == policy.ModeUnattended ||
+		(req.Mode == "" && s.mode == policy.ModeUnattended)
+
+	scoped, deepest := s.scope(req)
+
+	var trace []policy.TraceStep
+	var monitored []policy.MonitorHit
+	var matches []match
+	for i, m := range s.matchers {
+		p := m.policy
+		skip := ""
+		switch {
+		case scoped[i] == nil:
+			skip = "outside " + p.Dir
+		case !p.Locked && deepest[p.Description] != p.Dir:
+			skip = "overridden by " + path.Join(deepest[p.Description], ".veto")
+		}
+		if skip != "" || !p.When.Matches(req) {
+			trace = append(trace, policy.TraceStep{
+				Policy:  p.Description,
+				Reason:  skip,
+				Outcome: policy.OutcomeSkipped,
+			})
+			continue
+		}
+
+		// A "blocked" result means the policy's rules matched the request
+		result := m.check(scoped[i], mask)
+		if result.Allowed {
+			continue
+		}
+
+		decision := decide(p, unattended)
+		// A matcher can soften a denial to a question when it isn't sure
+		// the request breaks the policy
+		if result.Decision == policy.DecisionAsk && decision == policy.DecisionDeny && !unattended {
+			decision = policy.DecisionAsk
+		}
+		if p.Enforce == policy.EnforceMonitor || !inRollout(p, req) {
+			monitored = append(monitored, policy.MonitorHit{
+				Policy:   p.Description,
+				Reason:   result.Reason,
+				Rule:     result.Rule,
+				Decision: decision,
+			})
+			trace = append(trace, policy.TraceStep{
+				Policy:   p.Description,
+				Decision: decision,
+				Severity: p.Severity,
+				Locked:   p.Locked,
+				Reason:   result.Reason,
+				Rule:     result.Rule,
+				Outcome:  policy.OutcomeMonitored,
+			})
+			continue
+		}
+
+		matches = append(matches, match{
+			policy:   p,
+			result:   result,
+			decision: decision,
+			step:     len(trace),
+		})
+		trace = append(trace, policy.TraceStep{
+			Policy:   p.Description,
+			Decision: decision,
+			Severity: p.Severity,
+			Locked:   p.Locked,
+			Reason:   result.Reason,
+			Rule:     result.Rule,
+		})
+	}
+
+	winner := resolve(matches, trace)
+
+	result := &policy.CheckResult{Allowed: true}
+	if winner != nil {
+		result = winner.result
+		result.Policy = winner.policy.Description
+		result.Decision = winner.decision
+		result.Allowed = winner.decision == policy.DecisionWarn ||
+			winner.decision == policy.DecisionAllow
+		if winner.decision == policy.DecisionAllow {
+			result.Reason, result.Suggest, result.RunInstead, result.Rewrite = "", "", "", ""
+			result.Alternatives = nil
+		}
+	}
+	result.Monitored = monitored
+	result.Trace = trace
+	return result
+}
+
+// match is a policy that matched a request and will take part in
+// conflict resolution.
+type match struct {
+	policy   *policy.Policy
+	result   *policy.CheckResult
+	decision policy.Decision
+	step     int // index in the trace
+}
+
+// resolve picks the match that determines the outcome, recording every
+// match's outcome in trace. It returns nil if nothing matched.
+func resolve(matches []match, trace []policy.TraceStep) *match {
+	var localAllow, lockedAllow *match
+	for i := range matches {
+		m := &matches[i]
+		if m.decision != policy.DecisionAllow {
+			continue
+		}
+		if m.policy.Locked && lockedAllow == nil {
+			lockedAllow = m
+		} else if !m.policy.Locked && localAllow == nil {
+			localAllow = m
+		}
+	}
+
+	var winner *match
+	for i := range matches {
+		m := &matches[i]
+		if m.decision == policy.DecisionAllow {
+			continue
+		}
+		if lockedAllow != nil || (localAllow != nil && !m.policy.Locked) {
+			trace[m.step].Outcome = policy.OutcomeOverridden
+			continue
+		}
+		if winner == nil || outranks(m, winner) {
+			if winner != nil {
+				trace[winner.step].Outcome = policy.OutcomeOutranked
+			}
+			winner = m
+		} else {
+			trace[m.step].Outcome = policy.OutcomeOutranked
+		}
+	}
+
+	if winner == nil {
+		winner = lockedAllow
+		if winner == nil {
+			winner = localAllow
+		}
+	}
+	for i := range matches {
+		m := &matches[i]
+		if m.decision == policy.DecisionAllow && m != winner {
+			trace[m.step].Outcome = policy.OutcomeOutranked
+		}
+	}
+	if winner != nil {
+		trace[winner.step].Outcome = policy.OutcomeApplied
+	}
+	return winner
+}
+
+// outranks reports whether a should win over b. Earlier matches win ties.
+func outranks(a, b *match) bool {
+	if ra, rb := decisionRank(a.decision), decisionRank(b.decision); ra != rb {
+		return ra > rb
+	}
+	return a.policy.Severity.Rank() > b.policy.Severity.Rank()
+}
+
+// decisionRank orders decisions from least to most severe.
+func decisionRank(d policy.Decision) int {
+	switch d {
+	case policy.DecisionWarn:
+		return 1
+	case policy.DecisionAsk:
+		return 2
+	case policy.DecisionDeny:
+		return 3
+	}
+	return 0
+}
+
+// decide returns the decision a matching policy produces. With no human
+// to approve, ask becomes deny and high-severity warnings become blocks.
+func decide(p *policy.Policy, unattended bool) policy.Decision {
+	d := p.Decision
+	if d == "" {
+		d = policy.DecisionDeny
+	}
+	if !unattended || d == policy.DecisionAllow {
+		return d
+	}
+	switch d {
+	case policy.DecisionAsk:
+		return policy.DecisionDeny
+	case policy.DecisionWarn:
+		if p.Severity.Rank() >= policy.SeverityHigh.Rank() {
+			return policy.DecisionDeny
+		}
+	}
+	return d
+}
+
+// inRollout reports whether a request falls in the enforced fraction of a
+// policy's rollout. The bucket is derived from the session ID (falling back
+// to the request ID, then the request itself) so a session sees consistent
+// behavior across checks.
+func inRollout(p *policy.Policy, req *policy.CheckRequest) bool {
+	if p.Rollout <= 0 || p.Rollout >= 100 {
+		return true
+	}
+
+	key := req.SessionID
+	if key == "" {
+		key = req.RequestID
+	}
+	if key == "" {
+		key = req.Action + "\x00" + req.Target + "\x00" + req.Command
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(p.Description))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32()%100) < p.Rollout
+}
@@ -0,0 +1,292 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func commandPolicy(desc, pattern string, decision policy.Decision) *policy.Policy {
+	return &policy.Policy{
+		Description: desc,
+		Decision:    decision,
+		CommandRules: []policy.CommandRule{
+			{Block: []string{pattern}, Reason: desc},
+		},
+	}
+}
+
+func TestSetConflictResolution(t *testing.T) {
+	locked := func(p *policy.Policy) *policy.Policy {
+		p.Locked = true
+		return p
+	}
+	severity := func(p *policy.Policy, s policy.Severity) *policy.Policy {
+		p.Severity = s
+		return p
+	}
+
+	tests := []struct {
+		name        string
+		policies    []*policy.Policy
+		wantAllowed bool
+		wantPolicy  string
+		wantOutcome []policy.Outcome
+	}{
+		{
+			name: "deny beats earlier warn",
+			policies: []*policy.Policy{
+				commandPolicy("warn", "npm *", policy.DecisionWarn),
+				commandPolicy("deny", "npm *", policy.DecisionDeny),
+			},
+			wantAllowed: false,
+			wantPolicy:  "deny",
+			wantOutcome: []policy.Outcome{policy.OutcomeOutranked, policy.OutcomeApplied},
+		},
+		{
+			name: "deny beats ask",
+			policies: []*policy.Policy{
+				commandPolicy("ask", "npm *", policy.DecisionAsk),
+				commandPolicy("deny", "npm *", ""),
+			},
+			wantAllowed: false,
+			wantPolicy:  "deny",
+			wantOutcome: []policy.Outcome{policy.OutcomeOutranked, policy.OutcomeApplied},
+		},
+		{
+			name: "higher severity breaks decision ties",
+			policies: []*policy.Policy{
+				severity(commandPolicy("low", "npm *", policy.DecisionDeny), policy.SeverityLow),
+				severity(commandPolicy("high", "npm *", policy.DecisionDeny), policy.SeverityHigh),
+			},
+			wantAllowed: false,
+			wantPolicy:  "high",
+			wantOutcome: []policy.Outcome{policy.OutcomeOutranked, policy.OutcomeApplied},
+		},
+		{
+			name: "config order breaks full ties",
+			policies: []*policy.Policy{
+				commandPolicy("first", "npm *", policy.DecisionDeny),
+				commandPolicy("second", "npm *", policy.DecisionDeny),
+			},
+			wantAllowed: false,
+			wantPolicy:  "first",
+			wantOutcome: []policy.Outcome{policy.OutcomeApplied, policy.OutcomeOutranked},
+		},
+		{
+			name: "local allow overrides local deny",
+			policies: []*policy.Policy{
+				commandPolicy("deny", "npm *", policy.DecisionDeny),
+				commandPolicy("allow", "npm install", policy.DecisionAllow),
+			},
+			wantAllowed: true,
+			wantPolicy:  "allow",
+			wantOutcome: []policy.Outcome{policy.OutcomeOverridden, policy.OutcomeApplied},
+		},
+		{
+			name: "local allow does not override locked deny",
+			policies: []*policy.Policy{
+				locked(commandPolicy("locked deny", "npm *", policy.DecisionDeny)),
+				commandPolicy("allow", "npm install", policy.DecisionAllow),
+			},
+			wantAllowed: false,
+			wantPolicy:  "locked deny",
+			wantOutcome: []policy.Outcome{policy.OutcomeApplied, policy.OutcomeOutranked},
+		},
+		{
+			name: "locked allow overrides everything",
+			policies: []*policy.Policy{
+				locked(commandPolicy("locked deny", "npm *", policy.DecisionDeny)),
+				commandPolicy("deny", "npm *", policy.DecisionDeny),
+				locked(commandPolicy("locked allow", "npm install", policy.DecisionAllow)),
+			},
+			wantAllowed: true,
+			wantPolicy:  "locked allow",
+			wantOutcome: []policy.Outcome{policy.OutcomeOverridden, policy.OutcomeOverridden, policy.OutcomeApplied},
+		},
+		{
+			name: "allow that doesn't match has no effect",
+			policies: []*policy.Policy{
+				commandPolicy("deny", "npm *", policy.DecisionDeny),
+				commandPolicy("allow", "npm ci", policy.DecisionAllow),
+			},
+			wantAllowed: false,
+			wantPolicy:  "deny",
+			wantOutcome: []policy.Outcome{policy.OutcomeApplied},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSet(tt.policies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Check(&policy.CheckRequest{Action: "execute", Command: "npm install"})
+
+			if got.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", got.Allowed, tt.wantAllowed)
+			}
+			if got.Policy != tt.wantPolicy {
+				t.Errorf("Policy = %q, want %q", got.Policy, tt.wantPolicy)
+			}
+			if len(got.Trace) != len(tt.wantOutcome) {
+				t.Fatalf("Trace = %+v, want %d steps", got.Trace, len(tt.wantOutcome))
+			}
+			for i, want := range tt.wantOutcome {
+				if got.Trace[i].Outcome != want {
+					t.Errorf("Trace[%d].Outcome = %q, want %q", i, got.Trace[i].Outcome, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetMonitorDoesNotBlock(t *testing.T) {
+	p := commandPolicy("monitored", "npm *", policy.DecisionDeny)
+	p.Enforce = policy.EnforceMonitor
+
+	s, err := NewSet([]*policy.Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Check(&policy.CheckRequest{Action: "execute", Command: "npm install"})
+
+	if !got.Allowed {
+		t.Errorf("monitor-mode policy blocked the request")
+	}
+	if len(got.Monitored) != 1 || got.Monitored[0].Policy != "monitored" {
+		t.Errorf("Monitored = %+v, want one hit", got.Monitored)
+	}
+}
+
+func TestSetUnattendedEscalation(t *testing.T) {
+	ask := commandPolicy("ask", "sudo *", policy.DecisionAsk)
+	warn := commandPolicy("warn", "git push -f*", policy.DecisionWarn)
+	warn.Severity = policy.SeverityHigh
+
+	s, err := NewSet([]*policy.Policy{ask, warn})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range []string{"sudo ls", "git push -f"} {
+		interactive := s.Check(&policy.CheckRequest{Command: cmd})
+		unattended := s.Check(&policy.CheckRequest{Command: cmd, Mode: policy.ModeUnattended})
+
+		if interactive.Decision == policy.DecisionDeny {
+			t.Errorf("%q: interactive decision = deny, want ask or warn", cmd)
+		}
+		if unattended.Decision != policy.DecisionDeny || unattended.Allowed {
+			t.Errorf("%q: unattended decision = %q (allowed %v), want deny", cmd, unattended.Decision, unattended.Allowed)
+		}
+	}
+}
+
+func TestAllowRuleCarveOut(t *testing.T) {
+	p := commandPolicy("prefer pnpm", "npm install*", policy.DecisionDeny)
+	p.Include = []string{"**/.env"}
+	p.Allow = []policy.AllowRule{
+		{Commands: []string{"npm install*"}, Paths: []string{"tools/legacy/**"}},
+		{Paths: []string{"fixtures/**"}},
+	}
+
+	m, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		req     policy.CheckRequest
+		allowed bool
+	}{
+		{"command outside carve-out", policy.CheckRequest{Command: "npm install", Cwd: "."}, false},
+		{"command in carve-out dir", policy.CheckRequest{Command: "npm install", Cwd: "tools/legacy"}, true},
+		{"command in carve-out subdir", policy.CheckRequest{Command: "npm install", Cwd: "tools/legacy/pkg"}, true},
+		{"file outside carve-out", policy.CheckRequest{Target: "app/.env"}, false},
+		{"file in carve-out", policy.CheckRequest{Target: "fixtures/.env"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Check(&tt.req).Allowed; got != tt.allowed {
+				t.Errorf("Allowed = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestSetDeterministic(t *testing.T) {
+	newSet := func() *Set {
+		policies := []*policy.Policy{
+			commandPolicy("first", "npm *", policy.DecisionDeny),
+			commandPolicy("second", "npm *", policy.DecisionDeny),
+		}
+		for _, name := range builtin.Names() {
+			b := builtin.Find(name)
+			policies = append(policies, b.ToPolicy(policy.ActionExecute), b.ToPolicy(policy.ActionModify))
+		}
+		set, err := NewSet(policies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return set
+	}
+
+	requests := []policy.CheckRequest{
+		{Action: "execute", Command: "npm install lodash"},
+		{Action: "execute", Command: "git push --force origin main && rm -rf /"},
+		{Action: "modify", Target: "app/.env"},
+		{Action: "modify", Target: "src/a.ts", Content: "console.log(1)"},
+		{Action: "read", Target: "README.md"},
+	}
+	set := newSet()
+	for _, req := range requests {
+		want := set.Check(&req)
+		for i := 0; i < 20; i++ {
+			s := set
+			if i%2 == 1 {
+				s = newSet()
+			}
+			if got := s.Check(&req); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%+v: check %d = %+v, want %+v", req, i, got, want)
+			}
+		}
+	}
+
+	got := set.Check(&policy.CheckRequest{Action: "execute", Command: "npm install lodash"})
+	if got.Policy != "first" || got.Rule != "commandRules[0]" {
+		t.Errorf("tie went to %q (%s), want the earlier policy's commandRules[0]", got.Policy, got.Rule)
+	}
+}
+
+func TestRuleIDs(t *testing.T) {
+	p := &policy.Policy{
+		Action:      policy.ActionModify,
+		Description: "rules",
+		Include:     []string{"**/*.lock", "**/.env"},
+		ContentRules: []policy.ContentRule{
+			{Pattern: `eval\(`, Reason: "no eval"},
+			{Pattern: `console\.log`, Reason: "no console.log"},
+		},
+	}
+	m, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req  policy.CheckRequest
+		rule string
+	}{
+		{policy.CheckRequest{Action: "modify", Target: "app/.env"}, "include[1]"},
+		{policy.CheckRequest{Action: "modify", Target: "src/a.ts", Content: "console.log(1)"}, "contentRules[1]"},
+		{policy.CheckRequest{Action: "modify", Target: "src/a.ts", Content: "eval(x); console.log(1)"}, "contentRules[0]"},
+	}
+	for _, tt := range tests {
+		if got := m.Check(&tt.req); got.Allowed || got.Rule != tt.rule {
+			t.Errorf("%s: allowed %v, rule %q, want blocked by %s", tt.req.Target, got.Allowed, got.Rule, tt.rule)
+		}
+	}
+}
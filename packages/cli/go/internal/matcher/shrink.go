@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"fmt"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// checkShrink checks a write resizing path, a file of size bytes on
+// disk, against the policy's shrink rules. Files that are empty, or
+// that the write doesn't shrink, pass.
+func (m *Matcher) checkShrink(path string, resize *policy.Resize, size int64) *policy.CheckResult {
+	if len(m.policy.ShrinkRules) == 0 {
+		return &policy.CheckResult{Allowed: true}
+	}
+	before, after, ok := resize.Sizes(size)
+	if !ok || before <= 0 || after >= before {
+		return &policy.CheckResult{Allowed: true}
+	}
+	removed := (before - after) * 100 / before
+	normalized := NormalizePath(path)
+	for i, rule := range m.policy.ShrinkRules {
+		if !matchFileTypes(path, rule.FileTypes) {
+			continue
+		}
+		if len(m.shrinkPaths[i]) > 0 && !matchAnyGlob(m.shrinkPaths[i], normalized) {
+			continue
+		}
+		if removed <= int64(rule.MaxPercent) {
+			continue
+		}
+		why := fmt.Sprintf("Write removes %d%% of the file (%d of %d bytes), over the limit of %d%%", removed, before-after, before, rule.MaxPercent)
+		if rule.Reason != "" {
+			why += ": " + rule.Reason
+		}
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  why,
+			Suggest: rule.Suggest,
+			Rule:    policy.RuleID(policy.RuleShrink, i),
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
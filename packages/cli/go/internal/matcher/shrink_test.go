@@ -0,0 +1,63 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestShrinkLimitPhrase(t *testing.T) {
+	tests := map[string]int{
+		"don't shrink data files by more than 20%":           20,
+		"Never truncate data files over 10 %":                10,
+		"protect data files from shrinking over 30%":         30,
+		"protect data files from truncation by more than 5%": 5,
+		"protect data files":                                 0,
+		"don't shrink data files":                            0,
+	}
+	for phrase, want := range tests {
+		if got := builtin.ShrinkLimit(phrase); got != want {
+			t.Errorf("ShrinkLimit(%q) = %d, want %d", phrase, got, want)
+		}
+	}
+}
+
+func TestProtectDataFiles(t *testing.T) {
+	m, err := New(builtin.Find("protect data files").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int64
+		resize  *policy.Resize
+		blocked bool
+	}{
+		{"rewritten from a sample", "data/users.csv", 10000, &policy.Resize{Whole: true, Added: 900}, true},
+		{"rewritten whole", "data/users.csv", 10000, &policy.Resize{Whole: true, Added: 9800}, false},
+		{"grown", "data/users.parquet", 100, &policy.Resize{Whole: true, Added: 5000}, false},
+		{"emptied", "data/events.jsonl", 100, &policy.Resize{Whole: true}, true},
+		{"edit removing rows", "data/users.csv", 1000, &policy.Resize{Removed: 800, Added: 10}, true},
+		{"edit fixing a row", "data/users.csv", 1000, &policy.Resize{Removed: 40, Added: 42}, false},
+		{"edit done", "data/users.csv", 200, &policy.Resize{Removed: 800, Added: 0, Done: true}, true},
+		{"whole write done", "data/users.csv", 10, &policy.Resize{Whole: true, Added: 10, Done: true}, false},
+		{"fixture of any type", "tests/fixtures/order.json", 4000, &policy.Resize{Whole: true, Added: 100}, true},
+		{"new file", "data/new.csv", 0, &policy.Resize{Whole: true, Added: 10}, false},
+		{"not data", "src/app.ts", 10000, &policy.Resize{Whole: true, Added: 10}, false},
+		{"size unknown", "data/users.csv", 10000, nil, false},
+	}
+	for _, tt := range tests {
+		result := m.Check(&policy.CheckRequest{Action: "modify", Target: tt.path, Resize: tt.resize, Size: tt.size})
+		if result.Allowed == tt.blocked {
+			t.Errorf("%s: allowed = %v (%s), want %v", tt.name, result.Allowed, result.Reason, !tt.blocked)
+		}
+	}
+
+	strict := builtin.Find("don't shrink data files by more than 5%")
+	if strict == nil || strict.ShrinkRules[0].MaxPercent != 5 {
+		t.Fatalf("shrink limit phrase compiled to %+v", strict)
+	}
+}
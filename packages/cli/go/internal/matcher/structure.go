@@ -0,0 +1,204 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// defaultHeaderLines is how far from the top a required header may match
+// when a rule doesn't say.
+const defaultHeaderLines = 10
+
+// structureRule is a compiled policy.StructureRule.
+type structureRule struct {
+	header *regexp.Regexp
+}
+
+// functionStarts match the line a function starts on, by language. The
+// Go engine doesn't parse code, so these cover the common declaration
+// forms rather than every one.
+var functionStarts = map[string]*regexp.Regexp{
+	"go":         regexp.MustCompile(`^\s*func\b`),
+	"javascript": jsFunction,
+	"typescript": jsFunction,
+	"rust":       regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe)\s+)*fn\s+\w+`),
+	"c":          cFunction,
+	"cpp":        cFunction,
+	"java":       cFunction,
+	"kotlin":     regexp.MustCompile(`^\s*(?:\w+\s+)*fun\b`),
+	"php":        regexp.MustCompile(`^\s*(?:\w+\s+)*function\b`),
+	"python":     regexp.MustCompile(`^\s*(?:async\s+)?def\s+\w+`),
+	"ruby":       regexp.MustCompile(`^\s*def\s+`),
+	"bash":       regexp.MustCompile(`^\s*(?:function\s+[\w-]+|[\w-]+\s*\(\s*\))`),
+}
+
+var (
+	jsFunction = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\b|=\s*(?:async\s+)?(?:\([^)]*\)|[\w$]+)\s*=>\s*\{\s*$|^\s*(?:(?:public|private|protected|static|async|get|set|override)\s+)*[A-Za-z_$][\w$]*\s*\([^)]*\)\s*(?::\s*[^={;]+)?\{\s*$`)
+	cFunction  = regexp.MustCompile(`^\s*(?:[\w<>\[\],*&:~]+\s+)+[*&]*[\w:~]+\s*\([^;]*\)\s*(?:const\s*)?(?:throws\s+[\w., ]+)?\{?\s*$`)
+	// keywords open blocks that look like calls or declarations
+	keywords = regexp.MustCompile(`^\s*(?:if|for|while|switch|catch|return|else|do|try|with|elif)\b|^\s*\}?\s*else\b`)
+)
+
+// indented languages end a function where the indentation returns.
+var indented = map[string]bool{"python": true, "ruby": true}
+
+// function is a function's span in content, by 1-based line number.
+type function struct{ start, end int }
+
+func (f function) lines() int { return f.end - f.start + 1 }
+
+// functions finds the functions in content written in lang. Nested
+// functions are found too, each measured on its own.
+func functions(lines []string, lang string) []function {
+	start := functionStarts[dialects[lang]]
+	if start == nil {
+		start = functionStarts[lang]
+	}
+	if start == nil {
+		return nil
+	}
+	var found []function
+	for i, line := range lines {
+		if !start.MatchString(line) || keywords.MatchString(line) {
+			continue
+		}
+		var end int
+		if indented[lang] {
+			end = indentEnd(lines, i, lang == "ruby")
+		} else {
+			end = braceEnd(lines, i)
+		}
+		if end >= 0 {
+			found = append(found, function{i + 1, end + 1})
+		}
+	}
+	return found
+}
+
+// braceEnd returns the line the braces opened by the declaration on line
+// from close on, or -1 when it has no body (a prototype) or it doesn't
+// close. Braces in strings and // comments don't count.
+func braceEnd(lines []string, from int) int {
+	depth, opened := 0, false
+	for i := from; i < len(lines); i++ {
+		var quote rune
+		prev := rune(0)
+		for _, r := range lines[i] {
+			switch {
+			case quote != 0:
+				if r == quote && prev != '\\' {
+					quote = 0
+				}
+			case r == '"' || r == '\'' || r == '`':
+				quote = r
+			case r == '/' && prev == '/':
+				goto next
+			case r == ';' && !opened:
+				return -1
+			case r == '{':
+				depth++
+				opened = true
+			case r == '}':
+				depth--
+				if opened && depth == 0 {
+					return i
+				}
+			}
+			prev = r
+		}
+	next:
+		// Template strings span lines; other quotes don't
+		if quote != '`' {
+			quote = 0
+		}
+	}
+	return -1
+}
+
+// indentEnd returns the last line of the indented body below line from.
+// With closer, the line ending the body at the declaration's own
+// indentation (Ruby's "end") belongs to it.
+func indentEnd(lines []string, from int, closer bool) int {
+	indent := indentation(lines[from])
+	end := from
+	for i := from + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentation(lines[i]) <= indent {
+			if closer && indentation(lines[i]) == indent && strings.TrimSpace(lines[i]) == "end" {
+				end = i
+			}
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// checkStructure checks content written to path against the policy's
+// structure rules. Required headers only apply to whole files (see
+// CheckContent).
+func (m *Matcher) checkStructure(path, content string, whole bool) *policy.CheckResult {
+	if len(m.policy.StructureRules) == 0 {
+		return &policy.CheckResult{Allowed: true}
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, rule := range m.policy.StructureRules {
+		if !matchFileTypes(path, rule.FileTypes) {
+			continue
+		}
+		if result := m.checkShape(i, path, lines, whole); !result.Allowed {
+			return result
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
+
+// checkShape checks the lines of content written to path against the
+// policy's i'th structure rule.
+func (m *Matcher) checkShape(i int, path string, lines []string, whole bool) *policy.CheckResult {
+	rule := m.policy.StructureRules[i]
+	block := func(why string) *policy.CheckResult {
+		if rule.Reason != "" {
+			why += ": " + rule.Reason
+		}
+		return &policy.CheckResult{
+			Allowed: false,
+			Reason:  why,
+			Suggest: rule.Suggest,
+			Rule:    policy.RuleID(policy.RuleStructure, i),
+		}
+	}
+	if rule.MaxLines > 0 && len(lines) > rule.MaxLines {
+		return block(fmt.Sprintf("File is %d lines, over the limit of %d", len(lines), rule.MaxLines))
+	}
+	if rule.MaxFunctionLines > 0 {
+		for _, f := range functions(lines, Language(path)) {
+			if f.lines() > rule.MaxFunctionLines {
+				return block(fmt.Sprintf("Function at line %d is %d lines, over the limit of %d", f.start, f.lines(), rule.MaxFunctionLines))
+			}
+		}
+	}
+	if header := m.structure[i].header; header != nil && whole {
+		n := rule.HeaderLines
+		if n <= 0 {
+			n = defaultHeaderLines
+		}
+		top := lines
+		if len(top) > n {
+			top = top[:n]
+		}
+		if !header.MatchString(strings.Join(top, "\n")) {
+			return block(fmt.Sprintf("Missing the required header (/%s/ in the first %d lines)", rule.RequireHeader, n))
+		}
+	}
+	return &policy.CheckResult{Allowed: true}
+}
@@ -0,0 +1,133 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestStructurePhrase(t *testing.T) {
+	tests := map[string]struct {
+		lines    int
+		function bool
+	}{
+		"max 500 lines per file":         {500, false},
+		"Max 80 lines per function":      {80, true},
+		"limit functions to 60 lines":    {60, true},
+		"keep files under 400 lines":     {400, false},
+		"at most 50 lines in a function": {50, true},
+		"max 20 files per session":       {0, false},
+		"max lines":                      {0, false},
+	}
+	for phrase, want := range tests {
+		lines, function := builtin.StructureLimit(phrase)
+		if lines != want.lines || function != want.function {
+			t.Errorf("StructureLimit(%q) = %d, %v, want %d, %v", phrase, lines, function, want.lines, want.function)
+		}
+	}
+}
+
+func TestMaxLines(t *testing.T) {
+	m, err := New(builtin.Find("max 5 lines per file").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := m.CheckContent("a.go", "package a\n\nfunc A() {}\n"); !result.Allowed {
+		t.Errorf("3 lines blocked: %s", result.Reason)
+	}
+	result := m.CheckContent("a.go", strings.Repeat("x\n", 6))
+	if result.Allowed || result.Rule != "structureRules[0]" || !strings.HasPrefix(result.Reason, "File is 6 lines") {
+		t.Errorf("6 lines = %+v, want blocked by structureRules[0]", result)
+	}
+	// Edits count the lines they add
+	req := &policy.CheckRequest{Action: "modify", Target: "a.go", Content: strings.Repeat("x\n", 6)}
+	if m.Check(req).Allowed {
+		t.Error("6 line edit allowed, want blocked")
+	}
+}
+
+func TestMaxFunctionLines(t *testing.T) {
+	p := &policy.Policy{
+		Action:      policy.ActionModify,
+		Description: "Short functions",
+		StructureRules: []policy.StructureRule{{
+			MaxFunctionLines: 4,
+			Reason:           "split it up",
+		}},
+	}
+	m, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := func(n int) string { return strings.Repeat("\tx++\n", n) }
+
+	tests := []struct {
+		name, path, content string
+		line                int // the long function's line, 0 when allowed
+	}{
+		{"go short", "a.go", "package a\n\nfunc A() {\n" + body(2) + "}\n", 0},
+		{"go long", "a.go", "package a\n\nfunc A() {\n\tif x {\n" + body(3) + "\t}\n}\n", 3},
+		{"go braces in strings", "a.go", "func A() {\n\ts := \"}}}\"\n" + body(4) + "}\n", 1},
+		{"go prototype", "a.go", "func A()\n" + body(10), 0},
+		{"ts method", "a.ts", "class C {\n  run(x: number): void {\n" + body(4) + "  }\n}\n", 2},
+		{"ts arrow", "a.ts", "export const f = async (a) => {\n" + body(4) + "};\n", 1},
+		{"ts control flow", "a.ts", "if (x) {\n" + body(10) + "}\n", 0},
+		{"python", "a.py", "def f():\n    a = 1\n    b = 2\n\n    c = 3\n    return a\n\nx = 1\n", 1},
+		{"python short", "a.py", "def f():\n    return 1\n\ndef g():\n    return 2\n", 0},
+		{"ruby", "a.rb", "def f\n  a\n  b\n  c\nend\n", 1},
+		{"unknown language", "a.txt", "func A() {\n" + body(10) + "}\n", 0},
+	}
+	for _, tt := range tests {
+		result := m.CheckContent(tt.path, tt.content)
+		if tt.line == 0 {
+			if !result.Allowed {
+				t.Errorf("%s: blocked: %s", tt.name, result.Reason)
+			}
+			continue
+		}
+		want := fmt.Sprintf("Function at line %d ", tt.line)
+		if result.Allowed || !strings.HasPrefix(result.Reason, want) || !strings.HasSuffix(result.Reason, ": split it up") {
+			t.Errorf("%s: %+v, want blocked with %q", tt.name, result, want)
+		}
+	}
+}
+
+func TestRequireHeader(t *testing.T) {
+	p := &policy.Policy{
+		Action:      policy.ActionModify,
+		Description: "License headers",
+		StructureRules: []policy.StructureRule{{
+			FileTypes:     []string{"*.go"},
+			RequireHeader: `^// Copyright \d{4} Acme`,
+			HeaderLines:   3,
+		}},
+	}
+	m, err := New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req  policy.CheckRequest
+		want bool
+	}{
+		{policy.CheckRequest{Action: "create", Target: "a.go", Content: "// Copyright 2026 Acme\n\npackage a\n"}, true},
+		{policy.CheckRequest{Action: "create", Target: "a.go", Content: "//go:build linux\n\n// Copyright 2026 Acme\npackage a\n"}, true},
+		{policy.CheckRequest{Action: "create", Target: "a.go", Content: "package a\n"}, false},
+		{policy.CheckRequest{Action: "create", Target: "a.go", Content: "package a\n\n\n// Copyright 2026 Acme\n"}, false},
+		// Edits don't include the top of the file
+		{policy.CheckRequest{Action: "modify", Target: "a.go", Content: "func A() {}\n"}, true},
+		{policy.CheckRequest{Action: "create", Target: "a.ts", Content: "export {}\n"}, true},
+	}
+	for _, tt := range tests {
+		if got := m.Check(&tt.req).Allowed; got != tt.want {
+			t.Errorf("%s %s %q: allowed = %v, want %v", tt.req.Action, tt.req.Target, tt.req.Content, got, tt.want)
+		}
+	}
+	if !m.Concerns("a.go") || m.Concerns("a.ts") {
+		t.Error("Concerns should follow the rule's file types")
+	}
+}
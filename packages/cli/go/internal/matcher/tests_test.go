@@ -0,0 +1,97 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestPreserveTests(t *testing.T) {
+	m, err := New(builtin.Find("don't skip tests").ToPolicy(policy.ActionDelete))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range []string{
+		"rm src/sum.test.ts",
+		"rm -f src/a.spec.js src/b.ts",
+		"git rm pkg/server/handler_test.go",
+		"rm tests/test_api.py",
+		"rm -rf src/__tests__",
+	} {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+	for _, cmd := range []string{"rm src/sum.ts", "rm -rf dist", "go test ./...", "git rm docs/testing.md"} {
+		if !m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: blocked, want allowed", cmd)
+		}
+	}
+
+	skips := map[string]string{
+		"src/sum.test.ts":           "it.skip('adds', () => {})",
+		"src/sum.spec.js":           "describe.skip('sum', () => {})",
+		"src/__tests__/sum.js":      "xit('adds', () => {})",
+		"tests/test_api.py":         "@pytest.mark.skip(reason='flaky')\ndef test_get(): pass",
+		"tests/api_test.py":         "@unittest.skip('later')",
+		"pkg/server/server_test.go": "func TestServe(t *testing.T) {\n\tt.Skip(\"flaky\")\n}",
+	}
+	for path, content := range skips {
+		if m.CheckContent(path, content).Allowed {
+			t.Errorf("%s: skip allowed, want blocked", path)
+		}
+	}
+
+	fine := map[string]string{
+		"src/sum.test.ts":   "it('adds', () => { expect(sum(1, 2)).toBe(3) })",
+		"src/skip.ts":       "export const skip = list.skip(2)",
+		"pkg/server/x.go":   "t.Skip()",
+		"tests/test_api.py": "def test_skip_header(): assert skip(1) == 2",
+	}
+	for path, content := range fine {
+		if !m.CheckContent(path, content).Allowed {
+			t.Errorf("%s: blocked, want allowed", path)
+		}
+	}
+}
+
+func TestPreserveTestsFiles(t *testing.T) {
+	// The phrase's verb says modify; the builtin keeps tests from
+	// deletion only, so they can still be edited
+	m, err := New(builtin.Find("preserve tests").ToPolicy(policy.ActionModify))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.policy.Action != policy.ActionDelete {
+		t.Fatalf("action = %q, want delete", m.policy.Action)
+	}
+
+	tests := []struct {
+		target  string
+		action  policy.Action
+		allowed bool
+	}{
+		{"src/a.test.ts", policy.ActionDelete, false},
+		{"a.spec.js", policy.ActionDelete, false},
+		{"pkg/server/handler_test.go", policy.ActionDelete, false},
+		{"tests/test_api.py", policy.ActionDelete, false},
+		{"tests/fixtures/data.json", policy.ActionDelete, false},
+		{"src/__tests__/sum.js", policy.ActionDelete, false},
+		{"src/a.test.ts", policy.ActionRename, false},
+		{"src/a.test.ts", policy.ActionModify, true},
+		{"src/a.test.ts", policy.ActionCreate, true},
+		{"src/a.ts", policy.ActionDelete, true},
+		{"docs/testing.md", policy.ActionDelete, true},
+	}
+	for _, tt := range tests {
+		req := &policy.CheckRequest{Action: string(tt.action), Target: tt.target}
+		if got := m.Check(req); got.Allowed != tt.allowed {
+			t.Errorf("%s %s: allowed = %v, want %v", tt.action, tt.target, got.Allowed, tt.allowed)
+		}
+	}
+	if m.CheckCommand("unlink src/a.test.ts").Allowed {
+		t.Error("unlink src/a.test.ts: allowed, want blocked")
+	}
+}
@@ -0,0 +1,263 @@
+package matcher
+
+import (
+	"encoding/base64"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// windowsCommands maps POSIX commands to the PowerShell cmdlets (with
+// their built-in aliases) and cmd.exe builtins that do the same, so rules
+// written against the POSIX command constrain agents on Windows too.
+var windowsCommands = []struct {
+	posix    string
+	cmdlet   string
+	aliases  []string
+	builtins []string // cmd.exe, taking /s (recurse) and /q (quiet)
+	dirs     []string // cmd.exe builtins for whole directories, only with /s
+}{
+	{"rm", "Remove-Item", []string{"ri", "rm", "del", "erase", "rd", "rmdir"}, []string{"del"}, []string{"rd", "rmdir"}},
+	{"cp", "Copy-Item", []string{"cpi", "cp", "copy"}, []string{"copy"}, nil},
+	{"mv", "Move-Item", []string{"mi", "mv", "move"}, []string{"move"}, nil},
+	{"cat", "Get-Content", []string{"gc", "cat", "type"}, []string{"type"}, nil},
+	{"curl", "Invoke-WebRequest", []string{"iwr"}, nil, nil},
+	{"curl", "Invoke-RestMethod", []string{"irm"}, nil, nil},
+}
+
+// shims are node tools installed on Windows as .cmd wrappers, which
+// agents often call by their full name (npm.cmd install).
+var shims = map[string]bool{
+	"npm": true, "npx": true, "pnpm": true, "pnpx": true, "yarn": true,
+	"jest": true, "vitest": true, "tsc": true, "eslint": true, "prettier": true,
+}
+
+// windowsExts are stripped from program names: git.exe runs git.
+var windowsExts = []string{".exe", ".cmd", ".bat", ".ps1"}
+
+// pathParams name a PowerShell parameter whose value is a positional
+// argument in the POSIX form.
+var pathParams = map[string]bool{
+	"-path": true, "-literalpath": true, "-lp": true, "-destination": true, "-uri": true,
+}
+
+var posixFlagsRe = regexp.MustCompile(`^-[A-Za-z]+$`)
+
+// WindowsPatterns returns the PowerShell and cmd.exe forms of a POSIX
+// command pattern, for agent configs that match commands themselves:
+// "rm -rf *" gives "Remove-Item -Recurse -Force *", "del /s /q *" and so
+// on, "npm install*" gives "npm.cmd install*". Patterns with no Windows
+// equivalent give nil.
+func WindowsPatterns(pattern string) []string {
+	words := strings.Fields(pattern)
+	if len(words) == 0 {
+		return nil
+	}
+	name := strings.ToLower(words[0])
+	if shims[name] {
+		return []string{words[0] + ".cmd" + pattern[strings.Index(pattern, words[0])+len(words[0]):]}
+	}
+
+	var patterns []string
+	for _, wc := range windowsCommands {
+		if wc.posix != name {
+			continue
+		}
+		rest := words[1:]
+		var recurse, force bool
+		for len(rest) > 0 && posixFlagsRe.MatchString(rest[0]) {
+			recurse = recurse || strings.ContainsAny(rest[0], "rR")
+			force = force || strings.Contains(rest[0], "f")
+			rest = rest[1:]
+		}
+		args := strings.Join(rest, " ")
+
+		var psFlags, cmdFlags string
+		if recurse {
+			psFlags, cmdFlags = psFlags+" -Recurse", cmdFlags+" /s"
+		}
+		if force {
+			psFlags, cmdFlags = psFlags+" -Force", cmdFlags+" /q"
+		}
+		patterns = append(patterns, joinNonEmpty(wc.cmdlet+psFlags, args))
+		if args != "" && psFlags != "" {
+			// PowerShell takes switches after the path as well
+			patterns = append(patterns, wc.cmdlet+" "+args+psFlags)
+		}
+		builtins := wc.builtins
+		if recurse {
+			builtins = append(builtins[:len(builtins):len(builtins)], wc.dirs...)
+		}
+		for _, b := range builtins {
+			patterns = append(patterns, joinNonEmpty(b+cmdFlags, args))
+		}
+	}
+	return patterns
+}
+
+func joinNonEmpty(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + " " + b
+}
+
+// posixForm translates a PowerShell or cmd.exe command into the POSIX
+// command rules are written against: "Remove-Item -Recurse -Force
+// infra\x" becomes "rm -rf infra/x", "del /s /q x" becomes "rm -rf x" and
+// "npm.cmd install" becomes "npm install". It returns "" for commands
+// that are already POSIX or have no equivalent.
+func posixForm(part string) string {
+	words := windowsFields(part)
+	if len(words) == 0 {
+		return ""
+	}
+	program := strings.ToLower(path.Base(strings.ReplaceAll(words[0], "\\", "/")))
+	name := program
+	for _, ext := range windowsExts {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	for _, wc := range windowsCommands {
+		if !containsFold(wc.aliases, name) && !strings.EqualFold(wc.cmdlet, name) {
+			continue
+		}
+		// Aliases shared with POSIX only count with Windows-style switches
+		windows := name != wc.posix
+		var recurse, force bool
+		var args []string
+		for _, w := range words[1:] {
+			lw := strings.ToLower(w)
+			switch {
+			case lw == "/s":
+				recurse, windows = true, true
+			case lw == "/q" || lw == "/f":
+				force, windows = true, true
+			case abbrev(lw, "-recurse", 2):
+				recurse, windows = true, windows || len(lw) > 2
+			case abbrev(lw, "-force", 3) || lw == "-f":
+				force, windows = true, windows || len(lw) > 2
+			case pathParams[lw]:
+				windows = true
+			case strings.HasPrefix(lw, "-") || len(lw) == 2 && lw[0] == '/':
+				// Other switches don't change what's touched
+			default:
+				args = append(args, strings.ReplaceAll(w, "\\", "/"))
+			}
+		}
+		if !windows {
+			return ""
+		}
+		flags := ""
+		if recurse {
+			flags += "r"
+		}
+		if force {
+			flags += "f"
+		}
+		cmd := wc.posix
+		if flags != "" {
+			cmd += " -" + flags
+		}
+		return joinNonEmpty(cmd, strings.Join(args, " "))
+	}
+
+	if name != program {
+		// npm.cmd, git.exe: the same program under its Windows file name
+		return joinNonEmpty(name, strings.Join(words[1:], " "))
+	}
+	return ""
+}
+
+// abbrev reports whether w is full or a PowerShell abbreviation of it at
+// least min characters long.
+func abbrev(w, full string, min int) bool {
+	return len(w) >= min && strings.HasPrefix(full, w)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// windowsFields splits a command into words on whitespace outside quotes.
+// Unlike shellFields, backslashes are path separators, not escapes.
+func windowsFields(s string) []string {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// windowsShell reports whether a program is PowerShell or cmd.exe.
+func windowsShell(program string) bool {
+	switch strings.TrimSuffix(strings.ToLower(program), ".exe") {
+	case "powershell", "pwsh", "cmd":
+		return true
+	}
+	return false
+}
+
+// windowsScript returns the script a PowerShell or cmd.exe invocation
+// runs: the rest of the line after -Command or /c, or the decoded
+// -EncodedCommand. ok is false when an encoded command can't be decoded.
+func windowsScript(args []string) (script string, ok bool) {
+	for i, arg := range args {
+		a := strings.ToLower(arg)
+		switch {
+		case a == "/c" || a == "/k" || abbrev(a, "-command", 2):
+			return strings.Join(args[i+1:], " "), true
+		case abbrev(a, "-encodedcommand", 2) || a == "-ec":
+			if i+1 == len(args) {
+				return "", false
+			}
+			return decodePowerShell(args[i+1])
+		}
+	}
+	return "", true
+}
+
+// decodePowerShell decodes an -EncodedCommand payload: base64 of UTF-16LE.
+func decodePowerShell(payload string) (string, bool) {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(data)%2 != 0 {
+		return "", false
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+	}
+	return string(utf16.Decode(units)), true
+}
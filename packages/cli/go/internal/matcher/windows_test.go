@@ -0,0 +1,119 @@
+package matcher
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestWindowsCommandsMatch(t *testing.T) {
+	m, err := New(commandPolicy("no rm -rf", "rm -rf*", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := func(script string) string {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(script)) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	blocked := []string{
+		"Remove-Item -Recurse -Force C:\\repo",
+		"Remove-Item C:\\repo -Recurse -Force",
+		"remove-item -path .\\dist -rec -fo",
+		"ri -r -fo build",
+		"rm -Recurse -Force build",
+		"del /s /q build",
+		"rd /s /q build",
+		"rmdir /S /Q build",
+		`cmd /c "rd /s /q build"`,
+		`powershell -NoProfile -Command "Remove-Item -Recurse -Force build"`,
+		"pwsh -c Remove-Item -Recurse -Force build",
+		"powershell -EncodedCommand " + encoded("Remove-Item -Recurse -Force build"),
+	}
+	for _, cmd := range blocked {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+
+	allowed := []string{
+		"Remove-Item notes.txt",
+		"del notes.txt",
+		"rd build",
+		"Get-ChildItem -Recurse",
+	}
+	for _, cmd := range allowed {
+		if !m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: blocked, want allowed", cmd)
+		}
+	}
+}
+
+func TestWindowsCommandsMatchBuiltins(t *testing.T) {
+	tests := []struct {
+		phrase string
+		cmd    string
+	}{
+		{"prefer pnpm", "npm.cmd install"},
+		{"prefer pnpm", "C:\\nodejs\\npm.cmd i lodash"},
+		{"protect dir: infra/", "Remove-Item -Recurse infra\\modules"},
+		{"protect dir: infra/", "del /q infra\\main.tf"},
+		{"protect dir: infra/", "Move-Item infra\\main.tf old.tf"},
+		{"preserve tests", "Remove-Item src\\app.test.ts"},
+	}
+	for _, tt := range tests {
+		b := builtin.Find(tt.phrase)
+		if b == nil {
+			t.Fatalf("no builtin for %q", tt.phrase)
+		}
+		m, err := New(b.ToPolicy(policy.ActionExecute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.CheckCommand(tt.cmd).Allowed {
+			t.Errorf("%s: %q allowed, want blocked", tt.phrase, tt.cmd)
+		}
+	}
+}
+
+func TestWindowsEnv(t *testing.T) {
+	m, err := New(&policy.Policy{
+		Description: "protect env vars",
+		EnvRules:    []policy.EnvRule{{Names: []string{"*_TOKEN"}, Reason: "secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []string{"echo $env:GITHUB_TOKEN", "echo %NPM_TOKEN%", "Get-ChildItem env:", "set"} {
+		if m.CheckCommand(cmd).Allowed {
+			t.Errorf("%q: allowed, want blocked", cmd)
+		}
+	}
+	if !m.CheckCommand("echo $env:PATH").Allowed {
+		t.Error("$env:PATH blocked, want allowed")
+	}
+}
+
+func TestWindowsPatterns(t *testing.T) {
+	tests := map[string][]string{
+		"rm -rf *": {
+			"Remove-Item -Recurse -Force *", "Remove-Item * -Recurse -Force",
+			"del /s /q *", "rd /s /q *", "rmdir /s /q *",
+		},
+		"rm *.test.*":  {"Remove-Item *.test.*", "del *.test.*"},
+		"npm install*": {"npm.cmd install*"},
+		"git push -f*": nil,
+	}
+	for pattern, want := range tests {
+		if got := WindowsPatterns(pattern); !slices.Equal(got, want) {
+			t.Errorf("WindowsPatterns(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
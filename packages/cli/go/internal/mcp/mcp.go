@@ -0,0 +1,228 @@
+// Package mcp serves veto's policies to agents over the Model Context
+// Protocol on stdin and stdout, so an agent can pull in just the
+// policies that concern the files it is working on instead of carrying
+// every policy in its instructions. It offers:
+//
+//	prompt   policies(files)             the policies for some files
+//	resource veto://policies             every policy
+//	resource veto://policies/{+path}     the policies for one file
+//
+// Messages are JSON-RPC 2.0, one per line.
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/jsonrpc"
+	"github.com/VulnZap/veto/internal/message"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// ProtocolVersion is the newest protocol revision the server speaks.
+const ProtocolVersion = "2025-06-18"
+
+// supported are the revisions the server accepts, newest first.
+var supported = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// URI is the resource listing every policy; a file's policies are under
+// URI + "/" + its path.
+const URI = "veto://policies"
+
+// mimeType is what resources and prompts are written in.
+const mimeType = "text/markdown"
+
+// Server answers one agent session.
+type Server struct {
+	conn    *jsonrpc.Conn
+	version string
+	// dir is where relative paths are resolved: the agent's working
+	// directory
+	dir      string
+	projects jsonrpc.Projects
+}
+
+// NewServer returns a server reading requests from r and writing replies
+// to w, resolving relative paths against dir. version is reported to the
+// agent.
+func NewServer(r io.Reader, w io.Writer, dir, version string) *Server {
+	return &Server{
+		conn:     jsonrpc.NewConn(r, w, jsonrpc.Lines),
+		version:  version,
+		dir:      dir,
+		projects: make(jsonrpc.Projects),
+	}
+}
+
+// Serve handles messages until the agent closes the stream.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var perr *jsonrpc.Error
+		if errors.As(err, &perr) {
+			s.conn.Write(jsonrpc.Message{Error: perr})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		result, rerr := s.dispatch(msg)
+		if result == nil && rerr == nil {
+			// MCP answers requests without a result with an empty object
+			result = map[string]any{}
+		}
+		s.conn.Reply(msg, result, rerr)
+	}
+}
+
+func (s *Server) dispatch(msg *jsonrpc.Message) (any, *jsonrpc.Error) {
+	switch msg.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		version := ProtocolVersion
+		for _, v := range supported {
+			if v == p.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"prompts": map[string]any{}, "resources": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "veto", "version": s.version},
+			"instructions":    "Before changing files, get the veto policies that concern them (prompt policies, or resource " + URI + "/<path>). Blocked actions stay blocked however they are rephrased.",
+		}, nil
+	case "ping":
+		return nil, nil
+	case "prompts/list":
+		return map[string]any{"prompts": []map[string]any{{
+			"name":        "policies",
+			"title":       "veto policies",
+			"description": "The veto policies that concern the files you are about to change",
+			"arguments": []map[string]any{{
+				"name":        "files",
+				"description": "Paths of the files, separated by commas or spaces",
+				"required":    true,
+			}},
+		}}}, nil
+	case "prompts/get":
+		var p struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		if p.Name != "policies" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "unknown prompt " + p.Name}
+		}
+		files := strings.FieldsFunc(p.Arguments["files"], func(r rune) bool { return r == ',' || r == ' ' || r == '\n' })
+		if len(files) == 0 {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "policies needs files"}
+		}
+		text, err := s.hints(files)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInternalError, Message: err.Error()}
+		}
+		return map[string]any{
+			"description": "veto policies for " + strings.Join(files, ", "),
+			"messages": []map[string]any{{
+				"role":    "user",
+				"content": map[string]string{"type": "text", "text": text},
+			}},
+		}, nil
+	case "resources/list":
+		return map[string]any{"resources": []map[string]string{{
+			"uri":         URI,
+			"name":        "policies",
+			"title":       "veto policies",
+			"description": "Every veto policy in force in this project",
+			"mimeType":    mimeType,
+		}}}, nil
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": []map[string]string{{
+			"uriTemplate": URI + "/{+path}",
+			"name":        "file-policies",
+			"title":       "veto policies for a file",
+			"description": "The veto policies that concern one file, by its path",
+			"mimeType":    mimeType,
+		}}}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, jsonrpc.InvalidParams(err)
+		}
+		text, err := s.read(p.URI)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+		return map[string]any{"contents": []map[string]string{{"uri": p.URI, "mimeType": mimeType, "text": text}}}, nil
+	case "notifications/initialized", "notifications/cancelled":
+	default:
+		if msg.ID != nil {
+			return nil, jsonrpc.MethodNotFound(msg.Method)
+		}
+	}
+	return nil, nil
+}
+
+// read returns a resource's text.
+func (s *Server) read(uri string) (string, error) {
+	if uri == URI {
+		p, err := s.projects.Find(s.dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return "No veto policies apply here.\n", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return message.Instructions(p.Policies), nil
+	}
+	path, ok := strings.CutPrefix(uri, URI+"/")
+	if !ok || path == "" {
+		return "", fmt.Errorf("unknown resource %s", uri)
+	}
+	return s.hints([]string{path})
+}
+
+// hints renders the policies that concern files, each policy once.
+func (s *Server) hints(files []string) (string, error) {
+	var relevant []*policy.Policy
+	seen := map[*policy.Policy]bool{}
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(s.dir, file)
+		}
+		p, err := s.projects.Find(filepath.Dir(file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, pol := range p.Relevant(&policy.CheckRequest{Action: string(policy.ActionModify), Target: file, Cwd: s.dir}) {
+			if !seen[pol] {
+				seen[pol] = true
+				relevant = append(relevant, pol)
+			}
+		}
+	}
+	if len(relevant) == 0 {
+		return "No veto policies concern " + strings.Join(files, ", ") + ".\n", nil
+	}
+	return message.Hints(files, relevant), nil
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/jsonrpc"
+)
+
+func TestSession(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("protect .env\nno console.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var in bytes.Buffer
+	for _, m := range []map[string]any{
+		{"id": 1, "method": "initialize", "params": map[string]any{"protocolVersion": "2024-11-05"}},
+		{"method": "notifications/initialized"},
+		{"id": 2, "method": "prompts/get", "params": map[string]any{"name": "policies", "arguments": map[string]string{"files": "src/app.ts"}}},
+		{"id": 3, "method": "resources/read", "params": map[string]any{"uri": URI + "/.env"}},
+		{"id": 4, "method": "resources/read", "params": map[string]any{"uri": URI}},
+		{"id": 5, "method": "tools/call"},
+	} {
+		m["jsonrpc"] = "2.0"
+		line, _ := json.Marshal(m)
+		in.Write(append(line, '\n'))
+	}
+	var out bytes.Buffer
+	if err := NewServer(&in, &out, root, "1.2.3").Serve(); err != nil {
+		t.Fatalf("Serve() = %v", err)
+	}
+
+	type reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *jsonrpc.Error  `json:"error"`
+	}
+	var replies []reply
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		var r reply
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("reply %s: %v", sc.Text(), err)
+		}
+		replies = append(replies, r)
+	}
+	if len(replies) != 5 {
+		t.Fatalf("got %d replies, want 5 (none for the notification)", len(replies))
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(replies[0].Result, &init)
+	if init.ProtocolVersion != "2024-11-05" {
+		t.Errorf("protocolVersion = %q, want the client's 2024-11-05", init.ProtocolVersion)
+	}
+
+	var prompt struct {
+		Messages []struct {
+			Content struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(replies[1].Result, &prompt)
+	if len(prompt.Messages) != 1 {
+		t.Fatalf("prompt = %s", replies[1].Result)
+	}
+	text := prompt.Messages[0].Content.Text
+	if !strings.Contains(text, "console.log") || strings.Contains(text, ".env") {
+		t.Errorf("prompt for src/app.ts = %q, want only the console.log policy", text)
+	}
+
+	read := func(i int) string {
+		var r struct {
+			Contents []struct {
+				Text string `json:"text"`
+			} `json:"contents"`
+		}
+		json.Unmarshal(replies[i].Result, &r)
+		if len(r.Contents) != 1 {
+			t.Fatalf("resource = %s", replies[i].Result)
+		}
+		return r.Contents[0].Text
+	}
+	if text := read(2); !strings.Contains(text, ".env") || strings.Contains(text, "console.log") {
+		t.Errorf("policies for .env = %q, want only the .env policy", text)
+	}
+	if text := read(3); !strings.Contains(text, ".env") || !strings.Contains(text, "console.log") {
+		t.Errorf("all policies = %q, want both", text)
+	}
+
+	if replies[4].Error == nil || replies[4].Error.Code != jsonrpc.CodeMethodNotFound {
+		t.Errorf("tools/call error = %+v, want method not found", replies[4].Error)
+	}
+}
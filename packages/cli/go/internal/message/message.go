@@ -0,0 +1,283 @@
+// Package message renders block results and policies for the place they
+// are shown: terse text for hook replies, markdown for agent instruction
+// files, and a single line for the terminal.
+package message
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Hook is the text an agent hook puts in its JSON reply for a result the
+// agent has to act on. It names the policy when the reason doesn't.
+func Hook(r *policy.CheckResult) string {
+	msg := "Blocked by veto"
+	if r.Decision == policy.DecisionAsk {
+		msg = "Needs approval (veto)"
+	}
+	if r.Policy != "" && r.Policy != r.Reason {
+		msg += " [" + r.Policy + "]"
+	}
+	msg += ": " + r.Reason
+	if len(r.Alternatives) > 0 {
+		msg += ". Instead run " + alternatives(r.Alternatives)
+	} else if r.Suggest != "" {
+		msg += ". Try: " + r.Suggest
+	}
+	return msg
+}
+
+// alternatives lists a result's alternatives for a hook reply, each
+// command in backticks followed by what it does and its safety notes.
+func alternatives(alts []policy.Alternative) string {
+	parts := make([]string, len(alts))
+	for i, a := range alts {
+		part := "`" + a.Command + "`"
+		var notes []string
+		for _, n := range []string{a.Description, a.Safety} {
+			if n = strings.TrimRight(n, ". "); n != "" {
+				notes = append(notes, n)
+			}
+		}
+		if len(notes) > 0 {
+			part += " (" + strings.Join(notes, "; ") + ")"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, " or ")
+}
+
+// Terminal is the one-line summary of a result printed by the CLI, or ""
+// when there is nothing to report.
+func Terminal(r *policy.CheckResult) string {
+	var line string
+	switch {
+	case !r.Allowed && r.Decision == policy.DecisionAsk:
+		line = "? Approval required: " + r.Reason
+	case !r.Allowed:
+		line = "✗ Blocked: " + r.Reason
+	case r.Decision == policy.DecisionWarn:
+		line = "! Warning: " + r.Reason
+	default:
+		return ""
+	}
+	if r.Suggest != "" {
+		line += " → try: " + r.Suggest
+	}
+	return line
+}
+
+// Markdown renders a policy as a bullet for agent instruction files, with
+// one sub-bullet per rule and the suggested alternative where there is one.
+func Markdown(p *policy.Policy) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- **%s**\n", p.Description)
+	// Content policies use Include to scope their rules, not to protect files
+	if len(p.Include) > 0 && len(p.ContentRules) == 0 {
+		fmt.Fprintf(&b, "  - Don't %s %s", verb(p.Action), code(p.Include))
+		if len(p.Exclude) > 0 {
+			fmt.Fprintf(&b, " (allowed: %s)", code(p.Exclude))
+		}
+		b.WriteString("\n")
+	}
+	for _, r := range p.CommandRules {
+		fmt.Fprintf(&b, "  - Don't run %s%s\n", commands(r.Block), reason(r.Reason, r.Suggestion()))
+	}
+	for _, r := range p.ContentRules {
+		fmt.Fprintf(&b, "  - In %s%s%s\n", code(r.FileTypes), under(r.Include, r.Exclude), reason(r.Reason, r.Suggest))
+	}
+	for _, r := range p.HeaderRules {
+		fmt.Fprintf(&b, "  - Don't edit files with %s in their first %d lines%s\n", code(r.Markers), r.Lines, reason(r.Reason, r.Suggest))
+	}
+	for _, r := range p.StructureRules {
+		fmt.Fprintf(&b, "  - %s%s\n", shape(r), reason(r.Reason, r.Suggest))
+	}
+	for _, r := range p.ShrinkRules {
+		files := "files"
+		if len(r.FileTypes) > 0 {
+			files = code(r.FileTypes)
+		}
+		fmt.Fprintf(&b, "  - Don't remove more than %d%% of %s%s in one write%s\n", r.MaxPercent, files, under(r.Include, nil), reason(r.Reason, r.Suggest))
+	}
+	for _, r := range p.EnvRules {
+		fmt.Fprintf(&b, "  - Keep %s off command lines%s\n", code(r.Names), reason(r.Reason, r.Suggest))
+	}
+	return b.String()
+}
+
+// Hints renders the policies that concern the files an agent is working
+// on, for adding to its context: a line naming the files, then a bullet
+// per policy as in Markdown. It returns "" when there are none.
+func Hints(files []string, policies []*policy.Policy) string {
+	if len(policies) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "veto policies for %s:\n", code(files))
+	for _, p := range policies {
+		b.WriteString(Markdown(p))
+	}
+	return b.String()
+}
+
+// Instructions renders every policy for an instruction file.
+func Instructions(policies []*policy.Policy) string {
+	var b strings.Builder
+	for _, p := range policies {
+		b.WriteString(Markdown(p))
+	}
+	return b.String()
+}
+
+// Tokens estimates how much of an agent's context text takes, at about
+// four characters a token.
+func Tokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Condensed renders policies in a line each, grouped by what they govern
+// and most severe first, for instruction files whose full list would
+// crowd the agent's context. Lines that don't fit in budget tokens are
+// cut, with a pointer to appendix, where the full rules are.
+func Condensed(policies []*policy.Policy, budget int, appendix string) string {
+	groups := []struct {
+		title    string
+		policies []*policy.Policy
+	}{{title: "Commands"}, {title: "Files"}, {title: "Code"}}
+	for _, p := range policies {
+		switch {
+		case len(p.CommandRules) > 0 || len(p.EnvRules) > 0:
+			groups[0].policies = append(groups[0].policies, p)
+		case len(p.ContentRules) > 0 || len(p.ASTRules) > 0:
+			groups[2].policies = append(groups[2].policies, p)
+		default:
+			groups[1].policies = append(groups[1].policies, p)
+		}
+	}
+
+	var b strings.Builder
+	more := fmt.Sprintf("Full rules, with reasons and alternatives: `%s`.\n", appendix)
+	used := Tokens(more)
+	left := len(policies)
+	for _, g := range groups {
+		if len(g.policies) == 0 {
+			continue
+		}
+		sorted := append([]*policy.Policy(nil), g.policies...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Severity.Rank() > sorted[j].Severity.Rank() })
+		heading := "\n" + g.title + ":\n"
+		for i, p := range sorted {
+			line := "- " + p.Description + condensedSuggestion(p) + "\n"
+			if i == 0 {
+				line = heading + line
+			}
+			if used+Tokens(line) > budget {
+				break
+			}
+			b.WriteString(line)
+			used += Tokens(line)
+			left--
+		}
+	}
+	if left > 0 {
+		more = fmt.Sprintf("%d more policies, and the full rules with reasons and alternatives: `%s`.\n", left, appendix)
+	}
+	return strings.TrimPrefix(b.String(), "\n") + "\n" + more
+}
+
+// condensedSuggestion is the first alternative a policy suggests, as
+// " (use …)", or "".
+func condensedSuggestion(p *policy.Policy) string {
+	for _, r := range p.CommandRules {
+		if s := r.Suggestion(); s != "" {
+			return " (use " + s + ")"
+		}
+	}
+	return ""
+}
+
+func verb(a policy.Action) string {
+	switch a {
+	case policy.ActionDelete:
+		return "delete"
+	case policy.ActionRead:
+		return "read"
+	case policy.ActionCreate:
+		return "create"
+	case policy.ActionRename:
+		return "rename"
+	case policy.ActionMove:
+		return "move"
+	case policy.ActionCopy:
+		return "copy"
+	case policy.ActionExecute:
+		return "run"
+	}
+	return "modify"
+}
+
+// maxPatterns is how many command patterns a rule lists before the rest
+// are summarized; generated rules can have dozens.
+const maxPatterns = 6
+
+func commands(patterns []string) string {
+	if len(patterns) <= maxPatterns {
+		return code(patterns)
+	}
+	return fmt.Sprintf("%s and %d similar", code(patterns[:maxPatterns]), len(patterns)-maxPatterns)
+}
+
+func code(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = "`" + s + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// under describes the paths a content rule is limited to, or "".
+func under(include, exclude []string) string {
+	s := ""
+	if len(include) > 0 {
+		s = " under " + code(include)
+	}
+	if len(exclude) > 0 {
+		s += " (except " + code(exclude) + ")"
+	}
+	return s
+}
+
+// shape describes the limits a structure rule sets.
+func shape(r policy.StructureRule) string {
+	var parts []string
+	if r.MaxLines > 0 {
+		parts = append(parts, fmt.Sprintf("keep files to %d lines at most", r.MaxLines))
+	}
+	if r.MaxFunctionLines > 0 {
+		parts = append(parts, fmt.Sprintf("keep functions to %d lines at most", r.MaxFunctionLines))
+	}
+	if r.RequireHeader != "" {
+		parts = append(parts, "start new files with a header matching `"+r.RequireHeader+"`")
+	}
+	s := strings.Join(parts, " and ")
+	if len(r.FileTypes) > 0 {
+		s = "In " + code(r.FileTypes) + ", " + s
+	} else if s != "" {
+		s = strings.ToUpper(s[:1]) + s[1:]
+	}
+	return s
+}
+
+func reason(why, suggest string) string {
+	s := ""
+	if why != "" {
+		s = ": " + why
+	}
+	if suggest != "" {
+		s += ". Instead: " + suggest
+	}
+	return s
+}
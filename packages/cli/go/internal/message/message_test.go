@@ -0,0 +1,99 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestHook(t *testing.T) {
+	tests := []struct {
+		name string
+		r    policy.CheckResult
+		want string
+	}{
+		{"default", policy.CheckResult{Reason: "blocked"}, "Blocked by veto: blocked"},
+		{"ask", policy.CheckResult{Reason: "needs a human", Decision: policy.DecisionAsk}, "Needs approval (veto): needs a human"},
+		{"names the policy", policy.CheckResult{Reason: "lock files are generated", Policy: "protect lock files"},
+			"Blocked by veto [protect lock files]: lock files are generated"},
+		{"policy is the reason", policy.CheckResult{Reason: "no sudo", Policy: "no sudo"}, "Blocked by veto: no sudo"},
+		{"suggestion", policy.CheckResult{Reason: "no force push", Suggest: "git push --force-with-lease"},
+			"Blocked by veto: no force push. Try: git push --force-with-lease"},
+		{"alternatives over suggestion", policy.CheckResult{
+			Reason:  "use pnpm",
+			Suggest: "pnpm add",
+			Alternatives: []policy.Alternative{
+				{Command: "pnpm add lodash", Description: "Add the packages named.", Safety: "check the package names"},
+				{Command: "pnpm install"},
+			},
+		}, "Blocked by veto: use pnpm. Instead run `pnpm add lodash` (Add the packages named; check the package names) or `pnpm install`"},
+		// The matcher expands "{args}"; anything still in braces is the
+		// policy author's text and is shown as written
+		{"unknown placeholders", policy.CheckResult{
+			Reason:       "don't touch {file}",
+			Alternatives: []policy.Alternative{{Command: "make {target}"}},
+		}, "Blocked by veto: don't touch {file}. Instead run `make {target}`"},
+	}
+	for _, tt := range tests {
+		if got := Hook(&tt.r); got != tt.want {
+			t.Errorf("%s: Hook() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	tests := []struct {
+		r    policy.CheckResult
+		want string
+	}{
+		{policy.CheckResult{Allowed: true}, ""},
+		{policy.CheckResult{Allowed: true, Decision: policy.DecisionAllow, Reason: "allowed"}, ""},
+		{policy.CheckResult{Reason: "protected"}, "✗ Blocked: protected"},
+		{policy.CheckResult{Reason: "protected", Suggest: "edit a copy"}, "✗ Blocked: protected → try: edit a copy"},
+		{policy.CheckResult{Reason: "risky", Decision: policy.DecisionAsk}, "? Approval required: risky"},
+		{policy.CheckResult{Allowed: true, Reason: "risky", Decision: policy.DecisionWarn}, "! Warning: risky"},
+		{policy.CheckResult{Reason: "{policy} says no"}, "✗ Blocked: {policy} says no"},
+	}
+	for _, tt := range tests {
+		if got := Terminal(&tt.r); got != tt.want {
+			t.Errorf("Terminal(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	p := &policy.Policy{
+		Description: "Protect lock files",
+		Action:      policy.ActionDelete,
+		Include:     []string{"*.lock"},
+		Exclude:     []string{"vendor/*.lock"},
+		CommandRules: []policy.CommandRule{{
+			Block:   []string{"rm *.lock", "a", "b", "c", "d", "e", "f"},
+			Reason:  "regenerate instead",
+			Suggest: "pnpm install",
+		}},
+	}
+	want := "- **Protect lock files**\n" +
+		"  - Don't delete `*.lock` (allowed: `vendor/*.lock`)\n" +
+		"  - Don't run `rm *.lock`, `a`, `b`, `c`, `d`, `e` and 1 similar: regenerate instead. Instead: pnpm install\n"
+	if got := Markdown(p); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	// A content policy's Include scopes its rules; it protects no files
+	content := &policy.Policy{
+		Description:  "No eval",
+		Include:      []string{"**/*.ts"},
+		ContentRules: []policy.ContentRule{{FileTypes: []string{"*.ts"}, Reason: "eval runs arbitrary code"}},
+	}
+	if got := Markdown(content); strings.Contains(got, "Don't modify") {
+		t.Errorf("content policy rendered as protecting files:\n%s", got)
+	}
+}
+
+func TestHintsEmpty(t *testing.T) {
+	if got := Hints([]string{"a.go"}, nil); got != "" {
+		t.Errorf("Hints() with no policies = %q, want empty", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Package notify raises OS-native desktop notifications when an agent is
+// blocked or waits for approval, for users who aren't watching the
+// agent's terminal: Notification Center on macOS, libnotify on Linux and
+// toasts on Windows (including from WSL).
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/settings"
+	"github.com/VulnZap/veto/internal/wsl"
+)
+
+// DefaultSeverity is the lowest severity of block that notifies unless
+// the user picks another.
+const DefaultSeverity = policy.SeverityHigh
+
+// unrated is the severity assumed for policies without one.
+const unrated = policy.SeverityMedium
+
+// toast shows a Windows toast from $env:VETO_TITLE and $env:VETO_BODY,
+// under PowerShell's app ID so it appears without registering one.
+const toast = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode($env:VETO_TITLE)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode($env:VETO_BODY)) > $null
+$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// Wants reports whether a result should raise a notification under n
+// (nil when notifications are off) at now. While hushed only critical
+// blocks notify.
+func Wants(n *settings.Notifications, r *policy.CheckResult, now time.Time) bool {
+	if n == nil || r.Allowed {
+		return false
+	}
+	if Hushed(n, now) {
+		return r.Decision != policy.DecisionAsk && Severity(r) == policy.SeverityCritical
+	}
+	if r.Decision == policy.DecisionAsk {
+		return n.Asks
+	}
+	threshold := n.Severity
+	if threshold == "" {
+		threshold = DefaultSeverity
+	}
+	return Severity(r).Rank() >= threshold.Rank()
+}
+
+// Hushed reports whether n holds notifications to critical blocks at
+// now: in focus mode or inside the quiet hours.
+func Hushed(n *settings.Notifications, now time.Time) bool {
+	if n.Focus {
+		return true
+	}
+	if n.Quiet == "" {
+		return false
+	}
+	start, end, err := ParseQuiet(n.Quiet)
+	if err != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return start <= minute && minute < end
+	}
+	// The window spans midnight
+	return minute >= start || minute < end
+}
+
+// ParseQuiet parses quiet hours written "HH:MM-HH:MM" into minutes after
+// midnight.
+func ParseQuiet(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("quiet hours %q: want HH:MM-HH:MM", s)
+	}
+	if start, err = clock(from); err != nil {
+		return 0, 0, fmt.Errorf("quiet hours %q: %w", s, err)
+	}
+	if end, err = clock(to); err != nil {
+		return 0, 0, fmt.Errorf("quiet hours %q: %w", s, err)
+	}
+	return start, end, nil
+}
+
+// clock parses "HH:MM" into minutes after midnight.
+func clock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 22:00", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Severity returns the severity of the policy that decided a result, or
+// medium when it has none.
+func Severity(r *policy.CheckResult) policy.Severity {
+	for _, step := range r.Trace {
+		if step.Outcome == policy.OutcomeApplied && step.Severity != "" {
+			return step.Severity
+		}
+	}
+	return unrated
+}
+
+// Message returns the title and body describing a result.
+func Message(req *policy.CheckRequest, r *policy.CheckResult) (title, body string) {
+	title = "veto blocked an agent"
+	if r.Decision == policy.DecisionAsk {
+		title = "veto needs your approval"
+	}
+	if req.Agent != "" {
+		title += " (" + req.Agent + ")"
+	}
+	subject := req.Command
+	if subject == "" {
+		subject = req.Action + " " + req.Target
+	}
+	body = subject
+	if r.Policy != "" {
+		body += "\n" + r.Policy
+	}
+	if r.Reason != "" && r.Reason != r.Policy {
+		body += ": " + r.Reason
+	}
+	return title, body
+}
+
+// Send shows a notification. It returns once the notifier has started,
+// without waiting for it, so hooks aren't slowed down.
+func Send(title, body string) error {
+	name, args, env := command(runtime.GOOS, wsl.Detected(), title, body)
+	if name == "" {
+		return fmt.Errorf("desktop notifications aren't supported on %s", runtime.GOOS)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// command returns the program, arguments and extra environment that
+// notify on goos. Title and body are never spliced into a script.
+func command(goos string, inWSL bool, title, body string) (name string, args, env []string) {
+	switch {
+	case goos == "darwin":
+		return "osascript", []string{
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body,
+		}, nil
+	case goos == "windows", inWSL:
+		env = []string{"VETO_TITLE=" + title, "VETO_BODY=" + body}
+		name = "powershell"
+		if inWSL {
+			// WSLENV carries the variables across to the Windows process
+			name = "powershell.exe"
+			wslenv := "VETO_TITLE:VETO_BODY"
+			if prev := os.Getenv("WSLENV"); prev != "" {
+				wslenv = prev + ":" + wslenv
+			}
+			env = append(env, "WSLENV="+wslenv)
+		}
+		return name, []string{"-NoProfile", "-NonInteractive", "-Command", toast}, env
+	case goos == "linux", goos == "freebsd", goos == "openbsd", goos == "netbsd":
+		return "notify-send", []string{"--app-name=veto", title, body}, nil
+	}
+	return "", nil, nil
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/settings"
+)
+
+func blocked(decision policy.Decision, severity policy.Severity) *policy.CheckResult {
+	return &policy.CheckResult{
+		Decision: decision,
+		Policy:   "p",
+		Trace:    []policy.TraceStep{{Policy: "p", Decision: decision, Severity: severity, Outcome: policy.OutcomeApplied}},
+	}
+}
+
+var noon = time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+
+func TestWants(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      *settings.Notifications
+		result *policy.CheckResult
+		want   bool
+	}{
+		{"off", nil, blocked(policy.DecisionDeny, policy.SeverityCritical), false},
+		{"allowed", &settings.Notifications{}, &policy.CheckResult{Allowed: true}, false},
+		{"high by default", &settings.Notifications{}, blocked(policy.DecisionDeny, policy.SeverityHigh), true},
+		{"medium below default", &settings.Notifications{}, blocked(policy.DecisionDeny, policy.SeverityMedium), false},
+		{"unrated counts as medium", &settings.Notifications{Severity: policy.SeverityMedium}, blocked(policy.DecisionDeny, ""), true},
+		{"low threshold", &settings.Notifications{Severity: policy.SeverityLow}, blocked(policy.DecisionDeny, policy.SeverityLow), true},
+		{"asks on", &settings.Notifications{Asks: true}, blocked(policy.DecisionAsk, ""), true},
+		{"asks off", &settings.Notifications{Severity: policy.SeverityLow}, blocked(policy.DecisionAsk, policy.SeverityCritical), false},
+		{"focus keeps critical", &settings.Notifications{Focus: true}, blocked(policy.DecisionDeny, policy.SeverityCritical), true},
+		{"focus drops high", &settings.Notifications{Focus: true}, blocked(policy.DecisionDeny, policy.SeverityHigh), false},
+		{"focus drops asks", &settings.Notifications{Focus: true, Asks: true}, blocked(policy.DecisionAsk, ""), false},
+		{"quiet hours drop high", &settings.Notifications{Quiet: "11:30-13:00"}, blocked(policy.DecisionDeny, policy.SeverityHigh), false},
+		{"outside quiet hours", &settings.Notifications{Quiet: "22:00-08:00"}, blocked(policy.DecisionDeny, policy.SeverityHigh), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Wants(tt.n, tt.result, noon); got != tt.want {
+				t.Errorf("Wants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHushed(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.Local) }
+	n := &settings.Notifications{Quiet: "22:00-08:00"}
+	for _, tc := range []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(21, 59), false},
+		{at(22, 0), true},
+		{at(3, 0), true},
+		{at(8, 0), false},
+		{at(12, 0), false},
+	} {
+		if got := Hushed(n, tc.now); got != tc.want {
+			t.Errorf("Hushed at %s = %v, want %v", tc.now.Format("15:04"), got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"22:00", "10pm-6am", "25:00-08:00"} {
+		if _, _, err := ParseQuiet(bad); err == nil {
+			t.Errorf("ParseQuiet(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestCommandKeepsTextOutOfScripts(t *testing.T) {
+	title, body := `"; do shell script "rm -rf ~"`, "$(whoami)"
+	for _, tc := range []struct {
+		goos  string
+		wsl   bool
+		name  string
+		inEnv bool
+	}{
+		{"darwin", false, "osascript", false},
+		{"linux", false, "notify-send", false},
+		{"linux", true, "powershell.exe", true},
+		{"windows", false, "powershell", true},
+	} {
+		name, args, env := command(tc.goos, tc.wsl, title, body)
+		if name != tc.name {
+			t.Errorf("%s (wsl=%v): notifier %q, want %q", tc.goos, tc.wsl, name, tc.name)
+		}
+		for _, a := range args[:len(args)-2] {
+			if strings.Contains(a, title) || strings.Contains(a, body) {
+				t.Errorf("%s: script argument %q contains the message", tc.goos, a)
+			}
+		}
+		if tc.inEnv && !slices.Contains(env, "VETO_TITLE="+title) {
+			t.Errorf("%s: env %q missing the title", tc.goos, env)
+		}
+	}
+	if name, _, _ := command("plan9", false, title, body); name != "" {
+		t.Errorf("plan9 notifier = %q, want none", name)
+	}
+}
@@ -0,0 +1,190 @@
+// Package output defines the JSON documents veto prints with the global
+// --json flag, for scripts and editor integrations. Each command prints
+// one document on stdout; its other output goes to stderr. Fields may be
+// added, but existing ones keep their names and meaning.
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Error is printed in place of a command's document when it fails.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Kinds of entries in a project's .veto.
+const (
+	// KindBuiltin is a phrase naming a builtin
+	KindBuiltin = "builtin"
+	// KindPhrase is a phrase compiled by an LLM
+	KindPhrase = "phrase"
+	// KindRule is a rule defined in full under rules:
+	KindRule = "rule"
+)
+
+// List is `veto list`: the policies in the project's .veto, phrases
+// first, as listed.
+type List struct {
+	// Config is the path of the .veto, "" when there is none
+	Config   string   `json:"config"`
+	Policies []Policy `json:"policies"`
+}
+
+// Policy is an entry in a .veto.
+type Policy struct {
+	// Name is the phrase, or the rule's description
+	Name string `json:"name"`
+	// Kind is KindBuiltin, KindPhrase or KindRule
+	Kind string `json:"kind"`
+}
+
+// Enforcement levels, from weakest to strongest (see agent.Level).
+const (
+	EnforcementNone         = "none"
+	EnforcementInstructions = "instructions"
+	EnforcementDenyList     = "deny-list"
+	EnforcementHooks        = "hooks"
+)
+
+// Status is `veto status`.
+type Status struct {
+	Agents []Agent `json:"agents"`
+	// Drift lists, per agent and scope synced from the project, the
+	// policies out of date
+	Drift  []Drift `json:"drift"`
+	Daemon bool    `json:"daemon"`
+	// Project is the project's root, "" outside one
+	Project string `json:"project"`
+	// Policies counts the entries in the project's .veto
+	Policies    int    `json:"policies"`
+	Environment string `json:"environment,omitempty"`
+	// Baseline summarizes the accepted violations, "" without a baseline
+	Baseline string `json:"baseline,omitempty"`
+	// MachinePolicies counts the machine-wide policies
+	MachinePolicies int `json:"machinePolicies"`
+}
+
+// Agent is a detected agent and how veto's policies bind it.
+type Agent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Enforcement is one of the Enforcement levels
+	Enforcement string `json:"enforcement"`
+	// Advisory is set when the agent can ignore the policies
+	Advisory bool `json:"advisory"`
+	// ViaDaemon is set when live hooks are answered by the daemon
+	ViaDaemon bool `json:"viaDaemon,omitempty"`
+	// Source is the file providing the enforcement
+	Source string `json:"source,omitempty"`
+	// Scopes the agent is synced at from the project
+	Scopes []string `json:"scopes"`
+}
+
+// Drift is how an agent's synced policies differ from the project's.
+type Drift struct {
+	Agent    string    `json:"agent"`
+	Scope    string    `json:"scope"`
+	SyncedAt time.Time `json:"syncedAt"`
+	Added    []string  `json:"added"`
+	Changed  []string  `json:"changed"`
+	Removed  []string  `json:"removed"`
+}
+
+// Sync is `veto sync`.
+type Sync struct {
+	Scope string `json:"scope"`
+	// Environment is the overlay selected with --env, "none" to clear it
+	Environment string   `json:"environment,omitempty"`
+	Agents      []Synced `json:"agents"`
+}
+
+// Synced is an agent `veto sync` installed to.
+type Synced struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Error is why installing failed, "" when it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Add is `veto add`: the policies added to the .veto and those skipped
+// because an existing policy covers them.
+type Add struct {
+	Added   []Added   `json:"added"`
+	Skipped []Skipped `json:"skipped"`
+}
+
+// Added is a policy `veto add` wrote.
+type Added struct {
+	Policy string `json:"policy"`
+	// Source is how the phrase compiled: builtin, cache or the LLM's name
+	Source  string `json:"source"`
+	Monitor bool   `json:"monitor,omitempty"`
+}
+
+// Skipped is a policy `veto add` left out.
+type Skipped struct {
+	Policy string `json:"policy"`
+	// CoveredBy is the existing policy covering it
+	CoveredBy string `json:"coveredBy"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Audit is `veto audit`: the violations already in the repository.
+type Audit struct {
+	Root string `json:"root"`
+	// Files is how many files were scanned
+	Files    int       `json:"files"`
+	Blocking int       `json:"blocking"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is a violation found by `veto audit`.
+type Finding struct {
+	// File is slash-separated and relative to Root
+	File string `json:"file"`
+	// Line and Column start at 1; both are 0 for the file as a whole
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Policy   string `json:"policy"`
+	Rule     string `json:"rule"`
+	Reason   string `json:"reason"`
+	Suggest  string `json:"suggest,omitempty"`
+	Decision string `json:"decision"`
+	Severity string `json:"severity"`
+	// Blocking is set when the policies would stop an agent writing it
+	Blocking  bool   `json:"blocking"`
+	Monitored bool   `json:"monitored,omitempty"`
+	Possible  bool   `json:"possible,omitempty"`
+	Baselined bool   `json:"baselined,omitempty"`
+	Text      string `json:"text"`
+}
+
+// Check is `veto check`: the decision on one action.
+type Check struct {
+	Allowed bool `json:"allowed"`
+	// Decision is deny, ask or warn when a policy matched, "" otherwise
+	Decision string `json:"decision,omitempty"`
+	Policy   string `json:"policy,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Suggest  string `json:"suggest,omitempty"`
+	// Monitored lists the monitor-mode policies that would have acted
+	Monitored []Monitored `json:"monitored"`
+}
+
+// Monitored is a monitor-mode policy that matched a checked action.
+type Monitored struct {
+	Policy   string `json:"policy"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Print writes v to w as indented JSON.
+func Print(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,34 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, List{Policies: []Policy{{Name: "protect .env", Kind: KindBuiltin}}}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "config": "",
+  "policies": [
+    {
+      "name": "protect .env",
+      "kind": "builtin"
+    }
+  ]
+}
+`
+	if buf.String() != want {
+		t.Errorf("Print = %s, want %s", buf.String(), want)
+	}
+
+	// Documents decode back unchanged, so scripts can use the same types
+	var check Check
+	data, _ := json.Marshal(Check{Allowed: false, Decision: "deny", Policy: "Protect .env", Monitored: []Monitored{}})
+	if err := json.Unmarshal(data, &check); err != nil || check.Decision != "deny" || check.Allowed {
+		t.Errorf("round trip = %+v, %v", check, err)
+	}
+}
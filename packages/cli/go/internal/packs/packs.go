@@ -0,0 +1,321 @@
+// Package packs fetches policy packs: shareable bundles of policies a
+// .veto imports with packs:, so teams can hold every repository to an
+// org-wide baseline without copying its rules around. A pack is written
+// like a YAML .veto, with a name and description:
+//
+//	name: acme/baseline
+//	description: What every Acme repository enforces
+//	policies:
+//	  - protect .env
+//	rules:
+//	  - description: No internal hostnames
+//	    contentRules: [...]
+//
+// A pack's source is a local file, an HTTP(S) URL, a git repository
+// (git+<url>[//<path>][#<ref>]) or a name in the pack registry
+// (owner/name, or owner/name@1.2.0 pinned to a version; see the registry
+// package). Remote packs are cached per user, so checks don't touch the
+// network once a pack has been fetched; a pinned one never changes.
+package packs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/httpclient"
+	"github.com/VulnZap/veto/internal/registry"
+	"github.com/VulnZap/veto/internal/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+// maxPackSize bounds a fetched pack.
+const maxPackSize = 1 << 20
+
+// DefaultFile is the pack read from a git repository when the source
+// doesn't name one.
+const DefaultFile = "veto-pack.yaml"
+
+// Kinds of pack source.
+const (
+	KindFile     = "file"
+	KindHTTP     = "http"
+	KindGit      = "git"
+	KindRegistry = "registry"
+)
+
+// Pack is a parsed policy pack.
+type Pack struct {
+	// Name is the pack's declared name, or its source when it has none
+	Name string
+	// Version is the pack's declared version (packVersion:), if any
+	Version     string
+	Description string
+	// Source is where the pack was imported from, as .veto lists it
+	Source string
+	// Config holds the pack's policies and rules
+	Config *config.VetoConfig
+}
+
+// header is the part of a pack that isn't .veto syntax.
+type header struct {
+	Name        string   `yaml:"name"`
+	PackVersion string   `yaml:"packVersion"`
+	Description string   `yaml:"description"`
+	Packs       []string `yaml:"packs"`
+}
+
+// Parse parses a pack fetched from source.
+func Parse(source string, data []byte) (*Pack, error) {
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %s", source, strings.TrimPrefix(err.Error(), "invalid .veto: "))
+	}
+	if cfg.Format != config.FormatYAML {
+		return nil, fmt.Errorf("pack %s: not a YAML pack (want name:, policies: or rules:)", source)
+	}
+	var h header
+	if err := yaml.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("pack %s: %w", source, err)
+	}
+	if len(h.Packs) > 0 {
+		return nil, fmt.Errorf("pack %s: packs can't import other packs", source)
+	}
+	if h.PackVersion != "" && !registry.ValidVersion(h.PackVersion) {
+		return nil, fmt.Errorf("pack %s: packVersion %q isn't a semantic version like 1.2.0", source, h.PackVersion)
+	}
+	if len(cfg.Policies) == 0 && len(cfg.Rules) == 0 {
+		return nil, fmt.Errorf("pack %s: no policies or rules", source)
+	}
+	name := h.Name
+	if name == "" {
+		name = source
+	}
+	return &Pack{Name: name, Version: h.PackVersion, Description: h.Description, Source: source, Config: cfg}, nil
+}
+
+// Kind returns what kind of source a pack source is, or an error when it
+// isn't one.
+func Kind(source string) (string, error) {
+	switch {
+	case strings.HasPrefix(source, "git+"):
+		return KindGit, nil
+	case strings.HasPrefix(source, "https://"), strings.HasPrefix(source, "http://"):
+		return KindHTTP, nil
+	case strings.HasSuffix(source, ".yaml"), strings.HasSuffix(source, ".yml"),
+		strings.HasPrefix(source, "."), filepath.IsAbs(source):
+		return KindFile, nil
+	case registry.ValidRef(source):
+		return KindRegistry, nil
+	}
+	return "", fmt.Errorf("pack %q: want a .yaml file, an http(s) URL, git+<url> or a registry name like owner/pack[@version]", source)
+}
+
+// Unpinned returns a registry source without its pinned version, and
+// other sources as they are: two sources naming the same pack give the
+// same result.
+func Unpinned(source string) string {
+	if kind, _ := Kind(source); kind == KindRegistry {
+		name, _ := registry.SplitRef(source)
+		return name
+	}
+	return source
+}
+
+// Load reads the packs in sources for the project at root, in order.
+// Local files are read relative to root; remote packs come from the
+// cache, and are fetched the first time.
+func Load(root string, sources []string) ([]*Pack, error) {
+	var packs []*Pack
+	for _, source := range sources {
+		p, err := read(root, source, false)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, p)
+	}
+	return packs, nil
+}
+
+// Fetch reads the pack at source afresh, bypassing and then updating the
+// cache for remote packs. Local files are read relative to root. A
+// registry pack fetched without a version comes back pinned to the one it
+// declares: its Source has the @version to list in .veto.
+func Fetch(root, source string) (*Pack, error) {
+	return read(root, source, true)
+}
+
+// read reads and parses a pack, fetching a remote one when fresh is set
+// or it isn't cached, and caching it once it parses.
+func read(root, source string, fresh bool) (*Pack, error) {
+	kind, err := Kind(source)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	fetched := false
+	switch {
+	case kind == KindFile:
+		data, err = os.ReadFile(localPath(root, source))
+	case !fresh:
+		data, err = os.ReadFile(cachePath(source))
+		if errors.Is(err, os.ErrNotExist) {
+			data, err = fetch(kind, source)
+			fetched = true
+		}
+	default:
+		data, err = fetch(kind, source)
+		fetched = true
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", source, err)
+	}
+	p, err := Parse(source, data)
+	if err != nil {
+		return nil, err
+	}
+	if !fetched {
+		return p, nil
+	}
+
+	if kind == KindRegistry {
+		name, pinned := registry.SplitRef(source)
+		switch {
+		case pinned != "" && p.Version != "" && p.Version != pinned:
+			return nil, fmt.Errorf("pack %s: the registry sent version %s", source, p.Version)
+		case pinned == "" && p.Version != "" && fresh:
+			cache(name+"@"+p.Version, data)
+			p.Source = name + "@" + p.Version
+		}
+	}
+	cache(source, data)
+	return p, nil
+}
+
+// cache stores a fetched pack for source.
+func cache(source string, data []byte) {
+	path := cachePath(source)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		os.WriteFile(path, data, 0644)
+	}
+}
+
+// localPath resolves a local pack source against root.
+func localPath(root, source string) string {
+	if strings.HasPrefix(source, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, source[2:])
+		}
+	}
+	path := filepath.FromSlash(source)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return path
+}
+
+// fetch downloads a remote pack.
+func fetch(kind, source string) ([]byte, error) {
+	switch kind {
+	case KindGit:
+		return fetchGit(source)
+	case KindRegistry:
+		return registry.New().Fetch(registry.SplitRef(source))
+	}
+	return fetchHTTP(source)
+}
+
+// cachePath is where a remote pack is cached.
+func cachePath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(xdg.CacheDir(), "packs", hex.EncodeToString(sum[:8])+".yaml")
+}
+
+// fetchHTTP downloads a pack.
+func fetchHTTP(rawURL string) ([]byte, error) {
+	resp, err := httpclient.New(30 * time.Second).Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return readLimited(resp.Body)
+}
+
+// readLimited reads a pack, failing when it's larger than maxPackSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPackSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPackSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxPackSize)
+	}
+	return data, nil
+}
+
+// fetchGit reads a pack from a shallow clone of a git repository.
+func fetchGit(source string) ([]byte, error) {
+	repo, file, ref, err := ParseGit(source)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "veto-pack-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	cmd := exec.Command("git", append(args, "--", repo, dir)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("git clone %s: %v: %s", repo, err, strings.TrimSpace(string(out)))
+	}
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, fmt.Errorf("%s not in %s", file, repo)
+	}
+	defer f.Close()
+	return readLimited(f)
+}
+
+// ParseGit splits a git+<url>[//<path>][#<ref>] source into the
+// repository URL, the pack's path in it (DefaultFile when not given) and
+// the branch or tag to check out ("" for the default branch).
+func ParseGit(source string) (repo, file, ref string, err error) {
+	repo, ok := strings.CutPrefix(source, "git+")
+	if !ok {
+		return "", "", "", fmt.Errorf("pack %q: not a git+ source", source)
+	}
+	repo, ref, _ = strings.Cut(repo, "#")
+	file = DefaultFile
+	// The path follows a // after the URL's own
+	rest := repo
+	if u, err := url.Parse(repo); err == nil && u.Scheme != "" {
+		rest = strings.TrimPrefix(repo, u.Scheme+"://")
+	}
+	if i := strings.Index(rest, "//"); i >= 0 {
+		repo = repo[:len(repo)-len(rest)+i]
+		file = strings.Trim(rest[i+2:], "/")
+	}
+	if repo == "" || file == "" || strings.Contains(file, "..") {
+		return "", "", "", fmt.Errorf("pack %q: want git+<url>[//<path>][#<ref>]", source)
+	}
+	return repo, file, ref, nil
+}
@@ -0,0 +1,136 @@
+package packs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/registry"
+)
+
+const basePack = `name: acme/baseline
+description: Acme's baseline
+policies:
+  - protect .env
+rules:
+  - description: no direct kubectl
+    action: execute
+    commandRules:
+      - block: ["kubectl apply*"]
+        reason: deploys go through CI
+`
+
+func TestKind(t *testing.T) {
+	tests := map[string]string{
+		"./packs/base.yaml":         KindFile,
+		"packs/base.yml":            KindFile,
+		"/etc/veto/base.yaml":       KindFile,
+		"https://example.com/p":     KindHTTP,
+		"git+https://example.com/r": KindGit,
+		"acme/baseline":             KindRegistry,
+		"acme/baseline@1.2.0":       KindRegistry,
+		"acme/baseline@latest":      "",
+		"acme":                      "",
+		"Acme/Baseline!":            "",
+	}
+	for source, want := range tests {
+		got, err := Kind(source)
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("Kind(%q) = %q, %v; want %q", source, got, err, want)
+		}
+	}
+}
+
+func TestParseGit(t *testing.T) {
+	tests := []struct {
+		source, repo, file, ref string
+	}{
+		{"git+https://github.com/acme/packs.git", "https://github.com/acme/packs.git", DefaultFile, ""},
+		{"git+https://github.com/acme/packs.git//node/strict.yaml#v1.2", "https://github.com/acme/packs.git", "node/strict.yaml", "v1.2"},
+		{"git+ssh://git@github.com/acme/packs.git#main", "ssh://git@github.com/acme/packs.git", DefaultFile, "main"},
+	}
+	for _, tt := range tests {
+		repo, file, ref, err := ParseGit(tt.source)
+		if err != nil || repo != tt.repo || file != tt.file || ref != tt.ref {
+			t.Errorf("ParseGit(%q) = %q, %q, %q, %v; want %q, %q, %q", tt.source, repo, file, ref, err, tt.repo, tt.file, tt.ref)
+		}
+	}
+	if _, _, _, err := ParseGit("git+https://example.com/r//../../etc/passwd"); err == nil {
+		t.Error("ParseGit accepted a path outside the repository")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+	t.Setenv("HOME", tmp)
+	root := filepath.Join(tmp, "repo")
+	os.MkdirAll(filepath.Join(root, "packs"), 0755)
+	os.WriteFile(filepath.Join(root, "packs", "base.yaml"), []byte(basePack), 0644)
+
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/packs/acme/node", "/packs/acme/node/1.2.0":
+			w.Write([]byte("name: acme/node\npackVersion: 1.2.0\npolicies:\n  - prefer pnpm\n"))
+		case "/packs/acme/node/1.1.0":
+			w.Write([]byte("name: acme/node\npackVersion: 1.2.0\npolicies:\n  - prefer npm\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Setenv(registry.URLEnv, srv.URL+"/")
+
+	sources := []string{"./packs/base.yaml", "acme/node"}
+	packs, err := Load(root, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 2 || packs[0].Name != "acme/baseline" || len(packs[0].Config.Rules) != 1 ||
+		packs[1].Name != "acme/node" || packs[1].Config.Policies[0] != "prefer pnpm" {
+		t.Fatalf("Load = %+v", packs)
+	}
+
+	// Fetching without a version pins the latest
+	p, err := Fetch(root, "acme/node")
+	if err != nil || p.Source != "acme/node@1.2.0" || p.Version != "1.2.0" {
+		t.Fatalf("Fetch(acme/node) = %+v, %v", p, err)
+	}
+	if _, err := Fetch(root, "acme/node@1.1.0"); err == nil || !strings.Contains(err.Error(), "sent version 1.2.0") {
+		t.Errorf("Fetch of a mislabeled version = %v", err)
+	}
+	sources = append(sources, p.Source)
+	hits = 0
+
+	// Cached once fetched, so checks work offline
+	srv.Close()
+	if _, err := Load(root, sources); err != nil || hits != 0 {
+		t.Errorf("Load after fetching = %v with %d requests, want the cached pack", err, hits)
+	}
+	if _, err := Fetch(root, "acme/node"); err == nil {
+		t.Error("Fetch used the cache")
+	}
+	if _, err := Load(root, []string{"./packs/missing.yaml"}); err == nil {
+		t.Error("Load of a missing pack succeeded")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		data, want string
+	}{
+		{"protect .env\n", "not a YAML pack"},
+		{"name: empty\n", "no policies or rules"},
+		{"name: nested\npacks: [acme/other]\npolicies: [protect .env]\n", "other packs"},
+		{"name: bad\nrules:\n  - description: empty\n", "needs include"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse("test.yaml", []byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error mentioning %q", tt.data, err, tt.want)
+		}
+	}
+}
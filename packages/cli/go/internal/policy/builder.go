@@ -0,0 +1,221 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// NewCommandRule returns a rule blocking commands that match any of the
+// glob patterns.
+func NewCommandRule(reason string, patterns ...string) CommandRule {
+	return CommandRule{Block: patterns, Reason: reason}
+}
+
+// NewContentRule returns a rule blocking file content that matches the
+// regex pattern, in files matching fileTypes (all files when empty).
+func NewContentRule(pattern, reason string, fileTypes ...string) ContentRule {
+	return ContentRule{Pattern: pattern, FileTypes: fileTypes, Reason: reason}
+}
+
+// NewEnvRule returns a rule keeping environment variables matching the
+// glob names off command lines.
+func NewEnvRule(reason string, names ...string) EnvRule {
+	return EnvRule{Names: names, Reason: reason}
+}
+
+// Builder assembles a Policy for tools that author policies in code
+// rather than as .veto phrases:
+//
+//	p, err := policy.NewBuilder("Keep migrations immutable").
+//		Protect("db/migrations/**").
+//		Commands(policy.NewCommandRule("migrations are applied", "rm db/migrations/*")).
+//		Severity(policy.SeverityHigh).
+//		Build()
+type Builder struct {
+	p Policy
+}
+
+// NewBuilder starts a policy with a description and ActionModify.
+func NewBuilder(description string) *Builder {
+	return &Builder{p: Policy{Description: description, Action: ActionModify}}
+}
+
+// Action sets which file operations the protected patterns block.
+func (b *Builder) Action(a Action) *Builder {
+	b.p.Action = a
+	return b
+}
+
+// Protect adds file patterns the policy protects.
+func (b *Builder) Protect(patterns ...string) *Builder {
+	b.p.Include = append(b.p.Include, patterns...)
+	return b
+}
+
+// Except adds file patterns exempt from the protected ones.
+func (b *Builder) Except(patterns ...string) *Builder {
+	b.p.Exclude = append(b.p.Exclude, patterns...)
+	return b
+}
+
+// Commands adds command rules.
+func (b *Builder) Commands(rules ...CommandRule) *Builder {
+	b.p.CommandRules = append(b.p.CommandRules, rules...)
+	return b
+}
+
+// Content adds content rules.
+func (b *Builder) Content(rules ...ContentRule) *Builder {
+	b.p.ContentRules = append(b.p.ContentRules, rules...)
+	return b
+}
+
+// Env adds environment variable rules.
+func (b *Builder) Env(rules ...EnvRule) *Builder {
+	b.p.EnvRules = append(b.p.EnvRules, rules...)
+	return b
+}
+
+// Allow adds exceptions evaluated before the blocking rules.
+func (b *Builder) Allow(rules ...AllowRule) *Builder {
+	b.p.Allow = append(b.p.Allow, rules...)
+	return b
+}
+
+// When limits the policy to matching requests.
+func (b *Builder) When(c *Condition) *Builder {
+	b.p.When = c
+	return b
+}
+
+// Decision sets what happens on a match.
+func (b *Builder) Decision(d Decision) *Builder {
+	b.p.Decision = d
+	return b
+}
+
+// Severity sets how serious a violation is.
+func (b *Builder) Severity(s Severity) *Builder {
+	b.p.Severity = s
+	return b
+}
+
+// Locked stops local allow policies from overriding the policy.
+func (b *Builder) Locked() *Builder {
+	b.p.Locked = true
+	return b
+}
+
+// Build validates the policy and returns a copy of it.
+func (b *Builder) Build() (*Policy, error) {
+	p := b.p
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that a policy is complete, its options are known
+// values and its file patterns are globs, joining every problem found.
+// Whether its regexes are safe for both engines is checked when the
+// policy is compiled or locked.
+func (p *Policy) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("policy %q: "+format, append([]any{p.Description}, args...)...))
+	}
+
+	if p.Description == "" {
+		fail("needs a description")
+	}
+	switch p.Action {
+	case ActionDelete, ActionModify, ActionExecute, ActionRead, ActionCreate, ActionRename, ActionMove, ActionCopy:
+	default:
+		fail("unknown action %q (want read, create, modify, rename, move, copy, delete or execute)", p.Action)
+	}
+	switch p.Decision {
+	case "", DecisionDeny, DecisionAsk, DecisionWarn, DecisionAllow:
+	default:
+		fail("unknown decision %q (want deny, ask, warn or allow)", p.Decision)
+	}
+	if p.Severity != "" && p.Severity.Rank() == 0 {
+		fail("unknown severity %q (want low, medium, high or critical)", p.Severity)
+	}
+	switch p.Enforce {
+	case "", EnforceOn, EnforceMonitor:
+	default:
+		fail("unknown enforce %q (want enforce or monitor)", p.Enforce)
+	}
+	if p.Rollout < 0 || p.Rollout > 100 {
+		fail("rollout %d is not a percentage", p.Rollout)
+	}
+
+	if len(p.Include) == 0 && len(p.CommandRules) == 0 && len(p.ContentRules) == 0 &&
+		len(p.EnvRules) == 0 && len(p.HeaderRules) == 0 && len(p.ASTRules) == 0 &&
+		len(p.StructureRules) == 0 && len(p.ShrinkRules) == 0 && p.MaxFiles == 0 && p.Decision != DecisionAllow {
+		fail("has no rules")
+	}
+	for _, pattern := range p.Include {
+		if err := checkGlob(pattern); err != nil {
+			fail("include %v", err)
+		}
+	}
+	for _, pattern := range p.Exclude {
+		if err := checkGlob(pattern); err != nil {
+			fail("exclude %v", err)
+		}
+	}
+	for i, r := range p.CommandRules {
+		if len(r.Block) == 0 {
+			fail("command rule %d has no patterns", i+1)
+		}
+	}
+	for i, r := range p.ContentRules {
+		if r.Pattern == "" {
+			fail("content rule %d has no pattern", i+1)
+		}
+	}
+	for i, r := range p.StructureRules {
+		if r.MaxLines < 0 || r.MaxFunctionLines < 0 || r.HeaderLines < 0 {
+			fail("structure rule %d has a negative limit", i+1)
+		}
+		if r.MaxLines == 0 && r.MaxFunctionLines == 0 && r.RequireHeader == "" {
+			fail("structure rule %d has no limits or header", i+1)
+		}
+	}
+	for i, r := range p.ShrinkRules {
+		if r.MaxPercent < 1 || r.MaxPercent > 99 {
+			fail("shrink rule %d maxPercent %d is not between 1 and 99", i+1, r.MaxPercent)
+		}
+	}
+	for i, r := range p.EnvRules {
+		if len(r.Names) == 0 {
+			fail("env rule %d has no variable names", i+1)
+		}
+	}
+	for i, r := range p.Allow {
+		if len(r.Commands) == 0 && len(r.Paths) == 0 {
+			fail("allow rule %d needs commands or paths", i+1)
+		}
+		for _, pattern := range r.Paths {
+			if err := checkGlob(pattern); err != nil {
+				fail("allow rule %d path %v", i+1, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkGlob reports a file pattern that is empty or not a glob.
+func checkGlob(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("pattern is empty")
+	}
+	if _, err := glob.Compile(pattern, '/'); err != nil {
+		return fmt.Errorf("pattern %q: %v", pattern, err)
+	}
+	return nil
+}
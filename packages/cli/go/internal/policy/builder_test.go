@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	p, err := NewBuilder("Keep migrations immutable").
+		Protect("db/migrations/**").
+		Except("db/migrations/README.md").
+		Commands(NewCommandRule("migrations are applied", "rm db/migrations/*")).
+		Severity(SeverityHigh).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Action != ActionModify || len(p.Include) != 1 || len(p.CommandRules) != 1 || p.Severity != SeverityHigh {
+		t.Errorf("Build() = %+v", p)
+	}
+
+	// Build returns a copy, so the builder can go on to build another
+	b := NewBuilder("No sudo").Commands(NewCommandRule("no root", "sudo *"))
+	first, _ := b.Build()
+	second, err := b.Action(ActionExecute).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Action != ActionModify || second.Action != ActionExecute {
+		t.Errorf("actions = %s, %s, want modify, execute", first.Action, second.Action)
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *Builder
+		want string
+	}{
+		{"no description", NewBuilder("").Protect(".env"), "needs a description"},
+		{"no rules", NewBuilder("Nothing"), "has no rules"},
+		{"empty include", NewBuilder("Blank").Protect(""), "include pattern is empty"},
+		{"bad include glob", NewBuilder("Bad").Protect("src/[abc"), `include pattern "src/[abc"`},
+		{"bad exclude glob", NewBuilder("Bad").Protect("src/**").Except("src/[x"), `exclude pattern "src/[x"`},
+		{"bad allow path", NewBuilder("Bad").Protect("src/**").Allow(AllowRule{Paths: []string{"[a"}}), `allow rule 1 path pattern "[a"`},
+		{"unknown action", NewBuilder("Odd").Protect(".env").Action("erase"), `unknown action "erase"`},
+		{"unknown decision", NewBuilder("Odd").Protect(".env").Decision("maybe"), `unknown decision "maybe"`},
+		{"unknown severity", NewBuilder("Odd").Protect(".env").Severity("dire"), `unknown severity "dire"`},
+		{"command rule without patterns", NewBuilder("Empty").Commands(NewCommandRule("why")), "command rule 1 has no patterns"},
+		{"content rule without pattern", NewBuilder("Empty").Content(NewContentRule("", "why")), "content rule 1 has no pattern"},
+		{"env rule without names", NewBuilder("Empty").Env(NewEnvRule("why")), "env rule 1 has no variable names"},
+		{"allow rule without commands or paths", NewBuilder("Empty").Protect(".env").Allow(AllowRule{}), "allow rule 1 needs commands or paths"},
+	}
+	for _, tt := range tests {
+		p, err := tt.b.Build()
+		if err == nil {
+			t.Errorf("%s: Build() = %+v, want an error", tt.name, p)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Every problem is reported, not just the first
+	_, err := NewBuilder("").Action("erase").Build()
+	for _, want := range []string{"needs a description", "unknown action", "has no rules"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v, want it to mention %q", err, want)
+		}
+	}
+}
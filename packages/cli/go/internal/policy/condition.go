@@ -0,0 +1,42 @@
+package policy
+
+import "path"
+
+// Condition limits a policy to requests made in a particular context.
+// Each non-empty field must match; values may use shell wildcards
+// (e.g., "claude-*").
+type Condition struct {
+	// Agent IDs the policy applies to
+	Agents []string `json:"agent,omitempty" yaml:"agent,omitempty"`
+	// Model names the policy applies to
+	Models []string `json:"model,omitempty" yaml:"model,omitempty"`
+	// OS users the policy applies to
+	Users []string `json:"user,omitempty" yaml:"user,omitempty"`
+	// Session IDs the policy applies to
+	Sessions []string `json:"session,omitempty" yaml:"session,omitempty"`
+}
+
+// Matches reports whether the request's context satisfies the condition.
+func (c *Condition) Matches(req *CheckRequest) bool {
+	if c == nil {
+		return true
+	}
+	return matchAny(c.Agents, req.Agent) &&
+		matchAny(c.Models, req.Model) &&
+		matchAny(c.Users, req.User) &&
+		matchAny(c.Sessions, req.SessionID)
+}
+
+// matchAny reports whether value matches one of patterns.
+// An empty pattern list matches everything.
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
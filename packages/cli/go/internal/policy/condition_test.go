@@ -0,0 +1,32 @@
+package policy
+
+import "testing"
+
+func TestConditionMatches(t *testing.T) {
+	req := &CheckRequest{Agent: "claude-code", Model: "gpt-4o", User: "ci", SessionID: "abc"}
+
+	tests := []struct {
+		name string
+		when *Condition
+		want bool
+	}{
+		{"nil condition", nil, true},
+		{"empty condition", &Condition{}, true},
+		{"agent listed", &Condition{Agents: []string{"cursor", "claude-code"}}, true},
+		{"agent not listed", &Condition{Agents: []string{"cursor"}}, false},
+		{"model wildcard", &Condition{Models: []string{"gpt-*"}}, true},
+		{"model wildcard misses", &Condition{Models: []string{"claude-*"}}, false},
+		{"every field must match", &Condition{Agents: []string{"claude-code"}, Users: []string{"alice"}}, false},
+		{"all fields match", &Condition{Agents: []string{"claude-*"}, Users: []string{"ci"}, Sessions: []string{"abc"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.when.Matches(req); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A condition on a field the request leaves empty does not match
+	if (&Condition{Agents: []string{"cursor"}}).Matches(&CheckRequest{}) {
+		t.Error("agent condition matched a request without an agent")
+	}
+}
@@ -0,0 +1,105 @@
+package policy
+
+import "fmt"
+
+// Expectations a Test can make of the outcome of its request.
+const (
+	// ExpectBlock passes when the request isn't allowed (deny or ask)
+	ExpectBlock = "block"
+	// ExpectAllow passes when the request is allowed, warnings included
+	ExpectAllow = "allow"
+)
+
+// Test is an example shipped alongside policies: a request and what the
+// policies are expected to do with it, so a set of policies can prove its
+// behavior on whichever veto version loads it. In YAML:
+//
+//	tests:
+//	  - command: npm i lodash
+//	    expect: block
+//	  - file: src/app.ts
+//	    content: "console.log('x')"
+//	    expect: warn
+type Test struct {
+	// Command is a shell command to check
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+	// File is a path to check, relative to the project root
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	// Content is the new content written to File
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Action on File (default modify)
+	Action Action `json:"action,omitempty" yaml:"action,omitempty"`
+	// To is File's new path when Action is rename or move, or where it
+	// is copied to when Action is copy
+	To string `json:"to,omitempty" yaml:"to,omitempty"`
+	// Expect is block, allow, or the exact decision (deny, ask, warn)
+	Expect string `json:"expect" yaml:"expect"`
+}
+
+// Request returns the check request the test describes.
+func (t Test) Request() *CheckRequest {
+	if t.Command != "" {
+		return &CheckRequest{Action: string(ActionExecute), Command: t.Command}
+	}
+	action := t.Action
+	if action == "" {
+		action = ActionModify
+	}
+	return &CheckRequest{Action: string(action), Target: t.File, Destination: t.To, Content: t.Content}
+}
+
+// Name describes the test's request in messages.
+func (t Test) Name() string {
+	if t.Command != "" {
+		return "command `" + t.Command + "`"
+	}
+	if t.To != "" {
+		return string(t.Action) + " " + t.File + " to " + t.To
+	}
+	if t.Action != "" && t.Action != ActionModify {
+		return string(t.Action) + " " + t.File
+	}
+	return "modify " + t.File
+}
+
+// Validate checks the test describes exactly one request and a known
+// expectation.
+func (t Test) Validate() error {
+	if (t.Command == "") == (t.File == "") {
+		return fmt.Errorf("test needs either command or file")
+	}
+	if t.To != "" && t.Action != ActionRename && t.Action != ActionMove && t.Action != ActionCopy {
+		return fmt.Errorf("%s: to needs action rename, move or copy", t.Name())
+	}
+	switch Decision(t.Expect) {
+	case ExpectBlock, ExpectAllow, DecisionDeny, DecisionAsk, DecisionWarn:
+	default:
+		return fmt.Errorf("%s: unknown expect %q (want block, allow, deny, ask or warn)", t.Name(), t.Expect)
+	}
+	return nil
+}
+
+// Verdict reports what a result did, in the terms Expect uses: allow,
+// warn, ask or deny.
+func Verdict(r *CheckResult) string {
+	switch {
+	case r.Decision == DecisionWarn:
+		return string(DecisionWarn)
+	case r.Allowed:
+		return ExpectAllow
+	case r.Decision == DecisionAsk:
+		return string(DecisionAsk)
+	}
+	return string(DecisionDeny)
+}
+
+// Passes reports whether a result meets the test's expectation.
+func (t Test) Passes(r *CheckResult) bool {
+	switch t.Expect {
+	case ExpectBlock:
+		return !r.Allowed
+	case ExpectAllow:
+		return r.Allowed
+	}
+	return Verdict(r) == t.Expect
+}
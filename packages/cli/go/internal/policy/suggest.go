@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Alternative is a command an agent can run in place of a blocked one.
+// Hook replies pass alternatives on as data, so an agent can run one
+// without parsing the block message.
+type Alternative struct {
+	// Command template; "{args}" is replaced by the blocked command's
+	// arguments (e.g., "pnpm add {args}")
+	Command string `json:"command" yaml:"command"`
+	// Description says what the command does
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Safety says what to check before or after running it
+	Safety string `json:"safety,omitempty" yaml:"safety,omitempty"`
+}
+
+// Suggestion returns the rule's suggestion as text: Suggest, or the
+// commands of its alternatives when it only has those, or its rewrite.
+func (r CommandRule) Suggestion() string {
+	if r.Suggest == "" && len(r.Alternatives) == 0 {
+		return strings.TrimSpace(strings.ReplaceAll(r.Rewrite, "{args}", ""))
+	}
+	if r.Suggest != "" {
+		return r.Suggest
+	}
+	commands := make([]string, len(r.Alternatives))
+	for i, a := range r.Alternatives {
+		commands[i] = strings.ReplaceAll(a.Command, " {args}", "")
+	}
+	return strings.Join(commands, " or ")
+}
+
+// UnmarshalJSON reads suggest as text, as one alternative or as a list
+// of them (commands or alternatives), adding the structured forms to
+// Alternatives.
+func (r *CommandRule) UnmarshalJSON(data []byte) error {
+	type plain CommandRule
+	var raw struct {
+		plain
+		Suggest json.RawMessage `json:"suggest,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = CommandRule(raw.plain)
+	if len(raw.Suggest) == 0 || string(raw.Suggest) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw.Suggest, &r.Suggest); err == nil {
+		return nil
+	}
+	var items []json.RawMessage
+	if raw.Suggest[0] != '[' {
+		items = []json.RawMessage{raw.Suggest}
+	} else if err := json.Unmarshal(raw.Suggest, &items); err != nil {
+		return fmt.Errorf("suggest: %w", err)
+	}
+	for _, item := range items {
+		var a Alternative
+		if err := json.Unmarshal(item, &a.Command); err != nil {
+			if err := json.Unmarshal(item, &a); err != nil {
+				return fmt.Errorf("suggest: want text, a command or an alternative with a command: %w", err)
+			}
+		}
+		r.Alternatives = append(r.Alternatives, a)
+	}
+	return nil
+}
+
+// UnmarshalYAML reads suggest in the same forms as UnmarshalJSON.
+func (r *CommandRule) UnmarshalYAML(n *yaml.Node) error {
+	var suggest *yaml.Node
+	if n.Kind == yaml.MappingNode {
+		// Decode everything else as written
+		rest := *n
+		rest.Content = nil
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == "suggest" {
+				suggest = n.Content[i+1]
+				continue
+			}
+			rest.Content = append(rest.Content, n.Content[i], n.Content[i+1])
+		}
+		n = &rest
+	}
+	type plain CommandRule
+	if err := n.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	if suggest == nil {
+		return nil
+	}
+	switch suggest.Kind {
+	case yaml.ScalarNode:
+		r.Suggest = suggest.Value
+		return nil
+	case yaml.MappingNode:
+		return r.addAlternative(suggest)
+	case yaml.SequenceNode:
+		for _, item := range suggest.Content {
+			if err := r.addAlternative(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: suggest: want text, a command or an alternative with a command", suggest.Line)
+}
+
+// addAlternative adds an alternative written as a command or a mapping.
+func (r *CommandRule) addAlternative(n *yaml.Node) error {
+	var a Alternative
+	if n.Kind == yaml.ScalarNode {
+		a.Command = n.Value
+	} else if err := n.Decode(&a); err != nil {
+		return fmt.Errorf("line %d: suggest: %w", n.Line, err)
+	}
+	r.Alternatives = append(r.Alternatives, a)
+	return nil
+}
@@ -1,6 +1,8 @@
 // Package policy defines core domain types for veto policies.
 package policy
 
+import "strconv"
+
 // Action represents what type of operation a policy applies to.
 type Action string
 
@@ -9,16 +11,150 @@ const (
 	ActionModify  Action = "modify"
 	ActionExecute Action = "execute"
 	ActionRead    Action = "read"
+	// ActionCreate writes a file that doesn't exist yet
+	ActionCreate Action = "create"
+	// ActionRename gives a file a new name in the same directory
+	ActionRename Action = "rename"
+	// ActionMove puts a file in another directory
+	ActionMove Action = "move"
+	// ActionCopy writes a file's content somewhere else: a copy, an
+	// archive, or output redirected to a file
+	ActionCopy Action = "copy"
+)
+
+// covers lists the file operations a policy stops on the files it
+// includes, by the policy's action. A policy on an operation also stops
+// the ones that go further: protecting a file from modification protects
+// it from deletion, and a file kept from deletion can't be renamed or
+// moved away either. Copying leaves the file as it is, but puts its
+// content where the policy no longer protects it, so read and modify
+// policies stop copies too (modify policies by asking; see
+// matcher.CheckFile).
+var covers = map[Action][]Action{
+	ActionRead:   {ActionRead, ActionCreate, ActionModify, ActionDelete, ActionRename, ActionMove, ActionCopy},
+	ActionModify: {ActionCreate, ActionModify, ActionDelete, ActionRename, ActionMove, ActionCopy},
+	ActionCreate: {ActionCreate},
+	ActionDelete: {ActionDelete, ActionRename, ActionMove},
+	ActionRename: {ActionRename, ActionMove},
+	ActionMove:   {ActionMove},
+	ActionCopy:   {ActionCopy},
+}
+
+// Covers reports whether a policy with action a stops operation op on
+// the files it includes. Execute policies and policies without an action
+// protect files the way modify does, and an unknown or empty operation
+// is taken to be a modification.
+func (a Action) Covers(op Action) bool {
+	ops, ok := covers[a]
+	if !ok {
+		ops = covers[ActionModify]
+	}
+	if _, known := covers[op]; !known {
+		op = ActionModify
+	}
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Decision is what happens when a policy matches a request.
+type Decision string
+
+const (
+	// DecisionDeny blocks the action (the default)
+	DecisionDeny Decision = "deny"
+	// DecisionAsk requires a human to approve the action
+	DecisionAsk Decision = "ask"
+	// DecisionWarn allows the action but reports the violation
+	DecisionWarn Decision = "warn"
+	// DecisionAllow permits the action
+	DecisionAllow Decision = "allow"
+)
+
+// Severity ranks how serious a violation is.
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Rank orders severities from 0 (unset) to 4 (critical).
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// Enforcement controls whether a matching policy actually blocks.
+type Enforcement string
+
+const (
+	// EnforceOn applies the policy's decision (the default)
+	EnforceOn Enforcement = "enforce"
+	// EnforceMonitor only records would-be blocks
+	EnforceMonitor Enforcement = "monitor"
+)
+
+// Modes a check can run under.
+const (
+	// ModeInteractive assumes a human can answer ask prompts
+	ModeInteractive = "interactive"
+	// ModeUnattended assumes nobody is watching (CI, background agents)
+	ModeUnattended = "unattended"
 )
 
 // CommandRule blocks specific shell commands.
 type CommandRule struct {
 	// Glob patterns for commands to block (e.g., "npm install*")
 	Block []string `json:"block" yaml:"block"`
-	// Suggestion to show user
+	// Suggestion to show user. In YAML and JSON it may also be written as
+	// an alternative or a list of them, which go in Alternatives.
 	Suggest string `json:"suggest,omitempty" yaml:"suggest,omitempty"`
+	// Alternatives are commands the agent can run instead, offered in
+	// hook replies as data (see Alternative)
+	Alternatives []Alternative `json:"alternatives,omitempty" yaml:"alternatives,omitempty"`
 	// Human-readable reason
 	Reason string `json:"reason" yaml:"reason"`
+	// RunInstead is a command veto may run in place of a blocked one when
+	// the policy has AutoRun; "{args}" is replaced by the blocked
+	// command's arguments (e.g., "docker compose {args}"). It replaces
+	// only that command, so the rest of the line runs as written
+	RunInstead string `json:"runInstead,omitempty" yaml:"runInstead,omitempty"`
+	// Rewrite replaces the words a block pattern matched literally (its
+	// text before any wildcard) within the command line, keeping the
+	// rest of it (e.g., "pnpm install" for "npm install*"). With AutoRun
+	// the rewritten line runs in place of the blocked one; without it,
+	// it is suggested.
+	Rewrite string `json:"rewrite,omitempty" yaml:"rewrite,omitempty"`
+	// AllowHosts lets blocked commands through when every URL or host
+	// they name matches one of these globs (e.g., "github.com" for
+	// "git remote add *")
+	AllowHosts []string `json:"allowHosts,omitempty" yaml:"allowHosts,omitempty"`
+}
+
+// AllowRule permits actions a policy would otherwise block. When both
+// Commands and Paths are set, both must match.
+type AllowRule struct {
+	// Glob patterns for commands to permit (e.g., "npm install*")
+	Commands []string `json:"commands,omitempty" yaml:"commands,omitempty"`
+	// Glob patterns for files, or for the working directory of commands
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	// Human-readable reason for the exception
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
 }
 
 // ContentRule matches patterns within file contents.
@@ -27,6 +163,11 @@ type ContentRule struct {
 	Pattern string `json:"pattern" yaml:"pattern"`
 	// File patterns where this applies (e.g., "*.ts")
 	FileTypes []string `json:"fileTypes" yaml:"fileTypes"`
+	// Path globs limiting the rule to part of the tree (e.g., "src/**");
+	// empty for everywhere
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Path globs the rule never applies to (e.g., "scripts/**")
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 	// Human-readable reason
 	Reason string `json:"reason" yaml:"reason"`
 	// Suggestion for alternative
@@ -37,6 +178,76 @@ type ContentRule struct {
 	Exceptions []string `json:"exceptions,omitempty" yaml:"exceptions,omitempty"`
 }
 
+// EnvRule keeps environment variables off command lines, so agents can't
+// echo, export or send them.
+type EnvRule struct {
+	// Glob patterns for protected variable names (e.g., "*_TOKEN")
+	Names []string `json:"names" yaml:"names"`
+	// Hosts network commands may send protected variables to (glob)
+	AllowHosts []string `json:"allowHosts,omitempty" yaml:"allowHosts,omitempty"`
+	// Human-readable reason
+	Reason string `json:"reason" yaml:"reason"`
+	// Suggestion for alternative
+	Suggest string `json:"suggest,omitempty" yaml:"suggest,omitempty"`
+}
+
+// HeaderRule blocks changes to files whose opening lines on disk carry a
+// marker, such as the "Code generated ... DO NOT EDIT." line generators
+// write.
+type HeaderRule struct {
+	// Markers to look for (plain substrings, case-sensitive)
+	Markers []string `json:"markers" yaml:"markers"`
+	// How many lines from the top of the file to search
+	Lines int `json:"lines" yaml:"lines"`
+	// Human-readable reason
+	Reason string `json:"reason" yaml:"reason"`
+	// Suggestion for alternative
+	Suggest string `json:"suggest,omitempty" yaml:"suggest,omitempty"`
+}
+
+// CommitMessage is the pseudo file name commit messages are checked as,
+// after the file git writes them to. Content and structure rules apply
+// to the messages agents pass to git commit only when their fileTypes
+// name it, so rules for code don't trip on messages describing it.
+const CommitMessage = "COMMIT_EDITMSG"
+
+// StructureRule limits the shape of the files agents write, for agents
+// that put everything into one huge file or function.
+type StructureRule struct {
+	// File patterns where this applies (e.g., "*.go"; all files when empty)
+	FileTypes []string `json:"fileTypes,omitempty" yaml:"fileTypes,omitempty"`
+	// Most lines the content written may have (0 = no limit)
+	MaxLines int `json:"maxLines,omitempty" yaml:"maxLines,omitempty"`
+	// Most lines a function in it may span (0 = no limit)
+	MaxFunctionLines int `json:"maxFunctionLines,omitempty" yaml:"maxFunctionLines,omitempty"`
+	// Regex the opening lines of a new file must match, such as a
+	// license or ownership comment
+	RequireHeader string `json:"requireHeader,omitempty" yaml:"requireHeader,omitempty"`
+	// How many lines from the top RequireHeader may match in (default 10)
+	HeaderLines int `json:"headerLines,omitempty" yaml:"headerLines,omitempty"`
+	// Human-readable reason
+	Reason string `json:"reason" yaml:"reason"`
+	// Suggestion for alternative
+	Suggest string `json:"suggest,omitempty" yaml:"suggest,omitempty"`
+}
+
+// ShrinkRule keeps writes from truncating files, such as data files an
+// agent regenerates from a sample or rewrites from the rows it read, by
+// how much of the file on disk a write would remove.
+type ShrinkRule struct {
+	// File patterns where this applies (e.g., "*.csv"; all files when empty)
+	FileTypes []string `json:"fileTypes,omitempty" yaml:"fileTypes,omitempty"`
+	// Path globs limiting the rule to part of the tree (e.g.,
+	// "**/fixtures/**"); empty for everywhere
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Largest share of the file, in percent (1-99), a write may remove
+	MaxPercent int `json:"maxPercent" yaml:"maxPercent"`
+	// Human-readable reason
+	Reason string `json:"reason" yaml:"reason"`
+	// Suggestion for alternative
+	Suggest string `json:"suggest,omitempty" yaml:"suggest,omitempty"`
+}
+
 // ASTRule uses tree-sitter queries for precise pattern matching.
 type ASTRule struct {
 	// Unique identifier
@@ -58,17 +269,63 @@ type Policy struct {
 	// What action this policy applies to
 	Action Action `json:"action" yaml:"action"`
 	// File patterns to protect (glob)
-	Include []string `json:"include" yaml:"include"`
+	Include []string `json:"include" yaml:"include,omitempty"`
 	// File patterns to allow (exceptions)
-	Exclude []string `json:"exclude" yaml:"exclude"`
+	Exclude []string `json:"exclude" yaml:"exclude,omitempty"`
 	// Human-readable description
 	Description string `json:"description" yaml:"description"`
 	// Command-level rules
 	CommandRules []CommandRule `json:"commandRules,omitempty" yaml:"commandRules,omitempty"`
 	// Content-level rules (regex-based)
 	ContentRules []ContentRule `json:"contentRules,omitempty" yaml:"contentRules,omitempty"`
+	// Protected environment variables
+	EnvRules []EnvRule `json:"envRules,omitempty" yaml:"envRules,omitempty"`
+	// Rules on the existing file's header (e.g., generated code markers)
+	HeaderRules []HeaderRule `json:"headerRules,omitempty" yaml:"headerRules,omitempty"`
 	// AST-based rules (tree-sitter)
 	ASTRules []ASTRule `json:"astRules,omitempty" yaml:"astRules,omitempty"`
+	// Limits on file and function length, and required headers
+	StructureRules []StructureRule `json:"structureRules,omitempty" yaml:"structureRules,omitempty"`
+	// Limits on how much of a file a write may remove
+	ShrinkRules []ShrinkRule `json:"shrinkRules,omitempty" yaml:"shrinkRules,omitempty"`
+	// Exceptions evaluated before any blocking rule, or for an allowlist
+	// the only files and commands permitted
+	Allow []AllowRule `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// Allowlist inverts the policy: within its action and its include
+	// patterns (every file when there are none), only what its allow rules
+	// list is permitted. Allow rules with paths and no commands list
+	// files; those with commands list commands, in the working
+	// directories their paths match if they have any.
+	Allowlist bool `json:"allowlist,omitempty" yaml:"allowlist,omitempty"`
+	// Request context this policy is limited to (nil = always applies)
+	When *Condition `json:"when,omitempty" yaml:"when,omitempty"`
+	// What happens on a match (default deny)
+	Decision Decision `json:"decision,omitempty" yaml:"decision,omitempty"`
+	// How serious a violation is
+	Severity Severity `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// Whether matches are enforced or only monitored
+	Enforce Enforcement `json:"enforce,omitempty" yaml:"enforce,omitempty"`
+	// Percentage (1-100) of sessions that enforce; the rest monitor (0 = all)
+	Rollout int `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+	// Locked policies can't be overridden by local allow policies
+	Locked bool `json:"locked,omitempty" yaml:"locked,omitempty"`
+	// BlockOpaque blocks commands whose effect can't be determined
+	// statically, such as eval of a variable or an undecodable payload
+	// piped to a shell
+	BlockOpaque bool `json:"blockOpaque,omitempty" yaml:"blockOpaque,omitempty"`
+	// MaxFiles matches once a session has modified or deleted more than
+	// this many distinct files (0 = no budget)
+	MaxFiles int `json:"maxFiles,omitempty" yaml:"maxFiles,omitempty"`
+	// TestCommand is a quick test run after each agent edit to catch
+	// tests that start failing or get skipped
+	TestCommand string `json:"testCommand,omitempty" yaml:"testCommand,omitempty"`
+	// AutoRun approves running command rules' RunInstead commands, and
+	// their rewritten command lines, in place of the commands they block
+	AutoRun bool `json:"autoRun,omitempty" yaml:"autoRun,omitempty"`
+	// Dir limits the policy to a directory, slash-separated and relative
+	// to the project root, whose paths its patterns are relative to ("" for
+	// the whole project). Policies from a nested .veto get its directory.
+	Dir string `json:"dir,omitempty" yaml:"-"`
 }
 
 // CheckRequest represents an action to validate.
@@ -77,6 +334,59 @@ type CheckRequest struct {
 	Target  string `json:"target"`
 	Command string `json:"command,omitempty"`
 	Content string `json:"content,omitempty"`
+	// Destination is the new path of a renamed or moved target, or where
+	// a copied one is copied to
+	Destination string `json:"destination,omitempty"`
+	// Working directory of the agent, used to resolve relative targets
+	Cwd string `json:"cwd,omitempty"`
+
+	// Optional context populated by hook adapters
+	Agent     string `json:"agent,omitempty"`
+	Model     string `json:"model,omitempty"`
+	SessionID string `json:"sessionId,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	User      string `json:"user,omitempty"`
+	// Mode the agent runs under (interactive or unattended)
+	Mode string `json:"mode,omitempty"`
+
+	// Header is the start of the target file as it is on disk, read by
+	// whoever resolves the project when a policy has header rules
+	Header string `json:"-"`
+	// Resize is how a write changes the target's size, from hooks whose
+	// tools tell (nil when unknown)
+	Resize *Resize `json:"resize,omitempty"`
+	// Size is the target's size on disk, read by whoever resolves the
+	// project when a policy has shrink rules
+	Size int64 `json:"-"`
+	// SessionFiles is how many distinct files the session will have
+	// changed if this request goes ahead, counted by the daemon
+	SessionFiles int `json:"-"`
+}
+
+// Resize is how a write changes a file's size. A write of the whole file
+// replaces whatever it held with Added bytes; an edit swaps Removed bytes
+// for Added ones. Done is set when hooks report the write after it
+// happened, so the file on disk already has its new size.
+type Resize struct {
+	Whole   bool  `json:"whole,omitempty"`
+	Removed int64 `json:"removed,omitempty"`
+	Added   int64 `json:"added"`
+	Done    bool  `json:"done,omitempty"`
+}
+
+// Sizes returns a file's size before and after the write from its size
+// on disk. ok is false when they can't be told: a whole file written
+// already leaves no trace of what it replaced.
+func (r *Resize) Sizes(disk int64) (before, after int64, ok bool) {
+	switch {
+	case r.Whole && r.Done:
+		return 0, 0, false
+	case r.Whole:
+		return disk, r.Added, true
+	case r.Done:
+		return disk - r.Added + r.Removed, disk, true
+	}
+	return disk, max(disk-r.Removed+r.Added, 0), true
 }
 
 // CheckResult is the outcome of policy validation.
@@ -84,4 +394,88 @@ type CheckResult struct {
 	Allowed bool   `json:"allowed"`
 	Reason  string `json:"reason,omitempty"`
 	Suggest string `json:"suggest,omitempty"`
+	// Alternatives are the blocking command rule's alternatives, with
+	// "{args}" expanded for the blocked command
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+	// RunInstead is the blocked command line with the matching rule's
+	// RunInstead in place of the command it blocked, approved to run
+	RunInstead string `json:"runInstead,omitempty"`
+	// Rewrite is the blocked command line with the matching rule's
+	// Rewrite applied, approved to run in its place
+	Rewrite string `json:"rewrite,omitempty"`
+	// Decision taken by the matching policy (empty when nothing matched).
+	// A matcher's own result may set ask to soften the policy's deny.
+	Decision Decision `json:"decision,omitempty"`
+	// Description of the matching policy
+	Policy string `json:"policy,omitempty"`
+	// Rule identifies the policy's rule that matched (see RuleID)
+	Rule string `json:"rule,omitempty"`
+	// Monitor-mode policies that would have acted on this request
+	Monitored []MonitorHit `json:"monitored,omitempty"`
+	// Every policy that matched and how the conflict was resolved
+	Trace []TraceStep `json:"trace,omitempty"`
+	// Hints lists, as Markdown, the policies that concern the file an
+	// allowed request touched, for agents that take extra context
+	Hints string `json:"hints,omitempty"`
+}
+
+// Outcome describes what happened to a matching policy during resolution.
+type Outcome string
+
+const (
+	// OutcomeApplied is the policy that determined the result
+	OutcomeApplied Outcome = "applied"
+	// OutcomeOutranked lost to a more severe match
+	OutcomeOutranked Outcome = "outranked"
+	// OutcomeOverridden was cancelled by an explicit allow
+	OutcomeOverridden Outcome = "overridden"
+	// OutcomeMonitored matched in monitor mode and did not act
+	OutcomeMonitored Outcome = "monitored"
+	// OutcomeSkipped was not evaluated because its When condition failed,
+	// the request was outside its directory or a nearer .veto overrides it
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// TraceStep is one entry in a result's decision chain.
+type TraceStep struct {
+	Policy   string   `json:"policy"`
+	Decision Decision `json:"decision,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+	Locked   bool     `json:"locked,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Outcome  Outcome  `json:"outcome"`
+}
+
+// MonitorHit records a match by a policy in monitor mode.
+type MonitorHit struct {
+	Policy   string   `json:"policy"`
+	Reason   string   `json:"reason,omitempty"`
+	Rule     string   `json:"rule,omitempty"`
+	Decision Decision `json:"decision"`
+}
+
+// Rule kinds in rule IDs, named after the policy fields they index.
+const (
+	RuleInclude   = "include"
+	RuleCommand   = "commandRules"
+	RuleContent   = "contentRules"
+	RuleEnv       = "envRules"
+	RuleHeader    = "headerRules"
+	RuleAST       = "astRules"
+	RuleStructure = "structureRules"
+	RuleShrink    = "shrinkRules"
+	// RuleMaxFiles, RuleBlockOpaque and RuleAllowlist are single
+	// settings, not lists
+	RuleMaxFiles    = "maxFiles"
+	RuleBlockOpaque = "blockOpaque"
+	RuleAllowlist   = "allowlist"
+)
+
+// RuleID identifies a rule within a policy by the field it's in and its
+// index there, counting from 0: "commandRules[1]", or "include[0]" for
+// the first include pattern. The index follows the order rules are
+// written in, so IDs don't change between runs.
+func RuleID(kind string, index int) string {
+	return kind + "[" + strconv.Itoa(index) + "]"
 }
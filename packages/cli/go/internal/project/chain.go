@@ -0,0 +1,95 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// chainOf returns the .veto files merged into the project governed by
+// configPath, outermost first and ending with configPath: in a monorepo,
+// those in its parent directories up to the repository root (see
+// config.FindChain). The outermost file's directory is the project root.
+func chainOf(configPath string) []string {
+	if filepath.Base(configPath) != ".veto" {
+		return []string{configPath}
+	}
+	chain, err := config.FindChain(filepath.Dir(configPath))
+	if err != nil || chain[len(chain)-1] != configPath {
+		return []string{configPath}
+	}
+	return chain
+}
+
+// layer is one .veto file in a project's chain.
+type layer struct {
+	path string
+	cfg  *config.VetoConfig
+	mod  time.Time
+}
+
+// loadChain reads the .veto files in a chain.
+func loadChain(chain []string) ([]layer, error) {
+	var layers []layer
+	for _, path := range chain {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer{path: path, cfg: cfg, mod: info.ModTime()})
+	}
+	return layers, nil
+}
+
+// compileChain compiles each layer's policies with its own lock file and
+// packs, in chain order, limiting those of nested .veto files to their
+// directories (see policy.Policy.Dir).
+func compileChain(layers []layer, root string) ([]*policy.Policy, error) {
+	var policies []*policy.Policy
+	for _, l := range layers {
+		dir := filepath.Dir(l.path)
+		lf, err := lock.Read(lock.Path(dir))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		compiled, err := CompileWithPacks(l.cfg, lf, dir)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range compiled {
+			if rel != "." {
+				scoped := *p
+				scoped.Dir = filepath.ToSlash(rel)
+				p = &scoped
+			}
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+// chainMods returns the modification times of the .veto files in chain
+// but the last, zero for those that are gone.
+func chainMods(chain []string) []time.Time {
+	var mods []time.Time
+	for _, path := range chain[:len(chain)-1] {
+		var mod time.Time
+		if info, err := os.Stat(path); err == nil {
+			mod = info.ModTime()
+		}
+		mods = append(mods, mod)
+	}
+	return mods
+}
@@ -0,0 +1,85 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestMonorepoChain(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	t.Setenv(EnvVar, "")
+	root := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, ".veto"), "version: 1\npolicies:\n  - protect .env\n")
+	api := filepath.Join(root, "packages", "api")
+	writeFile(t, filepath.Join(api, ".veto"), `version: 2
+policies:
+  - policy: protect .env
+    decision: allow
+rules:
+  - description: keep migrations
+    action: delete
+    include: ["migrations/**"]
+  - description: no direct kubectl
+    action: execute
+    commandRules:
+      - block: ["kubectl apply*"]
+`)
+
+	p, err := Resolve(filepath.Join(api, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != root || p.ConfigPath != filepath.Join(api, ".veto") {
+		t.Fatalf("root = %s, config = %s; want the repo's root and the api's .veto", p.Root, p.ConfigPath)
+	}
+	check := func(action, target string) *policy.CheckResult {
+		return p.Check(&policy.CheckRequest{Action: action, Target: filepath.Join(root, target)})
+	}
+	if r := check("modify", ".env"); r.Allowed {
+		t.Error("the root's .env lost its protection")
+	}
+	if r := check("modify", "packages/api/.env"); !r.Allowed {
+		t.Errorf("the api's .veto didn't override protect .env in its directory: %s", r.Reason)
+	}
+	if r := check("delete", "packages/api/migrations/001.sql"); r.Allowed {
+		t.Error("the api's rule didn't apply in its directory")
+	}
+	if r := check("delete", "migrations/001.sql"); !r.Allowed {
+		t.Errorf("the api's rule applied outside its directory: %s", r.Reason)
+	}
+	deploy := &policy.CheckRequest{Action: "execute", Command: "kubectl apply -f k8s.yaml", Cwd: api}
+	if r := p.Check(deploy); r.Allowed {
+		t.Error("a command run in the api's directory escaped its rule")
+	}
+	deploy.Cwd = root
+	if r := p.Check(deploy); !r.Allowed {
+		t.Errorf("the api's command rule applied at the root: %s", r.Reason)
+	}
+
+	// Parent .veto files are watched too
+	writeFile(t, filepath.Join(root, ".veto"), "version: 1\npolicies:\n  - protect .env\n  - protect lock files\n")
+	if !p.Stale() {
+		t.Error("changing the root's .veto didn't make the api stale")
+	}
+
+	// Outside a repository a nested .veto stands alone
+	if err := os.Remove(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Stale() {
+		t.Error("leaving the repository didn't make the api stale")
+	}
+	if p, err = Resolve(api); err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != api {
+		t.Errorf("root = %s, want %s", p.Root, api)
+	}
+}
@@ -0,0 +1,130 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/readonly"
+)
+
+// EnvVar selects a project's environment, overriding the one veto sync
+// --env recorded. It applies to the process loading the policies, so
+// set it for hooks rather than for a shared daemon.
+const EnvVar = "VETO_ENV"
+
+// envFile is where veto sync --env records the environment, in the
+// project's audit.DirName: a checkout's environment is local, like a
+// deployment repo's prod branch.
+const envFile = "env"
+
+var envRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidEnv reports whether env can name an environment: lower case
+// letters, digits, - and _.
+func ValidEnv(env string) bool {
+	return envRe.MatchString(env)
+}
+
+// OverlayPath returns the policy overlay for env in the project at
+// root: .veto.<env>.yaml, next to .veto.
+func OverlayPath(root, env string) string {
+	return filepath.Join(root, ".veto."+env+".yaml")
+}
+
+// Env returns the environment selected for the project at root, or ""
+// for none.
+func Env(root string) string {
+	if env := os.Getenv(EnvVar); env != "" {
+		return env
+	}
+	data, err := os.ReadFile(filepath.Join(root, audit.DirName, envFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SetEnv records env as the environment of the project at root, or
+// clears it when env is "".
+func SetEnv(root, env string) error {
+	if env == "" {
+		err := readonly.Remove(filepath.Join(root, audit.DirName, envFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !ValidEnv(env) {
+		return fmt.Errorf("environment %q: use lower case letters, digits, - and _", env)
+	}
+	dir, err := audit.DataDir(root)
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(filepath.Join(dir, envFile), []byte(env+"\n"), 0644)
+}
+
+// LoadOverlay reads the overlay for env in the project at root, along
+// with its modification time.
+func LoadOverlay(root, env string) (*config.VetoConfig, time.Time, error) {
+	if !ValidEnv(env) {
+		return nil, time.Time{}, fmt.Errorf("environment %q: use lower case letters, digits, - and _", env)
+	}
+	path := OverlayPath(root, env)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, fmt.Errorf("environment %q: no %s", env, filepath.Base(path))
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return cfg, info.ModTime(), nil
+}
+
+// merge returns base with o, an environment's overlay or a nested .veto,
+// applied: its policies, rules, packs and tests follow the base's, and
+// its mode, agents and audit schedule replace the base's when set.
+// Overlays only add policies, so an environment can be stricter but
+// never looser.
+func merge(base, o *config.VetoConfig) *config.VetoConfig {
+	merged := *base
+	merged.Policies = append([]string(nil), base.Policies...)
+	merged.Entries = append([]config.PolicyEntry(nil), base.Entries...)
+	have := map[string]bool{}
+	for _, p := range base.Policies {
+		have[p] = true
+	}
+	for _, p := range o.Policies {
+		if have[p] {
+			continue
+		}
+		have[p] = true
+		merged.Policies = append(merged.Policies, p)
+		merged.Entries = append(merged.Entries, o.Entry(p))
+	}
+	merged.Rules = append(base.Rules[:len(base.Rules):len(base.Rules)], o.Rules...)
+	merged.Packs = append(base.Packs[:len(base.Packs):len(base.Packs)], o.Packs...)
+	merged.Tests = append(base.Tests[:len(base.Tests):len(base.Tests)], o.Tests...)
+	if o.Mode != "" {
+		merged.Mode = o.Mode
+	}
+	if len(o.Agents) > 0 {
+		merged.Agents = o.Agents
+	}
+	if o.Audit != nil {
+		merged.Audit = o.Audit
+	}
+	return &merged
+}
@@ -0,0 +1,77 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func TestEnvOverlay(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	t.Setenv(EnvVar, "")
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), "version: 1\npolicies:\n  - protect .env\n")
+	writeFile(t, OverlayPath(root, "prod"), `version: 2
+mode: unattended
+rules:
+  - description: no direct kubectl
+    action: execute
+    commandRules:
+      - block: ["kubectl apply*"]
+        reason: deploys go through CI
+`)
+	deploy := &policy.CheckRequest{Action: "execute", Command: "kubectl apply -f app.yaml"}
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := p.Check(deploy); !r.Allowed {
+		t.Fatalf("without an environment kubectl was blocked: %s", r.Reason)
+	}
+
+	if err := SetEnv(root, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Stale() {
+		t.Error("selecting an environment didn't make the project stale")
+	}
+	if p, err = Resolve(root); err != nil {
+		t.Fatal(err)
+	}
+	if p.Env != "prod" || p.Config.Mode != policy.ModeUnattended {
+		t.Errorf("env = %q, mode = %q; want prod's overlay", p.Env, p.Config.Mode)
+	}
+	if r := p.Check(deploy); r.Allowed {
+		t.Error("prod's overlay didn't block kubectl")
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: ".env"}); r.Allowed {
+		t.Error("the overlay dropped .veto's policies")
+	}
+
+	// The environment variable wins, and a missing overlay is an error
+	t.Setenv(EnvVar, "staging")
+	if !p.Stale() {
+		t.Error("VETO_ENV didn't make the project stale")
+	}
+	if _, err := Resolve(root); err == nil || !strings.Contains(err.Error(), ".veto.staging.yaml") {
+		t.Errorf("missing overlay: err = %v", err)
+	}
+	t.Setenv(EnvVar, "")
+
+	if err := SetEnv(root, ""); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = Resolve(root); err != nil {
+		t.Fatal(err)
+	}
+	if r := p.Check(deploy); !r.Allowed || p.Env != "" {
+		t.Errorf("after clearing the environment: env = %q, %+v", p.Env, r)
+	}
+	if err := SetEnv(root, "../prod"); err == nil {
+		t.Error("SetEnv accepted a path")
+	}
+}
@@ -0,0 +1,99 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// machinePath is the machine-wide policy file outside Windows.
+var machinePath = "/etc/veto/policies.yaml"
+
+// MachinePath returns the machine-wide policy file. An administrator on a
+// shared machine lists policies in it, in .veto syntax, and they apply to
+// every user and project, locked so no .veto can override them. A
+// .veto.lock next to it pins their compiled form.
+func MachinePath() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, "veto", "policies.yaml")
+		}
+	}
+	return machinePath
+}
+
+// Find returns the .veto file governing dir or, outside any project, the
+// machine-wide policy file when there is one.
+func Find(dir string) (string, error) {
+	path, err := config.FindFrom(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, serr := os.Stat(MachinePath()); serr == nil {
+			return MachinePath(), nil
+		}
+	}
+	return path, err
+}
+
+// machinePolicies compiles the machine-wide policies, locked, along with
+// the file's modification time. A machine without the file has none.
+func machinePolicies() ([]*policy.Policy, time.Time, error) {
+	path := MachinePath()
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	lf, err := lock.Read(lock.Path(filepath.Dir(path)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, time.Time{}, err
+	}
+
+	policies, err := CompileWithPacks(cfg, lf, filepath.Dir(path))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for _, p := range policies {
+		p.Locked = true
+	}
+	return policies, info.ModTime(), nil
+}
+
+// loadMachine returns a project holding only the machine-wide policies,
+// for paths outside any project. Paths are checked relative to the
+// filesystem root.
+func loadMachine() (*Project, error) {
+	policies, modTime, err := machinePolicies()
+	if err != nil {
+		return nil, err
+	}
+	if policies == nil && modTime.IsZero() {
+		return nil, os.ErrNotExist
+	}
+	set, err := matcher.NewSet(policies)
+	if err != nil {
+		return nil, err
+	}
+	return &Project{
+		Root:       string(filepath.Separator),
+		ConfigPath: MachinePath(),
+		Config:     &config.VetoConfig{},
+		Policies:   policies,
+		Set:        set,
+		modTime:    modTime,
+		machineMod: modTime,
+	}, nil
+}
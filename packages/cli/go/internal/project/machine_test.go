@@ -0,0 +1,75 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func useMachinePath(t *testing.T, path string) {
+	t.Helper()
+	old := machinePath
+	machinePath = path
+	t.Cleanup(func() { machinePath = old })
+}
+
+func TestMachinePoliciesCannotBeOverridden(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	writeFile(t, MachinePath(), "protect .env\n")
+
+	// The project tries to allow what the machine protects
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), "version: 1\npolicies:\n  - policy: protect .env\n    decision: allow\n")
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := p.Check(&policy.CheckRequest{Action: string(policy.ActionModify), Target: filepath.Join(root, ".env")})
+	if result.Allowed {
+		t.Errorf("project allow overrode the machine policy: %+v", result)
+	}
+}
+
+func TestMachinePoliciesOutsideProjects(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	dir := filepath.Join(tmp, "scratch")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Resolve(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Resolve without machine policies = %v, want ErrNotExist", err)
+	}
+
+	writeFile(t, MachinePath(), "protect .env\n")
+	p, err := Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ConfigPath != MachinePath() {
+		t.Errorf("ConfigPath = %q, want the machine file", p.ConfigPath)
+	}
+	result := p.Check(&policy.CheckRequest{Action: string(policy.ActionModify), Target: filepath.Join(dir, ".env")})
+	if result.Allowed {
+		t.Errorf("machine policy didn't apply outside a project: %+v", result)
+	}
+	if p.Stale() {
+		t.Error("freshly loaded machine project is stale")
+	}
+}
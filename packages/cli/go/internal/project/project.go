@@ -0,0 +1,589 @@
+// Package project resolves the .veto configuration governing a path and
+// compiles it into an enforceable policy set.
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/compile"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+	"github.com/VulnZap/veto/internal/matcher"
+	"github.com/VulnZap/veto/internal/packs"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+// Project is a repository with a loaded and compiled .veto file.
+type Project struct {
+	// Root is the directory containing the .veto file
+	Root string
+	// ConfigPath is the absolute path to the .veto file
+	ConfigPath string
+	// Config is the parsed .veto file
+	Config *config.VetoConfig
+	// Lock is the parsed .veto.lock, or nil when the project has none
+	Lock *lock.File
+	// Policies are the compiled policies in config order
+	Policies []*policy.Policy
+	// Set evaluates requests against Policies
+	Set *matcher.Set
+	// Baseline is the project's accepted existing violations, or nil
+	// when it has none
+	Baseline *audit.Baseline
+	// Env is the environment whose overlay applies (see Env), or ""
+	Env string
+
+	// chain is the .veto files merged into the project, outermost first
+	// and ending with ConfigPath, and chainMods when those but the last
+	// last changed
+	chain     []string
+	chainMods []time.Time
+	modTime   time.Time
+	// baselineMod is when the baseline last changed (zero when there is
+	// none)
+	baselineMod time.Time
+	// machineMod is when the machine-wide policy file last changed (zero
+	// when there is none)
+	machineMod time.Time
+	// overlayMod is when the environment's overlay last changed (zero
+	// when there is no environment)
+	overlayMod time.Time
+}
+
+// Resolve finds the project governing dir and loads it. Outside any
+// project the machine-wide policies still apply.
+func Resolve(dir string) (*Project, error) {
+	path, err := Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+// CheckFrom validates a request against the project governing it, as
+// the daemon does with the requests sent to it: relative targets and
+// destinations are taken relative to the request's working directory,
+// not the project root. Requests outside any project are allowed.
+func CheckFrom(req *policy.CheckRequest) (*policy.CheckResult, error) {
+	local := *req
+	local.Target = Absolute(local.Target, local.Cwd)
+	local.Destination = Absolute(local.Destination, local.Cwd)
+
+	dir := local.Cwd
+	if filepath.IsAbs(local.Target) {
+		dir = filepath.Dir(local.Target)
+	}
+	p, err := Resolve(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return &policy.CheckResult{Allowed: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Check(&local), nil
+}
+
+// Absolute resolves a request path against its working directory. Paths
+// already absolute, or with no absolute directory to resolve against, are
+// returned as they are.
+func Absolute(path, cwd string) string {
+	if path != "" && !filepath.IsAbs(path) && filepath.IsAbs(cwd) {
+		return filepath.Join(cwd, path)
+	}
+	return path
+}
+
+// Load reads and compiles the .veto file at configPath, after the
+// machine-wide policies. In a monorepo the .veto files in its parent
+// directories come first, each of their policies limited to its own
+// directory, and the outermost file's directory is the root; a policy
+// in a nearer file overrides one with the same description further out.
+// The selected environment's overlay applies to the root's file. Loading
+// MachinePath yields the machine-wide policies alone.
+func Load(configPath string) (*Project, error) {
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if configPath == MachinePath() {
+		return loadMachine()
+	}
+
+	chain := chainOf(configPath)
+	layers, err := loadChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	nearest := layers[len(layers)-1]
+
+	root := filepath.Dir(chain[0])
+	env := Env(root)
+	var overlayMod time.Time
+	if env != "" {
+		var o *config.VetoConfig
+		if o, overlayMod, err = LoadOverlay(root, env); err != nil {
+			return nil, err
+		}
+		layers[0].cfg = merge(layers[0].cfg, o)
+	}
+	cfg := layers[0].cfg
+	for _, l := range layers[1:] {
+		cfg = merge(cfg, l.cfg)
+	}
+	lf, err := lock.Read(lock.Path(filepath.Dir(configPath)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	machine, machineMod, err := machinePolicies()
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := compileChain(layers, root)
+	if err != nil {
+		return nil, err
+	}
+	// Machine-wide policies are evaluated first, so they win ties
+	policies := append(machine, compiled...)
+	set, err := matcher.NewSet(policies)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Mode != "" {
+		set.SetMode(cfg.Mode)
+	}
+
+	var baselineMod time.Time
+	baseline, err := audit.ReadBaseline(audit.BaselinePath(root))
+	switch {
+	case err == nil:
+		if info, err := os.Stat(audit.BaselinePath(root)); err == nil {
+			baselineMod = info.ModTime()
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		baseline = nil
+	default:
+		return nil, err
+	}
+
+	return &Project{
+		Root:        root,
+		ConfigPath:  configPath,
+		Config:      cfg,
+		Lock:        lf,
+		Policies:    policies,
+		Set:         set,
+		Baseline:    baseline,
+		Env:         env,
+		chain:       chain,
+		chainMods:   chainMods(chain),
+		modTime:     nearest.mod,
+		baselineMod: baselineMod,
+		machineMod:  machineMod,
+		overlayMod:  overlayMod,
+	}, nil
+}
+
+// skipDirs are never searched for nested projects.
+var skipDirs = map[string]bool{"node_modules": true, "vendor": true, "dist": true, "build": true}
+
+// Nested returns the directories below root with a .veto of their own.
+// Each governs its subtree, as in a monorepo where packages carry their
+// own policies; inside a git repository they add to root's (see Load). Hidden and dependency directories
+// are skipped.
+func Nested(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".veto")); err == nil {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// Stale reports whether the .veto files, the environment or its overlay,
+// the baseline or the machine-wide policies changed since the project
+// was loaded.
+func (p *Project) Stale() bool {
+	info, err := os.Stat(p.ConfigPath)
+	if err != nil {
+		return true
+	}
+	if !info.ModTime().Equal(p.modTime) {
+		return true
+	}
+	if p.ConfigPath != MachinePath() {
+		chain := chainOf(p.ConfigPath)
+		if !slices.Equal(chain, p.chain) || !slices.EqualFunc(chainMods(chain), p.chainMods, time.Time.Equal) {
+			return true
+		}
+		env := Env(p.Root)
+		if env != p.Env {
+			return true
+		}
+		var overlayMod time.Time
+		if env != "" {
+			if info, err := os.Stat(OverlayPath(p.Root, env)); err == nil {
+				overlayMod = info.ModTime()
+			}
+		}
+		if !overlayMod.Equal(p.overlayMod) {
+			return true
+		}
+	}
+	var baselineMod time.Time
+	if info, err := os.Stat(audit.BaselinePath(p.Root)); err == nil {
+		baselineMod = info.ModTime()
+	}
+	if !baselineMod.Equal(p.baselineMod) {
+		return true
+	}
+	var machineMod time.Time
+	if info, err := os.Stat(MachinePath()); err == nil {
+		machineMod = info.ModTime()
+	}
+	return !machineMod.Equal(p.machineMod)
+}
+
+// Check validates a request against the project's policies.
+// Absolute targets and working directories are made relative to the
+// project root first, and modifying a file that doesn't exist yet is
+// checked as creating it. Content the baseline accepts for the file is
+// left out of the check of the rule it was accepted for, so only new
+// violations are flagged; locked policies see all of it.
+func (p *Project) Check(req *policy.CheckRequest) *policy.CheckResult {
+	local := *req
+	if local.Target != "" && local.Header == "" && p.Set.ReadsHeaders() {
+		local.Header = p.header(local.Target)
+	}
+	if local.Target != "" && local.Resize != nil && local.Size == 0 && p.Set.ReadsSizes() {
+		local.Size = p.size(local.Target)
+	}
+	if local.Action == string(policy.ActionModify) && local.Target != "" && !p.exists(local.Target) {
+		local.Action = string(policy.ActionCreate)
+	}
+	resolved := p.resolve(local.Target)
+	local.Target = p.relative(local.Target)
+	local.Destination = p.relative(local.Destination)
+	local.Cwd = p.relative(local.Cwd)
+	var mask matcher.Mask
+	if p.Baseline != nil {
+		file := local.Target
+		mask = func(pol *policy.Policy, rule, content string) string {
+			if pol.Locked {
+				return content
+			}
+			return p.Baseline.Mask(pol.Description, rule, file, content)
+		}
+	}
+	result := p.Set.CheckMasked(&local, mask)
+
+	// A symlink is checked as the file it points to, too, so protected
+	// files can't be reached through links to them. Renaming or deleting
+	// a link leaves its target alone.
+	switch policy.Action(local.Action) {
+	case policy.ActionRename, policy.ActionMove, policy.ActionDelete:
+		resolved = ""
+	}
+	if resolved != "" && result.Allowed {
+		linked := local
+		linked.Target = p.relative(resolved)
+		if r := p.Set.CheckMasked(&linked, mask); !r.Allowed || result.Decision == "" && r.Decision != "" {
+			r.Reason = fmt.Sprintf("%s (%s links to %s)", r.Reason, local.Target, linked.Target)
+			return r
+		}
+	}
+	return result
+}
+
+// Relevant returns the project's policies that concern the file req
+// targets (see matcher.Set.Relevant), for agents to be told about before
+// they change it. The target and cwd may be absolute or relative to Root.
+func (p *Project) Relevant(req *policy.CheckRequest) []*policy.Policy {
+	local := *req
+	local.Target = p.relative(local.Target)
+	local.Cwd = p.relative(local.Cwd)
+	return p.Set.Relevant(&local)
+}
+
+// resolve returns the file target (absolute, or relative to Root) is a
+// symlink to, following every link on the way, or "" when it isn't one
+// or can't be resolved.
+func (p *Project) resolve(target string) string {
+	if target == "" {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(p.Root, target)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return ""
+	}
+	// The root may itself be reached through a link (/tmp on macOS)
+	if root, err := filepath.EvalSymlinks(p.Root); err == nil {
+		if rel, err := filepath.Rel(root, resolved); err == nil && !strings.HasPrefix(rel, "..") {
+			resolved = filepath.Join(p.Root, rel)
+		}
+	}
+	if resolved == filepath.Clean(target) {
+		return ""
+	}
+	return resolved
+}
+
+// exists reports whether target (absolute, or relative to Root) is on
+// disk. Anything but a clean "doesn't exist" counts as existing.
+func (p *Project) exists(target string) bool {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(p.Root, target)
+	}
+	_, err := os.Lstat(target)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// size returns the size of the file at target (absolute, or relative to
+// Root), or 0 when it can't be read.
+func (p *Project) size(target string) int64 {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(p.Root, target)
+	}
+	info, err := os.Stat(target)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// headerSize is how much of a target file is read for header rules.
+const headerSize = 4096
+
+// header returns the start of the file at target (absolute, or relative
+// to Root), or "" when it can't be read.
+func (p *Project) header(target string) string {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(p.Root, target)
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, headerSize)
+	n, _ := io.ReadFull(f, buf)
+	return string(buf[:n])
+}
+
+// relative converts an absolute path inside the project to a slash-separated
+// path relative to Root.
+func (p *Project) relative(path string) string {
+	if path == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(p.Root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// Compile converts the policies in a .veto config into enforceable policies.
+// Phrases pinned in locked (which may be nil) use the locked policy. Rules
+// defined in full follow the phrases, as written. The order returned is
+// the evaluation order, which decides ties between equally severe
+// matches: phrases as listed, then rules as listed.
+func Compile(cfg *config.VetoConfig, locked *lock.File) []*policy.Policy {
+	var policies []*policy.Policy
+	for _, policyStr := range cfg.Policies {
+		var p *policy.Policy
+		// Lock file first, then builtins, then what the phrase compiled to
+		if lp := lookup(locked, policyStr); lp != nil {
+			p = lp
+		} else if b := builtin.Find(policyStr); b != nil {
+			// The phrase's verb says which operations it stops: "don't
+			// delete tests" leaves edits alone, "protect .env" doesn't
+			action, _ := compile.Action(strings.ToLower(strings.TrimSpace(policyStr)))
+			if builtin.ProtectedDir(policyStr) != "" || builtin.OnlyDir(policyStr) != "" {
+				// Nothing in a protected directory may change, nor
+				// anything outside an allowed one
+				action = policy.ActionModify
+			}
+			p = b.ToPolicy(action)
+		} else if cp := compile.Cached(policyStr); cp != nil {
+			p = cp
+		} else {
+			// Not compiled on this machine (veto add compiles it), so
+			// there's nothing to enforce yet
+			p = &policy.Policy{
+				Action:      policy.ActionDelete,
+				Description: policyStr,
+			}
+		}
+
+		entry := cfg.Entry(policyStr)
+		p.When = entry.When
+		p.Decision = entry.Decision
+		if p.Decision == "" {
+			p.Decision = defaultDecision(policyStr, p)
+		}
+		p.Severity = entry.Severity
+		p.Enforce = entry.Enforce
+		p.Rollout, _ = entry.RolloutPercent() // validated by config.Load
+		p.Locked = entry.Locked
+		p.Allow = append(p.Allow, entry.Allow...)
+		p.BlockOpaque = p.BlockOpaque || entry.BlockOpaque
+		p.AutoRun = entry.AutoRun
+		if entry.TestCommand != "" {
+			p.TestCommand = entry.TestCommand
+		}
+		if len(entry.ProtectEnv) > 0 || len(entry.AllowHosts) > 0 && len(p.EnvRules) > 0 {
+			p.EnvRules = withEnv(p.EnvRules, entry, p.Description)
+		}
+		if len(entry.AllowHosts) > 0 {
+			p.CommandRules = withHosts(p.CommandRules, entry.AllowHosts)
+		}
+		policies = append(policies, p)
+	}
+	// Copied, so marking the compiled policies (as machine policies are
+	// locked) leaves cfg alone
+	for _, r := range cfg.Rules {
+		p := *r
+		if p.Action == "" {
+			p.Action = policy.ActionModify
+		}
+		policies = append(policies, &p)
+	}
+	return policies
+}
+
+// CompileWithPacks compiles cfg's policies, as Compile does, followed by
+// those of the packs it imports (see the packs package). Local packs are
+// read relative to root. A pack phrase cfg lists itself is skipped, so
+// the project's options for it apply.
+func CompileWithPacks(cfg *config.VetoConfig, locked *lock.File, root string) ([]*policy.Policy, error) {
+	policies := Compile(cfg, locked)
+	if len(cfg.Packs) == 0 {
+		return policies, nil
+	}
+	loaded, err := packs.Load(root, cfg.Packs)
+	if err != nil {
+		return nil, err
+	}
+	listed := map[string]bool{}
+	for _, p := range cfg.Policies {
+		listed[p] = true
+	}
+	for _, pack := range loaded {
+		pc := *pack.Config
+		pc.Policies = nil
+		for _, p := range pack.Config.Policies {
+			if !listed[p] {
+				listed[p] = true
+				pc.Policies = append(pc.Policies, p)
+			}
+		}
+		policies = append(policies, Compile(&pc, locked)...)
+	}
+	return policies, nil
+}
+
+// defaultDecision is the decision for a policy whose .veto entry doesn't
+// set one: ask for change budgets and for builtins that ask by default,
+// such as "no publishing", even when the policy was pinned.
+func defaultDecision(phrase string, p *policy.Policy) policy.Decision {
+	if p.MaxFiles > 0 {
+		// Going over a change budget needs a human, not a hard stop
+		return policy.DecisionAsk
+	}
+	if b := builtin.Find(phrase); b != nil {
+		return b.Decision
+	}
+	return ""
+}
+
+// withEnv returns a copy of rules extended with an entry's protectEnv
+// names and allowHosts. Names go to the first rule (created if needed),
+// hosts to every rule.
+func withEnv(rules []policy.EnvRule, entry config.PolicyEntry, reason string) []policy.EnvRule {
+	rules = append([]policy.EnvRule(nil), rules...)
+	if len(rules) == 0 {
+		rules = []policy.EnvRule{{Reason: reason}}
+	}
+	for i := range rules {
+		r := &rules[i]
+		if i == 0 {
+			r.Names = append(append([]string(nil), r.Names...), entry.ProtectEnv...)
+		}
+		r.AllowHosts = append(append([]string(nil), r.AllowHosts...), entry.AllowHosts...)
+	}
+	return rules
+}
+
+// withHosts returns a copy of rules with hosts added to those that
+// already allow some (such as the remotes "allowlist git remotes"
+// permits); rules without AllowHosts don't take URLs into account.
+func withHosts(rules []policy.CommandRule, hosts []string) []policy.CommandRule {
+	rules = append([]policy.CommandRule(nil), rules...)
+	for i := range rules {
+		r := &rules[i]
+		if len(r.AllowHosts) > 0 {
+			r.AllowHosts = append(append([]string(nil), r.AllowHosts...), hosts...)
+		}
+	}
+	return rules
+}
+
+// lookup returns a copy of the policy pinned for phrase, or nil.
+func lookup(f *lock.File, phrase string) *policy.Policy {
+	if lp := f.Lookup(phrase); lp != nil {
+		p := *lp
+		return &p
+	}
+	return nil
+}
+
+// Register pins a policy built in code in the current project's
+// .veto.lock under source, and adds source to .veto so it is enforced
+// like any phrase. An existing entry for source is replaced.
+func Register(source string, p *policy.Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if path, err := config.Find(); err == nil {
+		root = filepath.Dir(path)
+	}
+
+	lockPath := lock.Path(root)
+	var entries []lock.Entry
+	f, err := lock.Read(lockPath)
+	switch {
+	case err == nil:
+		entries = f.Policies
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	if err := lock.Write(lockPath, lock.Put(entries, lock.Entry{Source: source, Policy: p, Registered: true})); err != nil {
+		return err
+	}
+	return config.AddPolicy(source)
+}
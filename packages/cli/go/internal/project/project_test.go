@@ -0,0 +1,95 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+func writeVeto(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".veto")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveFromSubdirectory(t *testing.T) {
+	root := t.TempDir()
+	writeVeto(t, root, "protect .env\n")
+	sub := filepath.Join(root, "src", "deep")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Resolve(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != root {
+		t.Errorf("Root = %s, want %s", p.Root, root)
+	}
+	if len(p.Policies) != 1 {
+		t.Fatalf("got %d policies, want 1", len(p.Policies))
+	}
+	if result := p.Check(&policy.CheckRequest{Action: "modify", Target: ".env"}); result.Allowed {
+		t.Errorf(".env: %+v, want blocked", result)
+	}
+	if result := p.Check(&policy.CheckRequest{Action: "modify", Target: "main.go"}); !result.Allowed {
+		t.Errorf("main.go: %+v, want allowed", result)
+	}
+}
+
+func TestResolveOutsideProject(t *testing.T) {
+	if _, err := Resolve(t.TempDir()); err == nil {
+		t.Error("directory without a .veto: want an error")
+	}
+}
+
+func TestStale(t *testing.T) {
+	root := t.TempDir()
+	path := writeVeto(t, root, "protect .env\n")
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Stale() {
+		t.Error("freshly loaded project reported stale")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Stale() {
+		t.Error("edited .veto not reported stale")
+	}
+	os.Remove(path)
+	if !p.Stale() {
+		t.Error("removed .veto not reported stale")
+	}
+}
+
+func TestWhenCondition(t *testing.T) {
+	root := t.TempDir()
+	path := writeVeto(t, root, "version: 1\npolicies:\n  - policy: protect .env\n    when:\n      agent: [cursor]\n  - prefer pnpm\n")
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := p.Check(&policy.CheckRequest{Action: "modify", Target: ".env", Agent: "cursor"}); result.Allowed {
+		t.Errorf("cursor: %+v, want blocked", result)
+	}
+	if result := p.Check(&policy.CheckRequest{Action: "modify", Target: ".env", Agent: "claude-code"}); !result.Allowed {
+		t.Errorf("claude-code: %+v, want allowed", result)
+	}
+	// Absolute targets are matched relative to the root
+	if result := p.Check(&policy.CheckRequest{Action: "modify", Target: filepath.Join(root, ".env"), Agent: "cursor"}); result.Allowed {
+		t.Errorf("absolute target: %+v, want blocked", result)
+	}
+}
@@ -0,0 +1,348 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/policy"
+)
+
+const rulesVeto = `version: 2
+policies:
+  - protect .env
+rules:
+  - description: keep infra read-only
+    include: ["infra/**"]
+    exclude: ["infra/README.md"]
+  - description: no direct kubectl
+    action: execute
+    commandRules:
+      - block: ["kubectl apply*"]
+        reason: deploys go through CI
+  - description: no debugger statements
+    contentRules:
+      - pattern: '\bdebugger\b'
+        fileTypes: ["*.ts"]
+        reason: remove debugger before committing
+`
+
+func TestRules(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), rulesVeto)
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Policies) != 4 {
+		t.Fatalf("compiled %d policies, want 4", len(p.Policies))
+	}
+
+	tests := []struct {
+		req     policy.CheckRequest
+		allowed bool
+	}{
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "infra", "main.tf")}, false},
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "infra", "README.md")}, true},
+		{policy.CheckRequest{Action: "execute", Command: "kubectl apply -f deploy.yaml"}, false},
+		{policy.CheckRequest{Action: "execute", Command: "kubectl get pods"}, true},
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "app.ts"), Content: "debugger;"}, false},
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, ".env")}, false},
+	}
+	for _, tt := range tests {
+		if got := p.Check(&tt.req); got.Allowed != tt.allowed {
+			t.Errorf("Check(%s %s%s) allowed = %v, want %v", tt.req.Action, tt.req.Target, tt.req.Command, got.Allowed, tt.allowed)
+		}
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  string
+	}{
+		{"  - include: [\"x\"]\n", "description"},
+		{"  - description: empty\n", "needs include"},
+		{"  - description: bad\n    action: launch\n    include: [\"x\"]\n", "unknown action"},
+		{"  - description: bad\n    commandRules:\n      - reason: nothing\n", "block patterns"},
+		{"  - description: bad\n    include: [\"x\"]\n    decision: maybe\n", "unknown decision"},
+		{"  - description: bad\n    shrinkRules:\n      - fileTypes: [\"*.csv\"]\n", "maxPercent"},
+	}
+	for _, tt := range tests {
+		tmp := t.TempDir()
+		useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+		path := filepath.Join(tmp, ".veto")
+		writeFile(t, path, "version: 2\nrules:\n"+tt.rules)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) = %v, want an error mentioning %q", tt.rules, err, tt.want)
+		}
+	}
+}
+
+func TestFileActionsFromPhrases(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), `version: 2
+policies:
+  - don't delete test files
+rules:
+  - description: no new files in legacy
+    action: create
+    include: ["legacy/**"]
+`)
+	writeFile(t, filepath.Join(root, "legacy", "old.go"), "package legacy\n")
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req     policy.CheckRequest
+		allowed bool
+	}{
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "src", "a.test.ts")}, true},
+		{policy.CheckRequest{Action: "delete", Target: filepath.Join(root, "src", "a.test.ts")}, false},
+		{policy.CheckRequest{Action: "rename", Target: filepath.Join(root, "src", "a.test.ts"), Destination: filepath.Join(root, "src", "a.ts")}, false},
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "legacy", "old.go")}, true},
+		// Writing a file that isn't there creates it
+		{policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "legacy", "new.go")}, false},
+	}
+	for _, tt := range tests {
+		if got := p.Check(&tt.req); got.Allowed != tt.allowed {
+			t.Errorf("Check(%s %s) allowed = %v, want %v", tt.req.Action, tt.req.Target, got.Allowed, tt.allowed)
+		}
+	}
+}
+
+func TestPublishingAsks(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), `version: 2
+policies:
+  - no npm publish
+  - policy: no cargo publish
+    decision: deny
+  - policy: no docker push
+    allowHosts: ["registry.corp.example"]
+`)
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cmd      string
+		decision policy.Decision
+	}{
+		{"npm publish --access public", policy.DecisionAsk},
+		{"cargo publish", policy.DecisionDeny},
+		{"docker push ghcr.io/acme/app:1.2", policy.DecisionAsk},
+		{"docker push acme/app", policy.DecisionAsk},
+		{"docker push registry.corp.example/app:1.2", ""},
+		{"docker push localhost:5000/app", ""},
+		{"npm pack", ""},
+	}
+	for _, tt := range tests {
+		got := p.Check(&policy.CheckRequest{Action: "execute", Command: tt.cmd})
+		if got.Decision != tt.decision || got.Allowed != (tt.decision == "") {
+			t.Errorf("Check(%s) = %v %q, want %q", tt.cmd, got.Allowed, got.Decision, tt.decision)
+		}
+	}
+}
+
+func TestShrinkRules(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), `version: 2
+policies:
+  - protect data files
+rules:
+  - description: keep the seed whole
+    shrinkRules:
+      - include: ["db/seed.sql"]
+        maxPercent: 10
+`)
+	writeFile(t, filepath.Join(root, "data", "users.csv"), strings.Repeat("id,name\n", 100))
+	writeFile(t, filepath.Join(root, "db", "seed.sql"), strings.Repeat("insert;\n", 100))
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target  string
+		resize  *policy.Resize
+		allowed bool
+	}{
+		{"data/users.csv", &policy.Resize{Whole: true, Added: 80}, false},
+		{filepath.Join(root, "data", "users.csv"), &policy.Resize{Whole: true, Added: 790}, true},
+		{"data/new.csv", &policy.Resize{Whole: true, Added: 8}, true},
+		{"db/seed.sql", &policy.Resize{Removed: 200}, false},
+		{"db/seed.sql", &policy.Resize{Removed: 8}, true},
+	}
+	for _, tt := range tests {
+		got := p.Check(&policy.CheckRequest{Action: "modify", Target: tt.target, Resize: tt.resize})
+		if got.Allowed != tt.allowed {
+			t.Errorf("Check(%s %+v) allowed = %v (%s), want %v", tt.target, tt.resize, got.Allowed, got.Reason, tt.allowed)
+		}
+	}
+}
+
+func TestPacks(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, "packs", "base.yaml"), `name: acme/baseline
+policies:
+  - protect .env
+  - no force push
+rules:
+  - description: no direct kubectl
+    action: execute
+    commandRules:
+      - block: ["kubectl apply*"]
+        reason: deploys go through CI
+`)
+	writeFile(t, filepath.Join(root, ".veto"), `version: 1
+packs:
+  - ./packs/base.yaml
+policies:
+  - policy: protect .env
+    enforce: monitor
+`)
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The project's own policies come first; a phrase it lists itself
+	// keeps its options rather than the pack's
+	var got []string
+	for _, pol := range p.Policies {
+		got = append(got, pol.Description)
+	}
+	if len(got) != 3 || got[2] != "no direct kubectl" || p.Policies[0].Enforce != policy.EnforceMonitor {
+		t.Errorf("policies = %q", got)
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "execute", Command: "kubectl apply -f x.yaml"}); r.Allowed {
+		t.Error("pack rule not enforced")
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: filepath.Join(root, ".env")}); !r.Allowed {
+		t.Error("pack phrase overrode the project's monitor mode")
+	}
+
+	writeFile(t, filepath.Join(root, ".veto"), "version: 1\npacks:\n  - ./packs/missing.yaml\n")
+	if _, err := Resolve(root); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("Resolve with a missing pack = %v", err)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), rulesVeto)
+	writeFile(t, filepath.Join(root, "app.ts"), "debugger;\nrun()\n")
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := audit.Scan(root, p.Set, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.NewBaseline(report.Findings, time.Now()).Write(audit.BaselinePath(root)); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Stale() {
+		t.Error("writing a baseline didn't make the project stale")
+	}
+	if p, err = Resolve(root); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(root, "app.ts")
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: target, Content: "debugger;\nrun(2)\n"}); !r.Allowed {
+		t.Errorf("editing around a baselined violation was blocked: %s", r.Reason)
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: target, Content: "debugger;\nrun()\ndebugger;\n"}); r.Allowed {
+		t.Error("a new violation was allowed")
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "other.ts"), Content: "debugger;\n"}); r.Allowed {
+		t.Error("a baselined line was allowed in another file")
+	}
+}
+
+func TestSymlinksToProtectedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), "protect .env\n")
+	writeFile(t, filepath.Join(root, ".env"), "API_KEY=x\n")
+	writeFile(t, filepath.Join(root, "public", "index.html"), "")
+	if err := os.Symlink("../.env", filepath.Join(root, "public", "config")); err != nil {
+		t.Skip(err)
+	}
+
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "public", "config")
+	r := p.Check(&policy.CheckRequest{Action: "modify", Target: link})
+	if r.Allowed || !strings.Contains(r.Reason, "public/config links to .env") {
+		t.Errorf("edit through link: %+v, want blocked by .env's policy", r)
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "delete", Target: link}); !r.Allowed {
+		t.Errorf("deleting the link: %+v, want allowed", r)
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: filepath.Join(root, "public", "index.html")}); !r.Allowed {
+		t.Errorf("plain file: %+v, want allowed", r)
+	}
+}
+
+func TestBaselineCannotHideMachinePolicies(t *testing.T) {
+	tmp := t.TempDir()
+	useMachinePath(t, filepath.Join(tmp, "etc", "policies.yaml"))
+	writeFile(t, MachinePath(), `version: 2
+rules:
+  - description: no eval
+    contentRules:
+      - pattern: '\beval\('
+        reason: eval is banned on this machine
+`)
+	root := filepath.Join(tmp, "repo")
+	writeFile(t, filepath.Join(root, ".veto"), rulesVeto)
+
+	// A baseline written by hand to accept both the project's violation
+	// and the machine's
+	b := audit.NewBaseline([]audit.Finding{
+		{File: "app.ts", Policy: "no debugger statements", Rule: "contentRules[0]", Text: "debugger;"},
+		{File: "app.ts", Policy: "no eval", Rule: "contentRules[0]", Text: "eval(x)"},
+	}, time.Now())
+	if err := b.Write(audit.BaselinePath(root)); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Resolve(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(root, "app.ts")
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: target, Content: "debugger;\n"}); !r.Allowed {
+		t.Errorf("baselined project violation was blocked: %s", r.Reason)
+	}
+	if r := p.Check(&policy.CheckRequest{Action: "modify", Target: target, Content: "eval(x)\n"}); r.Allowed {
+		t.Error("the baseline hid a locked machine policy's violation")
+	}
+}
@@ -0,0 +1,150 @@
+// Package readonly simulates veto's writes for `veto --read-only`, so
+// users can see exactly what syncing, installing or editing the config
+// would do to their files before letting it. Code that changes the user's
+// files writes through this package: with read-only mode on, each write
+// is printed, with a diff of what would change, and nothing on disk is
+// touched. Logs, caches and crash reports veto keeps for itself aren't
+// simulated.
+package readonly
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// EnvVar turns read-only mode on when set to 1 or true.
+const EnvVar = "VETO_READ_ONLY"
+
+// maxDiffLines bounds the diff printed for one file.
+const maxDiffLines = 40
+
+var enabled atomic.Bool
+
+// Out is where simulated writes are printed.
+var Out io.Writer = os.Stderr
+
+// Enable turns read-only mode on for the rest of the process.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled reports whether writes are simulated: read-only mode was turned
+// on, or the environment asks for it.
+func Enabled() bool {
+	if enabled.Load() {
+		return true
+	}
+	switch strings.ToLower(os.Getenv(EnvVar)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// WriteFile is os.WriteFile, printing the change instead in read-only
+// mode.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if !Enabled() {
+		return os.WriteFile(path, data, perm)
+	}
+	old, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Fprintf(Out, "● Would create %s\n", path)
+	case err != nil:
+		fmt.Fprintf(Out, "● Would write %s\n", path)
+		return nil
+	case bytes.Equal(old, data):
+		fmt.Fprintf(Out, "● Would leave %s unchanged\n", path)
+		return nil
+	default:
+		fmt.Fprintf(Out, "● Would change %s\n", path)
+	}
+	printDiff(Out, string(old), string(data))
+	return nil
+}
+
+// MkdirAll is os.MkdirAll, doing nothing in read-only mode: the files
+// that would be written in it are printed instead.
+func MkdirAll(path string, perm os.FileMode) error {
+	if !Enabled() {
+		return os.MkdirAll(path, perm)
+	}
+	return nil
+}
+
+// Remove is os.Remove, printing the removal instead in read-only mode.
+// Like os.Remove, it fails for a file that doesn't exist.
+func Remove(path string) error {
+	if !Enabled() {
+		return os.Remove(path)
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return &os.PathError{Op: "remove", Path: path, Err: os.ErrNotExist}
+	}
+	fmt.Fprintf(Out, "● Would remove %s\n", path)
+	return nil
+}
+
+// Rename is os.Rename, printing the rename instead in read-only mode.
+func Rename(oldpath, newpath string) error {
+	if !Enabled() {
+		return os.Rename(oldpath, newpath)
+	}
+	fmt.Fprintf(Out, "● Would replace %s with %s\n", newpath, oldpath)
+	return nil
+}
+
+// printDiff prints the lines that differ between old and new, - for
+// removed and + for added, up to maxDiffLines. Unchanged lines at either
+// end are left out; changes in the middle show as a block.
+func printDiff(w io.Writer, old, new string) {
+	a, b := lines(old), lines(new)
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+	}
+	printed := 0
+	emit := func(mark string, ls []string) {
+		for _, l := range ls {
+			if printed == maxDiffLines {
+				return
+			}
+			fmt.Fprintf(w, "    %s %s\n", mark, l)
+			printed++
+		}
+	}
+	emit("-", a[start:endA])
+	emit("+", b[start:endB])
+	if more := endA - start + endB - start - printed; more > 0 {
+		fmt.Fprintf(w, "    … %d more line(s)\n", more)
+	}
+}
+
+// lines splits content into lines, without a final empty one.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Would prints what an operation would do, "● Would <what>", and reports
+// true in read-only mode, for writes the functions here don't cover.
+// Callers skip the operation when it does.
+func Would(format string, args ...any) bool {
+	if !Enabled() {
+		return false
+	}
+	fmt.Fprintf(Out, "● Would "+format+"\n", args...)
+	return true
+}
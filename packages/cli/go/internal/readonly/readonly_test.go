@@ -0,0 +1,78 @@
+package readonly
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSimulatedWrites(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(existing, []byte("{\n  \"a\": 1,\n  \"b\": 2\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	Out = &out
+	enabled.Store(true)
+	t.Cleanup(func() {
+		Out = os.Stderr
+		enabled.Store(false)
+	})
+
+	created := filepath.Join(dir, "sub", "CLAUDE.md")
+	if err := MkdirAll(filepath.Dir(created), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(created, []byte("# Policies\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(existing, []byte("{\n  \"a\": 1,\n  \"b\": 3\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(existing); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(created); !os.IsNotExist(err) {
+		t.Errorf("Remove of a missing file = %v, want not exist", err)
+	}
+
+	if _, err := os.Stat(filepath.Dir(created)); !os.IsNotExist(err) {
+		t.Errorf("read-only MkdirAll created %s", filepath.Dir(created))
+	}
+	if data, _ := os.ReadFile(existing); !strings.Contains(string(data), `"b": 2`) {
+		t.Errorf("read-only WriteFile changed %s: %s", existing, data)
+	}
+	want := "● Would create " + created + "\n" +
+		"    + # Policies\n" +
+		"● Would change " + existing + "\n" +
+		"    -   \"b\": 2\n" +
+		"    +   \"b\": 3\n" +
+		"● Would remove " + existing + "\n"
+	if out.String() != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestEnabledByEnv(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	if Enabled() {
+		t.Fatal("enabled without the flag or environment")
+	}
+	t.Setenv(EnvVar, "1")
+	if !Enabled() {
+		t.Errorf("%s=1 doesn't enable read-only mode", EnvVar)
+	}
+	path := filepath.Join(t.TempDir(), "x")
+	Out = &bytes.Buffer{}
+	t.Cleanup(func() { Out = os.Stderr })
+	if err := WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s=1 wrote %s", EnvVar, path)
+	}
+}
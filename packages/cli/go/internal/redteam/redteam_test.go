@@ -0,0 +1,118 @@
+package redteam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VulnZap/veto/internal/builtin"
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/project"
+)
+
+// compiled are counters no builtin provides: they stop their attacks
+// once `veto add` has compiled them, so builtins alone let them through.
+var compiled = map[string]bool{"don't run rm -rf": true}
+
+// load returns a project in a temporary directory whose .veto lists
+// phrases.
+func load(t *testing.T, phrases ...string) *project.Project {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".veto")
+	if err := os.WriteFile(path, []byte(strings.Join(phrases, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := project.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func check(p *project.Project) func(*policy.CheckRequest) (*policy.CheckResult, error) {
+	return func(req *policy.CheckRequest) (*policy.CheckResult, error) {
+		return p.Check(req), nil
+	}
+}
+
+// builtinCounters returns the corpus's counters that are builtins, once
+// each.
+func builtinCounters(t *testing.T) []string {
+	var counters []string
+	seen := map[string]bool{}
+	for _, a := range Corpus {
+		if seen[a.Counter] {
+			continue
+		}
+		seen[a.Counter] = true
+		switch {
+		case compiled[a.Counter]:
+		case builtin.Find(a.Counter) == nil:
+			t.Errorf("counter %q is not a builtin", a.Counter)
+		default:
+			counters = append(counters, a.Counter)
+		}
+	}
+	return counters
+}
+
+func TestCorpusAgainstBuiltins(t *testing.T) {
+	p := load(t, builtinCounters(t)...)
+	for _, o := range Run(p.Root, check(p)) {
+		want := !compiled[o.Attack.Counter]
+		if o.Err != nil || o.Blocked != want {
+			t.Errorf("%s: %s: blocked = %v (%s), want %v", o.Attack.Category, o.Attack.Description, o.Blocked, o.Reason, want)
+		}
+	}
+}
+
+func TestEachCounterBlocksItsAttacks(t *testing.T) {
+	for _, a := range Corpus {
+		if compiled[a.Counter] {
+			continue
+		}
+		p := load(t, a.Counter)
+		req := a.Request
+		req.Cwd = p.Root
+		if p.Check(&req).Allowed {
+			t.Errorf("%s: allowed with %q, want blocked", a.Description, a.Counter)
+		}
+	}
+}
+
+func TestCorpusAllowedWithoutCounters(t *testing.T) {
+	p := load(t, "protect lock files")
+	for _, o := range Run(p.Root, check(p)) {
+		if o.Blocked {
+			t.Errorf("%s: blocked by an unrelated policy (%s)", o.Attack.Description, o.Reason)
+		}
+	}
+}
+
+func TestEverydayActionsAllowed(t *testing.T) {
+	p := load(t, builtinCounters(t)...)
+	for _, req := range []policy.CheckRequest{
+		execute("ls -la"),
+		execute("git push origin main"),
+		execute("git reset --soft HEAD~1"),
+		execute("curl -fsSL https://example.com/data.json"),
+		execute("curl -d @payload.json https://api.example.com"),
+		execute("cat README.md | wc -l"),
+		execute("rm -rf node_modules"),
+		execute("echo $HOME"),
+		execute(`python3 -c "print(1)"`),
+		modify("src/app.ts"),
+		modify(".env.example"),
+		modify("src/auth.test.ts"),
+		remove("src/app.ts"),
+	} {
+		req.Cwd = p.Root
+		if r := p.Check(&req); !r.Allowed {
+			t.Errorf("%s %s%s: blocked (%s), want allowed", req.Action, req.Command, req.Target, r.Reason)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package registry is a client for the policy pack registry, where pack
+// maintainers publish versioned packs like "veto/react-strict" and users
+// find and fetch them. The API is plain HTTP:
+//
+//	GET /packs?q=<query>        search, as JSON
+//	GET /packs/<name>           the latest version's pack file
+//	GET /packs/<name>/<version> a version's pack file
+//	PUT /packs/<name>/<version> publish a version (bearer token)
+//
+// Published versions are immutable, so a pinned pack never changes.
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/httpclient"
+)
+
+// URLEnv overrides the registry's URL, for private registries.
+const URLEnv = "VETO_REGISTRY"
+
+// TokenEnv holds the token publishing authenticates with.
+const TokenEnv = "VETO_REGISTRY_TOKEN"
+
+// DefaultURL is the public registry.
+const DefaultURL = "https://veto.run/registry"
+
+// maxPackSize bounds a pack fetched or published.
+const maxPackSize = 1 << 20
+
+var (
+	nameRe    = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*/[a-z0-9][a-z0-9._-]*$`)
+	versionRe = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?$`)
+)
+
+// ErrExists is returned when publishing a version that is already
+// published.
+var ErrExists = errors.New("version already published")
+
+// Summary describes a pack in search results.
+type Summary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Latest is the newest published version
+	Latest string `json:"latest"`
+	// Versions are the published versions, newest first
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Client talks to a registry.
+type Client struct {
+	// URL is the registry's base URL, without a trailing slash
+	URL string
+	// Token authenticates publishing
+	Token  string
+	Client *http.Client
+}
+
+// New returns a client for the registry the environment names, or the
+// public one.
+func New() *Client {
+	u := strings.TrimRight(os.Getenv(URLEnv), "/")
+	if u == "" {
+		u = DefaultURL
+	}
+	return &Client{URL: u, Token: os.Getenv(TokenEnv), Client: httpclient.New(30 * time.Second)}
+}
+
+// ValidName reports whether name is a pack name: owner/pack, in lower
+// case.
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// ValidVersion reports whether version is a semantic version such as
+// 1.2.0 or 2.0.0-beta.1.
+func ValidVersion(version string) bool {
+	return versionRe.MatchString(version)
+}
+
+// SplitRef splits a pack reference, owner/pack or owner/pack@version,
+// into the name and the pinned version ("" for the latest).
+func SplitRef(ref string) (name, version string) {
+	name, version, _ = strings.Cut(ref, "@")
+	return name, version
+}
+
+// ValidRef reports whether ref is a pack name, optionally pinned to a
+// version.
+func ValidRef(ref string) bool {
+	name, version := SplitRef(ref)
+	return ValidName(name) && (version == "" && !strings.Contains(ref, "@") || ValidVersion(version))
+}
+
+// Fetch downloads a pack file: version, or the latest when version is "".
+func (c *Client) Fetch(name, version string) ([]byte, error) {
+	path := "/packs/" + name
+	if version != "" {
+		path += "/" + url.PathEscape(version)
+	}
+	resp, err := c.Client.Get(c.URL + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		if version != "" {
+			return nil, fmt.Errorf("%s@%s isn't in the registry", name, version)
+		}
+		return nil, fmt.Errorf("%s isn't in the registry", name)
+	default:
+		return nil, fmt.Errorf("GET %s: %s", c.URL+path, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPackSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPackSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxPackSize)
+	}
+	return data, nil
+}
+
+// Search returns the packs matching query, as the registry ranks them.
+func (c *Client) Search(query string) ([]Summary, error) {
+	resp, err := c.Client.Get(c.URL + "/packs?q=" + url.QueryEscape(query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search: %s", resp.Status)
+	}
+	var body struct {
+		Packs []Summary `json:"packs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	return body.Packs, nil
+}
+
+// Publish uploads data as version of the pack name.
+func (c *Client) Publish(name, version string, data []byte) error {
+	if !ValidName(name) {
+		return fmt.Errorf("pack name %q: want owner/pack in lower case", name)
+	}
+	if !ValidVersion(version) {
+		return fmt.Errorf("pack version %q: want a semantic version like 1.2.0", version)
+	}
+	if len(data) > maxPackSize {
+		return fmt.Errorf("pack is larger than %d bytes", maxPackSize)
+	}
+	if c.Token == "" {
+		return fmt.Errorf("publishing needs a registry token; set %s", TokenEnv)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.URL+"/packs/"+name+"/"+url.PathEscape(version), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/yaml")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		return fmt.Errorf("%s@%s: %w", name, version, ErrExists)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("publish %s: %s (check %s)", name, resp.Status, TokenEnv)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if m := strings.TrimSpace(string(msg)); m != "" {
+		return fmt.Errorf("publish %s: %s: %s", name, resp.Status, m)
+	}
+	return fmt.Errorf("publish %s: %s", name, resp.Status)
+}
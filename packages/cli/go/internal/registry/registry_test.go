@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const pack = "name: acme/node\npackVersion: 1.2.0\npolicies:\n  - prefer pnpm\n"
+
+// fakeRegistry serves acme/node 1.2.0 and accepts new versions from
+// token "secret".
+func fakeRegistry(t *testing.T) *Client {
+	t.Helper()
+	published := map[string]string{"acme/node/1.2.0": pack}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/packs")
+		switch {
+		case r.Method == http.MethodGet && path == "":
+			var found []Summary
+			if strings.Contains("acme/node", r.URL.Query().Get("q")) {
+				found = append(found, Summary{Name: "acme/node", Latest: "1.2.0", Versions: []string{"1.2.0"}})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"packs": found})
+		case r.Method == http.MethodGet && path == "/acme/node":
+			io.WriteString(w, published["acme/node/1.2.0"])
+		case r.Method == http.MethodGet:
+			data, ok := published[strings.TrimPrefix(path, "/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, data)
+		case r.Method == http.MethodPut:
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			key := strings.TrimPrefix(path, "/")
+			if _, ok := published[key]; ok {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			published[key] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{URL: srv.URL, Client: srv.Client()}
+}
+
+func TestFetch(t *testing.T) {
+	c := fakeRegistry(t)
+	for _, version := range []string{"", "1.2.0"} {
+		if data, err := c.Fetch("acme/node", version); err != nil || string(data) != pack {
+			t.Errorf("Fetch(acme/node, %q) = %q, %v", version, data, err)
+		}
+	}
+	if _, err := c.Fetch("acme/node", "9.9.9"); err == nil || !strings.Contains(err.Error(), "acme/node@9.9.9 isn't in the registry") {
+		t.Errorf("Fetch of a missing version = %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	c := fakeRegistry(t)
+	results, err := c.Search("node")
+	if err != nil || len(results) != 1 || results[0].Name != "acme/node" || results[0].Latest != "1.2.0" {
+		t.Errorf("Search(node) = %+v, %v", results, err)
+	}
+	if results, err := c.Search("python"); err != nil || len(results) != 0 {
+		t.Errorf("Search(python) = %+v, %v", results, err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	c := fakeRegistry(t)
+	next := strings.Replace(pack, "1.2.0", "1.3.0", 1)
+	if err := c.Publish("acme/node", "1.3.0", []byte(next)); err == nil || !strings.Contains(err.Error(), TokenEnv) {
+		t.Errorf("Publish without a token = %v", err)
+	}
+
+	c.Token = "secret"
+	if err := c.Publish("acme/node", "1.3.0", []byte(next)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := c.Fetch("acme/node", "1.3.0"); err != nil || string(data) != next {
+		t.Errorf("Fetch after publishing = %q, %v", data, err)
+	}
+	if err := c.Publish("acme/node", "1.3.0", []byte(next)); !errors.Is(err, ErrExists) {
+		t.Errorf("republishing = %v, want ErrExists", err)
+	}
+	if err := c.Publish("Acme Node", "1.3.0", []byte(next)); err == nil {
+		t.Error("Publish accepted an invalid name")
+	}
+	if err := c.Publish("acme/node", "v2", []byte(next)); err == nil {
+		t.Error("Publish accepted an invalid version")
+	}
+}
+
+func TestValidRef(t *testing.T) {
+	tests := map[string]bool{
+		"veto/react-strict":              true,
+		"veto/react-strict@1.2.0":        true,
+		"veto/react-strict@2.0.0-beta.1": true,
+		"veto/react-strict@":             false,
+		"veto/react-strict@latest":       false,
+		"react-strict":                   false,
+		"Veto/React":                     false,
+	}
+	for ref, want := range tests {
+		if got := ValidRef(ref); got != want {
+			t.Errorf("ValidRef(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package schedule parses cron-like schedules for the daemon's recurring
+// jobs. A schedule is five cron fields (minute, hour, day of month,
+// month, day of week), each *, a number, a range a-b, a step */n or a-b/n,
+// or a comma-separated list of them, or one of the names hourly, daily,
+// nightly (02:00), weekly and monthly, with or without a leading @.
+// Times are local.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// names are the schedules that can be given by name.
+var names = map[string]string{
+	"hourly":   "0 * * * *",
+	"daily":    "0 0 * * *",
+	"midnight": "0 0 * * *",
+	"nightly":  "0 2 * * *",
+	"weekly":   "0 0 * * 0",
+	"monthly":  "0 0 1 * *",
+}
+
+// field bounds, in cron order.
+var bounds = [5]struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// Schedule is a parsed schedule.
+type Schedule struct {
+	spec string
+	// sets holds the allowed values of each field
+	sets [5]map[int]bool
+	// anyDOM and anyDOW record whether the day of month and day of week
+	// fields are *, since cron matches either day field when both are set
+	anyDOM, anyDOW bool
+}
+
+// Parse parses a schedule.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	expr := spec
+	if e, ok := names[strings.TrimPrefix(strings.ToLower(spec), "@")]; ok {
+		expr = e
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: want five cron fields (minute hour day month weekday) or hourly, daily, nightly, weekly or monthly", spec)
+	}
+	s := &Schedule{spec: spec, anyDOM: fields[2] == "*", anyDOW: fields[4] == "*"}
+	for i, f := range fields {
+		set, err := parseField(f, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		s.sets[i] = set
+	}
+	// Sunday is 0 or 7
+	if s.sets[4][7] {
+		s.sets[4][0] = true
+	}
+	return s, nil
+}
+
+// parseField parses one cron field into the values it allows.
+func parseField(f string, lo, hi int) (map[int]bool, error) {
+	set := map[int]bool{}
+	// Day of week allows 7 for Sunday
+	top := hi
+	if lo == 0 && hi == 6 {
+		top = 7
+	}
+	for _, part := range strings.Split(f, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+		}
+		start, end := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("bad value %q", part)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("bad range %q", part)
+				}
+			} else if hasStep {
+				end = hi
+			}
+		}
+		if start < lo || end > top || start > end {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// String returns the schedule as it was written.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Matches reports whether the schedule fires in t's minute.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.sets[0][t.Minute()] && s.sets[1][t.Hour()] && s.sets[3][int(t.Month())] && s.dayMatches(t)
+}
+
+// maxSearch bounds how far ahead Next looks: past four years a schedule
+// such as February 30th never fires.
+const maxSearch = 4 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the schedule fires, or the zero
+// time when it never does.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Add(maxSearch)
+	for next.Before(limit) {
+		switch {
+		case !s.sets[3][int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case !s.sets[1][next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+		case !s.sets[0][next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the schedule fires on t's day.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.sets[2][t.Day()], s.sets[4][int(t.Weekday())]
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"nightly", "2026-03-10 14:30", "2026-03-11 02:00"},
+		{"@nightly", "2026-03-10 01:59", "2026-03-10 02:00"},
+		{"hourly", "2026-03-10 14:00", "2026-03-10 15:00"},
+		{"*/15 * * * *", "2026-03-10 14:07", "2026-03-10 14:15"},
+		{"30 9 * * 1-5", "2026-03-13 10:00", "2026-03-16 09:30"}, // Friday to Monday
+		{"0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00"},
+		{"0 12 * * 7", "2026-03-10 00:00", "2026-03-15 12:00"}, // 7 is Sunday
+		{"0 0 13 * 5", "2026-03-01 00:00", "2026-03-06 00:00"}, // 13th or any Friday
+		{"0 6,18 * * *", "2026-03-10 07:00", "2026-03-10 18:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.spec, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+		if !s.Matches(at(tt.want)) {
+			t.Errorf("%q doesn't match %s", tt.spec, tt.want)
+		}
+	}
+
+	never, _ := Parse("0 0 30 2 *")
+	if got := never.Next(at("2026-01-01 00:00")); !got.IsZero() {
+		t.Errorf("February 30th fired at %s", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "sometimes", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
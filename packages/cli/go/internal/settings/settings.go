@@ -0,0 +1,84 @@
+// Package settings stores per-user veto preferences that aren't tied to a
+// project's .veto file.
+package settings
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/VulnZap/veto/internal/policy"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Settings are the user's preferences.
+type Settings struct {
+	// Channel selects which releases updates come from (stable or beta)
+	Channel string `json:"channel,omitempty"`
+	// LastSeenVersion is the version whose "what's new" panel was last shown
+	LastSeenVersion string `json:"lastSeenVersion,omitempty"`
+	// TourStep is the furthest onboarding tour step reached
+	TourStep string `json:"tourStep,omitempty"`
+	// TourCompleted is set once the onboarding tour reaches the end
+	TourCompleted bool `json:"tourCompleted,omitempty"`
+	// CABundle is a PEM file of extra CA certificates to trust, for
+	// proxies that intercept TLS
+	CABundle string `json:"caBundle,omitempty"`
+	// Notify turns on desktop notifications (nil when off)
+	Notify *Notifications `json:"notify,omitempty"`
+	// ReadOnly runs every command as if given --read-only, for demos
+	// and evaluations: writes are printed instead of made
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Workspaces are the directories veto repos looks for projects in
+	// (~/code when empty)
+	Workspaces []string `json:"workspaces,omitempty"`
+}
+
+// Notifications chooses which agent events raise a desktop notification.
+type Notifications struct {
+	// Severity is the lowest severity of block that notifies (default
+	// high). Policies without a severity count as medium.
+	Severity policy.Severity `json:"severity,omitempty"`
+	// Asks notifies when a request waits for approval
+	Asks bool `json:"asks"`
+	// Quiet is a daily local-time window, like "22:00-08:00", when only
+	// critical blocks notify
+	Quiet string `json:"quiet,omitempty"`
+	// Focus holds notifications to critical blocks until turned off
+	Focus bool `json:"focus,omitempty"`
+}
+
+// Path returns the per-user settings file.
+func Path() string {
+	return filepath.Join(xdg.ConfigDir(), "settings.json")
+}
+
+// Load reads the user's settings. A missing file yields zero settings.
+func Load() (*Settings, error) {
+	data, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the user's settings.
+func (s *Settings) Save() error {
+	path := Path()
+	if err := readonly.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return readonly.WriteFile(path, append(data, '\n'), 0644)
+}
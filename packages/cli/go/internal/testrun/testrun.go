@@ -0,0 +1,134 @@
+// Package testrun runs a project's quick test command after agent edits
+// and reports tests that started failing or were skipped since the
+// previous run. The last result per project and command is kept in the
+// user cache directory.
+package testrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Timeout bounds a test run; hooks block the agent while it runs.
+const Timeout = 2 * time.Minute
+
+// outputLines is how much of a failing run's output is kept.
+const outputLines = 20
+
+// Result is the outcome of one test run.
+type Result struct {
+	Passed bool `json:"passed"`
+	// Skipped counts skipped tests as reported by the runner
+	Skipped int       `json:"skipped"`
+	At      time.Time `json:"at"`
+	// Output is the end of the run's output
+	Output string `json:"output,omitempty"`
+}
+
+var (
+	// "2 skipped" (vitest, jest, pytest, mocha)
+	skippedRe = regexp.MustCompile(`(?i)\b(\d+)\s+skipped\b`)
+	// "--- SKIP: TestFoo" (go test -v)
+	goSkipRe = regexp.MustCompile(`(?m)^\s*--- SKIP:`)
+)
+
+// Run runs command through the shell in dir.
+func Run(dir, command string) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+
+	r := Result{Passed: err == nil, Skipped: skipped(string(out)), At: time.Now()}
+	if ctx.Err() != nil {
+		out = append(out, fmt.Sprintf("\n(timed out after %s)", Timeout)...)
+	}
+	if !r.Passed {
+		r.Output = tail(string(out), outputLines)
+	}
+	return r
+}
+
+// skipped counts the tests a run reports as skipped.
+func skipped(output string) int {
+	n := len(goSkipRe.FindAllString(output, -1))
+	for _, m := range skippedRe.FindAllStringSubmatch(output, -1) {
+		count, _ := strconv.Atoi(m[1])
+		n += count
+	}
+	return n
+}
+
+func tail(s string, lines int) string {
+	all := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n")
+}
+
+// Regression describes how cur is worse than prev, or returns "" when it
+// isn't. Without a previous run there is nothing to compare against, so
+// only a baseline is recorded.
+func Regression(prev *Result, cur Result) string {
+	if prev == nil {
+		return ""
+	}
+	if prev.Passed && !cur.Passed {
+		return "tests started failing after this edit:\n" + cur.Output
+	}
+	if cur.Skipped > prev.Skipped {
+		return fmt.Sprintf("skipped tests went from %d to %d after this edit", prev.Skipped, cur.Skipped)
+	}
+	return ""
+}
+
+// StatePath is where the last result of each project's test command is kept.
+func StatePath() string {
+	return filepath.Join(xdg.StateDir(), "testruns.json")
+}
+
+func key(root, command string) string {
+	return root + "\x00" + command
+}
+
+func load() map[string]Result {
+	state := make(map[string]Result)
+	if data, err := os.ReadFile(StatePath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// Last returns the previous result of command in the project at root.
+func Last(root, command string) *Result {
+	if r, ok := load()[key(root, command)]; ok {
+		return &r
+	}
+	return nil
+}
+
+// Save records r as the latest result of command in the project at root.
+func Save(root, command string, r Result) error {
+	state := load()
+	state[key(root, command)] = r
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(StatePath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(StatePath(), data, 0644)
+}
@@ -0,0 +1,54 @@
+package testrun
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSkipped(t *testing.T) {
+	tests := map[string]int{
+		" Test Files  2 passed (2)\n      Tests  11 passed | 2 skipped (13)": 2,
+		"Tests:       1 skipped, 40 passed, 41 total":                        1,
+		"==== 12 passed, 3 skipped in 0.52s ====":                            3,
+		"=== RUN   TestA\n--- SKIP: TestA (0.00s)\n--- SKIP: TestB (0.00s)":  2,
+		"ok  \tgithub.com/x/y\t0.01s":                                        0,
+	}
+	for output, want := range tests {
+		if got := skipped(output); got != want {
+			t.Errorf("skipped(%q) = %d, want %d", output, got, want)
+		}
+	}
+}
+
+func TestRegression(t *testing.T) {
+	pass := Result{Passed: true, Skipped: 1}
+	tests := []struct {
+		name string
+		prev *Result
+		cur  Result
+		want string
+	}{
+		{"first run", nil, Result{Passed: false, Output: "FAIL"}, ""},
+		{"still passing", &pass, Result{Passed: true, Skipped: 1}, ""},
+		{"newly failing", &pass, Result{Passed: false, Output: "FAIL sum.test.ts"}, "started failing"},
+		{"already failing", &Result{Passed: false}, Result{Passed: false}, ""},
+		{"newly skipped", &pass, Result{Passed: true, Skipped: 3}, "skipped tests went from 1 to 3"},
+		{"fewer skipped", &pass, Result{Passed: true}, ""},
+	}
+	for _, tt := range tests {
+		got := Regression(tt.prev, tt.cur)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: Regression = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	if r := Run(t.TempDir(), "echo '1 skipped'"); !r.Passed || r.Skipped != 1 {
+		t.Errorf("passing run = %+v", r)
+	}
+	r := Run(t.TempDir(), "echo boom; exit 1")
+	if r.Passed || !strings.Contains(r.Output, "boom") {
+		t.Errorf("failing run = %+v", r)
+	}
+}
@@ -0,0 +1,397 @@
+// Package update checks for new veto releases and installs them.
+//
+// npm installs are checked against the registry's dist-tags; standalone
+// binary installs are checked against GitHub releases. Results are cached
+// for CacheTTL so the TUI doesn't hit the network on every launch.
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/httpclient"
+	"github.com/VulnZap/veto/internal/readonly"
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// Release channels.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// How veto was installed.
+const (
+	InstallNPM    = "npm"
+	InstallBinary = "binary"
+)
+
+// CacheTTL is how long a check result is reused.
+const CacheTTL = 24 * time.Hour
+
+// Sources checked for releases.
+var (
+	NPMRegistryURL = "https://registry.npmjs.org/veto-cli"
+	GitHubURL      = "https://api.github.com/repos/VulnZap/veto/releases?per_page=30"
+)
+
+// npmPackage is the npm package name releases are installed from.
+const npmPackage = "veto-cli"
+
+// Release is an available version of veto.
+type Release struct {
+	Version    string  `json:"version"`
+	Notes      string  `json:"notes,omitempty"`
+	URL        string  `json:"url,omitempty"`
+	Prerelease bool    `json:"prerelease,omitempty"`
+	Assets     []Asset `json:"assets,omitempty"`
+}
+
+// Asset is a downloadable file attached to a GitHub release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Checker looks up the latest release for a channel and install method.
+type Checker struct {
+	// Channel is stable or beta
+	Channel string
+	// Method is how veto was installed (npm or binary)
+	Method string
+	// CachePath is where results are cached ("" disables caching)
+	CachePath string
+	// Client makes the HTTP requests
+	Client *http.Client
+	// Now returns the current time, for cache expiry (nil uses time.Now)
+	Now func() time.Time
+}
+
+// NewChecker returns a checker for the given channel using the default
+// cache location and the detected install method.
+func NewChecker(channel string) *Checker {
+	if channel == "" {
+		channel = ChannelStable
+	}
+	return &Checker{
+		Channel:   channel,
+		Method:    DetectInstall(),
+		CachePath: CachePath(),
+		Client:    httpclient.New(5 * time.Second),
+	}
+}
+
+// CachePath returns the per-user update check cache.
+func CachePath() string {
+	return filepath.Join(xdg.CacheDir(), "update.json")
+}
+
+// DetectInstall reports whether the running binary was installed by npm
+// (it lives under node_modules) or as a standalone binary.
+func DetectInstall() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return InstallNPM
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if strings.Contains(filepath.ToSlash(exe), "/node_modules/") {
+		return InstallNPM
+	}
+	return InstallBinary
+}
+
+// cacheEntry is the on-disk shape of the update cache.
+type cacheEntry struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Channel   string    `json:"channel"`
+	Method    string    `json:"method"`
+	Release   *Release  `json:"release"`
+}
+
+// Latest returns the newest release on the checker's channel, using the
+// cache unless it is older than CacheTTL or force is set.
+func (c *Checker) Latest(force bool) (*Release, error) {
+	if !force {
+		if r, ok := c.cached(); ok {
+			return r, nil
+		}
+	}
+
+	var r *Release
+	var err error
+	if c.Method == InstallNPM {
+		r, err = c.latestNPM()
+	} else {
+		r, err = c.latestGitHub()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.store(r)
+	return r, nil
+}
+
+func (c *Checker) cached() (*Release, bool) {
+	if c.CachePath == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.CachePath)
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	if e.Channel != c.Channel || e.Method != c.Method || e.Release == nil || c.now().Sub(e.CheckedAt) > CacheTTL {
+		return nil, false
+	}
+	return e.Release, true
+}
+
+func (c *Checker) store(r *Release) {
+	if c.CachePath == "" {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{CheckedAt: c.now(), Channel: c.Channel, Method: c.Method, Release: r})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.CachePath), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.CachePath, data, 0644) // best effort
+}
+
+func (c *Checker) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// latestNPM reads the registry's dist-tags. The beta channel takes the beta
+// tag when it is newer than latest. Release notes come from the matching
+// GitHub release when one exists.
+func (c *Checker) latestNPM() (*Release, error) {
+	var pkg struct {
+		DistTags map[string]string `json:"dist-tags"`
+	}
+	if err := c.getJSON(NPMRegistryURL, &pkg); err != nil {
+		return nil, err
+	}
+	version := pkg.DistTags["latest"]
+	if beta := pkg.DistTags["beta"]; c.Channel == ChannelBeta && beta != "" && Newer(beta, version) {
+		version = beta
+	}
+	if version == "" {
+		return nil, fmt.Errorf("no %s release published", c.Channel)
+	}
+
+	r := &Release{Version: version, Prerelease: strings.Contains(version, "-")}
+	if releases, err := c.githubReleases(); err == nil {
+		for _, gr := range releases {
+			if gr.Version == version {
+				r.Notes, r.URL = gr.Notes, gr.URL
+				break
+			}
+		}
+	}
+	return r, nil
+}
+
+// latestGitHub picks the newest GitHub release on the channel.
+func (c *Checker) latestGitHub() (*Release, error) {
+	releases, err := c.githubReleases()
+	if err != nil {
+		return nil, err
+	}
+	var best *Release
+	for i := range releases {
+		r := &releases[i]
+		if r.Prerelease && c.Channel != ChannelBeta {
+			continue
+		}
+		if best == nil || Newer(r.Version, best.Version) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s release published", c.Channel)
+	}
+	return best, nil
+}
+
+// githubReleases lists CLI releases. Tags are either "vX.Y.Z" or the
+// monorepo form "veto-cli@X.Y.Z"; other packages' releases are skipped.
+func (c *Checker) githubReleases() ([]Release, error) {
+	var raw []struct {
+		TagName    string `json:"tag_name"`
+		Body       string `json:"body"`
+		HTMLURL    string `json:"html_url"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		Assets     []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := c.getJSON(GitHubURL, &raw); err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	for _, gr := range raw {
+		version, ok := strings.CutPrefix(gr.TagName, npmPackage+"@")
+		if !ok {
+			if !strings.HasPrefix(gr.TagName, "v") {
+				continue
+			}
+			version = strings.TrimPrefix(gr.TagName, "v")
+		}
+		if gr.Draft {
+			continue
+		}
+		r := Release{
+			Version:    version,
+			Notes:      strings.TrimSpace(gr.Body),
+			URL:        gr.HTMLURL,
+			Prerelease: gr.Prerelease || strings.Contains(version, "-"),
+		}
+		for _, a := range gr.Assets {
+			r.Assets = append(r.Assets, Asset{Name: a.Name, URL: a.URL})
+		}
+		releases = append(releases, r)
+	}
+	return releases, nil
+}
+
+func (c *Checker) getJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Apply installs a release: through npm for npm installs, or by replacing
+// the running executable with the release's platform binary.
+func (c *Checker) Apply(r *Release) error {
+	if c.Method == InstallNPM {
+		cmd := exec.Command("npm", "install", "-g", npmPackage+"@"+r.Version)
+		// npm honors the proxy variables itself; hand it the CA bundle too
+		if ca := httpclient.CABundle(); ca != "" && os.Getenv("npm_config_cafile") == "" {
+			cmd.Env = append(os.Environ(), "npm_config_cafile="+ca)
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("npm install: %v\n%s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return c.replaceBinary(r)
+}
+
+// AssetName is the release asset built for the current platform. Linux
+// systems on musl (Alpine) get the static -musl build.
+func AssetName() string {
+	name := fmt.Sprintf("veto-%s-%s", runtime.GOOS, runtime.GOARCH)
+	switch {
+	case runtime.GOOS == "windows":
+		name += ".exe"
+	case runtime.GOOS == "linux" && musl():
+		name += "-musl"
+	}
+	return name
+}
+
+// musl reports whether the system's C library is musl.
+func musl() bool {
+	loaders, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+	return len(loaders) > 0
+}
+
+func (c *Checker) replaceBinary(r *Release) error {
+	want := AssetName()
+	var url string
+	for _, a := range r.Assets {
+		if a.Name == want {
+			url = a.URL
+			break
+		}
+	}
+	if url == "" {
+		return fmt.Errorf("release %s has no %s binary", r.Version, want)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	resp, err := httpclient.New(5 * time.Minute).Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", want, resp.Status)
+	}
+
+	return ReplaceExecutable(resp.Body, exe)
+}
+
+// ReplaceExecutable writes an executable read from r to dest, replacing
+// whatever is there (the running executable included) in one rename.
+// In read-only mode it only says so.
+func ReplaceExecutable(r io.Reader, dest string) error {
+	if readonly.Would("replace %s", dest) {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".veto-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		// A running executable can be renamed but not overwritten
+		old := dest + ".old"
+		os.Remove(old)
+		if err := os.Rename(dest, old); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), dest)
+}
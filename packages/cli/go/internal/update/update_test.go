@@ -0,0 +1,163 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// registry serves npm dist-tags and an empty GitHub release list, counting
+// registry requests.
+func registry(t *testing.T, tags string) *int {
+	t.Helper()
+	hits := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/npm" {
+			*hits++
+			w.Write([]byte(`{"dist-tags": ` + tags + `}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	npm, gh := NPMRegistryURL, GitHubURL
+	NPMRegistryURL, GitHubURL = srv.URL+"/npm", srv.URL+"/gh"
+	t.Cleanup(func() { NPMRegistryURL, GitHubURL = npm, gh })
+	return hits
+}
+
+func checker(channel, cache string, now *time.Time) *Checker {
+	return &Checker{
+		Channel:   channel,
+		Method:    InstallNPM,
+		CachePath: cache,
+		Client:    http.DefaultClient,
+		Now:       func() time.Time { return *now },
+	}
+}
+
+func latest(t *testing.T, c *Checker, force bool) string {
+	t.Helper()
+	r, err := c.Latest(force)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Version
+}
+
+func TestCacheExpiry(t *testing.T) {
+	hits := registry(t, `{"latest": "1.2.0"}`)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := checker(ChannelStable, filepath.Join(t.TempDir(), "update.json"), &now)
+
+	if v := latest(t, c, false); v != "1.2.0" || *hits != 1 {
+		t.Fatalf("first check: %s after %d requests, want 1.2.0 after 1", v, *hits)
+	}
+	now = now.Add(CacheTTL - time.Minute)
+	latest(t, c, false)
+	if *hits != 1 {
+		t.Errorf("within the TTL: %d requests, want the cached result", *hits)
+	}
+	latest(t, c, true)
+	if *hits != 2 {
+		t.Errorf("forced: %d requests, want a fresh check", *hits)
+	}
+
+	// The forced check restarted the TTL
+	now = now.Add(CacheTTL + time.Minute)
+	latest(t, c, false)
+	if *hits != 3 {
+		t.Errorf("after the TTL: %d requests, want a fresh check", *hits)
+	}
+}
+
+func TestChannelSwitch(t *testing.T) {
+	hits := registry(t, `{"latest": "1.2.0", "beta": "1.3.0-beta.2"}`)
+	now := time.Now()
+	cache := filepath.Join(t.TempDir(), "update.json")
+
+	if v := latest(t, checker(ChannelStable, cache, &now), false); v != "1.2.0" {
+		t.Errorf("stable: %s, want 1.2.0", v)
+	}
+	// The stable result cached doesn't answer for beta
+	if v := latest(t, checker(ChannelBeta, cache, &now), false); v != "1.3.0-beta.2" || *hits != 2 {
+		t.Errorf("beta: %s after %d requests, want 1.3.0-beta.2 after 2", v, *hits)
+	}
+	if v := latest(t, checker(ChannelStable, cache, &now), false); v != "1.2.0" || *hits != 3 {
+		t.Errorf("back to stable: %s after %d requests, want 1.2.0 after 3", v, *hits)
+	}
+}
+
+func TestBetaBehindStable(t *testing.T) {
+	registry(t, `{"latest": "1.3.0", "beta": "1.3.0-beta.2"}`)
+	now := time.Now()
+	if v := latest(t, checker(ChannelBeta, "", &now), false); v != "1.3.0" {
+		t.Errorf("beta older than latest: %s, want 1.3.0", v)
+	}
+}
+
+func TestCorruptCache(t *testing.T) {
+	hits := registry(t, `{"latest": "1.2.0"}`)
+	now := time.Now()
+	cache := filepath.Join(t.TempDir(), "update.json")
+	if err := os.WriteFile(cache, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := checker(ChannelStable, cache, &now)
+
+	if v := latest(t, c, false); v != "1.2.0" || *hits != 1 {
+		t.Fatalf("corrupt cache: %s after %d requests, want a fresh check", v, *hits)
+	}
+	// The corrupt file was replaced with a usable one
+	latest(t, c, false)
+	if *hits != 1 {
+		t.Errorf("after rewrite: %d requests, want the cached result", *hits)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.1", "1.2.0", true},
+		{"v1.10.0", "1.9.9", true},
+		{"1.2.0", "1.2.0-beta.1", true},
+		{"1.2.0-beta.10", "1.2.0-beta.9", true},
+		{"1.2.0-beta.1", "1.2.0", false},
+		{"1.2.0", "1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubChannels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"tag_name": "veto-cli@1.3.0-beta.1", "prerelease": true},
+			{"tag_name": "v1.2.0"},
+			{"tag_name": "v1.4.0", "draft": true},
+			{"tag_name": "sdk@9.0.0"}
+		]`))
+	}))
+	defer srv.Close()
+	gh := GitHubURL
+	GitHubURL = srv.URL
+	defer func() { GitHubURL = gh }()
+
+	now := time.Now()
+	for channel, want := range map[string]string{ChannelStable: "1.2.0", ChannelBeta: "1.3.0-beta.1"} {
+		c := checker(channel, "", &now)
+		c.Method = InstallBinary
+		if v := latest(t, c, false); v != want {
+			t.Errorf("%s: %s, want %s", channel, v, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package update
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Newer reports whether version a is newer than b. Versions are semver
+// strings with an optional "v" prefix; a prerelease ("3.2.0-beta.1") is
+// older than its release ("3.2.0").
+func Newer(a, b string) bool {
+	return compareVersions(a, b) > 0
+}
+
+func compareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+	for i := 0; i < 3; i++ {
+		if aCore[i] != bCore[i] {
+			if aCore[i] > bCore[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePrerelease(aPre, bPre)
+}
+
+// splitVersion parses "v1.2.3-beta.1" into [1 2 3] and "beta.1".
+func splitVersion(v string) ([3]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, _ := strings.Cut(v, "-")
+	var nums [3]int
+	for i, part := range strings.SplitN(core, ".", 3) {
+		nums[i], _ = strconv.Atoi(part)
+	}
+	return nums, pre
+}
+
+// comparePrerelease orders dot-separated prerelease identifiers, comparing
+// numeric identifiers numerically.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an > bn {
+					return 1
+				}
+				return -1
+			}
+		case as[i] != bs[i]:
+			if as[i] > bs[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case len(as) > len(bs):
+		return 1
+	case len(as) < len(bs):
+		return -1
+	}
+	return 0
+}
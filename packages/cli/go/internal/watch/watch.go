@@ -0,0 +1,125 @@
+// Package watch reports edits to a project's veto configuration as they
+// settle, so agents can be re-synced while the files are edited by hand.
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/project"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Debounce is how long edits must stop for before they're reported.
+// Editors often save in several steps (write a temp file, rename it over
+// the original), and a sync per step would be wasted.
+const Debounce = 300 * time.Millisecond
+
+// Config reports whether rel, a slash-separated path relative to the
+// project root, is configuration a sync reads: a .veto (the root's or a
+// nested package's), .veto.lock, an environment overlay, the selected
+// environment, or a custom agent definition. The rest of .veto.d, such
+// as the audit log and the sync state, isn't.
+func Config(rel string) bool {
+	dir, name := filepath.Split(filepath.FromSlash(rel))
+	dir = filepath.Clean(dir)
+	switch {
+	case name == ".veto":
+		return true
+	case dir == ".":
+		return name == ".veto.lock" || strings.HasPrefix(name, ".veto.") && strings.HasSuffix(name, ".yaml")
+	case dir == audit.DirName:
+		return name == "env"
+	case dir == agent.CustomDir:
+		ext := filepath.Ext(name)
+		return ext == ".yaml" || ext == ".yml"
+	}
+	return false
+}
+
+// Watch watches the project at root until ctx is done, calling changed
+// with the configuration files edited (see Config), relative to root,
+// once edits have stopped for Debounce. Calls are never concurrent:
+// edits made during one are reported by the next.
+func Watch(ctx context.Context, root string, changed func(files []string)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	dirs := []string{root, filepath.Join(root, audit.DirName), filepath.Join(root, agent.CustomDir)}
+	nested, _ := project.Nested(root)
+	for _, dir := range append(dirs, nested...) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			if err := w.Add(dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	timer := time.NewTimer(Debounce)
+	timer.Stop()
+	var pending []string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case e, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(root, e.Name)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			files := []string{rel}
+			// .veto.d and its agents directory may only appear later, with
+			// files written before they're watched
+			if e.Has(fsnotify.Create) && (rel == audit.DirName || rel == filepath.ToSlash(agent.CustomDir)) {
+				files = nil
+				for _, dir := range dirs[1:] {
+					files = append(files, add(w, root, dir)...)
+				}
+			}
+			for _, f := range files {
+				if Config(f) && e.Op != fsnotify.Chmod && !slices.Contains(pending, f) {
+					pending = append(pending, f)
+					timer.Reset(Debounce)
+				}
+			}
+		case <-timer.C:
+			files := pending
+			pending = nil
+			slices.Sort(files)
+			changed(files)
+		}
+	}
+}
+
+// add watches dir, if it exists, and returns the files already in it,
+// relative to root.
+func add(w *fsnotify.Watcher, root, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || w.Add(dir) != nil {
+		return nil
+	}
+	rel, _ := filepath.Rel(root, dir)
+	var files []string
+	for _, e := range entries {
+		files = append(files, filepath.ToSlash(filepath.Join(rel, e.Name())))
+	}
+	return files
+}
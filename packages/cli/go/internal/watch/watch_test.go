@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	for rel, want := range map[string]bool{
+		".veto":                   true,
+		"packages/api/.veto":      true,
+		".veto.lock":              true,
+		".veto.prod.yaml":         true,
+		".veto.d/env":             true,
+		".veto.d/agents/zed.yaml": true,
+		".veto.d/audit.log":       false,
+		".veto.d/state.json":      false,
+		".veto-baseline.json":     false,
+		"src/.veto.prod.yaml":     false,
+		"README.md":               false,
+	} {
+		if got := Config(rel); got != want {
+			t.Errorf("Config(%q) = %t, want %t", rel, got, want)
+		}
+	}
+}
+
+func TestWatchDebounces(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".veto", "no force push\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan []string, 10)
+	done := make(chan error)
+	go func() { done <- Watch(ctx, root, func(files []string) { got <- files }) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// A burst of edits is one sync; the audit log is no edit at all
+	write(".veto", "no force push\n")
+	write(".veto.d/audit.log", "{}\n")
+	write(".veto", "no force push\nprotect .env\n")
+	write(".veto.lock", "{}\n")
+	select {
+	case files := <-got:
+		if want := []string{".veto", ".veto.lock"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("changed %q, want %q", files, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sync after editing .veto")
+	}
+	select {
+	case files := <-got:
+		t.Errorf("second sync for %q, want one per burst", files)
+	case <-time.After(2 * Debounce):
+	}
+
+	// .veto.d appeared since the watch started
+	write(".veto.d/agents/zed.yaml", "id: zed\n")
+	select {
+	case files := <-got:
+		if want := []string{".veto.d/agents/zed.yaml"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("changed %q, want %q", files, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no sync after adding a custom agent")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch() = %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/VulnZap/veto/internal/xdg"
+)
+
+// maxRecent caps how many projects Recent remembers.
+const maxRecent = 20
+
+type opened struct {
+	Root string    `json:"root"`
+	At   time.Time `json:"at"`
+}
+
+// recentPath is where the projects opened in the TUI are remembered.
+func recentPath() string {
+	return filepath.Join(xdg.StateDir(), "projects.json")
+}
+
+func loadOpened() []opened {
+	var list []opened
+	if data, err := os.ReadFile(recentPath()); err == nil {
+		json.Unmarshal(data, &list)
+	}
+	return list
+}
+
+// Recent returns the projects opened most recently, latest first,
+// leaving out those that no longer have a .veto file.
+func Recent() []string {
+	var roots []string
+	for _, o := range loadOpened() {
+		if info, err := os.Stat(filepath.Join(o.Root, ".veto")); err == nil && !info.IsDir() {
+			roots = append(roots, o.Root)
+		}
+	}
+	return roots
+}
+
+// Remember records root as the project opened last.
+func Remember(root string) error {
+	list := slices.DeleteFunc(loadOpened(), func(o opened) bool { return o.Root == root })
+	list = append([]opened{{Root: root, At: time.Now()}}, list...)
+	if len(list) > maxRecent {
+		list = list[:maxRecent]
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(recentPath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(recentPath(), append(data, '\n'), 0644)
+}
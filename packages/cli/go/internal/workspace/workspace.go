@@ -0,0 +1,118 @@
+// Package workspace finds the veto projects under a developer's workspace
+// roots and sums up each one (policies, agent sync, recent blocks) for
+// `veto repos`.
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VulnZap/veto/internal/agent"
+	"github.com/VulnZap/veto/internal/audit"
+	"github.com/VulnZap/veto/internal/config"
+	"github.com/VulnZap/veto/internal/lock"
+)
+
+// DefaultRoot is the workspace root used when none is configured,
+// relative to the home directory.
+const DefaultRoot = "code"
+
+// MaxDepth is how many directories below a root projects are looked for.
+const MaxDepth = 4
+
+// skipDirs are never searched: dependencies and build output hold other
+// people's projects, not the user's.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "target": true, "dist": true,
+	"build": true, "venv": true, "__pycache__": true,
+}
+
+// Find returns the directories with a .veto file under roots, sorted, at
+// most depth levels down. A project's subdirectories aren't searched:
+// nested .veto files belong to it.
+func Find(roots []string, depth int) []string {
+	seen := make(map[string]bool)
+	var found []string
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if info, err := os.Stat(filepath.Join(dir, ".veto")); err == nil && !info.IsDir() {
+			found = append(found, dir)
+			return
+		}
+		if level == depth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !e.IsDir() || strings.HasPrefix(name, ".") || skipDirs[name] {
+				continue
+			}
+			walk(filepath.Join(dir, name), level+1)
+		}
+	}
+	for _, root := range roots {
+		if abs, err := filepath.Abs(root); err == nil {
+			walk(abs, 0)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// Repo sums up one project.
+type Repo struct {
+	Root string `json:"root"`
+	// Policies counts the phrases and rules in .veto
+	Policies int `json:"policies"`
+	// Error is why .veto couldn't be read
+	Error string `json:"error,omitempty"`
+	// Agents have veto config installed in the project
+	Agents []string `json:"agents,omitempty"`
+	// SyncedAt is when agent config was last written (zero if never)
+	SyncedAt time.Time `json:"syncedAt,omitempty"`
+	// Stale means the policies changed after the last sync
+	Stale bool `json:"stale,omitempty"`
+	// Blocks counts the requests blocked since the time asked for
+	Blocks    int       `json:"blocks"`
+	LastBlock time.Time `json:"lastBlock,omitempty"`
+}
+
+// Summarize sums up the project at root, counting blocks since the given
+// time.
+func Summarize(root string, since time.Time) Repo {
+	r := Repo{Root: root}
+	if cfg, err := config.Load(filepath.Join(root, ".veto")); err != nil {
+		r.Error = err.Error()
+	} else {
+		r.Policies = len(cfg.Policies) + len(cfg.Rules)
+	}
+
+	r.Agents, r.SyncedAt = agent.ProjectSync(root)
+	if !r.SyncedAt.IsZero() {
+		// The lock pins compiled policies, so changing it changes them too
+		for _, path := range []string{filepath.Join(root, ".veto"), lock.Path(root)} {
+			if info, err := os.Stat(path); err == nil && info.ModTime().After(r.SyncedAt) {
+				r.Stale = true
+			}
+		}
+	}
+
+	if entries, err := audit.Read(root, audit.Filter{Blocked: true, Since: since}); err == nil {
+		r.Blocks = len(entries)
+		if len(entries) > 0 {
+			r.LastBlock = entries[len(entries)-1].Time
+		}
+	}
+	return r
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"app", "org/api", "org/api/packages/web", "app/node_modules/dep", ".cache/old", "deep/a/b/c/d"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, ".veto"), []byte("no force push\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{filepath.Join(root, "app"), filepath.Join(root, "org", "api")}
+	if got := Find([]string{root, root}, MaxDepth); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %q, want %q (no dependencies, hidden or nested projects, nothing too deep)", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("no force push\nprotect .env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := Summarize(root, time.Time{})
+	if r.Policies != 2 || r.Error != "" || len(r.Agents) != 0 || r.Stale {
+		t.Errorf("unsynced project = %+v", r)
+	}
+
+	// A sync older than .veto is stale
+	if err := os.WriteFile(filepath.Join(root, ".goosehints"), []byte("# Project Policies (managed by veto)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(root, ".goosehints"), old, old)
+	r = Summarize(root, time.Time{})
+	if !reflect.DeepEqual(r.Agents, []string{"goose"}) || !r.Stale {
+		t.Errorf("project synced before .veto changed = %+v, want goose and stale", r)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	var roots []string
+	for i := 0; i < 3; i++ {
+		root := t.TempDir()
+		if err := os.WriteFile(filepath.Join(root, ".veto"), []byte("no sudo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	for _, root := range []string{roots[0], roots[1], roots[2], roots[0]} {
+		if err := Remember(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Remove(filepath.Join(roots[1], ".veto"))
+
+	if got, want := Recent(), []string{roots[0], roots[2]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Recent() = %q, want latest first without the project that lost its .veto %q", got, want)
+	}
+}
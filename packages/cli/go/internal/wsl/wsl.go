@@ -0,0 +1,161 @@
+// Package wsl translates paths between Windows and the WSL distro veto
+// runs in. Agents on the Windows side of a WSL setup, like Cursor editing
+// a repo inside the distro, report paths such as
+// \\wsl.localhost\Ubuntu\home\me\repo\main.go or C:\Users\me\repo\main.go;
+// checks need them as /home/me/repo/main.go and /mnt/c/Users/me/repo/main.go
+// to find the project and match its policies.
+package wsl
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMountRoot is where WSL mounts Windows drives unless wsl.conf
+// says otherwise.
+const DefaultMountRoot = "/mnt/"
+
+var (
+	detectOnce sync.Once
+	detected   bool
+	mountRoot  string
+
+	homeOnce    sync.Once
+	windowsHome string
+)
+
+// Detected reports whether veto runs inside WSL.
+func Detected() bool {
+	detectOnce.Do(func() {
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			detected = true
+		} else if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+			detected = strings.Contains(strings.ToLower(string(data)), "microsoft")
+		}
+		mountRoot = readMountRoot("/etc/wsl.conf")
+	})
+	return detected
+}
+
+// Distro returns the name of the WSL distro veto runs in, or "" when it
+// isn't known.
+func Distro() string {
+	return os.Getenv("WSL_DISTRO_NAME")
+}
+
+// Path returns p as a path inside the distro when veto runs in WSL and p
+// is a Windows path. Anything else is returned unchanged.
+func Path(p string) string {
+	if p == "" || !Detected() {
+		return p
+	}
+	if lp, ok := ToLinux(p, Distro(), mountRoot); ok {
+		return lp
+	}
+	return p
+}
+
+// Windows returns a path inside the distro as Windows programs see it when
+// veto runs in WSL. Anything else is returned unchanged.
+func Windows(p string) string {
+	if p == "" || !Detected() || !path.IsAbs(p) {
+		return p
+	}
+	return ToWindows(p, Distro(), mountRoot)
+}
+
+// ToLinux translates a Windows path to its location inside a distro:
+// drive paths to the drive's mount under mount (C:\x to /mnt/c/x), and
+// \\wsl$\<distro>\x or \\wsl.localhost\<distro>\x to /x. UNC paths into
+// another distro aren't translated. ok is false for paths that aren't
+// Windows paths.
+func ToLinux(p, distro, mount string) (string, bool) {
+	s := strings.ReplaceAll(p, "\\", "/")
+	if len(s) >= 2 && s[1] == ':' && isLetter(s[0]) && (len(s) == 2 || s[2] == '/') {
+		drive := strings.ToLower(s[:1])
+		return path.Join(mount, drive, s[2:]), true
+	}
+	for _, host := range []string{"//wsl$/", "//wsl.localhost/"} {
+		if len(s) < len(host) || !strings.EqualFold(s[:len(host)], host) {
+			continue
+		}
+		name, rest, _ := strings.Cut(s[len(host):], "/")
+		if distro != "" && !strings.EqualFold(name, distro) {
+			return p, false
+		}
+		return path.Join("/", rest), true
+	}
+	return p, false
+}
+
+// ToWindows translates a path inside a distro to the path Windows
+// programs use for it: drive mounts back to drive letters (/mnt/c/x to
+// C:\x), everything else through \\wsl.localhost\<distro>.
+func ToWindows(p, distro, mount string) string {
+	mount = strings.TrimSuffix(mount, "/") + "/"
+	if rest, ok := strings.CutPrefix(p, mount); ok && len(rest) >= 1 && isLetter(rest[0]) && (len(rest) == 1 || rest[1] == '/') {
+		return strings.ToUpper(rest[:1]) + ":\\" + strings.ReplaceAll(strings.TrimPrefix(rest[1:], "/"), "/", "\\")
+	}
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(p, "/", "\\")
+}
+
+// WindowsHome returns the Windows user's profile directory as a path
+// inside the distro (/mnt/c/Users/me), or "" outside WSL or when it can't
+// be found.
+func WindowsHome() string {
+	homeOnce.Do(func() {
+		if !Detected() {
+			return
+		}
+		profile := os.Getenv("USERPROFILE") // shared through WSLENV
+		if profile == "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			out, err := exec.CommandContext(ctx, "cmd.exe", "/c", "echo %USERPROFILE%").Output()
+			if err != nil {
+				return
+			}
+			profile = strings.TrimSpace(string(out))
+		}
+		if home, ok := ToLinux(profile, Distro(), mountRoot); ok {
+			windowsHome = home
+		}
+	})
+	return windowsHome
+}
+
+// readMountRoot returns the [automount] root set in a wsl.conf file, or
+// DefaultMountRoot.
+func readMountRoot(conf string) string {
+	f, err := os.Open(conf)
+	if err != nil {
+		return DefaultMountRoot
+	}
+	defer f.Close()
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && section == "automount" && strings.TrimSpace(key) == "root" {
+			if root := strings.Trim(strings.TrimSpace(value), `"`); root != "" {
+				return strings.TrimSuffix(root, "/") + "/"
+			}
+		}
+	}
+	return DefaultMountRoot
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
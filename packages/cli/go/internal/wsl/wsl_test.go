@@ -0,0 +1,57 @@
+package wsl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToLinux(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{`C:\Users\me\repo\main.go`, "/mnt/c/Users/me/repo/main.go", true},
+		{"d:/work", "/mnt/d/work", true},
+		{`C:\`, "/mnt/c", true},
+		{`\\wsl.localhost\Ubuntu\home\me\repo`, "/home/me/repo", true},
+		{`\\wsl$\ubuntu\home\me`, "/home/me", true},
+		{`\\wsl$\Debian\home\me`, `\\wsl$\Debian\home\me`, false},
+		{"/home/me/repo", "/home/me/repo", false},
+		{"src/main.go", "src/main.go", false},
+	}
+	for _, tt := range tests {
+		got, ok := ToLinux(tt.in, "Ubuntu", DefaultMountRoot)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ToLinux(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestToWindows(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/c/Users/me/repo": `C:\Users\me\repo`,
+		"/mnt/d":               `D:\`,
+		"/home/me/repo":        `\\wsl.localhost\Ubuntu\home\me\repo`,
+		"/mnt/wsl/x":           `\\wsl.localhost\Ubuntu\mnt\wsl\x`,
+	}
+	for in, want := range tests {
+		if got := ToWindows(in, "Ubuntu", DefaultMountRoot); got != want {
+			t.Errorf("ToWindows(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReadMountRoot(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "wsl.conf")
+	os.WriteFile(conf, []byte("[boot]\nsystemd=true\n\n[automount]\nroot = /win\n"), 0644)
+	if got := readMountRoot(conf); got != "/win/" {
+		t.Errorf("readMountRoot = %q, want /win/", got)
+	}
+	if got := readMountRoot(filepath.Join(t.TempDir(), "missing")); got != DefaultMountRoot {
+		t.Errorf("readMountRoot(missing) = %q, want %q", got, DefaultMountRoot)
+	}
+	if got, _ := ToLinux(`C:\x`, "", "/win/"); got != "/win/c/x" {
+		t.Errorf("ToLinux with /win/ root = %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+// Package xdg locates veto's per-user directories, following the XDG base
+// directory spec on Linux and the BSDs and the platform conventions
+// elsewhere. When a directory can't be determined (no HOME, as for some
+// service accounts) it falls back to a directory in the temp dir named
+// for the user, never one shared by everyone on the machine.
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDir returns veto's per-user configuration directory, like
+// ~/.config/veto.
+func ConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return fallback("config")
+	}
+	return filepath.Join(dir, "veto")
+}
+
+// CacheDir returns veto's per-user cache directory, like ~/.cache/veto.
+func CacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return fallback("cache")
+	}
+	return filepath.Join(dir, "veto")
+}
+
+// StateDir returns veto's per-user state directory: $XDG_STATE_HOME/veto
+// or ~/.local/state/veto where XDG applies, the config directory
+// elsewhere.
+func StateDir() string {
+	if !usesXDG() {
+		return ConfigDir()
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "veto")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fallback("state")
+	}
+	return filepath.Join(home, ".local", "state", "veto")
+}
+
+// RuntimeDir returns veto's per-user directory for sockets:
+// $XDG_RUNTIME_DIR/veto when the session has one, the cache directory
+// otherwise.
+func RuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "veto")
+	}
+	return CacheDir()
+}
+
+func usesXDG() bool {
+	switch runtime.GOOS {
+	case "darwin", "ios", "windows", "plan9":
+		return false
+	}
+	return true
+}
+
+// fallback returns a per-user directory under the temp dir.
+func fallback(kind string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("veto-%d", os.Getuid()), kind)
+}
@@ -19,10 +19,15 @@ const binaryMap = {
   'darwin-x64': 'veto-darwin-amd64',
   'linux-x64': 'veto-linux-amd64',
   'linux-arm64': 'veto-linux-arm64',
+  'linux-x64-musl': 'veto-linux-amd64-musl',
+  'linux-arm64-musl': 'veto-linux-arm64-musl',
   'win32-x64': 'veto-windows-amd64.exe',
 };
 
-const key = `${platform}-${arch}`;
+// Alpine and other musl systems have no glibc version in the process report
+const musl = platform === 'linux' && !process.report?.getReport()?.header?.glibcVersionRuntime;
+
+const key = `${platform}-${arch}${musl ? '-musl' : ''}`;
 const binaryName = binaryMap[key];
 
 if (!binaryName) {
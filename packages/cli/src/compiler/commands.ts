@@ -1,10 +1,19 @@
 // src/compiler/commands.ts
 // Command parsing and matching for command-level policy enforcement
 
-import micromatch from 'micromatch';
 import type { CommandRule, CommandCheckResult, Policy } from '../types.js';
 
-const { isMatch } = micromatch;
+/**
+ * Match a command against a glob. Unlike file globs, "*" also spans "/",
+ * so patterns like "curl * | bash*" match commands containing URLs.
+ */
+function commandGlob(command: string, pattern: string): boolean {
+  const source = pattern
+    .split('')
+    .map((c) => (c === '*' ? '[\\s\\S]*' : c === '?' ? '[\\s\\S]' : c.replace(/[.+^${}()|[\]\\]/g, '\\$&')))
+    .join('');
+  return new RegExp(`^${source}$`, 'i').test(command);
+}
 
 /**
  * Common command aliases - normalized for matching
@@ -54,13 +63,22 @@ function expandAliases(command: string): string[] {
 
 /**
  * Split a complex command string into individual commands.
- * Handles: &&, ||, ;, |, and subshells
+ * Handles: &&, ||, ;, |, and subshells. Whole pipelines ("curl x | bash")
+ * are included too, so patterns can match across a pipe.
  */
 export function splitCommands(fullCommand: string): string[] {
   const commands: string[] = [];
   let current = '';
+  let pipeline = '';
+  let piped = false;
   let depth = 0;
   let inQuote: string | null = null;
+
+  const endPipeline = () => {
+    if (piped && pipeline.trim()) commands.push(pipeline.trim());
+    pipeline = '';
+    piped = false;
+  };
   
   for (let i = 0; i < fullCommand.length; i++) {
     const char = fullCommand[i];
@@ -74,6 +92,7 @@ export function splitCommands(fullCommand: string): string[] {
         inQuote = char;
       }
       current += char;
+      pipeline += char;
       continue;
     }
     
@@ -88,38 +107,45 @@ export function splitCommands(fullCommand: string): string[] {
     
     // Split on command separators (only at depth 0, outside quotes)
     if (depth === 0 && !inQuote) {
-      // Check for && || ; |
+      // Check for && || ; | and newlines
       if (char === '&' && fullCommand[i + 1] === '&') {
         if (current.trim()) commands.push(current.trim());
         current = '';
+        endPipeline();
         i++; // Skip next &
         continue;
       }
       if (char === '|' && fullCommand[i + 1] === '|') {
         if (current.trim()) commands.push(current.trim());
         current = '';
+        endPipeline();
         i++; // Skip next |
         continue;
       }
-      if (char === ';') {
+      if (char === ';' || char === '\n') {
         if (current.trim()) commands.push(current.trim());
         current = '';
+        endPipeline();
         continue;
       }
       // Single pipe - still add the command before pipe
       if (char === '|' && fullCommand[i + 1] !== '|') {
         if (current.trim()) commands.push(current.trim());
         current = '';
+        piped = true;
+        pipeline += char;
         continue;
       }
     }
     
     current += char;
+    pipeline += char;
   }
   
   if (current.trim()) {
     commands.push(current.trim());
   }
+  endPipeline();
   
   // Extract commands from subshells: bash -c "command", sh -c 'command'
   const expanded: string[] = [];
@@ -180,7 +206,7 @@ export function commandMatches(command: string, pattern: string): boolean {
   
   // If pattern starts with *, it's a contains match
   if (normalizedPattern.startsWith('*')) {
-    return isMatch(normalizedCmd, normalizedPattern, { nocase: true });
+    return commandGlob(normalizedCmd, normalizedPattern);
   }
   
   // Otherwise, pattern should match from the beginning
@@ -208,9 +234,9 @@ export function commandMatches(command: string, pattern: string): boolean {
     return true;
   }
   
-  // For more complex patterns, use micromatch on the remaining part
+  // For more complex patterns, glob the remaining part
   const remainingCmd = normalizedCmd.slice(prefix.length);
-  return isMatch(remainingCmd, suffix, { nocase: true });
+  return commandGlob(remainingCmd, suffix);
 }
 
 /**
@@ -0,0 +1,59 @@
+// test/conformance.test.ts
+//
+// Cross-engine conformance suite. The same fixtures are run by the Go
+// matcher (go/internal/matcher/conformance_test.go); both must pass.
+
+import { describe, it, expect } from 'vitest';
+import { readFileSync } from 'fs';
+import { dirname, join } from 'path';
+import { fileURLToPath } from 'url';
+import { isProtected, checkCommand } from '../src/matcher.js';
+import { checkContent } from '../src/compiler/content.js';
+import type { Policy } from '../src/types.js';
+
+interface ConformanceRequest {
+  action?: string;
+  target?: string;
+  command?: string;
+  content?: string;
+}
+
+interface ConformanceSuite {
+  policies: Record<string, Policy>;
+  cases: Array<{
+    name: string;
+    policy: string;
+    request: ConformanceRequest;
+    expected: 'allow' | 'deny';
+  }>;
+}
+
+const fixtures = join(dirname(fileURLToPath(import.meta.url)), 'fixtures', 'conformance.json');
+const suite: ConformanceSuite = JSON.parse(readFileSync(fixtures, 'utf-8'));
+
+/**
+ * Evaluate a request the way the Go matcher's Check does:
+ * command rules, then file globs, then content rules.
+ */
+function evaluate(policy: Policy, req: ConformanceRequest): 'allow' | 'deny' {
+  if (req.command && checkCommand(req.command, policy).blocked) {
+    return 'deny';
+  }
+  if (req.target && isProtected(req.target, policy)) {
+    return 'deny';
+  }
+  if (req.target && req.content && checkContent(req.content, req.target, policy).blocked) {
+    return 'deny';
+  }
+  return 'allow';
+}
+
+describe('conformance', () => {
+  for (const tc of suite.cases) {
+    it(tc.name, () => {
+      const policy = suite.policies[tc.policy];
+      expect(policy, `unknown policy ${tc.policy}`).toBeDefined();
+      expect(evaluate(policy, tc.request)).toBe(tc.expected);
+    });
+  }
+});